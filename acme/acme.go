@@ -0,0 +1,232 @@
+// Package acme implements an ACME (RFC 8555) server issuing certificates
+// through the certs service.
+//
+// Clients create accounts bound to their keys, order certificates for DNS
+// identifiers, prove control over the identifiers with http-01 or dns-01
+// challenges and finalize the orders with a CSR. Every request is a JWS
+// signed with the account key and protected by an anti-replay nonce.
+package acme
+
+import (
+	"context"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+// Paths of the ACME resources below the base URL.
+const (
+	DirectoryPath     = "/acme/directory"
+	NewNoncePath      = "/acme/new-nonce"
+	NewAccountPath    = "/acme/new-account"
+	AccountPath       = "/acme/account/%s"
+	NewOrderPath      = "/acme/new-order"
+	OrderPath         = "/acme/order/%s"
+	FinalizePath      = "/acme/order/%s/finalize"
+	AuthorizationPath = "/acme/authz/%s"
+	ChallengePath     = "/acme/chall/%s/%s"
+	CertificatePath   = "/acme/cert/%s"
+	RevokeCertPath    = "/acme/revoke-cert"
+)
+
+// Status is the status of an account, order, authorization or challenge.
+type Status string
+
+const (
+	StatusPending     Status = "pending"
+	StatusReady       Status = "ready"
+	StatusProcessing  Status = "processing"
+	StatusValid       Status = "valid"
+	StatusInvalid     Status = "invalid"
+	StatusDeactivated Status = "deactivated"
+	StatusExpired     Status = "expired"
+)
+
+// IdentifierDNS is the type of DNS name identifiers, the only type supported.
+const IdentifierDNS = "dns"
+
+// Challenge types.
+const (
+	ChallengeHTTP01 = "http-01"
+	ChallengeDNS01  = "dns-01"
+)
+
+var (
+	ErrMalformed             = errors.New("malformed request")
+	ErrNotFound              = errors.New("resource not found")
+	ErrBadNonce              = errors.New("invalid anti-replay nonce")
+	ErrBadSignatureAlgorithm = errors.New("unsupported signature algorithm")
+	ErrBadPublicKey          = errors.New("unsupported public key")
+	ErrUnauthorized          = errors.New("unauthorized")
+	ErrAccountDoesNotExist   = errors.New("account does not exist")
+	ErrInvalidContact        = errors.New("invalid contact")
+	ErrUnsupportedIdentifier = errors.New("unsupported identifier")
+	ErrRejectedIdentifier    = errors.New("rejected identifier")
+	ErrOrderNotReady         = errors.New("order is not ready")
+	ErrBadCSR                = errors.New("invalid CSR")
+	ErrBadRevocationReason   = errors.New("invalid revocation reason")
+	ErrAlreadyRevoked        = errors.New("certificate is already revoked")
+	ErrIncorrectResponse     = errors.New("incorrect challenge response")
+	ErrConnection            = errors.New("failed to connect to the identifier")
+	ErrDNS                   = errors.New("failed to look up the identifier")
+)
+
+// Config holds the ACME server settings.
+type Config struct {
+	// Enabled serves the ACME API next to the certs API.
+	Enabled bool `env:"ENABLED" envDefault:"false"`
+	// BaseURL is the public URL the ACME resources are published below,
+	// e.g. https://certs.example.com. It defaults to the URL of the requests.
+	BaseURL string `env:"BASE_URL" envDefault:""`
+	// Issuer and Profile are the issuer and profile certificates are issued with.
+	Issuer  string `env:"ISSUER"  envDefault:""`
+	Profile string `env:"PROFILE" envDefault:"server"`
+	// OrderValidity is how long orders and their authorizations can be completed.
+	OrderValidity time.Duration `env:"ORDER_VALIDITY" envDefault:"24h"`
+	// HTTP01Port is the port http-01 challenges are validated on.
+	HTTP01Port int `env:"HTTP01_PORT" envDefault:"80"`
+	// DNSResolver is the address of the DNS server dns-01 challenges are
+	// validated with. It defaults to the system resolver.
+	DNSResolver string `env:"DNS_RESOLVER" envDefault:""`
+}
+
+// Identifier is an identifier a certificate is ordered for.
+type Identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Problem is an RFC 7807 problem document with an ACME error type.
+type Problem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+	Status int    `json:"status,omitempty"`
+}
+
+// Account is an ACME account bound to the key of its client.
+type Account struct {
+	ID      string   `json:"id"`
+	Status  Status   `json:"status"`
+	Contact []string `json:"contact,omitempty"`
+	Key     JWK      `json:"key"`
+	// Thumbprint is the RFC 7638 thumbprint of the account key.
+	Thumbprint string    `json:"thumbprint"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Order is a request of an account for a certificate.
+type Order struct {
+	ID               string       `json:"id"`
+	AccountID        string       `json:"account_id"`
+	Status           Status       `json:"status"`
+	Expires          time.Time    `json:"expires"`
+	Identifiers      []Identifier `json:"identifiers"`
+	NotAfter         time.Time    `json:"not_after,omitempty"`
+	AuthorizationIDs []string     `json:"authorization_ids"`
+	// CertSerial is the serial number of the certificate issued for a valid order.
+	CertSerial string   `json:"cert_serial,omitempty"`
+	Error      *Problem `json:"error,omitempty"`
+}
+
+// Authorization is the authorization of an account for an identifier.
+type Authorization struct {
+	ID         string      `json:"id"`
+	AccountID  string      `json:"account_id"`
+	OrderID    string      `json:"order_id"`
+	Identifier Identifier  `json:"identifier"`
+	Status     Status      `json:"status"`
+	Expires    time.Time   `json:"expires"`
+	Wildcard   bool        `json:"wildcard,omitempty"`
+	Challenges []Challenge `json:"challenges"`
+}
+
+// Challenge is a way to prove control over the identifier of an authorization.
+type Challenge struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Status    Status    `json:"status"`
+	Token     string    `json:"token"`
+	Validated time.Time `json:"validated,omitempty"`
+	Error     *Problem  `json:"error,omitempty"`
+}
+
+// Message is a verified JWS request.
+type Message struct {
+	// Payload is empty for POST-as-GET requests.
+	Payload []byte
+	// Account is the account that signed the request with its key ID. It is
+	// unset for requests signed with an embedded key.
+	Account Account
+	// Key is the key the request is signed with.
+	Key JWK
+}
+
+// Service specifies the ACME server API.
+type Service interface {
+	// Nonce issues a fresh anti-replay nonce.
+	Nonce(ctx context.Context) (string, error)
+
+	// Verify verifies the JWS request sent to the path below the base URL and consumes its nonce.
+	Verify(ctx context.Context, baseURL, path string, jws []byte) (Message, error)
+
+	// NewAccount creates the account of the key or returns the existing one,
+	// reporting whether the account was created.
+	NewAccount(ctx context.Context, key JWK, contact []string, onlyReturnExisting bool) (Account, bool, error)
+
+	// UpdateAccount updates the contacts of the account or deactivates it.
+	UpdateAccount(ctx context.Context, account Account, contact []string, deactivate bool) (Account, error)
+
+	// NewOrder creates an order of the account together with the authorizations of its identifiers.
+	NewOrder(ctx context.Context, accountID string, identifiers []Identifier, notBefore, notAfter time.Time) (Order, error)
+
+	// ViewOrder retrieves the order of the account.
+	ViewOrder(ctx context.Context, accountID, id string) (Order, error)
+
+	// ViewAuthorization retrieves the authorization of the account.
+	ViewAuthorization(ctx context.Context, accountID, id string) (Authorization, error)
+
+	// RespondChallenge starts the validation of the challenge of the authorization.
+	RespondChallenge(ctx context.Context, account Account, authzID, id string) (Challenge, error)
+
+	// FinalizeOrder issues the certificate of a ready order from the DER encoded CSR.
+	FinalizeOrder(ctx context.Context, accountID, id string, csr []byte) (Order, error)
+
+	// Certificate retrieves the PEM encoded certificate chain of a valid order.
+	Certificate(ctx context.Context, accountID, orderID string) ([]byte, error)
+
+	// RevokeCert revokes the DER encoded certificate issued to the account or
+	// to the key the request is signed with.
+	RevokeCert(ctx context.Context, msg Message, cert []byte, reason certs.RevocationReason) error
+}
+
+// Repository specifies the ACME persistence API.
+type Repository interface {
+	// CreateAccount stores an account.
+	CreateAccount(ctx context.Context, account Account) error
+
+	// RetrieveAccount retrieves the account with the given ID.
+	RetrieveAccount(ctx context.Context, id string) (Account, error)
+
+	// RetrieveAccountByThumbprint retrieves the account of the key with the given thumbprint.
+	RetrieveAccountByThumbprint(ctx context.Context, thumbprint string) (Account, error)
+
+	// UpdateAccount updates an account.
+	UpdateAccount(ctx context.Context, account Account) error
+
+	// CreateOrder stores an order together with its authorizations.
+	CreateOrder(ctx context.Context, order Order, authzs []Authorization) error
+
+	// RetrieveOrder retrieves the order with the given ID.
+	RetrieveOrder(ctx context.Context, id string) (Order, error)
+
+	// UpdateOrder updates an order if it still has the given status, and
+	// returns ErrOrderNotReady otherwise.
+	UpdateOrder(ctx context.Context, order Order, status Status) error
+
+	// RetrieveAuthorization retrieves the authorization with the given ID.
+	RetrieveAuthorization(ctx context.Context, id string) (Authorization, error)
+
+	// UpdateAuthorization updates an authorization.
+	UpdateAuthorization(ctx context.Context, authz Authorization) error
+}
@@ -0,0 +1,562 @@
+package acme_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/acme"
+	amocks "github.com/hantdev/certs/acme/mocks"
+	acmeapi "github.com/hantdev/certs/api/acme"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/certstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver serves the TXT records dns-01 challenges are provisioned in.
+type fakeResolver struct {
+	mu      sync.Mutex
+	records map[string][]string
+}
+
+func (r *fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, ok := r.records[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func (r *fakeResolver) set(name, record string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[name] = []string{record}
+}
+
+// challengeServer serves the key authorizations of http-01 challenges.
+type challengeServer struct {
+	mu       sync.Mutex
+	keyAuths map[string]string
+}
+
+func (s *challengeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keyAuth, ok := s.keyAuths[strings.TrimPrefix(r.URL.Path, "/.well-known/acme-challenge/")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = io.WriteString(w, keyAuth)
+}
+
+func (s *challengeServer) set(token, keyAuth string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keyAuths[token] = keyAuth
+}
+
+// newRepository returns the ACME repository backed by maps.
+func newRepository() *amocks.MockRepository {
+	var mu sync.Mutex
+	accounts := map[string]acme.Account{}
+	orders := map[string]acme.Order{}
+	authzs := map[string]acme.Authorization{}
+
+	repo := new(amocks.MockRepository)
+	repo.On("CreateAccount", mock.Anything, mock.Anything).Return(func(_ context.Context, account acme.Account) error {
+		mu.Lock()
+		defer mu.Unlock()
+		accounts[account.ID] = account
+		return nil
+	})
+	repo.On("RetrieveAccount", mock.Anything, mock.Anything).Return(func(_ context.Context, id string) (acme.Account, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		account, ok := accounts[id]
+		if !ok {
+			return acme.Account{}, acme.ErrNotFound
+		}
+		return account, nil
+	})
+	repo.On("RetrieveAccountByThumbprint", mock.Anything, mock.Anything).Return(func(_ context.Context, thumbprint string) (acme.Account, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, account := range accounts {
+			if account.Thumbprint == thumbprint {
+				return account, nil
+			}
+		}
+		return acme.Account{}, acme.ErrNotFound
+	})
+	repo.On("UpdateAccount", mock.Anything, mock.Anything).Return(func(_ context.Context, account acme.Account) error {
+		mu.Lock()
+		defer mu.Unlock()
+		accounts[account.ID] = account
+		return nil
+	})
+	repo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, order acme.Order, as []acme.Authorization) error {
+		mu.Lock()
+		defer mu.Unlock()
+		orders[order.ID] = order
+		for _, authz := range as {
+			authzs[authz.ID] = authz
+		}
+		return nil
+	})
+	repo.On("RetrieveOrder", mock.Anything, mock.Anything).Return(func(_ context.Context, id string) (acme.Order, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		order, ok := orders[id]
+		if !ok {
+			return acme.Order{}, acme.ErrNotFound
+		}
+		return order, nil
+	})
+	repo.On("UpdateOrder", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, order acme.Order, status acme.Status) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if orders[order.ID].Status != status {
+			return acme.ErrOrderNotReady
+		}
+		orders[order.ID] = order
+		return nil
+	})
+	repo.On("RetrieveAuthorization", mock.Anything, mock.Anything).Return(func(_ context.Context, id string) (acme.Authorization, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		authz, ok := authzs[id]
+		if !ok {
+			return acme.Authorization{}, acme.ErrNotFound
+		}
+		return authz, nil
+	})
+	repo.On("UpdateAuthorization", mock.Anything, mock.Anything).Return(func(_ context.Context, authz acme.Authorization) error {
+		mu.Lock()
+		defer mu.Unlock()
+		authzs[authz.ID] = authz
+		return nil
+	})
+
+	return repo
+}
+
+// client is a minimal ACME client signing its requests with an ECDSA key.
+type client struct {
+	t     *testing.T
+	base  string
+	key   *ecdsa.PrivateKey
+	kid   string
+	nonce string
+}
+
+func newClient(t *testing.T, base string, key *ecdsa.PrivateKey) *client {
+	if key == nil {
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+	}
+	return &client{t: t, base: base, key: key}
+}
+
+func (c *client) jwk() acme.JWK {
+	jwk, err := acme.NewJWK(&c.key.PublicKey)
+	require.NoError(c.t, err)
+	return jwk
+}
+
+func (c *client) thumbprint() string {
+	thumbprint, err := c.jwk().Thumbprint()
+	require.NoError(c.t, err)
+	return thumbprint
+}
+
+// post sends the payload signed with the client key to the URL. A nil
+// payload sends a POST-as-GET request.
+func (c *client) post(url string, payload any) (*http.Response, []byte) {
+	if c.nonce == "" {
+		res, err := http.Head(c.base + acme.NewNoncePath)
+		require.NoError(c.t, err)
+		res.Body.Close()
+		c.nonce = res.Header.Get("Replay-Nonce")
+	}
+
+	header := map[string]any{"alg": "ES256", "nonce": c.nonce, "url": url}
+	if c.kid != "" {
+		header["kid"] = c.kid
+	} else {
+		header["jwk"] = c.jwk()
+	}
+	protected, err := json.Marshal(header)
+	require.NoError(c.t, err)
+	var body []byte
+	if payload != nil {
+		body, err = json.Marshal(payload)
+		require.NoError(c.t, err)
+	}
+	input := encode(protected) + "." + encode(body)
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, c.key, digest[:])
+	require.NoError(c.t, err)
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	data, err := json.Marshal(map[string]string{
+		"protected": encode(protected),
+		"payload":   encode(body),
+		"signature": encode(signature),
+	})
+	require.NoError(c.t, err)
+	res, err := http.Post(url, acmeapi.JOSEType, strings.NewReader(string(data)))
+	require.NoError(c.t, err)
+	defer res.Body.Close()
+	c.nonce = res.Header.Get("Replay-Nonce")
+	resBody, err := io.ReadAll(res.Body)
+	require.NoError(c.t, err)
+
+	return res, resBody
+}
+
+// postJSON sends the request and decodes the JSON response into v.
+func (c *client) postJSON(url string, payload, v any) *http.Response {
+	res, body := c.post(url, payload)
+	require.NoError(c.t, json.Unmarshal(body, v), string(body))
+	return res
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+type order struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+}
+
+type authorization struct {
+	Identifier acme.Identifier `json:"identifier"`
+	Status     string          `json:"status"`
+	Wildcard   bool            `json:"wildcard"`
+	Challenges []struct {
+		Type   string        `json:"type"`
+		URL    string        `json:"url"`
+		Status string        `json:"status"`
+		Token  string        `json:"token"`
+		Error  *acme.Problem `json:"error"`
+	} `json:"challenges"`
+}
+
+func newServer(t *testing.T) (*httptest.Server, *challengeServer, *fakeResolver) {
+	chals := &challengeServer{keyAuths: map[string]string{}}
+	chalSrv := httptest.NewServer(chals)
+	t.Cleanup(chalSrv.Close)
+	// Every identifier resolves to the local challenge server.
+	httpClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, chalSrv.Listener.Addr().String())
+		},
+	}}
+	resolver := &fakeResolver{records: map[string][]string{}}
+	validators := map[string]acme.Validator{
+		acme.ChallengeHTTP01: acme.NewHTTP01Validator(httpClient, 80),
+		acme.ChallengeDNS01:  acme.NewDNS01Validator(resolver),
+	}
+
+	svc := acme.NewService(newRepository(), certstest.NewService(t), validators, acme.Config{Profile: "server", OrderValidity: time.Hour})
+	srv := httptest.NewServer(acmeapi.MakeHandler(svc, slog.New(slog.NewTextHandler(io.Discard, nil)), ""))
+	t.Cleanup(srv.Close)
+
+	return srv, chals, resolver
+}
+
+func TestACME(t *testing.T) {
+	srv, chals, resolver := newServer(t)
+
+	res, err := http.Get(srv.URL + acme.DirectoryPath)
+	require.NoError(t, err)
+	var dir map[string]string
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&dir))
+	res.Body.Close()
+	assert.Equal(t, srv.URL+acme.NewNoncePath, dir["newNonce"])
+	assert.Equal(t, srv.URL+acme.NewAccountPath, dir["newAccount"])
+	assert.NotEmpty(t, res.Header.Get("Replay-Nonce"))
+
+	c := newClient(t, srv.URL, nil)
+	var account map[string]any
+	res = c.postJSON(dir["newAccount"], map[string]any{"contact": []string{"mailto:admin@example.com"}, "termsOfServiceAgreed": true}, &account)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "valid", account["status"])
+	c.kid = res.Header.Get("Location")
+	require.NotEmpty(t, c.kid)
+
+	// Registering the same key again returns the existing account.
+	existing := newClient(t, srv.URL, c.key)
+	res = existing.postJSON(dir["newAccount"], map[string]any{"onlyReturnExisting": true}, &account)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, c.kid, res.Header.Get("Location"))
+
+	var o order
+	res = c.postJSON(dir["newOrder"], map[string]any{"identifiers": []acme.Identifier{
+		{Type: acme.IdentifierDNS, Value: "www.example.com"},
+		{Type: acme.IdentifierDNS, Value: "*.example.org"},
+	}}, &o)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	orderURL := res.Header.Get("Location")
+	assert.Equal(t, "pending", o.Status)
+	require.Len(t, o.Authorizations, 2)
+
+	keyAuth := func(token string) string {
+		return acme.KeyAuthorization(token, c.thumbprint())
+	}
+	for _, url := range o.Authorizations {
+		var authz authorization
+		c.postJSON(url, nil, &authz)
+		assert.Equal(t, "pending", authz.Status)
+		var chalURL string
+		for _, chal := range authz.Challenges {
+			switch {
+			case authz.Wildcard:
+				// Wildcards can only be validated with dns-01.
+				require.Equal(t, acme.ChallengeDNS01, chal.Type)
+				assert.Equal(t, "example.org", authz.Identifier.Value)
+				digest := sha256.Sum256([]byte(keyAuth(chal.Token)))
+				resolver.set("_acme-challenge."+authz.Identifier.Value, encode(digest[:]))
+				chalURL = chal.URL
+			case chal.Type == acme.ChallengeHTTP01:
+				chals.set(chal.Token, keyAuth(chal.Token))
+				chalURL = chal.URL
+			}
+		}
+		require.NotEmpty(t, chalURL)
+		res = c.postJSON(chalURL, struct{}{}, &map[string]any{})
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, res.Header.Values("Link"), fmt.Sprintf("<%s>;rel=%q", url, "up"))
+	}
+
+	assert.Eventually(t, func() bool {
+		c.postJSON(orderURL, nil, &o)
+		return o.Status == "ready"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{"www.example.com", "*.example.org"}}, certKey)
+	require.NoError(t, err)
+
+	// A CSR for identifiers other than the ordered ones is rejected.
+	bad, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{"www.example.com", "mail.example.com"}}, certKey)
+	require.NoError(t, err)
+	var problem acme.Problem
+	res = c.postJSON(o.Finalize, map[string]string{"csr": encode(bad)}, &problem)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "urn:ietf:params:acme:error:badCSR", problem.Type)
+	assert.Equal(t, "application/problem+json", res.Header.Get("Content-Type"))
+
+	c.postJSON(o.Finalize, map[string]string{"csr": encode(csr)}, &o)
+	assert.Equal(t, "valid", o.Status)
+	require.NotEmpty(t, o.Certificate)
+
+	res, chain := c.post(o.Certificate, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, acmeapi.ChainType, res.Header.Get("Content-Type"))
+	block, rest := pem.Decode(chain)
+	require.NotNil(t, block)
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"www.example.com", "*.example.org"}, leaf.DNSNames)
+	block, _ = pem.Decode(rest)
+	require.NotNil(t, block)
+	issuer, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	require.NoError(t, leaf.CheckSignatureFrom(issuer))
+
+	// Other accounts can neither download nor revoke the certificate.
+	other := newClient(t, srv.URL, nil)
+	res = other.postJSON(dir["newAccount"], map[string]any{}, &account)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	other.kid = res.Header.Get("Location")
+	res, _ = other.post(o.Certificate, nil)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res, _ = other.post(dir["revokeCert"], map[string]any{"certificate": encode(leaf.Raw)})
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	// The certificate key revokes the certificate without an account.
+	holder := newClient(t, srv.URL, certKey)
+	res, _ = holder.post(dir["revokeCert"], map[string]any{"certificate": encode(leaf.Raw), "reason": int(certs.RevocationKeyCompromise)})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	res = c.postJSON(dir["revokeCert"], map[string]any{"certificate": encode(leaf.Raw)}, &problem)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "urn:ietf:params:acme:error:alreadyRevoked", problem.Type)
+}
+
+func TestACMEFailedChallenge(t *testing.T) {
+	srv, chals, _ := newServer(t)
+
+	c := newClient(t, srv.URL, nil)
+	res := c.postJSON(srv.URL+acme.NewAccountPath, map[string]any{}, &map[string]any{})
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	c.kid = res.Header.Get("Location")
+
+	var o order
+	res = c.postJSON(srv.URL+acme.NewOrderPath, map[string]any{"identifiers": []acme.Identifier{{Type: acme.IdentifierDNS, Value: "www.example.com"}}}, &o)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	orderURL := res.Header.Get("Location")
+
+	var authz authorization
+	c.postJSON(o.Authorizations[0], nil, &authz)
+	for _, chal := range authz.Challenges {
+		if chal.Type == acme.ChallengeHTTP01 {
+			chals.set(chal.Token, "wrong")
+			c.postJSON(chal.URL, struct{}{}, &map[string]any{})
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		c.postJSON(o.Authorizations[0], nil, &authz)
+		return authz.Status == "invalid"
+	}, 5*time.Second, 20*time.Millisecond)
+	for _, chal := range authz.Challenges {
+		if chal.Type == acme.ChallengeHTTP01 {
+			require.NotNil(t, chal.Error)
+			assert.Equal(t, "urn:ietf:params:acme:error:incorrectResponse", chal.Error.Type)
+		}
+	}
+	c.postJSON(orderURL, nil, &o)
+	assert.Equal(t, "invalid", o.Status)
+}
+
+func TestACMEConcurrentFinalize(t *testing.T) {
+	repo := newRepository()
+	svc := acme.NewService(repo, certstest.NewService(t), nil, acme.Config{Profile: "server", OrderValidity: time.Hour})
+	order := acme.Order{
+		ID:          "order",
+		AccountID:   "account",
+		Status:      acme.StatusReady,
+		Expires:     time.Now().Add(time.Hour),
+		Identifiers: []acme.Identifier{{Type: acme.IdentifierDNS, Value: "www.example.com"}},
+	}
+	require.NoError(t, repo.CreateOrder(context.Background(), order, nil))
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{"www.example.com"}}, key)
+	require.NoError(t, err)
+
+	// Concurrent finalizations of the same order issue a single certificate.
+	const finalizations = 5
+	results := make(chan error, finalizations)
+	var wg sync.WaitGroup
+	for range finalizations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.FinalizeOrder(context.Background(), order.AccountID, order.ID, csr)
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+	var issued int
+	for err := range results {
+		if err == nil {
+			issued++
+			continue
+		}
+		assert.True(t, errors.Contains(err, acme.ErrOrderNotReady), "expected error %v, got %v", acme.ErrOrderNotReady, err)
+	}
+	assert.Equal(t, 1, issued)
+	order, err = svc.ViewOrder(context.Background(), order.AccountID, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, acme.StatusValid, order.Status)
+	assert.NotEmpty(t, order.CertSerial)
+}
+
+func TestACMERequestValidation(t *testing.T) {
+	srv, _, _ := newServer(t)
+
+	cases := []struct {
+		desc    string
+		prepare func(c *client) string
+		problem string
+		status  int
+	}{
+		{
+			desc: "reused nonce",
+			prepare: func(c *client) string {
+				nonce := c.nonce
+				c.post(srv.URL+acme.NewAccountPath, map[string]any{})
+				c.nonce = nonce
+				return srv.URL + acme.NewAccountPath
+			},
+			problem: "badNonce",
+			status:  http.StatusBadRequest,
+		},
+		{
+			desc: "unknown nonce",
+			prepare: func(c *client) string {
+				c.nonce = "bogus"
+				return srv.URL + acme.NewAccountPath
+			},
+			problem: "badNonce",
+			status:  http.StatusBadRequest,
+		},
+		{
+			desc: "url mismatch",
+			prepare: func(c *client) string {
+				return srv.URL + acme.NewAccountPath + "?x"
+			},
+			problem: "unauthorized",
+			status:  http.StatusForbidden,
+		},
+		{
+			desc: "unknown account",
+			prepare: func(c *client) string {
+				c.kid = srv.URL + fmt.Sprintf(acme.AccountPath, "unknown")
+				return srv.URL + acme.NewOrderPath
+			},
+			problem: "accountDoesNotExist",
+			status:  http.StatusBadRequest,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newClient(t, srv.URL, nil)
+			res, err := http.Head(srv.URL + acme.NewNoncePath)
+			require.NoError(t, err)
+			res.Body.Close()
+			c.nonce = res.Header.Get("Replay-Nonce")
+
+			url := tc.prepare(c)
+			var problem acme.Problem
+			res = c.postJSON(url, map[string]any{}, &problem)
+			assert.Equal(t, tc.status, res.StatusCode)
+			assert.Equal(t, "urn:ietf:params:acme:error:"+tc.problem, problem.Type)
+		})
+	}
+}
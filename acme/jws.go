@@ -0,0 +1,254 @@
+package acme
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// minRSAKeySize is the minimum size of RSA account keys.
+const minRSAKeySize = 2048
+
+var errInvalidSignature = errors.New("invalid JWS signature")
+
+// ecCurve is an elliptic curve of ECDSA account keys with the JWS algorithm
+// signing with it.
+type ecCurve struct {
+	curve elliptic.Curve
+	ecdh  ecdh.Curve
+	alg   string
+	hash  crypto.Hash
+}
+
+var ecCurves = map[string]ecCurve{
+	"P-256": {elliptic.P256(), ecdh.P256(), "ES256", crypto.SHA256},
+	"P-384": {elliptic.P384(), ecdh.P384(), "ES384", crypto.SHA384},
+	"P-521": {elliptic.P521(), ecdh.P521(), "ES512", crypto.SHA512},
+}
+
+// JWK is a JSON Web Key (RFC 7517) holding an RSA, ECDSA or Ed25519 public key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// jws is a JWS in the flattened JSON serialization.
+type jws struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// jwsHeader is the protected header of an ACME request.
+type jwsHeader struct {
+	Alg   string `json:"alg"`
+	Nonce string `json:"nonce"`
+	URL   string `json:"url"`
+	JWK   *JWK   `json:"jwk"`
+	KID   string `json:"kid"`
+}
+
+// NewJWK returns the JWK of an RSA, ECDSA or Ed25519 public key.
+func NewJWK(pub crypto.PublicKey) (JWK, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   encode(key.N.Bytes()),
+			E:   encode(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		for name, c := range ecCurves {
+			if c.curve != key.Curve {
+				continue
+			}
+			size := (c.curve.Params().BitSize + 7) / 8
+			return JWK{
+				Kty: "EC",
+				Crv: name,
+				X:   encode(key.X.FillBytes(make([]byte, size))),
+				Y:   encode(key.Y.FillBytes(make([]byte, size))),
+			}, nil
+		}
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: encode(key)}, nil
+	}
+
+	return JWK{}, ErrBadPublicKey
+}
+
+// PublicKey returns the public key of the JWK.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() < minRSAKeySize || !e.IsInt64() || e.Int64() < 3 || e.Bit(0) == 0 || e.Int64() > 1<<31-1 {
+			return nil, ErrBadPublicKey
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		c, ok := ecCurves[k.Crv]
+		if !ok {
+			return nil, ErrBadPublicKey
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (c.curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, ErrBadPublicKey
+		}
+		// The uncompressed point is rejected unless it lies on the curve.
+		if _, err := c.ecdh.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, errors.Wrap(ErrBadPublicKey, err)
+		}
+		return &ecdsa.PublicKey{Curve: c.curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrBadPublicKey
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, ErrBadPublicKey
+}
+
+// Thumbprint returns the RFC 7638 thumbprint of the JWK.
+func (k JWK) Thumbprint() (string, error) {
+	// The required members are hashed in lexicographic order.
+	var members any
+	switch k.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Crv, k.Kty, k.X, k.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
+	default:
+		return "", ErrBadPublicKey
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+
+	return encode(sum[:]), nil
+}
+
+// KeyAuthorization returns the key authorization of a challenge token for
+// the account key with the given thumbprint.
+func KeyAuthorization(token, thumbprint string) string {
+	return token + "." + thumbprint
+}
+
+// verifySignature verifies the JWS signature of the signing input with the
+// public key, which must match the algorithm.
+func verifySignature(alg string, pub crypto.PublicKey, input, signature []byte) error {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			return ErrBadSignatureAlgorithm
+		}
+		digest := sha256.Sum256(input)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.Wrap(ErrMalformed, errInvalidSignature)
+		}
+	case *ecdsa.PublicKey:
+		var c ecCurve
+		for _, curve := range ecCurves {
+			if curve.curve == key.Curve {
+				c = curve
+			}
+		}
+		if alg != c.alg {
+			return ErrBadSignatureAlgorithm
+		}
+		size := (c.curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.Wrap(ErrMalformed, errInvalidSignature)
+		}
+		h := c.hash.New()
+		h.Write(input)
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, h.Sum(nil), r, s) {
+			return errors.Wrap(ErrMalformed, errInvalidSignature)
+		}
+	case ed25519.PublicKey:
+		if alg != "EdDSA" {
+			return ErrBadSignatureAlgorithm
+		}
+		if !ed25519.Verify(key, input, signature) {
+			return errors.Wrap(ErrMalformed, errInvalidSignature)
+		}
+	default:
+		return ErrBadPublicKey
+	}
+
+	return nil
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(s string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(ErrMalformed, err)
+	}
+
+	return data, nil
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	data, err := decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
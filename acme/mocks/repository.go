@@ -0,0 +1,503 @@
+// Code generated by mockery v2.53.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	acme "github.com/hantdev/certs/acme"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockRepository is an autogenerated mock type for the Repository type
+type MockRepository struct {
+	mock.Mock
+}
+
+type MockRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRepository) EXPECT() *MockRepository_Expecter {
+	return &MockRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateAccount provides a mock function with given fields: ctx, account
+func (_m *MockRepository) CreateAccount(ctx context.Context, account acme.Account) error {
+	ret := _m.Called(ctx, account)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAccount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, acme.Account) error); ok {
+		r0 = rf(ctx, account)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_CreateAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAccount'
+type MockRepository_CreateAccount_Call struct {
+	*mock.Call
+}
+
+// CreateAccount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - account acme.Account
+func (_e *MockRepository_Expecter) CreateAccount(ctx interface{}, account interface{}) *MockRepository_CreateAccount_Call {
+	return &MockRepository_CreateAccount_Call{Call: _e.mock.On("CreateAccount", ctx, account)}
+}
+
+func (_c *MockRepository_CreateAccount_Call) Run(run func(ctx context.Context, account acme.Account)) *MockRepository_CreateAccount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(acme.Account))
+	})
+	return _c
+}
+
+func (_c *MockRepository_CreateAccount_Call) Return(_a0 error) *MockRepository_CreateAccount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_CreateAccount_Call) RunAndReturn(run func(context.Context, acme.Account) error) *MockRepository_CreateAccount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateOrder provides a mock function with given fields: ctx, order, authzs
+func (_m *MockRepository) CreateOrder(ctx context.Context, order acme.Order, authzs []acme.Authorization) error {
+	ret := _m.Called(ctx, order, authzs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, acme.Order, []acme.Authorization) error); ok {
+		r0 = rf(ctx, order, authzs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_CreateOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateOrder'
+type MockRepository_CreateOrder_Call struct {
+	*mock.Call
+}
+
+// CreateOrder is a helper method to define mock.On call
+//   - ctx context.Context
+//   - order acme.Order
+//   - authzs []acme.Authorization
+func (_e *MockRepository_Expecter) CreateOrder(ctx interface{}, order interface{}, authzs interface{}) *MockRepository_CreateOrder_Call {
+	return &MockRepository_CreateOrder_Call{Call: _e.mock.On("CreateOrder", ctx, order, authzs)}
+}
+
+func (_c *MockRepository_CreateOrder_Call) Run(run func(ctx context.Context, order acme.Order, authzs []acme.Authorization)) *MockRepository_CreateOrder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(acme.Order), args[2].([]acme.Authorization))
+	})
+	return _c
+}
+
+func (_c *MockRepository_CreateOrder_Call) Return(_a0 error) *MockRepository_CreateOrder_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_CreateOrder_Call) RunAndReturn(run func(context.Context, acme.Order, []acme.Authorization) error) *MockRepository_CreateOrder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveAccount provides a mock function with given fields: ctx, id
+func (_m *MockRepository) RetrieveAccount(ctx context.Context, id string) (acme.Account, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAccount")
+	}
+
+	var r0 acme.Account
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (acme.Account, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) acme.Account); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(acme.Account)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveAccount'
+type MockRepository_RetrieveAccount_Call struct {
+	*mock.Call
+}
+
+// RetrieveAccount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockRepository_Expecter) RetrieveAccount(ctx interface{}, id interface{}) *MockRepository_RetrieveAccount_Call {
+	return &MockRepository_RetrieveAccount_Call{Call: _e.mock.On("RetrieveAccount", ctx, id)}
+}
+
+func (_c *MockRepository_RetrieveAccount_Call) Run(run func(ctx context.Context, id string)) *MockRepository_RetrieveAccount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveAccount_Call) Return(_a0 acme.Account, _a1 error) *MockRepository_RetrieveAccount_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveAccount_Call) RunAndReturn(run func(context.Context, string) (acme.Account, error)) *MockRepository_RetrieveAccount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveAccountByThumbprint provides a mock function with given fields: ctx, thumbprint
+func (_m *MockRepository) RetrieveAccountByThumbprint(ctx context.Context, thumbprint string) (acme.Account, error) {
+	ret := _m.Called(ctx, thumbprint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAccountByThumbprint")
+	}
+
+	var r0 acme.Account
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (acme.Account, error)); ok {
+		return rf(ctx, thumbprint)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) acme.Account); ok {
+		r0 = rf(ctx, thumbprint)
+	} else {
+		r0 = ret.Get(0).(acme.Account)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, thumbprint)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveAccountByThumbprint_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveAccountByThumbprint'
+type MockRepository_RetrieveAccountByThumbprint_Call struct {
+	*mock.Call
+}
+
+// RetrieveAccountByThumbprint is a helper method to define mock.On call
+//   - ctx context.Context
+//   - thumbprint string
+func (_e *MockRepository_Expecter) RetrieveAccountByThumbprint(ctx interface{}, thumbprint interface{}) *MockRepository_RetrieveAccountByThumbprint_Call {
+	return &MockRepository_RetrieveAccountByThumbprint_Call{Call: _e.mock.On("RetrieveAccountByThumbprint", ctx, thumbprint)}
+}
+
+func (_c *MockRepository_RetrieveAccountByThumbprint_Call) Run(run func(ctx context.Context, thumbprint string)) *MockRepository_RetrieveAccountByThumbprint_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveAccountByThumbprint_Call) Return(_a0 acme.Account, _a1 error) *MockRepository_RetrieveAccountByThumbprint_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveAccountByThumbprint_Call) RunAndReturn(run func(context.Context, string) (acme.Account, error)) *MockRepository_RetrieveAccountByThumbprint_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveAuthorization provides a mock function with given fields: ctx, id
+func (_m *MockRepository) RetrieveAuthorization(ctx context.Context, id string) (acme.Authorization, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAuthorization")
+	}
+
+	var r0 acme.Authorization
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (acme.Authorization, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) acme.Authorization); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(acme.Authorization)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveAuthorization_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveAuthorization'
+type MockRepository_RetrieveAuthorization_Call struct {
+	*mock.Call
+}
+
+// RetrieveAuthorization is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockRepository_Expecter) RetrieveAuthorization(ctx interface{}, id interface{}) *MockRepository_RetrieveAuthorization_Call {
+	return &MockRepository_RetrieveAuthorization_Call{Call: _e.mock.On("RetrieveAuthorization", ctx, id)}
+}
+
+func (_c *MockRepository_RetrieveAuthorization_Call) Run(run func(ctx context.Context, id string)) *MockRepository_RetrieveAuthorization_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveAuthorization_Call) Return(_a0 acme.Authorization, _a1 error) *MockRepository_RetrieveAuthorization_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveAuthorization_Call) RunAndReturn(run func(context.Context, string) (acme.Authorization, error)) *MockRepository_RetrieveAuthorization_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveOrder provides a mock function with given fields: ctx, id
+func (_m *MockRepository) RetrieveOrder(ctx context.Context, id string) (acme.Order, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveOrder")
+	}
+
+	var r0 acme.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (acme.Order, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) acme.Order); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(acme.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveOrder'
+type MockRepository_RetrieveOrder_Call struct {
+	*mock.Call
+}
+
+// RetrieveOrder is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockRepository_Expecter) RetrieveOrder(ctx interface{}, id interface{}) *MockRepository_RetrieveOrder_Call {
+	return &MockRepository_RetrieveOrder_Call{Call: _e.mock.On("RetrieveOrder", ctx, id)}
+}
+
+func (_c *MockRepository_RetrieveOrder_Call) Run(run func(ctx context.Context, id string)) *MockRepository_RetrieveOrder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveOrder_Call) Return(_a0 acme.Order, _a1 error) *MockRepository_RetrieveOrder_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveOrder_Call) RunAndReturn(run func(context.Context, string) (acme.Order, error)) *MockRepository_RetrieveOrder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateAccount provides a mock function with given fields: ctx, account
+func (_m *MockRepository) UpdateAccount(ctx context.Context, account acme.Account) error {
+	ret := _m.Called(ctx, account)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAccount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, acme.Account) error); ok {
+		r0 = rf(ctx, account)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_UpdateAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateAccount'
+type MockRepository_UpdateAccount_Call struct {
+	*mock.Call
+}
+
+// UpdateAccount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - account acme.Account
+func (_e *MockRepository_Expecter) UpdateAccount(ctx interface{}, account interface{}) *MockRepository_UpdateAccount_Call {
+	return &MockRepository_UpdateAccount_Call{Call: _e.mock.On("UpdateAccount", ctx, account)}
+}
+
+func (_c *MockRepository_UpdateAccount_Call) Run(run func(ctx context.Context, account acme.Account)) *MockRepository_UpdateAccount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(acme.Account))
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateAccount_Call) Return(_a0 error) *MockRepository_UpdateAccount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_UpdateAccount_Call) RunAndReturn(run func(context.Context, acme.Account) error) *MockRepository_UpdateAccount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateAuthorization provides a mock function with given fields: ctx, authz
+func (_m *MockRepository) UpdateAuthorization(ctx context.Context, authz acme.Authorization) error {
+	ret := _m.Called(ctx, authz)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAuthorization")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, acme.Authorization) error); ok {
+		r0 = rf(ctx, authz)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_UpdateAuthorization_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateAuthorization'
+type MockRepository_UpdateAuthorization_Call struct {
+	*mock.Call
+}
+
+// UpdateAuthorization is a helper method to define mock.On call
+//   - ctx context.Context
+//   - authz acme.Authorization
+func (_e *MockRepository_Expecter) UpdateAuthorization(ctx interface{}, authz interface{}) *MockRepository_UpdateAuthorization_Call {
+	return &MockRepository_UpdateAuthorization_Call{Call: _e.mock.On("UpdateAuthorization", ctx, authz)}
+}
+
+func (_c *MockRepository_UpdateAuthorization_Call) Run(run func(ctx context.Context, authz acme.Authorization)) *MockRepository_UpdateAuthorization_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(acme.Authorization))
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateAuthorization_Call) Return(_a0 error) *MockRepository_UpdateAuthorization_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_UpdateAuthorization_Call) RunAndReturn(run func(context.Context, acme.Authorization) error) *MockRepository_UpdateAuthorization_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateOrder provides a mock function with given fields: ctx, order, status
+func (_m *MockRepository) UpdateOrder(ctx context.Context, order acme.Order, status acme.Status) error {
+	ret := _m.Called(ctx, order, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateOrder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, acme.Order, acme.Status) error); ok {
+		r0 = rf(ctx, order, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_UpdateOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateOrder'
+type MockRepository_UpdateOrder_Call struct {
+	*mock.Call
+}
+
+// UpdateOrder is a helper method to define mock.On call
+//   - ctx context.Context
+//   - order acme.Order
+//   - status acme.Status
+func (_e *MockRepository_Expecter) UpdateOrder(ctx interface{}, order interface{}, status interface{}) *MockRepository_UpdateOrder_Call {
+	return &MockRepository_UpdateOrder_Call{Call: _e.mock.On("UpdateOrder", ctx, order, status)}
+}
+
+func (_c *MockRepository_UpdateOrder_Call) Run(run func(ctx context.Context, order acme.Order, status acme.Status)) *MockRepository_UpdateOrder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(acme.Order), args[2].(acme.Status))
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateOrder_Call) Return(_a0 error) *MockRepository_UpdateOrder_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_UpdateOrder_Call) RunAndReturn(run func(context.Context, acme.Order, acme.Status) error) *MockRepository_UpdateOrder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRepository creates a new instance of MockRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRepository {
+	mock := &MockRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,46 @@
+package acme
+
+import (
+	"net/http"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// errorNamespace prefixes the ACME error types.
+const errorNamespace = "urn:ietf:params:acme:error:"
+
+var problems = []struct {
+	err    error
+	typ    string
+	status int
+}{
+	{ErrBadNonce, "badNonce", http.StatusBadRequest},
+	{ErrBadSignatureAlgorithm, "badSignatureAlgorithm", http.StatusBadRequest},
+	{ErrBadPublicKey, "badPublicKey", http.StatusBadRequest},
+	{ErrUnauthorized, "unauthorized", http.StatusForbidden},
+	{ErrAccountDoesNotExist, "accountDoesNotExist", http.StatusBadRequest},
+	{ErrInvalidContact, "invalidContact", http.StatusBadRequest},
+	{ErrUnsupportedIdentifier, "unsupportedIdentifier", http.StatusBadRequest},
+	{ErrRejectedIdentifier, "rejectedIdentifier", http.StatusBadRequest},
+	{ErrOrderNotReady, "orderNotReady", http.StatusForbidden},
+	{ErrBadCSR, "badCSR", http.StatusBadRequest},
+	{ErrBadRevocationReason, "badRevocationReason", http.StatusBadRequest},
+	{ErrAlreadyRevoked, "alreadyRevoked", http.StatusBadRequest},
+	{ErrIncorrectResponse, "incorrectResponse", http.StatusForbidden},
+	{ErrConnection, "connection", http.StatusBadRequest},
+	{ErrDNS, "dns", http.StatusBadRequest},
+	{ErrNotFound, "malformed", http.StatusNotFound},
+	{ErrMalformed, "malformed", http.StatusBadRequest},
+}
+
+// NewProblem returns the problem document reporting the error to the client.
+func NewProblem(err error) Problem {
+	for _, p := range problems {
+		if errors.Contains(err, p.err) {
+			return Problem{Type: errorNamespace + p.typ, Detail: err.Error(), Status: p.status}
+		}
+	}
+
+	// Internal errors are not disclosed to clients.
+	return Problem{Type: errorNamespace + "serverInternal", Detail: "internal server error", Status: http.StatusInternalServerError}
+}
@@ -0,0 +1,644 @@
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/uuid"
+)
+
+const (
+	nonceSize         = 16
+	tokenSize         = 32
+	nonceValidity     = time.Hour
+	maxNonces         = 1 << 16
+	orderValidity     = 24 * time.Hour
+	validationTimeout = 30 * time.Second
+)
+
+// challengeTypes are the challenge types offered, in order of preference.
+var challengeTypes = []string{ChallengeHTTP01, ChallengeDNS01}
+
+type service struct {
+	repo       Repository
+	certs      certs.Service
+	validators map[string]Validator
+	config     Config
+	idp        uuid.IDProvider
+	// nonces holds the expiry of the issued and unused nonces, guarded by nonceMu.
+	// Nonces are kept in memory, so they are only valid on the instance issuing them.
+	nonceMu sync.Mutex
+	nonces  map[string]time.Time
+}
+
+var _ Service = (*service)(nil)
+
+// NewService returns a new ACME service issuing certificates with the certs
+// service. Challenges are offered for the types there are validators of.
+func NewService(repo Repository, certsSvc certs.Service, validators map[string]Validator, config Config) Service {
+	if config.OrderValidity == 0 {
+		config.OrderValidity = orderValidity
+	}
+
+	return &service{
+		repo:       repo,
+		certs:      certsSvc,
+		validators: validators,
+		config:     config,
+		idp:        uuid.New(),
+		nonces:     make(map[string]time.Time),
+	}
+}
+
+func (s *service) Nonce(ctx context.Context) (string, error) {
+	b := make([]byte, nonceSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	nonce := encode(b)
+
+	s.nonceMu.Lock()
+	defer s.nonceMu.Unlock()
+
+	if len(s.nonces) >= maxNonces {
+		s.pruneNonces()
+	}
+	s.nonces[nonce] = time.Now().Add(nonceValidity)
+
+	return nonce, nil
+}
+
+// pruneNonces drops the expired nonces and, if there are still too many, the
+// oldest ones in no particular order. The caller must hold nonceMu.
+func (s *service) pruneNonces() {
+	now := time.Now()
+	for nonce, expiry := range s.nonces {
+		if now.After(expiry) {
+			delete(s.nonces, nonce)
+		}
+	}
+	for nonce := range s.nonces {
+		if len(s.nonces) < maxNonces {
+			return
+		}
+		delete(s.nonces, nonce)
+	}
+}
+
+// useNonce consumes the nonce, reporting whether it was issued and is not expired.
+func (s *service) useNonce(nonce string) bool {
+	s.nonceMu.Lock()
+	defer s.nonceMu.Unlock()
+
+	expiry, ok := s.nonces[nonce]
+	delete(s.nonces, nonce)
+
+	return ok && time.Now().Before(expiry)
+}
+
+func (s *service) Verify(ctx context.Context, baseURL, path string, data []byte) (Message, error) {
+	var req jws
+	if err := json.Unmarshal(data, &req); err != nil {
+		return Message{}, errors.Wrap(ErrMalformed, err)
+	}
+	protected, err := decode(req.Protected)
+	if err != nil {
+		return Message{}, err
+	}
+	var header jwsHeader
+	if err := json.Unmarshal(protected, &header); err != nil {
+		return Message{}, errors.Wrap(ErrMalformed, err)
+	}
+	payload, err := decode(req.Payload)
+	if err != nil {
+		return Message{}, err
+	}
+	signature, err := decode(req.Signature)
+	if err != nil {
+		return Message{}, err
+	}
+	if header.URL != baseURL+path {
+		return Message{}, errors.Wrap(ErrUnauthorized, errors.New("JWS url does not match the request URL"))
+	}
+
+	msg := Message{Payload: payload}
+	switch {
+	case header.JWK != nil && header.KID == "":
+		msg.Key = *header.JWK
+	case header.JWK == nil && header.KID != "":
+		id, ok := strings.CutPrefix(header.KID, baseURL+fmt.Sprintf(AccountPath, ""))
+		if !ok {
+			return Message{}, errors.Wrap(ErrMalformed, errors.New("kid is not an account URL"))
+		}
+		account, err := s.repo.RetrieveAccount(ctx, id)
+		if err != nil {
+			if errors.Contains(err, ErrNotFound) {
+				return Message{}, ErrAccountDoesNotExist
+			}
+			return Message{}, err
+		}
+		if account.Status != StatusValid {
+			return Message{}, errors.Wrap(ErrUnauthorized, errors.New("account is "+string(account.Status)))
+		}
+		msg.Account, msg.Key = account, account.Key
+	default:
+		return Message{}, errors.Wrap(ErrMalformed, errors.New("exactly one of jwk and kid must be set"))
+	}
+
+	pub, err := msg.Key.PublicKey()
+	if err != nil {
+		return Message{}, err
+	}
+	if err := verifySignature(header.Alg, pub, []byte(req.Protected+"."+req.Payload), signature); err != nil {
+		return Message{}, err
+	}
+	if !s.useNonce(header.Nonce) {
+		return Message{}, ErrBadNonce
+	}
+
+	return msg, nil
+}
+
+func (s *service) NewAccount(ctx context.Context, key JWK, contact []string, onlyReturnExisting bool) (Account, bool, error) {
+	if _, err := key.PublicKey(); err != nil {
+		return Account{}, false, err
+	}
+	thumbprint, err := key.Thumbprint()
+	if err != nil {
+		return Account{}, false, err
+	}
+	account, err := s.repo.RetrieveAccountByThumbprint(ctx, thumbprint)
+	switch {
+	case err == nil:
+		return account, false, nil
+	case !errors.Contains(err, ErrNotFound):
+		return Account{}, false, err
+	case onlyReturnExisting:
+		return Account{}, false, ErrAccountDoesNotExist
+	}
+	if err := validateContact(contact); err != nil {
+		return Account{}, false, err
+	}
+
+	id, err := s.idp.ID()
+	if err != nil {
+		return Account{}, false, err
+	}
+	account = Account{
+		ID:         id,
+		Status:     StatusValid,
+		Contact:    contact,
+		Key:        key,
+		Thumbprint: thumbprint,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.CreateAccount(ctx, account); err != nil {
+		return Account{}, false, err
+	}
+
+	return account, true, nil
+}
+
+func (s *service) UpdateAccount(ctx context.Context, account Account, contact []string, deactivate bool) (Account, error) {
+	if contact == nil && !deactivate {
+		return account, nil
+	}
+	if contact != nil {
+		if err := validateContact(contact); err != nil {
+			return Account{}, err
+		}
+		account.Contact = contact
+	}
+	if deactivate {
+		account.Status = StatusDeactivated
+	}
+	if err := s.repo.UpdateAccount(ctx, account); err != nil {
+		return Account{}, err
+	}
+
+	return account, nil
+}
+
+func (s *service) NewOrder(ctx context.Context, accountID string, identifiers []Identifier, notBefore, notAfter time.Time) (Order, error) {
+	now := time.Now()
+	switch {
+	case len(identifiers) == 0:
+		return Order{}, errors.Wrap(ErrMalformed, errors.New("order has no identifiers"))
+	case !notBefore.IsZero():
+		return Order{}, errors.Wrap(ErrMalformed, errors.New("notBefore is not supported"))
+	case !notAfter.IsZero() && !notAfter.After(now):
+		return Order{}, errors.Wrap(ErrMalformed, errors.New("notAfter must be in the future"))
+	}
+
+	id, err := s.idp.ID()
+	if err != nil {
+		return Order{}, err
+	}
+	order := Order{
+		ID:        id,
+		AccountID: accountID,
+		Status:    StatusPending,
+		Expires:   now.Add(s.config.OrderValidity),
+		NotAfter:  notAfter,
+	}
+	var authzs []Authorization
+	for _, identifier := range identifiers {
+		name, wildcard, err := normalizeIdentifier(identifier)
+		if err != nil {
+			return Order{}, err
+		}
+		value := name
+		if wildcard {
+			value = "*." + name
+		}
+		if slices.ContainsFunc(order.Identifiers, func(i Identifier) bool { return i.Value == value }) {
+			continue
+		}
+		authz, err := s.newAuthorization(order, Identifier{Type: IdentifierDNS, Value: name}, wildcard)
+		if err != nil {
+			return Order{}, err
+		}
+		order.Identifiers = append(order.Identifiers, Identifier{Type: IdentifierDNS, Value: value})
+		order.AuthorizationIDs = append(order.AuthorizationIDs, authz.ID)
+		authzs = append(authzs, authz)
+	}
+	if err := s.repo.CreateOrder(ctx, order, authzs); err != nil {
+		return Order{}, err
+	}
+
+	return order, nil
+}
+
+// newAuthorization creates the authorization of the order for the identifier
+// with a challenge of every type that can validate it.
+func (s *service) newAuthorization(order Order, identifier Identifier, wildcard bool) (Authorization, error) {
+	id, err := s.idp.ID()
+	if err != nil {
+		return Authorization{}, err
+	}
+	authz := Authorization{
+		ID:         id,
+		AccountID:  order.AccountID,
+		OrderID:    order.ID,
+		Identifier: identifier,
+		Status:     StatusPending,
+		Expires:    order.Expires,
+		Wildcard:   wildcard,
+	}
+	for _, typ := range challengeTypes {
+		// Control over a wildcard domain can only be proven in its DNS zone.
+		if _, ok := s.validators[typ]; !ok || (wildcard && typ != ChallengeDNS01) {
+			continue
+		}
+		token := make([]byte, tokenSize)
+		if _, err := rand.Read(token); err != nil {
+			return Authorization{}, err
+		}
+		id, err := s.idp.ID()
+		if err != nil {
+			return Authorization{}, err
+		}
+		authz.Challenges = append(authz.Challenges, Challenge{ID: id, Type: typ, Status: StatusPending, Token: encode(token)})
+	}
+	if len(authz.Challenges) == 0 {
+		return Authorization{}, errors.Wrap(ErrRejectedIdentifier, errors.New("no challenge can validate "+identifier.Value))
+	}
+
+	return authz, nil
+}
+
+func (s *service) ViewOrder(ctx context.Context, accountID, id string) (Order, error) {
+	order, err := s.repo.RetrieveOrder(ctx, id)
+	if err != nil {
+		return Order{}, err
+	}
+	if order.AccountID != accountID {
+		return Order{}, ErrUnauthorized
+	}
+
+	return s.refreshOrder(ctx, order)
+}
+
+// refreshOrder updates the status of a pending or ready order from its
+// expiry and the status of its authorizations.
+func (s *service) refreshOrder(ctx context.Context, order Order) (Order, error) {
+	if order.Status != StatusPending && order.Status != StatusReady {
+		return order, nil
+	}
+
+	status := order.Status
+	switch {
+	case time.Now().After(order.Expires):
+		status = StatusInvalid
+	case order.Status == StatusPending:
+		status = StatusReady
+		for _, authzID := range order.AuthorizationIDs {
+			authz, err := s.ViewAuthorization(ctx, order.AccountID, authzID)
+			if err != nil {
+				return Order{}, err
+			}
+			switch authz.Status {
+			case StatusValid:
+			case StatusPending:
+				if status == StatusReady {
+					status = StatusPending
+				}
+			default:
+				status = StatusInvalid
+			}
+		}
+	}
+	if status == order.Status {
+		return order, nil
+	}
+	current := order.Status
+	order.Status = status
+	if err := s.repo.UpdateOrder(ctx, order, current); err != nil {
+		// The order was changed meanwhile, e.g. finalized, so it is
+		// up to date already.
+		if errors.Contains(err, ErrOrderNotReady) {
+			return s.repo.RetrieveOrder(ctx, order.ID)
+		}
+		return Order{}, err
+	}
+
+	return order, nil
+}
+
+func (s *service) ViewAuthorization(ctx context.Context, accountID, id string) (Authorization, error) {
+	authz, err := s.repo.RetrieveAuthorization(ctx, id)
+	if err != nil {
+		return Authorization{}, err
+	}
+	if authz.AccountID != accountID {
+		return Authorization{}, ErrUnauthorized
+	}
+	if authz.Status == StatusPending && time.Now().After(authz.Expires) {
+		authz.Status = StatusExpired
+		if err := s.repo.UpdateAuthorization(ctx, authz); err != nil {
+			return Authorization{}, err
+		}
+	}
+
+	return authz, nil
+}
+
+func (s *service) RespondChallenge(ctx context.Context, account Account, authzID, id string) (Challenge, error) {
+	authz, err := s.ViewAuthorization(ctx, account.ID, authzID)
+	if err != nil {
+		return Challenge{}, err
+	}
+	i := slices.IndexFunc(authz.Challenges, func(c Challenge) bool { return c.ID == id })
+	if i < 0 {
+		return Challenge{}, ErrNotFound
+	}
+	// Responding again does not restart a validation.
+	if authz.Status != StatusPending || authz.Challenges[i].Status != StatusPending {
+		return authz.Challenges[i], nil
+	}
+
+	authz.Challenges[i].Status = StatusProcessing
+	if err := s.repo.UpdateAuthorization(ctx, authz); err != nil {
+		return Challenge{}, err
+	}
+	chal := authz.Challenges[i]
+	go s.validate(authz, i, KeyAuthorization(chal.Token, account.Thumbprint))
+
+	return chal, nil
+}
+
+// validate validates the challenge of the authorization in the background
+// and stores the outcome. A failed challenge invalidates the authorization.
+func (s *service) validate(authz Authorization, i int, keyAuthorization string) {
+	ctx, cancel := context.WithTimeout(context.Background(), validationTimeout)
+	defer cancel()
+
+	authz.Challenges = slices.Clone(authz.Challenges)
+	chal := &authz.Challenges[i]
+	if err := s.validators[chal.Type].Validate(ctx, authz.Identifier, chal.Token, keyAuthorization); err != nil {
+		problem := NewProblem(err)
+		chal.Status = StatusInvalid
+		chal.Error = &problem
+		authz.Status = StatusInvalid
+	} else {
+		chal.Status = StatusValid
+		chal.Validated = time.Now()
+		authz.Status = StatusValid
+	}
+	_ = s.repo.UpdateAuthorization(ctx, authz)
+}
+
+func (s *service) FinalizeOrder(ctx context.Context, accountID, id string, der []byte) (Order, error) {
+	order, err := s.ViewOrder(ctx, accountID, id)
+	if err != nil {
+		return Order{}, err
+	}
+	if order.Status != StatusReady {
+		return Order{}, errors.Wrap(ErrOrderNotReady, errors.New("order is "+string(order.Status)))
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return Order{}, errors.Wrap(ErrBadCSR, err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return Order{}, errors.Wrap(ErrBadCSR, err)
+	}
+	if err := matchIdentifiers(csr, order.Identifiers); err != nil {
+		return Order{}, errors.Wrap(ErrBadCSR, err)
+	}
+
+	// Only one request can move the order to processing, so concurrent or
+	// retried finalizations issue a single certificate. A failed issuance
+	// leaves the order ready, so it can be finalized again.
+	order.Status = StatusProcessing
+	if err := s.repo.UpdateOrder(ctx, order, StatusReady); err != nil {
+		return Order{}, err
+	}
+	var ttl string
+	if !order.NotAfter.IsZero() {
+		ttl = time.Until(order.NotAfter).Round(time.Second).String()
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	cert, err := s.certs.IssueFromCSR(ctx, accountID, s.config.Issuer, s.config.Profile, ttl, certs.CSR{CSR: csrPEM})
+	if err != nil {
+		order.Status = StatusReady
+		if uerr := s.repo.UpdateOrder(ctx, order, StatusProcessing); uerr != nil {
+			return Order{}, errors.Wrap(err, uerr)
+		}
+		if errors.Contains(err, certs.ErrPolicyViolation) || errors.Contains(err, certs.ErrMalformedEntity) {
+			return Order{}, errors.Wrap(ErrBadCSR, err)
+		}
+		return Order{}, err
+	}
+	order.Status = StatusValid
+	order.CertSerial = cert.SerialNumber
+	if err := s.repo.UpdateOrder(ctx, order, StatusProcessing); err != nil {
+		return Order{}, err
+	}
+
+	return order, nil
+}
+
+func (s *service) Certificate(ctx context.Context, accountID, orderID string) ([]byte, error) {
+	order, err := s.repo.RetrieveOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.AccountID != accountID {
+		return nil, ErrUnauthorized
+	}
+	if order.Status != StatusValid {
+		return nil, errors.Wrap(ErrNotFound, errors.New("order has no certificate"))
+	}
+	cert, err := s.certs.ViewCert(ctx, order.CertSerial)
+	if err != nil {
+		return nil, err
+	}
+	chain := slices.Clone(cert.Certificate)
+	if cert.IssuerSerial != "" {
+		ca, err := s.certs.ViewCACert(ctx, cert.IssuerSerial)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, ca.Certificate...)
+	}
+
+	return chain, nil
+}
+
+func (s *service) RevokeCert(ctx context.Context, msg Message, der []byte, reason certs.RevocationReason) error {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return errors.Wrap(ErrMalformed, err)
+	}
+	stored, err := s.certs.ViewCert(ctx, cert.SerialNumber.String())
+	if err != nil {
+		if errors.Contains(err, certs.ErrNotFound) {
+			return errors.Wrap(ErrMalformed, errors.New("unknown certificate"))
+		}
+		return err
+	}
+	block, _ := pem.Decode(stored.Certificate)
+	if block == nil || !bytes.Equal(block.Bytes, der) || stored.Type != certs.ClientCert {
+		return errors.Wrap(ErrMalformed, errors.New("unknown certificate"))
+	}
+
+	// The certificate is revoked by the account it was issued to or with its own key.
+	switch msg.Account.ID {
+	case "":
+		pub, err := msg.Key.PublicKey()
+		if err != nil {
+			return err
+		}
+		if key, ok := pub.(interface{ Equal(crypto.PublicKey) bool }); !ok || !key.Equal(cert.PublicKey) {
+			return ErrUnauthorized
+		}
+	case stored.EntityID:
+	default:
+		return ErrUnauthorized
+	}
+	if stored.Revoked && stored.RevocationReason != certs.RevocationCertificateHold {
+		return ErrAlreadyRevoked
+	}
+	if err := s.certs.RevokeCert(ctx, stored.SerialNumber, reason, time.Time{}); err != nil {
+		if errors.Contains(err, certs.ErrMalformedEntity) {
+			return errors.Wrap(ErrBadRevocationReason, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// normalizeIdentifier returns the lower case DNS name of the identifier and
+// whether it is a wildcard, without the wildcard label.
+func normalizeIdentifier(identifier Identifier) (string, bool, error) {
+	if identifier.Type != IdentifierDNS {
+		return "", false, errors.Wrap(ErrUnsupportedIdentifier, errors.New(identifier.Type))
+	}
+	name := strings.ToLower(identifier.Value)
+	name, wildcard := strings.CutPrefix(name, "*.")
+	if !validDNSName(name) {
+		return "", false, errors.Wrap(ErrRejectedIdentifier, errors.New("invalid DNS name "+identifier.Value))
+	}
+
+	return name, wildcard, nil
+}
+
+// validDNSName reports whether the name is a fully qualified DNS name without
+// a trailing dot. IP addresses are not DNS names.
+func validDNSName(name string) bool {
+	if len(name) > 253 || net.ParseIP(name) != nil {
+		return false
+	}
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// matchIdentifiers checks that the CSR requests exactly the identifiers of
+// the order. A common name must be one of them.
+func matchIdentifiers(csr *x509.CertificateRequest, identifiers []Identifier) error {
+	if len(csr.IPAddresses) > 0 || len(csr.EmailAddresses) > 0 || len(csr.URIs) > 0 {
+		return errors.New("CSR requests identifiers other than DNS names")
+	}
+	names := make([]string, len(csr.DNSNames))
+	for i, name := range csr.DNSNames {
+		names[i] = strings.ToLower(name)
+	}
+	cn := strings.ToLower(csr.Subject.CommonName)
+	if cn != "" && !slices.Contains(names, cn) {
+		names = append(names, cn)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	values := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		values[i] = identifier.Value
+	}
+	slices.Sort(values)
+	if !slices.Equal(names, values) {
+		return errors.New("CSR names do not match the order identifiers")
+	}
+
+	return nil
+}
+
+// validateContact checks that the contacts are single mailto URLs.
+func validateContact(contact []string) error {
+	for _, c := range contact {
+		address, ok := strings.CutPrefix(c, "mailto:")
+		if !ok || !strings.Contains(address, "@") || strings.ContainsAny(address, ",?") {
+			return errors.Wrap(ErrInvalidContact, errors.New(c))
+		}
+	}
+
+	return nil
+}
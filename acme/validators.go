@@ -0,0 +1,97 @@
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// maxHTTP01Response limits the http-01 response read from the identifier.
+const maxHTTP01Response = 1 << 10
+
+// Validator proves control over an identifier with a type of challenge.
+type Validator interface {
+	// Validate checks that the identifier provisioned the key authorization
+	// of the challenge with the given token.
+	Validate(ctx context.Context, identifier Identifier, token, keyAuthorization string) error
+}
+
+// Resolver looks up the TXT records of a DNS name, as net.Resolver does.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type http01Validator struct {
+	client *http.Client
+	port   int
+}
+
+// NewHTTP01Validator returns the validator of http-01 challenges, which
+// fetches the key authorization from the identifier on the given port.
+func NewHTTP01Validator(client *http.Client, port int) Validator {
+	return &http01Validator{client: client, port: port}
+}
+
+func (v *http01Validator) Validate(ctx context.Context, identifier Identifier, token, keyAuthorization string) error {
+	host := identifier.Value
+	if v.port != 0 && v.port != 80 {
+		host = net.JoinHostPort(host, strconv.Itoa(v.port))
+	}
+	url := fmt.Sprintf("http://%s/.well-known/acme-challenge/%s", host, token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(ErrConnection, err)
+	}
+	res, err := v.client.Do(req)
+	if err != nil {
+		return errors.Wrap(ErrConnection, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Wrap(ErrIncorrectResponse, errors.New(fmt.Sprintf("unexpected status %d from %s", res.StatusCode, url)))
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxHTTP01Response))
+	if err != nil {
+		return errors.Wrap(ErrConnection, err)
+	}
+	if strings.TrimSpace(string(body)) != keyAuthorization {
+		return errors.Wrap(ErrIncorrectResponse, errors.New("key authorization mismatch at "+url))
+	}
+
+	return nil
+}
+
+type dns01Validator struct {
+	resolver Resolver
+}
+
+// NewDNS01Validator returns the validator of dns-01 challenges, which looks
+// up the digest of the key authorization in the TXT records of the identifier.
+func NewDNS01Validator(resolver Resolver) Validator {
+	return &dns01Validator{resolver: resolver}
+}
+
+func (v *dns01Validator) Validate(ctx context.Context, identifier Identifier, _, keyAuthorization string) error {
+	name := "_acme-challenge." + identifier.Value
+	records, err := v.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return errors.Wrap(ErrDNS, err)
+	}
+	digest := sha256.Sum256([]byte(keyAuthorization))
+	expected := encode(digest[:])
+	for _, record := range records {
+		if record == expected {
+			return nil
+		}
+	}
+
+	return errors.Wrap(ErrIncorrectResponse, errors.New("no matching TXT record at "+name))
+}
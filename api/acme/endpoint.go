@@ -0,0 +1,194 @@
+package acme
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/acme"
+	"github.com/hantdev/certs/errors"
+)
+
+func directoryEndpoint() endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return newDirectoryRes(baseURL(ctx)), nil
+	}
+}
+
+func newNonceEndpoint() endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		// The nonce itself is added to every response by the nonce middleware.
+		if request.(string) == http.MethodHead {
+			return nonceRes{code: http.StatusOK}, nil
+		}
+
+		return nonceRes{code: http.StatusNoContent}, nil
+	}
+}
+
+func newAccountEndpoint(svc acme.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(newAccountReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		account, created, err := svc.NewAccount(ctx, req.msg.Key, req.Contact, req.OnlyReturnExisting)
+		if err != nil {
+			return nil, err
+		}
+
+		return newAccountRes(baseURL(ctx), account, created), nil
+	}
+}
+
+func accountEndpoint(svc acme.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(accountReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		account, err := svc.UpdateAccount(ctx, req.msg.Account, req.Contact, req.Status == string(acme.StatusDeactivated))
+		if err != nil {
+			return nil, err
+		}
+
+		return newAccountRes(baseURL(ctx), account, false), nil
+	}
+}
+
+func newOrderEndpoint(svc acme.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(newOrderReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		order, err := svc.NewOrder(ctx, req.msg.Account.ID, req.Identifiers, req.NotBefore, req.NotAfter)
+		if err != nil {
+			return nil, err
+		}
+
+		return newOrderRes(baseURL(ctx), order, true), nil
+	}
+}
+
+func orderEndpoint(svc acme.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(postAsGetReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		order, err := svc.ViewOrder(ctx, req.msg.Account.ID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return newOrderRes(baseURL(ctx), order, false), nil
+	}
+}
+
+func finalizeEndpoint(svc acme.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(finalizeReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		csr, err := base64.RawURLEncoding.DecodeString(req.CSR)
+		if err != nil {
+			return nil, errors.Wrap(acme.ErrBadCSR, err)
+		}
+
+		order, err := svc.FinalizeOrder(ctx, req.msg.Account.ID, req.id, csr)
+		if err != nil {
+			return nil, err
+		}
+
+		return newOrderRes(baseURL(ctx), order, false), nil
+	}
+}
+
+func authzEndpoint(svc acme.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(postAsGetReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		authz, err := svc.ViewAuthorization(ctx, req.msg.Account.ID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return newAuthzRes(baseURL(ctx), authz), nil
+	}
+}
+
+func challengeEndpoint(svc acme.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(challengeReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		// An empty payload retrieves the challenge, any other responds to it.
+		if len(req.msg.Payload) == 0 {
+			authz, err := svc.ViewAuthorization(ctx, req.msg.Account.ID, req.authzID)
+			if err != nil {
+				return nil, err
+			}
+			for _, chal := range authz.Challenges {
+				if chal.ID == req.id {
+					return newChallengeRes(baseURL(ctx), authz.ID, chal), nil
+				}
+			}
+			return nil, acme.ErrNotFound
+		}
+
+		chal, err := svc.RespondChallenge(ctx, req.msg.Account, req.authzID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return newChallengeRes(baseURL(ctx), req.authzID, chal), nil
+	}
+}
+
+func certificateEndpoint(svc acme.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(postAsGetReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		chain, err := svc.Certificate(ctx, req.msg.Account.ID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return certificateRes{chain: chain}, nil
+	}
+}
+
+func revokeCertEndpoint(svc acme.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeCertReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		der, err := base64.RawURLEncoding.DecodeString(req.Certificate)
+		if err != nil {
+			return nil, errors.Wrap(acme.ErrMalformed, err)
+		}
+
+		if err := svc.RevokeCert(ctx, req.msg, der, certs.RevocationReason(req.Reason)); err != nil {
+			return nil, err
+		}
+
+		return revokeCertRes{}, nil
+	}
+}
@@ -0,0 +1,130 @@
+package acme
+
+import (
+	"time"
+
+	"github.com/hantdev/certs/acme"
+	"github.com/hantdev/certs/errors"
+)
+
+var (
+	errAccountRequired = errors.New("request must be signed with the kid of an account")
+	errJWKRequired     = errors.New("request must be signed with a jwk")
+	errPayloadNotEmpty = errors.New("POST-as-GET request must have an empty payload")
+)
+
+// requireAccount checks that the request is signed by an account.
+func requireAccount(msg acme.Message) error {
+	if msg.Account.ID == "" {
+		return errors.Wrap(acme.ErrMalformed, errAccountRequired)
+	}
+
+	return nil
+}
+
+type newAccountReq struct {
+	msg                  acme.Message
+	Contact              []string `json:"contact"`
+	TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed"`
+	OnlyReturnExisting   bool     `json:"onlyReturnExisting"`
+}
+
+func (req newAccountReq) validate() error {
+	if req.msg.Account.ID != "" {
+		return errors.Wrap(acme.ErrMalformed, errJWKRequired)
+	}
+
+	return nil
+}
+
+type accountReq struct {
+	msg     acme.Message
+	id      string
+	Contact []string `json:"contact"`
+	Status  string   `json:"status"`
+}
+
+func (req accountReq) validate() error {
+	if err := requireAccount(req.msg); err != nil {
+		return err
+	}
+	if req.msg.Account.ID != req.id {
+		return acme.ErrUnauthorized
+	}
+	if req.Status != "" && req.Status != string(acme.StatusDeactivated) {
+		return errors.Wrap(acme.ErrMalformed, errors.New("account status can only be changed to deactivated"))
+	}
+
+	return nil
+}
+
+type newOrderReq struct {
+	msg         acme.Message
+	Identifiers []acme.Identifier `json:"identifiers"`
+	NotBefore   time.Time         `json:"notBefore"`
+	NotAfter    time.Time         `json:"notAfter"`
+}
+
+func (req newOrderReq) validate() error {
+	return requireAccount(req.msg)
+}
+
+// postAsGetReq retrieves the resource with the given ID.
+type postAsGetReq struct {
+	msg acme.Message
+	id  string
+}
+
+func (req postAsGetReq) validate() error {
+	if err := requireAccount(req.msg); err != nil {
+		return err
+	}
+	if len(req.msg.Payload) > 0 {
+		return errors.Wrap(acme.ErrMalformed, errPayloadNotEmpty)
+	}
+
+	return nil
+}
+
+// challengeReq retrieves a challenge with an empty payload or responds to
+// it with an empty JSON object.
+type challengeReq struct {
+	msg     acme.Message
+	authzID string
+	id      string
+}
+
+func (req challengeReq) validate() error {
+	return requireAccount(req.msg)
+}
+
+type finalizeReq struct {
+	msg acme.Message
+	id  string
+	CSR string `json:"csr"`
+}
+
+func (req finalizeReq) validate() error {
+	if err := requireAccount(req.msg); err != nil {
+		return err
+	}
+	if req.CSR == "" {
+		return errors.Wrap(acme.ErrMalformed, errors.New("missing csr"))
+	}
+
+	return nil
+}
+
+type revokeCertReq struct {
+	msg         acme.Message
+	Certificate string `json:"certificate"`
+	Reason      int    `json:"reason"`
+}
+
+func (req revokeCertReq) validate() error {
+	if req.Certificate == "" {
+		return errors.Wrap(acme.ErrMalformed, errors.New("missing certificate"))
+	}
+
+	return nil
+}
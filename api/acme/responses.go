@@ -0,0 +1,257 @@
+package acme
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hantdev/certs/acme"
+)
+
+var (
+	_ Response = (*directoryRes)(nil)
+	_ Response = (*nonceRes)(nil)
+	_ Response = (*accountRes)(nil)
+	_ Response = (*orderRes)(nil)
+	_ Response = (*authzRes)(nil)
+	_ Response = (*challengeRes)(nil)
+	_ Response = (*revokeCertRes)(nil)
+)
+
+// Response contains HTTP response specific methods.
+type Response interface {
+	// Code returns HTTP response code.
+	Code() int
+
+	// Headers returns map of HTTP headers with their values.
+	Headers() map[string]string
+
+	// Empty indicates if HTTP response has content.
+	Empty() bool
+}
+
+type directoryRes struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+	RevokeCert string `json:"revokeCert"`
+}
+
+func newDirectoryRes(base string) directoryRes {
+	return directoryRes{
+		NewNonce:   base + acme.NewNoncePath,
+		NewAccount: base + acme.NewAccountPath,
+		NewOrder:   base + acme.NewOrderPath,
+		RevokeCert: base + acme.RevokeCertPath,
+	}
+}
+
+func (res directoryRes) Code() int {
+	return http.StatusOK
+}
+
+func (res directoryRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res directoryRes) Empty() bool {
+	return false
+}
+
+type nonceRes struct {
+	code int
+}
+
+func (res nonceRes) Code() int {
+	return res.code
+}
+
+func (res nonceRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res nonceRes) Empty() bool {
+	return true
+}
+
+type accountRes struct {
+	Status   acme.Status `json:"status"`
+	Contact  []string    `json:"contact,omitempty"`
+	location string
+	created  bool
+}
+
+func newAccountRes(base string, account acme.Account, created bool) accountRes {
+	return accountRes{
+		Status:   account.Status,
+		Contact:  account.Contact,
+		location: base + fmt.Sprintf(acme.AccountPath, account.ID),
+		created:  created,
+	}
+}
+
+func (res accountRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res accountRes) Headers() map[string]string {
+	return map[string]string{"Location": res.location}
+}
+
+func (res accountRes) Empty() bool {
+	return false
+}
+
+type orderRes struct {
+	Status         acme.Status       `json:"status"`
+	Expires        string            `json:"expires"`
+	Identifiers    []acme.Identifier `json:"identifiers"`
+	NotAfter       string            `json:"notAfter,omitempty"`
+	Authorizations []string          `json:"authorizations"`
+	Finalize       string            `json:"finalize"`
+	Certificate    string            `json:"certificate,omitempty"`
+	Error          *acme.Problem     `json:"error,omitempty"`
+	location       string
+	created        bool
+}
+
+func newOrderRes(base string, order acme.Order, created bool) orderRes {
+	res := orderRes{
+		Status:      order.Status,
+		Expires:     formatTime(order.Expires),
+		Identifiers: order.Identifiers,
+		NotAfter:    formatTime(order.NotAfter),
+		Finalize:    base + fmt.Sprintf(acme.FinalizePath, order.ID),
+		Error:       order.Error,
+		location:    base + fmt.Sprintf(acme.OrderPath, order.ID),
+		created:     created,
+	}
+	for _, id := range order.AuthorizationIDs {
+		res.Authorizations = append(res.Authorizations, base+fmt.Sprintf(acme.AuthorizationPath, id))
+	}
+	if order.Status == acme.StatusValid {
+		res.Certificate = base + fmt.Sprintf(acme.CertificatePath, order.ID)
+	}
+
+	return res
+}
+
+func (res orderRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res orderRes) Headers() map[string]string {
+	return map[string]string{"Location": res.location}
+}
+
+func (res orderRes) Empty() bool {
+	return false
+}
+
+type authzRes struct {
+	Identifier acme.Identifier `json:"identifier"`
+	Status     acme.Status     `json:"status"`
+	Expires    string          `json:"expires"`
+	Challenges []challengeRes  `json:"challenges"`
+	Wildcard   bool            `json:"wildcard,omitempty"`
+}
+
+func newAuthzRes(base string, authz acme.Authorization) authzRes {
+	res := authzRes{
+		Identifier: authz.Identifier,
+		Status:     authz.Status,
+		Expires:    formatTime(authz.Expires),
+		Challenges: []challengeRes{},
+		Wildcard:   authz.Wildcard,
+	}
+	for _, chal := range authz.Challenges {
+		res.Challenges = append(res.Challenges, newChallengeRes(base, authz.ID, chal))
+	}
+
+	return res
+}
+
+func (res authzRes) Code() int {
+	return http.StatusOK
+}
+
+func (res authzRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res authzRes) Empty() bool {
+	return false
+}
+
+type challengeRes struct {
+	Type      string        `json:"type"`
+	URL       string        `json:"url"`
+	Status    acme.Status   `json:"status"`
+	Token     string        `json:"token"`
+	Validated string        `json:"validated,omitempty"`
+	Error     *acme.Problem `json:"error,omitempty"`
+	authz     string
+}
+
+func newChallengeRes(base, authzID string, chal acme.Challenge) challengeRes {
+	return challengeRes{
+		Type:      chal.Type,
+		URL:       base + fmt.Sprintf(acme.ChallengePath, authzID, chal.ID),
+		Status:    chal.Status,
+		Token:     chal.Token,
+		Validated: formatTime(chal.Validated),
+		Error:     chal.Error,
+		authz:     base + fmt.Sprintf(acme.AuthorizationPath, authzID),
+	}
+}
+
+func (res challengeRes) Code() int {
+	return http.StatusOK
+}
+
+func (res challengeRes) Headers() map[string]string {
+	return map[string]string{"Link": link(res.authz, "up")}
+}
+
+func (res challengeRes) Empty() bool {
+	return false
+}
+
+type certificateRes struct {
+	chain []byte
+}
+
+type revokeCertRes struct{}
+
+func (res revokeCertRes) Code() int {
+	return http.StatusOK
+}
+
+func (res revokeCertRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res revokeCertRes) Empty() bool {
+	return true
+}
+
+// formatTime formats the time as of RFC 3339, or returns an empty string for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func link(url, rel string) string {
+	return fmt.Sprintf("<%s>;rel=%q", url, rel)
+}
@@ -0,0 +1,335 @@
+// Package acme contains the HTTP transport of the ACME (RFC 8555) server.
+package acme
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/certs/acme"
+	"github.com/hantdev/certs/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	// JOSEType is the content type of the JWS signed requests.
+	JOSEType = "application/jose+json"
+	// ProblemType is the content type of the ACME error documents.
+	ProblemType = "application/problem+json"
+	// ContentType represents JSON content type.
+	ContentType = "application/json"
+	// ChainType is the content type of the PEM encoded certificate chain.
+	ChainType = "application/pem-certificate-chain"
+
+	// maxRequestSize limits the size of the JWS request body.
+	maxRequestSize = 1 << 16
+)
+
+var errUnsupportedContentType = errors.New("unsupported content type")
+
+type baseURLKey struct{}
+
+// MakeHandler returns a HTTP handler for the ACME endpoints. The base URL is
+// used to build the resource URLs; if empty, it is derived from the request.
+func MakeHandler(svc acme.Service, logger *slog.Logger, base string) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(loggingErrorEncoder(logger, EncodeError)),
+	}
+
+	r := chi.NewRouter()
+	r.Use(withBaseURL(strings.TrimSuffix(base, "/")), withNonce(svc, logger))
+
+	r.Route("/acme", func(r chi.Router) {
+		r.Get("/directory", otelhttp.NewHandler(kithttp.NewServer(
+			directoryEndpoint(),
+			kithttp.NopRequestDecoder,
+			EncodeResponse,
+			opts...,
+		), "acme_directory").ServeHTTP)
+		r.Head("/new-nonce", otelhttp.NewHandler(kithttp.NewServer(
+			newNonceEndpoint(),
+			decodeNewNonce,
+			EncodeResponse,
+			opts...,
+		), "acme_new_nonce").ServeHTTP)
+		r.Get("/new-nonce", otelhttp.NewHandler(kithttp.NewServer(
+			newNonceEndpoint(),
+			decodeNewNonce,
+			EncodeResponse,
+			opts...,
+		), "acme_new_nonce").ServeHTTP)
+		r.Post("/new-account", otelhttp.NewHandler(kithttp.NewServer(
+			newAccountEndpoint(svc),
+			decodeNewAccount(svc),
+			EncodeResponse,
+			opts...,
+		), "acme_new_account").ServeHTTP)
+		r.Post("/account/{id}", otelhttp.NewHandler(kithttp.NewServer(
+			accountEndpoint(svc),
+			decodeAccount(svc),
+			EncodeResponse,
+			opts...,
+		), "acme_account").ServeHTTP)
+		r.Post("/new-order", otelhttp.NewHandler(kithttp.NewServer(
+			newOrderEndpoint(svc),
+			decodeNewOrder(svc),
+			EncodeResponse,
+			opts...,
+		), "acme_new_order").ServeHTTP)
+		r.Post("/order/{id}", otelhttp.NewHandler(kithttp.NewServer(
+			orderEndpoint(svc),
+			decodePostAsGet(svc),
+			EncodeResponse,
+			opts...,
+		), "acme_order").ServeHTTP)
+		r.Post("/order/{id}/finalize", otelhttp.NewHandler(kithttp.NewServer(
+			finalizeEndpoint(svc),
+			decodeFinalize(svc),
+			EncodeResponse,
+			opts...,
+		), "acme_finalize").ServeHTTP)
+		r.Post("/authz/{id}", otelhttp.NewHandler(kithttp.NewServer(
+			authzEndpoint(svc),
+			decodePostAsGet(svc),
+			EncodeResponse,
+			opts...,
+		), "acme_authz").ServeHTTP)
+		r.Post("/chall/{authzID}/{id}", otelhttp.NewHandler(kithttp.NewServer(
+			challengeEndpoint(svc),
+			decodeChallenge(svc),
+			EncodeResponse,
+			opts...,
+		), "acme_challenge").ServeHTTP)
+		r.Post("/cert/{id}", otelhttp.NewHandler(kithttp.NewServer(
+			certificateEndpoint(svc),
+			decodePostAsGet(svc),
+			encodeCertificateResponse,
+			opts...,
+		), "acme_certificate").ServeHTTP)
+		r.Post("/revoke-cert", otelhttp.NewHandler(kithttp.NewServer(
+			revokeCertEndpoint(svc),
+			decodeRevokeCert(svc),
+			EncodeResponse,
+			opts...,
+		), "acme_revoke_cert").ServeHTTP)
+	})
+
+	return r
+}
+
+// withBaseURL stores the base URL of the server in the request context.
+func withBaseURL(base string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			url := base
+			if url == "" {
+				scheme := "http"
+				switch {
+				case r.Header.Get("X-Forwarded-Proto") != "":
+					scheme = r.Header.Get("X-Forwarded-Proto")
+				case r.TLS != nil:
+					scheme = "https"
+				}
+				url = scheme + "://" + r.Host
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), baseURLKey{}, url)))
+		})
+	}
+}
+
+// withNonce adds a fresh nonce and the directory link to every response.
+func withNonce(svc acme.Service, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce, err := svc.Nonce(r.Context())
+			if err != nil {
+				logger.Error("failed to issue ACME nonce", slog.Any("error", err))
+			} else {
+				w.Header().Set("Replay-Nonce", nonce)
+			}
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Add("Link", link(baseURL(r.Context())+acme.DirectoryPath, "index"))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func baseURL(ctx context.Context) string {
+	url, _ := ctx.Value(baseURLKey{}).(string)
+	return url
+}
+
+func decodeNewNonce(_ context.Context, r *http.Request) (interface{}, error) {
+	return r.Method, nil
+}
+
+// verify reads the JWS request body and verifies it against the request URL.
+func verify(ctx context.Context, svc acme.Service, r *http.Request) (acme.Message, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != JOSEType {
+		return acme.Message{}, errors.Wrap(acme.ErrMalformed, errUnsupportedContentType)
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		return acme.Message{}, errors.Wrap(acme.ErrMalformed, err)
+	}
+
+	return svc.Verify(ctx, baseURL(ctx), r.URL.Path, body)
+}
+
+// decodePayload unmarshals the JSON payload of the message into v.
+func decodePayload(msg acme.Message, v interface{}) error {
+	if err := json.Unmarshal(msg.Payload, v); err != nil {
+		return errors.Wrap(acme.ErrMalformed, err)
+	}
+
+	return nil
+}
+
+func decodeNewAccount(svc acme.Service) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		msg, err := verify(ctx, svc, r)
+		if err != nil {
+			return nil, err
+		}
+		req := newAccountReq{msg: msg}
+		if err := decodePayload(msg, &req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
+}
+
+func decodeAccount(svc acme.Service) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		msg, err := verify(ctx, svc, r)
+		if err != nil {
+			return nil, err
+		}
+		req := accountReq{msg: msg, id: chi.URLParam(r, "id")}
+		// An empty payload retrieves the account unchanged.
+		if len(msg.Payload) > 0 {
+			if err := decodePayload(msg, &req); err != nil {
+				return nil, err
+			}
+		}
+
+		return req, nil
+	}
+}
+
+func decodeNewOrder(svc acme.Service) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		msg, err := verify(ctx, svc, r)
+		if err != nil {
+			return nil, err
+		}
+		req := newOrderReq{msg: msg}
+		if err := decodePayload(msg, &req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
+}
+
+func decodePostAsGet(svc acme.Service) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		msg, err := verify(ctx, svc, r)
+		if err != nil {
+			return nil, err
+		}
+
+		return postAsGetReq{msg: msg, id: chi.URLParam(r, "id")}, nil
+	}
+}
+
+func decodeChallenge(svc acme.Service) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		msg, err := verify(ctx, svc, r)
+		if err != nil {
+			return nil, err
+		}
+
+		return challengeReq{msg: msg, authzID: chi.URLParam(r, "authzID"), id: chi.URLParam(r, "id")}, nil
+	}
+}
+
+func decodeFinalize(svc acme.Service) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		msg, err := verify(ctx, svc, r)
+		if err != nil {
+			return nil, err
+		}
+		req := finalizeReq{msg: msg, id: chi.URLParam(r, "id")}
+		if err := decodePayload(msg, &req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
+}
+
+func decodeRevokeCert(svc acme.Service) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		msg, err := verify(ctx, svc, r)
+		if err != nil {
+			return nil, err
+		}
+		req := revokeCertReq{msg: msg}
+		if err := decodePayload(msg, &req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
+}
+
+// EncodeResponse encodes successful response.
+func EncodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	if ar, ok := response.(Response); ok {
+		for k, v := range ar.Headers() {
+			w.Header().Add(k, v)
+		}
+		if ar.Empty() {
+			w.WriteHeader(ar.Code())
+			return nil
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.WriteHeader(ar.Code())
+	}
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeCertificateResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(certificateRes)
+	w.Header().Set("Content-Type", ChainType)
+	_, err := w.Write(res.chain)
+
+	return err
+}
+
+// EncodeError encodes the error as an ACME problem document.
+func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
+	problem := acme.NewProblem(err)
+	w.Header().Set("Content-Type", ProblemType)
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+func loggingErrorEncoder(logger *slog.Logger, enc kithttp.ErrorEncoder) kithttp.ErrorEncoder {
+	return func(ctx context.Context, err error, w http.ResponseWriter) {
+		if problem := acme.NewProblem(err); problem.Status == http.StatusInternalServerError {
+			logger.Error(err.Error())
+		}
+		enc(ctx, err, w)
+	}
+}
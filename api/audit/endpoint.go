@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/certs/audit"
+)
+
+func listEventsEndpoint(svc audit.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listEventsReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		page, err := svc.ListEvents(ctx, req.Filter)
+		if err != nil {
+			return nil, err
+		}
+
+		return listEventsRes{Page: page}, nil
+	}
+}
+
+func verifyEndpoint(svc audit.Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		v, err := svc.Verify(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return verifyRes{Verification: v}, nil
+	}
+}
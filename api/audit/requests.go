@@ -0,0 +1,21 @@
+package audit
+
+import (
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/audit"
+	"github.com/hantdev/certs/errors"
+)
+
+var errLimitSize = errors.New("limit must be between 1 and 100")
+
+type listEventsReq struct {
+	audit.Filter
+}
+
+func (req listEventsReq) validate() error {
+	if req.Limit == 0 || req.Limit > maxLimit {
+		return errors.Wrap(certs.ErrMalformedEntity, errLimitSize)
+	}
+
+	return nil
+}
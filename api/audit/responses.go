@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/hantdev/certs/audit"
+)
+
+type listEventsRes struct {
+	audit.Page
+}
+
+func (res listEventsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res listEventsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res listEventsRes) Empty() bool {
+	return false
+}
+
+type verifyRes struct {
+	audit.Verification
+}
+
+func (res verifyRes) Code() int {
+	return http.StatusOK
+}
+
+func (res verifyRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res verifyRes) Empty() bool {
+	return false
+}
@@ -0,0 +1,127 @@
+// Package audit contains the HTTP transport of the audit log API.
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/certs"
+	httpapi "github.com/hantdev/certs/api/http"
+	"github.com/hantdev/certs/audit"
+	"github.com/hantdev/certs/auth"
+	"github.com/hantdev/certs/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	actorKey  = "actor"
+	actionKey = "action"
+	serialKey = "serial_number"
+	entityKey = "entity_id"
+	resultKey = "result"
+	fromKey   = "from"
+	toKey     = "to"
+	offsetKey = "offset"
+	limitKey  = "limit"
+	defLimit  = 10
+	maxLimit  = 100
+	defOffset = 0
+)
+
+// MakeHandler returns a HTTP handler for the audit log endpoints. Unless
+// authn is nil, callers must authenticate as admins or auditors.
+func MakeHandler(svc audit.Service, authn auth.Authenticator, logger *slog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(loggingErrorEncoder(logger, httpapi.EncodeError)),
+	}
+
+	r := chi.NewRouter()
+	r.Route("/audit", func(r chi.Router) {
+		r.Use(auth.Authenticate(authn, httpapi.EncodeError))
+		r.Use(auth.Authorize(httpapi.EncodeError, auth.RoleAdmin, auth.RoleAuditor))
+
+		r.Get("/events", otelhttp.NewHandler(kithttp.NewServer(
+			listEventsEndpoint(svc),
+			decodeListEvents,
+			httpapi.EncodeResponse,
+			opts...,
+		), "list_audit_events").ServeHTTP)
+		r.Get("/verify", otelhttp.NewHandler(kithttp.NewServer(
+			verifyEndpoint(svc),
+			kithttp.NopRequestDecoder,
+			httpapi.EncodeResponse,
+			opts...,
+		), "verify_audit_log").ServeHTTP)
+	})
+
+	return r
+}
+
+func decodeListEvents(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	req := listEventsReq{
+		Filter: audit.Filter{
+			Actor:        q.Get(actorKey),
+			Action:       audit.Action(q.Get(actionKey)),
+			SerialNumber: q.Get(serialKey),
+			EntityID:     q.Get(entityKey),
+			Result:       audit.Result(q.Get(resultKey)),
+			Offset:       defOffset,
+			Limit:        defLimit,
+		},
+	}
+	var err error
+	if req.From, err = readTime(q.Get(fromKey)); err != nil {
+		return nil, err
+	}
+	if req.To, err = readTime(q.Get(toKey)); err != nil {
+		return nil, err
+	}
+	if req.Offset, err = readNum(q.Get(offsetKey), defOffset); err != nil {
+		return nil, err
+	}
+	if req.Limit, err = readNum(q.Get(limitKey), defLimit); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func readTime(val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, errors.Wrap(httpapi.ErrInvalidQueryParams, err)
+	}
+
+	return t, nil
+}
+
+func readNum(val string, def uint64) (uint64, error) {
+	if val == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(httpapi.ErrInvalidQueryParams, err)
+	}
+
+	return n, nil
+}
+
+// loggingErrorEncoder logs the errors which are not caused by a malformed request.
+func loggingErrorEncoder(logger *slog.Logger, enc kithttp.ErrorEncoder) kithttp.ErrorEncoder {
+	return func(ctx context.Context, err error, w http.ResponseWriter) {
+		if !errors.Contains(err, httpapi.ErrInvalidQueryParams) && !errors.Contains(err, certs.ErrMalformedEntity) {
+			logger.Error(err.Error())
+		}
+		enc(ctx, err, w)
+	}
+}
@@ -0,0 +1,107 @@
+package est
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/certs/est"
+)
+
+type labelReq struct {
+	label string
+}
+
+type enrollReq struct {
+	client est.Client
+	label  string
+	csr    []byte
+}
+
+type certsRes struct {
+	certs []*x509.Certificate
+	// ca marks the CA certificates, which are not a certs-only response.
+	ca bool
+}
+
+func (res certsRes) contentType() string {
+	if res.ca {
+		return PKCS7Type
+	}
+
+	return CertsOnlyType
+}
+
+type csrAttrsRes struct {
+	attrs []byte
+}
+
+type serverKeyGenRes struct {
+	cert *x509.Certificate
+	key  []byte
+}
+
+func caCertsEndpoint(svc est.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(labelReq)
+
+		cas, err := svc.CACerts(ctx, req.label)
+		if err != nil {
+			return nil, err
+		}
+
+		return certsRes{certs: cas, ca: true}, nil
+	}
+}
+
+func csrAttrsEndpoint(svc est.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(labelReq)
+
+		attrs, err := svc.CSRAttrs(ctx, req.label)
+		if err != nil {
+			return nil, err
+		}
+
+		return csrAttrsRes{attrs: attrs}, nil
+	}
+}
+
+func enrollEndpoint(svc est.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(enrollReq)
+
+		cert, err := svc.Enroll(ctx, req.client, req.label, req.csr)
+		if err != nil {
+			return nil, err
+		}
+
+		return certsRes{certs: []*x509.Certificate{cert}}, nil
+	}
+}
+
+func reenrollEndpoint(svc est.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(enrollReq)
+
+		cert, err := svc.Reenroll(ctx, req.client, req.label, req.csr)
+		if err != nil {
+			return nil, err
+		}
+
+		return certsRes{certs: []*x509.Certificate{cert}}, nil
+	}
+}
+
+func serverKeyGenEndpoint(svc est.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(enrollReq)
+
+		cert, key, err := svc.ServerKeyGen(ctx, req.client, req.label, req.csr)
+		if err != nil {
+			return nil, err
+		}
+
+		return serverKeyGenRes{cert: cert, key: key}, nil
+	}
+}
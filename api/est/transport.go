@@ -0,0 +1,240 @@
+// Package est contains the HTTP transport of the EST (RFC 7030) server.
+package est
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/est"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	// PKCS7Type and CertsOnlyType are the content types of the CA
+	// certificates and of the issued certificates.
+	PKCS7Type     = "application/pkcs7-mime"
+	CertsOnlyType = "application/pkcs7-mime; smime-type=certs-only"
+	// CSRAttrsType is the content type of the CSR attributes.
+	CSRAttrsType = "application/csrattrs"
+	// PKCS8Type is the content type of the server generated private key.
+	PKCS8Type = "application/pkcs8"
+
+	labelKey = "label"
+	realm    = `Basic realm="est"`
+
+	// maxRequestSize limits the size of the base64 encoded CSR.
+	maxRequestSize = 1 << 16
+)
+
+var errInvalidBase64 = errors.New("request body is not base64 encoded")
+
+// MakeHandler returns a HTTP handler for the EST endpoints. The endpoints
+// are served below the well-known path and, to select the issuer, below a
+// CA label named after it.
+func MakeHandler(svc est.Service, logger *slog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(loggingErrorEncoder(logger, EncodeError)),
+	}
+
+	routes := func(r chi.Router) {
+		r.Get("/cacerts", otelhttp.NewHandler(kithttp.NewServer(
+			caCertsEndpoint(svc),
+			decodeLabel,
+			encodeCertsResponse,
+			opts...,
+		), "est_cacerts").ServeHTTP)
+		r.Get("/csrattrs", otelhttp.NewHandler(kithttp.NewServer(
+			csrAttrsEndpoint(svc),
+			decodeLabel,
+			encodeCSRAttrsResponse,
+			opts...,
+		), "est_csrattrs").ServeHTTP)
+		r.Post("/simpleenroll", otelhttp.NewHandler(kithttp.NewServer(
+			enrollEndpoint(svc),
+			decodeEnroll,
+			encodeCertsResponse,
+			opts...,
+		), "est_simpleenroll").ServeHTTP)
+		r.Post("/simplereenroll", otelhttp.NewHandler(kithttp.NewServer(
+			reenrollEndpoint(svc),
+			decodeEnroll,
+			encodeCertsResponse,
+			opts...,
+		), "est_simplereenroll").ServeHTTP)
+		r.Post("/serverkeygen", otelhttp.NewHandler(kithttp.NewServer(
+			serverKeyGenEndpoint(svc),
+			decodeEnroll,
+			encodeServerKeyGenResponse,
+			opts...,
+		), "est_serverkeygen").ServeHTTP)
+	}
+
+	r := chi.NewRouter()
+	r.Route(est.WellKnownPath, func(r chi.Router) {
+		r.Group(routes)
+		r.Route("/{label}", routes)
+	})
+
+	return r
+}
+
+func decodeLabel(_ context.Context, r *http.Request) (interface{}, error) {
+	return labelReq{label: chi.URLParam(r, labelKey)}, nil
+}
+
+func decodeEnroll(_ context.Context, r *http.Request) (interface{}, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		return nil, errors.Wrap(est.ErrMalformed, err)
+	}
+	csr, err := decodeBase64(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req := enrollReq{
+		client: client(r),
+		label:  chi.URLParam(r, labelKey),
+		csr:    csr,
+	}
+
+	return req, nil
+}
+
+// client returns the client identified by its TLS certificate and basic credentials.
+func client(r *http.Request) est.Client {
+	var c est.Client
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		c.Certificate = r.TLS.PeerCertificates[0]
+	}
+	c.Username, c.Password, _ = r.BasicAuth()
+
+	return c
+}
+
+// decodeBase64 decodes the base64 body, which may be broken into lines.
+func decodeBase64(body []byte) ([]byte, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(body)), ""))
+	if err != nil || len(der) == 0 {
+		return nil, errors.Wrap(est.ErrMalformed, errInvalidBase64)
+	}
+
+	return der, nil
+}
+
+// encodeBase64 encodes the DER data in base64 lines of 64 characters.
+func encodeBase64(der []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(der)
+	var buf bytes.Buffer
+	for len(encoded) > 64 {
+		buf.WriteString(encoded[:64] + "\r\n")
+		encoded = encoded[64:]
+	}
+	buf.WriteString(encoded + "\r\n")
+
+	return buf.Bytes()
+}
+
+func encodeCertsResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(certsRes)
+	der, err := est.MarshalCertsOnly(res.certs)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", res.contentType())
+	w.Header().Set("Content-Transfer-Encoding", "base64")
+	_, err = w.Write(encodeBase64(der))
+
+	return err
+}
+
+func encodeCSRAttrsResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(csrAttrsRes)
+	w.Header().Set("Content-Type", CSRAttrsType)
+	w.Header().Set("Content-Transfer-Encoding", "base64")
+	_, err := w.Write(encodeBase64(res.attrs))
+
+	return err
+}
+
+// encodeServerKeyGenResponse writes the private key and the certificate as
+// the parts of a multipart response.
+func encodeServerKeyGenResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(serverKeyGenRes)
+	der, err := est.MarshalCertsOnly([]*x509.Certificate{res.cert})
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	parts := []struct {
+		contentType string
+		body        []byte
+	}{
+		{PKCS8Type, res.key},
+		{CertsOnlyType, der},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := pw.Write(encodeBase64(p.body)); err != nil {
+			return err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	_, err = w.Write(buf.Bytes())
+
+	return err
+}
+
+// EncodeError encodes the error as a plain text response.
+func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	switch {
+	case errors.Contains(err, est.ErrUnauthorized):
+		w.Header().Set("WWW-Authenticate", realm)
+		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Contains(err, est.ErrMalformed):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Contains(err, est.ErrNotFound),
+		errors.Contains(err, est.ErrNotSupported):
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		// Internal errors are not disclosed to clients.
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, "internal server error\n")
+		return
+	}
+	_, _ = io.WriteString(w, err.Error()+"\n")
+}
+
+func loggingErrorEncoder(logger *slog.Logger, enc kithttp.ErrorEncoder) kithttp.ErrorEncoder {
+	return func(ctx context.Context, err error, w http.ResponseWriter) {
+		if !errors.Contains(err, est.ErrUnauthorized) && !errors.Contains(err, est.ErrMalformed) &&
+			!errors.Contains(err, est.ErrNotFound) && !errors.Contains(err, est.ErrNotSupported) {
+			logger.Error(err.Error())
+		}
+		enc(ctx, err, w)
+	}
+}
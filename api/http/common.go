@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/auth"
 	"github.com/hantdev/certs/errors"
 )
 
@@ -13,6 +14,11 @@ const (
 	// ContentType represents JSON content type.
 	ContentType = "application/json"
 	OCSPType    = "application/ocsp-response"
+	// CertType and CRLType represent DER encoded certificate and CRL content types.
+	CertType = "application/pkix-cert"
+	CRLType  = "application/pkix-crl"
+	// PEMType represents PEM encoded content type.
+	PEMType = "application/x-pem-file"
 )
 
 // Response contains HTTP response specific methods.
@@ -36,13 +42,24 @@ func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
 
 	w.Header().Set("Content-Type", ContentType)
 	switch {
-	case errors.Contains(err, certs.ErrCertExpired):
+	case errors.Contains(err, auth.ErrUnauthenticated):
+		err = unwrap(err)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Contains(err, auth.ErrForbidden):
+		err = unwrap(err)
+		w.WriteHeader(http.StatusForbidden)
+	case errors.Contains(err, certs.ErrCertExpired),
+		errors.Contains(err, certs.ErrProfileReadOnly):
 		err = unwrap(err)
 		w.WriteHeader(http.StatusForbidden)
 
 	case errors.Contains(err, certs.ErrCertRevoked):
 		err = unwrap(err)
 		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Contains(err, certs.ErrPolicyViolation):
+		err = policyError(err)
+		w.WriteHeader(http.StatusUnprocessableEntity)
 	case errors.Contains(err, certs.ErrMalformedEntity),
 		errors.Contains(err, ErrMissingEntityID),
 		errors.Contains(err, ErrEmptySerialNo),
@@ -57,17 +74,28 @@ func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
 	case errors.Contains(err, certs.ErrCreateEntity),
 		errors.Contains(err, certs.ErrUpdateEntity),
 		errors.Contains(err, certs.ErrViewEntity),
-		errors.Contains(err, certs.ErrGetToken):
+		errors.Contains(err, certs.ErrGetToken),
+		errors.Contains(err, certs.ErrCAKeyUnavailable),
+		errors.Contains(err, certs.ErrCAKeyNotExportable),
+		errors.Contains(err, certs.ErrIssuerRetired),
+		errors.Contains(err, certs.ErrKeyStoreNotConfigured):
 		err = unwrap(err)
 		w.WriteHeader(http.StatusUnprocessableEntity)
 
 	case errors.Contains(err, certs.ErrNotFound),
 		errors.Contains(err, certs.ErrRootCANotFound),
-		errors.Contains(err, certs.ErrIntermediateCANotFound):
+		errors.Contains(err, certs.ErrIntermediateCANotFound),
+		errors.Contains(err, certs.ErrIssuerNotFound),
+		errors.Contains(err, certs.ErrCANotFound),
+		errors.Contains(err, certs.ErrDeltaCRLDisabled),
+		errors.Contains(err, certs.ErrProfileNotFound):
 		err = unwrap(err)
 		w.WriteHeader(http.StatusNotFound)
 
-	case errors.Contains(err, certs.ErrConflict):
+	case errors.Contains(err, certs.ErrConflict),
+		errors.Contains(err, certs.ErrCertAlreadyRevoked),
+		errors.Contains(err, certs.ErrCertNotOnHold),
+		errors.Contains(err, certs.ErrCSRNotPending):
 		err = unwrap(err)
 		w.WriteHeader(http.StatusConflict)
 
@@ -86,6 +114,17 @@ func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
 	}
 }
 
+// policyError returns the policy violation from the error chain so that the
+// response names the rule that failed.
+func policyError(err error) error {
+	for e, ok := err.(errors.Error); ok && e != nil; e = e.Err() {
+		if pe, ok := e.(*certs.PolicyError); ok {
+			return pe
+		}
+	}
+	return unwrap(err)
+}
+
 func unwrap(err error) error {
 	wrapper, err := errors.Unwrap(err)
 	if wrapper != nil {
@@ -2,15 +2,15 @@ package http
 
 import (
 	"context"
-	"crypto"
 	"crypto/x509"
 	"encoding/pem"
-	"math/rand"
-	"strings"
+	"fmt"
 	"time"
 
-	"github.com/hantdev/certs"
 	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/auth"
+	"github.com/hantdev/certs/errors"
 	"golang.org/x/crypto/ocsp"
 )
 
@@ -20,6 +20,9 @@ func renewCertEndpoint(svc certs.Service) endpoint.Endpoint {
 		if err := req.validate(); err != nil {
 			return renewCertRes{}, err
 		}
+		if err := authorizeCert(ctx, svc, req.id); err != nil {
+			return renewCertRes{}, err
+		}
 
 		if err = svc.RenewCert(ctx, req.id); err != nil {
 			return renewCertRes{}, err
@@ -31,12 +34,23 @@ func renewCertEndpoint(svc certs.Service) endpoint.Endpoint {
 
 func revokeCertEndpoint(svc certs.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		req := request.(viewReq)
+		req := request.(revokeCertReq)
 		if err := req.validate(); err != nil {
 			return revokeCertRes{revoked: false}, err
 		}
+		if err := authorizeCert(ctx, svc, req.id); err != nil {
+			return revokeCertRes{revoked: false}, err
+		}
+		reason, err := certs.ParseRevocationReason(req.Reason)
+		if err != nil {
+			return revokeCertRes{revoked: false}, errors.Wrap(certs.ErrMalformedEntity, err)
+		}
+		var invalidityDate time.Time
+		if req.InvalidityDate != nil {
+			invalidityDate = *req.InvalidityDate
+		}
 
-		if err = svc.RevokeCert(ctx, req.id); err != nil {
+		if err = svc.RevokeCert(ctx, req.id, reason, invalidityDate); err != nil {
 			return revokeCertRes{revoked: false}, err
 		}
 
@@ -44,12 +58,51 @@ func revokeCertEndpoint(svc certs.Service) endpoint.Endpoint {
 	}
 }
 
+func holdCertEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(viewReq)
+		if err := req.validate(); err != nil {
+			return holdCertRes{}, err
+		}
+		if err := authorizeCert(ctx, svc, req.id); err != nil {
+			return holdCertRes{}, err
+		}
+
+		if err = svc.HoldCert(ctx, req.id); err != nil {
+			return holdCertRes{}, err
+		}
+
+		return holdCertRes{updated: true}, nil
+	}
+}
+
+func releaseCertEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(viewReq)
+		if err := req.validate(); err != nil {
+			return holdCertRes{}, err
+		}
+		if err := authorizeCert(ctx, svc, req.id); err != nil {
+			return holdCertRes{}, err
+		}
+
+		if err = svc.ReleaseCert(ctx, req.id); err != nil {
+			return holdCertRes{}, err
+		}
+
+		return holdCertRes{updated: true}, nil
+	}
+}
+
 func deleteCertEndpoint(svc certs.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(deleteReq)
 		if err := req.validate(); err != nil {
 			return deleteCertRes{deleted: false}, err
 		}
+		if err := auth.AuthorizeEntity(ctx, req.entityID); err != nil {
+			return deleteCertRes{deleted: false}, err
+		}
 
 		if err = svc.RemoveCert(ctx, req.entityID); err != nil {
 			return deleteCertRes{deleted: false}, err
@@ -65,6 +118,9 @@ func requestCertDownloadTokenEndpoint(svc certs.Service) endpoint.Endpoint {
 		if err := req.validate(); err != nil {
 			return requestCertDownloadTokenRes{}, err
 		}
+		if err := authorizeCert(ctx, svc, req.id); err != nil {
+			return requestCertDownloadTokenRes{}, err
+		}
 
 		token, err := svc.RetrieveCertDownloadToken(ctx, req.id)
 		if err != nil {
@@ -81,6 +137,9 @@ func downloadCertEndpoint(svc certs.Service) endpoint.Endpoint {
 		if err := req.validate(); err != nil {
 			return fileDownloadRes{}, err
 		}
+		if err := authorizeCert(ctx, svc, req.id); err != nil {
+			return fileDownloadRes{}, err
+		}
 		cert, ca, err := svc.RetrieveCert(ctx, req.token, req.id)
 		if err != nil {
 			return fileDownloadRes{}, err
@@ -102,8 +161,11 @@ func issueCertEndpoint(svc certs.Service) endpoint.Endpoint {
 		if err := req.validate(); err != nil {
 			return issueCertRes{}, err
 		}
+		if err := auth.AuthorizeEntity(ctx, req.entityID); err != nil {
+			return issueCertRes{}, err
+		}
 
-		cert, err := svc.IssueCert(ctx, req.entityID, req.TTL, req.IpAddrs, req.Options)
+		cert, err := svc.IssueCert(ctx, req.entityID, req.issuer, req.profile, req.TTL, req.IpAddrs, req.Options)
 		if err != nil {
 			return issueCertRes{}, err
 		}
@@ -125,6 +187,9 @@ func listCertsEndpoint(svc certs.Service) endpoint.Endpoint {
 		if err := req.validate(); err != nil {
 			return listCertsRes{}, err
 		}
+		if req.pm.EntityID, err = auth.ScopeEntity(ctx, req.pm.EntityID); err != nil {
+			return listCertsRes{}, err
+		}
 
 		certPage, err := svc.ListCerts(ctx, req.pm)
 		if err != nil {
@@ -156,19 +221,31 @@ func viewCertEndpoint(svc certs.Service) endpoint.Endpoint {
 		if err := req.validate(); err != nil {
 			return viewCertRes{}, err
 		}
+		if err := authorizeCert(ctx, svc, req.id); err != nil {
+			return viewCertRes{}, err
+		}
 		cert, err := svc.ViewCert(ctx, req.id)
 		if err != nil {
 			return viewCertRes{}, err
 		}
 
-		return viewCertRes{
+		res := viewCertRes{
 			SerialNumber: cert.SerialNumber,
 			Certificate:  string(cert.Certificate),
 			Key:          string(cert.Key),
 			Revoked:      cert.Revoked,
 			ExpiryTime:   cert.ExpiryTime,
 			EntityID:     cert.EntityID,
-		}, nil
+		}
+		if cert.Revoked {
+			res.RevocationReason = cert.RevocationReason.String()
+			res.RevocationTime = &cert.RevocationTime
+			if !cert.InvalidityDate.IsZero() {
+				res.InvalidityDate = &cert.InvalidityDate
+			}
+		}
+
+		return res, nil
 	}
 }
 
@@ -179,63 +256,52 @@ func ocspEndpoint(svc certs.Service) endpoint.Endpoint {
 			return nil, err
 		}
 
-		cert, status, issuerCert, err := svc.OCSP(ctx, req.req.SerialNumber.String())
+		// OCSP clients understand OCSP error responses only, so a
+		// failure to look up or sign the status is answered with one.
+		cert, status, issuer, err := svc.OCSP(ctx, req.req.SerialNumber.String())
 		if err != nil {
-			return nil, err
+			return ocspRes{response: ocsp.InternalErrorErrorResponse}, nil
 		}
-
-		switch strings.ToUpper(req.statusParam) {
-		case "REVOKE":
-			status = ocsp.Revoked
-		case "GOOD":
-			status = ocsp.Good
-		case "SERVERFAILED":
-			status = ocsp.ServerFailed
-		case "RANDOM":
-			r := rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
-			status = r.Intn(ocsp.ServerFailed)
+		if issuer == nil {
+			return nil, certs.ErrIntermediateCANotFound
 		}
 
 		template := ocsp.Response{
 			Status:       status,
 			SerialNumber: req.req.SerialNumber,
-			ThisUpdate:   time.Now(),
-			NextUpdate:   time.Now(),
 			IssuerHash:   req.req.HashAlgorithm,
 		}
 		if template.Status == ocsp.Revoked {
 			template.RevokedAt = time.Now()
 		}
-		var signer crypto.Signer
-
 		if cert != nil {
 			if cert.Revoked {
-				template.RevokedAt = cert.ExpiryTime
-				template.RevocationReason = ocsp.Unspecified
+				template.RevokedAt = cert.RevocationTime
+				template.RevocationReason = int(cert.RevocationReason)
 			}
 			pemBlock, _ := pem.Decode(cert.Certificate)
 			parsedCert, err := x509.ParseCertificate(pemBlock.Bytes)
 			if err != nil {
 				return nil, err
 			}
-			template.Certificate = parsedCert
-			keyBlock, _ := pem.Decode(cert.Key)
-			privKey, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
-			if err != nil {
-				return nil, err
-			}
-			signer = privKey
 			if !parsedCert.NotAfter.After(time.Now()) {
 				template.Status = ocsp.Revoked
 				template.RevocationReason = ocsp.CessationOfOperation
+				if template.RevokedAt.IsZero() {
+					template.RevokedAt = parsedCert.NotAfter
+				}
 			}
 		}
 
-		return ocspRes{
-			template:   template,
-			issuerCert: issuerCert,
-			signer:     signer,
-		}, nil
+		res, err := svc.SignOCSP(ctx, issuer.SerialNumber, template, req.nonce)
+		if err != nil {
+			return ocspRes{response: ocsp.InternalErrorErrorResponse}, nil
+		}
+		if !req.cacheable {
+			return ocspRes{response: res.DER}, nil
+		}
+
+		return ocspRes{response: res.DER, headers: ocspHeaders(res)}, nil
 	}
 }
 
@@ -245,7 +311,7 @@ func generateCRLEndpoint(svc certs.Service) endpoint.Endpoint {
 		if err := req.validate(); err != nil {
 			return crlRes{}, err
 		}
-		crlBytes, err := svc.GenerateCRL(ctx, req.certtype)
+		crlBytes, err := svc.GenerateCRL(ctx, req.certtype, req.issuer)
 		if err != nil {
 			return crlRes{}, err
 		}
@@ -256,9 +322,57 @@ func generateCRLEndpoint(svc certs.Service) endpoint.Endpoint {
 	}
 }
 
+func viewCACertEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(viewReq)
+		if err := req.validate(); err != nil {
+			return derRes{}, err
+		}
+		cert, err := svc.ViewCACert(ctx, req.id)
+		if err != nil {
+			return derRes{}, err
+		}
+		block, _ := pem.Decode(cert.Certificate)
+		if block == nil {
+			return derRes{}, errors.Wrap(certs.ErrViewEntity, errors.New("failed to decode CA certificate PEM"))
+		}
+
+		return derRes{
+			Data:        block.Bytes,
+			ContentType: CertType,
+		}, nil
+	}
+}
+
+func generateCACRLEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(caCRLReq)
+		if err := req.validate(); err != nil {
+			return derRes{}, err
+		}
+		crl, err := svc.GenerateCACRL(ctx, req.id, req.delta)
+		if err != nil {
+			return derRes{}, err
+		}
+
+		res := derRes{
+			Data:        crl.DER,
+			ContentType: CRLType,
+			Headers:     crlHeaders(crl),
+		}
+		if req.format == pemFormat {
+			res.Data, res.ContentType = crl.PEM(), PEMType
+		}
+
+		return res, nil
+	}
+}
+
 func getDownloadCATokenEndpoint(svc certs.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		token, err := svc.RetrieveCAToken(ctx)
+		req := request.(issuerReq)
+
+		token, err := svc.RetrieveCAToken(ctx, req.Name)
 		if err != nil {
 			return requestCertDownloadTokenRes{}, err
 		}
@@ -271,19 +385,20 @@ func downloadCAEndpoint(svc certs.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(downloadReq)
 		if err := req.validate(); err != nil {
-			return fileDownloadRes{}, err
+			return derRes{}, err
 		}
 
 		cert, err := svc.GetChainCA(ctx, req.token)
 		if err != nil {
-			return fileDownloadRes{}, err
+			return derRes{}, err
 		}
 
-		return fileDownloadRes{
-			Certificate: cert.Certificate,
-			PrivateKey:  cert.Key,
-			Filename:    "ca.zip",
-			ContentType: "application/zip",
+		return derRes{
+			Data:        cert.Certificate,
+			ContentType: PEMType,
+			Headers: map[string]string{
+				"Content-Disposition": "attachment; filename=ca.crt",
+			},
 		}, nil
 	}
 }
@@ -302,19 +417,94 @@ func viewCAEndpoint(svc certs.Service) endpoint.Endpoint {
 
 		return viewCertRes{
 			Certificate: string(cert.Certificate),
-			Key:         string(cert.Key),
 		}, nil
 	}
 }
 
-func issueFromCSREndpoint(svc certs.Service) endpoint.Endpoint {
+func viewCAChainEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(issuerReq)
+
+		cert, err := svc.ViewCAChain(ctx, req.Name)
+		if err != nil {
+			return derRes{}, err
+		}
+
+		return derRes{
+			Data:        cert.Certificate,
+			ContentType: PEMType,
+		}, nil
+	}
+}
+
+func trustBundleEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (response interface{}, err error) {
+		bundle, err := svc.TrustBundle(ctx)
+		if err != nil {
+			return derRes{}, err
+		}
+
+		return derRes{
+			Data:        bundle,
+			ContentType: PEMType,
+		}, nil
+	}
+}
+
+// exportCAEndpoint returns the encrypted backup of a CA as an attachment.
+func exportCAEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(exportCAReq)
+		if err := req.validate(); err != nil {
+			return derRes{}, err
+		}
+
+		backup, err := svc.ExportCA(ctx, req.id, req.Passphrase)
+		if err != nil {
+			return derRes{}, err
+		}
+
+		return derRes{
+			Data:        backup,
+			ContentType: PEMType,
+			Headers: map[string]string{
+				"Content-Disposition": fmt.Sprintf("attachment; filename=ca-%s.backup.pem", req.id),
+				"Cache-Control":       "no-store",
+			},
+		}, nil
+	}
+}
+
+// submitCSREndpoint submits the CSR to the approval queue. The certificate is
+// returned if the CSR was auto-approved, the pending CSR otherwise.
+func submitCSREndpoint(svc certs.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(IssueFromCSRReq)
 		if err := req.validate(); err != nil {
 			return issueFromCSRRes{}, err
 		}
+		if err := auth.AuthorizeEntity(ctx, req.entityID); err != nil {
+			return issueFromCSRRes{}, err
+		}
 
-		cert, err := svc.IssueFromCSR(ctx, req.entityID, req.ttl, certs.CSR{CSR: []byte(req.CSR)})
+		csr, err := svc.SubmitCSR(ctx, certs.CSR{
+			EntityID:  req.entityID,
+			Issuer:    req.issuer,
+			Profile:   req.profile,
+			TTL:       req.ttl,
+			CSR:       []byte(req.CSR),
+			Requester: req.requester,
+		})
+		if err != nil {
+			return issueFromCSRRes{}, err
+		}
+		if csr.Status != certs.CSRIssued {
+			res := newCSRRes(csr)
+			res.accepted = true
+			return res, nil
+		}
+
+		cert, err := svc.ViewCert(ctx, csr.SerialNumber)
 		if err != nil {
 			return issueFromCSRRes{}, err
 		}
@@ -325,6 +515,323 @@ func issueFromCSREndpoint(svc certs.Service) endpoint.Endpoint {
 			Revoked:      cert.Revoked,
 			ExpiryTime:   cert.ExpiryTime,
 			EntityID:     cert.EntityID,
+			CSRID:        csr.ID,
+			Status:       csr.Status,
+		}, nil
+	}
+}
+
+func listCSRsEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(listCSRsReq)
+		if err := req.validate(); err != nil {
+			return listCSRsRes{}, err
+		}
+		if req.pm.EntityID, err = auth.ScopeEntity(ctx, req.pm.EntityID); err != nil {
+			return listCSRsRes{}, err
+		}
+
+		page, err := svc.ListCSRs(ctx, req.pm)
+		if err != nil {
+			return listCSRsRes{}, err
+		}
+
+		res := listCSRsRes{
+			Total:  page.Total,
+			Offset: page.Offset,
+			Limit:  page.Limit,
+			CSRs:   []csrRes{},
+		}
+		for _, csr := range page.CSRs {
+			res.CSRs = append(res.CSRs, newCSRRes(csr))
+		}
+
+		return res, nil
+	}
+}
+
+func viewCSREndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(viewReq)
+		if req.id == "" {
+			return csrRes{}, errors.Wrap(certs.ErrMalformedEntity, ErrMissingCSRID)
+		}
+
+		csr, err := svc.ViewCSR(ctx, req.id)
+		if err != nil {
+			return csrRes{}, err
+		}
+		if err := auth.AuthorizeEntity(ctx, csr.EntityID); err != nil {
+			return csrRes{}, err
+		}
+
+		return newCSRRes(csr), nil
+	}
+}
+
+func approveCSREndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(approveCSRReq)
+		if err := req.validate(); err != nil {
+			return csrRes{}, err
+		}
+		if err := authorizeCSR(ctx, svc, req.id); err != nil {
+			return csrRes{}, err
+		}
+
+		csr, err := svc.ApproveCSR(ctx, req.id, req.ttl, req.profile)
+		if err != nil {
+			return csrRes{}, err
+		}
+
+		return newCSRRes(csr), nil
+	}
+}
+
+func rejectCSREndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(rejectCSRReq)
+		if err := req.validate(); err != nil {
+			return csrRes{}, err
+		}
+		if err := authorizeCSR(ctx, svc, req.id); err != nil {
+			return csrRes{}, err
+		}
+
+		csr, err := svc.RejectCSR(ctx, req.id, req.Reason)
+		if err != nil {
+			return csrRes{}, err
+		}
+
+		return newCSRRes(csr), nil
+	}
+}
+
+func importCAEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(importCAReq)
+		if err := req.validate(); err != nil {
+			return caRes{}, err
+		}
+
+		certType, err := certs.CertTypeFromString(req.Type)
+		if err != nil {
+			return caRes{}, errors.Wrap(certs.ErrMalformedEntity, err)
+		}
+
+		ca, err := svc.ImportCA(ctx, certs.CAImport{
+			Type:        certType,
+			Name:        req.Name,
+			Certificate: []byte(req.Certificate),
+			Key:         []byte(req.PrivateKey),
+			KeyRef:      req.KeyRef,
+		})
+		if err != nil {
+			return caRes{}, err
+		}
+
+		return caRes{
+			SerialNumber: ca.SerialNumber,
+			Certificate:  string(ca.Certificate),
+			ExpiryTime:   ca.ExpiryTime,
+			Type:         ca.Type.String(),
 		}, nil
 	}
+}
+
+func generateIntermediateCSREndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		csr, err := svc.GenerateIntermediateCSR(ctx)
+		if err != nil {
+			return intermediateCSRRes{}, err
+		}
+
+		return intermediateCSRRes{CSR: string(csr.CSR)}, nil
+	}
+}
+
+func installIntermediateCAEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(installCAReq)
+		if err := req.validate(); err != nil {
+			return caRes{}, err
+		}
+
+		ca, err := svc.InstallIntermediateCA(ctx, []byte(req.Certificate))
+		if err != nil {
+			return caRes{}, err
+		}
+
+		return caRes{
+			SerialNumber: ca.SerialNumber,
+			Certificate:  string(ca.Certificate),
+			ExpiryTime:   ca.ExpiryTime,
+			Type:         ca.Type.String(),
+		}, nil
+	}
+}
+
+func createIssuerEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(issuerReq)
+		if err := req.validate(); err != nil {
+			return issuerRes{}, err
+		}
+
+		issuer, err := svc.CreateIssuer(ctx, req.Name)
+		if err != nil {
+			return issuerRes{}, err
+		}
+
+		res := toIssuerRes(issuer)
+		res.created = true
+
+		return res, nil
+	}
+}
+
+func listIssuersEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		issuers, err := svc.ListIssuers(ctx)
+		if err != nil {
+			return listIssuersRes{}, err
+		}
+
+		res := listIssuersRes{Issuers: []issuerRes{}}
+		for _, issuer := range issuers {
+			res.Issuers = append(res.Issuers, toIssuerRes(issuer))
+		}
+
+		return res, nil
+	}
+}
+
+func retireIssuerEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(issuerReq)
+		if err := req.validate(); err != nil {
+			return retireIssuerRes{retired: false}, err
+		}
+
+		if err := svc.RetireIssuer(ctx, req.Name); err != nil {
+			return retireIssuerRes{retired: false}, err
+		}
+
+		return retireIssuerRes{retired: true}, nil
+	}
+}
+
+func toIssuerRes(issuer certs.Issuer) issuerRes {
+	return issuerRes{
+		Name:         issuer.Name,
+		SerialNumber: issuer.SerialNumber,
+		Certificate:  string(issuer.Certificate),
+		ExpiryTime:   issuer.ExpiryTime,
+		Retired:      issuer.Retired,
+	}
+}
+
+func createProfileEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(profileReq)
+		if err := req.validate(); err != nil {
+			return profileRes{}, err
+		}
+
+		profile, err := svc.CreateProfile(ctx, req.Profile)
+		if err != nil {
+			return profileRes{}, err
+		}
+
+		return profileRes{Profile: profile, created: true}, nil
+	}
+}
+
+func viewProfileEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(profileNameReq)
+		if err := req.validate(); err != nil {
+			return profileRes{}, err
+		}
+
+		profile, err := svc.ViewProfile(ctx, req.name)
+		if err != nil {
+			return profileRes{}, err
+		}
+
+		return profileRes{Profile: profile}, nil
+	}
+}
+
+func listProfilesEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		profiles, err := svc.ListProfiles(ctx)
+		if err != nil {
+			return listProfilesRes{}, err
+		}
+
+		res := listProfilesRes{Profiles: []certs.Profile{}}
+		res.Profiles = append(res.Profiles, profiles...)
+
+		return res, nil
+	}
+}
+
+func updateProfileEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(profileReq)
+		if err := req.validate(); err != nil {
+			return profileRes{}, err
+		}
+
+		profile, err := svc.UpdateProfile(ctx, req.Profile)
+		if err != nil {
+			return profileRes{}, err
+		}
+
+		return profileRes{Profile: profile}, nil
+	}
+}
+
+func removeProfileEndpoint(svc certs.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(profileNameReq)
+		if err := req.validate(); err != nil {
+			return removeProfileRes{removed: false}, err
+		}
+
+		if err := svc.RemoveProfile(ctx, req.name); err != nil {
+			return removeProfileRes{removed: false}, err
+		}
+
+		return removeProfileRes{removed: true}, nil
+	}
+}
+
+// authorizeCert returns an error unless the caller of the context may access
+// the certificate. The entity of the certificate is only looked up for
+// callers limited to some entities.
+func authorizeCert(ctx context.Context, svc certs.Service, serialNumber string) error {
+	if id, ok := auth.FromContext(ctx); !ok || !id.Limited() {
+		return nil
+	}
+	entityID, err := svc.GetEntityID(ctx, serialNumber)
+	if err != nil {
+		return err
+	}
+
+	return auth.AuthorizeEntity(ctx, entityID)
+}
+
+// authorizeCSR returns an error unless the caller of the context may access the CSR.
+func authorizeCSR(ctx context.Context, svc certs.Service, id string) error {
+	if identity, ok := auth.FromContext(ctx); !ok || !identity.Limited() {
+		return nil
+	}
+	csr, err := svc.ViewCSR(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return auth.AuthorizeEntity(ctx, csr.EntityID)
 }
\ No newline at end of file
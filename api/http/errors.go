@@ -33,6 +33,33 @@ var (
 	// ErrMissingCSR indicates missing csr.
 	ErrMissingCSR = errors.New("missing CSR")
 
+	// ErrMissingCSRID indicates missing CSR ID.
+	ErrMissingCSRID = errors.New("missing CSR ID")
+
 	// ErrMissingPrivKey indicates missing csr.
 	ErrMissingPrivKey = errors.New("missing private key")
+
+	// ErrMissingCertificate indicates missing certificate.
+	ErrMissingCertificate = errors.New("missing certificate")
+
+	// ErrInvalidCAType indicates a CA type other than root or intermediate.
+	ErrInvalidCAType = errors.New("invalid CA type, expected RootCA or IntermediateCA")
+
+	// ErrKeyAndKeyRef indicates that both a private key and a key reference were provided.
+	ErrKeyAndKeyRef = errors.New("private key and key reference are mutually exclusive")
+
+	// ErrMissingPassphrase indicates a missing backup passphrase.
+	ErrMissingPassphrase = errors.New("missing passphrase")
+
+	// ErrMissingIssuerName indicates missing issuer name.
+	ErrMissingIssuerName = errors.New("missing issuer name")
+
+	// ErrInvalidIssuerName indicates an issuer name with unsupported characters.
+	ErrInvalidIssuerName = errors.New("invalid issuer name, expected lowercase letters, digits, '-' or '_'")
+
+	// ErrMissingProfileName indicates missing profile name.
+	ErrMissingProfileName = errors.New("missing profile name")
+
+	// ErrInvalidProfileName indicates a profile name with unsupported characters.
+	ErrInvalidProfileName = errors.New("invalid profile name, expected lowercase letters, digits, '-' or '_'")
 )
\ No newline at end of file
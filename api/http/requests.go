@@ -1,11 +1,16 @@
 package http
 
 import (
+	"regexp"
+	"time"
+
 	"github.com/hantdev/certs"
 	"github.com/hantdev/certs/errors"
 	"golang.org/x/crypto/ocsp"
 )
 
+var nameRegExp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
 type downloadReq struct {
 	id    string
 	token string
@@ -29,6 +34,22 @@ func (req viewReq) validate() error {
 	return nil
 }
 
+type revokeCertReq struct {
+	id             string
+	Reason         string     `json:"reason"`
+	InvalidityDate *time.Time `json:"invalidity_date"`
+}
+
+func (req revokeCertReq) validate() error {
+	if req.id == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrEmptySerialNo)
+	}
+	if _, err := certs.ParseRevocationReason(req.Reason); err != nil {
+		return errors.Wrap(certs.ErrMalformedEntity, err)
+	}
+	return nil
+}
+
 type deleteReq struct {
 	entityID string
 }
@@ -42,17 +63,36 @@ func (req deleteReq) validate() error {
 
 type crlReq struct {
 	certtype certs.CertType
+	issuer   string
 }
 
 func (req crlReq) validate() error {
-	if req.certtype != certs.IntermediateCA {
+	if req.certtype != certs.RootCA && req.certtype != certs.IntermediateCA {
 		return errors.Wrap(certs.ErrMalformedEntity, errors.New("invalid CA type"))
 	}
 	return nil
 }
 
+type caCRLReq struct {
+	id     string
+	delta  bool
+	format string
+}
+
+func (req caCRLReq) validate() error {
+	if req.id == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrEmptySerialNo)
+	}
+	if req.format != derFormat && req.format != pemFormat {
+		return errors.Wrap(certs.ErrMalformedEntity, errors.Wrap(ErrInvalidQueryParams, errors.New("format must be der or pem")))
+	}
+	return nil
+}
+
 type issueCertReq struct {
 	entityID string               `json:"-"`
+	issuer   string               `json:"-"`
+	profile  string               `json:"-"`
 	TTL      string               `json:"ttl"`
 	IpAddrs  []string             `json:"ip_addresses"`
 	Options  certs.SubjectOptions `json:"options"`
@@ -74,8 +114,10 @@ func (req listCertsReq) validate() error {
 }
 
 type ocspReq struct {
-	req         *ocsp.Request
-	statusParam string
+	req   *ocsp.Request
+	nonce []byte
+	// cacheable marks GET requests without a nonce, whose responses may be cached.
+	cacheable bool
 }
 
 func (req ocspReq) validate() error {
@@ -86,9 +128,12 @@ func (req ocspReq) validate() error {
 }
 
 type IssueFromCSRReq struct {
-	entityID string
-	ttl      string
-	CSR      string `json:"csr"`
+	entityID  string
+	issuer    string
+	profile   string
+	ttl       string
+	requester certs.Requester
+	CSR       string `json:"csr"`
 }
 
 func (req IssueFromCSRReq) validate() error {
@@ -99,5 +144,132 @@ func (req IssueFromCSRReq) validate() error {
 		return errors.Wrap(certs.ErrMalformedEntity, ErrMissingCSR)
 	}
 
+	return nil
+}
+
+type listCSRsReq struct {
+	pm certs.PageMetadata
+}
+
+func (req listCSRsReq) validate() error {
+	if req.pm.Status != "" {
+		if err := req.pm.Status.Validate(); err != nil {
+			return errors.Wrap(certs.ErrMalformedEntity, err)
+		}
+	}
+	return nil
+}
+
+type approveCSRReq struct {
+	id      string
+	ttl     string
+	profile string
+}
+
+func (req approveCSRReq) validate() error {
+	if req.id == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrMissingCSRID)
+	}
+	return nil
+}
+
+type rejectCSRReq struct {
+	id     string
+	Reason string `json:"reason"`
+}
+
+func (req rejectCSRReq) validate() error {
+	if req.id == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrMissingCSRID)
+	}
+	return nil
+}
+
+type importCAReq struct {
+	Type        string `json:"type"`
+	Name        string `json:"name,omitempty"`
+	Certificate string `json:"certificate"`
+	PrivateKey  string `json:"private_key,omitempty"`
+	KeyRef      string `json:"key_ref,omitempty"`
+}
+
+func (req importCAReq) validate() error {
+	if req.Type != certs.Root && req.Type != certs.Inter {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrInvalidCAType)
+	}
+	if req.Certificate == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrMissingCertificate)
+	}
+	if req.PrivateKey != "" && req.KeyRef != "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrKeyAndKeyRef)
+	}
+	if req.Name != "" && !nameRegExp.MatchString(req.Name) {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrInvalidIssuerName)
+	}
+	return nil
+}
+
+type installCAReq struct {
+	Certificate string `json:"certificate"`
+}
+
+func (req installCAReq) validate() error {
+	if req.Certificate == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrMissingCertificate)
+	}
+	return nil
+}
+
+type exportCAReq struct {
+	id         string
+	Passphrase string `json:"passphrase"`
+}
+
+func (req exportCAReq) validate() error {
+	if req.id == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrEmptySerialNo)
+	}
+	if req.Passphrase == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrMissingPassphrase)
+	}
+	return nil
+}
+
+type issuerReq struct {
+	Name string `json:"name"`
+}
+
+func (req issuerReq) validate() error {
+	if req.Name == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrMissingIssuerName)
+	}
+	if !nameRegExp.MatchString(req.Name) {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrInvalidIssuerName)
+	}
+	return nil
+}
+
+type profileReq struct {
+	certs.Profile
+}
+
+func (req profileReq) validate() error {
+	if req.Name == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrMissingProfileName)
+	}
+	if !nameRegExp.MatchString(req.Name) {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrInvalidProfileName)
+	}
+	return nil
+}
+
+type profileNameReq struct {
+	name string
+}
+
+func (req profileNameReq) validate() error {
+	if req.name == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, ErrMissingProfileName)
+	}
 	return nil
 }
\ No newline at end of file
@@ -1,19 +1,31 @@
 package http
 
 import (
-	"crypto"
-	"crypto/x509"
+	"crypto/sha1"
+	"fmt"
 	"net/http"
 	"time"
 
-	"golang.org/x/crypto/ocsp"
+	"github.com/hantdev/certs"
 )
 
 var (
 	_ Response = (*revokeCertRes)(nil)
+	_ Response = (*holdCertRes)(nil)
 	_ Response = (*issueCertRes)(nil)
 	_ Response = (*renewCertRes)(nil)
 	_ Response = (*ocspRes)(nil)
+	_ Response = (*caRes)(nil)
+	_ Response = (*intermediateCSRRes)(nil)
+	_ Response = (*issuerRes)(nil)
+	_ Response = (*listIssuersRes)(nil)
+	_ Response = (*retireIssuerRes)(nil)
+	_ Response = (*profileRes)(nil)
+	_ Response = (*listProfilesRes)(nil)
+	_ Response = (*removeProfileRes)(nil)
+	_ Response = (*issueFromCSRRes)(nil)
+	_ Response = (*csrRes)(nil)
+	_ Response = (*listCSRsRes)(nil)
 )
 
 type renewCertRes struct {
@@ -56,6 +68,27 @@ func (res revokeCertRes) Empty() bool {
 	return true
 }
 
+// holdCertRes is the response of placing a certificate on hold and of releasing it.
+type holdCertRes struct {
+	updated bool
+}
+
+func (res holdCertRes) Code() int {
+	if res.updated {
+		return http.StatusNoContent
+	}
+
+	return http.StatusUnprocessableEntity
+}
+
+func (res holdCertRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res holdCertRes) Empty() bool {
+	return true
+}
+
 type deleteCertRes struct {
 	deleted bool
 }
@@ -143,6 +176,10 @@ type viewCertRes struct {
 	Revoked      bool      `json:"revoked,omitempty"`
 	ExpiryTime   time.Time `json:"expiry_time,omitempty"`
 	EntityID     string    `json:"entity_id,omitempty"`
+	// RevocationReason, RevocationTime and InvalidityDate are only set for revoked certificates.
+	RevocationReason string     `json:"revocation_reason,omitempty"`
+	RevocationTime   *time.Time `json:"revocation_time,omitempty"`
+	InvalidityDate   *time.Time `json:"invalidity_date,omitempty"`
 }
 
 func (res viewCertRes) Code() int {
@@ -174,9 +211,8 @@ func (res crlRes) Empty() bool {
 }
 
 type ocspRes struct {
-	template   ocsp.Response
-	signer     crypto.Signer
-	issuerCert *x509.Certificate
+	response []byte
+	headers  map[string]string
 }
 
 func (res ocspRes) Code() int {
@@ -184,13 +220,50 @@ func (res ocspRes) Code() int {
 }
 
 func (res ocspRes) Headers() map[string]string {
-	return map[string]string{}
+	if res.headers == nil {
+		return map[string]string{}
+	}
+
+	return res.headers
 }
 
 func (res ocspRes) Empty() bool {
 	return false
 }
 
+// crlHeaders returns the caching headers of a published CRL. Relying parties
+// and proxies may cache it until its next update.
+func crlHeaders(crl certs.CRL) map[string]string {
+	maxAge := max(int(time.Until(crl.NextUpdate).Seconds()), 0)
+
+	return map[string]string{
+		"Cache-Control": fmt.Sprintf("public, max-age=%d", maxAge),
+		"Expires":       crl.NextUpdate.UTC().Format(http.TimeFormat),
+		"Last-Modified": crl.ThisUpdate.UTC().Format(http.TimeFormat),
+		"ETag":          fmt.Sprintf(`"%d"`, crl.Number),
+	}
+}
+
+// ocspHeaders returns the caching headers of RFC 5019 for an OCSP response
+// retrieved with GET. Relying parties and proxies may cache it until its next
+// update.
+func ocspHeaders(res certs.OCSPResponse) map[string]string {
+	maxAge := max(int(time.Until(res.NextUpdate).Seconds()), 0)
+
+	return map[string]string{
+		"Cache-Control": fmt.Sprintf("public, max-age=%d, no-transform, must-revalidate", maxAge),
+		"Expires":       res.NextUpdate.UTC().Format(http.TimeFormat),
+		"Last-Modified": res.ThisUpdate.UTC().Format(http.TimeFormat),
+		"ETag":          fmt.Sprintf(`"%X"`, sha1.Sum(res.DER)),
+	}
+}
+
+type derRes struct {
+	Data        []byte
+	ContentType string
+	Headers     map[string]string
+}
+
 type fileDownloadRes struct {
 	Certificate []byte `json:"certificate"`
 	PrivateKey  []byte `json:"private_key"`
@@ -205,6 +278,9 @@ type issueFromCSRRes struct {
 	Revoked      bool      `json:"revoked"`
 	ExpiryTime   time.Time `json:"expiry_time"`
 	EntityID     string    `json:"entity_id"`
+	// CSRID and Status identify the submitted CSR the certificate was issued for.
+	CSRID  string          `json:"csr_id,omitempty"`
+	Status certs.CSRStatus `json:"status,omitempty"`
 }
 
 func (res issueFromCSRRes) Code() int {
@@ -217,4 +293,226 @@ func (res issueFromCSRRes) Headers() map[string]string {
 
 func (res issueFromCSRRes) Empty() bool {
 	return false
+}
+
+type csrRes struct {
+	ID           string          `json:"id"`
+	EntityID     string          `json:"entity_id"`
+	Issuer       string          `json:"issuer,omitempty"`
+	Profile      string          `json:"profile,omitempty"`
+	TTL          string          `json:"ttl,omitempty"`
+	CSR          string          `json:"csr"`
+	Status       certs.CSRStatus `json:"status"`
+	Requester    certs.Requester `json:"requester"`
+	Reason       string          `json:"reason,omitempty"`
+	SerialNumber string          `json:"serial_number,omitempty"`
+	SubmittedAt  time.Time       `json:"submitted_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	// accepted marks a submitted CSR that waits for approval.
+	accepted bool
+}
+
+func newCSRRes(csr certs.CSR) csrRes {
+	return csrRes{
+		ID:           csr.ID,
+		EntityID:     csr.EntityID,
+		Issuer:       csr.Issuer,
+		Profile:      csr.Profile,
+		TTL:          csr.TTL,
+		CSR:          string(csr.CSR),
+		Status:       csr.Status,
+		Requester:    csr.Requester,
+		Reason:       csr.Reason,
+		SerialNumber: csr.SerialNumber,
+		SubmittedAt:  csr.SubmittedAt,
+		UpdatedAt:    csr.UpdatedAt,
+	}
+}
+
+func (res csrRes) Code() int {
+	if res.accepted {
+		return http.StatusAccepted
+	}
+
+	return http.StatusOK
+}
+
+func (res csrRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res csrRes) Empty() bool {
+	return false
+}
+
+type listCSRsRes struct {
+	Total  uint64   `json:"total"`
+	Offset uint64   `json:"offset"`
+	Limit  uint64   `json:"limit"`
+	CSRs   []csrRes `json:"csrs"`
+}
+
+func (res listCSRsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res listCSRsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res listCSRsRes) Empty() bool {
+	return false
+}
+
+type caRes struct {
+	SerialNumber string    `json:"serial_number"`
+	Certificate  string    `json:"certificate,omitempty"`
+	ExpiryTime   time.Time `json:"expiry_time"`
+	Type         string    `json:"type"`
+}
+
+func (res caRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res caRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res caRes) Empty() bool {
+	return false
+}
+
+type intermediateCSRRes struct {
+	CSR string `json:"csr"`
+}
+
+func (res intermediateCSRRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res intermediateCSRRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res intermediateCSRRes) Empty() bool {
+	return false
+}
+
+type issuerRes struct {
+	Name         string    `json:"name"`
+	SerialNumber string    `json:"serial_number"`
+	Certificate  string    `json:"certificate,omitempty"`
+	ExpiryTime   time.Time `json:"expiry_time"`
+	Retired      bool      `json:"retired"`
+	created      bool
+}
+
+func (res issuerRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res issuerRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res issuerRes) Empty() bool {
+	return false
+}
+
+type listIssuersRes struct {
+	Issuers []issuerRes `json:"issuers"`
+}
+
+func (res listIssuersRes) Code() int {
+	return http.StatusOK
+}
+
+func (res listIssuersRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res listIssuersRes) Empty() bool {
+	return false
+}
+
+type retireIssuerRes struct {
+	retired bool
+}
+
+func (res retireIssuerRes) Code() int {
+	if res.retired {
+		return http.StatusNoContent
+	}
+
+	return http.StatusUnprocessableEntity
+}
+
+func (res retireIssuerRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res retireIssuerRes) Empty() bool {
+	return true
+}
+
+type profileRes struct {
+	certs.Profile
+	created bool
+}
+
+func (res profileRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res profileRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res profileRes) Empty() bool {
+	return false
+}
+
+type listProfilesRes struct {
+	Profiles []certs.Profile `json:"profiles"`
+}
+
+func (res listProfilesRes) Code() int {
+	return http.StatusOK
+}
+
+func (res listProfilesRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res listProfilesRes) Empty() bool {
+	return false
+}
+
+type removeProfileRes struct {
+	removed bool
+}
+
+func (res removeProfileRes) Code() int {
+	if res.removed {
+		return http.StatusNoContent
+	}
+
+	return http.StatusUnprocessableEntity
+}
+
+func (res removeProfileRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res removeProfileRes) Empty() bool {
+	return true
 }
\ No newline at end of file
@@ -4,146 +4,310 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"strconv"
 	"strings"
 
-	"github.com/hantdev/certs"
-	"github.com/hantdev/certs/errors"
 	"github.com/go-chi/chi/v5"
 	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/auth"
+	"github.com/hantdev/certs/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/crypto/ocsp"
 )
 
-var idPKIXOCSPBasic = asn1.ObjectIdentifier([]int{1, 3, 6, 1, 5, 5, 7, 48, 1, 1})
-
 const (
-	offsetKey       = "offset"
-	limitKey        = "limit"
-	entityKey       = "entity_id"
-	commonName      = "common_name"
-	approve         = "approve"
-	status          = "status"
-	token           = "token"
-	ocspStatusParam = "force_status"
-	entityIDParam   = "entityID"
-	ttl             = "ttl"
-	defOffset       = 0
-	defLimit        = 10
-	defType         = 1
+	offsetKey     = "offset"
+	limitKey      = "limit"
+	entityKey     = "entity_id"
+	commonName    = "common_name"
+	status        = "status"
+	token         = "token"
+	entityIDParam = "entityID"
+	ttl           = "ttl"
+	issuerKey     = "issuer"
+	profileKey    = "profile"
+	typeKey       = "type"
+	formatKey     = "format"
+	derFormat     = "der"
+	pemFormat     = "pem"
+	defOffset     = 0
+	defLimit      = 10
+	defType       = 1
 )
 
-type responseASN1 struct {
-	Status   asn1.Enumerated
-	Response responseBytes `asn1:"explicit,tag:0,optional"`
-}
-
-type responseBytes struct {
-	ResponseType asn1.ObjectIdentifier
-	Response     []byte
-}
+// oidOCSPNonce is the OCSP request extension holding the nonce.
+var oidOCSPNonce = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
 
 // MakeHandler returns a HTTP handler for API endpoints.
-func MakeHandler(svc certs.Service, logger *slog.Logger, instanceID string) http.Handler {
+// Unless authn is nil, callers of the endpoints other than the OCSP responder,
+// the CRLs and the CA certificates must authenticate.
+func MakeHandler(svc certs.Service, authn auth.Authenticator, logger *slog.Logger, instanceID string, trustedProxies []netip.Prefix) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorEncoder(loggingErrorEncoder(logger, EncodeError)),
 	}
 
+	admin := auth.Authorize(EncodeError, auth.RoleAdmin)
+	manage := auth.Authorize(EncodeError, auth.RoleAdmin, auth.RoleIssuer)
+	enroll := auth.Authorize(EncodeError, auth.RoleAdmin, auth.RoleIssuer, auth.RoleDevice)
+	read := auth.Authorize(EncodeError, auth.RoleAdmin, auth.RoleIssuer, auth.RoleAuditor, auth.RoleDevice)
+
 	r := chi.NewRouter()
 
 	r.Route("/certs", func(r chi.Router) {
-		r.Post("/issue/{entityID}", otelhttp.NewHandler(kithttp.NewServer(
-			issueCertEndpoint(svc),
-			decodeIssueCert,
-			EncodeResponse,
-			opts...,
-		), "issue_cert").ServeHTTP)
-		r.Patch("/{id}/renew", otelhttp.NewHandler(kithttp.NewServer(
-			renewCertEndpoint(svc),
-			decodeView,
-			EncodeResponse,
-			opts...,
-		), "renew_cert").ServeHTTP)
-		r.Patch("/{id}/revoke", otelhttp.NewHandler(kithttp.NewServer(
-			revokeCertEndpoint(svc),
-			decodeView,
-			EncodeResponse,
-			opts...,
-		), "revoke_cert").ServeHTTP)
-		r.Delete("/{entityID}/delete", otelhttp.NewHandler(kithttp.NewServer(
-			deleteCertEndpoint(svc),
-			decodeDelete,
-			EncodeResponse,
-			opts...,
-		), "delete_cert").ServeHTTP)
-		r.Get("/{id}/download/token", otelhttp.NewHandler(kithttp.NewServer(
-			requestCertDownloadTokenEndpoint(svc),
-			decodeView,
-			EncodeResponse,
-			opts...,
-		), "get_download_token").ServeHTTP)
-		r.Get("/", otelhttp.NewHandler(kithttp.NewServer(
-			listCertsEndpoint(svc),
-			decodeListCerts,
-			EncodeResponse,
-			opts...,
-		), "list_certs").ServeHTTP)
-		r.Get("/{id}", otelhttp.NewHandler(kithttp.NewServer(
-			viewCertEndpoint(svc),
-			decodeView,
-			EncodeResponse,
-			opts...,
-		), "view_cert").ServeHTTP)
-		r.Get("/{id}/download", otelhttp.NewHandler(kithttp.NewServer(
-			downloadCertEndpoint(svc),
-			decodeDownloadCerts,
-			encodeFileDownloadResponse,
-			opts...,
-		), "download_cert").ServeHTTP)
+		// The OCSP responder, the CRLs and the CA certificates are referenced
+		// by issued certificates and are public, as are the CA chains and the
+		// trust bundle, which hold no private keys.
 		r.Post("/ocsp", otelhttp.NewHandler(kithttp.NewServer(
 			ocspEndpoint(svc),
 			decodeOCSPRequest,
 			encodeOSCPResponse,
 			opts...,
 		), "ocsp").ServeHTTP)
+		r.Get("/ocsp/*", otelhttp.NewHandler(kithttp.NewServer(
+			ocspEndpoint(svc),
+			decodeOCSPRequest,
+			encodeOSCPResponse,
+			opts...,
+		), "ocsp").ServeHTTP)
 		r.Get("/crl", otelhttp.NewHandler(kithttp.NewServer(
 			generateCRLEndpoint(svc),
 			decodeCRL,
 			EncodeResponse,
 			opts...,
 		), "generate_crl").ServeHTTP)
-		r.Get("/get-ca/token", otelhttp.NewHandler(kithttp.NewServer(
-			getDownloadCATokenEndpoint(svc),
+		r.Get("/ca/chain", otelhttp.NewHandler(kithttp.NewServer(
+			viewCAChainEndpoint(svc),
+			decodeIssuerQuery,
+			encodeDERResponse,
+			opts...,
+		), "view_ca_chain").ServeHTTP)
+		r.Get("/ca/bundle", otelhttp.NewHandler(kithttp.NewServer(
+			trustBundleEndpoint(svc),
+			kithttp.NopRequestDecoder,
+			encodeDERResponse,
+			opts...,
+		), "trust_bundle").ServeHTTP)
+		r.Get("/ca/{id}", otelhttp.NewHandler(kithttp.NewServer(
+			viewCACertEndpoint(svc),
 			decodeView,
-			EncodeResponse,
+			encodeDERResponse,
 			opts...,
-		), "get_ca_token").ServeHTTP)
-		r.Get("/view-ca", otelhttp.NewHandler(kithttp.NewServer(
-			viewCAEndpoint(svc),
-			decodeDownloadCA,
-			EncodeResponse,
+		), "view_ca_cert").ServeHTTP)
+		r.Get("/ca/{id}/crl", otelhttp.NewHandler(kithttp.NewServer(
+			generateCACRLEndpoint(svc),
+			decodeCACRL(false),
+			encodeDERResponse,
 			opts...,
-		), "view_ca").ServeHTTP)
-		r.Get("/download-ca", otelhttp.NewHandler(kithttp.NewServer(
-			downloadCAEndpoint(svc),
-			decodeDownloadCA,
-			encodeCADownloadResponse,
+		), "generate_ca_crl").ServeHTTP)
+		r.Get("/ca/{id}/delta-crl", otelhttp.NewHandler(kithttp.NewServer(
+			generateCACRLEndpoint(svc),
+			decodeCACRL(true),
+			encodeDERResponse,
 			opts...,
-		), "download_ca").ServeHTTP)
-		r.Route("/csrs", func(r chi.Router) {
-			r.Post("/{entityID}", otelhttp.NewHandler(kithttp.NewServer(
-				issueFromCSREndpoint(svc),
-				decodeIssueFromCSR,
+		), "generate_ca_delta_crl").ServeHTTP)
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.Authenticate(authn, EncodeError))
+
+			r.With(enroll).Post("/issue/{entityID}", otelhttp.NewHandler(kithttp.NewServer(
+				issueCertEndpoint(svc),
+				decodeIssueCert,
+				EncodeResponse,
+				opts...,
+			), "issue_cert").ServeHTTP)
+			r.With(enroll).Patch("/{id}/renew", otelhttp.NewHandler(kithttp.NewServer(
+				renewCertEndpoint(svc),
+				decodeView,
+				EncodeResponse,
+				opts...,
+			), "renew_cert").ServeHTTP)
+			r.With(enroll).Patch("/{id}/revoke", otelhttp.NewHandler(kithttp.NewServer(
+				revokeCertEndpoint(svc),
+				decodeRevokeCert,
+				EncodeResponse,
+				opts...,
+			), "revoke_cert").ServeHTTP)
+			r.With(manage).Patch("/{id}/hold", otelhttp.NewHandler(kithttp.NewServer(
+				holdCertEndpoint(svc),
+				decodeView,
+				EncodeResponse,
+				opts...,
+			), "hold_cert").ServeHTTP)
+			r.With(manage).Patch("/{id}/release", otelhttp.NewHandler(kithttp.NewServer(
+				releaseCertEndpoint(svc),
+				decodeView,
+				EncodeResponse,
+				opts...,
+			), "release_cert").ServeHTTP)
+			r.With(manage).Delete("/{entityID}/delete", otelhttp.NewHandler(kithttp.NewServer(
+				deleteCertEndpoint(svc),
+				decodeDelete,
+				EncodeResponse,
+				opts...,
+			), "delete_cert").ServeHTTP)
+			r.With(enroll).Get("/{id}/download/token", otelhttp.NewHandler(kithttp.NewServer(
+				requestCertDownloadTokenEndpoint(svc),
+				decodeView,
+				EncodeResponse,
+				opts...,
+			), "get_download_token").ServeHTTP)
+			r.With(read).Get("/", otelhttp.NewHandler(kithttp.NewServer(
+				listCertsEndpoint(svc),
+				decodeListCerts,
+				EncodeResponse,
+				opts...,
+			), "list_certs").ServeHTTP)
+			r.With(enroll).Get("/{id}", otelhttp.NewHandler(kithttp.NewServer(
+				viewCertEndpoint(svc),
+				decodeView,
 				EncodeResponse,
 				opts...,
-			), "issue_from_csr").ServeHTTP)
+			), "view_cert").ServeHTTP)
+			r.With(enroll).Get("/{id}/download", otelhttp.NewHandler(kithttp.NewServer(
+				downloadCertEndpoint(svc),
+				decodeDownloadCerts,
+				encodeFileDownloadResponse,
+				opts...,
+			), "download_cert").ServeHTTP)
+			r.With(admin).Get("/get-ca/token", otelhttp.NewHandler(kithttp.NewServer(
+				getDownloadCATokenEndpoint(svc),
+				decodeIssuerQuery,
+				EncodeResponse,
+				opts...,
+			), "get_ca_token").ServeHTTP)
+			r.With(admin).Get("/view-ca", otelhttp.NewHandler(kithttp.NewServer(
+				viewCAEndpoint(svc),
+				decodeDownloadCA,
+				EncodeResponse,
+				opts...,
+			), "view_ca").ServeHTTP)
+			r.With(admin).Get("/download-ca", otelhttp.NewHandler(kithttp.NewServer(
+				downloadCAEndpoint(svc),
+				decodeDownloadCA,
+				encodeDERResponse,
+				opts...,
+			), "download_ca").ServeHTTP)
+			r.With(admin).Post("/ca/import", otelhttp.NewHandler(kithttp.NewServer(
+				importCAEndpoint(svc),
+				decodeImportCA,
+				EncodeResponse,
+				opts...,
+			), "import_ca").ServeHTTP)
+			r.With(admin).Post("/ca/{id}/export", otelhttp.NewHandler(kithttp.NewServer(
+				exportCAEndpoint(svc),
+				decodeExportCA,
+				encodeDERResponse,
+				opts...,
+			), "export_ca").ServeHTTP)
+			r.With(admin).Post("/ca/intermediate/csr", otelhttp.NewHandler(kithttp.NewServer(
+				generateIntermediateCSREndpoint(svc),
+				decodeView,
+				EncodeResponse,
+				opts...,
+			), "generate_intermediate_csr").ServeHTTP)
+			r.With(admin).Post("/ca/intermediate/install", otelhttp.NewHandler(kithttp.NewServer(
+				installIntermediateCAEndpoint(svc),
+				decodeInstallCA,
+				EncodeResponse,
+				opts...,
+			), "install_intermediate_ca").ServeHTTP)
+			r.Route("/issuers", func(r chi.Router) {
+				r.With(admin).Post("/", otelhttp.NewHandler(kithttp.NewServer(
+					createIssuerEndpoint(svc),
+					decodeCreateIssuer,
+					EncodeResponse,
+					opts...,
+				), "create_issuer").ServeHTTP)
+				r.With(read).Get("/", otelhttp.NewHandler(kithttp.NewServer(
+					listIssuersEndpoint(svc),
+					decodeIssuerQuery,
+					EncodeResponse,
+					opts...,
+				), "list_issuers").ServeHTTP)
+				r.With(admin).Patch("/{name}/retire", otelhttp.NewHandler(kithttp.NewServer(
+					retireIssuerEndpoint(svc),
+					decodeIssuer,
+					EncodeResponse,
+					opts...,
+				), "retire_issuer").ServeHTTP)
+			})
+			r.Route("/profiles", func(r chi.Router) {
+				r.With(admin).Post("/", otelhttp.NewHandler(kithttp.NewServer(
+					createProfileEndpoint(svc),
+					decodeCreateProfile,
+					EncodeResponse,
+					opts...,
+				), "create_profile").ServeHTTP)
+				r.With(read).Get("/", otelhttp.NewHandler(kithttp.NewServer(
+					listProfilesEndpoint(svc),
+					decodeView,
+					EncodeResponse,
+					opts...,
+				), "list_profiles").ServeHTTP)
+				r.With(read).Get("/{name}", otelhttp.NewHandler(kithttp.NewServer(
+					viewProfileEndpoint(svc),
+					decodeProfileName,
+					EncodeResponse,
+					opts...,
+				), "view_profile").ServeHTTP)
+				r.With(admin).Put("/{name}", otelhttp.NewHandler(kithttp.NewServer(
+					updateProfileEndpoint(svc),
+					decodeUpdateProfile,
+					EncodeResponse,
+					opts...,
+				), "update_profile").ServeHTTP)
+				r.With(admin).Delete("/{name}", otelhttp.NewHandler(kithttp.NewServer(
+					removeProfileEndpoint(svc),
+					decodeProfileName,
+					EncodeResponse,
+					opts...,
+				), "remove_profile").ServeHTTP)
+			})
+			r.Route("/csrs", func(r chi.Router) {
+				r.With(enroll).Post("/{entityID}", otelhttp.NewHandler(kithttp.NewServer(
+					submitCSREndpoint(svc),
+					decodeIssueFromCSR(trustedProxies),
+					EncodeResponse,
+					opts...,
+				), "submit_csr").ServeHTTP)
+				r.With(read).Get("/", otelhttp.NewHandler(kithttp.NewServer(
+					listCSRsEndpoint(svc),
+					decodeListCSRs,
+					EncodeResponse,
+					opts...,
+				), "list_csrs").ServeHTTP)
+				r.With(read).Get("/{id}", otelhttp.NewHandler(kithttp.NewServer(
+					viewCSREndpoint(svc),
+					decodeView,
+					EncodeResponse,
+					opts...,
+				), "view_csr").ServeHTTP)
+				r.With(manage).Patch("/{id}/approve", otelhttp.NewHandler(kithttp.NewServer(
+					approveCSREndpoint(svc),
+					decodeApproveCSR,
+					EncodeResponse,
+					opts...,
+				), "approve_csr").ServeHTTP)
+				r.With(manage).Patch("/{id}/reject", otelhttp.NewHandler(kithttp.NewServer(
+					rejectCSREndpoint(svc),
+					decodeRejectCSR,
+					EncodeResponse,
+					opts...,
+				), "reject_csr").ServeHTTP)
+			})
 		})
 	})
 
@@ -160,6 +324,24 @@ func decodeView(_ context.Context, r *http.Request) (interface{}, error) {
 	return req, nil
 }
 
+func decodeRevokeCert(_ context.Context, r *http.Request) (interface{}, error) {
+	req := revokeCertReq{
+		id: chi.URLParam(r, "id"),
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidRequest, err)
+	}
+	// The body is optional, certificates revoked without one have an unspecified reason.
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, errors.Wrap(ErrInvalidRequest, err)
+		}
+	}
+
+	return req, nil
+}
+
 func decodeDelete(_ context.Context, r *http.Request) (interface{}, error) {
 	req := deleteReq{
 		entityID: chi.URLParam(r, "entityID"),
@@ -168,16 +350,36 @@ func decodeDelete(_ context.Context, r *http.Request) (interface{}, error) {
 }
 
 func decodeCRL(_ context.Context, r *http.Request) (interface{}, error) {
-	certType, err := readNumQuery(r, "", defType)
+	certType, err := readNumQuery(r, typeKey, defType)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := readStringQuery(r, issuerKey, "")
 	if err != nil {
 		return nil, err
 	}
 	req := crlReq{
 		certtype: certs.CertType(certType),
+		issuer:   issuer,
 	}
 	return req, nil
 }
 
+func decodeCACRL(delta bool) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		format, err := readStringQuery(r, formatKey, derFormat)
+		if err != nil {
+			return nil, err
+		}
+		req := caCRLReq{
+			id:     chi.URLParam(r, "id"),
+			delta:  delta,
+			format: format,
+		}
+		return req, nil
+	}
+}
+
 func decodeDownloadCerts(_ context.Context, r *http.Request) (interface{}, error) {
 	token, err := readStringQuery(r, token, "")
 	if err != nil {
@@ -203,22 +405,72 @@ func decodeDownloadCA(_ context.Context, r *http.Request) (interface{}, error) {
 	return req, nil
 }
 
+// decodeOCSPRequest decodes an OCSP request sent in the body of a POST or, as
+// of RFC 6960 appendix A.1, base64 encoded in the path of a GET request.
 func decodeOCSPRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		return nil, err
+	var body []byte
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		encoded, err := url.PathUnescape(chi.URLParam(r, "*"))
+		if err != nil {
+			return nil, errors.Wrap(certs.ErrMalformedEntity, err)
+		}
+		if body, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return nil, errors.Wrap(certs.ErrMalformedEntity, err)
+		}
+	default:
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
 	}
 	req, err := ocsp.ParseRequest(body)
 	if err != nil {
 		return nil, err
 	}
+	nonce, err := ocspNonce(body)
+	if err != nil {
+		return nil, errors.Wrap(certs.ErrMalformedEntity, err)
+	}
 	request := ocspReq{
-		req:         req,
-		statusParam: strings.TrimSpace(r.URL.Query().Get(ocspStatusParam)),
+		req:       req,
+		nonce:     nonce,
+		cacheable: r.Method == http.MethodGet && nonce == nil,
 	}
 	return request, nil
 }
 
+// ocspNonce returns the nonce of the OCSP request, if any. Nonces longer than
+// the 32 octets allowed by RFC 8954 are rejected.
+func ocspNonce(der []byte) ([]byte, error) {
+	var req struct {
+		TBSRequest struct {
+			Version       int           `asn1:"explicit,tag:0,default:0,optional"`
+			RequestorName asn1.RawValue `asn1:"explicit,tag:1,optional"`
+			RequestList   []asn1.RawValue
+			Extensions    []pkix.Extension `asn1:"explicit,tag:2,optional"`
+		}
+	}
+	if _, err := asn1.Unmarshal(der, &req); err != nil {
+		return nil, err
+	}
+	for _, ext := range req.TBSRequest.Extensions {
+		if !ext.Id.Equal(oidOCSPNonce) {
+			continue
+		}
+		var nonce []byte
+		if _, err := asn1.Unmarshal(ext.Value, &nonce); err != nil {
+			return nil, err
+		}
+		if len(nonce) == 0 || len(nonce) > 32 {
+			return nil, errors.New("nonce must be 1 to 32 octets")
+		}
+		return nonce, nil
+	}
+
+	return nil, nil
+}
+
 func decodeIssueCert(_ context.Context, r *http.Request) (interface{}, error) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -231,8 +483,18 @@ func decodeIssueCert(_ context.Context, r *http.Request) (interface{}, error) {
 	if cn == "" {
 		return nil, ErrMissingCN
 	}
+	issuer, err := readStringQuery(r, issuerKey, "")
+	if err != nil {
+		return nil, err
+	}
+	profile, err := readStringQuery(r, profileKey, "")
+	if err != nil {
+		return nil, err
+	}
 	req := issueCertReq{
 		entityID: chi.URLParam(r, entityIDParam),
+		issuer:   issuer,
+		profile:  profile,
 		Options: certs.SubjectOptions{
 			CommonName: cn,
 		},
@@ -269,30 +531,232 @@ func decodeListCerts(_ context.Context, r *http.Request) (interface{}, error) {
 	return req, nil
 }
 
-func decodeIssueFromCSR(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeIssueFromCSR decodes a submitted CSR together with the client that
+// submitted it, whose address the trusted proxies may forward.
+func decodeIssueFromCSR(trustedProxies []netip.Prefix) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		t, err := readStringQuery(r, ttl, "")
+		if err != nil {
+			return nil, err
+		}
+		issuer, err := readStringQuery(r, issuerKey, "")
+		if err != nil {
+			return nil, err
+		}
+		profile, err := readStringQuery(r, profileKey, "")
+		if err != nil {
+			return nil, err
+		}
+
+		req := IssueFromCSRReq{
+			entityID: chi.URLParam(r, "entityID"),
+			issuer:   issuer,
+			profile:  profile,
+			ttl:      t,
+			requester: certs.Requester{
+				Address:   requesterAddress(r, trustedProxies),
+				UserAgent: r.UserAgent(),
+			},
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidRequest, errors.New("failed to read request body"))
+		}
+		defer r.Body.Close()
+
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, errors.Wrap(ErrInvalidRequest, errors.New("failed to decode JSON"))
+		}
+
+		return req, nil
+	}
+}
+
+func decodeListCSRs(_ context.Context, r *http.Request) (interface{}, error) {
+	o, err := readNumQuery(r, offsetKey, defOffset)
+	if err != nil {
+		return nil, err
+	}
+	l, err := readNumQuery(r, limitKey, defLimit)
+	if err != nil {
+		return nil, err
+	}
+	entity, err := readStringQuery(r, entityKey, "")
+	if err != nil {
+		return nil, err
+	}
+	s, err := readStringQuery(r, status, "")
+	if err != nil {
+		return nil, err
+	}
+
+	req := listCSRsReq{
+		pm: certs.PageMetadata{
+			Offset:   o,
+			Limit:    l,
+			EntityID: entity,
+			Status:   certs.CSRStatus(s),
+		},
+	}
+	return req, nil
+}
+
+func decodeApproveCSR(_ context.Context, r *http.Request) (interface{}, error) {
 	t, err := readStringQuery(r, ttl, "")
 	if err != nil {
 		return nil, err
 	}
+	profile, err := readStringQuery(r, profileKey, "")
+	if err != nil {
+		return nil, err
+	}
 
-	req := IssueFromCSRReq{
-		entityID: chi.URLParam(r, "entityID"),
-		ttl:      t,
+	req := approveCSRReq{
+		id:      chi.URLParam(r, "id"),
+		ttl:     t,
+		profile: profile,
 	}
+	return req, nil
+}
 
+func decodeRejectCSR(_ context.Context, r *http.Request) (interface{}, error) {
+	req := rejectCSRReq{
+		id: chi.URLParam(r, "id"),
+	}
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		return nil, errors.Wrap(ErrInvalidRequest, errors.New("failed to read request body"))
+		return nil, errors.Wrap(ErrInvalidRequest, err)
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, errors.Wrap(ErrInvalidRequest, err)
+		}
+	}
+	return req, nil
+}
+
+// requesterAddress returns the address of the client. Only requests of the
+// trusted proxies are taken to be forwarded, in which case the client is the
+// last address of the X-Forwarded-For header no trusted proxy appended, as
+// the addresses before it are set by the client itself.
+func requesterAddress(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
 	}
-	defer r.Body.Close()
+	if !trusted(host, trustedProxies) {
+		return host
+	}
+	fwd := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(fwd) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(fwd[i])
+		if addr == "" {
+			continue
+		}
+		host = addr
+		if !trusted(addr, trustedProxies) {
+			break
+		}
+	}
+	return host
+}
 
-	if err := json.Unmarshal(body, &req); err != nil {
-		return nil, errors.Wrap(ErrInvalidRequest, errors.New("failed to decode JSON"))
+// trusted reports whether the address belongs to one of the trusted proxies.
+func trusted(addr string, trustedProxies []netip.Prefix) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeImportCA(_ context.Context, r *http.Request) (interface{}, error) {
+	var req importCAReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(ErrInvalidRequest, err)
+	}
+
+	return req, nil
+}
+
+func decodeInstallCA(_ context.Context, r *http.Request) (interface{}, error) {
+	var req installCAReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(ErrInvalidRequest, err)
+	}
+
+	return req, nil
+}
+
+func decodeExportCA(_ context.Context, r *http.Request) (interface{}, error) {
+	req := exportCAReq{id: chi.URLParam(r, "id")}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(ErrInvalidRequest, err)
+	}
+
+	return req, nil
+}
+
+func decodeCreateIssuer(_ context.Context, r *http.Request) (interface{}, error) {
+	var req issuerReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(ErrInvalidRequest, err)
 	}
 
 	return req, nil
 }
 
+func decodeIssuer(_ context.Context, r *http.Request) (interface{}, error) {
+	req := issuerReq{
+		Name: chi.URLParam(r, "name"),
+	}
+	return req, nil
+}
+
+func decodeIssuerQuery(_ context.Context, r *http.Request) (interface{}, error) {
+	issuer, err := readStringQuery(r, issuerKey, "")
+	if err != nil {
+		return nil, err
+	}
+	req := issuerReq{
+		Name: issuer,
+	}
+	return req, nil
+}
+
+func decodeCreateProfile(_ context.Context, r *http.Request) (interface{}, error) {
+	var req profileReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(ErrInvalidRequest, err)
+	}
+
+	return req, nil
+}
+
+func decodeUpdateProfile(_ context.Context, r *http.Request) (interface{}, error) {
+	var req profileReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(ErrInvalidRequest, err)
+	}
+	req.Name = chi.URLParam(r, "name")
+
+	return req, nil
+}
+
+func decodeProfileName(_ context.Context, r *http.Request) (interface{}, error) {
+	req := profileNameReq{
+		name: chi.URLParam(r, "name"),
+	}
+	return req, nil
+}
+
 // EncodeResponse encodes successful response.
 func EncodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
 	if ar, ok := response.(Response); ok {
@@ -312,33 +776,12 @@ func EncodeResponse(_ context.Context, w http.ResponseWriter, response interface
 
 func encodeOSCPResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
 	res := response.(ocspRes)
-	if res.template.Certificate == nil {
-		ocspRes, err := asn1.Marshal(responseASN1{
-			Status: asn1.Enumerated(ocsp.Malformed),
-			Response: responseBytes{
-				ResponseType: idPKIXOCSPBasic,
-			},
-		})
-		if err != nil {
-			return err
-		}
-
-		w.Header().Set("Content-Type", OCSPType)
-		if _, err := w.Write(ocspRes); err != nil {
-			return err
-		}
-
-		return err
-	}
-
-	ocspRes, err := ocsp.CreateResponse(res.issuerCert, res.template.Certificate, res.template, res.signer)
-	if err != nil {
-		return err
+	for k, v := range res.Headers() {
+		w.Header().Set(k, v)
 	}
 	w.Header().Set("Content-Type", OCSPType)
-	if _, err := w.Write(ocspRes); err != nil {
-		return err
-	}
+	_, err := w.Write(res.response)
+
 	return err
 }
 
@@ -386,37 +829,13 @@ func encodeFileDownloadResponse(_ context.Context, w http.ResponseWriter, respon
 	return err
 }
 
-func encodeCADownloadResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
-	resp := response.(fileDownloadRes)
-	var buffer bytes.Buffer
-	zw := zip.NewWriter(&buffer)
-
-	f, err := zw.Create("ca.crt")
-	if err != nil {
-		return err
-	}
-
-	if _, err = f.Write(resp.Certificate); err != nil {
-		return err
-	}
-
-	f, err = zw.Create("ca.key")
-	if err != nil {
-		return err
-	}
-
-	if _, err = f.Write(resp.PrivateKey); err != nil {
-		return err
+func encodeDERResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(derRes)
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
 	}
-
-	if err := zw.Close(); err != nil {
-		return err
-	}
-
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", resp.Filename))
 	w.Header().Set("Content-Type", resp.ContentType)
-
-	_, err = w.Write(buffer.Bytes())
+	_, err := w.Write(resp.Data)
 
 	return err
 }
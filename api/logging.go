@@ -2,12 +2,13 @@ package api
 
 import (
 	"context"
-	"crypto/x509"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/auth"
+	"golang.org/x/crypto/ocsp"
 )
 
 var _ certs.Service = (*loggingMiddleware)(nil)
@@ -22,14 +23,28 @@ func LoggingMiddleware(svc certs.Service, logger *slog.Logger) certs.Service {
 	return &loggingMiddleware{logger, svc}
 }
 
+// log returns the logger annotated with the caller of the context, if any.
+func (lm *loggingMiddleware) log(ctx context.Context) *slog.Logger {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return lm.logger
+	}
+
+	return lm.logger.With(
+		slog.String("caller", id.Subject),
+		slog.String("role", string(id.Role)),
+		slog.String("auth_method", string(id.Method)),
+	)
+}
+
 func (lm *loggingMiddleware) RenewCert(ctx context.Context, serialNumber string) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method renew_cert for cert %s took %s to complete", serialNumber, time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
 	return lm.svc.RenewCert(ctx, serialNumber)
 }
@@ -38,70 +53,70 @@ func (lm *loggingMiddleware) RetrieveCert(ctx context.Context, token, serialNumb
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method get_cert for cert %s took %s to complete", serialNumber, time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
 	return lm.svc.RetrieveCert(ctx, token, serialNumber)
 }
 
-func (lm *loggingMiddleware) RevokeCert(ctx context.Context, serialNumber string) (err error) {
+func (lm *loggingMiddleware) RevokeCert(ctx context.Context, serialNumber string, reason certs.RevocationReason, invalidityDate time.Time) (err error) {
 	defer func(begin time.Time) {
-		message := fmt.Sprintf("Method revoke_cert for cert %s and took %s to complete", serialNumber, time.Since(begin))
+		message := fmt.Sprintf("Method revoke_cert for cert %s with reason %s and took %s to complete", serialNumber, reason, time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
-	return lm.svc.RevokeCert(ctx, serialNumber)
+	return lm.svc.RevokeCert(ctx, serialNumber, reason, invalidityDate)
 }
 
 func (lm *loggingMiddleware) RetrieveCertDownloadToken(ctx context.Context, serialNumber string) (tokenString string, err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method get_cert_download_token for cert took %s to complete", time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
 	return lm.svc.RetrieveCertDownloadToken(ctx, serialNumber)
 }
 
-func (lm *loggingMiddleware) RetrieveCAToken(ctx context.Context) (tokenString string, err error) {
+func (lm *loggingMiddleware) RetrieveCAToken(ctx context.Context, issuer string) (tokenString string, err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method get_cert_download_token for cert took %s to complete", time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
-	return lm.svc.RetrieveCAToken(ctx)
+	return lm.svc.RetrieveCAToken(ctx, issuer)
 }
 
-func (lm *loggingMiddleware) IssueCert(ctx context.Context, entityID, ttl string, ipAddrs []string, options certs.SubjectOptions) (cert certs.Certificate, err error) {
+func (lm *loggingMiddleware) IssueCert(ctx context.Context, entityID, issuer, profile, ttl string, ipAddrs []string, options certs.SubjectOptions) (cert certs.Certificate, err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method issue_cert for took %s to complete", time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
-	return lm.svc.IssueCert(ctx, entityID, ttl, ipAddrs, options)
+	return lm.svc.IssueCert(ctx, entityID, issuer, profile, ttl, ipAddrs, options)
 }
 
 func (lm *loggingMiddleware) ListCerts(ctx context.Context, pm certs.PageMetadata) (cp certs.CertificatePage, err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method list_certs took %s to complete", time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
 	return lm.svc.ListCerts(ctx, pm)
 }
@@ -110,10 +125,10 @@ func (lm *loggingMiddleware) RemoveCert(ctx context.Context, entityId string) (e
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method remove_cert took %s to complete", time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
 	return lm.svc.RemoveCert(ctx, entityId)
 }
@@ -122,22 +137,22 @@ func (lm *loggingMiddleware) ViewCert(ctx context.Context, serialNumber string)
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method view_cert for serial number %s took %s to complete", serialNumber, time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
 	return lm.svc.ViewCert(ctx, serialNumber)
 }
 
-func (lm *loggingMiddleware) OCSP(ctx context.Context, serialNumber string) (cert *certs.Certificate, ocspStatus int, rootCACert *x509.Certificate, err error) {
+func (lm *loggingMiddleware) OCSP(ctx context.Context, serialNumber string) (cert *certs.Certificate, ocspStatus int, issuer *certs.CA, err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method ocsp for serial number %s took %s to complete", serialNumber, time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
 	return lm.svc.OCSP(ctx, serialNumber)
 }
@@ -146,46 +161,335 @@ func (lm *loggingMiddleware) GetEntityID(ctx context.Context, serialNumber strin
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method get_entity_id for serial number %s took %s to complete", serialNumber, time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
 	return lm.svc.GetEntityID(ctx, serialNumber)
 }
 
-func (lm *loggingMiddleware) GenerateCRL(ctx context.Context, caType certs.CertType) (crl []byte, err error) {
+func (lm *loggingMiddleware) GenerateCRL(ctx context.Context, caType certs.CertType, issuer string) (crl []byte, err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method generate_crl took %s to complete", time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.GenerateCRL(ctx, caType, issuer)
+}
+
+func (lm *loggingMiddleware) ViewCAChain(ctx context.Context, issuer string) (cert certs.Certificate, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method view_ca_chain for issuer %s took %s to complete", issuer, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.ViewCAChain(ctx, issuer)
+}
+
+func (lm *loggingMiddleware) TrustBundle(ctx context.Context) (bundle []byte, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method trust_bundle took %s to complete", time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
-	return lm.svc.GenerateCRL(ctx, caType)
+	return lm.svc.TrustBundle(ctx)
+}
+
+// ExportCA is always logged at the warning level, as it discloses the CA key.
+func (lm *loggingMiddleware) ExportCA(ctx context.Context, serialNumber, passphrase string) (backup []byte, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method export_ca for CA %s took %s to complete", serialNumber, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Warn(fmt.Sprintf("%s, the encrypted CA key was exported.", message))
+	}(time.Now())
+	return lm.svc.ExportCA(ctx, serialNumber, passphrase)
 }
 
 func (lm *loggingMiddleware) GetChainCA(ctx context.Context, token string) (cert certs.Certificate, err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method get_chain_ca took %s to complete", time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
 	return lm.svc.GetChainCA(ctx, token)
 }
 
-func (lm *loggingMiddleware) IssueFromCSR(ctx context.Context, entityID, ttl string, csr certs.CSR) (c certs.Certificate, err error) {
+func (lm *loggingMiddleware) IssueFromCSR(ctx context.Context, entityID, issuer, profile, ttl string, csr certs.CSR) (c certs.Certificate, err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method issue_from_csr took %s to complete", time.Since(begin))
 		if err != nil {
-			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.IssueFromCSR(ctx, entityID, issuer, profile, ttl, csr)
+}
+
+func (lm *loggingMiddleware) SubmitCSR(ctx context.Context, csr certs.CSR) (c certs.CSR, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method submit_csr for entity %s took %s to complete", csr.EntityID, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(fmt.Sprintf("%s, CSR %s is %s.", message, c.ID, c.Status))
+	}(time.Now())
+	return lm.svc.SubmitCSR(ctx, csr)
+}
+
+func (lm *loggingMiddleware) ViewCSR(ctx context.Context, id string) (c certs.CSR, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method view_csr for %s took %s to complete", id, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.ViewCSR(ctx, id)
+}
+
+func (lm *loggingMiddleware) ListCSRs(ctx context.Context, pm certs.PageMetadata) (cp certs.CSRPage, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method list_csrs took %s to complete", time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.ListCSRs(ctx, pm)
+}
+
+func (lm *loggingMiddleware) ApproveCSR(ctx context.Context, id, ttl, profile string) (c certs.CSR, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method approve_csr for %s took %s to complete", id, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(fmt.Sprintf("%s, issued certificate %s.", message, c.SerialNumber))
+	}(time.Now())
+	return lm.svc.ApproveCSR(ctx, id, ttl, profile)
+}
+
+func (lm *loggingMiddleware) RejectCSR(ctx context.Context, id, reason string) (c certs.CSR, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method reject_csr for %s took %s to complete", id, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.RejectCSR(ctx, id, reason)
+}
+
+func (lm *loggingMiddleware) ImportCA(ctx context.Context, ca certs.CAImport) (c certs.Certificate, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method import_ca for %s took %s to complete", ca.Type, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(fmt.Sprintf("%s, imported CA %s.", message, c.SerialNumber))
+	}(time.Now())
+	return lm.svc.ImportCA(ctx, ca)
+}
+
+func (lm *loggingMiddleware) GenerateIntermediateCSR(ctx context.Context) (csr certs.CSR, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method generate_intermediate_csr took %s to complete", time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.GenerateIntermediateCSR(ctx)
+}
+
+func (lm *loggingMiddleware) InstallIntermediateCA(ctx context.Context, cert []byte) (c certs.Certificate, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method install_intermediate_ca took %s to complete", time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(fmt.Sprintf("%s, installed CA %s.", message, c.SerialNumber))
+	}(time.Now())
+	return lm.svc.InstallIntermediateCA(ctx, cert)
+}
+
+func (lm *loggingMiddleware) CreateIssuer(ctx context.Context, name string) (issuer certs.Issuer, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method create_issuer for %s took %s to complete", name, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(fmt.Sprintf("%s, created CA %s.", message, issuer.SerialNumber))
+	}(time.Now())
+	return lm.svc.CreateIssuer(ctx, name)
+}
+
+func (lm *loggingMiddleware) ListIssuers(ctx context.Context) (issuers []certs.Issuer, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method list_issuers took %s to complete", time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.ListIssuers(ctx)
+}
+
+func (lm *loggingMiddleware) RetireIssuer(ctx context.Context, name string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method retire_issuer for %s took %s to complete", name, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.RetireIssuer(ctx, name)
+}
+
+func (lm *loggingMiddleware) CreateProfile(ctx context.Context, profile certs.Profile) (p certs.Profile, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method create_profile for %s took %s to complete", profile.Name, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.CreateProfile(ctx, profile)
+}
+
+func (lm *loggingMiddleware) ViewProfile(ctx context.Context, name string) (p certs.Profile, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method view_profile for %s took %s to complete", name, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.ViewProfile(ctx, name)
+}
+
+func (lm *loggingMiddleware) ListProfiles(ctx context.Context) (profiles []certs.Profile, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method list_profiles took %s to complete", time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.ListProfiles(ctx)
+}
+
+func (lm *loggingMiddleware) UpdateProfile(ctx context.Context, profile certs.Profile) (p certs.Profile, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method update_profile for %s took %s to complete", profile.Name, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.UpdateProfile(ctx, profile)
+}
+
+func (lm *loggingMiddleware) RemoveProfile(ctx context.Context, name string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method remove_profile for %s took %s to complete", name, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.RemoveProfile(ctx, name)
+}
+
+func (lm *loggingMiddleware) ViewCACert(ctx context.Context, serialNumber string) (cert certs.Certificate, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method view_ca_cert for %s took %s to complete", serialNumber, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.ViewCACert(ctx, serialNumber)
+}
+
+func (lm *loggingMiddleware) GenerateCACRL(ctx context.Context, serialNumber string, delta bool) (crl certs.CRL, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method generate_ca_crl for %s with delta %t took %s to complete", serialNumber, delta, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.GenerateCACRL(ctx, serialNumber, delta)
+}
+
+func (lm *loggingMiddleware) HoldCert(ctx context.Context, serialNumber string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method hold_cert for %s took %s to complete", serialNumber, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.HoldCert(ctx, serialNumber)
+}
+
+func (lm *loggingMiddleware) ReleaseCert(ctx context.Context, serialNumber string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method release_cert for %s took %s to complete", serialNumber, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.log(ctx).Info(message)
+	}(time.Now())
+	return lm.svc.ReleaseCert(ctx, serialNumber)
+}
+
+func (lm *loggingMiddleware) SignOCSP(ctx context.Context, issuerSerial string, template ocsp.Response, nonce []byte) (response certs.OCSPResponse, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method sign_ocsp for %s took %s to complete", issuerSerial, time.Since(begin))
+		if err != nil {
+			lm.log(ctx).Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
 		}
-		lm.logger.Info(message)
+		lm.log(ctx).Info(message)
 	}(time.Now())
-	return lm.svc.IssueFromCSR(ctx, entityID, ttl, csr)
+	return lm.svc.SignOCSP(ctx, issuerSerial, template, nonce)
 }
\ No newline at end of file
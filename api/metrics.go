@@ -2,11 +2,11 @@ package api
 
 import (
 	"context"
-	"crypto/x509"
 	"time"
 
-	"github.com/hantdev/certs"
 	"github.com/go-kit/kit/metrics"
+	"github.com/hantdev/certs"
+	"golang.org/x/crypto/ocsp"
 )
 
 var _ certs.Service = (*metricsMiddleware)(nil)
@@ -42,12 +42,12 @@ func (mm *metricsMiddleware) RetrieveCert(ctx context.Context, token, serialNumb
 	return mm.svc.RetrieveCert(ctx, token, serialNumber)
 }
 
-func (mm *metricsMiddleware) RevokeCert(ctx context.Context, serialNumber string) error {
+func (mm *metricsMiddleware) RevokeCert(ctx context.Context, serialNumber string, reason certs.RevocationReason, invalidityDate time.Time) error {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "revoke_certificate").Add(1)
 		mm.latency.With("method", "revoke_certificate").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return mm.svc.RevokeCert(ctx, serialNumber)
+	return mm.svc.RevokeCert(ctx, serialNumber, reason, invalidityDate)
 }
 
 func (mm *metricsMiddleware) RetrieveCertDownloadToken(ctx context.Context, serialNumber string) (string, error) {
@@ -59,21 +59,21 @@ func (mm *metricsMiddleware) RetrieveCertDownloadToken(ctx context.Context, seri
 	return mm.svc.RetrieveCertDownloadToken(ctx, serialNumber)
 }
 
-func (mm *metricsMiddleware) RetrieveCAToken(ctx context.Context) (string, error) {
+func (mm *metricsMiddleware) RetrieveCAToken(ctx context.Context, issuer string) (string, error) {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "get_CA_token").Add(1)
 		mm.latency.With("method", "get_CA_token").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mm.svc.RetrieveCAToken(ctx)
+	return mm.svc.RetrieveCAToken(ctx, issuer)
 }
 
-func (mm *metricsMiddleware) IssueCert(ctx context.Context, entityID, ttl string, ipAddrs []string, options certs.SubjectOptions) (certs.Certificate, error) {
+func (mm *metricsMiddleware) IssueCert(ctx context.Context, entityID, issuer, profile, ttl string, ipAddrs []string, options certs.SubjectOptions) (certs.Certificate, error) {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "issue_certificate").Add(1)
 		mm.latency.With("method", "issue_certificate").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return mm.svc.IssueCert(ctx, entityID, ttl, ipAddrs, options)
+	return mm.svc.IssueCert(ctx, entityID, issuer, profile, ttl, ipAddrs, options)
 }
 
 func (mm *metricsMiddleware) ListCerts(ctx context.Context, pm certs.PageMetadata) (certs.CertificatePage, error) {
@@ -101,7 +101,7 @@ func (mm *metricsMiddleware) ViewCert(ctx context.Context, serialNumber string)
 	return mm.svc.ViewCert(ctx, serialNumber)
 }
 
-func (mm *metricsMiddleware) OCSP(ctx context.Context, serialNumber string) (*certs.Certificate, int, *x509.Certificate, error) {
+func (mm *metricsMiddleware) OCSP(ctx context.Context, serialNumber string) (*certs.Certificate, int, *certs.CA, error) {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "ocsp").Add(1)
 		mm.latency.With("method", "ocsp").Observe(time.Since(begin).Seconds())
@@ -117,12 +117,36 @@ func (mm *metricsMiddleware) GetEntityID(ctx context.Context, serialNumber strin
 	return mm.svc.GetEntityID(ctx, serialNumber)
 }
 
-func (mm *metricsMiddleware) GenerateCRL(ctx context.Context, caType certs.CertType) ([]byte, error) {
+func (mm *metricsMiddleware) GenerateCRL(ctx context.Context, caType certs.CertType, issuer string) ([]byte, error) {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "generate_crl").Add(1)
 		mm.latency.With("method", "generate_crl").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return mm.svc.GenerateCRL(ctx, caType)
+	return mm.svc.GenerateCRL(ctx, caType, issuer)
+}
+
+func (mm *metricsMiddleware) ViewCAChain(ctx context.Context, issuer string) (certs.Certificate, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "view_ca_chain").Add(1)
+		mm.latency.With("method", "view_ca_chain").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ViewCAChain(ctx, issuer)
+}
+
+func (mm *metricsMiddleware) TrustBundle(ctx context.Context) ([]byte, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "trust_bundle").Add(1)
+		mm.latency.With("method", "trust_bundle").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.TrustBundle(ctx)
+}
+
+func (mm *metricsMiddleware) ExportCA(ctx context.Context, serialNumber, passphrase string) ([]byte, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "export_ca").Add(1)
+		mm.latency.With("method", "export_ca").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ExportCA(ctx, serialNumber, passphrase)
 }
 
 func (mm *metricsMiddleware) GetChainCA(ctx context.Context, token string) (certs.Certificate, error) {
@@ -133,10 +157,178 @@ func (mm *metricsMiddleware) GetChainCA(ctx context.Context, token string) (cert
 	return mm.svc.GetChainCA(ctx, token)
 }
 
-func (mm *metricsMiddleware) IssueFromCSR(ctx context.Context, entityID, ttl string, csr certs.CSR) (certs.Certificate, error) {
+func (mm *metricsMiddleware) IssueFromCSR(ctx context.Context, entityID, issuer, profile, ttl string, csr certs.CSR) (certs.Certificate, error) {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "issue_from_csr").Add(1)
 		mm.latency.With("method", "issue_from_csr").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return mm.svc.IssueFromCSR(ctx, entityID, ttl, csr)
+	return mm.svc.IssueFromCSR(ctx, entityID, issuer, profile, ttl, csr)
+}
+
+func (mm *metricsMiddleware) SubmitCSR(ctx context.Context, csr certs.CSR) (certs.CSR, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "submit_csr").Add(1)
+		mm.latency.With("method", "submit_csr").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.SubmitCSR(ctx, csr)
+}
+
+func (mm *metricsMiddleware) ViewCSR(ctx context.Context, id string) (certs.CSR, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "view_csr").Add(1)
+		mm.latency.With("method", "view_csr").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ViewCSR(ctx, id)
+}
+
+func (mm *metricsMiddleware) ListCSRs(ctx context.Context, pm certs.PageMetadata) (certs.CSRPage, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "list_csrs").Add(1)
+		mm.latency.With("method", "list_csrs").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ListCSRs(ctx, pm)
+}
+
+func (mm *metricsMiddleware) ApproveCSR(ctx context.Context, id, ttl, profile string) (certs.CSR, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "approve_csr").Add(1)
+		mm.latency.With("method", "approve_csr").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ApproveCSR(ctx, id, ttl, profile)
+}
+
+func (mm *metricsMiddleware) RejectCSR(ctx context.Context, id, reason string) (certs.CSR, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "reject_csr").Add(1)
+		mm.latency.With("method", "reject_csr").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.RejectCSR(ctx, id, reason)
+}
+
+func (mm *metricsMiddleware) ImportCA(ctx context.Context, ca certs.CAImport) (certs.Certificate, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "import_ca").Add(1)
+		mm.latency.With("method", "import_ca").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ImportCA(ctx, ca)
+}
+
+func (mm *metricsMiddleware) GenerateIntermediateCSR(ctx context.Context) (certs.CSR, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "generate_intermediate_csr").Add(1)
+		mm.latency.With("method", "generate_intermediate_csr").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.GenerateIntermediateCSR(ctx)
+}
+
+func (mm *metricsMiddleware) InstallIntermediateCA(ctx context.Context, cert []byte) (certs.Certificate, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "install_intermediate_ca").Add(1)
+		mm.latency.With("method", "install_intermediate_ca").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.InstallIntermediateCA(ctx, cert)
+}
+
+func (mm *metricsMiddleware) CreateIssuer(ctx context.Context, name string) (certs.Issuer, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "create_issuer").Add(1)
+		mm.latency.With("method", "create_issuer").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.CreateIssuer(ctx, name)
+}
+
+func (mm *metricsMiddleware) ListIssuers(ctx context.Context) ([]certs.Issuer, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "list_issuers").Add(1)
+		mm.latency.With("method", "list_issuers").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ListIssuers(ctx)
+}
+
+func (mm *metricsMiddleware) RetireIssuer(ctx context.Context, name string) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "retire_issuer").Add(1)
+		mm.latency.With("method", "retire_issuer").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.RetireIssuer(ctx, name)
+}
+
+func (mm *metricsMiddleware) CreateProfile(ctx context.Context, profile certs.Profile) (certs.Profile, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "create_profile").Add(1)
+		mm.latency.With("method", "create_profile").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.CreateProfile(ctx, profile)
+}
+
+func (mm *metricsMiddleware) ViewProfile(ctx context.Context, name string) (certs.Profile, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "view_profile").Add(1)
+		mm.latency.With("method", "view_profile").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ViewProfile(ctx, name)
+}
+
+func (mm *metricsMiddleware) ListProfiles(ctx context.Context) ([]certs.Profile, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "list_profiles").Add(1)
+		mm.latency.With("method", "list_profiles").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ListProfiles(ctx)
+}
+
+func (mm *metricsMiddleware) UpdateProfile(ctx context.Context, profile certs.Profile) (certs.Profile, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "update_profile").Add(1)
+		mm.latency.With("method", "update_profile").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.UpdateProfile(ctx, profile)
+}
+
+func (mm *metricsMiddleware) RemoveProfile(ctx context.Context, name string) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "remove_profile").Add(1)
+		mm.latency.With("method", "remove_profile").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.RemoveProfile(ctx, name)
+}
+
+func (mm *metricsMiddleware) ViewCACert(ctx context.Context, serialNumber string) (certs.Certificate, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "view_ca_cert").Add(1)
+		mm.latency.With("method", "view_ca_cert").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ViewCACert(ctx, serialNumber)
+}
+
+func (mm *metricsMiddleware) GenerateCACRL(ctx context.Context, serialNumber string, delta bool) (certs.CRL, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "generate_ca_crl").Add(1)
+		mm.latency.With("method", "generate_ca_crl").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.GenerateCACRL(ctx, serialNumber, delta)
+}
+
+func (mm *metricsMiddleware) HoldCert(ctx context.Context, serialNumber string) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "hold_cert").Add(1)
+		mm.latency.With("method", "hold_cert").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.HoldCert(ctx, serialNumber)
+}
+
+func (mm *metricsMiddleware) ReleaseCert(ctx context.Context, serialNumber string) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "release_cert").Add(1)
+		mm.latency.With("method", "release_cert").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.ReleaseCert(ctx, serialNumber)
+}
+
+func (mm *metricsMiddleware) SignOCSP(ctx context.Context, issuerSerial string, template ocsp.Response, nonce []byte) (certs.OCSPResponse, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "sign_ocsp").Add(1)
+		mm.latency.With("method", "sign_ocsp").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.svc.SignOCSP(ctx, issuerSerial, template, nonce)
 }
\ No newline at end of file
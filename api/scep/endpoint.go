@@ -0,0 +1,97 @@
+package scep
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/certs/scep"
+)
+
+func caCapsEndpoint() endpoint.Endpoint {
+	return func(_ context.Context, _ interface{}) (interface{}, error) {
+		return caCapsRes{caps: scep.Capabilities}, nil
+	}
+}
+
+func caCertEndpoint(svc scep.Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		certs, err := svc.CACerts(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return caCertRes{certs: certs}, nil
+	}
+}
+
+func pkiOperationEndpoint(svc scep.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(pkiOperationReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		msg, err := svc.PKIOperation(ctx, req.msg)
+		if err != nil {
+			return nil, err
+		}
+
+		return pkiOperationRes{msg: msg}, nil
+	}
+}
+
+func createChallengeEndpoint(svc scep.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createChallengeReq)
+		ttl, err := req.validate()
+		if err != nil {
+			return nil, err
+		}
+
+		challenge, err := svc.CreateChallenge(ctx, req.EntityID, ttl)
+		if err != nil {
+			return nil, err
+		}
+
+		return challengeRes{Challenge: challenge}, nil
+	}
+}
+
+func listRequestsEndpoint(svc scep.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequestsReq)
+
+		reqs, err := svc.ListRequests(ctx, req.status)
+		if err != nil {
+			return nil, err
+		}
+
+		return listRequestsRes{Requests: reqs}, nil
+	}
+}
+
+func approveRequestEndpoint(svc scep.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(requestReq)
+
+		r, err := svc.ApproveRequest(ctx, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return requestRes{Request: r}, nil
+	}
+}
+
+func rejectRequestEndpoint(svc scep.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(rejectRequestReq)
+
+		r, err := svc.RejectRequest(ctx, req.id, req.Reason)
+		if err != nil {
+			return nil, err
+		}
+
+		return requestRes{Request: r}, nil
+	}
+}
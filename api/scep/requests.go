@@ -0,0 +1,47 @@
+package scep
+
+import (
+	"time"
+
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/scep"
+)
+
+type pkiOperationReq struct {
+	msg []byte
+}
+
+func (req pkiOperationReq) validate() error {
+	if len(req.msg) == 0 {
+		return errors.Wrap(scep.ErrMalformed, errMissingMessage)
+	}
+
+	return nil
+}
+
+type createChallengeReq struct {
+	EntityID string `json:"entity_id"`
+	TTL      string `json:"ttl"`
+}
+
+// validate validates the request and returns its TTL.
+func (req createChallengeReq) validate() (time.Duration, error) {
+	if req.EntityID == "" {
+		return 0, errors.Wrap(scep.ErrMalformed, errors.New("missing entity ID"))
+	}
+
+	return parseTTL(req.TTL)
+}
+
+type listRequestsReq struct {
+	status scep.Status
+}
+
+type requestReq struct {
+	id string
+}
+
+type rejectRequestReq struct {
+	id     string
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,86 @@
+package scep
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/hantdev/certs/scep"
+)
+
+var (
+	_ Response = (*challengeRes)(nil)
+	_ Response = (*listRequestsRes)(nil)
+	_ Response = (*requestRes)(nil)
+)
+
+// Response contains HTTP response specific methods.
+type Response interface {
+	// Code returns HTTP response code.
+	Code() int
+
+	// Headers returns map of HTTP headers with their values.
+	Headers() map[string]string
+
+	// Empty indicates if HTTP response has content.
+	Empty() bool
+}
+
+type caCapsRes struct {
+	caps []string
+}
+
+type caCertRes struct {
+	certs []*x509.Certificate
+}
+
+type pkiOperationRes struct {
+	msg []byte
+}
+
+type challengeRes struct {
+	scep.Challenge
+}
+
+func (res challengeRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res challengeRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res challengeRes) Empty() bool {
+	return false
+}
+
+type listRequestsRes struct {
+	Requests []scep.Request `json:"requests"`
+}
+
+func (res listRequestsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res listRequestsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res listRequestsRes) Empty() bool {
+	return false
+}
+
+type requestRes struct {
+	scep.Request
+}
+
+func (res requestRes) Code() int {
+	return http.StatusOK
+}
+
+func (res requestRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res requestRes) Empty() bool {
+	return false
+}
@@ -0,0 +1,297 @@
+// Package scep contains the HTTP transport of the SCEP (RFC 8894) responder.
+package scep
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/certs/auth"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/cms"
+	"github.com/hantdev/certs/scep"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	// CACertType is the content type of the registration authority
+	// certificate together with the CA certificates.
+	CACertType = "application/x-x509-ca-ra-cert"
+	// PKIMessageType is the content type of the PKI messages.
+	PKIMessageType = "application/x-pki-message"
+	// ContentType is the content type of the management API.
+	ContentType = "application/json"
+
+	operationKey = "operation"
+	messageKey   = "message"
+	statusKey    = "status"
+	idKey        = "transactionID"
+
+	// maxMessageSize limits the size of the PKI messages and of the
+	// management requests.
+	maxMessageSize = 1 << 16
+)
+
+var (
+	errUnknownOperation = errors.New("unknown operation")
+	errMissingMessage   = errors.New("missing message")
+	errInvalidTTL       = errors.New("invalid ttl")
+)
+
+// MakeHandler returns a HTTP handler for the SCEP endpoints. The protocol is
+// served at /scep and at the /cgi-bin/pkiclient.exe path some clients
+// insist on; the challenges and requests are managed below /scep. Unless
+// authn is nil, managing them requires authentication.
+func MakeHandler(svc scep.Service, authn auth.Authenticator, logger *slog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(loggingErrorEncoder(logger, EncodeError)),
+	}
+
+	operations := map[string]http.Handler{
+		scep.OpGetCACaps: otelhttp.NewHandler(kithttp.NewServer(
+			caCapsEndpoint(),
+			decodeEmpty,
+			encodeCACapsResponse,
+			opts...,
+		), "scep_get_ca_caps"),
+		scep.OpGetCACert: otelhttp.NewHandler(kithttp.NewServer(
+			caCertEndpoint(svc),
+			decodeEmpty,
+			encodeCACertResponse,
+			opts...,
+		), "scep_get_ca_cert"),
+		scep.OpPKIOperation: otelhttp.NewHandler(kithttp.NewServer(
+			pkiOperationEndpoint(svc),
+			decodePKIOperation,
+			encodePKIOperationResponse,
+			opts...,
+		), "scep_pki_operation"),
+	}
+	pkiclient := func(w http.ResponseWriter, r *http.Request) {
+		h, ok := operations[r.URL.Query().Get(operationKey)]
+		if !ok {
+			EncodeError(r.Context(), errors.Wrap(scep.ErrMalformed, errUnknownOperation), w)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}
+
+	r := chi.NewRouter()
+	r.Get("/cgi-bin/pkiclient.exe", pkiclient)
+	r.Post("/cgi-bin/pkiclient.exe", pkiclient)
+	r.Route("/scep", func(r chi.Router) {
+		r.Get("/", pkiclient)
+		r.Post("/", pkiclient)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Authenticate(authn, EncodeError))
+		manage := auth.Authorize(EncodeError, auth.RoleAdmin, auth.RoleIssuer)
+		read := auth.Authorize(EncodeError, auth.RoleAdmin, auth.RoleIssuer, auth.RoleAuditor)
+
+		r.With(manage).Post("/scep/challenges", otelhttp.NewHandler(kithttp.NewServer(
+			createChallengeEndpoint(svc),
+			decodeCreateChallenge,
+			EncodeResponse,
+			opts...,
+		), "scep_create_challenge").ServeHTTP)
+		r.With(read).Get("/scep/requests", otelhttp.NewHandler(kithttp.NewServer(
+			listRequestsEndpoint(svc),
+			decodeListRequests,
+			EncodeResponse,
+			opts...,
+		), "scep_list_requests").ServeHTTP)
+		r.With(manage).Patch("/scep/requests/{transactionID}/approve", otelhttp.NewHandler(kithttp.NewServer(
+			approveRequestEndpoint(svc),
+			decodeRequestID,
+			EncodeResponse,
+			opts...,
+		), "scep_approve_request").ServeHTTP)
+		r.With(manage).Patch("/scep/requests/{transactionID}/reject", otelhttp.NewHandler(kithttp.NewServer(
+			rejectRequestEndpoint(svc),
+			decodeRejectRequest,
+			EncodeResponse,
+			opts...,
+		), "scep_reject_request").ServeHTTP)
+	})
+
+	return r
+}
+
+func decodeEmpty(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodePKIOperation reads the message of a POST body or of the base64
+// encoded message query parameter of a GET.
+func decodePKIOperation(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == http.MethodPost {
+		msg, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+		if err != nil {
+			return nil, errors.Wrap(scep.ErrMalformed, err)
+		}
+		return pkiOperationReq{msg: msg}, nil
+	}
+
+	// Clients do not always escape the message, so a plus sign may
+	// have been decoded as a space.
+	encoded := strings.ReplaceAll(r.URL.Query().Get(messageKey), " ", "+")
+	msg, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(scep.ErrMalformed, err)
+	}
+
+	return pkiOperationReq{msg: msg}, nil
+}
+
+func decodeCreateChallenge(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), ContentType) {
+		return nil, errors.Wrap(scep.ErrMalformed, errors.New("unsupported content type"))
+	}
+	req := createChallengeReq{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxMessageSize)).Decode(&req); err != nil {
+		return nil, errors.Wrap(scep.ErrMalformed, err)
+	}
+
+	return req, nil
+}
+
+func decodeListRequests(_ context.Context, r *http.Request) (interface{}, error) {
+	return listRequestsReq{status: scep.Status(r.URL.Query().Get(statusKey))}, nil
+}
+
+func decodeRequestID(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := requestID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return requestReq{id: id}, nil
+}
+
+func decodeRejectRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := requestID(r)
+	if err != nil {
+		return nil, err
+	}
+	req := rejectRequestReq{id: id}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxMessageSize)).Decode(&req); err != nil && err != io.EOF {
+			return nil, errors.Wrap(scep.ErrMalformed, err)
+		}
+	}
+
+	return req, nil
+}
+
+// requestID returns the transaction ID of the path, which clients may
+// choose to contain characters that have to be escaped.
+func requestID(r *http.Request) (string, error) {
+	id, err := url.PathUnescape(chi.URLParam(r, idKey))
+	if err != nil {
+		return "", errors.Wrap(scep.ErrMalformed, err)
+	}
+
+	return id, nil
+}
+
+func encodeCACapsResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(caCapsRes)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err := io.WriteString(w, strings.Join(res.caps, "\n")+"\n")
+
+	return err
+}
+
+func encodeCACertResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(caCertRes)
+	der, err := cms.MarshalCertsOnly(res.certs)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", CACertType)
+	_, err = w.Write(der)
+
+	return err
+}
+
+func encodePKIOperationResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(pkiOperationRes)
+	w.Header().Set("Content-Type", PKIMessageType)
+	_, err := w.Write(res.msg)
+
+	return err
+}
+
+// EncodeResponse encodes the responses of the management API.
+func EncodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	if ar, ok := response.(Response); ok {
+		for k, v := range ar.Headers() {
+			w.Header().Set(k, v)
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.WriteHeader(ar.Code())
+
+		if ar.Empty() {
+			return nil
+		}
+	}
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+// EncodeError encodes the error as a plain text response.
+func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	switch {
+	case errors.Contains(err, auth.ErrUnauthenticated):
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, auth.ErrUnauthenticated.Error()+"\n")
+		return
+	case errors.Contains(err, auth.ErrForbidden):
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, auth.ErrForbidden.Error()+"\n")
+		return
+	case errors.Contains(err, scep.ErrMalformed):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Contains(err, scep.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Contains(err, scep.ErrNotPending):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		// Internal errors are not disclosed to clients.
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, "internal server error\n")
+		return
+	}
+	_, _ = io.WriteString(w, err.Error()+"\n")
+}
+
+func loggingErrorEncoder(logger *slog.Logger, enc kithttp.ErrorEncoder) kithttp.ErrorEncoder {
+	return func(ctx context.Context, err error, w http.ResponseWriter) {
+		if !errors.Contains(err, scep.ErrMalformed) && !errors.Contains(err, scep.ErrNotFound) &&
+			!errors.Contains(err, scep.ErrNotPending) {
+			logger.Error(err.Error())
+		}
+		enc(ctx, err, w)
+	}
+}
+
+func parseTTL(ttl string) (time.Duration, error) {
+	if ttl == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil || d < 0 {
+		return 0, errors.Wrap(scep.ErrMalformed, errInvalidTTL)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,99 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/certs/webhooks"
+)
+
+func createSubscriptionEndpoint(svc webhooks.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createSubscriptionReq)
+		sub, err := svc.CreateSubscription(ctx, webhooks.Subscription{
+			URL:    req.URL,
+			Events: req.Events,
+			Secret: req.Secret,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return subscriptionRes{Subscription: sub, created: true}, nil
+	}
+}
+
+func viewSubscriptionEndpoint(svc webhooks.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(subscriptionReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		sub, err := svc.ViewSubscription(ctx, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return subscriptionRes{Subscription: sub}, nil
+	}
+}
+
+func listSubscriptionsEndpoint(svc webhooks.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listSubscriptionsReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		page, err := svc.ListSubscriptions(ctx, req.offset, req.limit)
+		if err != nil {
+			return nil, err
+		}
+
+		return listSubscriptionsRes{SubscriptionsPage: page}, nil
+	}
+}
+
+func removeSubscriptionEndpoint(svc webhooks.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(subscriptionReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.RemoveSubscription(ctx, req.id); err != nil {
+			return nil, err
+		}
+
+		return removeSubscriptionRes{}, nil
+	}
+}
+
+func listDeliveriesEndpoint(svc webhooks.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listDeliveriesReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		page, err := svc.ListDeliveries(ctx, req.DeliveryFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		return listDeliveriesRes{DeliveriesPage: page}, nil
+	}
+}
+
+func replayDeliveryEndpoint(svc webhooks.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deliveryReq)
+		d, err := svc.ReplayDelivery(ctx, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return deliveryRes{Delivery: d}, nil
+	}
+}
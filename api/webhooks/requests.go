@@ -0,0 +1,59 @@
+package webhooks
+
+import (
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/webhooks"
+)
+
+var (
+	errLimitSize = errors.New("limit must be between 1 and 100")
+	errMissingID = errors.New("missing subscription ID")
+)
+
+type createSubscriptionReq struct {
+	URL    string               `json:"url"`
+	Events []webhooks.EventType `json:"events,omitempty"`
+	Secret string               `json:"secret,omitempty"`
+}
+
+type listSubscriptionsReq struct {
+	offset uint64
+	limit  uint64
+}
+
+func (req listSubscriptionsReq) validate() error {
+	return validateLimit(req.limit)
+}
+
+type subscriptionReq struct {
+	id string
+}
+
+func (req subscriptionReq) validate() error {
+	if req.id == "" {
+		return errors.Wrap(certs.ErrMalformedEntity, errMissingID)
+	}
+
+	return nil
+}
+
+type listDeliveriesReq struct {
+	webhooks.DeliveryFilter
+}
+
+func (req listDeliveriesReq) validate() error {
+	return validateLimit(req.Limit)
+}
+
+type deliveryReq struct {
+	id uint64
+}
+
+func validateLimit(limit uint64) error {
+	if limit == 0 || limit > maxLimit {
+		return errors.Wrap(certs.ErrMalformedEntity, errLimitSize)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/hantdev/certs/webhooks"
+)
+
+type subscriptionRes struct {
+	webhooks.Subscription
+	created bool
+}
+
+func (res subscriptionRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res subscriptionRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res subscriptionRes) Empty() bool {
+	return false
+}
+
+type listSubscriptionsRes struct {
+	webhooks.SubscriptionsPage
+}
+
+func (res listSubscriptionsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res listSubscriptionsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res listSubscriptionsRes) Empty() bool {
+	return false
+}
+
+type removeSubscriptionRes struct{}
+
+func (res removeSubscriptionRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res removeSubscriptionRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res removeSubscriptionRes) Empty() bool {
+	return true
+}
+
+type listDeliveriesRes struct {
+	webhooks.DeliveriesPage
+}
+
+func (res listDeliveriesRes) Code() int {
+	return http.StatusOK
+}
+
+func (res listDeliveriesRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res listDeliveriesRes) Empty() bool {
+	return false
+}
+
+type deliveryRes struct {
+	webhooks.Delivery
+}
+
+func (res deliveryRes) Code() int {
+	return http.StatusOK
+}
+
+func (res deliveryRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res deliveryRes) Empty() bool {
+	return false
+}
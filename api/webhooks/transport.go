@@ -0,0 +1,167 @@
+// Package webhooks contains the HTTP transport of the webhooks API.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/certs"
+	httpapi "github.com/hantdev/certs/api/http"
+	"github.com/hantdev/certs/auth"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/webhooks"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	idKey           = "id"
+	subscriptionKey = "subscription_id"
+	statusKey       = "status"
+	offsetKey       = "offset"
+	limitKey        = "limit"
+	defLimit        = 10
+	maxLimit        = 100
+	defOffset       = 0
+	// maxBodySize limits the size of the request bodies.
+	maxBodySize = 1 << 16
+)
+
+// MakeHandler returns a HTTP handler for the webhooks endpoints. Unless
+// authn is nil, callers must authenticate as admins.
+func MakeHandler(svc webhooks.Service, authn auth.Authenticator, logger *slog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(loggingErrorEncoder(logger, httpapi.EncodeError)),
+	}
+
+	r := chi.NewRouter()
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Use(auth.Authenticate(authn, httpapi.EncodeError))
+		r.Use(auth.Authorize(httpapi.EncodeError, auth.RoleAdmin))
+
+		r.Route("/subscriptions", func(r chi.Router) {
+			r.Post("/", otelhttp.NewHandler(kithttp.NewServer(
+				createSubscriptionEndpoint(svc),
+				decodeCreateSubscription,
+				httpapi.EncodeResponse,
+				opts...,
+			), "create_webhook_subscription").ServeHTTP)
+			r.Get("/", otelhttp.NewHandler(kithttp.NewServer(
+				listSubscriptionsEndpoint(svc),
+				decodeListSubscriptions,
+				httpapi.EncodeResponse,
+				opts...,
+			), "list_webhook_subscriptions").ServeHTTP)
+			r.Get("/{id}", otelhttp.NewHandler(kithttp.NewServer(
+				viewSubscriptionEndpoint(svc),
+				decodeSubscriptionID,
+				httpapi.EncodeResponse,
+				opts...,
+			), "view_webhook_subscription").ServeHTTP)
+			r.Delete("/{id}", otelhttp.NewHandler(kithttp.NewServer(
+				removeSubscriptionEndpoint(svc),
+				decodeSubscriptionID,
+				httpapi.EncodeResponse,
+				opts...,
+			), "remove_webhook_subscription").ServeHTTP)
+		})
+		r.Get("/deliveries", otelhttp.NewHandler(kithttp.NewServer(
+			listDeliveriesEndpoint(svc),
+			decodeListDeliveries,
+			httpapi.EncodeResponse,
+			opts...,
+		), "list_webhook_deliveries").ServeHTTP)
+		r.Post("/deliveries/{id}/replay", otelhttp.NewHandler(kithttp.NewServer(
+			replayDeliveryEndpoint(svc),
+			decodeDeliveryID,
+			httpapi.EncodeResponse,
+			opts...,
+		), "replay_webhook_delivery").ServeHTTP)
+	})
+
+	return r
+}
+
+func decodeCreateSubscription(_ context.Context, r *http.Request) (interface{}, error) {
+	req := createSubscriptionReq{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&req); err != nil {
+		return nil, errors.Wrap(httpapi.ErrInvalidRequest, err)
+	}
+
+	return req, nil
+}
+
+func decodeListSubscriptions(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	req := listSubscriptionsReq{}
+	var err error
+	if req.offset, err = readNum(q.Get(offsetKey), defOffset); err != nil {
+		return nil, err
+	}
+	if req.limit, err = readNum(q.Get(limitKey), defLimit); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func decodeSubscriptionID(_ context.Context, r *http.Request) (interface{}, error) {
+	return subscriptionReq{id: chi.URLParam(r, idKey)}, nil
+}
+
+func decodeListDeliveries(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	req := listDeliveriesReq{
+		DeliveryFilter: webhooks.DeliveryFilter{
+			SubscriptionID: q.Get(subscriptionKey),
+			Status:         webhooks.Status(q.Get(statusKey)),
+		},
+	}
+	var err error
+	if req.Offset, err = readNum(q.Get(offsetKey), defOffset); err != nil {
+		return nil, err
+	}
+	if req.Limit, err = readNum(q.Get(limitKey), defLimit); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func decodeDeliveryID(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := strconv.ParseUint(chi.URLParam(r, idKey), 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(httpapi.ErrInvalidRequest, err)
+	}
+
+	return deliveryReq{id: id}, nil
+}
+
+func readNum(val string, def uint64) (uint64, error) {
+	if val == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(httpapi.ErrInvalidQueryParams, err)
+	}
+
+	return n, nil
+}
+
+// loggingErrorEncoder logs the errors which are not caused by a malformed request.
+func loggingErrorEncoder(logger *slog.Logger, enc kithttp.ErrorEncoder) kithttp.ErrorEncoder {
+	return func(ctx context.Context, err error, w http.ResponseWriter) {
+		if !errors.Contains(err, httpapi.ErrInvalidQueryParams) && !errors.Contains(err, httpapi.ErrInvalidRequest) &&
+			!errors.Contains(err, httpapi.ErrValidation) && !errors.Contains(err, certs.ErrMalformedEntity) &&
+			!errors.Contains(err, certs.ErrNotFound) {
+			logger.Error(err.Error())
+		}
+		enc(ctx, err, w)
+	}
+}
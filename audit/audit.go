@@ -0,0 +1,141 @@
+// Package audit keeps a tamper-evident record of the certificate lifecycle
+// operations of the certs service.
+//
+// Events are appended to a log in which each event holds the hash of the
+// previous one, so that changing or removing an event breaks the chain from
+// that event on. The log is verified by recomputing the chain.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// Action is the operation an event records.
+type Action string
+
+const (
+	ActionIssueCert             Action = "issue_cert"
+	ActionRenewCert             Action = "renew_cert"
+	ActionRevokeCert            Action = "revoke_cert"
+	ActionHoldCert              Action = "hold_cert"
+	ActionReleaseCert           Action = "release_cert"
+	ActionDeleteCerts           Action = "delete_certs"
+	ActionDownloadCert          Action = "download_cert"
+	ActionDownloadCA            Action = "download_ca"
+	ActionExportCA              Action = "export_ca"
+	ActionIssueFromCSR          Action = "issue_from_csr"
+	ActionSubmitCSR             Action = "submit_csr"
+	ActionApproveCSR            Action = "approve_csr"
+	ActionRejectCSR             Action = "reject_csr"
+	ActionImportCA              Action = "import_ca"
+	ActionGenerateIntermediate  Action = "generate_intermediate_csr"
+	ActionInstallIntermediateCA Action = "install_intermediate_ca"
+	ActionCreateIssuer          Action = "create_issuer"
+	ActionRetireIssuer          Action = "retire_issuer"
+	ActionCreateProfile         Action = "create_profile"
+	ActionUpdateProfile         Action = "update_profile"
+	ActionRemoveProfile         Action = "remove_profile"
+)
+
+// Result is the outcome of the recorded operation.
+type Result string
+
+const (
+	ResultSuccess Result = "success"
+	ResultFailure Result = "failure"
+)
+
+// AnonymousActor is the actor of operations of unauthenticated callers,
+// such as the enrollment protocols and the API with authentication disabled.
+const AnonymousActor = "anonymous"
+
+var (
+	// ErrConflict indicates an event with the sequence number of the
+	// appended event already exists.
+	ErrConflict = errors.New("audit event already exists")
+	// ErrInvalidTimeRange indicates a filter ending before it starts.
+	ErrInvalidTimeRange = errors.New("invalid time range, to is before from")
+)
+
+// Event is a recorded operation. Seq numbers the events from 1 without
+// gaps, PrevHash is the hash of the previous event and Hash the hash of the
+// event, both hex encoded SHA-256 digests.
+type Event struct {
+	Seq          uint64            `json:"seq"`
+	Time         time.Time         `json:"time"`
+	Actor        string            `json:"actor"`
+	Role         string            `json:"role,omitempty"`
+	Action       Action            `json:"action"`
+	SerialNumber string            `json:"serial_number,omitempty"`
+	EntityID     string            `json:"entity_id,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`
+	Result       Result            `json:"result"`
+	Error        string            `json:"error,omitempty"`
+	PrevHash     string            `json:"prev_hash"`
+	Hash         string            `json:"hash"`
+}
+
+// Filter selects events. Empty fields and zero times match all events.
+type Filter struct {
+	Actor        string    `json:"actor,omitempty"`
+	Action       Action    `json:"action,omitempty"`
+	SerialNumber string    `json:"serial_number,omitempty"`
+	EntityID     string    `json:"entity_id,omitempty"`
+	Result       Result    `json:"result,omitempty"`
+	From         time.Time `json:"from,omitzero"`
+	To           time.Time `json:"to,omitzero"`
+	Offset       uint64    `json:"offset"`
+	Limit        uint64    `json:"limit"`
+	Total        uint64    `json:"total"`
+}
+
+// Page is a page of events, the most recent first.
+type Page struct {
+	Filter
+	Events []Event `json:"events"`
+}
+
+// Verification is the result of verifying the chain. Events is the number
+// of events verified before the first broken event, if any.
+type Verification struct {
+	Valid    bool   `json:"valid"`
+	Events   uint64 `json:"events"`
+	LastHash string `json:"last_hash,omitempty"`
+	BrokenAt uint64 `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Service specifies the audit log API.
+type Service interface {
+	// Record appends the event to the log and returns it with its sequence
+	// number and hashes.
+	Record(ctx context.Context, event Event) (Event, error)
+
+	// ListEvents lists the events matching the filter, the most recent first.
+	ListEvents(ctx context.Context, filter Filter) (Page, error)
+
+	// Verify recomputes the hash chain of the whole log.
+	Verify(ctx context.Context) (Verification, error)
+}
+
+// Repository specifies the audit log persistence API. Events are never
+// updated or removed.
+type Repository interface {
+	// Last retrieves the event with the highest sequence number, or the
+	// zero event if the log is empty.
+	Last(ctx context.Context) (Event, error)
+
+	// Append stores the event. ErrConflict is returned if an event with
+	// the same sequence number exists.
+	Append(ctx context.Context, event Event) error
+
+	// List lists the events matching the filter, the most recent first.
+	List(ctx context.Context, filter Filter) (Page, error)
+
+	// Range retrieves at most limit events in sequence order, starting
+	// with the event with the sequence number from.
+	Range(ctx context.Context, from, limit uint64) ([]Event, error)
+}
@@ -0,0 +1,372 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hantdev/certs"
+	auditapi "github.com/hantdev/certs/api/audit"
+	"github.com/hantdev/certs/audit"
+	amocks "github.com/hantdev/certs/audit/mocks"
+	"github.com/hantdev/certs/auth"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const serialNumber = "serial number"
+
+// memoryLog is an audit log repository backed by a slice, which stores the
+// events in sequence order.
+type memoryLog struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (l *memoryLog) repository() *amocks.MockRepository {
+	repo := new(amocks.MockRepository)
+	repo.On("Last", mock.Anything).Return(func(context.Context) audit.Event {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.events) == 0 {
+			return audit.Event{}
+		}
+		return l.events[len(l.events)-1]
+	}, nil)
+	repo.On("Append", mock.Anything, mock.Anything).Return(func(_ context.Context, e audit.Event) error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if e.Seq <= uint64(len(l.events)) {
+			return audit.ErrConflict
+		}
+		l.events = append(l.events, e)
+		return nil
+	})
+	repo.On("Range", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, from, limit uint64) []audit.Event {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		var events []audit.Event
+		for _, e := range l.events {
+			if e.Seq >= from && uint64(len(events)) < limit {
+				events = append(events, e)
+			}
+		}
+		return events
+	}, nil)
+	repo.On("List", mock.Anything, mock.Anything).Return(func(_ context.Context, f audit.Filter) audit.Page {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		page := audit.Page{Filter: f, Events: []audit.Event{}}
+		for i := len(l.events) - 1; i >= 0; i-- {
+			if e := l.events[i]; f.Action == "" || e.Action == f.Action {
+				page.Events = append(page.Events, e)
+			}
+		}
+		page.Total = uint64(len(page.Events))
+		return page
+	}, nil)
+
+	return repo
+}
+
+func TestRecord(t *testing.T) {
+	log := &memoryLog{}
+	svc := audit.NewService(log.repository())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Record(context.Background(), audit.Event{Actor: "admin", Action: audit.ActionIssueCert, Result: audit.ResultSuccess})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, log.events, 10)
+	assert.Equal(t, strings.Repeat("0", 64), log.events[0].PrevHash)
+	for i, e := range log.events {
+		assert.Equal(t, uint64(i+1), e.Seq)
+		assert.Len(t, e.Hash, 64)
+		assert.False(t, e.Time.IsZero())
+		if i > 0 {
+			assert.Equal(t, log.events[i-1].Hash, e.PrevHash)
+		}
+	}
+
+	v, err := svc.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, audit.Verification{Valid: true, Events: 10, LastHash: log.events[9].Hash}, v)
+}
+
+func TestRecordConflict(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		conflicts int
+		err       error
+	}{
+		{
+			desc:      "append after another instance appended",
+			conflicts: 1,
+			err:       nil,
+		},
+		{
+			desc:      "append while other instances keep appending",
+			conflicts: 5,
+			err:       audit.ErrConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := new(amocks.MockRepository)
+			repo.On("Last", mock.Anything).Return(audit.Event{}, nil)
+			repo.On("Append", mock.Anything, mock.Anything).Return(audit.ErrConflict).Times(tc.conflicts)
+			repo.On("Append", mock.Anything, mock.Anything).Return(nil)
+			svc := audit.NewService(repo)
+
+			_, err := svc.Record(context.Background(), audit.Event{Action: audit.ActionRevokeCert})
+			assert.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		tamper   func(events []audit.Event) []audit.Event
+		valid    bool
+		events   uint64
+		brokenAt uint64
+	}{
+		{
+			desc:   "verify untouched log",
+			tamper: func(events []audit.Event) []audit.Event { return events },
+			valid:  true,
+			events: 5,
+		},
+		{
+			desc:   "verify empty log",
+			tamper: func([]audit.Event) []audit.Event { return nil },
+			valid:  true,
+			events: 0,
+		},
+		{
+			desc: "verify log with changed event",
+			tamper: func(events []audit.Event) []audit.Event {
+				events[2].Actor = "someone else"
+				return events
+			},
+			valid:    false,
+			events:   2,
+			brokenAt: 3,
+		},
+		{
+			desc: "verify log with changed and rehashed event",
+			tamper: func(events []audit.Event) []audit.Event {
+				events[1].Result = audit.ResultSuccess
+				events[1].Hash = strings.Repeat("1", 64)
+				return events
+			},
+			valid:    false,
+			events:   1,
+			brokenAt: 2,
+		},
+		{
+			desc: "verify log with removed event",
+			tamper: func(events []audit.Event) []audit.Event {
+				return append(events[:3], events[4:]...)
+			},
+			valid:    false,
+			events:   3,
+			brokenAt: 4,
+		},
+		{
+			desc: "verify log with removed first event",
+			tamper: func(events []audit.Event) []audit.Event {
+				return events[1:]
+			},
+			valid:    false,
+			events:   0,
+			brokenAt: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			log := &memoryLog{}
+			svc := audit.NewService(log.repository())
+			for i := 0; i < 5; i++ {
+				_, err := svc.Record(context.Background(), audit.Event{
+					Actor:        "admin",
+					Action:       audit.ActionRevokeCert,
+					SerialNumber: serialNumber,
+					Params:       map[string]string{"reason": "keyCompromise"},
+					Result:       audit.ResultFailure,
+				})
+				require.NoError(t, err)
+			}
+			log.events = tc.tamper(log.events)
+
+			v, err := svc.Verify(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tc.valid, v.Valid)
+			assert.Equal(t, tc.events, v.Events)
+			assert.Equal(t, tc.brokenAt, v.BrokenAt)
+			if !tc.valid {
+				assert.NotEmpty(t, v.Reason)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	log := &memoryLog{}
+	auditSvc := audit.NewService(log.repository())
+	certsSvc := new(mocks.MockService)
+	certsSvc.On("GetEntityID", mock.Anything, serialNumber).Return("entity", nil)
+	certsSvc.On("RevokeCert", mock.Anything, serialNumber, certs.RevocationKeyCompromise, mock.Anything).Return(nil).Once()
+	certsSvc.On("RevokeCert", mock.Anything, serialNumber, mock.Anything, mock.Anything).Return(certs.ErrCertAlreadyRevoked)
+	certsSvc.On("ExportCA", mock.Anything, serialNumber, mock.Anything).Return([]byte("backup"), nil)
+	certsSvc.On("ListCerts", mock.Anything, mock.Anything).Return(certs.CertificatePage{}, nil)
+	svc := audit.Middleware(certsSvc, auditSvc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Subject: "ops", Role: auth.RoleAdmin})
+	require.NoError(t, svc.RevokeCert(ctx, serialNumber, certs.RevocationKeyCompromise, time.Time{}))
+	err := svc.RevokeCert(context.Background(), serialNumber, certs.RevocationSuperseded, time.Time{})
+	assert.True(t, errors.Contains(err, certs.ErrCertAlreadyRevoked), "expected error %v, got %v", certs.ErrCertAlreadyRevoked, err)
+	_, err = svc.ExportCA(ctx, serialNumber, "secret passphrase")
+	require.NoError(t, err)
+	_, err = svc.ListCerts(ctx, certs.PageMetadata{})
+	require.NoError(t, err)
+
+	require.Len(t, log.events, 3, "read-only operations must not be recorded")
+
+	revoked := log.events[0]
+	assert.Equal(t, "ops", revoked.Actor)
+	assert.Equal(t, string(auth.RoleAdmin), revoked.Role)
+	assert.Equal(t, audit.ActionRevokeCert, revoked.Action)
+	assert.Equal(t, serialNumber, revoked.SerialNumber)
+	assert.Equal(t, "entity", revoked.EntityID)
+	assert.Equal(t, map[string]string{"reason": certs.RevocationKeyCompromise.String()}, revoked.Params)
+	assert.Equal(t, audit.ResultSuccess, revoked.Result)
+
+	failed := log.events[1]
+	assert.Equal(t, audit.AnonymousActor, failed.Actor)
+	assert.Equal(t, audit.ResultFailure, failed.Result)
+	assert.Equal(t, certs.ErrCertAlreadyRevoked.Error(), failed.Error)
+
+	exported := log.events[2]
+	assert.Equal(t, audit.ActionExportCA, exported.Action)
+	data, err := json.Marshal(exported)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret passphrase")
+}
+
+// roleAuthn authenticates callers with the role of the X-Role header.
+type roleAuthn struct{}
+
+func (roleAuthn) Authenticate(r *http.Request) (auth.Identity, error) {
+	return auth.Identity{Subject: "tester", Role: auth.Role(r.Header.Get("X-Role"))}, nil
+}
+
+func TestHandler(t *testing.T) {
+	log := &memoryLog{}
+	svc := audit.NewService(log.repository())
+	for _, action := range []audit.Action{audit.ActionIssueCert, audit.ActionRevokeCert, audit.ActionIssueCert} {
+		_, err := svc.Record(context.Background(), audit.Event{Actor: "admin", Action: action, Result: audit.ResultSuccess})
+		require.NoError(t, err)
+	}
+	srv := httptest.NewServer(auditapi.MakeHandler(svc, roleAuthn{}, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer srv.Close()
+
+	testCases := []struct {
+		desc   string
+		path   string
+		role   auth.Role
+		status int
+		events int
+	}{
+		{
+			desc:   "list events as auditor",
+			path:   "/audit/events",
+			role:   auth.RoleAuditor,
+			status: http.StatusOK,
+			events: 3,
+		},
+		{
+			desc:   "list events filtered by action",
+			path:   "/audit/events?action=issue_cert",
+			role:   auth.RoleAdmin,
+			status: http.StatusOK,
+			events: 2,
+		},
+		{
+			desc:   "list events as issuer",
+			path:   "/audit/events",
+			role:   auth.RoleIssuer,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "list events with invalid time",
+			path:   "/audit/events?from=yesterday",
+			role:   auth.RoleAuditor,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "list events with reversed time range",
+			path:   "/audit/events?from=2026-01-02T00:00:00Z&to=2026-01-01T00:00:00Z",
+			role:   auth.RoleAuditor,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "list events with limit above maximum",
+			path:   "/audit/events?limit=1000",
+			role:   auth.RoleAuditor,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "verify log as auditor",
+			path:   "/audit/verify",
+			role:   auth.RoleAuditor,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+tc.path, nil)
+			require.NoError(t, err)
+			req.Header.Set("X-Role", string(tc.role))
+			res, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			if tc.path == "/audit/verify" {
+				var v audit.Verification
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&v))
+				assert.True(t, v.Valid)
+				assert.Equal(t, uint64(3), v.Events)
+				return
+			}
+			var page audit.Page
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&page))
+			assert.Len(t, page.Events, tc.events)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/auth"
+)
+
+var _ certs.Service = (*auditMiddleware)(nil)
+
+// auditMiddleware records the certificate lifecycle operations of the certs
+// service. Read-only operations other than downloads are passed through.
+type auditMiddleware struct {
+	certs.Service
+	audit  Service
+	logger *slog.Logger
+}
+
+// Middleware adds audit records to the certs service. The operation is not
+// undone if its event cannot be recorded; the failure is logged instead.
+func Middleware(svc certs.Service, audit Service, logger *slog.Logger) certs.Service {
+	return &auditMiddleware{
+		Service: svc,
+		audit:   audit,
+		logger:  logger,
+	}
+}
+
+func (am *auditMiddleware) RenewCert(ctx context.Context, serialNumber string) error {
+	err := am.Service.RenewCert(ctx, serialNumber)
+	am.record(ctx, Event{Action: ActionRenewCert, SerialNumber: serialNumber, EntityID: am.entityID(ctx, serialNumber)}, err)
+
+	return err
+}
+
+func (am *auditMiddleware) RevokeCert(ctx context.Context, serialNumber string, reason certs.RevocationReason, invalidityDate time.Time) error {
+	err := am.Service.RevokeCert(ctx, serialNumber, reason, invalidityDate)
+	params := map[string]string{"reason": reason.String()}
+	if !invalidityDate.IsZero() {
+		params["invalidity_date"] = invalidityDate.UTC().Format(time.RFC3339)
+	}
+	am.record(ctx, Event{Action: ActionRevokeCert, SerialNumber: serialNumber, EntityID: am.entityID(ctx, serialNumber), Params: params}, err)
+
+	return err
+}
+
+func (am *auditMiddleware) HoldCert(ctx context.Context, serialNumber string) error {
+	err := am.Service.HoldCert(ctx, serialNumber)
+	am.record(ctx, Event{Action: ActionHoldCert, SerialNumber: serialNumber, EntityID: am.entityID(ctx, serialNumber)}, err)
+
+	return err
+}
+
+func (am *auditMiddleware) ReleaseCert(ctx context.Context, serialNumber string) error {
+	err := am.Service.ReleaseCert(ctx, serialNumber)
+	am.record(ctx, Event{Action: ActionReleaseCert, SerialNumber: serialNumber, EntityID: am.entityID(ctx, serialNumber)}, err)
+
+	return err
+}
+
+func (am *auditMiddleware) RetrieveCert(ctx context.Context, token, serialNumber string) (certs.Certificate, []byte, error) {
+	cert, ca, err := am.Service.RetrieveCert(ctx, token, serialNumber)
+	am.record(ctx, Event{Action: ActionDownloadCert, SerialNumber: serialNumber, EntityID: cert.EntityID}, err)
+
+	return cert, ca, err
+}
+
+func (am *auditMiddleware) IssueCert(ctx context.Context, entityID, issuer, profile, ttl string, ipAddrs []string, options certs.SubjectOptions) (certs.Certificate, error) {
+	cert, err := am.Service.IssueCert(ctx, entityID, issuer, profile, ttl, ipAddrs, options)
+	params := map[string]string{
+		"common_name": options.CommonName,
+		"issuer":      issuer,
+		"profile":     profile,
+		"ttl":         ttl,
+	}
+	if len(ipAddrs) > 0 {
+		params["ip_addresses"] = strings.Join(ipAddrs, ",")
+	}
+	am.record(ctx, Event{Action: ActionIssueCert, SerialNumber: cert.SerialNumber, EntityID: entityID, Params: params}, err)
+
+	return cert, err
+}
+
+func (am *auditMiddleware) GetChainCA(ctx context.Context, token string) (certs.Certificate, error) {
+	cert, err := am.Service.GetChainCA(ctx, token)
+	am.record(ctx, Event{Action: ActionDownloadCA}, err)
+
+	return cert, err
+}
+
+// ExportCA never records the passphrase.
+func (am *auditMiddleware) ExportCA(ctx context.Context, serialNumber, passphrase string) ([]byte, error) {
+	backup, err := am.Service.ExportCA(ctx, serialNumber, passphrase)
+	am.record(ctx, Event{Action: ActionExportCA, SerialNumber: serialNumber}, err)
+
+	return backup, err
+}
+
+func (am *auditMiddleware) RemoveCert(ctx context.Context, entityID string) error {
+	err := am.Service.RemoveCert(ctx, entityID)
+	am.record(ctx, Event{Action: ActionDeleteCerts, EntityID: entityID}, err)
+
+	return err
+}
+
+func (am *auditMiddleware) IssueFromCSR(ctx context.Context, entityID, issuer, profile, ttl string, csr certs.CSR) (certs.Certificate, error) {
+	cert, err := am.Service.IssueFromCSR(ctx, entityID, issuer, profile, ttl, csr)
+	params := map[string]string{
+		"issuer":  issuer,
+		"profile": profile,
+		"ttl":     ttl,
+	}
+	am.record(ctx, Event{Action: ActionIssueFromCSR, SerialNumber: cert.SerialNumber, EntityID: entityID, Params: params}, err)
+
+	return cert, err
+}
+
+func (am *auditMiddleware) SubmitCSR(ctx context.Context, csr certs.CSR) (certs.CSR, error) {
+	res, err := am.Service.SubmitCSR(ctx, csr)
+	params := map[string]string{
+		"csr_id":  res.ID,
+		"status":  string(res.Status),
+		"issuer":  csr.Issuer,
+		"profile": csr.Profile,
+		"ttl":     csr.TTL,
+	}
+	am.record(ctx, Event{Action: ActionSubmitCSR, SerialNumber: res.SerialNumber, EntityID: csr.EntityID, Params: params}, err)
+
+	return res, err
+}
+
+func (am *auditMiddleware) ApproveCSR(ctx context.Context, id, ttl, profile string) (certs.CSR, error) {
+	csr, err := am.Service.ApproveCSR(ctx, id, ttl, profile)
+	params := map[string]string{
+		"csr_id":  id,
+		"profile": profile,
+		"ttl":     ttl,
+	}
+	am.record(ctx, Event{Action: ActionApproveCSR, SerialNumber: csr.SerialNumber, EntityID: csr.EntityID, Params: params}, err)
+
+	return csr, err
+}
+
+func (am *auditMiddleware) RejectCSR(ctx context.Context, id, reason string) (certs.CSR, error) {
+	csr, err := am.Service.RejectCSR(ctx, id, reason)
+	params := map[string]string{
+		"csr_id": id,
+		"reason": reason,
+	}
+	am.record(ctx, Event{Action: ActionRejectCSR, EntityID: csr.EntityID, Params: params}, err)
+
+	return csr, err
+}
+
+func (am *auditMiddleware) ImportCA(ctx context.Context, ca certs.CAImport) (certs.Certificate, error) {
+	cert, err := am.Service.ImportCA(ctx, ca)
+	params := map[string]string{
+		"type": ca.Type.String(),
+		"name": ca.Name,
+	}
+	if ca.KeyRef != "" {
+		params["key_ref"] = ca.KeyRef
+	}
+	am.record(ctx, Event{Action: ActionImportCA, SerialNumber: cert.SerialNumber, Params: params}, err)
+
+	return cert, err
+}
+
+func (am *auditMiddleware) GenerateIntermediateCSR(ctx context.Context) (certs.CSR, error) {
+	csr, err := am.Service.GenerateIntermediateCSR(ctx)
+	am.record(ctx, Event{Action: ActionGenerateIntermediate}, err)
+
+	return csr, err
+}
+
+func (am *auditMiddleware) InstallIntermediateCA(ctx context.Context, cert []byte) (certs.Certificate, error) {
+	ca, err := am.Service.InstallIntermediateCA(ctx, cert)
+	am.record(ctx, Event{Action: ActionInstallIntermediateCA, SerialNumber: ca.SerialNumber}, err)
+
+	return ca, err
+}
+
+func (am *auditMiddleware) CreateIssuer(ctx context.Context, name string) (certs.Issuer, error) {
+	issuer, err := am.Service.CreateIssuer(ctx, name)
+	am.record(ctx, Event{Action: ActionCreateIssuer, SerialNumber: issuer.SerialNumber, Params: map[string]string{"name": name}}, err)
+
+	return issuer, err
+}
+
+func (am *auditMiddleware) RetireIssuer(ctx context.Context, name string) error {
+	err := am.Service.RetireIssuer(ctx, name)
+	am.record(ctx, Event{Action: ActionRetireIssuer, Params: map[string]string{"name": name}}, err)
+
+	return err
+}
+
+func (am *auditMiddleware) CreateProfile(ctx context.Context, profile certs.Profile) (certs.Profile, error) {
+	res, err := am.Service.CreateProfile(ctx, profile)
+	am.record(ctx, Event{Action: ActionCreateProfile, Params: map[string]string{"name": profile.Name}}, err)
+
+	return res, err
+}
+
+func (am *auditMiddleware) UpdateProfile(ctx context.Context, profile certs.Profile) (certs.Profile, error) {
+	res, err := am.Service.UpdateProfile(ctx, profile)
+	am.record(ctx, Event{Action: ActionUpdateProfile, Params: map[string]string{"name": profile.Name}}, err)
+
+	return res, err
+}
+
+func (am *auditMiddleware) RemoveProfile(ctx context.Context, name string) error {
+	err := am.Service.RemoveProfile(ctx, name)
+	am.record(ctx, Event{Action: ActionRemoveProfile, Params: map[string]string{"name": name}}, err)
+
+	return err
+}
+
+// entityID returns the entity of the certificate, or an empty string if
+// it cannot be retrieved.
+func (am *auditMiddleware) entityID(ctx context.Context, serialNumber string) string {
+	entityID, err := am.Service.GetEntityID(ctx, serialNumber)
+	if err != nil {
+		return ""
+	}
+
+	return entityID
+}
+
+// record records the event of an operation with the caller of the context
+// as its actor and the error as its result.
+func (am *auditMiddleware) record(ctx context.Context, event Event, err error) {
+	event.Actor = AnonymousActor
+	if id, ok := auth.FromContext(ctx); ok {
+		event.Actor, event.Role = id.Subject, string(id.Role)
+	}
+	event.Result = ResultSuccess
+	if err != nil {
+		event.Result, event.Error = ResultFailure, err.Error()
+	}
+	for k, v := range event.Params {
+		if v == "" {
+			delete(event.Params, k)
+		}
+	}
+
+	// The event is recorded even if the request was canceled meanwhile.
+	if _, rerr := am.audit.Record(context.WithoutCancel(ctx), event); rerr != nil {
+		am.logger.Error(fmt.Sprintf("Failed to record audit event %s by %s: %s", event.Action, event.Actor, rerr))
+	}
+}
@@ -0,0 +1,258 @@
+// Code generated by mockery v2.53.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	audit "github.com/hantdev/certs/audit"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockRepository is an autogenerated mock type for the Repository type
+type MockRepository struct {
+	mock.Mock
+}
+
+type MockRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRepository) EXPECT() *MockRepository_Expecter {
+	return &MockRepository_Expecter{mock: &_m.Mock}
+}
+
+// Append provides a mock function with given fields: ctx, event
+func (_m *MockRepository) Append(ctx context.Context, event audit.Event) error {
+	ret := _m.Called(ctx, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Append")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Event) error); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_Append_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Append'
+type MockRepository_Append_Call struct {
+	*mock.Call
+}
+
+// Append is a helper method to define mock.On call
+//   - ctx context.Context
+//   - event audit.Event
+func (_e *MockRepository_Expecter) Append(ctx interface{}, event interface{}) *MockRepository_Append_Call {
+	return &MockRepository_Append_Call{Call: _e.mock.On("Append", ctx, event)}
+}
+
+func (_c *MockRepository_Append_Call) Run(run func(ctx context.Context, event audit.Event)) *MockRepository_Append_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(audit.Event))
+	})
+	return _c
+}
+
+func (_c *MockRepository_Append_Call) Return(_a0 error) *MockRepository_Append_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_Append_Call) RunAndReturn(run func(context.Context, audit.Event) error) *MockRepository_Append_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Last provides a mock function with given fields: ctx
+func (_m *MockRepository) Last(ctx context.Context) (audit.Event, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Last")
+	}
+
+	var r0 audit.Event
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (audit.Event, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) audit.Event); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(audit.Event)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_Last_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Last'
+type MockRepository_Last_Call struct {
+	*mock.Call
+}
+
+// Last is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockRepository_Expecter) Last(ctx interface{}) *MockRepository_Last_Call {
+	return &MockRepository_Last_Call{Call: _e.mock.On("Last", ctx)}
+}
+
+func (_c *MockRepository_Last_Call) Run(run func(ctx context.Context)) *MockRepository_Last_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockRepository_Last_Call) Return(_a0 audit.Event, _a1 error) *MockRepository_Last_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_Last_Call) RunAndReturn(run func(context.Context) (audit.Event, error)) *MockRepository_Last_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// List provides a mock function with given fields: ctx, filter
+func (_m *MockRepository) List(ctx context.Context, filter audit.Filter) (audit.Page, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 audit.Page
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Filter) (audit.Page, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Filter) audit.Page); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(audit.Page)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, audit.Filter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type MockRepository_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter audit.Filter
+func (_e *MockRepository_Expecter) List(ctx interface{}, filter interface{}) *MockRepository_List_Call {
+	return &MockRepository_List_Call{Call: _e.mock.On("List", ctx, filter)}
+}
+
+func (_c *MockRepository_List_Call) Run(run func(ctx context.Context, filter audit.Filter)) *MockRepository_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(audit.Filter))
+	})
+	return _c
+}
+
+func (_c *MockRepository_List_Call) Return(_a0 audit.Page, _a1 error) *MockRepository_List_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_List_Call) RunAndReturn(run func(context.Context, audit.Filter) (audit.Page, error)) *MockRepository_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Range provides a mock function with given fields: ctx, from, limit
+func (_m *MockRepository) Range(ctx context.Context, from uint64, limit uint64) ([]audit.Event, error) {
+	ret := _m.Called(ctx, from, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Range")
+	}
+
+	var r0 []audit.Event
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) ([]audit.Event, error)); ok {
+		return rf(ctx, from, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) []audit.Event); ok {
+		r0 = rf(ctx, from, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]audit.Event)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, uint64) error); ok {
+		r1 = rf(ctx, from, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_Range_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Range'
+type MockRepository_Range_Call struct {
+	*mock.Call
+}
+
+// Range is a helper method to define mock.On call
+//   - ctx context.Context
+//   - from uint64
+//   - limit uint64
+func (_e *MockRepository_Expecter) Range(ctx interface{}, from interface{}, limit interface{}) *MockRepository_Range_Call {
+	return &MockRepository_Range_Call{Call: _e.mock.On("Range", ctx, from, limit)}
+}
+
+func (_c *MockRepository_Range_Call) Run(run func(ctx context.Context, from uint64, limit uint64)) *MockRepository_Range_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uint64), args[2].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockRepository_Range_Call) Return(_a0 []audit.Event, _a1 error) *MockRepository_Range_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_Range_Call) RunAndReturn(run func(context.Context, uint64, uint64) ([]audit.Event, error)) *MockRepository_Range_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRepository creates a new instance of MockRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRepository {
+	mock := &MockRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+const (
+	// appendAttempts bounds the retries of appending an event while other
+	// instances append to the log.
+	appendAttempts = 5
+	// verifyBatch is the number of events verified at a time.
+	verifyBatch = 1000
+)
+
+// genesisHash is the previous hash of the first event.
+var genesisHash = strings.Repeat("0", sha256.Size*2)
+
+type service struct {
+	repo Repository
+	// mu serializes the events appended by this instance.
+	mu sync.Mutex
+}
+
+var _ Service = (*service)(nil)
+
+// NewService returns a new audit log service.
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+func (s *service) Record(ctx context.Context, event Event) (Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	// The repository keeps times with microsecond precision.
+	event.Time = event.Time.UTC().Truncate(time.Microsecond)
+	for attempt := 1; ; attempt++ {
+		last, err := s.repo.Last(ctx)
+		if err != nil {
+			return Event{}, err
+		}
+		event.Seq = last.Seq + 1
+		event.PrevHash = genesisHash
+		if last.Seq != 0 {
+			event.PrevHash = last.Hash
+		}
+		event.Hash = hash(event)
+
+		err = s.repo.Append(ctx, event)
+		switch {
+		case err == nil:
+			return event, nil
+		case errors.Contains(err, ErrConflict) && attempt < appendAttempts:
+			// Another instance appended an event first.
+			continue
+		default:
+			return Event{}, err
+		}
+	}
+}
+
+func (s *service) ListEvents(ctx context.Context, filter Filter) (Page, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return Page{}, errors.Wrap(certs.ErrMalformedEntity, ErrInvalidTimeRange)
+	}
+
+	return s.repo.List(ctx, filter)
+}
+
+// Verify walks the log in sequence order. An event is broken if its
+// sequence number is not the next one, if it does not hold the hash of the
+// previous event or if its hash does not match its content. Removing the
+// most recent events is only detected by comparing the last hash with one
+// kept elsewhere.
+func (s *service) Verify(ctx context.Context) (Verification, error) {
+	v := Verification{Valid: true}
+	prev := genesisHash
+	for {
+		events, err := s.repo.Range(ctx, v.Events+1, verifyBatch)
+		if err != nil {
+			return Verification{}, err
+		}
+		for _, e := range events {
+			var reason string
+			switch {
+			case e.Seq != v.Events+1:
+				reason = fmt.Sprintf("event %d is missing", v.Events+1)
+			case e.PrevHash != prev:
+				reason = "previous hash does not match the previous event"
+			case e.Hash != hash(e):
+				reason = "hash does not match the event"
+			}
+			if reason != "" {
+				v.Valid, v.BrokenAt, v.Reason = false, v.Events+1, reason
+				return v, nil
+			}
+			prev = e.Hash
+			v.Events++
+			v.LastHash = e.Hash
+		}
+		if len(events) < verifyBatch {
+			return v, nil
+		}
+	}
+}
+
+// hashedEvent is the content of an event its hash is computed over. The
+// time is in microseconds so that the hash does not depend on its location.
+type hashedEvent struct {
+	Seq          uint64            `json:"seq"`
+	Time         int64             `json:"time"`
+	Actor        string            `json:"actor"`
+	Role         string            `json:"role,omitempty"`
+	Action       Action            `json:"action"`
+	SerialNumber string            `json:"serial_number,omitempty"`
+	EntityID     string            `json:"entity_id,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`
+	Result       Result            `json:"result"`
+	Error        string            `json:"error,omitempty"`
+	PrevHash     string            `json:"prev_hash"`
+}
+
+// hash returns the hex encoded SHA-256 digest of the JSON encoded event,
+// which has sorted parameter keys.
+func hash(e Event) string {
+	// Marshaling strings, numbers and string maps does not fail.
+	data, _ := json.Marshal(hashedEvent{
+		Seq:          e.Seq,
+		Time:         e.Time.UnixMicro(),
+		Actor:        e.Actor,
+		Role:         e.Role,
+		Action:       e.Action,
+		SerialNumber: e.SerialNumber,
+		EntityID:     e.EntityID,
+		Params:       e.Params,
+		Result:       e.Result,
+		Error:        e.Error,
+		PrevHash:     e.PrevHash,
+	})
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hantdev/certs/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// APIKeyHeader is the request header carrying an API key.
+const APIKeyHeader = "X-API-Key"
+
+// APIKey is an entry of the API keys file. Only the hex encoded SHA-256 hash
+// of the key is stored.
+type APIKey struct {
+	Name     string   `yaml:"name"`
+	Hash     string   `yaml:"hash"`
+	Role     Role     `yaml:"role"`
+	Entities []string `yaml:"entities"`
+}
+
+type apiKeys struct {
+	identities map[string]Identity
+}
+
+var _ Authenticator = (*apiKeys)(nil)
+
+// NewAPIKeys returns an authenticator of the API keys listed in the YAML file
+// below the keys key.
+func NewAPIKeys(filename string) (Authenticator, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Keys []APIKey `yaml:"keys"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	a := &apiKeys{identities: make(map[string]Identity, len(file.Keys))}
+	for _, k := range file.Keys {
+		hash := strings.ToLower(k.Hash)
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, errors.New("API key " + k.Name + " has an invalid SHA-256 hash")
+		}
+		id := Identity{
+			Subject:  k.Name,
+			Method:   MethodAPIKey,
+			Role:     k.Role,
+			Entities: k.Entities,
+		}
+		if err := id.validate(); err != nil {
+			return nil, errors.Wrap(errors.New("API key "+k.Name), err)
+		}
+		a.identities[hash] = id
+	}
+
+	return a, nil
+}
+
+func (a *apiKeys) Authenticate(r *http.Request) (Identity, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return Identity{}, ErrNoCredentials
+	}
+	id, ok := a.identities[HashAPIKey(key)]
+	if !ok {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, errors.New("unknown API key"))
+	}
+
+	return id, nil
+}
+
+// HashAPIKey returns the hash of an API key as stored in the API keys file.
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
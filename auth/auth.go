@@ -0,0 +1,220 @@
+// Package auth authenticates the callers of the HTTP API and authorizes them
+// by role and entity ID.
+//
+// Callers authenticate with an API key, a bearer JWT verified against the
+// keys of a JWKS file, or a client certificate issued by this CA over mutual
+// TLS. The identity of the caller is carried in the request context for the
+// authorization checks, logging and auditing.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+// Role determines the operations a caller may perform.
+type Role string
+
+const (
+	// RoleAdmin manages the CAs, issuers and profiles and all certificates.
+	RoleAdmin Role = "admin"
+	// RoleIssuer issues and manages the certificates of its entities.
+	RoleIssuer Role = "issuer"
+	// RoleAuditor has read-only access to the certificates, CSRs, issuers
+	// and profiles, but not to private keys.
+	RoleAuditor Role = "auditor"
+	// RoleDevice enrolls and renews the certificates of its own entity.
+	RoleDevice Role = "device"
+)
+
+// Method is the authentication method of a caller.
+type Method string
+
+const (
+	MethodAPIKey Method = "api_key"
+	MethodJWT    Method = "jwt"
+	MethodMTLS   Method = "mtls"
+)
+
+var (
+	// ErrUnauthenticated indicates missing or invalid credentials.
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	// ErrForbidden indicates that the caller may not perform the operation.
+	ErrForbidden = errors.New("caller is not authorized to perform the operation")
+	// ErrNoCredentials is returned by an authenticator when the request does
+	// not carry its kind of credentials, so the next one is tried.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidRole indicates an unknown role.
+	ErrInvalidRole = errors.New("invalid role, expected admin, issuer, auditor or device")
+)
+
+// Config holds the authentication settings of the HTTP API.
+type Config struct {
+	// Enabled requires callers of the API to authenticate. The CA
+	// certificates, CRLs and OCSP responses stay public.
+	Enabled bool `env:"ENABLED" envDefault:"false"`
+	// APIKeysFile is the YAML file of the API keys and their roles.
+	APIKeysFile string `env:"API_KEYS_FILE" envDefault:""`
+	// JWKSFile is the JSON Web Key Set bearer JWTs are verified with. Tokens
+	// must be issued by JWTIssuer and for JWTAudience if they are set. The
+	// role and the entity IDs of the caller are read from the RoleClaim and
+	// EntitiesClaim claims.
+	JWKSFile      string `env:"JWKS_FILE"          envDefault:""`
+	JWTIssuer     string `env:"JWT_ISSUER"         envDefault:""`
+	JWTAudience   string `env:"JWT_AUDIENCE"       envDefault:""`
+	RoleClaim     string `env:"JWT_ROLE_CLAIM"     envDefault:"role"`
+	EntitiesClaim string `env:"JWT_ENTITIES_CLAIM" envDefault:"entities"`
+	// MTLS authenticates callers presenting a valid client certificate issued
+	// by this CA with MTLSRole, limited to the entity of the certificate.
+	MTLS     bool `env:"MTLS"      envDefault:"true"`
+	MTLSRole Role `env:"MTLS_ROLE" envDefault:"device"`
+}
+
+// Identity is an authenticated caller.
+type Identity struct {
+	// Subject names the caller: the name of the API key, the subject of the
+	// JWT or the entity ID of the client certificate.
+	Subject string `json:"subject"`
+	Method  Method `json:"method"`
+	Role    Role   `json:"role"`
+	// Entities limits the caller to the certificates of the given entity
+	// IDs. Callers other than devices without entities are not limited.
+	Entities []string `json:"entities,omitempty"`
+}
+
+// Authenticator authenticates the caller of a request.
+type Authenticator interface {
+	// Authenticate returns the identity of the caller. ErrNoCredentials is
+	// returned if the request carries none of the credentials the
+	// authenticator verifies.
+	Authenticate(r *http.Request) (Identity, error)
+}
+
+// New returns an authenticator trying the configured methods in turn: API
+// keys, bearer JWTs and client certificates.
+func New(cfg Config, svc certs.Service) (Authenticator, error) {
+	var authns chain
+	if cfg.APIKeysFile != "" {
+		a, err := NewAPIKeys(cfg.APIKeysFile)
+		if err != nil {
+			return nil, errors.Wrap(errors.New("failed to load API keys"), err)
+		}
+		authns = append(authns, a)
+	}
+	if cfg.JWKSFile != "" {
+		a, err := NewJWT(cfg)
+		if err != nil {
+			return nil, errors.Wrap(errors.New("failed to load JWKS"), err)
+		}
+		authns = append(authns, a)
+	}
+	if cfg.MTLS {
+		if err := cfg.MTLSRole.Validate(); err != nil {
+			return nil, err
+		}
+		authns = append(authns, NewMTLS(svc, cfg.MTLSRole))
+	}
+	if len(authns) == 0 {
+		return nil, errors.New("no authentication method configured")
+	}
+
+	return authns, nil
+}
+
+// chain tries its authenticators in turn until one finds its credentials.
+type chain []Authenticator
+
+func (c chain) Authenticate(r *http.Request) (Identity, error) {
+	for _, a := range c {
+		id, err := a.Authenticate(r)
+		if errors.Contains(err, ErrNoCredentials) {
+			continue
+		}
+		return id, err
+	}
+
+	return Identity{}, errors.Wrap(ErrUnauthenticated, ErrNoCredentials)
+}
+
+// Validate reports whether the role is known.
+func (r Role) Validate() error {
+	switch r {
+	case RoleAdmin, RoleIssuer, RoleAuditor, RoleDevice:
+		return nil
+	default:
+		return ErrInvalidRole
+	}
+}
+
+// HasRole reports whether the identity has one of the roles.
+func (id Identity) HasRole(roles ...Role) bool {
+	return slices.Contains(roles, id.Role)
+}
+
+// Limited reports whether the identity is limited to some entities.
+func (id Identity) Limited() bool {
+	return id.Role == RoleDevice || len(id.Entities) > 0
+}
+
+// CanAccess reports whether the identity may access the certificates of the entity.
+func (id Identity) CanAccess(entityID string) bool {
+	return !id.Limited() || slices.Contains(id.Entities, entityID)
+}
+
+// validate checks the role and that devices are limited to their entities.
+func (id Identity) validate() error {
+	if err := id.Role.Validate(); err != nil {
+		return err
+	}
+	if id.Role == RoleDevice && len(id.Entities) == 0 {
+		return errors.New("device " + id.Subject + " has no entity")
+	}
+
+	return nil
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of the context carrying the identity.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity carried by the context. There is none if
+// authentication is disabled.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// AuthorizeEntity returns ErrForbidden unless the caller of the context may
+// access the certificates of the entity.
+func AuthorizeEntity(ctx context.Context, entityID string) error {
+	if id, ok := FromContext(ctx); ok && !id.CanAccess(entityID) {
+		return errors.Wrap(ErrForbidden, errors.New("entity "+entityID))
+	}
+
+	return nil
+}
+
+// ScopeEntity returns the entity ID a listing by the caller of the context is
+// filtered by. Limited callers must filter by one of their entities, which
+// defaults to their only entity.
+func ScopeEntity(ctx context.Context, entityID string) (string, error) {
+	id, ok := FromContext(ctx)
+	if !ok || !id.Limited() {
+		return entityID, nil
+	}
+	if entityID == "" {
+		if len(id.Entities) != 1 {
+			return "", errors.Wrap(ErrForbidden, errors.New("entity ID filter is required"))
+		}
+		return id.Entities[0], nil
+	}
+
+	return entityID, AuthorizeEntity(ctx, entityID)
+}
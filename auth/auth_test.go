@@ -0,0 +1,357 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/acme"
+	httpapi "github.com/hantdev/certs/api/http"
+	"github.com/hantdev/certs/auth"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/certstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	adminKey   = "admin-secret"
+	auditorKey = "auditor-secret"
+	deviceKey  = "device-secret"
+	deviceID   = "device-1"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func apiKeysFile(t *testing.T) string {
+	return writeFile(t, "keys.yml", `keys:
+  - name: admin
+    hash: `+auth.HashAPIKey(adminKey)+`
+    role: admin
+  - name: auditor
+    hash: `+auth.HashAPIKey(auditorKey)+`
+    role: auditor
+  - name: device
+    hash: `+auth.HashAPIKey(deviceKey)+`
+    role: device
+    entities: [`+deviceID+`]
+`)
+}
+
+func TestAPIKeys(t *testing.T) {
+	authn, err := auth.NewAPIKeys(apiKeysFile(t))
+	require.NoError(t, err)
+
+	cases := []struct {
+		desc string
+		key  string
+		id   auth.Identity
+		err  error
+	}{
+		{
+			desc: "admin key",
+			key:  adminKey,
+			id:   auth.Identity{Subject: "admin", Method: auth.MethodAPIKey, Role: auth.RoleAdmin},
+		},
+		{
+			desc: "device key",
+			key:  deviceKey,
+			id:   auth.Identity{Subject: "device", Method: auth.MethodAPIKey, Role: auth.RoleDevice, Entities: []string{deviceID}},
+		},
+		{
+			desc: "unknown key",
+			key:  "unknown",
+			err:  auth.ErrUnauthenticated,
+		},
+		{
+			desc: "missing key",
+			err:  auth.ErrNoCredentials,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/certs", nil)
+			if tc.key != "" {
+				r.Header.Set(auth.APIKeyHeader, tc.key)
+			}
+			id, err := authn.Authenticate(r)
+			if tc.err != nil {
+				assert.True(t, errors.Contains(err, tc.err), "expected %s, got %v", tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.id, id)
+		})
+	}
+
+	_, err = auth.NewAPIKeys(writeFile(t, "keys.yml", "keys:\n  - name: device\n    hash: "+auth.HashAPIKey(deviceKey)+"\n    role: device\n"))
+	assert.Error(t, err, "devices without entities must be rejected")
+	_, err = auth.NewAPIKeys(writeFile(t, "keys.yml", "keys:\n  - name: admin\n    hash: plain\n    role: admin\n"))
+	assert.Error(t, err, "keys which are not hashed must be rejected")
+}
+
+func TestJWT(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwk, err := acme.NewJWK(&key.PublicKey)
+	require.NoError(t, err)
+	set, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{"kty": jwk.Kty, "crv": jwk.Crv, "x": jwk.X, "y": jwk.Y, "kid": "k1", "use": "sig"}},
+	})
+	require.NoError(t, err)
+
+	authn, err := auth.NewJWT(auth.Config{
+		JWKSFile:      writeFile(t, "jwks.json", string(set)),
+		JWTIssuer:     "https://idp.example.com",
+		RoleClaim:     "role",
+		EntitiesClaim: "entities",
+	})
+	require.NoError(t, err)
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sign := func(claims jwt.MapClaims, key *ecdsa.PrivateKey) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = "k1"
+		s, err := token.SignedString(key)
+		require.NoError(t, err)
+		return s
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	cases := []struct {
+		desc  string
+		token string
+		id    auth.Identity
+		err   error
+	}{
+		{
+			desc:  "issuer token",
+			token: sign(jwt.MapClaims{"sub": "ops", "iss": "https://idp.example.com", "exp": exp, "role": "issuer", "entities": []string{"a", "b"}}, key),
+			id:    auth.Identity{Subject: "ops", Method: auth.MethodJWT, Role: auth.RoleIssuer, Entities: []string{"a", "b"}},
+		},
+		{
+			desc:  "device token defaults to the subject",
+			token: sign(jwt.MapClaims{"sub": deviceID, "iss": "https://idp.example.com", "exp": exp, "role": "device"}, key),
+			id:    auth.Identity{Subject: deviceID, Method: auth.MethodJWT, Role: auth.RoleDevice, Entities: []string{deviceID}},
+		},
+		{
+			desc:  "token signed by another key",
+			token: sign(jwt.MapClaims{"sub": "ops", "iss": "https://idp.example.com", "exp": exp, "role": "admin"}, other),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			desc:  "expired token",
+			token: sign(jwt.MapClaims{"sub": "ops", "iss": "https://idp.example.com", "exp": time.Now().Add(-time.Hour).Unix(), "role": "admin"}, key),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			desc:  "token without expiration",
+			token: sign(jwt.MapClaims{"sub": "ops", "iss": "https://idp.example.com", "role": "admin"}, key),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			desc:  "token of another issuer",
+			token: sign(jwt.MapClaims{"sub": "ops", "iss": "https://other.example.com", "exp": exp, "role": "admin"}, key),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			desc:  "token with an unknown role",
+			token: sign(jwt.MapClaims{"sub": "ops", "iss": "https://idp.example.com", "exp": exp, "role": "root"}, key),
+			err:   auth.ErrUnauthenticated,
+		},
+		{
+			desc: "missing token",
+			err:  auth.ErrNoCredentials,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/certs", nil)
+			if tc.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			id, err := authn.Authenticate(r)
+			if tc.err != nil {
+				assert.True(t, errors.Contains(err, tc.err), "expected %s, got %v", tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.id, id)
+		})
+	}
+}
+
+func TestMTLS(t *testing.T) {
+	ctx := context.Background()
+	svc := certstest.NewService(t)
+	authn := auth.NewMTLS(svc, auth.RoleDevice)
+
+	issue := func(entityID string) *x509.Certificate {
+		cert, err := svc.IssueCert(ctx, entityID, "", "", "1h", nil, certs.SubjectOptions{CommonName: entityID})
+		require.NoError(t, err)
+		block, _ := pem.Decode(cert.Certificate)
+		require.NotNil(t, block)
+		leaf, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		return leaf
+	}
+	request := func(leaf *x509.Certificate) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/certs", nil)
+		if leaf != nil {
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+		}
+		return r
+	}
+
+	leaf := issue(deviceID)
+	id, err := authn.Authenticate(request(leaf))
+	require.NoError(t, err)
+	assert.Equal(t, auth.Identity{Subject: deviceID, Method: auth.MethodMTLS, Role: auth.RoleDevice, Entities: []string{deviceID}}, id)
+
+	// A certificate with the serial number of an issued one is not trusted.
+	forged := *leaf
+	forged.Raw = append([]byte{}, leaf.Raw...)
+	forged.Raw[len(forged.Raw)-1] ^= 0xff
+	_, err = authn.Authenticate(request(&forged))
+	assert.True(t, errors.Contains(err, auth.ErrUnauthenticated), "expected %s, got %v", auth.ErrUnauthenticated, err)
+
+	revoked := issue("device-2")
+	require.NoError(t, svc.RevokeCert(ctx, revoked.SerialNumber.String(), certs.RevocationKeyCompromise, time.Time{}))
+	_, err = authn.Authenticate(request(revoked))
+	assert.True(t, errors.Contains(err, auth.ErrUnauthenticated), "expected %s, got %v", auth.ErrUnauthenticated, err)
+
+	_, err = authn.Authenticate(request(nil))
+	assert.True(t, errors.Contains(err, auth.ErrNoCredentials), "expected %s, got %v", auth.ErrNoCredentials, err)
+}
+
+func TestHandler(t *testing.T) {
+	svc := certstest.NewService(t)
+	authn, err := auth.New(auth.Config{APIKeysFile: apiKeysFile(t), MTLS: true, MTLSRole: auth.RoleDevice}, svc)
+	require.NoError(t, err)
+	srv := httptest.NewServer(httpapi.MakeHandler(svc, authn, slog.New(slog.NewTextHandler(io.Discard, nil)), "", nil))
+	defer srv.Close()
+
+	issued, err := svc.IssueCert(context.Background(), "device-2", "", "", "1h", nil, certs.SubjectOptions{CommonName: "device-2"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		desc   string
+		method string
+		path   string
+		body   string
+		key    string
+		status int
+	}{
+		{
+			desc:   "issue without credentials",
+			method: http.MethodPost,
+			path:   "/certs/issue/" + deviceID + "?common_name=" + deviceID,
+			body:   `{"ttl":"1h"}`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "issue with an unknown key",
+			method: http.MethodPost,
+			path:   "/certs/issue/" + deviceID + "?common_name=" + deviceID,
+			body:   `{"ttl":"1h"}`,
+			key:    "unknown",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "issue by a device for its entity",
+			method: http.MethodPost,
+			path:   "/certs/issue/" + deviceID + "?common_name=" + deviceID,
+			body:   `{"ttl":"1h"}`,
+			key:    deviceKey,
+			status: http.StatusCreated,
+		},
+		{
+			desc:   "issue by a device for another entity",
+			method: http.MethodPost,
+			path:   "/certs/issue/device-2?common_name=device-2",
+			body:   `{"ttl":"1h"}`,
+			key:    deviceKey,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "issue by an auditor",
+			method: http.MethodPost,
+			path:   "/certs/issue/" + deviceID + "?common_name=" + deviceID,
+			body:   `{"ttl":"1h"}`,
+			key:    auditorKey,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "view by a device of a certificate of another entity",
+			method: http.MethodGet,
+			path:   "/certs/" + issued.SerialNumber,
+			key:    deviceKey,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "view by an admin",
+			method: http.MethodGet,
+			path:   "/certs/" + issued.SerialNumber,
+			key:    adminKey,
+			status: http.StatusOK,
+		},
+		{
+			desc:   "view of the intermediate CA by a device",
+			method: http.MethodGet,
+			path:   "/certs/view-ca?token=token",
+			key:    deviceKey,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "list by a device of another entity",
+			method: http.MethodGet,
+			path:   "/certs?entity_id=device-2",
+			key:    deviceKey,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "CA certificate without credentials",
+			method: http.MethodGet,
+			path:   "/certs/ca/" + issued.SerialNumber,
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.key != "" {
+				req.Header.Set(auth.APIKeyHeader, tc.key)
+			}
+			res, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/hantdev/certs/acme"
+	"github.com/hantdev/certs/errors"
+)
+
+const bearerPrefix = "Bearer "
+
+var errUnknownKey = errors.New("unknown JWT signing key")
+
+// jwk is a key of a JSON Web Key Set.
+type jwk struct {
+	acme.JWK
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+type verificationKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+type jwtAuthenticator struct {
+	keys          map[string]verificationKey
+	issuer        string
+	audience      string
+	roleClaim     string
+	entitiesClaim string
+}
+
+var _ Authenticator = (*jwtAuthenticator)(nil)
+
+// NewJWT returns an authenticator of bearer JWTs signed by one of the keys of
+// the configured JWKS file. Keys for other uses than signatures are ignored.
+func NewJWT(cfg Config) (Authenticator, error) {
+	data, err := os.ReadFile(cfg.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	a := &jwtAuthenticator{
+		keys:          make(map[string]verificationKey, len(set.Keys)),
+		issuer:        cfg.JWTIssuer,
+		audience:      cfg.JWTAudience,
+		roleClaim:     cfg.RoleClaim,
+		entitiesClaim: cfg.EntitiesClaim,
+	}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.PublicKey()
+		if err != nil {
+			return nil, errors.Wrap(errors.New("key "+k.Kid), err)
+		}
+		a.keys[k.Kid] = verificationKey{key: pub, alg: k.Alg}
+	}
+	if len(a.keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+
+	return a, nil
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return Identity{}, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(strings.TrimPrefix(header, bearerPrefix), claims, a.key); err != nil {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, err)
+	}
+	// Tokens must expire, which MapClaims.Valid does not require.
+	if _, ok := claims["exp"]; !ok {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, errors.New("token has no expiration time"))
+	}
+	if a.issuer != "" && !claims.VerifyIssuer(a.issuer, true) {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, errors.New("unexpected token issuer"))
+	}
+	if a.audience != "" && !claims.VerifyAudience(a.audience, true) {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, errors.New("unexpected token audience"))
+	}
+
+	sub, _ := claims["sub"].(string)
+	role, _ := claims[a.roleClaim].(string)
+	id := Identity{
+		Subject:  sub,
+		Method:   MethodJWT,
+		Role:     Role(role),
+		Entities: stringsClaim(claims[a.entitiesClaim]),
+	}
+	// A device is the entity named by the subject unless the token names it.
+	if id.Role == RoleDevice && len(id.Entities) == 0 && sub != "" {
+		id.Entities = []string{sub}
+	}
+	if err := id.validate(); err != nil {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, err)
+	}
+
+	return id, nil
+}
+
+// key returns the key the token is verified with. The kid header selects the
+// key unless the set holds a single key. The algorithm of the token has to
+// match the type of the key and the algorithm the key is restricted to.
+func (a *jwtAuthenticator) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := a.keys[kid]
+	if !ok && kid == "" && len(a.keys) == 1 {
+		for _, key := range a.keys {
+			k, ok = key, true
+		}
+	}
+	if !ok {
+		return nil, errUnknownKey
+	}
+	if k.alg != "" && k.alg != token.Method.Alg() {
+		return nil, errors.New("unexpected signing algorithm " + token.Method.Alg())
+	}
+
+	var match bool
+	switch k.key.(type) {
+	case *rsa.PublicKey:
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			match = true
+		}
+	case *ecdsa.PublicKey:
+		_, match = token.Method.(*jwt.SigningMethodECDSA)
+	case ed25519.PublicKey:
+		_, match = token.Method.(*jwt.SigningMethodEd25519)
+	}
+	if !match {
+		return nil, errors.New("unexpected signing algorithm " + token.Method.Alg())
+	}
+
+	return k.key, nil
+}
+
+// stringsClaim returns the strings of a claim holding a string or an array of strings.
+func stringsClaim(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"net/http"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/certs/errors"
+)
+
+// Authenticate returns a middleware adding the identity of the caller to the
+// request context. Requests failing authentication are answered with the
+// error encoder. A nil authenticator lets all requests through, which
+// disables authentication and authorization.
+func Authenticate(authn Authenticator, enc kithttp.ErrorEncoder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if authn == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := authn.Authenticate(r)
+			if err != nil {
+				if !errors.Contains(err, ErrUnauthenticated) {
+					err = errors.Wrap(ErrUnauthenticated, err)
+				}
+				enc(r.Context(), err, w)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+		})
+	}
+}
+
+// Authorize returns a middleware letting only callers with one of the roles
+// through. Requests without an identity pass when authentication is disabled.
+func Authorize(enc kithttp.ErrorEncoder, roles ...Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id, ok := FromContext(r.Context()); ok && !id.HasRole(roles...) {
+				enc(r.Context(), errors.Wrap(ErrForbidden, errors.New("role "+string(id.Role))), w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+type mtls struct {
+	svc  certs.Service
+	role Role
+}
+
+var _ Authenticator = (*mtls)(nil)
+
+// NewMTLS returns an authenticator of the client certificates issued by the
+// certs service. Callers get the role, limited to the entity of their
+// certificate. The TLS server only requests client certificates, so they are
+// verified against the certificates issued by the service.
+func NewMTLS(svc certs.Service, role Role) Authenticator {
+	return &mtls{
+		svc:  svc,
+		role: role,
+	}
+}
+
+func (a *mtls) Authenticate(r *http.Request) (Identity, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return Identity{}, ErrNoCredentials
+	}
+	leaf := r.TLS.PeerCertificates[0]
+
+	// The presented certificate has to be the one issued with its serial
+	// number, which also proves it was signed by the CA.
+	cert, err := a.svc.ViewCert(r.Context(), leaf.SerialNumber.String())
+	if err != nil {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, errors.New("unknown client certificate"))
+	}
+	block, _ := pem.Decode(cert.Certificate)
+	if block == nil || !bytes.Equal(block.Bytes, leaf.Raw) {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, errors.New("unknown client certificate"))
+	}
+	if cert.Revoked {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, certs.ErrCertRevoked)
+	}
+	now := time.Now()
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, certs.ErrCertExpired)
+	}
+	if len(leaf.ExtKeyUsage) > 0 && !slices.Contains(leaf.ExtKeyUsage, x509.ExtKeyUsageClientAuth) &&
+		!slices.Contains(leaf.ExtKeyUsage, x509.ExtKeyUsageAny) {
+		return Identity{}, errors.Wrap(ErrUnauthenticated, errors.New("certificate is not valid for client authentication"))
+	}
+
+	return Identity{
+		Subject:  cert.EntityID,
+		Method:   MethodMTLS,
+		Role:     a.role,
+		Entities: []string{cert.EntityID},
+	}, nil
+}
@@ -0,0 +1,168 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/hantdev/certs/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// BackupBlockType is the PEM block type of an encrypted CA backup.
+	BackupBlockType = "CERTS ENCRYPTED CA BACKUP"
+
+	// MinBackupPassphraseLength is the minimum number of characters of the
+	// passphrase a CA backup is encrypted with.
+	MinBackupPassphraseLength = 12
+
+	serialHeader = "Serial-Number"
+	kdfHeader    = "KDF"
+	saltHeader   = "Salt"
+	nonceHeader  = "Nonce"
+
+	// The scrypt cost is higher than for interactive logins as backups are rare.
+	scryptN      = 1 << 17
+	scryptR      = 8
+	scryptP      = 1
+	backupKeyLen = 32
+	saltLen      = 16
+)
+
+var (
+	// ErrWeakPassphrase indicates a backup passphrase which is too short.
+	ErrWeakPassphrase = errors.New("backup passphrase must be at least 12 characters long")
+	// ErrCAKeyNotExportable indicates a CA key held by an external key store.
+	ErrCAKeyNotExportable = errors.New("CA key is held by an external key store and cannot be exported")
+	// ErrInvalidBackup indicates a malformed CA backup or a wrong passphrase.
+	ErrInvalidBackup = errors.New("invalid CA backup or passphrase")
+
+	backupKDF = fmt.Sprintf("scrypt;N=%d;r=%d;p=%d", scryptN, scryptR, scryptP)
+)
+
+// ExportCA returns a backup of the CA with the serial number for disaster
+// recovery: its certificate, the certificates of its chain and its private
+// key, encrypted with a key derived from the passphrase using scrypt and
+// AES-256-GCM. The backup is a PEM block of type BackupBlockType, which
+// DecryptCABackup turns into a certificate and a key ImportCA accepts.
+func (s *service) ExportCA(ctx context.Context, serialNumber, passphrase string) ([]byte, error) {
+	if utf8.RuneCountInString(passphrase) < MinBackupPassphraseLength {
+		return nil, errors.Wrap(ErrMalformedEntity, ErrWeakPassphrase)
+	}
+	ca := s.caBySerial(serialNumber)
+	if ca == nil {
+		return nil, ErrCANotFound
+	}
+	cert, err := s.repo.RetrieveCert(ctx, serialNumber)
+	if err != nil {
+		return nil, errors.Wrap(ErrViewEntity, err)
+	}
+	if len(cert.Key) == 0 {
+		return nil, ErrCAKeyNotExportable
+	}
+
+	plaintext := append([]byte{}, cert.Certificate...)
+	if ca.Type == IntermediateCA {
+		for _, root := range s.chainRoots(ca) {
+			plaintext = append(plaintext, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw})...)
+		}
+	}
+	plaintext = append(plaintext, cert.Key...)
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := backupCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	// The serial number is authenticated so that it cannot be swapped.
+	ciphertext := aead.Seal(nil, nonce, plaintext, []byte(serialNumber))
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type: BackupBlockType,
+		Headers: map[string]string{
+			serialHeader: serialNumber,
+			kdfHeader:    backupKDF,
+			saltHeader:   base64.StdEncoding.EncodeToString(salt),
+			nonceHeader:  base64.StdEncoding.EncodeToString(nonce),
+		},
+		Bytes: ciphertext,
+	}), nil
+}
+
+// DecryptCABackup decrypts a backup created by ExportCA. It returns the
+// serial number of the CA, the PEM encoded certificates of the CA and its
+// chain, the CA first, and the PEM encoded private key.
+func DecryptCABackup(backup []byte, passphrase string) (serialNumber string, chain, key []byte, err error) {
+	block, _ := pem.Decode(backup)
+	if block == nil || block.Type != BackupBlockType {
+		return "", nil, nil, ErrInvalidBackup
+	}
+	if block.Headers[kdfHeader] != backupKDF {
+		return "", nil, nil, errors.Wrap(ErrInvalidBackup, errors.New("unsupported key derivation "+block.Headers[kdfHeader]))
+	}
+	salt, err := base64.StdEncoding.DecodeString(block.Headers[saltHeader])
+	if err != nil {
+		return "", nil, nil, errors.Wrap(ErrInvalidBackup, err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(block.Headers[nonceHeader])
+	if err != nil {
+		return "", nil, nil, errors.Wrap(ErrInvalidBackup, err)
+	}
+	aead, err := backupCipher(passphrase, salt)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return "", nil, nil, ErrInvalidBackup
+	}
+	serialNumber = block.Headers[serialHeader]
+	plaintext, err := aead.Open(nil, nonce, block.Bytes, []byte(serialNumber))
+	if err != nil {
+		return "", nil, nil, ErrInvalidBackup
+	}
+
+	var certsPEM, keyPEM bytes.Buffer
+	for rest := plaintext; ; {
+		var b *pem.Block
+		if b, rest = pem.Decode(rest); b == nil {
+			break
+		}
+		if b.Type == "CERTIFICATE" {
+			certsPEM.Write(pem.EncodeToMemory(b))
+			continue
+		}
+		keyPEM.Write(pem.EncodeToMemory(b))
+	}
+	if certsPEM.Len() == 0 || keyPEM.Len() == 0 {
+		return "", nil, nil, ErrInvalidBackup
+	}
+
+	return serialNumber, certsPEM.Bytes(), keyPEM.Bytes(), nil
+}
+
+func backupCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, backupKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,348 @@
+package certs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+var (
+	ErrNotCA              = errors.New("certificate is not a CA certificate")
+	ErrKeyMismatch        = errors.New("private key does not match the certificate public key")
+	ErrCAKeyUnavailable   = errors.New("CA signing key is not available")
+	ErrPendingCSRNotFound = errors.New("no pending intermediate CSR matches the certificate")
+)
+
+// ImportCA imports an existing root or intermediate CA and makes it the active one.
+// Importing a root CA retires the current CAs and, if the root key is
+// available, issues a new intermediate under it. Importing an intermediate CA
+// requires it to be signed by the active root CA.
+func (s *service) ImportCA(ctx context.Context, ca CAImport) (Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.importCA(ctx, ca, true)
+}
+
+// GenerateIntermediateCSR generates a new intermediate CA key and returns a
+// CSR for it. The key is kept as a pending intermediate until the signed
+// certificate is installed with InstallIntermediateCA.
+func (s *service) GenerateIntermediateCSR(ctx context.Context) (CSR, error) {
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return CSR{}, err
+	}
+
+	key, err := s.generateCAKey(ctx, PendingIntermediateCA, serialNumber, s.config.Intermediate)
+	if err != nil {
+		return CSR{}, errors.Wrap(ErrCreateEntity, err)
+	}
+
+	template := x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName:         s.config.CommonName,
+			Organization:       s.config.Organization,
+			OrganizationalUnit: s.config.OrganizationalUnit,
+			Country:            s.config.Country,
+			Province:           s.config.Province,
+			Locality:           s.config.Locality,
+			StreetAddress:      s.config.StreetAddress,
+			PostalCode:         s.config.PostalCode,
+		},
+		DNSNames:    s.config.DNSNames,
+		IPAddresses: s.config.IPAddresses,
+	}
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &template, key)
+	if err != nil {
+		return CSR{}, errors.Wrap(ErrCreateEntity, err)
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrBytes})
+
+	pending := Certificate{
+		SerialNumber: serialNumber.String(),
+		Certificate:  csrPEM,
+		ExpiryTime:   time.Now(),
+		Type:         PendingIntermediateCA,
+	}
+	if err := s.storeCA(ctx, pending, key); err != nil {
+		return CSR{}, err
+	}
+
+	return CSR{CSR: csrPEM}, nil
+}
+
+// InstallIntermediateCA installs an intermediate CA certificate signed by the
+// active root CA for a CSR previously created by GenerateIntermediateCSR.
+// The installed CA replaces the default issuer.
+func (s *service) InstallIntermediateCA(ctx context.Context, certPEM []byte) (Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cert, err := parseCACertificate(certPEM)
+	if err != nil {
+		return Certificate{}, err
+	}
+	if s.rootCA == nil {
+		return Certificate{}, ErrRootCANotFound
+	}
+	if err := cert.CheckSignatureFrom(s.rootCA.Certificate); err != nil {
+		return Certificate{}, errors.Wrap(ErrMalformedEntity, err)
+	}
+	if err := allowsSubordinateCA(s.rootCA.Certificate); err != nil {
+		return Certificate{}, errors.Wrap(ErrMalformedEntity, err)
+	}
+
+	pending, err := s.repo.GetCAs(ctx, PendingIntermediateCA)
+	if err != nil {
+		return Certificate{}, errors.Wrap(ErrViewEntity, err)
+	}
+
+	for _, p := range pending {
+		block, _ := pem.Decode(p.Certificate)
+		if block == nil {
+			continue
+		}
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil || !publicKeysEqual(csr.PublicKey, cert.PublicKey) {
+			continue
+		}
+
+		key, err := s.loadCAKey(ctx, p)
+		if err != nil {
+			return Certificate{}, err
+		}
+		if err := s.retireIssuer(ctx, DefaultIssuer); err != nil {
+			return Certificate{}, err
+		}
+		record := intermediateRecord(cert, s.rootCA, DefaultIssuer)
+		if err := s.storeCA(ctx, record, key); err != nil {
+			return Certificate{}, err
+		}
+		if err := s.repo.RemoveCertBySerial(ctx, p.SerialNumber); err != nil {
+			return Certificate{}, errors.Wrap(ErrUpdateEntity, err)
+		}
+		s.intermediates[record.SerialNumber] = &CA{
+			Type:         IntermediateCA,
+			Certificate:  cert,
+			Signer:       key,
+			SerialNumber: record.SerialNumber,
+			Name:         DefaultIssuer,
+		}
+
+		return record, nil
+	}
+
+	return Certificate{}, errors.Wrap(ErrNotFound, ErrPendingCSRNotFound)
+}
+
+func (s *service) importCA(ctx context.Context, ca CAImport, issueIntermediate bool) (Certificate, error) {
+	cert, err := parseCACertificate(ca.Certificate)
+	if err != nil {
+		return Certificate{}, err
+	}
+	now := time.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return Certificate{}, ErrCertExpired
+	}
+
+	key, err := s.importCAKey(ctx, ca)
+	if err != nil {
+		return Certificate{}, err
+	}
+	if key != nil && !publicKeysEqual(key.Public(), cert.PublicKey) {
+		return Certificate{}, errors.Wrap(ErrMalformedEntity, ErrKeyMismatch)
+	}
+
+	switch ca.Type {
+	case RootCA:
+		if err := cert.CheckSignatureFrom(cert); err != nil {
+			return Certificate{}, errors.Wrap(ErrMalformedEntity, err)
+		}
+		names := s.activeIssuers()
+		if err := s.retireCAs(ctx); err != nil {
+			return Certificate{}, err
+		}
+		if err := s.saveCA(ctx, cert, key, RootCA); err != nil {
+			return Certificate{}, err
+		}
+		s.rootCA = &CA{
+			Type:         RootCA,
+			Certificate:  cert,
+			Signer:       key,
+			SerialNumber: cert.SerialNumber.String(),
+		}
+		s.roots[s.rootCA.SerialNumber] = s.rootCA
+		if key != nil && issueIntermediate {
+			if len(names) == 0 {
+				names = []string{DefaultIssuer}
+			}
+			// Issuers are recreated under the imported root CA.
+			for _, name := range names {
+				intermediateCA, err := s.createIntermediateCA(ctx, s.rootCA, name, s.config)
+				if err != nil {
+					return Certificate{}, err
+				}
+				s.intermediates[intermediateCA.SerialNumber] = intermediateCA
+			}
+		}
+	case IntermediateCA:
+		if key == nil {
+			return Certificate{}, errors.Wrap(ErrMalformedEntity, ErrCAKeyUnavailable)
+		}
+		if s.rootCA == nil {
+			return Certificate{}, ErrRootCANotFound
+		}
+		if err := cert.CheckSignatureFrom(s.rootCA.Certificate); err != nil {
+			return Certificate{}, errors.Wrap(ErrMalformedEntity, err)
+		}
+		if err := allowsSubordinateCA(s.rootCA.Certificate); err != nil {
+			return Certificate{}, errors.Wrap(ErrMalformedEntity, err)
+		}
+		name := issuerName(ca.Name)
+		if err := s.retireIssuer(ctx, name); err != nil {
+			return Certificate{}, err
+		}
+		record := intermediateRecord(cert, s.rootCA, name)
+		if err := s.storeCA(ctx, record, key); err != nil {
+			return Certificate{}, err
+		}
+		s.intermediates[record.SerialNumber] = &CA{
+			Type:         IntermediateCA,
+			Certificate:  cert,
+			Signer:       key,
+			SerialNumber: record.SerialNumber,
+			Name:         name,
+		}
+
+		return record, nil
+	default:
+		return Certificate{}, ErrCertInvalidType
+	}
+
+	return caCertificate(cert, ca.Type), nil
+}
+
+// importFromConfig imports the CAs referenced in the config file unless they
+// are already the active ones.
+func (s *service) importFromConfig(ctx context.Context, config *Config) error {
+	if config.ImportRootCA != nil {
+		imp := *config.ImportRootCA
+		imp.Type = RootCA
+		if !s.isActive(s.rootCA, imp.Certificate) {
+			if _, err := s.importCA(ctx, imp, config.ImportIntermediateCA == nil); err != nil {
+				return err
+			}
+		}
+	}
+	if config.ImportIntermediateCA != nil {
+		imp := *config.ImportIntermediateCA
+		imp.Type = IntermediateCA
+		if !s.isActive(s.findIssuer(imp.Name), imp.Certificate) {
+			if _, err := s.importCA(ctx, imp, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *service) isActive(ca *CA, certPEM []byte) bool {
+	if ca == nil {
+		return false
+	}
+	cert, err := parseCACertificate(certPEM)
+	if err != nil {
+		return false
+	}
+
+	return cert.Equal(ca.Certificate)
+}
+
+func (s *service) importCAKey(ctx context.Context, ca CAImport) (crypto.Signer, error) {
+	switch {
+	case ca.KeyRef != "":
+		if s.keyStore == nil {
+			return nil, ErrKeyStoreNotConfigured
+		}
+		return s.keyStore.Load(ctx, ca.KeyRef)
+	case len(ca.Key) > 0:
+		key, err := ParsePrivateKey(ca.Key)
+		if err != nil {
+			return nil, errors.Wrap(ErrMalformedEntity, err)
+		}
+		return key, nil
+	default:
+		return nil, nil
+	}
+}
+
+// retireCAs retires the roots and issuers replaced by an imported root CA.
+// They stay published and valid for verification, so certificates issued
+// under them stay valid until they expire or are renewed. The caller must
+// hold the lock.
+func (s *service) retireCAs(ctx context.Context) error {
+	for _, ca := range s.roots {
+		if ca.Retired {
+			continue
+		}
+		if err := s.retireCA(ctx, ca); err != nil {
+			return err
+		}
+	}
+	for _, ca := range s.intermediates {
+		if ca.Retired {
+			continue
+		}
+		if err := s.retireCA(ctx, ca); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseCACertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.Wrap(ErrMalformedEntity, errors.New("failed to parse certificate PEM"))
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(ErrMalformedEntity, err)
+	}
+	if !cert.BasicConstraintsValid || !cert.IsCA {
+		return nil, errors.Wrap(ErrMalformedEntity, ErrNotCA)
+	}
+
+	return cert, nil
+}
+
+func caCertificate(cert *x509.Certificate, certType CertType) Certificate {
+	return Certificate{
+		SerialNumber: cert.SerialNumber.String(),
+		Certificate:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
+		ExpiryTime:   cert.NotAfter,
+		Type:         certType,
+	}
+}
+
+// intermediateRecord returns the record of an intermediate CA acting as the named issuer.
+func intermediateRecord(cert *x509.Certificate, rootCA *CA, name string) Certificate {
+	record := caCertificate(cert, IntermediateCA)
+	record.IssuerName = name
+	record.IssuerSerial = rootCA.SerialNumber
+
+	return record
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
@@ -2,12 +2,13 @@ package certs
 
 import (
 	"context"
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
 	"net"
 	"time"
 
 	"github.com/hantdev/certs/errors"
+	"golang.org/x/crypto/ocsp"
 )
 
 type CertType int
@@ -16,13 +17,15 @@ const (
 	RootCA CertType = iota
 	IntermediateCA
 	ClientCert
+	PendingIntermediateCA
 )
 
 const (
-	Root    = "RootCA"
-	Inter   = "IntermediateCA"
-	Client  = "ClientCert"
-	Unknown = "Unknown"
+	Root         = "RootCA"
+	Inter        = "IntermediateCA"
+	Client       = "ClientCert"
+	PendingInter = "PendingIntermediateCA"
+	Unknown      = "Unknown"
 )
 
 func (c CertType) String() string {
@@ -33,6 +36,8 @@ func (c CertType) String() string {
 		return Inter
 	case ClientCert:
 		return Client
+	case PendingIntermediateCA:
+		return PendingInter
 	default:
 		return Unknown
 	}
@@ -46,27 +51,99 @@ func CertTypeFromString(s string) (CertType, error) {
 		return IntermediateCA, nil
 	case Client:
 		return ClientCert, nil
+	case PendingInter:
+		return PendingIntermediateCA, nil
 	default:
 		return -1, errors.New("unknown cert type")
 	}
 }
 
+// DefaultIssuer is the name of the issuer used when a request does not select one.
+const DefaultIssuer = "default"
+
 type CA struct {
 	Type         CertType
 	Certificate  *x509.Certificate
-	PrivateKey   *rsa.PrivateKey
+	Signer       crypto.Signer
 	SerialNumber string
+	// Name identifies an intermediate CA as a named issuer.
+	Name string
+	// Retired issuers no longer issue certificates but still sign CRLs
+	// and OCSP responses for the certificates they issued. A retired root
+	// stays in the trust bundle while certificates under it are still valid.
+	Retired bool
+	// Staged marks a root CA generated ahead of a rotation. It is published
+	// in the trust bundle but does not sign until it is activated.
+	Staged bool
+	// CrossCertificate is the root certificate cross-signed by the previous root.
+	CrossCertificate *x509.Certificate
+}
+
+// Issuer is a named intermediate CA under the root CA.
+type Issuer struct {
+	Name         string    `json:"name"`
+	SerialNumber string    `json:"serial_number"`
+	Certificate  []byte    `json:"certificate,omitempty"`
+	ExpiryTime   time.Time `json:"expiry_time"`
+	Retired      bool      `json:"retired"`
+}
+
+// CAImport holds an existing CA certificate that is imported instead of
+// being generated by the service. The signing key is given either as a PEM
+// encoded private key or as a key store reference. A root CA may be imported
+// without a key, in which case intermediates are signed offline through
+// GenerateIntermediateCSR and InstallIntermediateCA. An imported intermediate
+// replaces the issuer with the given name, or the default issuer.
+type CAImport struct {
+	Type        CertType
+	Name        string
+	Certificate []byte
+	Key         []byte
+	KeyRef      string
+}
+
+// Signer is a CA signing key whose private part may live outside of the
+// service, e.g. in an HSM or a key management service.
+type Signer interface {
+	crypto.Signer
+
+	// KeyRef returns the reference that is persisted instead of the private key.
+	KeyRef() string
+}
+
+// KeyStore generates and loads CA signing keys kept outside of the repository.
+type KeyStore interface {
+	// Generate creates a new key pair identified by keyID.
+	Generate(ctx context.Context, keyID, algorithm string, size int) (Signer, error)
+
+	// Load returns the signer for a previously generated key reference.
+	Load(ctx context.Context, keyRef string) (Signer, error)
 }
 
 type Certificate struct {
 	SerialNumber string    `db:"serial_number"`
 	Certificate  []byte    `db:"certificate"`
 	Key          []byte    `db:"key"`
+	KeyRef       string    `db:"key_ref"`
+	KeyVersion   int       `db:"key_version"`
 	Revoked      bool      `db:"revoked"`
 	ExpiryTime   time.Time `db:"expiry_time"`
-	EntityID     string    `db:"entity_id"`
-	Type         CertType  `db:"type"`
-	DownloadUrl  string    `db:"-"`
+	// RevocationReason, RevocationTime and InvalidityDate describe the
+	// revocation of a revoked certificate. A zero InvalidityDate is not set.
+	RevocationReason RevocationReason `db:"revocation_reason"`
+	RevocationTime   time.Time        `db:"revocation_time"`
+	InvalidityDate   time.Time        `db:"invalidity_date"`
+	EntityID         string           `db:"entity_id"`
+	Type             CertType         `db:"type"`
+	IssuerName       string           `db:"issuer_name"`
+	IssuerSerial     string           `db:"issuer_serial"`
+	Retired          bool             `db:"retired"`
+	Staged           bool             `db:"staged"`
+	CrossCert        []byte           `db:"cross_certificate"`
+	// Profile is the name of the profile a client certificate was issued
+	// with, which its renewals are validated against.
+	Profile     string `db:"profile"`
+	DownloadUrl string `db:"-"`
 }
 
 type CertificatePage struct {
@@ -79,6 +156,8 @@ type PageMetadata struct {
 	Offset   uint64 `json:"offset,omitempty" db:"offset"`
 	Limit    uint64 `json:"limit" db:"limit"`
 	EntityID string `json:"entity_id,omitempty" db:"entity_id"`
+	// Status filters the listed CSRs by their approval status.
+	Status CSRStatus `json:"status,omitempty" db:"status"`
 }
 
 type CSRMetadata struct {
@@ -95,9 +174,29 @@ type CSRMetadata struct {
 	EmailAddresses     []string `json:"email_addresses"`
 }
 
+// CSR is a certificate signing request. Submitted CSRs are kept in the
+// approval queue together with the requested issuer, profile and TTL and the
+// metadata of the requester.
 type CSR struct {
-	CSR        []byte `json:"csr,omitempty"`
-	PrivateKey []byte `json:"private_key,omitempty"`
+	ID           string    `json:"id,omitempty"`
+	EntityID     string    `json:"entity_id,omitempty"`
+	Issuer       string    `json:"issuer,omitempty"`
+	Profile      string    `json:"profile,omitempty"`
+	TTL          string    `json:"ttl,omitempty"`
+	CSR          []byte    `json:"csr,omitempty"`
+	PrivateKey   []byte    `json:"private_key,omitempty"`
+	Status       CSRStatus `json:"status,omitempty"`
+	Requester    Requester `json:"requester"`
+	Reason       string    `json:"reason,omitempty"`
+	SerialNumber string    `json:"serial_number,omitempty"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Requester describes the client that submitted a CSR.
+type Requester struct {
+	Address   string `json:"address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
 }
 
 type CSRPage struct {
@@ -116,28 +215,179 @@ type SubjectOptions struct {
 	PostalCode         []string `json:"postal_code"`
 	DnsNames           []string `json:"dns_names"`
 	IpAddresses        []net.IP `json:"ip_addresses"`
+	EmailAddresses     []string `json:"email_addresses"`
+}
+
+// Profile is a named certificate template. It determines the key usages,
+// extended key usages, validity and subject alternative names of the
+// certificates issued with it. Profiles loaded from the configuration are
+// read-only, the others are managed through the API.
+type Profile struct {
+	Name         string         `json:"name" yaml:"name"`
+	KeyUsages    []string       `json:"key_usages" yaml:"key_usages"`
+	ExtKeyUsages []string       `json:"ext_key_usages" yaml:"ext_key_usages"`
+	TTL          string         `json:"ttl,omitempty" yaml:"ttl"`
+	MaxTTL       string         `json:"max_ttl,omitempty" yaml:"max_ttl"`
+	AllowedSANs  []string       `json:"allowed_sans" yaml:"allowed_sans"`
+	Subject      ProfileSubject `json:"subject" yaml:"subject"`
+	ReadOnly     bool           `json:"read_only" yaml:"-"`
+}
+
+// ProfileSubject holds the subject fields applied to the certificates issued
+// with a profile when the request leaves them empty.
+type ProfileSubject struct {
+	Organization       []string `json:"organization,omitempty" yaml:"organization"`
+	OrganizationalUnit []string `json:"organizational_unit,omitempty" yaml:"organizational_unit"`
+	Country            []string `json:"country,omitempty" yaml:"country"`
+	Province           []string `json:"province,omitempty" yaml:"province"`
+	Locality           []string `json:"locality,omitempty" yaml:"locality"`
+	StreetAddress      []string `json:"street_address,omitempty" yaml:"street_address"`
+	PostalCode         []string `json:"postal_code,omitempty" yaml:"postal_code"`
 }
 
 type Config struct {
-	CommonName         string   `yaml:"common_name"`
-	Organization       []string `yaml:"organization"`
-	OrganizationalUnit []string `yaml:"organizational_unit"`
-	Country            []string `yaml:"country"`
-	Province           []string `yaml:"province"`
-	Locality           []string `yaml:"locality"`
-	StreetAddress      []string `yaml:"street_address"`
-	PostalCode         []string `yaml:"postal_code"`
-	DNSNames           []string `yaml:"dns_names"`
-	IPAddresses        []net.IP `yaml:"ip_addresses"`
-	ValidityPeriod     string   `yaml:"validity_period"`
+	CommonName         string     `yaml:"common_name"`
+	Organization       []string   `yaml:"organization"`
+	OrganizationalUnit []string   `yaml:"organizational_unit"`
+	Country            []string   `yaml:"country"`
+	Province           []string   `yaml:"province"`
+	Locality           []string   `yaml:"locality"`
+	StreetAddress      []string   `yaml:"street_address"`
+	PostalCode         []string   `yaml:"postal_code"`
+	DNSNames           []string   `yaml:"dns_names"`
+	IPAddresses        []net.IP   `yaml:"ip_addresses"`
+	ValidityPeriod     string     `yaml:"validity_period"`
+	KeyAlgorithm       string     `yaml:"key_algorithm"`
+	KeySize            int        `yaml:"key_size"`
+	CrossSign          bool       `yaml:"cross_sign"`
+	Root               CASettings `yaml:"-"`
+	Intermediate       CASettings `yaml:"-"`
+	// Issuers holds the settings of the named issuers that differ from the intermediate CA settings.
+	Issuers  map[string]CASettings `yaml:"-"`
+	Profiles []Profile             `yaml:"profiles"`
+	// PublicURLs are the base URLs the service is reachable at by relying
+	// parties. They are embedded in the AIA and CDP extensions of issued certificates.
+	PublicURLs []string `yaml:"public_urls"`
+	// CRL holds the publication settings of the certificate revocation lists.
+	CRL CRLSettings `yaml:"-"`
+	// OCSP holds the signing settings of the OCSP responses.
+	OCSP   OCSPSettings `yaml:"-"`
+	Policy Policy       `yaml:"-"`
+	// Approval determines which submitted CSRs wait for approval.
+	Approval ApprovalSettings `yaml:"approval"`
+	// DownloadTokens holds how the single-use download tokens are signed.
+	DownloadTokens       TokenSettings `yaml:"-"`
+	ImportRootCA         *CAImport     `yaml:"-"`
+	ImportIntermediateCA *CAImport     `yaml:"-"`
+}
+
+// CASettings holds the validity, key, constraint and rotation settings of the
+// root or the intermediate CA.
+type CASettings struct {
+	ValidityPeriod time.Duration
+	KeyAlgorithm   string
+	KeySize        int
+	// MaxPathLen and MaxPathLenZero limit the number of CAs below the CA
+	// the same way as in x509.Certificate.
+	MaxPathLen          int
+	MaxPathLenZero      bool
+	PermittedDNSDomains []string
+	ExcludedDNSDomains  []string
+	PermittedIPRanges   []*net.IPNet
+	ExcludedIPRanges    []*net.IPNet
+	// PermittedEmailAddresses and ExcludedEmailAddresses hold full mailboxes
+	// or domains the email addresses of the issued certificates are matched against.
+	PermittedEmailAddresses []string
+	ExcludedEmailAddresses  []string
+	// ExtKeyUsages are the names of the extended key usages of the CA. The
+	// certificates it issues must not use others unless "any" is included.
+	ExtKeyUsages []string
+	EmailAddress string
+	// RotationThreshold is how long before expiry the CA is rotated.
+	RotationThreshold time.Duration
+	// StageThreshold is how long before expiry the next root CA is staged.
+	StageThreshold time.Duration
+}
+
+// CRLSettings holds how long the published CRLs are valid and how often they
+// are regenerated.
+type CRLSettings struct {
+	// Validity is the time between the thisUpdate and nextUpdate of complete CRLs.
+	Validity time.Duration
+	// RefreshInterval is how often complete CRLs are regenerated.
+	RefreshInterval time.Duration
+	// DeltaValidity is the time between the thisUpdate and nextUpdate of delta
+	// CRLs. Delta CRLs are disabled if it is zero.
+	DeltaValidity time.Duration
+}
+
+// OCSPSettings holds how OCSP responses are signed and how long they are valid.
+type OCSPSettings struct {
+	// NextUpdate is the time between the thisUpdate and nextUpdate of responses.
+	NextUpdate time.Duration
+	// DelegatedResponder makes the CAs sign OCSP responses with auto-provisioned
+	// delegated responder certificates instead of their own keys.
+	DelegatedResponder bool
+	// ResponderValidity is the validity of the delegated responder certificates.
+	ResponderValidity time.Duration
+	// Cache keeps the signed responses in memory and serves them until they
+	// are re-signed halfway to their next update or on revocation changes.
+	Cache bool
+}
+
+// OCSPResponse is a signed OCSP response.
+type OCSPResponse struct {
+	ThisUpdate time.Time
+	NextUpdate time.Time
+	DER        []byte
+}
+
+// CRL is a complete or delta certificate revocation list published by a CA.
+type CRL struct {
+	Number uint64
+	// BaseNumber is the number of the complete CRL a delta CRL is based on.
+	// It is zero for complete CRLs.
+	BaseNumber uint64
+	ThisUpdate time.Time
+	NextUpdate time.Time
+	DER        []byte
+}
+
+// Policy holds the issuance rules evaluated before a certificate is signed.
+// Empty allow lists and zero limits do not restrict issuance.
+type Policy struct {
+	// AllowedDomains and DeniedDomains match the DNS names and DNS-like
+	// common names. A pattern starting with "*." matches a single label and
+	// one starting with "." matches any subdomain.
+	AllowedDomains  []string
+	DeniedDomains   []string
+	AllowedIPRanges []*net.IPNet
+	DeniedIPRanges  []*net.IPNet
+	// RequiredSubject lists the subject fields that must not be empty, such
+	// as "common_name" or "organization".
+	RequiredSubject []string
+	MaxTTL          time.Duration
+	// EntityMaxTTL caps the validity of the certificates of single entities.
+	EntityMaxTTL    map[string]time.Duration
+	MinRSAKeySize   int
+	MinECDSAKeySize int
 }
 
 type Service interface {
 	// RenewCert renews a certificate from the database.
 	RenewCert(ctx context.Context, serialNumber string) error
 
-	// RevokeCert revokes a certificate from the database.
-	RevokeCert(ctx context.Context, serialNumber string) error
+	// RevokeCert revokes a certificate with the given reason. A non-zero
+	// invalidity date is when the certificate is known or suspected to have
+	// become invalid.
+	RevokeCert(ctx context.Context, serialNumber string, reason RevocationReason, invalidityDate time.Time) error
+
+	// HoldCert places a certificate on hold, reporting it as revoked with the
+	// certificateHold reason until it is released.
+	HoldCert(ctx context.Context, serialNumber string) error
+
+	// ReleaseCert releases a certificate from hold.
+	ReleaseCert(ctx context.Context, serialNumber string) error
 
 	// RetrieveCert retrieves a certificate record from the database.
 	RetrieveCert(ctx context.Context, token, serialNumber string) (Certificate, []byte, error)
@@ -153,29 +403,106 @@ type Service interface {
 	RetrieveCertDownloadToken(ctx context.Context, serialNumber string) (string, error)
 
 	// RetrieveCAToken generates a CA download and view token.
-	// The token is needed to view and download the CA certificate.
-	RetrieveCAToken(ctx context.Context) (string, error)
+	// The token is needed to view and download the CA chain of the given issuer.
+	RetrieveCAToken(ctx context.Context, issuer string) (string, error)
+
+	// IssueCert issues a certificate signed by the given issuer using the given profile.
+	IssueCert(ctx context.Context, entityID, issuer, profile, ttl string, ipAddrs []string, option SubjectOptions) (Certificate, error)
 
-	// IssueCert issues a certificate from the database.
-	IssueCert(ctx context.Context, entityID, ttl string, ipAddrs []string, option SubjectOptions) (Certificate, error)
+	// OCSP retrieves the OCSP status for a certificate together with the CA that signs the response.
+	OCSP(ctx context.Context, serialNumber string) (*Certificate, int, *CA, error)
 
-	// OCSP retrieves the OCSP response for a certificate.
-	OCSP(ctx context.Context, serialNumber string) (*Certificate, int, *x509.Certificate, error)
+	// SignOCSP signs the OCSP response for a certificate issued by the CA with the given serial number.
+	// A request nonce is echoed in the response, which is then never served from the cache.
+	SignOCSP(ctx context.Context, issuerSerial string, template ocsp.Response, nonce []byte) (OCSPResponse, error)
 
 	// GetEntityID retrieves the entity ID for a certificate.
 	GetEntityID(ctx context.Context, serialNumber string) (string, error)
 
-	// GenerateCRL creates cert revocation list.
-	GenerateCRL(ctx context.Context, caType CertType) ([]byte, error)
+	// GenerateCRL creates cert revocation list of the root CA or the given issuer.
+	GenerateCRL(ctx context.Context, caType CertType, issuer string) ([]byte, error)
+
+	// ViewCACert retrieves the public certificate of the root or intermediate CA with the given serial number.
+	ViewCACert(ctx context.Context, serialNumber string) (Certificate, error)
+
+	// GenerateCACRL retrieves the published complete or delta cert revocation list of the CA with the given serial number.
+	GenerateCACRL(ctx context.Context, serialNumber string, delta bool) (CRL, error)
+
+	// ViewCAChain retrieves the public certificates of the chain of the issuer,
+	// the issuer first, without a token.
+	ViewCAChain(ctx context.Context, issuer string) (Certificate, error)
+
+	// TrustBundle retrieves the PEM encoded root CA certificates relying parties trust.
+	TrustBundle(ctx context.Context) ([]byte, error)
+
+	// ExportCA exports the CA with the serial number and its private key as a
+	// backup encrypted with the passphrase.
+	ExportCA(ctx context.Context, serialNumber, passphrase string) ([]byte, error)
 
 	// GetChainCA retrieves the chain of CA i.e. root and intermediate cert concat together.
+	// The issuer is the one the token was retrieved for.
 	GetChainCA(ctx context.Context, token string) (Certificate, error)
 
 	// RemoveCert deletes a cert for a provided  entityID.
 	RemoveCert(ctx context.Context, entityId string) error
 
-	// IssueFromCSR creates a certificate from a given CSR.
-	IssueFromCSR(ctx context.Context, entityID, ttl string, csr CSR) (Certificate, error)
+	// IssueFromCSR creates a certificate from a given CSR signed by the given issuer using the given profile.
+	IssueFromCSR(ctx context.Context, entityID, issuer, profile, ttl string, csr CSR) (Certificate, error)
+
+	// SubmitCSR stores a CSR in the approval queue. The certificate is issued
+	// right away if the CSR is auto-approved.
+	SubmitCSR(ctx context.Context, csr CSR) (CSR, error)
+
+	// ViewCSR retrieves a submitted CSR.
+	ViewCSR(ctx context.Context, id string) (CSR, error)
+
+	// ListCSRs retrieves the submitted CSRs while applying the entity ID and status filters.
+	ListCSRs(ctx context.Context, pm PageMetadata) (CSRPage, error)
+
+	// ApproveCSR approves a pending CSR and issues its certificate. A non-empty
+	// TTL or profile overrides the requested one. A CSR left issuing by a
+	// failed request can be approved again once its issuance timed out.
+	ApproveCSR(ctx context.Context, id, ttl, profile string) (CSR, error)
+
+	// RejectCSR rejects a pending CSR with the given reason. A CSR left
+	// issuing by a failed request can be rejected once its issuance timed out.
+	RejectCSR(ctx context.Context, id, reason string) (CSR, error)
+
+	// ImportCA imports an existing root or intermediate CA and makes it the active one.
+	// The CAs it replaces are retired, so certificates issued under them stay valid.
+	ImportCA(ctx context.Context, ca CAImport) (Certificate, error)
+
+	// GenerateIntermediateCSR generates a new intermediate CA key and returns
+	// a CSR to be signed by the root CA.
+	GenerateIntermediateCSR(ctx context.Context) (CSR, error)
+
+	// InstallIntermediateCA installs an intermediate CA certificate signed
+	// from a CSR created by GenerateIntermediateCSR.
+	InstallIntermediateCA(ctx context.Context, cert []byte) (Certificate, error)
+
+	// CreateIssuer creates a new named intermediate CA under the root CA.
+	CreateIssuer(ctx context.Context, name string) (Issuer, error)
+
+	// ListIssuers retrieves the active and retired issuers.
+	ListIssuers(ctx context.Context) ([]Issuer, error)
+
+	// RetireIssuer stops the issuer from issuing new certificates.
+	RetireIssuer(ctx context.Context, name string) error
+
+	// CreateProfile creates a new certificate profile.
+	CreateProfile(ctx context.Context, profile Profile) (Profile, error)
+
+	// ViewProfile retrieves the certificate profile with the given name.
+	ViewProfile(ctx context.Context, name string) (Profile, error)
+
+	// ListProfiles retrieves the configured and created certificate profiles.
+	ListProfiles(ctx context.Context) ([]Profile, error)
+
+	// UpdateProfile updates a created certificate profile.
+	UpdateProfile(ctx context.Context, profile Profile) (Profile, error)
+
+	// RemoveProfile deletes a created certificate profile.
+	RemoveProfile(ctx context.Context, name string) error
 }
 
 type Repository interface {
@@ -194,9 +521,64 @@ type Repository interface {
 	// GetCAs retrieves rootCA and intermediateCA from database.
 	GetCAs(ctx context.Context, caType ...CertType) ([]Certificate, error)
 
-	// ListRevokedCerts retrieves revoked lists from database.
-	ListRevokedCerts(ctx context.Context) ([]Certificate, error)
+	// CountValidCerts counts the certificates issued by the given CAs that
+	// are neither revoked nor expired.
+	CountValidCerts(ctx context.Context, issuerSerials []string) (uint64, error)
+
+	// ListRevokedCerts retrieves revoked lists from database. If issuer serial
+	// numbers are given, only certificates issued by those CAs are listed.
+	ListRevokedCerts(ctx context.Context, issuerSerials ...string) ([]Certificate, error)
+
+	// ListRevocationChanges retrieves the certificates revoked, held or
+	// released since the given time. If issuer serial numbers are given, only
+	// certificates issued by those CAs are listed.
+	ListRevocationChanges(ctx context.Context, since time.Time, issuerSerials ...string) ([]Certificate, error)
+
+	// NextCRLNumber increments and returns the CRL number of the CA with the given serial number.
+	NextCRLNumber(ctx context.Context, issuerSerial string) (uint64, error)
 
 	// RemoveCert deletes cert from database.
 	RemoveCert(ctx context.Context, entityId string) error
+
+	// ListStaleKeys retrieves certificates whose stored private key is not
+	// encrypted with the given master key version.
+	ListStaleKeys(ctx context.Context, keyVersion int, limit uint64) ([]Certificate, error)
+
+	// UpdateKey updates the stored private key and its master key version.
+	UpdateKey(ctx context.Context, cert Certificate) error
+
+	// RemoveCertBySerial deletes the certificate with the given serial number.
+	RemoveCertBySerial(ctx context.Context, serialNumber string) error
+
+	// CreateProfile adds a certificate profile to the database.
+	CreateProfile(ctx context.Context, profile Profile) error
+
+	// RetrieveProfile retrieves a certificate profile from the database.
+	RetrieveProfile(ctx context.Context, name string) (Profile, error)
+
+	// ListProfiles retrieves the certificate profiles from the database.
+	ListProfiles(ctx context.Context) ([]Profile, error)
+
+	// UpdateProfile updates a certificate profile in the database.
+	UpdateProfile(ctx context.Context, profile Profile) error
+
+	// RemoveProfile deletes a certificate profile from the database.
+	RemoveProfile(ctx context.Context, name string) error
+
+	// CreateCSR adds a submitted CSR to the database.
+	CreateCSR(ctx context.Context, csr CSR) error
+
+	// RetrieveCSR retrieves a submitted CSR from the database.
+	RetrieveCSR(ctx context.Context, id string) (CSR, error)
+
+	// UpdateCSR updates a submitted CSR in the database if it still has the
+	// given status, and returns ErrCSRNotPending otherwise.
+	UpdateCSR(ctx context.Context, csr CSR, status CSRStatus) error
+
+	// ListCSRs retrieves the submitted CSRs from the database while applying filters.
+	ListCSRs(ctx context.Context, pm PageMetadata) (CSRPage, error)
+
+	// RedeemToken records the redemption of the download token with the ID
+	// until it expires. ErrConflict is returned if it has already been redeemed.
+	RedeemToken(ctx context.Context, id string, expiresAt time.Time) error
 }
\ No newline at end of file
@@ -1,23 +1,34 @@
 package certs_test
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
 	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/golang-jwt/jwt"
 	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/envelope"
 	"github.com/hantdev/certs/errors"
 	"github.com/hantdev/certs/mocks"
+	"github.com/hantdev/certs/signer/file"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
 )
 
 const serialNumber = "serial number"
@@ -34,7 +45,7 @@ func TestIssueCert(t *testing.T) {
 
 	repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
 	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
-	svc, err := certs.NewService(context.Background(), cRepo, &config)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
 	require.NoError(t, err)
 	repoCall.Unset()
 	repoCall1.Unset()
@@ -64,7 +75,7 @@ func TestIssueCert(t *testing.T) {
 		t.Run(tc.desc, func(t *testing.T) {
 			repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(tc.err)
 
-			_, err = svc.IssueCert(context.Background(), tc.backendId, tc.ttl, []string{}, certs.SubjectOptions{})
+			_, err = svc.IssueCert(context.Background(), tc.backendId, "", "", tc.ttl, []string{}, certs.SubjectOptions{})
 			require.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
 			repoCall1.Unset()
 		})
@@ -78,17 +89,19 @@ func TestRevokeCert(t *testing.T) {
 
 	repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
 	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
-	svc, err := certs.NewService(context.Background(), cRepo, &config)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
 	require.NoError(t, err)
 	repoCall.Unset()
 	repoCall1.Unset()
 
 	testCases := []struct {
-		desc         string
-		serial       string
-		retrieveErr  error
-		err          error
-		shouldRevoke bool
+		desc           string
+		serial         string
+		reason         certs.RevocationReason
+		invalidityDate time.Time
+		retrieveErr    error
+		err            error
+		shouldRevoke   bool
 	}{
 		{
 			desc:         "successful revoke",
@@ -96,6 +109,35 @@ func TestRevokeCert(t *testing.T) {
 			err:          nil,
 			shouldRevoke: true,
 		},
+		{
+			desc:           "successful revoke with reason and invalidity date",
+			serial:         serialNumber,
+			reason:         certs.RevocationKeyCompromise,
+			invalidityDate: time.Now().Add(-time.Hour),
+			err:            nil,
+			shouldRevoke:   true,
+		},
+		{
+			desc:         "revoke with certificate hold reason",
+			serial:       serialNumber,
+			reason:       certs.RevocationCertificateHold,
+			err:          certs.ErrInvalidRevocationReason,
+			shouldRevoke: false,
+		},
+		{
+			desc:         "revoke with unknown reason",
+			serial:       serialNumber,
+			reason:       certs.RevocationReason(7),
+			err:          certs.ErrMalformedEntity,
+			shouldRevoke: false,
+		},
+		{
+			desc:           "revoke with future invalidity date",
+			serial:         serialNumber,
+			invalidityDate: time.Now().Add(time.Hour),
+			err:            certs.ErrMalformedEntity,
+			shouldRevoke:   false,
+		},
 		{
 			desc:         "failed repo get cert",
 			serial:       invalidSerialNumber,
@@ -119,18 +161,495 @@ func TestRevokeCert(t *testing.T) {
 			repoCall2 := cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(certs.Certificate{}, tc.retrieveErr)
 			defer repoCall2.Unset()
 
-			err = svc.RevokeCert(context.Background(), tc.serial)
+			err = svc.RevokeCert(context.Background(), tc.serial, tc.reason, tc.invalidityDate)
 			require.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
 		})
 	}
 }
 
+func TestRevocationDetails(t *testing.T) {
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) certs.Certificate {
+		return stored[sn]
+	}, nil)
+	cRepo.On("UpdateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything, mock.Anything).Return(func(context.Context, ...string) []certs.Certificate {
+		var revoked []certs.Certificate
+		for _, c := range stored {
+			if c.Revoked && c.Type == certs.ClientCert {
+				revoked = append(revoked, c)
+			}
+		}
+		return revoked
+	}, nil)
+	cRepo.On("NextCRLNumber", mock.Anything, mock.Anything).Return(uint64(1), nil)
+
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
+	require.NoError(t, err)
+
+	cert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", nil, certs.SubjectOptions{CommonName: "device"})
+	require.NoError(t, err)
+	invalidityDate := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, svc.RevokeCert(context.Background(), cert.SerialNumber, certs.RevocationKeyCompromise, invalidityDate))
+
+	revoked := stored[cert.SerialNumber]
+	assert.True(t, revoked.Revoked)
+	assert.Equal(t, cert.ExpiryTime, revoked.ExpiryTime)
+	assert.Equal(t, certs.RevocationKeyCompromise, revoked.RevocationReason)
+	assert.WithinDuration(t, time.Now(), revoked.RevocationTime, time.Minute)
+	revocationTime := revoked.RevocationTime
+
+	// Revoking again updates the reason but keeps the revocation time.
+	require.NoError(t, svc.RevokeCert(context.Background(), cert.SerialNumber, certs.RevocationSuperseded, time.Time{}))
+	assert.Equal(t, certs.RevocationSuperseded, stored[cert.SerialNumber].RevocationReason)
+	assert.Equal(t, revocationTime, stored[cert.SerialNumber].RevocationTime)
+	require.NoError(t, svc.RevokeCert(context.Background(), cert.SerialNumber, certs.RevocationKeyCompromise, invalidityDate))
+
+	crlPEM, err := svc.GenerateCRL(context.Background(), certs.IntermediateCA, "")
+	require.NoError(t, err)
+	block, _ := pem.Decode(crlPEM)
+	require.NotNil(t, block)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	require.NoError(t, err)
+	require.Len(t, crl.RevokedCertificateEntries, 1)
+	entry := crl.RevokedCertificateEntries[0]
+	assert.Equal(t, cert.SerialNumber, entry.SerialNumber.String())
+	assert.Equal(t, int(certs.RevocationKeyCompromise), entry.ReasonCode)
+	assert.WithinDuration(t, revocationTime, entry.RevocationTime, time.Second)
+	var date time.Time
+	for _, ext := range entry.Extensions {
+		if ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 24}) {
+			_, err := asn1.UnmarshalWithParams(ext.Value, &date, "generalized")
+			require.NoError(t, err)
+		}
+	}
+	assert.True(t, invalidityDate.Equal(date), "expected invalidity date %s, got %s", invalidityDate, date)
+
+	status, _, _, err := svc.OCSP(context.Background(), cert.SerialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, certs.RevocationKeyCompromise, status.RevocationReason)
+
+	for name, reason := range map[string]certs.RevocationReason{"": certs.RevocationUnspecified, "key_compromise": certs.RevocationKeyCompromise, "aa_compromise": certs.RevocationAACompromise} {
+		parsed, err := certs.ParseRevocationReason(name)
+		require.NoError(t, err)
+		assert.Equal(t, reason, parsed)
+	}
+	_, err = certs.ParseRevocationReason("lost")
+	assert.True(t, errors.Contains(err, certs.ErrInvalidRevocationReason), "expected error %v, got %v", certs.ErrInvalidRevocationReason, err)
+}
+
+func TestHoldCert(t *testing.T) {
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) certs.Certificate {
+		return stored[sn]
+	}, nil)
+	cRepo.On("UpdateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything, mock.Anything).Return(func(context.Context, ...string) []certs.Certificate {
+		var revoked []certs.Certificate
+		for _, c := range stored {
+			if c.Revoked && c.Type == certs.ClientCert {
+				revoked = append(revoked, c)
+			}
+		}
+		return revoked
+	}, nil)
+	cRepo.On("NextCRLNumber", mock.Anything, mock.Anything).Return(uint64(1), nil)
+
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
+	require.NoError(t, err)
+
+	cert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", nil, certs.SubjectOptions{CommonName: "device"})
+	require.NoError(t, err)
+
+	err = svc.ReleaseCert(context.Background(), cert.SerialNumber)
+	assert.True(t, errors.Contains(err, certs.ErrCertNotOnHold), "expected error %v, got %v", certs.ErrCertNotOnHold, err)
+
+	require.NoError(t, svc.HoldCert(context.Background(), cert.SerialNumber))
+	held := stored[cert.SerialNumber]
+	assert.True(t, held.Revoked)
+	assert.Equal(t, certs.RevocationCertificateHold, held.RevocationReason)
+	// Holding a held certificate again keeps the original hold.
+	require.NoError(t, svc.HoldCert(context.Background(), cert.SerialNumber))
+	assert.Equal(t, held.RevocationTime, stored[cert.SerialNumber].RevocationTime)
+
+	crlPEM, err := svc.GenerateCRL(context.Background(), certs.IntermediateCA, "")
+	require.NoError(t, err)
+	block, _ := pem.Decode(crlPEM)
+	require.NotNil(t, block)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	require.NoError(t, err)
+	require.Len(t, crl.RevokedCertificateEntries, 1)
+	assert.Equal(t, cert.SerialNumber, crl.RevokedCertificateEntries[0].SerialNumber.String())
+	assert.Equal(t, int(certs.RevocationCertificateHold), crl.RevokedCertificateEntries[0].ReasonCode)
+
+	status, _, _, err := svc.OCSP(context.Background(), cert.SerialNumber)
+	require.NoError(t, err)
+	assert.True(t, status.Revoked)
+	assert.Equal(t, certs.RevocationCertificateHold, status.RevocationReason)
+
+	require.NoError(t, svc.ReleaseCert(context.Background(), cert.SerialNumber))
+	released := stored[cert.SerialNumber]
+	assert.False(t, released.Revoked)
+	assert.Equal(t, certs.RevocationRemoveFromCRL, released.RevocationReason)
+
+	crlPEM, err = svc.GenerateCRL(context.Background(), certs.IntermediateCA, "")
+	require.NoError(t, err)
+	block, _ = pem.Decode(crlPEM)
+	require.NotNil(t, block)
+	crl, err = x509.ParseRevocationList(block.Bytes)
+	require.NoError(t, err)
+	assert.Empty(t, crl.RevokedCertificateEntries)
+
+	status, _, _, err = svc.OCSP(context.Background(), cert.SerialNumber)
+	require.NoError(t, err)
+	assert.False(t, status.Revoked)
+
+	// A held certificate may be revoked permanently, after which it can
+	// neither be held nor released.
+	require.NoError(t, svc.HoldCert(context.Background(), cert.SerialNumber))
+	require.NoError(t, svc.RevokeCert(context.Background(), cert.SerialNumber, certs.RevocationKeyCompromise, time.Time{}))
+	assert.Equal(t, certs.RevocationKeyCompromise, stored[cert.SerialNumber].RevocationReason)
+	err = svc.HoldCert(context.Background(), cert.SerialNumber)
+	assert.True(t, errors.Contains(err, certs.ErrCertAlreadyRevoked), "expected error %v, got %v", certs.ErrCertAlreadyRevoked, err)
+	err = svc.ReleaseCert(context.Background(), cert.SerialNumber)
+	assert.True(t, errors.Contains(err, certs.ErrCertNotOnHold), "expected error %v, got %v", certs.ErrCertNotOnHold, err)
+}
+
+func TestCRLPublishing(t *testing.T) {
+	stored := map[string]certs.Certificate{}
+	numbers := map[string]uint64{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) certs.Certificate {
+		return stored[sn]
+	}, nil)
+	cRepo.On("UpdateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything, mock.Anything).Return(func(context.Context, ...string) []certs.Certificate {
+		var revoked []certs.Certificate
+		for _, c := range stored {
+			if c.Revoked && c.Type == certs.ClientCert {
+				revoked = append(revoked, c)
+			}
+		}
+		return revoked
+	}, nil)
+	cRepo.On("ListRevocationChanges", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, since time.Time, _ ...string) []certs.Certificate {
+		var changed []certs.Certificate
+		for _, c := range stored {
+			if c.Type == certs.ClientCert && !c.RevocationTime.Before(since) && (c.Revoked || c.RevocationReason == certs.RevocationRemoveFromCRL) {
+				changed = append(changed, c)
+			}
+		}
+		return changed
+	}, nil)
+	cRepo.On("NextCRLNumber", mock.Anything, mock.Anything).Return(func(_ context.Context, serial string) uint64 {
+		numbers[serial]++
+		return numbers[serial]
+	}, nil)
+
+	cfg := config
+	cfg.PublicURLs = []string{"https://ca.example.com"}
+	cfg.CRL = certs.CRLSettings{DeltaValidity: time.Hour}
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+	require.NoError(t, err)
+	issuers, err := svc.ListIssuers(context.Background())
+	require.NoError(t, err)
+	issuer := issuers[0].SerialNumber
+
+	revoked, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", nil, certs.SubjectOptions{CommonName: "revoked"})
+	require.NoError(t, err)
+	held, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", nil, certs.SubjectOptions{CommonName: "held"})
+	require.NoError(t, err)
+
+	complete, err := svc.GenerateCACRL(context.Background(), issuer, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), complete.Number)
+	assert.Equal(t, complete.ThisUpdate.Add(24*time.Hour), complete.NextUpdate)
+	crl, err := x509.ParseRevocationList(complete.DER)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), crl.Number.Int64())
+	assert.Empty(t, crl.RevokedCertificateEntries)
+	var freshest bool
+	for _, ext := range crl.Extensions {
+		if ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 46}) {
+			freshest = true
+			assert.Contains(t, string(ext.Value), "https://ca.example.com/certs/ca/"+issuer+"/delta-crl")
+		}
+	}
+	assert.True(t, freshest, "complete CRL must carry the Freshest CRL extension")
+
+	// The published CRL is served until it is due.
+	cached, err := svc.GenerateCACRL(context.Background(), issuer, false)
+	require.NoError(t, err)
+	assert.Equal(t, complete, cached)
+	crlPEM, err := svc.GenerateCRL(context.Background(), certs.IntermediateCA, "")
+	require.NoError(t, err)
+	assert.Equal(t, complete.PEM(), crlPEM)
+
+	require.NoError(t, svc.RevokeCert(context.Background(), revoked.SerialNumber, certs.RevocationKeyCompromise, time.Time{}))
+	require.NoError(t, svc.HoldCert(context.Background(), held.SerialNumber))
+
+	// Revocations are published in the delta CRL, the complete CRL stays as it is.
+	cached, err = svc.GenerateCACRL(context.Background(), issuer, false)
+	require.NoError(t, err)
+	assert.Equal(t, complete.Number, cached.Number)
+	delta, err := svc.GenerateCACRL(context.Background(), issuer, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), delta.Number)
+	assert.Equal(t, complete.Number, delta.BaseNumber)
+	assert.Equal(t, delta.ThisUpdate.Add(time.Hour), delta.NextUpdate)
+	crl, err = x509.ParseRevocationList(delta.DER)
+	require.NoError(t, err)
+	assert.Len(t, crl.RevokedCertificateEntries, 2)
+	var indicator bool
+	for _, ext := range crl.Extensions {
+		if ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 27}) {
+			indicator = true
+			assert.True(t, ext.Critical)
+			var base int64
+			_, err := asn1.Unmarshal(ext.Value, &base)
+			require.NoError(t, err)
+			assert.Equal(t, int64(complete.Number), base)
+		}
+	}
+	assert.True(t, indicator, "delta CRL must carry the Delta CRL Indicator extension")
+
+	require.NoError(t, svc.ReleaseCert(context.Background(), held.SerialNumber))
+	delta, err = svc.GenerateCACRL(context.Background(), issuer, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), delta.Number)
+	crl, err = x509.ParseRevocationList(delta.DER)
+	require.NoError(t, err)
+	reasons := map[string]int{}
+	for _, entry := range crl.RevokedCertificateEntries {
+		reasons[entry.SerialNumber.String()] = entry.ReasonCode
+	}
+	assert.Equal(t, map[string]int{revoked.SerialNumber: int(certs.RevocationKeyCompromise), held.SerialNumber: int(certs.RevocationRemoveFromCRL)}, reasons)
+
+	// Without delta CRLs, revocations regenerate the complete CRL.
+	cfg.CRL = certs.CRLSettings{}
+	svc, err = certs.NewService(context.Background(), cRepo, nil, &cfg)
+	require.NoError(t, err)
+	issuers, err = svc.ListIssuers(context.Background())
+	require.NoError(t, err)
+	issuer = issuers[0].SerialNumber
+	_, err = svc.GenerateCACRL(context.Background(), issuer, true)
+	assert.True(t, errors.Contains(err, certs.ErrDeltaCRLDisabled), "expected error %v, got %v", certs.ErrDeltaCRLDisabled, err)
+	complete, err = svc.GenerateCACRL(context.Background(), issuer, false)
+	require.NoError(t, err)
+	suspended, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", nil, certs.SubjectOptions{CommonName: "suspended"})
+	require.NoError(t, err)
+	require.NoError(t, svc.HoldCert(context.Background(), suspended.SerialNumber))
+	cached, err = svc.GenerateCACRL(context.Background(), issuer, false)
+	require.NoError(t, err)
+	assert.Equal(t, complete.Number+1, cached.Number)
+	crl, err = x509.ParseRevocationList(cached.DER)
+	require.NoError(t, err)
+	assert.Len(t, crl.RevokedCertificateEntries, 2)
+	for _, ext := range crl.Extensions {
+		assert.False(t, ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 46}), "complete CRL must not reference delta CRLs when they are disabled")
+	}
+
+	for _, settings := range []certs.CRLSettings{
+		{Validity: time.Hour, RefreshInterval: 2 * time.Hour},
+		{Validity: time.Hour, DeltaValidity: 2 * time.Hour},
+		{DeltaValidity: -time.Hour},
+	} {
+		cfg.CRL = settings
+		_, err := certs.NewService(context.Background(), new(mocks.MockRepository), nil, &cfg)
+		assert.True(t, errors.Contains(err, certs.ErrInvalidConfig), "expected error %v, got %v", certs.ErrInvalidConfig, err)
+	}
+}
+
+func TestOCSPResponder(t *testing.T) {
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) certs.Certificate {
+		return stored[sn]
+	}, nil)
+
+	testCases := []struct {
+		desc       string
+		ocsp       certs.OCSPSettings
+		nextUpdate time.Duration
+		delegated  bool
+	}{
+		{
+			desc:       "signed by the issuing CA",
+			nextUpdate: time.Hour,
+		},
+		{
+			desc:       "signed by a delegated responder",
+			ocsp:       certs.OCSPSettings{NextUpdate: 30 * time.Minute, DelegatedResponder: true},
+			nextUpdate: 30 * time.Minute,
+			delegated:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := certs.Config{CommonName: "test", KeyAlgorithm: certs.KeyAlgorithmECDSA, KeySize: 256, OCSP: tc.ocsp}
+			svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+			require.NoError(t, err)
+
+			cert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", nil, certs.SubjectOptions{CommonName: "device"})
+			require.NoError(t, err)
+			leaf := parsePEMCert(t, cert.Certificate)
+			_, status, issuer, err := svc.OCSP(context.Background(), cert.SerialNumber)
+			require.NoError(t, err)
+			assert.IsType(t, &ecdsa.PublicKey{}, issuer.Certificate.PublicKey)
+
+			template := ocsp.Response{Status: status, SerialNumber: leaf.SerialNumber, IssuerHash: crypto.SHA1}
+			signed, err := svc.SignOCSP(context.Background(), issuer.SerialNumber, template, nil)
+			require.NoError(t, err)
+			res, err := ocsp.ParseResponseForCert(signed.DER, leaf, issuer.Certificate)
+			require.NoError(t, err)
+			assert.Equal(t, ocsp.Good, res.Status)
+			assert.WithinDuration(t, res.ThisUpdate.Add(tc.nextUpdate), res.NextUpdate, time.Second)
+
+			if !tc.delegated {
+				assert.Nil(t, res.Certificate)
+				assert.NoError(t, res.CheckSignatureFrom(issuer.Certificate))
+				return
+			}
+			require.NotNil(t, res.Certificate)
+			assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}, res.Certificate.ExtKeyUsage)
+			assert.IsType(t, &ecdsa.PublicKey{}, res.Certificate.PublicKey)
+			assert.NoError(t, res.Certificate.CheckSignatureFrom(issuer.Certificate))
+			var noCheck bool
+			for _, ext := range res.Certificate.Extensions {
+				noCheck = noCheck || ext.Id.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5})
+			}
+			assert.True(t, noCheck, "delegated responder certificate must carry the OCSP no check extension")
+
+			// The delegated responder is reused until it is due for replacement.
+			signed, err = svc.SignOCSP(context.Background(), issuer.SerialNumber, template, nil)
+			require.NoError(t, err)
+			again, err := ocsp.ParseResponseForCert(signed.DER, leaf, issuer.Certificate)
+			require.NoError(t, err)
+			assert.Equal(t, res.Certificate.SerialNumber, again.Certificate.SerialNumber)
+		})
+	}
+
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
+	require.NoError(t, err)
+	_, err = svc.SignOCSP(context.Background(), "unknown", ocsp.Response{SerialNumber: big.NewInt(1)}, nil)
+	assert.True(t, errors.Contains(err, certs.ErrCANotFound), "expected error %v, got %v", certs.ErrCANotFound, err)
+
+	for _, settings := range []certs.OCSPSettings{
+		{NextUpdate: -time.Hour},
+		{NextUpdate: 2 * time.Hour, ResponderValidity: time.Hour},
+	} {
+		cfg := config
+		cfg.OCSP = settings
+		_, err := certs.NewService(context.Background(), new(mocks.MockRepository), nil, &cfg)
+		assert.True(t, errors.Contains(err, certs.ErrInvalidConfig), "expected error %v, got %v", certs.ErrInvalidConfig, err)
+	}
+}
+
+func TestOCSPResponseCache(t *testing.T) {
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) certs.Certificate {
+		return stored[sn]
+	}, nil)
+	cRepo.On("UpdateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+
+	cfg := certs.Config{CommonName: "test", KeyAlgorithm: certs.KeyAlgorithmECDSA, KeySize: 256, OCSP: certs.OCSPSettings{Cache: true}}
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+	require.NoError(t, err)
+	cert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", nil, certs.SubjectOptions{CommonName: "device"})
+	require.NoError(t, err)
+	leaf := parsePEMCert(t, cert.Certificate)
+
+	sign := func(nonce []byte) (certs.OCSPResponse, *ocsp.Response) {
+		_, status, issuer, err := svc.OCSP(context.Background(), cert.SerialNumber)
+		require.NoError(t, err)
+		template := ocsp.Response{Status: status, SerialNumber: leaf.SerialNumber, IssuerHash: crypto.SHA1}
+		if status == ocsp.Revoked {
+			c := stored[cert.SerialNumber]
+			template.RevokedAt = c.RevocationTime
+			template.RevocationReason = int(c.RevocationReason)
+		}
+		signed, err := svc.SignOCSP(context.Background(), issuer.SerialNumber, template, nonce)
+		require.NoError(t, err)
+		res, err := ocsp.ParseResponseForCert(signed.DER, leaf, issuer.Certificate)
+		require.NoError(t, err)
+		return signed, res
+	}
+
+	// ECDSA signatures are randomized, so equal responses come from the cache.
+	first, res := sign(nil)
+	assert.Equal(t, ocsp.Good, res.Status)
+	cached, _ := sign(nil)
+	assert.Equal(t, first.DER, cached.DER)
+
+	nonce := []byte("0123456789abcdef")
+	withNonce, res := sign(nonce)
+	assert.NotEqual(t, first.DER, withNonce.DER)
+	assert.Equal(t, ocsp.Good, res.Status)
+	value, err := asn1.Marshal(nonce)
+	require.NoError(t, err)
+	ext, err := asn1.Marshal(pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}, Value: value})
+	require.NoError(t, err)
+	assert.True(t, bytes.Contains(withNonce.DER, ext), "response must echo the request nonce")
+	assert.False(t, bytes.Contains(first.DER, ext))
+
+	// Revocation drops the cached response of the certificate.
+	err = svc.RevokeCert(context.Background(), cert.SerialNumber, certs.RevocationKeyCompromise, time.Time{})
+	require.NoError(t, err)
+	revoked, res := sign(nil)
+	assert.NotEqual(t, first.DER, revoked.DER)
+	assert.Equal(t, ocsp.Revoked, res.Status)
+	assert.Equal(t, ocsp.KeyCompromise, res.RevocationReason)
+	cached, _ = sign(nil)
+	assert.Equal(t, revoked.DER, cached.DER)
+}
+
 func TestGetCertDownloadToken(t *testing.T) {
 	cRepo := new(mocks.MockRepository)
 
 	repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
 	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
-	svc, err := certs.NewService(context.Background(), cRepo, &config)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
 	require.NoError(t, err)
 	repoCall.Unset()
 	repoCall1.Unset()
@@ -158,26 +677,50 @@ func TestGetCertDownloadToken(t *testing.T) {
 func TestGetCert(t *testing.T) {
 	cRepo := new(mocks.MockRepository)
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute * 5).Unix(), Issuer: certs.Organization, Subject: "certs"})
-	validToken, err := jwtToken.SignedString([]byte(serialNumber))
-	require.NoError(t, err)
-
 	repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
 	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
-	svc, err := certs.NewService(context.Background(), cRepo, &config)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
+	require.NoError(t, err)
+	other, err := certs.NewService(context.Background(), cRepo, nil, &config)
 	require.NoError(t, err)
 	repoCall.Unset()
 	repoCall1.Unset()
 
+	var mu sync.Mutex
+	redeemed := map[string]bool{}
+	cRepo.On("RedeemToken", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, id string, _ time.Time) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if redeemed[id] {
+			return certs.ErrConflict
+		}
+		redeemed[id] = true
+		return nil
+	})
+
+	newToken := func(svc certs.Service, serial string) string {
+		token, err := svc.RetrieveCertDownloadToken(context.Background(), serial)
+		require.NoError(t, err)
+		return token
+	}
+	replayed := newToken(svc, serialNumber)
+	repoCall = cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(certs.Certificate{}, nil)
+	_, _, err = svc.RetrieveCert(context.Background(), replayed, serialNumber)
+	require.NoError(t, err)
+	repoCall.Unset()
+	caToken, err := svc.RetrieveCAToken(context.Background(), "")
+	require.NoError(t, err)
+
 	testCases := []struct {
-		desc   string
-		token  string
-		serial string
-		err    error
+		desc    string
+		token   string
+		serial  string
+		repoErr error
+		err     error
 	}{
 		{
 			desc:   "successful get cert",
-			token:  validToken,
+			token:  newToken(svc, serialNumber),
 			serial: serialNumber,
 			err:    nil,
 		},
@@ -185,25 +728,88 @@ func TestGetCert(t *testing.T) {
 			desc:   "failed token validation",
 			token:  invalidToken,
 			serial: serialNumber,
-			err:    certs.ErrMalformedEntity,
+			err:    certs.ErrInvalidToken,
+		},
+		{
+			desc:   "failed with a token for another certificate",
+			token:  newToken(svc, "1"),
+			serial: serialNumber,
+			err:    certs.ErrInvalidToken,
+		},
+		{
+			desc:   "failed with a token for the CA chain",
+			token:  caToken,
+			serial: serialNumber,
+			err:    certs.ErrInvalidToken,
+		},
+		{
+			desc:   "failed with a token signed by another service",
+			token:  newToken(other, serialNumber),
+			serial: serialNumber,
+			err:    certs.ErrInvalidToken,
 		},
 		{
-			desc:   "failed repo get cert",
-			token:  validToken,
+			desc:   "failed with a replayed token",
+			token:  replayed,
 			serial: serialNumber,
-			err:    certs.ErrViewEntity,
+			err:    certs.ErrTokenRedeemed,
+		},
+		{
+			desc:    "failed repo get cert",
+			token:   newToken(svc, serialNumber),
+			serial:  serialNumber,
+			repoErr: certs.ErrViewEntity,
+			err:     certs.ErrViewEntity,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
-			repoCall1 := cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(certs.Certificate{}, tc.err)
+			repoCall1 := cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(certs.Certificate{}, tc.repoErr)
 			defer repoCall1.Unset()
 
 			_, _, err = svc.RetrieveCert(context.Background(), tc.token, tc.serial)
 			require.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
 		})
 	}
+
+	t.Run("retry after failed retrieval", func(t *testing.T) {
+		token := newToken(svc, serialNumber)
+		repoCall := cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(certs.Certificate{}, certs.ErrViewEntity).Once()
+		_, _, err := svc.RetrieveCert(context.Background(), token, serialNumber)
+		require.True(t, errors.Contains(err, certs.ErrViewEntity), "expected error %v, got %v", certs.ErrViewEntity, err)
+		repoCall.Unset()
+
+		repoCall = cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(certs.Certificate{}, nil)
+		defer repoCall.Unset()
+		_, _, err = svc.RetrieveCert(context.Background(), token, serialNumber)
+		require.NoError(t, err)
+		_, _, err = svc.RetrieveCert(context.Background(), token, serialNumber)
+		require.True(t, errors.Contains(err, certs.ErrTokenRedeemed), "expected error %v, got %v", certs.ErrTokenRedeemed, err)
+	})
+}
+
+func TestDownloadTokenSettings(t *testing.T) {
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
+	cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(certs.Certificate{}, nil)
+	cRepo.On("RedeemToken", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	cfg := config
+	cfg.DownloadTokens = certs.TokenSettings{Key: key}
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+	require.NoError(t, err)
+	token, err := svc.RetrieveCertDownloadToken(context.Background(), serialNumber)
+	require.NoError(t, err)
+	_, _, err = svc.RetrieveCert(context.Background(), token, serialNumber)
+	assert.NoError(t, err)
+
+	cfg.DownloadTokens = certs.TokenSettings{Secret: []byte("short")}
+	_, err = certs.NewService(context.Background(), cRepo, nil, &cfg)
+	assert.True(t, errors.Contains(err, certs.ErrInvalidConfig), "expected error %v, got %v", certs.ErrInvalidConfig, err)
 }
 
 func TestRenewCert(t *testing.T) {
@@ -259,7 +865,7 @@ func TestRenewCert(t *testing.T) {
 
 	repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
 	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
-	svc, err := certs.NewService(context.Background(), cRepo, &config)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
 	require.NoError(t, err)
 	repoCall.Unset()
 	repoCall1.Unset()
@@ -344,6 +950,49 @@ func TestRenewCert(t *testing.T) {
 			require.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
 		})
 	}
+
+	// Renewals keep the lifetime of the certificate and are validated
+	// against the profile it was issued with.
+	cRepo.On("RetrieveProfile", mock.Anything, "short").Return(certs.Profile{Name: "short", TTL: "1h", MaxTTL: "2h"}, nil)
+	cRepo.On("RetrieveProfile", mock.Anything, mock.Anything).Return(certs.Profile{}, certs.ErrNotFound)
+	cert := certs.Certificate{
+		SerialNumber: serialNumber.String(),
+		Certificate:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: validCert}),
+		EntityID:     "backendId",
+		ExpiryTime:   time.Now().Add(time.Hour),
+	}
+	var renewed certs.Certificate
+	repoCall = cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(context.Context, string) (certs.Certificate, error) {
+		return cert, nil
+	})
+	defer repoCall.Unset()
+	repoCall1 = cRepo.On("UpdateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		renewed = args.Get(1).(certs.Certificate)
+	}).Return(nil)
+	defer repoCall1.Unset()
+
+	require.NoError(t, svc.RenewCert(context.Background(), serialNumber.String()))
+	assert.WithinDuration(t, time.Now().Add(25*time.Hour), renewed.ExpiryTime, time.Minute)
+
+	cert.Profile = "short"
+	err = svc.RenewCert(context.Background(), serialNumber.String())
+	assert.True(t, errors.Contains(err, certs.ErrProfileViolation), "expected error %v, got %v", certs.ErrProfileViolation, err)
+
+	cert.Profile = "removed"
+	err = svc.RenewCert(context.Background(), serialNumber.String())
+	assert.True(t, errors.Contains(err, certs.ErrProfileNotFound), "expected error %v, got %v", certs.ErrProfileNotFound, err)
+
+	cert.Profile = ""
+	cfg := config
+	cfg.Policy.EntityMaxTTL = map[string]time.Duration{"backendId": time.Hour}
+	repoCall2 := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	repoCall3 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
+	limited, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+	require.NoError(t, err)
+	repoCall2.Unset()
+	repoCall3.Unset()
+	err = limited.RenewCert(context.Background(), serialNumber.String())
+	assert.True(t, errors.Contains(err, certs.ErrPolicyViolation), "expected error %v, got %v", certs.ErrPolicyViolation, err)
 }
 
 func TestGetEntityID(t *testing.T) {
@@ -351,7 +1000,7 @@ func TestGetEntityID(t *testing.T) {
 
 	repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
 	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
-	svc, err := certs.NewService(context.Background(), cRepo, &config)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
 	require.NoError(t, err)
 	repoCall.Unset()
 	repoCall1.Unset()
@@ -382,7 +1031,7 @@ func TestListCerts(t *testing.T) {
 
 	repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
 	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
-	svc, err := certs.NewService(context.Background(), cRepo, &config)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
 	require.NoError(t, err)
 	repoCall.Unset()
 	repoCall1.Unset()
@@ -437,7 +1086,7 @@ func TestGenerateCRL(t *testing.T) {
 		{Type: certs.IntermediateCA, Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), Key: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})},
 	}, nil)
 	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
-	svc, err := certs.NewService(context.Background(), cRepo, &config)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
 	require.NoError(t, err)
 	repoCall.Unset()
 	repoCall1.Unset()
@@ -449,12 +1098,20 @@ func TestGenerateCRL(t *testing.T) {
 		repoErr error
 		err     error
 	}{
+		// Published CRLs are served until they are due, so the repository
+		// error has to come before the root CRL is published.
+		{
+			desc:    "ListRevokedCerts error",
+			caType:  certs.RootCA,
+			repoErr: certs.ErrViewEntity,
+			err:     certs.ErrViewEntity,
+		},
 		{
 			desc:   "generate CRL with root CA",
 			caType: certs.RootCA,
 			certs: []certs.Certificate{
-				{SerialNumber: "1", ExpiryTime: time.Now(), EntityID: "123"},
-				{SerialNumber: "2", ExpiryTime: time.Now(), EntityID: "456"},
+				{SerialNumber: "1", RevocationTime: time.Now(), EntityID: "123"},
+				{SerialNumber: "2", RevocationTime: time.Now(), EntityID: "456"},
 			},
 			err: nil,
 		},
@@ -462,7 +1119,7 @@ func TestGenerateCRL(t *testing.T) {
 			desc:   "generate CRL with intermediate CA",
 			caType: certs.IntermediateCA,
 			certs: []certs.Certificate{
-				{SerialNumber: "3", ExpiryTime: time.Now()},
+				{SerialNumber: "3", RevocationTime: time.Now()},
 			},
 			err: nil,
 		},
@@ -471,25 +1128,1845 @@ func TestGenerateCRL(t *testing.T) {
 			caType: certs.CertType(999),
 			err:    errors.New("invalid CA type"),
 		},
-		{
-			desc:    "ListRevokedCerts error",
-			caType:  certs.RootCA,
-			repoErr: certs.ErrViewEntity,
-			err:     certs.ErrViewEntity,
-		},
 	}
 
+	// The default issuer also lists certificates without an issuer serial.
+	var revoked []certs.Certificate
+	var revokedErr error
+	listRevoked := func(context.Context, ...string) ([]certs.Certificate, error) { return revoked, revokedErr }
+	cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything).Return(listRevoked)
+	cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything, mock.Anything).Return(listRevoked)
+	cRepo.On("NextCRLNumber", mock.Anything, mock.Anything).Return(uint64(1), nil)
+
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
-			repoCall := cRepo.On("ListRevokedCerts", mock.Anything).Return(tc.certs, tc.repoErr)
-			_, err := svc.GenerateCRL(context.Background(), tc.caType)
+			revoked, revokedErr = tc.certs, tc.repoErr
+			_, err := svc.GenerateCRL(context.Background(), tc.caType, "")
 			if tc.err != nil {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tc.err.Error())
 			} else {
 				assert.NoError(t, err)
 			}
+		})
+	}
+}
+
+func TestCAKeyAlgorithms(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		algorithm string
+		size      int
+		pubKey    any
+		err       error
+	}{
+		{
+			desc:      "default RSA CA",
+			algorithm: "",
+			pubKey:    &rsa.PublicKey{},
+		},
+		{
+			desc:      "ECDSA P-256 CA",
+			algorithm: certs.KeyAlgorithmECDSA,
+			size:      256,
+			pubKey:    &ecdsa.PublicKey{},
+		},
+		{
+			desc:      "ECDSA P-384 CA",
+			algorithm: certs.KeyAlgorithmECDSA,
+			size:      384,
+			pubKey:    &ecdsa.PublicKey{},
+		},
+		{
+			desc:      "Ed25519 CA",
+			algorithm: certs.KeyAlgorithmEd25519,
+			pubKey:    ed25519.PublicKey{},
+		},
+		{
+			desc:      "invalid ECDSA key size",
+			algorithm: certs.KeyAlgorithmECDSA,
+			size:      128,
+			err:       certs.ErrKeySize,
+		},
+		{
+			desc:      "weak RSA key size",
+			algorithm: certs.KeyAlgorithmRSA,
+			size:      1024,
+			err:       certs.ErrKeySize,
+		},
+		{
+			desc:      "unknown key algorithm",
+			algorithm: "dsa",
+			err:       certs.ErrKeyAlgorithm,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := certs.Config{CommonName: "test", KeyAlgorithm: tc.algorithm, KeySize: tc.size}
+
+			var saved []certs.Certificate
+			cRepo := new(mocks.MockRepository)
+			repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+			repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				saved = append(saved, args.Get(1).(certs.Certificate))
+			}).Return(nil)
+			_, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
 			repoCall.Unset()
+			repoCall1.Unset()
+			if tc.err != nil {
+				require.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, saved, 2)
+
+			for _, ca := range saved {
+				block, _ := pem.Decode(ca.Certificate)
+				require.NotNil(t, block)
+				cert, err := x509.ParseCertificate(block.Bytes)
+				require.NoError(t, err)
+				assert.IsType(t, tc.pubKey, cert.PublicKey)
+				key, err := certs.ParsePrivateKey(ca.Key)
+				require.NoError(t, err)
+				assert.IsType(t, tc.pubKey, key.Public())
+			}
+
+			// Reload the persisted CAs and make sure they can still sign.
+			cRepo = new(mocks.MockRepository)
+			cRepo.On("GetCAs", mock.Anything).Return(saved, nil)
+			cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
+			cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything).Return([]certs.Certificate{{SerialNumber: "1", RevocationTime: time.Now()}}, nil)
+			cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything, mock.Anything).Return([]certs.Certificate{{SerialNumber: "1", RevocationTime: time.Now()}}, nil)
+			cRepo.On("NextCRLNumber", mock.Anything, mock.Anything).Return(uint64(1), nil)
+			svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+			require.NoError(t, err)
+
+			cert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+			require.NoError(t, err)
+			_, err = svc.GenerateCRL(context.Background(), certs.IntermediateCA, "")
+			require.NoError(t, err)
+
+			// OCSP responses are signed by the issuing CA or a delegated
+			// responder with a key of the same algorithm.
+			cRepo.On("RetrieveCert", mock.Anything, cert.SerialNumber).Return(cert, nil)
+			leaf := parsePEMCert(t, cert.Certificate)
+			for _, delegated := range []bool{false, true} {
+				cfg := cfg
+				cfg.OCSP.DelegatedResponder = delegated
+				svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+				require.NoError(t, err)
+				_, status, issuer, err := svc.OCSP(context.Background(), cert.SerialNumber)
+				require.NoError(t, err)
+				template := ocsp.Response{Status: status, SerialNumber: leaf.SerialNumber, IssuerHash: crypto.SHA1}
+				for _, nonce := range [][]byte{nil, []byte("0123456789abcdef")} {
+					signed, err := svc.SignOCSP(context.Background(), issuer.SerialNumber, template, nonce)
+					require.NoError(t, err)
+					res, responder := verifyOCSP(t, signed.DER, issuer.Certificate)
+					assert.Equal(t, ocsp.Good, res.Status)
+					assert.Equal(t, leaf.SerialNumber, res.SerialNumber)
+					assert.IsType(t, tc.pubKey, responder.PublicKey)
+					assert.Equal(t, delegated, responder != issuer.Certificate)
+				}
+			}
 		})
 	}
 }
+
+func TestExternalKeyStore(t *testing.T) {
+	keyStore, err := file.New(file.Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+
+	var saved []certs.Certificate
+	cRepo := new(mocks.MockRepository)
+	repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).(certs.Certificate))
+	}).Return(nil)
+	_, err = certs.NewService(context.Background(), cRepo, keyStore, &config)
+	require.NoError(t, err)
+	repoCall.Unset()
+	repoCall1.Unset()
+
+	require.Len(t, saved, 2)
+	for _, ca := range saved {
+		assert.Empty(t, ca.Key, "CA private key must not be persisted in the repository")
+		assert.NotEmpty(t, ca.KeyRef)
+	}
+
+	testCases := []struct {
+		desc     string
+		keyStore certs.KeyStore
+		err      error
+	}{
+		{
+			desc:     "load CA keys from key store",
+			keyStore: keyStore,
+			err:      nil,
+		},
+		{
+			desc:     "load CA keys without key store",
+			keyStore: nil,
+			err:      certs.ErrKeyStoreNotConfigured,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cRepo := new(mocks.MockRepository)
+			cRepo.On("GetCAs", mock.Anything).Return(saved, nil)
+			cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
+			cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(certs.Certificate{}, nil)
+			cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything).Return([]certs.Certificate{}, nil)
+			cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything, mock.Anything).Return([]certs.Certificate{}, nil)
+			cRepo.On("NextCRLNumber", mock.Anything, mock.Anything).Return(uint64(1), nil)
+
+			svc, err := certs.NewService(context.Background(), cRepo, tc.keyStore, &config)
+			require.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
+			if tc.err != nil {
+				return
+			}
+
+			_, err = svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+			require.NoError(t, err)
+			_, err = svc.GenerateCRL(context.Background(), certs.IntermediateCA, "")
+			require.NoError(t, err)
+
+			_, _, issuer, err := svc.OCSP(context.Background(), "1")
+			require.NoError(t, err)
+			assert.Equal(t, saved[1].SerialNumber, issuer.SerialNumber)
+			assert.NotNil(t, issuer.Signer)
+		})
+	}
+}
+
+func TestEnvelopeEncryption(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	enc, err := envelope.NewWithKeys(map[int][]byte{1: oldKey}, 0)
+	require.NoError(t, err)
+
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	repoCall := cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	repoCall1 := cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	svc, err := certs.NewService(context.Background(), envelope.NewRepository(cRepo, enc), nil, &config)
+	require.NoError(t, err)
+
+	cert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+	require.NoError(t, err)
+	repoCall.Unset()
+	repoCall1.Unset()
+
+	require.Len(t, stored, 3)
+	for _, c := range stored {
+		assert.True(t, envelope.IsEncrypted(c.Key), "private key must be encrypted at rest")
+		assert.Equal(t, 1, c.KeyVersion)
+	}
+
+	leaf := stored[cert.SerialNumber]
+	leafKey, err := enc.Decrypt(leaf.Key, []byte(leaf.SerialNumber))
+	require.NoError(t, err)
+	_, err = certs.ParsePrivateKey(leafKey)
+	require.NoError(t, err)
+
+	_, err = enc.Decrypt(leaf.Key, []byte("other serial"))
+	assert.True(t, errors.Contains(err, envelope.ErrDecrypt), "expected error %v, got %v", envelope.ErrDecrypt, err)
+
+	var cas []certs.Certificate
+	for _, c := range stored {
+		if c.Type != certs.ClientCert {
+			cas = append(cas, c)
+		}
+	}
+	cRepo = new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return(cas, nil)
+	_, err = certs.NewService(context.Background(), envelope.NewRepository(cRepo, enc), nil, &config)
+	require.NoError(t, err)
+
+	rotated, err := envelope.NewWithKeys(map[int][]byte{1: oldKey, 2: newKey}, 0)
+	require.NoError(t, err)
+	plaintext := certs.Certificate{SerialNumber: "plain", Key: leafKey}
+
+	var updated []certs.Certificate
+	cRepo = new(mocks.MockRepository)
+	cRepo.On("ListStaleKeys", mock.Anything, 2, mock.Anything).Return([]certs.Certificate{leaf, plaintext}, nil).Once()
+	cRepo.On("ListStaleKeys", mock.Anything, 2, mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("UpdateKey", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		updated = append(updated, args.Get(1).(certs.Certificate))
+	}).Return(nil)
+
+	count, err := envelope.Reencrypt(context.Background(), cRepo, rotated)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+	require.Len(t, updated, 2)
+	for _, c := range updated {
+		assert.Equal(t, 2, c.KeyVersion)
+		key, err := rotated.Decrypt(c.Key, []byte(c.SerialNumber))
+		require.NoError(t, err)
+		assert.Equal(t, leafKey, key)
+	}
+
+	newOnly, err := envelope.NewWithKeys(map[int][]byte{2: newKey}, 0)
+	require.NoError(t, err)
+	_, err = newOnly.Decrypt(leaf.Key, []byte(leaf.SerialNumber))
+	assert.Equal(t, envelope.ErrUnknownKeyVersion, err)
+}
+
+func TestCADistribution(t *testing.T) {
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) certs.Certificate {
+		return stored[sn]
+	}, nil)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
+	require.NoError(t, err)
+
+	var root, intermediate certs.Certificate
+	for _, c := range stored {
+		switch c.Type {
+		case certs.RootCA:
+			root = c
+		case certs.IntermediateCA:
+			intermediate = c
+		}
+	}
+
+	chain, err := svc.ViewCAChain(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, chain.Key)
+	assert.True(t, bytes.HasPrefix(chain.Certificate, intermediate.Certificate), "chain must start with the issuer certificate")
+	assert.Contains(t, string(chain.Certificate), string(root.Certificate))
+	assert.NotContains(t, string(chain.Certificate), "PRIVATE KEY")
+
+	bundle, err := svc.TrustBundle(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, root.Certificate, bundle)
+
+	_, err = svc.ViewCAChain(context.Background(), "unknown")
+	assert.True(t, errors.Contains(err, certs.ErrIssuerNotFound), "expected error %v, got %v", certs.ErrIssuerNotFound, err)
+
+	passphrase := "correct horse battery"
+	testCases := []struct {
+		desc       string
+		serial     string
+		passphrase string
+		external   bool
+		err        error
+	}{
+		{
+			desc:       "export intermediate CA",
+			serial:     intermediate.SerialNumber,
+			passphrase: passphrase,
+		},
+		{
+			desc:       "export root CA",
+			serial:     root.SerialNumber,
+			passphrase: passphrase,
+		},
+		{
+			desc:       "export CA with weak passphrase",
+			serial:     intermediate.SerialNumber,
+			passphrase: "short",
+			err:        certs.ErrWeakPassphrase,
+		},
+		{
+			desc:       "export unknown CA",
+			serial:     serialNumber,
+			passphrase: passphrase,
+			err:        certs.ErrCANotFound,
+		},
+		{
+			desc:       "export CA with key in external key store",
+			serial:     intermediate.SerialNumber,
+			passphrase: passphrase,
+			external:   true,
+			err:        certs.ErrCAKeyNotExportable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if tc.external {
+				stored[tc.serial] = certs.Certificate{SerialNumber: tc.serial, Certificate: stored[tc.serial].Certificate, KeyRef: "ref"}
+				defer func() { stored[tc.serial] = intermediate }()
+			}
+			backup, err := svc.ExportCA(context.Background(), tc.serial, tc.passphrase)
+			require.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
+			if tc.err != nil {
+				return
+			}
+			assert.NotContains(t, string(backup), "PRIVATE KEY")
+
+			sn, certsPEM, keyPEM, err := certs.DecryptCABackup(backup, tc.passphrase)
+			require.NoError(t, err)
+			assert.Equal(t, tc.serial, sn)
+			assert.True(t, bytes.HasPrefix(certsPEM, stored[tc.serial].Certificate), "backup must start with the CA certificate")
+			assert.Contains(t, string(certsPEM), string(root.Certificate))
+			key, err := certs.ParsePrivateKey(keyPEM)
+			require.NoError(t, err)
+			pub := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+			assert.True(t, pub.Equal(parsePEMCert(t, stored[tc.serial].Certificate).PublicKey), "backup key must match the CA certificate")
+
+			_, _, _, err = certs.DecryptCABackup(backup, "wrong passphrase")
+			assert.True(t, errors.Contains(err, certs.ErrInvalidBackup), "expected error %v, got %v", certs.ErrInvalidBackup, err)
+
+			// The serial number is authenticated, so it cannot be swapped.
+			block, _ := pem.Decode(backup)
+			block.Headers["Serial-Number"] = serialNumber
+			_, _, _, err = certs.DecryptCABackup(pem.EncodeToMemory(block), tc.passphrase)
+			assert.True(t, errors.Contains(err, certs.ErrInvalidBackup), "expected error %v, got %v", certs.ErrInvalidBackup, err)
+		})
+	}
+}
+
+func TestImportCA(t *testing.T) {
+	extRoot, extRootKey := newTestCA(t, "external root", nil, nil)
+	otherRoot, otherRootKey := newTestCA(t, "other root", nil, nil)
+	extInter, extInterKey := newTestCA(t, "external intermediate", extRoot, extRootKey)
+	foreignInter, foreignInterKey := newTestCA(t, "foreign intermediate", otherRoot, otherRootKey)
+	_, strayKey := newTestCA(t, "stray", nil, nil)
+
+	stored := map[string]certs.Certificate{}
+	newSvc := func(t *testing.T) certs.Service {
+		cRepo := new(mocks.MockRepository)
+		cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil).Once()
+		cRepo.On("GetCAs", mock.Anything, mock.Anything).Return(func(_ context.Context, types ...certs.CertType) []certs.Certificate {
+			var cas []certs.Certificate
+			for _, c := range stored {
+				for _, ct := range types {
+					if c.Type == ct {
+						cas = append(cas, c)
+					}
+				}
+			}
+			return cas
+		}, nil)
+		cRepo.On("GetCAs", mock.Anything, mock.Anything, mock.Anything).Return([]certs.Certificate{}, nil)
+		cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			c := args.Get(1).(certs.Certificate)
+			stored[c.SerialNumber] = c
+		}).Return(nil)
+		cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) certs.Certificate {
+			return stored[sn]
+		}, nil)
+		cRepo.On("UpdateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			c := args.Get(1).(certs.Certificate)
+			stored[c.SerialNumber] = c
+		}).Return(nil)
+		cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything).Return([]certs.Certificate{}, nil)
+		cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything, mock.Anything).Return([]certs.Certificate{}, nil)
+		cRepo.On("NextCRLNumber", mock.Anything, mock.Anything).Return(uint64(1), nil)
+		cRepo.On("RemoveCertBySerial", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			delete(stored, args.String(1))
+		}).Return(nil)
+
+		svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
+		require.NoError(t, err)
+		return svc
+	}
+
+	cases := []struct {
+		desc string
+		ca   certs.CAImport
+		err  error
+	}{
+		{
+			desc: "import root CA with key",
+			ca:   certs.CAImport{Type: certs.RootCA, Certificate: pemCert(extRoot), Key: pemKey(t, extRootKey)},
+		},
+		{
+			desc: "import intermediate CA signed by active root",
+			ca:   certs.CAImport{Type: certs.IntermediateCA, Certificate: pemCert(extInter), Key: pemKey(t, extInterKey)},
+		},
+		{
+			desc: "import intermediate CA signed by another root",
+			ca:   certs.CAImport{Type: certs.IntermediateCA, Certificate: pemCert(foreignInter), Key: pemKey(t, foreignInterKey)},
+			err:  certs.ErrMalformedEntity,
+		},
+		{
+			desc: "import CA with mismatching key",
+			ca:   certs.CAImport{Type: certs.RootCA, Certificate: pemCert(otherRoot), Key: pemKey(t, strayKey)},
+			err:  certs.ErrKeyMismatch,
+		},
+		{
+			desc: "import intermediate CA without key",
+			ca:   certs.CAImport{Type: certs.IntermediateCA, Certificate: pemCert(extInter)},
+			err:  certs.ErrCAKeyUnavailable,
+		},
+	}
+
+	svc := newSvc(t)
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ca, err := svc.ImportCA(context.Background(), tc.ca)
+			assert.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
+			if tc.err != nil {
+				return
+			}
+			assert.Equal(t, tc.ca.Type, ca.Type)
+
+			cert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+			require.NoError(t, err)
+			leaf := parsePEMCert(t, stored[cert.SerialNumber].Certificate)
+			chain := x509.NewCertPool()
+			chain.AddCert(extRoot)
+			_, err = leaf.Verify(x509.VerifyOptions{Roots: chain, Intermediates: intermediates(t, svc, cert.SerialNumber), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("import keeps the replaced CAs valid", func(t *testing.T) {
+		svc := newSvc(t)
+		before, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+		require.NoError(t, err)
+		oldBundle, err := svc.TrustBundle(context.Background())
+		require.NoError(t, err)
+		oldIssuer := stored[before.SerialNumber].IssuerSerial
+
+		_, err = svc.ImportCA(context.Background(), certs.CAImport{Type: certs.RootCA, Certificate: pemCert(extRoot), Key: pemKey(t, extRootKey)})
+		require.NoError(t, err)
+		assert.False(t, stored[oldIssuer].Revoked, "replaced issuer must not be revoked")
+		assert.True(t, stored[oldIssuer].Retired, "replaced issuer must be retired")
+		bundle, err := svc.TrustBundle(context.Background())
+		require.NoError(t, err)
+		assert.True(t, bytes.Contains(bundle, oldBundle), "trust bundle must keep the replaced root")
+		assert.True(t, bytes.Contains(bundle, pemCert(extRoot)), "trust bundle must contain the imported root")
+
+		// Certificates issued before the import still verify, and new ones
+		// are issued under the imported root.
+		oldRoots := x509.NewCertPool()
+		require.True(t, oldRoots.AppendCertsFromPEM(oldBundle))
+		leaf := parsePEMCert(t, stored[before.SerialNumber].Certificate)
+		_, err = leaf.Verify(x509.VerifyOptions{Roots: oldRoots, Intermediates: intermediates(t, svc, before.SerialNumber), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+		assert.NoError(t, err)
+		_, status, _, err := svc.OCSP(context.Background(), before.SerialNumber)
+		require.NoError(t, err)
+		assert.Equal(t, ocsp.Good, status)
+
+		after, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+		require.NoError(t, err)
+		assert.NotEqual(t, oldIssuer, stored[after.SerialNumber].IssuerSerial)
+		leaf = parsePEMCert(t, stored[after.SerialNumber].Certificate)
+		newRoots := x509.NewCertPool()
+		newRoots.AddCert(extRoot)
+		_, err = leaf.Verify(x509.VerifyOptions{Roots: newRoots, Intermediates: intermediates(t, svc, after.SerialNumber), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("offline root with signed intermediate CSR", func(t *testing.T) {
+		svc := newSvc(t)
+		_, err := svc.ImportCA(context.Background(), certs.CAImport{Type: certs.RootCA, Certificate: pemCert(extRoot)})
+		require.NoError(t, err)
+
+		_, err = svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+		assert.True(t, errors.Contains(err, certs.ErrIntermediateCANotFound), "expected error %v, got %v", certs.ErrIntermediateCANotFound, err)
+		_, err = svc.GenerateCRL(context.Background(), certs.RootCA, "")
+		assert.True(t, errors.Contains(err, certs.ErrCAKeyUnavailable), "expected error %v, got %v", certs.ErrCAKeyUnavailable, err)
+
+		csr, err := svc.GenerateIntermediateCSR(context.Background())
+		require.NoError(t, err)
+		block, _ := pem.Decode(csr.CSR)
+		require.NotNil(t, block)
+		req, err := x509.ParseCertificateRequest(block.Bytes)
+		require.NoError(t, err)
+		require.NoError(t, req.CheckSignature())
+
+		_, err = svc.InstallIntermediateCA(context.Background(), pemCert(foreignInter))
+		assert.True(t, errors.Contains(err, certs.ErrMalformedEntity), "expected error %v, got %v", certs.ErrMalformedEntity, err)
+
+		_, err = svc.InstallIntermediateCA(context.Background(), pemCert(extInter))
+		assert.True(t, errors.Contains(err, certs.ErrPendingCSRNotFound), "expected error %v, got %v", certs.ErrPendingCSRNotFound, err)
+
+		signed := signCSR(t, req, extRoot, extRootKey)
+		ca, err := svc.InstallIntermediateCA(context.Background(), pemCert(signed))
+		require.NoError(t, err)
+		assert.Equal(t, signed.SerialNumber.String(), ca.SerialNumber)
+		for _, c := range stored {
+			assert.NotEqual(t, certs.PendingIntermediateCA, c.Type, "pending CSR must be removed after install")
+		}
+
+		cert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+		require.NoError(t, err)
+		leaf := parsePEMCert(t, stored[cert.SerialNumber].Certificate)
+		assert.NoError(t, leaf.CheckSignatureFrom(signed))
+
+		_, _, issuer, err := svc.OCSP(context.Background(), cert.SerialNumber)
+		require.NoError(t, err)
+		assert.Equal(t, ca.SerialNumber, issuer.SerialNumber)
+		_, err = svc.GenerateCRL(context.Background(), certs.IntermediateCA, "")
+		assert.NoError(t, err)
+	})
+}
+
+func TestIssuers(t *testing.T) {
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) certs.Certificate {
+		return stored[sn]
+	}, nil)
+	cRepo.On("UpdateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &config)
+	require.NoError(t, err)
+
+	issuer, err := svc.CreateIssuer(context.Background(), "tenant-a")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-a", stored[issuer.SerialNumber].IssuerName)
+	_, err = svc.CreateIssuer(context.Background(), "tenant-a")
+	assert.True(t, errors.Contains(err, certs.ErrConflict), "expected error %v, got %v", certs.ErrConflict, err)
+
+	issuers, err := svc.ListIssuers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, issuers, 2)
+	assert.Equal(t, certs.DefaultIssuer, issuers[0].Name)
+	assert.Equal(t, "tenant-a", issuers[1].Name)
+
+	_, err = svc.IssueCert(context.Background(), "entityID", "unknown", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+	assert.True(t, errors.Contains(err, certs.ErrIssuerNotFound), "expected error %v, got %v", certs.ErrIssuerNotFound, err)
+
+	cert, err := svc.IssueCert(context.Background(), "entityID", "tenant-a", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+	require.NoError(t, err)
+	assert.Equal(t, issuer.SerialNumber, stored[cert.SerialNumber].IssuerSerial)
+	leaf := parsePEMCert(t, stored[cert.SerialNumber].Certificate)
+	assert.NoError(t, leaf.CheckSignatureFrom(parsePEMCert(t, issuer.Certificate)))
+
+	defaultCert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+	require.NoError(t, err)
+	assert.Equal(t, issuers[0].SerialNumber, stored[defaultCert.SerialNumber].IssuerSerial)
+
+	_, _, ca, err := svc.OCSP(context.Background(), cert.SerialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, issuer.SerialNumber, ca.SerialNumber)
+
+	cRepo.On("RedeemToken", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	token, err := svc.RetrieveCAToken(context.Background(), "tenant-a")
+	require.NoError(t, err)
+	chain, err := svc.GetChainCA(context.Background(), token)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(chain.Certificate, issuer.Certificate), "chain must start with the issuer certificate")
+
+	repoCall := cRepo.On("ListRevokedCerts", mock.Anything, issuer.SerialNumber).Return([]certs.Certificate{{SerialNumber: "1", RevocationTime: time.Now()}}, nil)
+	cRepo.On("NextCRLNumber", mock.Anything, issuer.SerialNumber).Return(uint64(1), nil)
+	_, err = svc.GenerateCRL(context.Background(), certs.IntermediateCA, "tenant-a")
+	assert.NoError(t, err)
+
+	require.NoError(t, svc.RetireIssuer(context.Background(), "tenant-a"))
+	assert.True(t, stored[issuer.SerialNumber].Retired)
+	_, err = svc.IssueCert(context.Background(), "entityID", "tenant-a", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+	assert.True(t, errors.Contains(err, certs.ErrIssuerNotFound), "expected error %v, got %v", certs.ErrIssuerNotFound, err)
+	err = svc.RenewCert(context.Background(), cert.SerialNumber)
+	assert.True(t, errors.Contains(err, certs.ErrIssuerRetired), "expected error %v, got %v", certs.ErrIssuerRetired, err)
+
+	// A retired issuer keeps signing revocation information for its certificates.
+	_, err = svc.GenerateCRL(context.Background(), certs.IntermediateCA, "tenant-a")
+	assert.NoError(t, err)
+	_, _, ca, err = svc.OCSP(context.Background(), cert.SerialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, issuer.SerialNumber, ca.SerialNumber)
+	repoCall.Unset()
+
+	issuers, err = svc.ListIssuers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, issuers, 2)
+	assert.True(t, issuers[1].Retired)
+}
+
+func TestRotateCA(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		rootExpiry time.Duration
+		validCerts uint64
+		rotated    bool
+		chainLen   int
+	}{
+		{
+			desc:       "stage next root ahead of rotation",
+			rootExpiry: 45 * 24 * time.Hour,
+			chainLen:   4,
+		},
+		{
+			desc:       "rotate root with valid certificates under the old root",
+			rootExpiry: 20 * 24 * time.Hour,
+			validCerts: 1,
+			rotated:    true,
+			chainLen:   4,
+		},
+		{
+			desc:       "rotate root without valid certificates under the old root",
+			rootExpiry: 20 * 24 * time.Hour,
+			rotated:    true,
+			chainLen:   3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			require.NoError(t, err)
+			template := &x509.Certificate{
+				SerialNumber:          big.NewInt(time.Now().UnixNano()),
+				Subject:               pkix.Name{CommonName: "old root"},
+				NotBefore:             time.Now().Add(-time.Hour),
+				NotAfter:              time.Now().Add(tc.rootExpiry),
+				KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+				BasicConstraintsValid: true,
+				IsCA:                  true,
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, rootKey.Public(), rootKey)
+			require.NoError(t, err)
+			oldRoot, err := x509.ParseCertificate(der)
+			require.NoError(t, err)
+			oldInter, oldInterKey := newTestCA(t, "old intermediate", oldRoot, rootKey)
+
+			cas := []certs.Certificate{
+				{
+					SerialNumber: oldRoot.SerialNumber.String(),
+					Certificate:  pemCert(oldRoot),
+					Key:          pemKey(t, rootKey),
+					ExpiryTime:   oldRoot.NotAfter,
+					Type:         certs.RootCA,
+				},
+				{
+					SerialNumber: oldInter.SerialNumber.String(),
+					Certificate:  pemCert(oldInter),
+					Key:          pemKey(t, oldInterKey),
+					ExpiryTime:   oldInter.NotAfter,
+					Type:         certs.IntermediateCA,
+					IssuerName:   certs.DefaultIssuer,
+					IssuerSerial: oldRoot.SerialNumber.String(),
+				},
+			}
+			stored := map[string]certs.Certificate{}
+			for _, ca := range cas {
+				stored[ca.SerialNumber] = ca
+			}
+
+			cRepo := new(mocks.MockRepository)
+			cRepo.On("GetCAs", mock.Anything).Return(cas, nil)
+			cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(1).(certs.Certificate)
+				stored[c.SerialNumber] = c
+			}).Return(nil)
+			cRepo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) certs.Certificate {
+				return stored[sn]
+			}, nil)
+			cRepo.On("UpdateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				c := args.Get(1).(certs.Certificate)
+				stored[c.SerialNumber] = c
+			}).Return(nil)
+			cRepo.On("CountValidCerts", mock.Anything, []string{oldInter.SerialNumber.String()}).Return(tc.validCerts, nil)
+
+			cfg := certs.Config{CommonName: "test", CrossSign: true}
+			svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+			require.NoError(t, err)
+
+			var newRoot certs.Certificate
+			for _, c := range stored {
+				if c.Type == certs.RootCA && c.SerialNumber != oldRoot.SerialNumber.String() {
+					newRoot = c
+				}
+			}
+			require.NotEmpty(t, newRoot.SerialNumber, "next root must be generated")
+			assert.Equal(t, !tc.rotated, newRoot.Staged)
+			assert.False(t, stored[oldRoot.SerialNumber.String()].Revoked, "old root must not be revoked")
+			assert.Equal(t, tc.rotated, stored[oldRoot.SerialNumber.String()].Retired)
+			assert.False(t, stored[oldInter.SerialNumber.String()].Revoked, "old intermediate must not be revoked")
+			assert.Equal(t, tc.rotated, stored[oldInter.SerialNumber.String()].Retired)
+
+			newRootCert := parsePEMCert(t, newRoot.Certificate)
+			cross := parsePEMCert(t, newRoot.CrossCert)
+			assert.NoError(t, cross.CheckSignatureFrom(oldRoot))
+			assert.True(t, newRootCert.PublicKey.(interface{ Equal(crypto.PublicKey) bool }).Equal(cross.PublicKey))
+
+			issuers, err := svc.ListIssuers(context.Background())
+			require.NoError(t, err)
+			var issuer certs.Issuer
+			for _, i := range issuers {
+				if !i.Retired {
+					issuer = i
+				}
+			}
+			issuerRoot := oldRoot
+			if tc.rotated {
+				require.Len(t, issuers, 2)
+				assert.NotEqual(t, oldInter.SerialNumber.String(), issuer.SerialNumber)
+				assert.Equal(t, newRoot.SerialNumber, stored[issuer.SerialNumber].IssuerSerial)
+				issuerRoot = newRootCert
+			}
+			assert.NoError(t, parsePEMCert(t, issuer.Certificate).CheckSignatureFrom(issuerRoot))
+
+			cRepo.On("RedeemToken", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			token, err := svc.RetrieveCAToken(context.Background(), "")
+			require.NoError(t, err)
+			chain, err := svc.GetChainCA(context.Background(), token)
+			require.NoError(t, err)
+			var blocks []*x509.Certificate
+			for rest := chain.Certificate; ; {
+				var block *pem.Block
+				if block, rest = pem.Decode(rest); block == nil {
+					break
+				}
+				cert, err := x509.ParseCertificate(block.Bytes)
+				require.NoError(t, err)
+				blocks = append(blocks, cert)
+			}
+			require.Len(t, blocks, tc.chainLen)
+			assert.Equal(t, issuer.SerialNumber, blocks[0].SerialNumber.String())
+			assert.True(t, blocks[1].Equal(issuerRoot), "issuer root must follow the issuer")
+
+			cert, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", []string{}, certs.SubjectOptions{CommonName: "device"})
+			require.NoError(t, err)
+			leaf := parsePEMCert(t, cert.Certificate)
+
+			// Devices that only trust the old root validate certificates under the new one.
+			oldRoots := x509.NewCertPool()
+			oldRoots.AddCert(oldRoot)
+			pool := x509.NewCertPool()
+			pool.AddCert(blocks[0])
+			pool.AddCert(cross)
+			_, err = leaf.Verify(x509.VerifyOptions{Roots: oldRoots, Intermediates: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+			assert.NoError(t, err)
+
+			if !tc.rotated {
+				return
+			}
+			// Certificates of the retired issuer are reissued by its successor.
+			leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			require.NoError(t, err)
+			der, err = x509.CreateCertificate(rand.Reader, &x509.Certificate{
+				SerialNumber: big.NewInt(time.Now().UnixNano()),
+				Subject:      pkix.Name{CommonName: "old device"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(time.Hour),
+			}, oldInter, leafKey.Public(), oldInterKey)
+			require.NoError(t, err)
+			oldLeaf := certs.Certificate{
+				SerialNumber: "old-leaf",
+				Certificate:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+				ExpiryTime:   time.Now().Add(time.Hour),
+				Type:         certs.ClientCert,
+				IssuerSerial: oldInter.SerialNumber.String(),
+			}
+			stored[oldLeaf.SerialNumber] = oldLeaf
+
+			_, _, ca, err := svc.OCSP(context.Background(), oldLeaf.SerialNumber)
+			require.NoError(t, err)
+			assert.Equal(t, oldInter.SerialNumber.String(), ca.SerialNumber)
+
+			require.NoError(t, svc.RenewCert(context.Background(), oldLeaf.SerialNumber))
+			assert.Equal(t, issuer.SerialNumber, stored[oldLeaf.SerialNumber].IssuerSerial)
+			renewed := parsePEMCert(t, stored[oldLeaf.SerialNumber].Certificate)
+			assert.NoError(t, renewed.CheckSignatureFrom(parsePEMCert(t, issuer.Certificate)))
+		})
+	}
+}
+
+func TestCASettings(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+common_name: "test"
+key_algorithm: "ecdsa"
+key_size: 256
+validity_period: "4380h"
+root:
+  max_path_len: 1
+  email_address: "root@example.com"
+  rotation_threshold: "240h"
+  stage_threshold: "480h"
+intermediate:
+  validity_period: "720h"
+  key_algorithm: "ed25519"
+  max_path_len: 0
+  permitted_dns_domains:
+    - "example.com"
+  excluded_ip_ranges:
+    - "10.0.0.0/8"
+  rotation_threshold: "72h"
+`), 0o600))
+
+	cfg, err := certs.LoadConfig(cfgFile)
+	require.NoError(t, err)
+	assert.Equal(t, 4380*time.Hour, cfg.Root.ValidityPeriod)
+	assert.Equal(t, 720*time.Hour, cfg.Intermediate.ValidityPeriod)
+	assert.True(t, cfg.Intermediate.MaxPathLenZero)
+
+	var saved []certs.Certificate
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).(certs.Certificate))
+	}).Return(nil)
+	_, err = certs.NewService(context.Background(), cRepo, nil, cfg)
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+
+	root := parsePEMCert(t, saved[0].Certificate)
+	assert.WithinDuration(t, time.Now().Add(4380*time.Hour), root.NotAfter, time.Minute)
+	assert.Equal(t, 1, root.MaxPathLen)
+	assert.Contains(t, root.Subject.String(), "root@example.com")
+	assert.IsType(t, &ecdsa.PublicKey{}, root.PublicKey)
+
+	inter := parsePEMCert(t, saved[1].Certificate)
+	assert.WithinDuration(t, time.Now().Add(720*time.Hour), inter.NotAfter, time.Minute)
+	assert.True(t, inter.MaxPathLenZero)
+	assert.Equal(t, []string{"example.com"}, inter.PermittedDNSDomains)
+	require.Len(t, inter.ExcludedIPRanges, 1)
+	assert.Equal(t, "10.0.0.0/8", inter.ExcludedIPRanges[0].String())
+	assert.Contains(t, inter.Subject.String(), "captainnemot1k60@gmail.com")
+	assert.IsType(t, ed25519.PublicKey{}, inter.PublicKey)
+
+	testCases := []struct {
+		desc string
+		cfg  certs.Config
+	}{
+		{
+			desc: "rotation threshold exceeding validity",
+			cfg:  certs.Config{Intermediate: certs.CASettings{ValidityPeriod: time.Hour, RotationThreshold: 2 * time.Hour}},
+		},
+		{
+			desc: "stage threshold shorter than rotation threshold",
+			cfg:  certs.Config{Root: certs.CASettings{RotationThreshold: 48 * time.Hour, StageThreshold: 24 * time.Hour}},
+		},
+		{
+			desc: "stage threshold on intermediate",
+			cfg:  certs.Config{Intermediate: certs.CASettings{StageThreshold: 24 * time.Hour}},
+		},
+		{
+			desc: "intermediate outliving root",
+			cfg:  certs.Config{Root: certs.CASettings{ValidityPeriod: 24 * 90 * time.Hour}, Intermediate: certs.CASettings{ValidityPeriod: 24 * 91 * time.Hour}},
+		},
+		{
+			desc: "root path length forbidding intermediates",
+			cfg:  certs.Config{Root: certs.CASettings{MaxPathLenZero: true}},
+		},
+		{
+			desc: "intermediate path length not below root",
+			cfg:  certs.Config{Root: certs.CASettings{MaxPathLen: 1}, Intermediate: certs.CASettings{MaxPathLen: 1}},
+		},
+		{
+			desc: "invalid email address",
+			cfg:  certs.Config{Root: certs.CASettings{EmailAddress: "not an email"}},
+		},
+		{
+			desc: "unsupported intermediate key size",
+			cfg:  certs.Config{Intermediate: certs.CASettings{KeyAlgorithm: certs.KeyAlgorithmECDSA, KeySize: 128}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := certs.NewService(context.Background(), new(mocks.MockRepository), nil, &tc.cfg)
+			assert.True(t, errors.Contains(err, certs.ErrInvalidConfig), "expected error %v, got %v", certs.ErrInvalidConfig, err)
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("RetrieveProfile", mock.Anything, "tenant").Return(certs.Profile{
+		Name:         "tenant",
+		KeyUsages:    []string{"digital_signature"},
+		ExtKeyUsages: []string{"client_auth"},
+	}, nil)
+	cRepo.On("RetrieveProfile", mock.Anything, mock.Anything).Return(certs.Profile{}, certs.ErrNotFound)
+
+	cfg := config
+	cfg.DNSNames = []string{"ca.example.com"}
+	// The CAs must allow the extended key usages of the profiles under test.
+	cfg.Root.ExtKeyUsages = []string{"server_auth", "client_auth", "email_protection"}
+	cfg.Intermediate.ExtKeyUsages = cfg.Root.ExtKeyUsages
+	cfg.Profiles = []certs.Profile{
+		{
+			Name:         "iot",
+			KeyUsages:    []string{"digital_signature", "key_encipherment"},
+			ExtKeyUsages: []string{"client_auth"},
+			TTL:          "24h",
+			MaxTTL:       "48h",
+			AllowedSANs:  []string{certs.SANDNS},
+			Subject:      certs.ProfileSubject{Organization: []string{"Acme"}},
+		},
+	}
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+	require.NoError(t, err)
+
+	csrKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:        pkix.Name{CommonName: "alice"},
+		EmailAddresses: []string{"alice@example.com"},
+	}, csrKey)
+	require.NoError(t, err)
+	csr := certs.CSR{CSR: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})}
+
+	testCases := []struct {
+		desc     string
+		profile  string
+		ttl      string
+		options  certs.SubjectOptions
+		csr      bool
+		err      error
+		validity time.Duration
+		ku       x509.KeyUsage
+		eku      []x509.ExtKeyUsage
+		org      []string
+	}{
+		{
+			desc:     "default profile",
+			options:  certs.SubjectOptions{CommonName: "device"},
+			validity: 30 * 24 * time.Hour,
+			ku:       x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			eku:      []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		},
+		{
+			desc:     "server profile",
+			profile:  "server",
+			ttl:      "1h",
+			options:  certs.SubjectOptions{CommonName: "device", DnsNames: []string{"device.example.com"}},
+			validity: time.Hour,
+			ku:       x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			eku:      []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		},
+		{
+			desc:     "configured profile with default subject",
+			profile:  "iot",
+			options:  certs.SubjectOptions{CommonName: "device"},
+			validity: 24 * time.Hour,
+			ku:       x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			eku:      []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			org:      []string{"Acme"},
+		},
+		{
+			desc:     "created profile",
+			profile:  "tenant",
+			ttl:      "1h",
+			options:  certs.SubjectOptions{CommonName: "device"},
+			validity: time.Hour,
+			ku:       x509.KeyUsageDigitalSignature,
+			eku:      []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		},
+		{
+			desc:     "S/MIME profile from CSR with ECDSA key",
+			profile:  "smime",
+			ttl:      "1h",
+			csr:      true,
+			validity: time.Hour,
+			ku:       x509.KeyUsageDigitalSignature,
+			eku:      []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+		},
+		{
+			desc:    "TTL exceeding the profile max TTL",
+			profile: "iot",
+			ttl:     "72h",
+			options: certs.SubjectOptions{CommonName: "device"},
+			err:     certs.ErrProfileViolation,
+		},
+		{
+			desc:    "SAN type not allowed by the profile",
+			profile: "code-signing",
+			ttl:     "1h",
+			options: certs.SubjectOptions{CommonName: "device", DnsNames: []string{"device.example.com"}},
+			err:     certs.ErrProfileViolation,
+		},
+		{
+			desc:    "email SAN from CSR not allowed by the profile",
+			profile: "server",
+			ttl:     "1h",
+			csr:     true,
+			err:     certs.ErrProfileViolation,
+		},
+		{
+			desc:    "unknown profile",
+			profile: "unknown",
+			options: certs.SubjectOptions{CommonName: "device"},
+			err:     certs.ErrProfileNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var cert certs.Certificate
+			var err error
+			if tc.csr {
+				cert, err = svc.IssueFromCSR(context.Background(), "entityID", "", tc.profile, tc.ttl, csr)
+			} else {
+				cert, err = svc.IssueCert(context.Background(), "entityID", "", tc.profile, tc.ttl, []string{}, tc.options)
+			}
+			if tc.err != nil {
+				assert.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+
+			leaf := parsePEMCert(t, stored[cert.SerialNumber].Certificate)
+			assert.WithinDuration(t, time.Now().Add(tc.validity), leaf.NotAfter, time.Minute)
+			assert.Equal(t, tc.ku, leaf.KeyUsage)
+			assert.Equal(t, tc.eku, leaf.ExtKeyUsage)
+			assert.Equal(t, tc.org, leaf.Subject.Organization)
+			assert.NotContains(t, leaf.DNSNames, "ca.example.com", "leaf must not inherit the CA DNS names")
+		})
+	}
+
+	_, err = svc.CreateProfile(context.Background(), certs.Profile{Name: "server", KeyUsages: []string{"digital_signature"}})
+	assert.True(t, errors.Contains(err, certs.ErrConflict), "expected error %v, got %v", certs.ErrConflict, err)
+	_, err = svc.CreateProfile(context.Background(), certs.Profile{Name: "ca", KeyUsages: []string{"cert_sign"}})
+	assert.True(t, errors.Contains(err, certs.ErrMalformedEntity), "expected error %v, got %v", certs.ErrMalformedEntity, err)
+	_, err = svc.CreateProfile(context.Background(), certs.Profile{Name: "long", KeyUsages: []string{"digital_signature"}, TTL: "48h", MaxTTL: "24h"})
+	assert.True(t, errors.Contains(err, certs.ErrMalformedEntity), "expected error %v, got %v", certs.ErrMalformedEntity, err)
+	_, err = svc.UpdateProfile(context.Background(), certs.Profile{Name: "iot", KeyUsages: []string{"digital_signature"}})
+	assert.True(t, errors.Contains(err, certs.ErrProfileReadOnly), "expected error %v, got %v", certs.ErrProfileReadOnly, err)
+	err = svc.RemoveProfile(context.Background(), certs.DefaultProfile)
+	assert.True(t, errors.Contains(err, certs.ErrProfileReadOnly), "expected error %v, got %v", certs.ErrProfileReadOnly, err)
+
+	created := certs.Profile{Name: "tenant", KeyUsages: []string{"digital_signature"}, ExtKeyUsages: []string{"client_auth"}}
+	cRepo.On("CreateProfile", mock.Anything, created).Return(nil)
+	p, err := svc.CreateProfile(context.Background(), created)
+	require.NoError(t, err)
+	assert.False(t, p.ReadOnly)
+
+	cRepo.On("ListProfiles", mock.Anything).Return([]certs.Profile{created}, nil)
+	profiles, err := svc.ListProfiles(context.Background())
+	require.NoError(t, err)
+	var names []string
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"client", "code-signing", "default", "iot", "server", "smime", "tenant"}, names)
+}
+
+func TestPolicy(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+common_name: "test"
+policy:
+  allowed_domains:
+    - "*.example.com"
+    - ".iot.example.com"
+  denied_domains:
+    - "admin.example.com"
+  allowed_ip_ranges:
+    - "10.0.0.0/8"
+  required_subject:
+    - "organization"
+  max_ttl: "48h"
+  entities:
+    limited:
+      max_ttl: "1h"
+`), 0o600))
+	cfg, err := certs.LoadConfig(cfgFile)
+	require.NoError(t, err)
+	assert.Equal(t, 48*time.Hour, cfg.Policy.MaxTTL)
+	assert.Equal(t, time.Hour, cfg.Policy.EntityMaxTTL["limited"])
+
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Return(nil)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, cfg)
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	p224Key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	org := []string{"Acme"}
+	testCases := []struct {
+		desc     string
+		entityID string
+		ttl      string
+		options  certs.SubjectOptions
+		ipAddrs  []string
+		csrKey   crypto.Signer
+		rule     string
+	}{
+		{
+			desc:    "allowed single label wildcard",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org, DnsNames: []string{"a.example.com"}},
+			ipAddrs: []string{"10.1.2.3"},
+		},
+		{
+			desc:    "allowed subdomain",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org, DnsNames: []string{"a.b.iot.example.com"}},
+		},
+		{
+			desc:    "allowed key from CSR",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org},
+			csrKey:  p256Key,
+		},
+		{
+			desc:    "domain below the single label wildcard",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org, DnsNames: []string{"a.b.example.com"}},
+			rule:    certs.RuleAllowedDomains,
+		},
+		{
+			desc:    "denied domain",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org, DnsNames: []string{"admin.example.com"}},
+			rule:    certs.RuleDeniedDomains,
+		},
+		{
+			desc:    "wildcard covering a denied domain",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org, DnsNames: []string{"*.example.com"}},
+			rule:    certs.RuleDeniedDomains,
+		},
+		{
+			desc:    "wildcard outside the denied domains",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org, DnsNames: []string{"*.iot.example.com"}},
+		},
+		{
+			desc:    "common name outside the allowed domains",
+			options: certs.SubjectOptions{CommonName: "www.other.com", Organization: org},
+			rule:    certs.RuleAllowedDomains,
+		},
+		{
+			desc:    "IP address outside the allowed ranges",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org},
+			ipAddrs: []string{"192.168.1.1"},
+			rule:    certs.RuleAllowedIPRanges,
+		},
+		{
+			desc:    "missing required subject field",
+			options: certs.SubjectOptions{CommonName: "device"},
+			rule:    certs.RuleRequiredSubject,
+		},
+		{
+			desc:    "TTL exceeding the max TTL",
+			ttl:     "72h",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org},
+			rule:    certs.RuleMaxTTL,
+		},
+		{
+			desc:     "TTL exceeding the entity max TTL",
+			entityID: "limited",
+			ttl:      "2h",
+			options:  certs.SubjectOptions{CommonName: "device", Organization: org},
+			rule:     certs.RuleEntityMaxTTL,
+		},
+		{
+			desc:    "weak RSA key from CSR",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org},
+			csrKey:  rsaKey,
+			rule:    certs.RuleKeyStrength,
+		},
+		{
+			desc:    "weak ECDSA key from CSR",
+			options: certs.SubjectOptions{CommonName: "device", Organization: org},
+			csrKey:  p224Key,
+			rule:    certs.RuleKeyStrength,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			entityID := tc.entityID
+			if entityID == "" {
+				entityID = "entityID"
+			}
+			ttl := tc.ttl
+			if ttl == "" {
+				ttl = "1h"
+			}
+			var err error
+			if tc.csrKey != nil {
+				der, cerr := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+					Subject:  pkix.Name{CommonName: tc.options.CommonName, Organization: tc.options.Organization},
+					DNSNames: tc.options.DnsNames,
+				}, tc.csrKey)
+				require.NoError(t, cerr)
+				csr := certs.CSR{CSR: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})}
+				_, err = svc.IssueFromCSR(context.Background(), entityID, "", "", ttl, csr)
+			} else {
+				_, err = svc.IssueCert(context.Background(), entityID, "", "", ttl, tc.ipAddrs, tc.options)
+			}
+			if tc.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Contains(err, certs.ErrPolicyViolation), "expected error %v, got %v", certs.ErrPolicyViolation, err)
+			assert.Equal(t, tc.rule, policyRule(err))
+		})
+	}
+
+	_, err = svc.IssueCert(context.Background(), "entityID", "", "server", "1h", nil, certs.SubjectOptions{CommonName: "device", Organization: org, EmailAddresses: []string{"a@example.com"}})
+	assert.True(t, errors.Contains(err, certs.ErrProfileViolation), "expected error %v, got %v", certs.ErrProfileViolation, err)
+	assert.Equal(t, certs.RuleProfileAllowedSANs, policyRule(err))
+
+	invalid := []certs.Policy{
+		{AllowedDomains: []string{"foo.*.com"}},
+		{RequiredSubject: []string{"serial_number"}},
+		{EntityMaxTTL: map[string]time.Duration{"entity": 0}},
+	}
+	for _, policy := range invalid {
+		_, err := certs.NewService(context.Background(), new(mocks.MockRepository), nil, &certs.Config{Policy: policy})
+		assert.True(t, errors.Contains(err, certs.ErrInvalidConfig), "expected error %v, got %v", certs.ErrInvalidConfig, err)
+	}
+}
+
+func TestNameConstraints(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+common_name: "test"
+root:
+  max_path_len: 1
+  permitted_dns_domains:
+    - "example.com"
+intermediate:
+  max_path_len: 0
+  excluded_dns_domains:
+    - "admin.example.com"
+  permitted_ip_ranges:
+    - "10.0.0.0/8"
+  permitted_email_addresses:
+    - "example.com"
+issuers:
+  iot:
+    permitted_dns_domains:
+      - ".iot.example.com"
+    ext_key_usages:
+      - "client_auth"
+`), 0o600))
+	cfg, err := certs.LoadConfig(cfgFile)
+	require.NoError(t, err)
+
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("RetrieveProfile", mock.Anything, mock.Anything).Return(certs.Profile{}, certs.ErrNotFound)
+	svc, err := certs.NewService(context.Background(), cRepo, nil, cfg)
+	require.NoError(t, err)
+
+	issuer, err := svc.CreateIssuer(context.Background(), "iot")
+	require.NoError(t, err)
+	iot := parsePEMCert(t, stored[issuer.SerialNumber].Certificate)
+	assert.Equal(t, []string{".iot.example.com"}, iot.PermittedDNSDomains)
+	assert.Equal(t, []string{"admin.example.com"}, iot.ExcludedDNSDomains)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, iot.ExtKeyUsage)
+	assert.True(t, iot.MaxPathLenZero)
+
+	testCases := []struct {
+		desc    string
+		issuer  string
+		profile string
+		options certs.SubjectOptions
+		ipAddrs []string
+		rule    string
+	}{
+		{
+			desc:    "permitted names",
+			options: certs.SubjectOptions{CommonName: "device", DnsNames: []string{"a.example.com", "example.com"}, EmailAddresses: []string{"alice@mail.example.com"}},
+			ipAddrs: []string{"10.1.2.3"},
+		},
+		{
+			desc:    "DNS name outside the root permitted domains",
+			options: certs.SubjectOptions{CommonName: "device", DnsNames: []string{"example.org"}},
+			rule:    certs.RuleNameConstraints,
+		},
+		{
+			desc:    "DNS name in the intermediate excluded domains",
+			options: certs.SubjectOptions{CommonName: "device", DnsNames: []string{"a.admin.example.com"}},
+			rule:    certs.RuleNameConstraints,
+		},
+		{
+			desc:    "IP address outside the permitted ranges",
+			ipAddrs: []string{"192.168.1.1"},
+			options: certs.SubjectOptions{CommonName: "device"},
+			rule:    certs.RuleNameConstraints,
+		},
+		{
+			desc:    "email address outside the permitted domains",
+			options: certs.SubjectOptions{CommonName: "device", EmailAddresses: []string{"alice@example.org"}},
+			rule:    certs.RuleNameConstraints,
+		},
+		{
+			desc:    "permitted subdomain of the issuer",
+			issuer:  "iot",
+			profile: "client",
+			options: certs.SubjectOptions{CommonName: "device", DnsNames: []string{"dev.iot.example.com"}},
+		},
+		{
+			desc:    "issuer permitted domain itself",
+			issuer:  "iot",
+			profile: "client",
+			options: certs.SubjectOptions{CommonName: "device", DnsNames: []string{"iot.example.com"}},
+			rule:    certs.RuleNameConstraints,
+		},
+		{
+			desc:    "extended key usage not allowed by the issuer",
+			issuer:  "iot",
+			profile: "server",
+			options: certs.SubjectOptions{CommonName: "device", DnsNames: []string{"dev.iot.example.com"}},
+			rule:    certs.RuleIssuerExtKeyUsage,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cert, err := svc.IssueCert(context.Background(), "entityID", tc.issuer, tc.profile, "1h", tc.ipAddrs, tc.options)
+			if tc.rule == "" {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, cert.Certificate)
+				return
+			}
+			assert.True(t, errors.Contains(err, certs.ErrPolicyViolation), "expected error %v, got %v", certs.ErrPolicyViolation, err)
+			assert.Equal(t, tc.rule, policyRule(err))
+		})
+	}
+
+	invalid := []map[string]certs.CASettings{
+		{"iot": {ExtKeyUsages: []string{"unknown"}}},
+		{"iot": {MaxPathLen: 1}},
+		{"iot": {StageThreshold: time.Hour}},
+	}
+	for _, issuers := range invalid {
+		cfg := certs.Config{Root: certs.CASettings{MaxPathLen: 1}, Issuers: issuers}
+		_, err := certs.NewService(context.Background(), new(mocks.MockRepository), nil, &cfg)
+		assert.True(t, errors.Contains(err, certs.ErrInvalidConfig), "expected error %v, got %v", certs.ErrInvalidConfig, err)
+	}
+}
+
+func TestAuthorityURLs(t *testing.T) {
+	stored := map[string]certs.Certificate{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("ListRevokedCerts", mock.Anything, mock.Anything, mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("NextCRLNumber", mock.Anything, mock.Anything).Return(uint64(1), nil)
+
+	cfg := config
+	cfg.PublicURLs = []string{"https://ca.example.com/", "http://ca.internal"}
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+	require.NoError(t, err)
+
+	issuers, err := svc.ListIssuers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, issuers, 1)
+	intermediate := parsePEMCert(t, stored[issuers[0].SerialNumber].Certificate)
+	rootSerial := stored[issuers[0].SerialNumber].IssuerSerial
+	assert.Equal(t, []string{"https://ca.example.com/certs/ocsp", "http://ca.internal/certs/ocsp"}, intermediate.OCSPServer)
+	assert.Equal(t, []string{"https://ca.example.com/certs/ca/" + rootSerial, "http://ca.internal/certs/ca/" + rootSerial}, intermediate.IssuingCertificateURL)
+	assert.Equal(t, []string{"https://ca.example.com/certs/ca/" + rootSerial + "/crl", "http://ca.internal/certs/ca/" + rootSerial + "/crl"}, intermediate.CRLDistributionPoints)
+
+	issued, err := svc.IssueCert(context.Background(), "entityID", "", "", "1h", nil, certs.SubjectOptions{CommonName: "device"})
+	require.NoError(t, err)
+	leaf := parsePEMCert(t, issued.Certificate)
+	assert.Equal(t, []string{"https://ca.example.com/certs/ocsp", "http://ca.internal/certs/ocsp"}, leaf.OCSPServer)
+	assert.Equal(t, "https://ca.example.com/certs/ca/"+issuers[0].SerialNumber, leaf.IssuingCertificateURL[0])
+	assert.Equal(t, "https://ca.example.com/certs/ca/"+issuers[0].SerialNumber+"/crl", leaf.CRLDistributionPoints[0])
+
+	caCert, err := svc.ViewCACert(context.Background(), issuers[0].SerialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, intermediate.Raw, parsePEMCert(t, caCert.Certificate).Raw)
+	assert.Empty(t, caCert.Key)
+
+	published, err := svc.GenerateCACRL(context.Background(), rootSerial, false)
+	require.NoError(t, err)
+	crl, err := x509.ParseRevocationList(published.DER)
+	require.NoError(t, err)
+	root, err := svc.ViewCACert(context.Background(), rootSerial)
+	require.NoError(t, err)
+	assert.NoError(t, crl.CheckSignatureFrom(parsePEMCert(t, root.Certificate)))
+
+	_, err = svc.ViewCACert(context.Background(), "unknown")
+	assert.True(t, errors.Contains(err, certs.ErrCANotFound), "expected error %v, got %v", certs.ErrCANotFound, err)
+	_, err = svc.GenerateCACRL(context.Background(), "unknown", false)
+	assert.True(t, errors.Contains(err, certs.ErrCANotFound), "expected error %v, got %v", certs.ErrCANotFound, err)
+
+	for _, u := range []string{"ca.example.com", "ftp://ca.example.com", "https://ca.example.com?x=1"} {
+		_, err := certs.NewService(context.Background(), new(mocks.MockRepository), nil, &certs.Config{PublicURLs: []string{u}})
+		assert.True(t, errors.Contains(err, certs.ErrInvalidConfig), "expected error %v, got %v", certs.ErrInvalidConfig, err)
+	}
+}
+
+func TestCSRApproval(t *testing.T) {
+	var mu sync.Mutex
+	stored := map[string]certs.Certificate{}
+	csrs := map[string]certs.CSR{}
+	cRepo := new(mocks.MockRepository)
+	cRepo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	cRepo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	cRepo.On("CreateCSR", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		c := args.Get(1).(certs.CSR)
+		csrs[c.ID] = c
+	}).Return(nil)
+	cRepo.On("UpdateCSR", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, c certs.CSR, status certs.CSRStatus) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if csrs[c.ID].Status != status {
+			return certs.ErrCSRNotPending
+		}
+		csrs[c.ID] = c
+		return nil
+	})
+	cRepo.On("RetrieveCSR", mock.Anything, mock.Anything).Return(func(_ context.Context, id string) (certs.CSR, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		c, ok := csrs[id]
+		if !ok {
+			return certs.CSR{}, certs.ErrNotFound
+		}
+		return c, nil
+	})
+	cRepo.On("ListCSRs", mock.Anything, mock.Anything).Return(func(_ context.Context, pm certs.PageMetadata) (certs.CSRPage, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		page := certs.CSRPage{PageMetadata: pm}
+		for _, c := range csrs {
+			if (pm.Status == "" || c.Status == pm.Status) && (pm.EntityID == "" || c.EntityID == pm.EntityID) {
+				page.CSRs = append(page.CSRs, c)
+			}
+		}
+		page.Total = uint64(len(page.CSRs))
+		return page, nil
+	})
+	cRepo.On("RetrieveProfile", mock.Anything, mock.Anything).Return(certs.Profile{}, certs.ErrNotFound)
+
+	cfg := config
+	cfg.Approval = certs.ApprovalSettings{
+		Manual:              true,
+		AutoApproveEntities: []string{"trusted"},
+		AutoApproveProfiles: []string{"server"},
+	}
+	svc, err := certs.NewService(context.Background(), cRepo, nil, &cfg)
+	require.NoError(t, err)
+
+	csrKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "device"},
+		DNSNames: []string{"device.example.com"},
+	}, csrKey)
+	require.NoError(t, err)
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+	requester := certs.Requester{Address: "192.0.2.1", UserAgent: "test"}
+
+	testCases := []struct {
+		desc     string
+		csr      certs.CSR
+		status   certs.CSRStatus
+		err      error
+		validity time.Duration
+	}{
+		{
+			desc:   "manual approval",
+			csr:    certs.CSR{EntityID: "device", CSR: csrPEM, Requester: requester},
+			status: certs.CSRPending,
+		},
+		{
+			desc:     "auto-approved entity",
+			csr:      certs.CSR{EntityID: "trusted", TTL: "2h", CSR: csrPEM},
+			status:   certs.CSRIssued,
+			validity: 2 * time.Hour,
+		},
+		{
+			desc:     "auto-approved profile",
+			csr:      certs.CSR{EntityID: "device", Profile: "server", TTL: "3h", CSR: csrPEM},
+			status:   certs.CSRIssued,
+			validity: 3 * time.Hour,
+		},
+		{
+			desc: "malformed CSR",
+			csr:  certs.CSR{EntityID: "device", CSR: []byte("csr")},
+			err:  certs.ErrMalformedEntity,
+		},
+		{
+			desc: "unknown profile",
+			csr:  certs.CSR{EntityID: "device", Profile: "unknown", CSR: csrPEM},
+			err:  certs.ErrProfileNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			csr, err := svc.SubmitCSR(context.Background(), tc.csr)
+			if tc.err != nil {
+				assert.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, csr.ID)
+			assert.Equal(t, tc.status, csr.Status)
+			assert.Equal(t, tc.csr.Requester, csr.Requester)
+			if tc.status != certs.CSRIssued {
+				assert.Empty(t, csr.SerialNumber)
+				return
+			}
+			leaf := parsePEMCert(t, stored[csr.SerialNumber].Certificate)
+			assert.WithinDuration(t, time.Now().Add(tc.validity), leaf.NotAfter, time.Minute)
+		})
+	}
+
+	page, err := svc.ListCSRs(context.Background(), certs.PageMetadata{Status: certs.CSRPending})
+	require.NoError(t, err)
+	require.Len(t, page.CSRs, 1)
+	pending := page.CSRs[0]
+	_, err = svc.ListCSRs(context.Background(), certs.PageMetadata{Status: "unknown"})
+	assert.True(t, errors.Contains(err, certs.ErrMalformedEntity), "expected error %v, got %v", certs.ErrMalformedEntity, err)
+
+	// A failed issuance leaves the CSR approved so that it can be approved
+	// again with other settings.
+	_, err = svc.ApproveCSR(context.Background(), pending.ID, "", "code-signing")
+	assert.True(t, errors.Contains(err, certs.ErrProfileViolation), "expected error %v, got %v", certs.ErrProfileViolation, err)
+	csr, err := svc.ViewCSR(context.Background(), pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, certs.CSRApproved, csr.Status)
+
+	csr, err = svc.ApproveCSR(context.Background(), pending.ID, "1h", "server")
+	require.NoError(t, err)
+	assert.Equal(t, certs.CSRIssued, csr.Status)
+	assert.Equal(t, "server", csr.Profile)
+	leaf := parsePEMCert(t, stored[csr.SerialNumber].Certificate)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), leaf.NotAfter, time.Minute)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, leaf.ExtKeyUsage)
+
+	_, err = svc.ApproveCSR(context.Background(), pending.ID, "", "")
+	assert.True(t, errors.Contains(err, certs.ErrCSRNotPending), "expected error %v, got %v", certs.ErrCSRNotPending, err)
+	_, err = svc.RejectCSR(context.Background(), pending.ID, "too late")
+	assert.True(t, errors.Contains(err, certs.ErrCSRNotPending), "expected error %v, got %v", certs.ErrCSRNotPending, err)
+
+	csr, err = svc.SubmitCSR(context.Background(), certs.CSR{EntityID: "device", CSR: csrPEM})
+	require.NoError(t, err)
+	csr, err = svc.RejectCSR(context.Background(), csr.ID, "unknown device")
+	require.NoError(t, err)
+	assert.Equal(t, certs.CSRRejected, csr.Status)
+	assert.Equal(t, "unknown device", csr.Reason)
+	_, err = svc.ApproveCSR(context.Background(), csr.ID, "", "")
+	assert.True(t, errors.Contains(err, certs.ErrCSRNotPending), "expected error %v, got %v", certs.ErrCSRNotPending, err)
+
+	_, err = svc.ApproveCSR(context.Background(), "unknown", "", "")
+	assert.True(t, errors.Contains(err, certs.ErrNotFound), "expected error %v, got %v", certs.ErrNotFound, err)
+
+	// Concurrent approvals of the same CSR issue a single certificate.
+	csr, err = svc.SubmitCSR(context.Background(), certs.CSR{EntityID: "device", CSR: csrPEM})
+	require.NoError(t, err)
+	const approvals = 5
+	results := make(chan error, approvals)
+	var wg sync.WaitGroup
+	for range approvals {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.ApproveCSR(context.Background(), csr.ID, "", "")
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+	var issued int
+	for err := range results {
+		if err == nil {
+			issued++
+			continue
+		}
+		assert.True(t, errors.Contains(err, certs.ErrCSRNotPending), "expected error %v, got %v", certs.ErrCSRNotPending, err)
+	}
+	assert.Equal(t, 1, issued)
+	csr, err = svc.ViewCSR(context.Background(), csr.ID)
+	require.NoError(t, err)
+	assert.Equal(t, certs.CSRIssued, csr.Status)
+
+	// A CSR left issuing by a failed request is reclaimed once its issuance
+	// timed out.
+	stuck := func() certs.CSR {
+		csr, err := svc.SubmitCSR(context.Background(), certs.CSR{EntityID: "device", CSR: csrPEM})
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		csr.Status = certs.CSRIssuing
+		csr.UpdatedAt = time.Now().UTC()
+		csrs[csr.ID] = csr
+		return csr
+	}
+	timeOut := func(csr certs.CSR) {
+		mu.Lock()
+		defer mu.Unlock()
+		csr.UpdatedAt = time.Now().Add(-time.Hour).UTC()
+		csrs[csr.ID] = csr
+	}
+	csr = stuck()
+	_, err = svc.ApproveCSR(context.Background(), csr.ID, "", "")
+	assert.True(t, errors.Contains(err, certs.ErrCSRNotPending), "expected error %v, got %v", certs.ErrCSRNotPending, err)
+	_, err = svc.RejectCSR(context.Background(), csr.ID, "stuck")
+	assert.True(t, errors.Contains(err, certs.ErrCSRNotPending), "expected error %v, got %v", certs.ErrCSRNotPending, err)
+	timeOut(csr)
+	csr, err = svc.ApproveCSR(context.Background(), csr.ID, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, certs.CSRIssued, csr.Status)
+	assert.NotEmpty(t, csr.SerialNumber)
+
+	csr = stuck()
+	timeOut(csr)
+	csr, err = svc.RejectCSR(context.Background(), csr.ID, "stuck")
+	require.NoError(t, err)
+	assert.Equal(t, certs.CSRRejected, csr.Status)
+}
+
+func newTestCA(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sn, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          sn,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func signCSR(t *testing.T, csr *x509.CertificateRequest, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               csr.Subject,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, csr.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert
+}
+
+// verifyOCSP parses the OCSP response and checks its signature against the
+// issuer or the delegated responder certified by it, which it returns.
+// golang.org/x/crypto/ocsp does not verify Ed25519 signatures, so they are
+// checked here.
+func verifyOCSP(t *testing.T, der []byte, issuer *x509.Certificate) (*ocsp.Response, *x509.Certificate) {
+	var response struct {
+		Status asn1.Enumerated
+		Bytes  struct {
+			Type     asn1.ObjectIdentifier
+			Response []byte
+		} `asn1:"explicit,tag:0,optional"`
+	}
+	_, err := asn1.Unmarshal(der, &response)
+	require.NoError(t, err)
+	var basic struct {
+		TBSResponseData    asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+		Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+	}
+	_, err = asn1.Unmarshal(response.Bytes.Response, &basic)
+	require.NoError(t, err)
+
+	responder := issuer
+	if len(basic.Certificates) > 0 {
+		responder, err = x509.ParseCertificate(basic.Certificates[0].FullBytes)
+		require.NoError(t, err)
+		require.NoError(t, responder.CheckSignatureFrom(issuer))
+		assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}, responder.ExtKeyUsage)
+	}
+	if _, ok := responder.PublicKey.(ed25519.PublicKey); !ok {
+		res, err := ocsp.ParseResponse(der, issuer)
+		require.NoError(t, err)
+		require.NoError(t, res.CheckSignatureFrom(responder))
+		return res, responder
+	}
+
+	assert.Equal(t, asn1.ObjectIdentifier{1, 3, 101, 112}, basic.SignatureAlgorithm.Algorithm)
+	require.NoError(t, responder.CheckSignature(x509.PureEd25519, basic.TBSResponseData.FullBytes, basic.Signature.RightAlign()))
+	// Drop the certificates so that the response parses without the
+	// signature check the library cannot do.
+	basic.Certificates = nil
+	response.Bytes.Response, err = asn1.Marshal(basic)
+	require.NoError(t, err)
+	stripped, err := asn1.Marshal(response)
+	require.NoError(t, err)
+	res, err := ocsp.ParseResponse(stripped, nil)
+	require.NoError(t, err)
+
+	return res, responder
+}
+
+func intermediates(t *testing.T, svc certs.Service, serialNumber string) *x509.CertPool {
+	_, _, issuer, err := svc.OCSP(context.Background(), serialNumber)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(issuer.Certificate)
+
+	return pool
+}
+
+func pemCert(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func pemKey(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	data, err := certs.MarshalPrivateKey(key)
+	require.NoError(t, err)
+
+	return data
+}
+
+func parsePEMCert(t *testing.T, data []byte) *x509.Certificate {
+	block, _ := pem.Decode(data)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	return cert
+}
+
+func policyRule(err error) string {
+	for e, ok := err.(errors.Error); ok && e != nil; e = e.Err() {
+		if pe, ok := e.(*certs.PolicyError); ok {
+			return pe.Rule
+		}
+	}
+	return ""
+}
\ No newline at end of file
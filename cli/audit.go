@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	ctxsdk "github.com/hantdev/certs/sdk"
+	"github.com/spf13/cobra"
+)
+
+// NewAuditCmd returns the audit log commands.
+func NewAuditCmd() *cobra.Command {
+	var filter ctxsdk.AuditFilter
+	var from, to string
+	eventsCmd := cobra.Command{
+		Use:   "events [--actor=<actor>] [--action=<action>] [--serial=<serial_number>] [--entity=<entity_id>] [--result=<success | failure>] [--from=<RFC3339 time>] [--to=<RFC3339 time>]",
+		Short: "List audit events",
+		Long:  `Lists the audit events matching the filters, the most recent first.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 0 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			var err error
+			if filter.From, err = parseTime(from); err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			if filter.To, err = parseTime(to); err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			filter.Offset, filter.Limit = Offset, Limit
+			page, sdkerr := sdk.ListAuditEvents(filter)
+			if sdkerr != nil {
+				logErrorCmd(*cmd, sdkerr)
+				return
+			}
+			logJSONCmd(*cmd, page)
+		},
+	}
+
+	eventsCmd.Flags().StringVar(&filter.Actor, "actor", "", "name of the caller")
+	eventsCmd.Flags().StringVar(&filter.Action, "action", "", "recorded operation, e.g. revoke_cert")
+	eventsCmd.Flags().StringVar(&filter.SerialNumber, "serial", "", "serial number of the certificate")
+	eventsCmd.Flags().StringVar(&filter.EntityID, "entity", "", "entity ID of the certificate")
+	eventsCmd.Flags().StringVar(&filter.Result, "result", "", "result of the operation, success or failure")
+	eventsCmd.Flags().StringVar(&from, "from", "", "earliest event time in RFC 3339 format")
+	eventsCmd.Flags().StringVar(&to, "to", "", "latest event time in RFC 3339 format")
+
+	verifyCmd := cobra.Command{
+		Use:   "verify",
+		Short: "Verify audit log",
+		Long:  `Verifies the hash chain of the audit log and reports the first broken event.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 0 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			v, err := sdk.VerifyAuditLog()
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, v)
+		},
+	}
+
+	cmd := cobra.Command{
+		Use:   "audit [events | verify]",
+		Short: "Audit log",
+		Long:  `Audit log: list the recorded certificate lifecycle operations and verify their hash chain.`,
+	}
+
+	cmd.AddCommand(&eventsCmd)
+	cmd.AddCommand(&verifyCmd)
+
+	return &cmd
+}
+
+func parseTime(val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", val, err)
+	}
+
+	return t, nil
+}
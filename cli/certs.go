@@ -10,8 +10,10 @@ import (
 	"crypto/x509/pkix"
 	"encoding/json"
 	"encoding/pem"
+	"fmt"
 	"net"
 	"os"
+	"time"
 
 	"github.com/hantdev/certs"
 	"github.com/hantdev/certs/errors"
@@ -63,15 +65,15 @@ var cmdCerts = []cobra.Command{
 		},
 	},
 	{
-		Use:   "revoke <serial_number> ",
-		Short: "Revoke certificate",
-		Long:  `Revokes a certificate for a given serial number.`,
+		Use:   "delete <entity_id> ",
+		Short: "Delete certificate",
+		Long:  `Deletes certificates for a given entity id.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) != 1 {
 				logUsageCmd(*cmd, cmd.Use)
 				return
 			}
-			err := sdk.RevokeCert(args[0])
+			err := sdk.DeleteCert(args[0])
 			if err != nil {
 				logErrorCmd(*cmd, err)
 				return
@@ -80,16 +82,31 @@ var cmdCerts = []cobra.Command{
 		},
 	},
 	{
-		Use:   "delete <entity_id> ",
-		Short: "Delete certificate",
-		Long:  `Deletes certificates for a given entity id.`,
+		Use:   "hold <serial_number>",
+		Short: "Hold certificate",
+		Long:  `Places a certificate on hold, suspending it until it is released.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) != 1 {
 				logUsageCmd(*cmd, cmd.Use)
 				return
 			}
-			err := sdk.DeleteCert(args[0])
-			if err != nil {
+			if err := sdk.HoldCert(args[0]); err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logOKCmd(*cmd)
+		},
+	},
+	{
+		Use:   "release <serial_number>",
+		Short: "Release certificate",
+		Long:  `Releases a certificate from hold.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			if err := sdk.ReleaseCert(args[0]); err != nil {
 				logErrorCmd(*cmd, err)
 				return
 			}
@@ -215,7 +232,7 @@ var cmdCerts = []cobra.Command{
 	{
 		Use:   "download-ca <token>",
 		Short: "Download signing CA",
-		Long:  `Download intermediate cert and ca with a given token.`,
+		Long:  `Downloads the CA chain of the issuer with a given token.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) != 1 {
 				logUsageCmd(*cmd, cmd.Use)
@@ -230,15 +247,97 @@ var cmdCerts = []cobra.Command{
 		},
 	},
 	{
-		Use:   "token-ca",
-		Short: "Get CA token",
-		Long:  `Gets a download token for CA.`,
+		Use:   "ca-chain [<issuer>]",
+		Short: "Get CA chain",
+		Long:  `Saves the public CA chain of the issuer, or of the default issuer, to ca-chain.pem.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			var issuer string
+			if len(args) == 1 {
+				issuer = args[0]
+			}
+			chain, err := sdk.CAChain(issuer)
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logSaveFile(*cmd, "ca-chain.pem", chain)
+		},
+	},
+	{
+		Use:   "trust-bundle",
+		Short: "Get trust bundle",
+		Long:  `Saves the root CA certificates to trust to trust-bundle.pem.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) != 0 {
 				logUsageCmd(*cmd, cmd.Use)
 				return
 			}
-			token, err := sdk.GetCAToken()
+			bundle, err := sdk.TrustBundle()
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logSaveFile(*cmd, "trust-bundle.pem", bundle)
+		},
+	},
+	{
+		Use:   "export-ca <serial_number> <passphrase>",
+		Short: "Export CA backup",
+		Long:  `Saves an encrypted backup of the CA, including its private key, to ca-<serial_number>.backup.pem.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 2 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			backup, err := sdk.ExportCA(args[0], args[1])
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logSaveFile(*cmd, fmt.Sprintf("ca-%s.backup.pem", args[0]), backup)
+		},
+	},
+	{
+		Use:   "decrypt-ca <path_to_backup> <passphrase>",
+		Short: "Decrypt CA backup",
+		Long:  `Decrypts a CA backup into ca.crt, holding the CA chain, and ca.key, to be imported with import-ca.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 2 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			backup, err := os.ReadFile(args[0])
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			_, chain, key, err := certs.DecryptCABackup(backup, args[1])
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logSaveFile(*cmd, "ca.crt", chain)
+			logSaveFile(*cmd, "ca.key", key)
+		},
+	},
+	{
+		Use:   "token-ca [<issuer>]",
+		Short: "Get CA token",
+		Long:  `Gets a download token for the CA chain of the issuer, or of the default issuer.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			var issuer string
+			if len(args) == 1 {
+				issuer = args[0]
+			}
+			token, err := sdk.GetCAToken(issuer)
 			if err != nil {
 				logErrorCmd(*cmd, err)
 				return
@@ -278,14 +377,21 @@ var cmdCerts = []cobra.Command{
 		},
 	},
 	{
-		Use:   "issue-csr <entity_id> <ttl> <path_to_csr>",
+		Use:   "issue-csr <entity_id> <ttl> <path_to_csr> [<issuer>] [<profile>]",
 		Short: "Issue from CSR",
-		Long:  `issues a certificate for a given csr.`,
+		Long:  `Submits a CSR and issues its certificate, unless the CSR has to wait for approval.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) != 3 {
+			if len(args) < 3 || len(args) > 5 {
 				logUsageCmd(*cmd, cmd.Use)
 				return
 			}
+			var issuer, profile string
+			if len(args) >= 4 {
+				issuer = args[3]
+			}
+			if len(args) == 5 {
+				profile = args[4]
+			}
 
 			csrData, err := os.ReadFile(args[2])
 			if err != nil {
@@ -293,21 +399,294 @@ var cmdCerts = []cobra.Command{
 				return
 			}
 
-			cert, err := sdk.IssueFromCSR(args[0], args[1], string(csrData))
+			csr, err := sdk.SubmitCSR(args[0], args[1], issuer, profile, string(csrData))
 			if err != nil {
 				logErrorCmd(*cmd, err)
 				return
 			}
-			logJSONCmd(*cmd, cert)
+			logJSONCmd(*cmd, csr)
+		},
+	},
+	{
+		Use:   "csrs [<status>] [<entity_id>]",
+		Short: "List CSRs",
+		Long:  `Lists the submitted CSRs, optionally filtered by status (pending, approved, issuing, rejected or issued) and entity ID.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 2 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			pm := ctxsdk.PageMetadata{
+				Limit:  Limit,
+				Offset: Offset,
+			}
+			if len(args) >= 1 {
+				pm.Status = args[0]
+			}
+			if len(args) == 2 {
+				pm.EntityID = args[1]
+			}
+			page, err := sdk.ListCSRs(pm)
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, page)
+		},
+	},
+	{
+		Use:   "view-csr <id>",
+		Short: "View CSR",
+		Long:  `Views a submitted CSR.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			csr, err := sdk.ViewCSR(args[0])
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, csr)
+		},
+	},
+	{
+		Use:   "approve-csr <id> [<ttl>] [<profile>]",
+		Short: "Approve CSR",
+		Long:  `Approves a pending CSR and issues its certificate, optionally overriding the requested TTL and profile.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) < 1 || len(args) > 3 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			var ttl, profile string
+			if len(args) >= 2 {
+				ttl = args[1]
+			}
+			if len(args) == 3 {
+				profile = args[2]
+			}
+			csr, err := sdk.ApproveCSR(args[0], ttl, profile)
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, csr)
+		},
+	},
+	{
+		Use:   "reject-csr <id> [<reason>]",
+		Short: "Reject CSR",
+		Long:  `Rejects a pending CSR.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) < 1 || len(args) > 2 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			var reason string
+			if len(args) == 2 {
+				reason = args[1]
+			}
+			csr, err := sdk.RejectCSR(args[0], reason)
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, csr)
+		},
+	},
+	{
+		Use:   "intermediate-csr",
+		Short: "Generate intermediate CA CSR",
+		Long:  `Generates an intermediate CA key and saves its CSR to be signed by the root CA.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 0 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			csr, err := sdk.GenerateIntermediateCSR()
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logSaveCSRFiles(*cmd, certs.CSR{CSR: csr.CSR})
+		},
+	},
+	{
+		Use:   "install-intermediate <path_to_certificate>",
+		Short: "Install intermediate CA",
+		Long:  `Installs the intermediate CA certificate signed from the intermediate CSR.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			certData, err := os.ReadFile(args[0])
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			ca, err := sdk.InstallIntermediateCA(string(certData))
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, ca)
+		},
+	},
+	{
+		Use:   "issuers",
+		Short: "List issuers",
+		Long:  `Lists the active and retired issuing CAs.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 0 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			issuers, err := sdk.ListIssuers()
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, issuers)
+		},
+	},
+	{
+		Use:   "create-issuer <name>",
+		Short: "Create issuer",
+		Long:  `Creates a named intermediate CA under the root CA.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			issuer, err := sdk.CreateIssuer(args[0])
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, issuer)
+		},
+	},
+	{
+		Use:   "retire-issuer <name>",
+		Short: "Retire issuer",
+		Long:  `Stops the issuer from issuing new certificates.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			if err := sdk.RetireIssuer(args[0]); err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logOKCmd(*cmd)
+		},
+	},
+	{
+		Use:   "profiles",
+		Short: "List profiles",
+		Long:  `Lists the configured and created certificate profiles.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 0 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			profiles, err := sdk.ListProfiles()
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, profiles)
+		},
+	},
+	{
+		Use:   "profile <name>",
+		Short: "View profile",
+		Long:  `Views a certificate profile.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			profile, err := sdk.ViewProfile(args[0])
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, profile)
+		},
+	},
+	{
+		Use:   "create-profile <JSON_profile>",
+		Short: "Create profile",
+		Long:  `Creates a certificate profile, e.g. '{"name":"iot","key_usages":["digital_signature"],"ext_key_usages":["client_auth"],"max_ttl":"2160h"}'.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			var profile ctxsdk.Profile
+			if err := json.Unmarshal([]byte(args[0]), &profile); err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			profile, err := sdk.CreateProfile(profile)
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, profile)
+		},
+	},
+	{
+		Use:   "update-profile <name> <JSON_profile>",
+		Short: "Update profile",
+		Long:  `Updates a created certificate profile.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 2 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			var profile ctxsdk.Profile
+			if err := json.Unmarshal([]byte(args[1]), &profile); err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			profile.Name = args[0]
+			profile, err := sdk.UpdateProfile(profile)
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, profile)
+		},
+	},
+	{
+		Use:   "remove-profile <name>",
+		Short: "Remove profile",
+		Long:  `Removes a created certificate profile.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			if err := sdk.RemoveProfile(args[0]); err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logOKCmd(*cmd)
 		},
 	},
 }
 
 // NewCertsCmd returns certificate command.
 func NewCertsCmd() *cobra.Command {
-	var ttl string
+	var ttl, issuer, profile string
 	issueCmd := cobra.Command{
-		Use:   "issue <entity_id> <common_name> '[\"<ip_addr_1>\", \"<ip_addr_2>\"] '{\"organization\":[\"organization_name\"]}' [--ttl=8760h]",
+		Use:   "issue <entity_id> <common_name> '[\"<ip_addr_1>\", \"<ip_addr_2>\"] '{\"organization\":[\"organization_name\"]}' [--ttl=8760h] [--issuer=<issuer>] [--profile=<profile>]",
 		Short: "Issue certificate",
 		Long:  `Issues a certificate for a given entity ID.`,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -323,6 +702,8 @@ func NewCertsCmd() *cobra.Command {
 
 			var option ctxsdk.Options
 			option.CommonName = args[1]
+			option.Issuer = issuer
+			option.Profile = profile
 
 			if len(args) == 4 {
 				if err := json.Unmarshal([]byte(args[3]), &option); err != nil {
@@ -340,15 +721,81 @@ func NewCertsCmd() *cobra.Command {
 		},
 	}
 
-	issueCmd.Flags().StringVar(&ttl, "ttl", "8760h", "certificate time to live in duration")
+	issueCmd.Flags().StringVar(&ttl, "ttl", "", "certificate time to live in duration, the profile default if empty")
+	issueCmd.Flags().StringVar(&issuer, "issuer", "", "name of the issuing CA, the default issuer if empty")
+	issueCmd.Flags().StringVar(&profile, "profile", "", "name of the certificate profile, the default profile if empty")
+
+	var reason, invalidityDate string
+	revokeCmd := cobra.Command{
+		Use:   "revoke <serial_number> [--reason=<reason>] [--invalidity-date=<RFC3339 time>]",
+		Short: "Revoke certificate",
+		Long:  `Revokes a certificate for a given serial number with an RFC 5280 reason, e.g. key_compromise or superseded.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			var date time.Time
+			if invalidityDate != "" {
+				var err error
+				if date, err = time.Parse(time.RFC3339, invalidityDate); err != nil {
+					logErrorCmd(*cmd, err)
+					return
+				}
+			}
+			if err := sdk.RevokeCert(args[0], reason, date); err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logOKCmd(*cmd)
+		},
+	}
+
+	revokeCmd.Flags().StringVar(&reason, "reason", "", "revocation reason, unspecified if empty")
+	revokeCmd.Flags().StringVar(&invalidityDate, "invalidity-date", "", "time the certificate became invalid in RFC 3339 format")
+
+	var keyRef string
+	importCACmd := cobra.Command{
+		Use:   "import-ca <RootCA | IntermediateCA> <path_to_certificate> [<path_to_private_key>] [--key-ref=<key_ref>]",
+		Short: "Import CA",
+		Long:  `Imports an existing root or intermediate CA. The private key may be omitted for an offline root CA.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) < 2 || len(args) > 3 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			certData, err := os.ReadFile(args[1])
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			var keyData []byte
+			if len(args) == 3 {
+				if keyData, err = os.ReadFile(args[2]); err != nil {
+					logErrorCmd(*cmd, err)
+					return
+				}
+			}
+			ca, sdkerr := sdk.ImportCA(args[0], string(certData), string(keyData), keyRef)
+			if sdkerr != nil {
+				logErrorCmd(*cmd, sdkerr)
+				return
+			}
+			logJSONCmd(*cmd, ca)
+		},
+	}
+
+	importCACmd.Flags().StringVar(&keyRef, "key-ref", "", "reference of the CA key in the configured key store")
 
 	cmd := cobra.Command{
-		Use:   "certs [issue | get | revoke | renew | ocsp | token | download | download-ca | download-ca | csr | issue-csr]",
+		Use:   "certs [issue | get | revoke | hold | release | renew | ocsp | token | download | download-ca | ca-chain | trust-bundle | export-ca | decrypt-ca | csr | issue-csr | csrs | view-csr | approve-csr | reject-csr | import-ca | intermediate-csr | install-intermediate | issuers | create-issuer | retire-issuer | profiles | profile | create-profile | update-profile | remove-profile]",
 		Short: "Certificates management",
 		Long:  `Certificates management: issue, get all, get by entity ID, revoke, renew, OCSP, token, download.`,
 	}
 
 	cmd.AddCommand(&issueCmd)
+	cmd.AddCommand(&revokeCmd)
+	cmd.AddCommand(&importCACmd)
 
 	for i := range cmdCerts {
 		cmd.AddCommand(&cmdCerts[i])
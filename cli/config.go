@@ -35,6 +35,7 @@ type config struct {
 	Remotes   remotes `toml:"remotes"`
 	Filter    filter  `toml:"filter"`
 	UserToken string  `toml:"user_token"`
+	APIKey    string  `toml:"api_key"`
 	RawOutput string  `toml:"raw_output"`
 }
 
@@ -140,5 +141,13 @@ func ParseConfig(sdkConf ctxsdk.Config) (ctxsdk.Config, error) {
 
 	sdkConf.TLSVerification = config.Remotes.TLSVerification || sdkConf.TLSVerification
 
+	if sdkConf.APIKey == "" && config.APIKey != "" {
+		sdkConf.APIKey = config.APIKey
+	}
+
+	if sdkConf.Token == "" && config.UserToken != "" {
+		sdkConf.Token = config.UserToken
+	}
+
 	return sdkConf, nil
 }
\ No newline at end of file
@@ -82,7 +82,6 @@ func logSaveCertFiles(cmd cobra.Command, certBundle ctxsdk.CertificateBundle) {
 func logSaveCAFiles(cmd cobra.Command, certBundle ctxsdk.CertificateBundle) {
 	files := map[string][]byte{
 		"ca.crt": certBundle.Certificate,
-		"ca.key": certBundle.PrivateKey,
 	}
 
 	for filename, content := range files {
@@ -112,6 +111,14 @@ func logSaveCSRFiles(cmd cobra.Command, csr certs.CSR) {
 	fmt.Fprintf(cmd.OutOrStdout(), "\nCSR file have been saved successfully.\n")
 }
 
+func logSaveFile(cmd cobra.Command, filename string, content []byte) {
+	if err := saveToFile(filename, content); err != nil {
+		logErrorCmd(cmd, err)
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Saved %s\n", filename)
+}
+
 func saveToFile(filename string, content []byte) error {
 	cwd, err := os.Getwd()
 	if err != nil {
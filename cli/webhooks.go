@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"strconv"
+
+	ctxsdk "github.com/hantdev/certs/sdk"
+	"github.com/spf13/cobra"
+)
+
+// NewWebhooksCmd returns the webhook commands.
+func NewWebhooksCmd() *cobra.Command {
+	var sub ctxsdk.WebhookSubscription
+	createCmd := cobra.Command{
+		Use:   "create <url> [--events=<event types>] [--secret=<secret>]",
+		Short: "Create webhook subscription",
+		Long: `Subscribes the URL to certificate lifecycle events: cert.issued, cert.renewed, cert.revoked, cert.held, cert.released and cert.expiring.
+Without events the URL receives all of them. The secret signing the deliveries is generated unless one is given
+and is only shown once.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			sub.URL = args[0]
+			res, err := sdk.CreateWebhook(sub)
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, res)
+		},
+	}
+
+	createCmd.Flags().StringSliceVar(&sub.Events, "events", nil, "comma separated event types, e.g. cert.revoked,cert.expiring")
+	createCmd.Flags().StringVar(&sub.Secret, "secret", "", "secret signing the deliveries")
+
+	getCmd := cobra.Command{
+		Use:   "get [all | <subscription_id>]",
+		Short: "Get webhook subscriptions",
+		Long:  `Gets all webhook subscriptions or the subscription with the ID, without their secrets.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			if args[0] == "all" {
+				page, err := sdk.ListWebhooks(ctxsdk.PageMetadata{Offset: Offset, Limit: Limit})
+				if err != nil {
+					logErrorCmd(*cmd, err)
+					return
+				}
+				logJSONCmd(*cmd, page)
+				return
+			}
+			res, err := sdk.ViewWebhook(args[0])
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, res)
+		},
+	}
+
+	removeCmd := cobra.Command{
+		Use:   "remove <subscription_id>",
+		Short: "Remove webhook subscription",
+		Long:  `Removes the webhook subscription and its deliveries.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			if err := sdk.RemoveWebhook(args[0]); err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logOKCmd(*cmd)
+		},
+	}
+
+	var filter ctxsdk.WebhookDeliveryFilter
+	deliveriesCmd := cobra.Command{
+		Use:   "deliveries [--subscription=<subscription_id>] [--status=<pending | delivered | dead>]",
+		Short: "List webhook deliveries",
+		Long:  `Lists the webhook deliveries matching the filters, the most recent first.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 0 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			filter.Offset, filter.Limit = Offset, Limit
+			page, err := sdk.ListWebhookDeliveries(filter)
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			logJSONCmd(*cmd, page)
+		},
+	}
+
+	deliveriesCmd.Flags().StringVar(&filter.SubscriptionID, "subscription", "", "ID of the subscription")
+	deliveriesCmd.Flags().StringVar(&filter.Status, "status", "", "status of the delivery, pending, delivered or dead")
+
+	replayCmd := cobra.Command{
+		Use:   "replay <delivery_id>",
+		Short: "Replay webhook delivery",
+		Long:  `Makes a delivered or dead webhook delivery pending again, with a new set of attempts.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				logUsageCmd(*cmd, cmd.Use)
+				return
+			}
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				logErrorCmd(*cmd, err)
+				return
+			}
+			d, sdkerr := sdk.ReplayWebhookDelivery(id)
+			if sdkerr != nil {
+				logErrorCmd(*cmd, sdkerr)
+				return
+			}
+			logJSONCmd(*cmd, d)
+		},
+	}
+
+	cmd := cobra.Command{
+		Use:   "webhooks [create | get | remove | deliveries | replay]",
+		Short: "Webhooks",
+		Long:  `Webhooks: subscribe URLs to certificate lifecycle events and inspect and replay their deliveries.`,
+	}
+
+	cmd.AddCommand(&createCmd)
+	cmd.AddCommand(&getCmd)
+	cmd.AddCommand(&removeCmd)
+	cmd.AddCommand(&deliveriesCmd)
+	cmd.AddCommand(&replayCmd)
+
+	return &cmd
+}
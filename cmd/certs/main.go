@@ -5,15 +5,28 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"time"
 
 	"github.com/caarlos0/env/v10"
 	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/acme"
 	"github.com/hantdev/certs/api"
+	acmeapi "github.com/hantdev/certs/api/acme"
+	auditapi "github.com/hantdev/certs/api/audit"
+	estapi "github.com/hantdev/certs/api/est"
 	certsgrpc "github.com/hantdev/certs/api/grpc"
 	httpapi "github.com/hantdev/certs/api/http"
+	scepapi "github.com/hantdev/certs/api/scep"
+	webhooksapi "github.com/hantdev/certs/api/webhooks"
+	"github.com/hantdev/certs/audit"
+	"github.com/hantdev/certs/auth"
+	"github.com/hantdev/certs/envelope"
+	"github.com/hantdev/certs/est"
 	jaegerClient "github.com/hantdev/certs/internal/jaeger"
 	"github.com/hantdev/certs/internal/postgres"
 	pgClient "github.com/hantdev/certs/internal/postgres"
@@ -23,9 +36,13 @@ import (
 	httpserver "github.com/hantdev/certs/internal/server/http"
 	"github.com/hantdev/certs/internal/uuid"
 	cpostgres "github.com/hantdev/certs/postgres/certs"
+	"github.com/hantdev/certs/scep"
+	"github.com/hantdev/certs/signer/file"
 	"github.com/hantdev/certs/tracing"
+	"github.com/hantdev/certs/webhooks"
 	"github.com/jmoiron/sqlx"
 	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
@@ -37,6 +54,15 @@ const (
 	envPrefixHTTP  = "AM_CERTS_HTTP_"
 	envPrefixGRPC  = "AM_CERTS_GRPC_"
 	envPrefixAuth  = "AM_AUTH_GRPC_"
+	envPrefixAPI   = "AM_CERTS_AUTH_"
+	envPrefixFile  = "AM_CERTS_SIGNER_FILE_"
+	envPrefixP11   = "AM_CERTS_SIGNER_PKCS11_"
+	envPrefixEnc   = "AM_CERTS_KEY_ENCRYPTION_"
+	envPrefixACME  = "AM_CERTS_ACME_"
+	envPrefixEST   = "AM_CERTS_EST_"
+	envPrefixSCEP  = "AM_CERTS_SCEP_"
+	envPrefixHook  = "AM_CERTS_WEBHOOKS_"
+	reencryptCmd   = "reencrypt-keys"
 	defDB          = "certs"
 	defSvcHTTPPort = "9010"
 	defSvcGRPCPort = "7012"
@@ -44,10 +70,15 @@ const (
 )
 
 type config struct {
-	LogLevel   string  `env:"AM_COMPUTATIONS_LOG_LEVEL"     envDefault:"info"`
-	JaegerURL  url.URL `env:"AM_JAEGER_URL"                 envDefault:"http://jaeger:4318"`
-	InstanceID string  `env:"AM_COMPUTATIONS_INSTANCE_ID"   envDefault:""`
-	TraceRatio float64 `env:"AM_JAEGER_TRACE_RATIO"         envDefault:"1.0"`
+	LogLevel    string  `env:"AM_COMPUTATIONS_LOG_LEVEL"        envDefault:"info"`
+	JaegerURL   url.URL `env:"AM_JAEGER_URL"                    envDefault:"http://jaeger:4318"`
+	InstanceID  string  `env:"AM_COMPUTATIONS_INSTANCE_ID"      envDefault:""`
+	TraceRatio  float64 `env:"AM_JAEGER_TRACE_RATIO"            envDefault:"1.0"`
+	Signer      string  `env:"AM_CERTS_SIGNER"                  envDefault:"database"`
+	TokenSecret string  `env:"AM_CERTS_DOWNLOAD_TOKEN_SECRET"   envDefault:""`
+	// TrustedProxies are the networks of the reverse proxies whose
+	// X-Forwarded-For header tells the address of the clients.
+	TrustedProxies []netip.Prefix `env:"AM_CERTS_HTTP_TRUSTED_PROXIES" envDefault:""`
 }
 
 func main() {
@@ -61,13 +92,13 @@ func main() {
 
 	logger, err := initLogger(cfg.LogLevel)
 	if err != nil {
-		log.Fatalf(err.Error())
+		log.Fatal(err)
 	}
 
 	if cfg.InstanceID == "" {
 		cfg.InstanceID, err = uuid.New().ID()
 		if err != nil {
-			log.Fatalf(fmt.Sprintf("failed to generate instance ID: %s", err))
+			log.Fatalf("failed to generate instance ID: %s", err)
 		}
 	}
 
@@ -78,10 +109,28 @@ func main() {
 	cm := cpostgres.Migration()
 	db, err := pgClient.Setup(dbConfig, *cm)
 	if err != nil {
-		log.Fatalf(fmt.Sprintf("Failed to connect to %s database: %s", svcName, err))
+		log.Fatalf("Failed to connect to %s database: %s", svcName, err)
 	}
 	defer db.Close()
 
+	enc, err := newEncrypter()
+	if err != nil {
+		log.Fatalf("failed to load %s key encryption configuration: %s", svcName, err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == reencryptCmd {
+		if enc == nil {
+			log.Fatalf("%s requires %sMASTER_KEYS to be set", reencryptCmd, envPrefixEnc)
+		}
+		repo := cpostgres.NewRepository(postgres.NewDatabase(db, dbConfig, noop.NewTracerProvider().Tracer(svcName)))
+		count, err := envelope.Reencrypt(ctx, repo, enc)
+		if err != nil {
+			log.Fatalf("failed to re-encrypt private keys after %d keys: %s", count, err)
+		}
+		logger.Info(fmt.Sprintf("re-encrypted %d private keys with master key version %d", count, enc.ActiveVersion()))
+		return
+	}
+
 	tp, err := jaegerClient.NewProvider(ctx, svcName, cfg.JaegerURL, cfg.InstanceID, cfg.TraceRatio)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to init Jaeger: %s", err))
@@ -103,13 +152,38 @@ func main() {
 		logger.Error(fmt.Sprintf("failed to load CA config file : %s", err))
 		return
 	}
+	if cfg.TokenSecret != "" && config.DownloadTokens.Secret == nil && config.DownloadTokens.Key == nil {
+		config.DownloadTokens.Secret = []byte(cfg.TokenSecret)
+	}
+	if config.DownloadTokens.Secret == nil && config.DownloadTokens.Key == nil {
+		logger.Warn("No download token secret configured, tokens are signed with a random secret")
+	}
+
+	keyStore, closeKeyStore, err := newKeyStore(cfg.Signer)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to create %s key store: %s", cfg.Signer, err))
+		return
+	}
+	defer closeKeyStore()
 
-	svc, err := newService(ctx, db, tracer, logger, dbConfig, config)
+	auditSvc := audit.NewService(cpostgres.NewAuditRepository(postgres.NewDatabase(db, dbConfig, tracer)))
+
+	svc, err := newService(ctx, db, tracer, logger, dbConfig, keyStore, enc, auditSvc, config)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to create %s service: %s", svcName, err))
 		return
 	}
 
+	webhooksConfig := webhooks.Config{}
+	if err := env.ParseWithOptions(&webhooksConfig, env.Options{Prefix: envPrefixHook}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s webhooks configuration : %s", svcName, err))
+		return
+	}
+	var webhooksSvc webhooks.Service
+	if webhooksConfig.Enabled {
+		webhooksSvc = webhooks.NewService(cpostgres.NewWebhooksRepository(postgres.NewDatabase(db, dbConfig, tracer)), nil, webhooksConfig)
+	}
+
 	grpcServerConfig := server.Config{Port: defSvcGRPCPort}
 	if err := env.ParseWithOptions(&grpcServerConfig, env.Options{Prefix: envPrefixGRPC}); err != nil {
 		log.Printf("failed to load %s gRPC server configuration : %s", svcName, err.Error())
@@ -122,7 +196,13 @@ func main() {
 	}
 	gs := grpcserver.NewServer(ctx, cancel, svcName, grpcServerConfig, registerCertsServiceServer, logger, nil, nil)
 
-	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, httpapi.MakeHandler(svc, logger, cfg.InstanceID), logger)
+	handler, err := newHandler(db, tracer, logger, dbConfig, svc, auditSvc, webhooksSvc, cfg.InstanceID, cfg.TrustedProxies)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to create %s HTTP handler: %s", svcName, err))
+		return
+	}
+
+	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, handler, logger)
 
 	g.Go(func() error {
 		return hs.Start()
@@ -132,6 +212,12 @@ func main() {
 		return gs.Start()
 	})
 
+	if webhooksSvc != nil {
+		g.Go(func() error {
+			return webhooks.Run(ctx, webhooksSvc, webhooksConfig.Interval, logger)
+		})
+	}
+
 	g.Go(func() error {
 		return server.StopSignalHandler(ctx, cancel, logger, svcName, hs, gs)
 	})
@@ -141,13 +227,17 @@ func main() {
 	}
 }
 
-func newService(ctx context.Context, db *sqlx.DB, tracer trace.Tracer, logger *slog.Logger, dbConfig pgClient.Config, config *certs.Config) (certs.Service, error) {
+func newService(ctx context.Context, db *sqlx.DB, tracer trace.Tracer, logger *slog.Logger, dbConfig pgClient.Config, keyStore certs.KeyStore, enc *envelope.Encrypter, auditSvc audit.Service, config *certs.Config) (certs.Service, error) {
 	database := postgres.NewDatabase(db, dbConfig, tracer)
 	repo := cpostgres.NewRepository(database)
-	svc, err := certs.NewService(ctx, repo, config)
+	if enc != nil {
+		repo = envelope.NewRepository(repo, enc)
+	}
+	svc, err := certs.NewService(ctx, repo, keyStore, config)
 	if err != nil {
 		return nil, err
 	}
+	svc = audit.Middleware(svc, auditSvc, logger)
 	svc = api.LoggingMiddleware(svc, logger)
 	counter, latency := prometheus.MakeMetrics(svcName, "api")
 	svc = api.MetricsMiddleware(svc, counter, latency)
@@ -156,6 +246,124 @@ func newService(ctx context.Context, db *sqlx.DB, tracer trace.Tracer, logger *s
 	return svc, nil
 }
 
+// newHandler returns the HTTP handler of the certs API, serving the audit log
+// API below /audit/ and, when they are enabled, the webhooks API below
+// /webhooks/, the ACME API below /acme/, the EST API below /.well-known/est/
+// and the SCEP API at /scep. Callers of the certs, audit log and webhooks
+// APIs authenticate when authentication is enabled.
+func newHandler(db *sqlx.DB, tracer trace.Tracer, logger *slog.Logger, dbConfig pgClient.Config, svc certs.Service, auditSvc audit.Service, webhooksSvc webhooks.Service, instanceID string, trustedProxies []netip.Prefix) (http.Handler, error) {
+	authConfig := auth.Config{}
+	if err := env.ParseWithOptions(&authConfig, env.Options{Prefix: envPrefixAPI}); err != nil {
+		return nil, err
+	}
+	var authn auth.Authenticator
+	if authConfig.Enabled {
+		a, err := auth.New(authConfig, svc)
+		if err != nil {
+			return nil, err
+		}
+		authn = a
+	} else {
+		logger.Warn("Authentication of the certs API is disabled")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", httpapi.MakeHandler(svc, authn, logger, instanceID, trustedProxies))
+	mux.Handle("/audit/", auditapi.MakeHandler(auditSvc, authn, logger))
+	if webhooksSvc != nil {
+		mux.Handle("/webhooks/", webhooksapi.MakeHandler(webhooksSvc, authn, logger))
+	}
+
+	estConfig := est.Config{}
+	if err := env.ParseWithOptions(&estConfig, env.Options{Prefix: envPrefixEST}); err != nil {
+		return nil, err
+	}
+	if estConfig.Enabled {
+		if estConfig.Username == "" || estConfig.Password == "" {
+			return nil, fmt.Errorf("EST requires the %sUSERNAME and %sPASSWORD initial enrollment credentials", envPrefixEST, envPrefixEST)
+		}
+		mux.Handle(est.WellKnownPath+"/", estapi.MakeHandler(est.NewService(svc, estConfig), logger))
+	}
+
+	scepConfig := scep.Config{}
+	if err := env.ParseWithOptions(&scepConfig, env.Options{Prefix: envPrefixSCEP}); err != nil {
+		return nil, err
+	}
+	if scepConfig.Enabled {
+		repo := cpostgres.NewSCEPRepository(postgres.NewDatabase(db, dbConfig, tracer))
+		scepHandler := scepapi.MakeHandler(scep.NewService(repo, svc, scepConfig), authn, logger)
+		for _, path := range []string{"/scep", "/scep/", "/cgi-bin/pkiclient.exe"} {
+			mux.Handle(path, scepHandler)
+		}
+	}
+
+	acmeConfig := acme.Config{}
+	if err := env.ParseWithOptions(&acmeConfig, env.Options{Prefix: envPrefixACME}); err != nil {
+		return nil, err
+	}
+	if !acmeConfig.Enabled {
+		return mux, nil
+	}
+
+	resolver := net.DefaultResolver
+	if acmeConfig.DNSResolver != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, acmeConfig.DNSResolver)
+			},
+		}
+	}
+	validators := map[string]acme.Validator{
+		acme.ChallengeHTTP01: acme.NewHTTP01Validator(&http.Client{Timeout: 10 * time.Second}, acmeConfig.HTTP01Port),
+		acme.ChallengeDNS01:  acme.NewDNS01Validator(resolver),
+	}
+	repo := cpostgres.NewACMERepository(postgres.NewDatabase(db, dbConfig, tracer))
+	acmeSvc := acme.NewService(repo, svc, validators, acmeConfig)
+
+	mux.Handle("/acme/", acmeapi.MakeHandler(acmeSvc, logger, acmeConfig.BaseURL))
+
+	return mux, nil
+}
+
+// newKeyStore returns the key store holding CA private keys. The database
+// signer keeps the keys in the certs table and needs no key store.
+func newKeyStore(signer string) (certs.KeyStore, func(), error) {
+	switch signer {
+	case "", "database":
+		return nil, func() {}, nil
+	case "file":
+		fileConfig := file.Config{}
+		if err := env.ParseWithOptions(&fileConfig, env.Options{Prefix: envPrefixFile}); err != nil {
+			return nil, nil, err
+		}
+		ks, err := file.New(fileConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ks, func() {}, nil
+	case "pkcs11":
+		return newPKCS11KeyStore()
+	default:
+		return nil, nil, fmt.Errorf("unknown signer %q", signer)
+	}
+}
+
+// newEncrypter returns the private key encrypter, or nil when no master key
+// is configured and keys are stored in plaintext.
+func newEncrypter() (*envelope.Encrypter, error) {
+	encConfig := envelope.Config{}
+	if err := env.ParseWithOptions(&encConfig, env.Options{Prefix: envPrefixEnc}); err != nil {
+		return nil, err
+	}
+	if encConfig.MasterKeys == "" {
+		return nil, nil
+	}
+
+	return envelope.New(encConfig)
+}
+
 func initLogger(levelText string) (*slog.Logger, error) {
 	var level slog.Level
 	if err := level.UnmarshalText([]byte(levelText)); err != nil {
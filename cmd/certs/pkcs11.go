@@ -0,0 +1,22 @@
+//go:build pkcs11
+
+package main
+
+import (
+	"github.com/caarlos0/env/v10"
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/signer/pkcs11"
+)
+
+func newPKCS11KeyStore() (certs.KeyStore, func(), error) {
+	p11Config := pkcs11.Config{}
+	if err := env.ParseWithOptions(&p11Config, env.Options{Prefix: envPrefixP11}); err != nil {
+		return nil, nil, err
+	}
+	ks, err := pkcs11.New(p11Config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ks, func() { ks.Close() }, nil
+}
@@ -0,0 +1,13 @@
+//go:build !pkcs11
+
+package main
+
+import (
+	"errors"
+
+	"github.com/hantdev/certs"
+)
+
+func newPKCS11KeyStore() (certs.KeyStore, func(), error) {
+	return nil, nil, errors.New("PKCS#11 support is not compiled in, rebuild with -tags pkcs11")
+}
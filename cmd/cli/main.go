@@ -32,9 +32,13 @@ func main() {
 	}
 	// API commands
 	certsCmd := cli.NewCertsCmd()
+	auditCmd := cli.NewAuditCmd()
+	webhooksCmd := cli.NewWebhooksCmd()
 
 	// Root Commands
 	rootCmd.AddCommand(certsCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(webhooksCmd)
 
 	rootCmd.PersistentFlags().StringVarP(
 		&sdkConf.CertsURL,
@@ -68,6 +72,20 @@ func main() {
 		"Do not check for TLS cert",
 	)
 
+	rootCmd.PersistentFlags().StringVar(
+		&sdkConf.APIKey,
+		"api-key",
+		sdkConf.APIKey,
+		"API key authenticating the requests",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&sdkConf.Token,
+		"token",
+		sdkConf.Token,
+		"Bearer JWT authenticating the requests",
+	)
+
 	rootCmd.PersistentFlags().StringVarP(
 		&cli.ConfigPath,
 		"config",
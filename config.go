@@ -2,23 +2,115 @@ package certs
 
 import (
 	"net"
+	"net/mail"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/hantdev/certs/errors"
 	"gopkg.in/yaml.v2"
 )
 
+var ErrInvalidConfig = errors.New("invalid CA configuration")
+
 type CAConfig struct {
-	CommonName         string   `yaml:"common_name"`
-	Organization       []string `yaml:"organization"`
-	OrganizationalUnit []string `yaml:"organizational_unit"`
-	Country            []string `yaml:"country"`
-	Province           []string `yaml:"province"`
-	Locality           []string `yaml:"locality"`
-	StreetAddress      []string `yaml:"street_address"`
-	PostalCode         []string `yaml:"postal_code"`
-	DNSNames           []string `yaml:"dns_names"`
-	IPAddresses        []string `yaml:"ip_addresses"`
-	ValidityPeriod     string   `yaml:"validity_period"`
+	CommonName         string                      `yaml:"common_name"`
+	Organization       []string                    `yaml:"organization"`
+	OrganizationalUnit []string                    `yaml:"organizational_unit"`
+	Country            []string                    `yaml:"country"`
+	Province           []string                    `yaml:"province"`
+	Locality           []string                    `yaml:"locality"`
+	StreetAddress      []string                    `yaml:"street_address"`
+	PostalCode         []string                    `yaml:"postal_code"`
+	DNSNames           []string                    `yaml:"dns_names"`
+	IPAddresses        []string                    `yaml:"ip_addresses"`
+	ValidityPeriod     string                      `yaml:"validity_period"`
+	KeyAlgorithm       string                      `yaml:"key_algorithm"`
+	KeySize            int                         `yaml:"key_size"`
+	CrossSign          bool                        `yaml:"cross_sign"`
+	PublicURLs         []string                    `yaml:"public_urls"`
+	Root               CASettingsConfig            `yaml:"root"`
+	Intermediate       CASettingsConfig            `yaml:"intermediate"`
+	Issuers            map[string]CASettingsConfig `yaml:"issuers"`
+	Profiles           []Profile                   `yaml:"profiles"`
+	Policy             PolicyConfig                `yaml:"policy"`
+	CRL                CRLConfig                   `yaml:"crl"`
+	OCSP               OCSPConfig                  `yaml:"ocsp"`
+	Approval           ApprovalSettings            `yaml:"approval"`
+	DownloadTokens     TokenConfig                 `yaml:"download_tokens"`
+	Import             struct {
+		Root         *CAImportConfig `yaml:"root"`
+		Intermediate *CAImportConfig `yaml:"intermediate"`
+	} `yaml:"import"`
+}
+
+// CASettingsConfig holds the settings of the root or the intermediate CA, or
+// of a named issuer. Unset values fall back to the top-level settings and the
+// built-in defaults; those of a named issuer to the intermediate CA settings.
+type CASettingsConfig struct {
+	ValidityPeriod          string   `yaml:"validity_period"`
+	KeyAlgorithm            string   `yaml:"key_algorithm"`
+	KeySize                 int      `yaml:"key_size"`
+	MaxPathLen              *int     `yaml:"max_path_len"`
+	PermittedDNSDomains     []string `yaml:"permitted_dns_domains"`
+	ExcludedDNSDomains      []string `yaml:"excluded_dns_domains"`
+	PermittedIPRanges       []string `yaml:"permitted_ip_ranges"`
+	ExcludedIPRanges        []string `yaml:"excluded_ip_ranges"`
+	PermittedEmailAddresses []string `yaml:"permitted_email_addresses"`
+	ExcludedEmailAddresses  []string `yaml:"excluded_email_addresses"`
+	ExtKeyUsages            []string `yaml:"ext_key_usages"`
+	EmailAddress            string   `yaml:"email_address"`
+	RotationThreshold       string   `yaml:"rotation_threshold"`
+	StageThreshold          string   `yaml:"stage_threshold"`
+}
+
+// PolicyConfig holds the issuance policy evaluated before a certificate is signed.
+type PolicyConfig struct {
+	AllowedDomains  []string                      `yaml:"allowed_domains"`
+	DeniedDomains   []string                      `yaml:"denied_domains"`
+	AllowedIPRanges []string                      `yaml:"allowed_ip_ranges"`
+	DeniedIPRanges  []string                      `yaml:"denied_ip_ranges"`
+	RequiredSubject []string                      `yaml:"required_subject"`
+	MaxTTL          string                        `yaml:"max_ttl"`
+	MinRSAKeySize   int                           `yaml:"min_rsa_key_size"`
+	MinECDSAKeySize int                           `yaml:"min_ecdsa_key_size"`
+	Entities        map[string]EntityPolicyConfig `yaml:"entities"`
+}
+
+// EntityPolicyConfig holds the policy of a single entity.
+type EntityPolicyConfig struct {
+	MaxTTL string `yaml:"max_ttl"`
+}
+
+// CRLConfig holds the publication settings of the certificate revocation lists.
+type CRLConfig struct {
+	Validity        string `yaml:"validity"`
+	RefreshInterval string `yaml:"refresh_interval"`
+	DeltaValidity   string `yaml:"delta_validity"`
+}
+
+// OCSPConfig holds the signing settings of the OCSP responses.
+type OCSPConfig struct {
+	NextUpdate         string `yaml:"next_update"`
+	DelegatedResponder bool   `yaml:"delegated_responder"`
+	ResponderValidity  string `yaml:"responder_validity"`
+	Cache              bool   `yaml:"cache"`
+}
+
+// TokenConfig holds how download tokens are signed and how long they are
+// valid. The secret is read from the secret file, the key from the key file.
+type TokenConfig struct {
+	TTL        string `yaml:"ttl"`
+	SecretFile string `yaml:"secret_file"`
+	KeyFile    string `yaml:"key_file"`
+}
+
+// CAImportConfig references an existing CA certificate and its key on disk
+// or in the configured key store.
+type CAImportConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	KeyRef   string `yaml:"key_ref"`
 }
 
 func LoadConfig(filename string) (*Config, error) {
@@ -33,20 +125,465 @@ func LoadConfig(filename string) (*Config, error) {
 	if err := decoder.Decode(&config); err != nil {
 		return nil, err
 	}
+	rootCA, err := config.Import.Root.load(RootCA)
+	if err != nil {
+		return nil, err
+	}
+	intermediateCA, err := config.Import.Intermediate.load(IntermediateCA)
+	if err != nil {
+		return nil, err
+	}
+	// The top-level validity period applies to the root CA.
+	if config.Root.ValidityPeriod == "" {
+		config.Root.ValidityPeriod = config.ValidityPeriod
+	}
+	root, err := config.Root.settings()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("root CA"), err))
+	}
+	intermediate, err := config.Intermediate.settings()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("intermediate CA"), err))
+	}
+	var issuers map[string]CASettings
+	if len(config.Issuers) > 0 {
+		issuers = make(map[string]CASettings, len(config.Issuers))
+	}
+	for name, c := range config.Issuers {
+		if issuers[name], err = c.settings(); err != nil {
+			return nil, errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("issuer "+name), err))
+		}
+	}
+	policy, err := config.Policy.policy()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("policy"), err))
+	}
+	crl, err := config.CRL.settings()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("crl"), err))
+	}
+	ocsp, err := config.OCSP.settings()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("ocsp"), err))
+	}
+	tokens, err := config.DownloadTokens.settings()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("download_tokens"), err))
+	}
+
 	return &Config{
-		CommonName:         config.CommonName,
-		Organization:       config.Organization,
-		OrganizationalUnit: config.OrganizationalUnit,
-		Country:            config.Country,
-		Province:           config.Province,
-		Locality:           config.Locality,
-		StreetAddress:      config.StreetAddress,
-		PostalCode:         config.PostalCode,
-		DNSNames:           config.DNSNames,
-		IPAddresses:        parseIPs(config.IPAddresses),
+		CommonName:           config.CommonName,
+		Organization:         config.Organization,
+		OrganizationalUnit:   config.OrganizationalUnit,
+		Country:              config.Country,
+		Province:             config.Province,
+		Locality:             config.Locality,
+		StreetAddress:        config.StreetAddress,
+		PostalCode:           config.PostalCode,
+		DNSNames:             config.DNSNames,
+		IPAddresses:          parseIPs(config.IPAddresses),
+		ValidityPeriod:       config.ValidityPeriod,
+		KeyAlgorithm:         config.KeyAlgorithm,
+		KeySize:              config.KeySize,
+		CrossSign:            config.CrossSign,
+		PublicURLs:           config.PublicURLs,
+		CRL:                  crl,
+		OCSP:                 ocsp,
+		Root:                 root,
+		Intermediate:         intermediate,
+		Issuers:              issuers,
+		Profiles:             config.Profiles,
+		Policy:               policy,
+		Approval:             config.Approval,
+		DownloadTokens:       tokens,
+		ImportRootCA:         rootCA,
+		ImportIntermediateCA: intermediateCA,
 	}, nil
 }
 
+func (c CASettingsConfig) settings() (CASettings, error) {
+	settings := CASettings{
+		KeyAlgorithm:            c.KeyAlgorithm,
+		KeySize:                 c.KeySize,
+		PermittedDNSDomains:     c.PermittedDNSDomains,
+		ExcludedDNSDomains:      c.ExcludedDNSDomains,
+		PermittedEmailAddresses: c.PermittedEmailAddresses,
+		ExcludedEmailAddresses:  c.ExcludedEmailAddresses,
+		ExtKeyUsages:            c.ExtKeyUsages,
+		EmailAddress:            c.EmailAddress,
+	}
+
+	var err error
+	if settings.ValidityPeriod, err = parseDuration("validity_period", c.ValidityPeriod); err != nil {
+		return CASettings{}, err
+	}
+	if settings.RotationThreshold, err = parseDuration("rotation_threshold", c.RotationThreshold); err != nil {
+		return CASettings{}, err
+	}
+	if settings.StageThreshold, err = parseDuration("stage_threshold", c.StageThreshold); err != nil {
+		return CASettings{}, err
+	}
+	if c.MaxPathLen != nil {
+		if *c.MaxPathLen < 0 {
+			return CASettings{}, errors.New("max_path_len must not be negative")
+		}
+		settings.MaxPathLen = *c.MaxPathLen
+		settings.MaxPathLenZero = *c.MaxPathLen == 0
+	}
+	if settings.PermittedIPRanges, err = parseCIDRs(c.PermittedIPRanges); err != nil {
+		return CASettings{}, err
+	}
+	if settings.ExcludedIPRanges, err = parseCIDRs(c.ExcludedIPRanges); err != nil {
+		return CASettings{}, err
+	}
+
+	return settings, nil
+}
+
+func (c CRLConfig) settings() (CRLSettings, error) {
+	var settings CRLSettings
+	var err error
+	if settings.Validity, err = parseDuration("validity", c.Validity); err != nil {
+		return CRLSettings{}, err
+	}
+	if settings.RefreshInterval, err = parseDuration("refresh_interval", c.RefreshInterval); err != nil {
+		return CRLSettings{}, err
+	}
+	if settings.DeltaValidity, err = parseDuration("delta_validity", c.DeltaValidity); err != nil {
+		return CRLSettings{}, err
+	}
+
+	return settings, nil
+}
+
+func (c OCSPConfig) settings() (OCSPSettings, error) {
+	settings := OCSPSettings{DelegatedResponder: c.DelegatedResponder, Cache: c.Cache}
+	var err error
+	if settings.NextUpdate, err = parseDuration("next_update", c.NextUpdate); err != nil {
+		return OCSPSettings{}, err
+	}
+	if settings.ResponderValidity, err = parseDuration("responder_validity", c.ResponderValidity); err != nil {
+		return OCSPSettings{}, err
+	}
+
+	return settings, nil
+}
+
+func (c TokenConfig) settings() (TokenSettings, error) {
+	var settings TokenSettings
+	var err error
+	if settings.TTL, err = parseDuration("ttl", c.TTL); err != nil {
+		return TokenSettings{}, err
+	}
+	if c.SecretFile != "" {
+		secret, err := os.ReadFile(c.SecretFile)
+		if err != nil {
+			return TokenSettings{}, err
+		}
+		settings.Secret = []byte(strings.TrimSpace(string(secret)))
+	}
+	if c.KeyFile != "" {
+		key, err := os.ReadFile(c.KeyFile)
+		if err != nil {
+			return TokenSettings{}, err
+		}
+		if settings.Key, err = ParsePrivateKey(key); err != nil {
+			return TokenSettings{}, err
+		}
+	}
+
+	return settings, nil
+}
+
+func (c PolicyConfig) policy() (Policy, error) {
+	policy := Policy{
+		AllowedDomains:  c.AllowedDomains,
+		DeniedDomains:   c.DeniedDomains,
+		RequiredSubject: c.RequiredSubject,
+		MinRSAKeySize:   c.MinRSAKeySize,
+		MinECDSAKeySize: c.MinECDSAKeySize,
+	}
+
+	var err error
+	if policy.MaxTTL, err = parseDuration("max_ttl", c.MaxTTL); err != nil {
+		return Policy{}, err
+	}
+	if policy.AllowedIPRanges, err = parseCIDRs(c.AllowedIPRanges); err != nil {
+		return Policy{}, err
+	}
+	if policy.DeniedIPRanges, err = parseCIDRs(c.DeniedIPRanges); err != nil {
+		return Policy{}, err
+	}
+	if len(c.Entities) > 0 {
+		policy.EntityMaxTTL = make(map[string]time.Duration, len(c.Entities))
+	}
+	for entityID, entity := range c.Entities {
+		ttl, err := parseDuration("max_ttl", entity.MaxTTL)
+		if err != nil {
+			return Policy{}, errors.Wrap(errors.New("entity "+entityID), err)
+		}
+		policy.EntityMaxTTL[entityID] = ttl
+	}
+
+	return policy, nil
+}
+
+// withDefaults fills the unset root and intermediate CA settings from the
+// top-level settings and the built-in defaults, and the unset settings of the
+// named issuers from the intermediate CA settings.
+func (c Config) withDefaults() Config {
+	c.Root = c.Root.withDefaults(c, RootCAValidityPeriod, rCertExpiryThreshold)
+	if c.Root.StageThreshold == 0 {
+		c.Root.StageThreshold = rCertStageThreshold
+	}
+	c.Intermediate = c.Intermediate.withDefaults(c, IntermediateCAVAlidityPeriod, iCertExpiryThreshold)
+	if c.Issuers != nil {
+		issuers := make(map[string]CASettings, len(c.Issuers))
+		for name, settings := range c.Issuers {
+			issuers[name] = settings.inherit(c.Intermediate)
+		}
+		c.Issuers = issuers
+	}
+	c.Policy = c.Policy.withDefaults()
+	c.CRL = c.CRL.withDefaults()
+	c.OCSP = c.OCSP.withDefaults()
+	c.DownloadTokens = c.DownloadTokens.withDefaults()
+	if c.PublicURLs != nil {
+		urls := make([]string, len(c.PublicURLs))
+		for i, u := range c.PublicURLs {
+			urls[i] = strings.TrimSuffix(u, "/")
+		}
+		c.PublicURLs = urls
+	}
+
+	return c
+}
+
+func (s CASettings) withDefaults(c Config, validity, threshold time.Duration) CASettings {
+	if s.ValidityPeriod == 0 {
+		s.ValidityPeriod = validity
+	}
+	if s.RotationThreshold == 0 {
+		s.RotationThreshold = threshold
+	}
+	if s.KeyAlgorithm == "" {
+		s.KeyAlgorithm = c.KeyAlgorithm
+		if s.KeySize == 0 {
+			s.KeySize = c.KeySize
+		}
+	}
+	if s.EmailAddress == "" {
+		s.EmailAddress = emailAddress
+	}
+	if s.ExtKeyUsages == nil {
+		s.ExtKeyUsages = []string{"server_auth", "client_auth"}
+	}
+
+	return s
+}
+
+// inherit fills the unset settings from the parent settings.
+func (s CASettings) inherit(parent CASettings) CASettings {
+	if s.ValidityPeriod == 0 {
+		s.ValidityPeriod = parent.ValidityPeriod
+	}
+	if s.RotationThreshold == 0 {
+		s.RotationThreshold = parent.RotationThreshold
+	}
+	if s.KeyAlgorithm == "" {
+		s.KeyAlgorithm = parent.KeyAlgorithm
+		if s.KeySize == 0 {
+			s.KeySize = parent.KeySize
+		}
+	}
+	if s.MaxPathLen == 0 && !s.MaxPathLenZero {
+		s.MaxPathLen = parent.MaxPathLen
+		s.MaxPathLenZero = parent.MaxPathLenZero
+	}
+	inheritSlice(&s.PermittedDNSDomains, parent.PermittedDNSDomains)
+	inheritSlice(&s.ExcludedDNSDomains, parent.ExcludedDNSDomains)
+	inheritSlice(&s.PermittedIPRanges, parent.PermittedIPRanges)
+	inheritSlice(&s.ExcludedIPRanges, parent.ExcludedIPRanges)
+	inheritSlice(&s.PermittedEmailAddresses, parent.PermittedEmailAddresses)
+	inheritSlice(&s.ExcludedEmailAddresses, parent.ExcludedEmailAddresses)
+	inheritSlice(&s.ExtKeyUsages, parent.ExtKeyUsages)
+	if s.EmailAddress == "" {
+		s.EmailAddress = parent.EmailAddress
+	}
+
+	return s
+}
+
+func inheritSlice[T any](field *[]T, parent []T) {
+	if *field == nil {
+		*field = parent
+	}
+}
+
+// issuerSettings returns the settings of the named issuer.
+func (c Config) issuerSettings(name string) CASettings {
+	if settings, ok := c.Issuers[issuerName(name)]; ok {
+		return settings
+	}
+
+	return c.Intermediate
+}
+
+// validate checks the root and intermediate CA settings, the profiles and the policy for consistency.
+func (c Config) validate() error {
+	if err := c.Root.validate(); err != nil {
+		return errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("root CA"), err))
+	}
+	if err := c.Intermediate.validate(); err != nil {
+		return errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("intermediate CA"), err))
+	}
+	switch {
+	case c.Root.StageThreshold < c.Root.RotationThreshold:
+		return errors.Wrap(ErrInvalidConfig, errors.New("root stage_threshold must not be shorter than rotation_threshold"))
+	case c.Root.StageThreshold >= c.Root.ValidityPeriod:
+		return errors.Wrap(ErrInvalidConfig, errors.New("root stage_threshold must be shorter than validity_period"))
+	case c.Intermediate.StageThreshold != 0:
+		return errors.Wrap(ErrInvalidConfig, errors.New("stage_threshold is only supported for the root CA"))
+	case c.Intermediate.ValidityPeriod > c.Root.ValidityPeriod:
+		return errors.Wrap(ErrInvalidConfig, errors.New("intermediate validity_period must not exceed the root validity_period"))
+	case c.Root.MaxPathLenZero:
+		return errors.Wrap(ErrInvalidConfig, errors.New("root max_path_len must allow the intermediate CA"))
+	case c.Root.MaxPathLen > 0 && c.Intermediate.MaxPathLen >= c.Root.MaxPathLen:
+		return errors.Wrap(ErrInvalidConfig, errors.New("intermediate max_path_len must be lower than the root max_path_len"))
+	}
+	for name, settings := range c.Issuers {
+		if err := c.validateIssuer(settings); err != nil {
+			return errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("issuer "+name), err))
+		}
+	}
+	for _, u := range c.PublicURLs {
+		if err := validatePublicURL(u); err != nil {
+			return errors.Wrap(ErrInvalidConfig, err)
+		}
+	}
+	if err := c.Policy.validate(); err != nil {
+		return errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("policy"), err))
+	}
+	if err := c.CRL.validate(); err != nil {
+		return errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("crl"), err))
+	}
+	if err := c.OCSP.validate(); err != nil {
+		return errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("ocsp"), err))
+	}
+	if err := c.DownloadTokens.validate(); err != nil {
+		return errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("download_tokens"), err))
+	}
+	names := make(map[string]bool, len(c.Profiles))
+	for _, p := range c.Profiles {
+		if err := p.validate(); err != nil {
+			return errors.Wrap(ErrInvalidConfig, errors.Wrap(errors.New("profile "+p.Name), err))
+		}
+		if names[p.Name] {
+			return errors.Wrap(ErrInvalidConfig, errors.New("duplicate profile "+p.Name))
+		}
+		names[p.Name] = true
+	}
+
+	return nil
+}
+
+// validateIssuer checks the settings of a named issuer against the root CA settings.
+func (c Config) validateIssuer(s CASettings) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	switch {
+	case s.StageThreshold != 0:
+		return errors.New("stage_threshold is only supported for the root CA")
+	case s.ValidityPeriod > c.Root.ValidityPeriod:
+		return errors.New("validity_period must not exceed the root validity_period")
+	case c.Root.MaxPathLen > 0 && s.MaxPathLen >= c.Root.MaxPathLen:
+		return errors.New("max_path_len must be lower than the root max_path_len")
+	}
+
+	return nil
+}
+
+func (s CASettings) validate() error {
+	if err := validateKeyAlgorithm(s.KeyAlgorithm, s.KeySize); err != nil {
+		return err
+	}
+	if s.ValidityPeriod <= 0 {
+		return errors.New("validity_period must be positive")
+	}
+	if s.RotationThreshold <= 0 || s.RotationThreshold >= s.ValidityPeriod {
+		return errors.New("rotation_threshold must be positive and shorter than validity_period")
+	}
+	for _, domain := range append(s.PermittedDNSDomains, s.ExcludedDNSDomains...) {
+		if domain == "" {
+			return errors.New("name constraint domains must not be empty")
+		}
+	}
+	for _, email := range append(s.PermittedEmailAddresses, s.ExcludedEmailAddresses...) {
+		if email == "" {
+			return errors.New("name constraint email addresses must not be empty")
+		}
+	}
+	for _, eku := range s.ExtKeyUsages {
+		if _, ok := extKeyUsages[eku]; !ok {
+			return errors.New("unsupported extended key usage " + eku)
+		}
+	}
+	if _, err := mail.ParseAddress(s.EmailAddress); err != nil {
+		return errors.Wrap(errors.New("invalid email_address"), err)
+	}
+
+	return nil
+}
+
+func (c *CAImportConfig) load(certType CertType) (*CAImport, error) {
+	if c == nil || c.CertFile == "" {
+		return nil, nil
+	}
+
+	cert, err := os.ReadFile(c.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	ca := &CAImport{
+		Type:        certType,
+		Certificate: cert,
+		KeyRef:      c.KeyRef,
+	}
+	if c.KeyFile != "" {
+		if ca.Key, err = os.ReadFile(c.KeyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return ca, nil
+}
+
+func parseDuration(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Wrap(errors.New("invalid "+name), err)
+	}
+
+	return d, nil
+}
+
+func parseCIDRs(ranges []string) ([]*net.IPNet, error) {
+	var ipNets []*net.IPNet
+	for _, r := range ranges {
+		_, ipNet, err := net.ParseCIDR(r)
+		if err != nil {
+			return nil, errors.Wrap(errors.New("invalid IP range"), err)
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+
+	return ipNets, nil
+}
+
 func parseIPs(ipStrings []string) []net.IP {
 	var ips []net.IP
 	for _, ipString := range ipStrings {
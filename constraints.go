@@ -0,0 +1,140 @@
+package certs
+
+import (
+	"crypto/x509"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// Constraint rules reported by PolicyError.
+const (
+	RuleNameConstraints   = "name_constraints"
+	RuleIssuerExtKeyUsage = "issuer_ext_key_usage"
+)
+
+// issuerChain returns the issuer certificate followed by the root certificates
+// that signed it, i.e. the CA certificates whose constraints apply to the
+// certificates issued by the issuer.
+func (s *service) issuerChain(issuer *CA) []*x509.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	chain := []*x509.Certificate{issuer.Certificate}
+	for _, root := range s.roots {
+		if issuer.Certificate.CheckSignatureFrom(root.Certificate) == nil {
+			chain = append(chain, root.Certificate)
+		}
+	}
+
+	return chain
+}
+
+// allowsSubordinateCA rejects CAs signed by a parent CA whose path length
+// constraint does not allow any CA below it.
+func allowsSubordinateCA(parent *x509.Certificate) error {
+	if parent.MaxPathLen == 0 && parent.MaxPathLenZero {
+		return errors.New("CA " + parent.Subject.CommonName + " does not allow subordinate CAs")
+	}
+
+	return nil
+}
+
+// checkConstraints rejects the certificate template if it violates the name
+// constraints or the extended key usages of a CA certificate in the chain.
+// Clients validating the chain would reject such a certificate anyway.
+func checkConstraints(chain []*x509.Certificate, template *x509.Certificate) error {
+	for _, ca := range chain {
+		for _, name := range template.DNSNames {
+			if !permitted(name, ca.PermittedDNSDomains, ca.ExcludedDNSDomains, matchDNSConstraint) {
+				return policyError(nil, RuleNameConstraints, "dns_names", name, "DNS name is not permitted by the name constraints of CA "+ca.Subject.CommonName)
+			}
+		}
+		for _, ip := range template.IPAddresses {
+			if !permitted(ip, ca.PermittedIPRanges, ca.ExcludedIPRanges, matchIPConstraint) {
+				return policyError(nil, RuleNameConstraints, "ip_addresses", ip.String(), "IP address is not permitted by the name constraints of CA "+ca.Subject.CommonName)
+			}
+		}
+		for _, email := range template.EmailAddresses {
+			if !permitted(email, ca.PermittedEmailAddresses, ca.ExcludedEmailAddresses, matchEmailConstraint) {
+				return policyError(nil, RuleNameConstraints, "email_addresses", email, "email address is not permitted by the name constraints of CA "+ca.Subject.CommonName)
+			}
+		}
+		if len(ca.ExtKeyUsage) == 0 || slices.Contains(ca.ExtKeyUsage, x509.ExtKeyUsageAny) {
+			continue
+		}
+		for _, eku := range template.ExtKeyUsage {
+			if !slices.Contains(ca.ExtKeyUsage, eku) {
+				return policyError(nil, RuleIssuerExtKeyUsage, "ext_key_usages", extKeyUsageName(eku), "extended key usage is not allowed by CA "+ca.Subject.CommonName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// permitted reports whether the name matches none of the excluded constraints
+// and, if there are any, one of the permitted ones.
+func permitted[N, C any](name N, permitted, excluded []C, match func(N, C) bool) bool {
+	for _, c := range excluded {
+		if match(name, c) {
+			return false
+		}
+	}
+	if len(permitted) == 0 {
+		return true
+	}
+	for _, c := range permitted {
+		if match(name, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchDNSConstraint matches the name against a DNS name constraint as
+// defined in RFC 5280: "example.com" matches the domain and its subdomains
+// and ".example.com" only the subdomains.
+func matchDNSConstraint(name, constraint string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	constraint = strings.ToLower(constraint)
+	switch {
+	case constraint == "":
+		return true
+	case strings.HasPrefix(constraint, "."):
+		return strings.HasSuffix(name, constraint)
+	}
+
+	return name == constraint || strings.HasSuffix(name, "."+constraint)
+}
+
+func matchIPConstraint(ip net.IP, constraint *net.IPNet) bool {
+	return constraint.Contains(ip)
+}
+
+// matchEmailConstraint matches the address against an email constraint,
+// which is either a full mailbox or a domain matched like a DNS constraint.
+func matchEmailConstraint(email, constraint string) bool {
+	if strings.Contains(constraint, "@") {
+		return strings.EqualFold(email, constraint)
+	}
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return false
+	}
+
+	return matchDNSConstraint(email[i+1:], constraint)
+}
+
+func extKeyUsageName(eku x509.ExtKeyUsage) string {
+	for name, usage := range extKeyUsages {
+		if usage == eku {
+			return name
+		}
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,318 @@
+package certs
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// Default CRL publication settings.
+const (
+	crlValidity        = 24 * time.Hour
+	crlRefreshInterval = 12 * time.Hour
+)
+
+// CADeltaCRLPath is the path of the delta CRL of a CA below the public base URLs.
+const CADeltaCRLPath = "/certs/ca/%s/delta-crl"
+
+var ErrDeltaCRLDisabled = errors.New("delta CRLs are not enabled")
+
+var (
+	oidDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+	oidFreshestCRL       = asn1.ObjectIdentifier{2, 5, 29, 46}
+)
+
+// distributionPoint is the DistributionPoint of RFC 5280 holding a full name only.
+type distributionPoint struct {
+	DistributionPoint distributionPointName `asn1:"optional,tag:0"`
+}
+
+type distributionPointName struct {
+	FullName []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// publishedCRLs holds the complete and the delta CRL last published by a CA.
+type publishedCRLs struct {
+	complete *CRL
+	delta    *CRL
+}
+
+// GenerateCACRL retrieves the published complete or delta CRL of the root or
+// intermediate CA with the given serial number.
+func (s *service) GenerateCACRL(ctx context.Context, serialNumber string, delta bool) (CRL, error) {
+	ca := s.caBySerial(serialNumber)
+	if ca == nil {
+		return CRL{}, ErrCANotFound
+	}
+
+	return s.publishedCRL(ctx, ca, delta)
+}
+
+// PEM returns the PEM encoded CRL.
+func (c CRL) PEM() []byte {
+	return encodeCRL(c.DER)
+}
+
+// publishedCRL returns the complete or delta CRL of the CA. The complete CRL
+// is regenerated once the refresh interval has passed and the delta CRL
+// halfway through its validity. Revocations invalidate the delta CRL or, with
+// delta CRLs disabled, the complete CRL, so that they are regenerated as well.
+func (s *service) publishedCRL(ctx context.Context, ca *CA, delta bool) (CRL, error) {
+	if ca.Signer == nil {
+		return CRL{}, ErrCAKeyUnavailable
+	}
+	if delta && s.config.CRL.DeltaValidity == 0 {
+		return CRL{}, ErrDeltaCRLDisabled
+	}
+
+	s.crlMu.Lock()
+	defer s.crlMu.Unlock()
+
+	published := s.crls[ca.SerialNumber]
+	if published.complete == nil || time.Since(published.complete.ThisUpdate) >= s.config.CRL.RefreshInterval {
+		if err := s.publishCRL(ctx, ca); err != nil {
+			return CRL{}, err
+		}
+		published = s.crls[ca.SerialNumber]
+	}
+	if !delta {
+		return *published.complete, nil
+	}
+	if published.delta == nil || time.Since(published.delta.ThisUpdate) >= s.config.CRL.DeltaValidity/2 {
+		crl, err := s.generateCRL(ctx, ca, published.complete)
+		if err != nil {
+			return CRL{}, err
+		}
+		published.delta = crl
+		s.crls[ca.SerialNumber] = published
+	}
+
+	return *published.delta, nil
+}
+
+// publishCRL generates and publishes the complete CRL of the CA, dropping the
+// delta CRL based on the previous one. The caller must hold crlMu.
+func (s *service) publishCRL(ctx context.Context, ca *CA) error {
+	crl, err := s.generateCRL(ctx, ca, nil)
+	if err != nil {
+		return err
+	}
+	s.crls[ca.SerialNumber] = publishedCRLs{complete: crl}
+
+	return nil
+}
+
+// publishCRLs regenerates the complete CRLs of all CAs every refresh interval
+// until the context is done. A CRL failing to regenerate is retried when it
+// is requested.
+func (s *service) publishCRLs(ctx context.Context) {
+	ticker := time.NewTicker(s.config.CRL.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, ca := range s.crlIssuers() {
+				s.crlMu.Lock()
+				_ = s.publishCRL(ctx, ca)
+				s.crlMu.Unlock()
+			}
+		}
+	}
+}
+
+// crlIssuers returns the active and retired CAs that sign CRLs.
+func (s *service) crlIssuers() []*CA {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var cas []*CA
+	for _, ca := range s.roots {
+		if ca.Signer != nil && !ca.Staged {
+			cas = append(cas, ca)
+		}
+	}
+	for _, ca := range s.intermediates {
+		if ca.Signer != nil {
+			cas = append(cas, ca)
+		}
+	}
+
+	return cas
+}
+
+// invalidateCRLs drops the published CRLs that no longer reflect the
+// revocation status of the certificate. Certificates issued before named
+// issuers were introduced have no issuer serial and affect every CA.
+func (s *service) invalidateCRLs(cert Certificate) {
+	s.crlMu.Lock()
+	defer s.crlMu.Unlock()
+
+	for serial, published := range s.crls {
+		if cert.IssuerSerial != "" && serial != cert.IssuerSerial {
+			continue
+		}
+		if s.config.CRL.DeltaValidity == 0 {
+			delete(s.crls, serial)
+			continue
+		}
+		published.delta = nil
+		s.crls[serial] = published
+	}
+}
+
+// generateCRL creates the complete CRL of the CA or, if the complete CRL it is
+// based on is given, the delta CRL listing the revocation changes since then.
+// Complete and delta CRLs share the CRL number sequence of the CA.
+func (s *service) generateCRL(ctx context.Context, ca *CA, base *CRL) (*CRL, error) {
+	// Certificates issued before named issuers were introduced belong to the default issuer.
+	issuerSerials := []string{ca.SerialNumber}
+	if ca.Name == DefaultIssuer {
+		issuerSerials = append(issuerSerials, "")
+	}
+
+	// CRL times are encoded with a precision of seconds.
+	thisUpdate := time.Now().Truncate(time.Second)
+	validity := s.config.CRL.Validity
+	var revokedCerts []Certificate
+	var err error
+	if base == nil {
+		revokedCerts, err = s.repo.ListRevokedCerts(ctx, issuerSerials...)
+	} else {
+		validity = s.config.CRL.DeltaValidity
+		revokedCerts, err = s.repo.ListRevocationChanges(ctx, base.ThisUpdate, issuerSerials...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries, err := revocationEntries(revokedCerts)
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := s.repo.NextCRLNumber(ctx, ca.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+	crl := &CRL{
+		Number:     number,
+		ThisUpdate: thisUpdate,
+		NextUpdate: thisUpdate.Add(validity),
+	}
+	template := &x509.RevocationList{
+		Number:                    new(big.Int).SetUint64(number),
+		ThisUpdate:                crl.ThisUpdate,
+		NextUpdate:                crl.NextUpdate,
+		RevokedCertificateEntries: entries,
+	}
+	switch {
+	case base != nil:
+		crl.BaseNumber = base.Number
+		ext, err := deltaCRLIndicator(base.Number)
+		if err != nil {
+			return nil, err
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, ext)
+	case s.config.CRL.DeltaValidity > 0 && len(s.config.PublicURLs) > 0:
+		ext, err := s.config.freshestCRL(ca)
+		if err != nil {
+			return nil, err
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, ext)
+	}
+
+	if crl.DER, err = x509.CreateRevocationList(rand.Reader, template, ca.Certificate, ca.Signer); err != nil {
+		return nil, err
+	}
+
+	return crl, nil
+}
+
+// revocationEntries returns the CRL entries of the revoked, held and released certificates.
+func revocationEntries(revokedCerts []Certificate) ([]x509.RevocationListEntry, error) {
+	entries := make([]x509.RevocationListEntry, len(revokedCerts))
+	for i, cert := range revokedCerts {
+		serialNumber := new(big.Int)
+		serialNumber.SetString(cert.SerialNumber, 10)
+		entries[i] = x509.RevocationListEntry{
+			SerialNumber:   serialNumber,
+			RevocationTime: cert.RevocationTime,
+			ReasonCode:     int(cert.RevocationReason),
+		}
+		if !cert.InvalidityDate.IsZero() {
+			ext, err := invalidityDateExtension(cert.InvalidityDate)
+			if err != nil {
+				return nil, err
+			}
+			entries[i].ExtraExtensions = []pkix.Extension{ext}
+		}
+	}
+
+	return entries, nil
+}
+
+// deltaCRLIndicator returns the critical extension marking a delta CRL and
+// holding the number of the complete CRL it is based on.
+func deltaCRLIndicator(baseNumber uint64) (pkix.Extension, error) {
+	value, err := asn1.Marshal(new(big.Int).SetUint64(baseNumber))
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: oidDeltaCRLIndicator, Critical: true, Value: value}, nil
+}
+
+// freshestCRL returns the Freshest CRL extension pointing relying parties
+// to the delta CRL of the CA at the public URLs.
+func (c Config) freshestCRL(ca *CA) (pkix.Extension, error) {
+	points := make([]distributionPoint, len(c.PublicURLs))
+	for i, base := range c.PublicURLs {
+		uri := base + fmt.Sprintf(CADeltaCRLPath, ca.SerialNumber)
+		points[i].DistributionPoint.FullName = []asn1.RawValue{{Tag: 6, Class: asn1.ClassContextSpecific, Bytes: []byte(uri)}}
+	}
+	value, err := asn1.Marshal(points)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: oidFreshestCRL, Value: value}, nil
+}
+
+// withDefaults fills the unset CRL settings with the built-in defaults.
+func (c CRLSettings) withDefaults() CRLSettings {
+	if c.Validity == 0 {
+		c.Validity = crlValidity
+	}
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = min(crlRefreshInterval, c.Validity)
+	}
+
+	return c
+}
+
+func (c CRLSettings) validate() error {
+	switch {
+	case c.Validity <= 0:
+		return errors.New("validity must be positive")
+	case c.RefreshInterval <= 0 || c.RefreshInterval > c.Validity:
+		return errors.New("refresh_interval must be positive and not exceed validity")
+	case c.DeltaValidity < 0 || c.DeltaValidity > c.RefreshInterval:
+		return errors.New("delta_validity must not be negative or exceed refresh_interval")
+	}
+
+	return nil
+}
+
+func encodeCRL(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
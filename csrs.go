@@ -0,0 +1,247 @@
+package certs
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"slices"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/uuid"
+)
+
+// CSRStatus is the approval status of a submitted CSR.
+type CSRStatus string
+
+// Approval statuses of a submitted CSR. An approved CSR is issuing while its
+// certificate is signed. An approved CSR whose certificate could not be
+// issued is left approved, so it can be approved again with other settings
+// or rejected.
+const (
+	CSRPending  CSRStatus = "pending"
+	CSRApproved CSRStatus = "approved"
+	CSRIssuing  CSRStatus = "issuing"
+	CSRRejected CSRStatus = "rejected"
+	CSRIssued   CSRStatus = "issued"
+)
+
+// csrIssuingTimeout is how long after its issuance started a CSR may stay
+// issuing. A CSR issuing for longer was left behind by a failed request, so
+// it can be approved again or rejected.
+const csrIssuingTimeout = 10 * time.Minute
+
+var (
+	ErrCSRNotPending    = errors.New("CSR is not pending approval")
+	ErrInvalidCSRStatus = errors.New("invalid CSR status, expected pending, approved, issuing, rejected or issued")
+)
+
+// ApprovalSettings determines which submitted CSRs wait for approval. Unless
+// approval is manual, every CSR is approved when it is submitted.
+type ApprovalSettings struct {
+	Manual bool `yaml:"manual"`
+	// AutoApproveEntities and AutoApproveProfiles list the entities and the
+	// profiles whose CSRs are approved when submitted even if approval is manual.
+	AutoApproveEntities []string `yaml:"auto_approve_entities"`
+	AutoApproveProfiles []string `yaml:"auto_approve_profiles"`
+}
+
+// autoApproves reports whether a CSR of the entity using the profile is
+// approved when it is submitted.
+func (a ApprovalSettings) autoApproves(entityID, profile string) bool {
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	return !a.Manual || slices.Contains(a.AutoApproveEntities, entityID) || slices.Contains(a.AutoApproveProfiles, profile)
+}
+
+// Validate reports whether the status is a known CSR status.
+func (s CSRStatus) Validate() error {
+	switch s {
+	case CSRPending, CSRApproved, CSRIssuing, CSRRejected, CSRIssued:
+		return nil
+	default:
+		return ErrInvalidCSRStatus
+	}
+}
+
+// SubmitCSR validates the CSR and the requested issuer and profile and stores
+// the CSR as pending. Auto-approved CSRs are issued right away.
+func (s *service) SubmitCSR(ctx context.Context, csr CSR) (CSR, error) {
+	if _, err := parseCSR(csr.CSR); err != nil {
+		return CSR{}, err
+	}
+	if _, err := s.issuer(csr.Issuer); err != nil {
+		return CSR{}, err
+	}
+	if _, err := s.profile(ctx, csr.Profile); err != nil {
+		return CSR{}, err
+	}
+
+	id, err := uuid.New().ID()
+	if err != nil {
+		return CSR{}, errors.Wrap(ErrCreateEntity, err)
+	}
+	now := time.Now().UTC()
+	csr.ID = id
+	csr.Status = CSRPending
+	csr.PrivateKey = nil
+	csr.Reason = ""
+	csr.SerialNumber = ""
+	csr.SubmittedAt = now
+	csr.UpdatedAt = now
+	if err := s.repo.CreateCSR(ctx, csr); err != nil {
+		return CSR{}, errors.Wrap(ErrCreateEntity, err)
+	}
+
+	if !s.config.Approval.autoApproves(csr.EntityID, csr.Profile) {
+		return csr, nil
+	}
+
+	return s.approveCSR(ctx, csr, CSRPending)
+}
+
+// ViewCSR retrieves a submitted CSR.
+func (s *service) ViewCSR(ctx context.Context, id string) (CSR, error) {
+	csr, err := s.repo.RetrieveCSR(ctx, id)
+	if err != nil {
+		if errors.Contains(err, ErrNotFound) {
+			return CSR{}, ErrNotFound
+		}
+		return CSR{}, errors.Wrap(ErrViewEntity, err)
+	}
+
+	return csr, nil
+}
+
+// ListCSRs lists the submitted CSRs, the most recent first.
+func (s *service) ListCSRs(ctx context.Context, pm PageMetadata) (CSRPage, error) {
+	if pm.Status != "" {
+		if err := pm.Status.Validate(); err != nil {
+			return CSRPage{}, errors.Wrap(ErrMalformedEntity, err)
+		}
+	}
+	page, err := s.repo.ListCSRs(ctx, pm)
+	if err != nil {
+		return CSRPage{}, errors.Wrap(ErrViewEntity, err)
+	}
+
+	return page, nil
+}
+
+// ApproveCSR approves a pending CSR, or an approved or stale issuing one
+// whose certificate could not be issued, and issues its certificate.
+func (s *service) ApproveCSR(ctx context.Context, id, ttl, profile string) (CSR, error) {
+	csr, err := s.ViewCSR(ctx, id)
+	if err != nil {
+		return CSR{}, err
+	}
+	status := csr.Status
+	if !csr.open() {
+		return CSR{}, ErrCSRNotPending
+	}
+	if ttl != "" {
+		csr.TTL = ttl
+	}
+	if profile != "" {
+		csr.Profile = profile
+	}
+
+	return s.approveCSR(ctx, csr, status)
+}
+
+// RejectCSR rejects a pending, approved or stale issuing CSR.
+func (s *service) RejectCSR(ctx context.Context, id, reason string) (CSR, error) {
+	csr, err := s.ViewCSR(ctx, id)
+	if err != nil {
+		return CSR{}, err
+	}
+	status := csr.Status
+	if !csr.open() {
+		return CSR{}, ErrCSRNotPending
+	}
+	csr.Status = CSRRejected
+	csr.Reason = reason
+	csr.UpdatedAt = time.Now().UTC()
+	if err := s.updateCSR(ctx, csr, status); err != nil {
+		return CSR{}, err
+	}
+
+	return csr, nil
+}
+
+// open reports whether the CSR can still be approved or rejected. An issuing
+// CSR can once its issuance, which its update time records, timed out.
+func (csr CSR) open() bool {
+	switch csr.Status {
+	case CSRPending, CSRApproved:
+		return true
+	case CSRIssuing:
+		return time.Since(csr.UpdatedAt) > csrIssuingTimeout
+	default:
+		return false
+	}
+}
+
+// approveCSR moves the CSR from the status to issuing and issues its
+// certificate. Only one approval can move the CSR out of the status, so
+// concurrent or retried approvals issue a single certificate. A failed
+// issuance leaves the CSR approved.
+func (s *service) approveCSR(ctx context.Context, csr CSR, status CSRStatus) (CSR, error) {
+	csr.Status = CSRIssuing
+	csr.UpdatedAt = time.Now().UTC()
+	if err := s.updateCSR(ctx, csr, status); err != nil {
+		return CSR{}, err
+	}
+
+	cert, err := s.IssueFromCSR(ctx, csr.EntityID, csr.Issuer, csr.Profile, csr.TTL, CSR{CSR: csr.CSR})
+	if err != nil {
+		csr.Status = CSRApproved
+		csr.UpdatedAt = time.Now().UTC()
+		if uerr := s.updateCSR(ctx, csr, CSRIssuing); uerr != nil {
+			return CSR{}, errors.Wrap(err, uerr)
+		}
+		return CSR{}, err
+	}
+
+	csr.Status = CSRIssued
+	csr.SerialNumber = cert.SerialNumber
+	csr.UpdatedAt = time.Now().UTC()
+	if err := s.updateCSR(ctx, csr, CSRIssuing); err != nil {
+		return CSR{}, err
+	}
+
+	return csr, nil
+}
+
+// updateCSR stores the CSR if it still has the status, and returns
+// ErrCSRNotPending if another request changed the status meanwhile.
+func (s *service) updateCSR(ctx context.Context, csr CSR, status CSRStatus) error {
+	if err := s.repo.UpdateCSR(ctx, csr, status); err != nil {
+		if errors.Contains(err, ErrCSRNotPending) {
+			return ErrCSRNotPending
+		}
+		return errors.Wrap(ErrUpdateEntity, err)
+	}
+
+	return nil
+}
+
+// parseCSR parses a PEM encoded CSR and verifies its signature.
+func parseCSR(csrPEM []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, errors.Wrap(ErrMalformedEntity, errors.New("failed to parse CSR PEM"))
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(ErrMalformedEntity, err)
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, errors.Wrap(ErrMalformedEntity, err)
+	}
+
+	return csr, nil
+}
@@ -0,0 +1,221 @@
+// Package envelope implements envelope encryption of private keys at rest.
+//
+// Every private key is encrypted with a freshly generated data encryption key
+// (DEK) using AES-256-GCM. The DEK is then wrapped with a versioned master key
+// and stored next to the ciphertext, so that a dump of the certs table does not
+// disclose any key material without access to the master keys.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hantdev/certs/errors"
+)
+
+const (
+	// BlockType is the PEM block type of an encrypted private key.
+	BlockType = "CERTS ENCRYPTED PRIVATE KEY"
+
+	versionHeader = "Key-Version"
+	dekHeader     = "DEK"
+	keyLen        = 32
+)
+
+var (
+	// ErrNoMasterKey indicates that no master key has been configured.
+	ErrNoMasterKey = errors.New("no master key configured")
+
+	// ErrInvalidMasterKey indicates a malformed master key specification.
+	ErrInvalidMasterKey = errors.New("invalid master key, expected <version>:<base64 encoded 32 byte key>")
+
+	// ErrUnknownKeyVersion indicates that data was encrypted with a master key that is not configured.
+	ErrUnknownKeyVersion = errors.New("unknown master key version")
+
+	// ErrDecrypt indicates that the encrypted key could not be decrypted.
+	ErrDecrypt = errors.New("failed to decrypt private key")
+)
+
+// Config defines the master keys used to wrap data encryption keys.
+type Config struct {
+	// MasterKeys is a comma separated list of <version>:<base64 key> pairs.
+	MasterKeys string `env:"MASTER_KEYS" envDefault:""`
+	// ActiveVersion is the master key version used for new encryptions.
+	// Defaults to the highest configured version.
+	ActiveVersion int `env:"ACTIVE_VERSION" envDefault:"0"`
+}
+
+// Encrypter encrypts and decrypts private keys with versioned master keys.
+type Encrypter struct {
+	active     int
+	masterKeys map[int]cipher.AEAD
+}
+
+// New parses the master keys from the config and returns an Encrypter.
+func New(cfg Config) (*Encrypter, error) {
+	keys := make(map[int][]byte)
+	for _, spec := range strings.Split(cfg.MasterKeys, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		v, k, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, ErrInvalidMasterKey
+		}
+		version, err := strconv.Atoi(v)
+		if err != nil || version <= 0 {
+			return nil, ErrInvalidMasterKey
+		}
+		key, err := base64.StdEncoding.DecodeString(k)
+		if err != nil || len(key) != keyLen {
+			return nil, ErrInvalidMasterKey
+		}
+		keys[version] = key
+	}
+
+	return NewWithKeys(keys, cfg.ActiveVersion)
+}
+
+// NewWithKeys returns an Encrypter for the given master keys indexed by version.
+func NewWithKeys(keys map[int][]byte, active int) (*Encrypter, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoMasterKey
+	}
+
+	enc := &Encrypter{masterKeys: make(map[int]cipher.AEAD, len(keys))}
+	versions := make([]int, 0, len(keys))
+	for version, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidMasterKey, err)
+		}
+		enc.masterKeys[version] = aead
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	enc.active = versions[len(versions)-1]
+	if active != 0 {
+		if _, ok := enc.masterKeys[active]; !ok {
+			return nil, ErrUnknownKeyVersion
+		}
+		enc.active = active
+	}
+
+	return enc, nil
+}
+
+// ActiveVersion returns the master key version used for new encryptions.
+func (e *Encrypter) ActiveVersion() int {
+	return e.active
+}
+
+// Encrypt encrypts the plaintext with a new data encryption key wrapped by the
+// active master key. The associated data binds the ciphertext to its record.
+func (e *Encrypter) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	dek := make([]byte, keyLen)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+
+	dekAEAD, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(dekAEAD, plaintext, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedDEK, err := seal(e.masterKeys[e.active], dek, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type: BlockType,
+		Headers: map[string]string{
+			versionHeader: strconv.Itoa(e.active),
+			dekHeader:     base64.StdEncoding.EncodeToString(wrappedDEK),
+		},
+		Bytes: ciphertext,
+	}), nil
+}
+
+// Decrypt unwraps the data encryption key and decrypts the private key.
+// Data that has not been encrypted is returned unchanged.
+func (e *Encrypter) Decrypt(data, associatedData []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	block, _ := pem.Decode(data)
+	version, err := strconv.Atoi(block.Headers[versionHeader])
+	if err != nil {
+		return nil, errors.Wrap(ErrDecrypt, err)
+	}
+	masterKey, ok := e.masterKeys[version]
+	if !ok {
+		return nil, ErrUnknownKeyVersion
+	}
+
+	wrappedDEK, err := base64.StdEncoding.DecodeString(block.Headers[dekHeader])
+	if err != nil {
+		return nil, errors.Wrap(ErrDecrypt, err)
+	}
+	dek, err := open(masterKey, wrappedDEK, associatedData)
+	if err != nil {
+		return nil, errors.Wrap(ErrDecrypt, err)
+	}
+
+	dekAEAD, err := newAEAD(dek)
+	if err != nil {
+		return nil, errors.Wrap(ErrDecrypt, err)
+	}
+	plaintext, err := open(dekAEAD, block.Bytes, associatedData)
+	if err != nil {
+		return nil, errors.Wrap(ErrDecrypt, err)
+	}
+
+	return plaintext, nil
+}
+
+// IsEncrypted reports whether data holds an encrypted private key.
+func IsEncrypted(data []byte) bool {
+	block, _ := pem.Decode(data)
+	return block != nil && block.Type == BlockType
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, associatedData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, associatedData)
+}
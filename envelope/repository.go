@@ -0,0 +1,147 @@
+package envelope
+
+import (
+	"context"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+const defBatchSize = 100
+
+var _ certs.Repository = (*repository)(nil)
+
+// repository encrypts private keys before they reach the underlying
+// repository and decrypts them when they are read back.
+type repository struct {
+	certs.Repository
+	enc *Encrypter
+}
+
+// NewRepository wraps the repository with envelope encryption of private keys.
+func NewRepository(repo certs.Repository, enc *Encrypter) certs.Repository {
+	return &repository{
+		Repository: repo,
+		enc:        enc,
+	}
+}
+
+func (r *repository) CreateCert(ctx context.Context, cert certs.Certificate) error {
+	cert, err := r.encrypt(cert)
+	if err != nil {
+		return errors.Wrap(certs.ErrCreateEntity, err)
+	}
+
+	return r.Repository.CreateCert(ctx, cert)
+}
+
+func (r *repository) RetrieveCert(ctx context.Context, serialNumber string) (certs.Certificate, error) {
+	cert, err := r.Repository.RetrieveCert(ctx, serialNumber)
+	if err != nil {
+		return certs.Certificate{}, err
+	}
+
+	return r.decrypt(cert)
+}
+
+func (r *repository) UpdateCert(ctx context.Context, cert certs.Certificate) error {
+	cert, err := r.encrypt(cert)
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+
+	return r.Repository.UpdateCert(ctx, cert)
+}
+
+func (r *repository) GetCAs(ctx context.Context, caType ...certs.CertType) ([]certs.Certificate, error) {
+	cas, err := r.Repository.GetCAs(ctx, caType...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.decryptAll(cas)
+}
+
+func (r *repository) ListStaleKeys(ctx context.Context, keyVersion int, limit uint64) ([]certs.Certificate, error) {
+	stale, err := r.Repository.ListStaleKeys(ctx, keyVersion, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.decryptAll(stale)
+}
+
+func (r *repository) UpdateKey(ctx context.Context, cert certs.Certificate) error {
+	cert, err := r.encrypt(cert)
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+
+	return r.Repository.UpdateKey(ctx, cert)
+}
+
+func (r *repository) encrypt(cert certs.Certificate) (certs.Certificate, error) {
+	if len(cert.Key) == 0 {
+		return cert, nil
+	}
+
+	key, err := r.enc.Encrypt(cert.Key, []byte(cert.SerialNumber))
+	if err != nil {
+		return certs.Certificate{}, err
+	}
+	cert.Key = key
+	cert.KeyVersion = r.enc.ActiveVersion()
+
+	return cert, nil
+}
+
+func (r *repository) decrypt(cert certs.Certificate) (certs.Certificate, error) {
+	if len(cert.Key) == 0 {
+		return cert, nil
+	}
+
+	key, err := r.enc.Decrypt(cert.Key, []byte(cert.SerialNumber))
+	if err != nil {
+		return certs.Certificate{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+	cert.Key = key
+
+	return cert, nil
+}
+
+func (r *repository) decryptAll(cs []certs.Certificate) ([]certs.Certificate, error) {
+	for i := range cs {
+		c, err := r.decrypt(cs[i])
+		if err != nil {
+			return nil, err
+		}
+		cs[i] = c
+	}
+
+	return cs, nil
+}
+
+// Reencrypt re-encrypts every stored private key that is plaintext or wrapped
+// by a master key other than the active one. It is used to encrypt existing
+// keys after enabling encryption and to retire old master keys after rotation.
+// The number of re-encrypted keys is returned.
+func Reencrypt(ctx context.Context, repo certs.Repository, enc *Encrypter) (uint64, error) {
+	encRepo := NewRepository(repo, enc)
+
+	var total uint64
+	for {
+		stale, err := encRepo.ListStaleKeys(ctx, enc.ActiveVersion(), defBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(stale) == 0 {
+			return total, nil
+		}
+		for _, cert := range stale {
+			if err := encRepo.UpdateKey(ctx, cert); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
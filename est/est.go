@@ -0,0 +1,76 @@
+// Package est implements Enrollment over Secure Transport (RFC 7030) on top
+// of the certs service.
+//
+// Devices fetch the CA certificates, enroll with a PKCS#10 CSR and re-enroll
+// authenticated with their current certificate over mutual TLS. The server
+// can also generate the key pair on behalf of devices that cannot.
+package est
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// WellKnownPath is the path the EST resources are published below.
+const WellKnownPath = "/.well-known/est"
+
+var (
+	ErrMalformed    = errors.New("malformed request")
+	ErrUnauthorized = errors.New("client is not authorized")
+	ErrNotFound     = errors.New("CA not found")
+	ErrNotSupported = errors.New("operation not supported")
+)
+
+// Config holds the EST server settings.
+type Config struct {
+	// Enabled serves the EST API next to the certs API.
+	Enabled bool `env:"ENABLED" envDefault:"false"`
+	// Issuer, Profile and TTL are the issuer, profile and validity
+	// certificates are issued with. A CA label in the request URL
+	// selects the issuer by name instead.
+	Issuer  string `env:"ISSUER"  envDefault:""`
+	Profile string `env:"PROFILE" envDefault:""`
+	TTL     string `env:"TTL"     envDefault:""`
+	// Username and Password are the HTTP basic credentials that authorize
+	// initial enrollments of clients without a certificate. Without them,
+	// only clients with a certificate can enroll.
+	Username string `env:"USERNAME" envDefault:""`
+	Password string `env:"PASSWORD" envDefault:""`
+	// ServerKeyGen enables the generation of key pairs by the server with
+	// the given key algorithm and size, which are also advertised as the
+	// CSR attributes.
+	ServerKeyGen bool   `env:"SERVER_KEYGEN" envDefault:"false"`
+	KeyAlgorithm string `env:"KEY_ALGORITHM" envDefault:"ecdsa"`
+	KeySize      int    `env:"KEY_SIZE"      envDefault:"256"`
+}
+
+// Client identifies the client of a request with the certificate it
+// presented over TLS or its HTTP basic credentials.
+type Client struct {
+	Certificate *x509.Certificate
+	Username    string
+	Password    string
+}
+
+// Service specifies the EST operations. The label names the issuer; an
+// empty label selects the configured one.
+type Service interface {
+	// CACerts returns the certificates of the issuer's chain, the issuing CA first.
+	CACerts(ctx context.Context, label string) ([]*x509.Certificate, error)
+
+	// CSRAttrs returns the DER encoded CSR attributes clients should include.
+	CSRAttrs(ctx context.Context, label string) ([]byte, error)
+
+	// Enroll issues a certificate from the DER encoded CSR.
+	Enroll(ctx context.Context, client Client, label string, csr []byte) (*x509.Certificate, error)
+
+	// Reenroll issues a new certificate for the client certificate from a CSR
+	// with the same subject and subject alternative names.
+	Reenroll(ctx context.Context, client Client, label string, csr []byte) (*x509.Certificate, error)
+
+	// ServerKeyGen generates a key pair and issues a certificate for it with
+	// the subject of the CSR. It returns the PKCS#8 encoded private key.
+	ServerKeyGen(ctx context.Context, client Client, label string, csr []byte) (*x509.Certificate, []byte, error)
+}
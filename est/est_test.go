@@ -0,0 +1,258 @@
+package est_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hantdev/certs"
+	estapi "github.com/hantdev/certs/api/est"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/est"
+	"github.com/hantdev/certs/internal/certstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	username = "bootstrap"
+	password = "secret"
+)
+
+func newServer(t *testing.T, certsSvc certs.Service) *httptest.Server {
+	cfg := est.Config{Username: username, Password: password, ServerKeyGen: true, KeyAlgorithm: certs.KeyAlgorithmECDSA, KeySize: 256}
+	handler := estapi.MakeHandler(est.NewService(certsSvc, cfg), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// newClient returns a HTTP client of the server presenting the certificate, if any.
+func newClient(srv *httptest.Server, cert *x509.Certificate, key *ecdsa.PrivateKey) *http.Client {
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	if cert != nil {
+		transport.TLSClientConfig.Certificates = []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}}
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+func newCSR(t *testing.T, cn string, dnsNames ...string) ([]byte, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}, DNSNames: dnsNames}, key)
+	require.NoError(t, err)
+
+	return csr, key
+}
+
+func post(t *testing.T, client *http.Client, url string, csr []byte, basicAuth bool) (*http.Response, []byte) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(base64.StdEncoding.EncodeToString(csr)))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/pkcs10")
+	req.Header.Set("Content-Transfer-Encoding", "base64")
+	if basicAuth {
+		req.SetBasicAuth(username, password)
+	}
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	return res, body
+}
+
+// parseCerts decodes the base64 certs-only response.
+func parseCerts(t *testing.T, body []byte) []*x509.Certificate {
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(body)), ""))
+	require.NoError(t, err)
+	certs, err := est.ParseCertsOnly(der)
+	require.NoError(t, err)
+
+	return certs
+}
+
+func TestEST(t *testing.T) {
+	certsSvc := certstest.NewService(t)
+	srv := newServer(t, certsSvc)
+	base := srv.URL + est.WellKnownPath
+	anonymous := newClient(srv, nil, nil)
+
+	res, err := anonymous.Get(base + "/cacerts")
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, estapi.PKCS7Type, res.Header.Get("Content-Type"))
+	assert.Equal(t, "base64", res.Header.Get("Content-Transfer-Encoding"))
+	cas := parseCerts(t, body)
+	require.Len(t, cas, 2)
+	assert.True(t, cas[0].IsCA)
+	roots := x509.NewCertPool()
+	roots.AddCert(cas[1])
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(cas[0])
+	verify := func(cert *x509.Certificate) {
+		_, err := cert.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+		assert.NoError(t, err)
+	}
+
+	res, err = anonymous.Get(base + "/unknown/cacerts")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, err = anonymous.Get(base + "/csrattrs")
+	require.NoError(t, err)
+	body, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, estapi.CSRAttrsType, res.Header.Get("Content-Type"))
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(body)))
+	require.NoError(t, err)
+	var attrs []asn1.RawValue
+	_, err = asn1.Unmarshal(der, &attrs)
+	require.NoError(t, err)
+	assert.Len(t, attrs, 2)
+
+	// Initial enrollment requires the bootstrap credentials.
+	csr, key := newCSR(t, "device-1", "device-1.example.com")
+	res, _ = post(t, anonymous, base+"/simpleenroll", csr, false)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Equal(t, `Basic realm="est"`, res.Header.Get("WWW-Authenticate"))
+
+	res, body = post(t, anonymous, base+"/simpleenroll", csr, true)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	assert.Equal(t, estapi.CertsOnlyType, res.Header.Get("Content-Type"))
+	issued := parseCerts(t, body)
+	require.Len(t, issued, 1)
+	cert := issued[0]
+	assert.Equal(t, "device-1", cert.Subject.CommonName)
+	assert.Equal(t, []string{"device-1.example.com"}, cert.DNSNames)
+	verify(cert)
+	stored, err := certsSvc.ViewCert(context.Background(), cert.SerialNumber.String())
+	require.NoError(t, err)
+	assert.Equal(t, "device-1", stored.EntityID)
+
+	res, _ = post(t, anonymous, base+"/simpleenroll", []byte("not a csr"), true)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// Re-enrollment is authenticated with the current certificate only.
+	renewCSR, renewKey := newCSR(t, "device-1", "device-1.example.com")
+	res, _ = post(t, anonymous, base+"/simplereenroll", renewCSR, true)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	device := newClient(srv, cert, key)
+	otherCSR, _ := newCSR(t, "device-2", "device-1.example.com")
+	res, _ = post(t, device, base+"/simplereenroll", otherCSR, false)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, body = post(t, device, base+"/simplereenroll", renewCSR, false)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	renewed := parseCerts(t, body)[0]
+	assert.Equal(t, cert.Subject.CommonName, renewed.Subject.CommonName)
+	assert.NotEqual(t, cert.SerialNumber, renewed.SerialNumber)
+	assert.True(t, renewKey.PublicKey.Equal(renewed.PublicKey))
+	verify(renewed)
+
+	// A certificate the service has not issued is rejected.
+	foreignKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	foreignDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: cert.SerialNumber,
+		Subject:      cert.Subject,
+		DNSNames:     cert.DNSNames,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, &x509.Certificate{SerialNumber: cert.SerialNumber, Subject: pkix.Name{CommonName: "foreign"}}, &foreignKey.PublicKey, foreignKey)
+	require.NoError(t, err)
+	foreign, err := x509.ParseCertificate(foreignDER)
+	require.NoError(t, err)
+	res, _ = post(t, newClient(srv, foreign, foreignKey), base+"/simplereenroll", renewCSR, false)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// Revoked certificates cannot re-enroll.
+	require.NoError(t, certsSvc.RevokeCert(context.Background(), cert.SerialNumber.String(), certs.RevocationSuperseded, time.Time{}))
+	res, _ = post(t, device, base+"/simplereenroll", renewCSR, false)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// The server generates the key pair of devices that cannot.
+	keygenCSR, _ := newCSR(t, "device-3")
+	res, body = post(t, anonymous, base+"/serverkeygen", keygenCSR, true)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	mediaType, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	mr := multipart.NewReader(strings.NewReader(string(body)), params["boundary"])
+	part, err := mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, estapi.PKCS8Type, part.Header.Get("Content-Type"))
+	keyBody, err := io.ReadAll(part)
+	require.NoError(t, err)
+	keyDER, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(keyBody)), ""))
+	require.NoError(t, err)
+	generated, err := x509.ParsePKCS8PrivateKey(keyDER)
+	require.NoError(t, err)
+	part, err = mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, estapi.CertsOnlyType, part.Header.Get("Content-Type"))
+	certBody, err := io.ReadAll(part)
+	require.NoError(t, err)
+	keygenCert := parseCerts(t, certBody)[0]
+	assert.Equal(t, "device-3", keygenCert.Subject.CommonName)
+	assert.True(t, generated.(*ecdsa.PrivateKey).PublicKey.Equal(keygenCert.PublicKey))
+}
+
+func TestEnrollWithoutCredentials(t *testing.T) {
+	certsSvc := certstest.NewService(t)
+	svc := est.NewService(certsSvc, est.Config{})
+	csr, _ := newCSR(t, "device-1")
+
+	// Without configured credentials, anonymous clients cannot pick the entity.
+	for _, client := range []est.Client{{}, {Username: username, Password: password}} {
+		_, err := svc.Enroll(context.Background(), client, "", csr)
+		assert.True(t, errors.Contains(err, est.ErrUnauthorized), "expected error %v, got %v", est.ErrUnauthorized, err)
+	}
+	svc = est.NewService(certsSvc, est.Config{Username: username, ServerKeyGen: true})
+	_, _, err := svc.ServerKeyGen(context.Background(), est.Client{Username: username}, "", csr)
+	assert.True(t, errors.Contains(err, est.ErrUnauthorized), "expected error %v, got %v", est.ErrUnauthorized, err)
+}
+
+func TestCertsOnly(t *testing.T) {
+	certsSvc := certstest.NewService(t)
+	svc := est.NewService(certsSvc, est.Config{})
+	cas, err := svc.CACerts(context.Background(), "")
+	require.NoError(t, err)
+
+	der, err := est.MarshalCertsOnly(cas)
+	require.NoError(t, err)
+	parsed, err := est.ParseCertsOnly(der)
+	require.NoError(t, err)
+	require.Len(t, parsed, len(cas))
+	for i := range cas {
+		assert.True(t, cas[i].Equal(parsed[i]))
+	}
+
+	_, err = est.ParseCertsOnly(cas[0].Raw)
+	assert.Error(t, err)
+}
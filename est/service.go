@@ -0,0 +1,299 @@
+package est
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+var (
+	oidECPublicKey         = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA256     = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384     = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512     = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidRSAEncryption       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidEd25519             = asn1.ObjectIdentifier{1, 3, 101, 112}
+	oidNamedCurveP256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidNamedCurveP384      = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+	oidNamedCurveP521      = asn1.ObjectIdentifier{1, 3, 132, 0, 35}
+	errCertificateRequired = errors.New("re-enrollment requires a client certificate")
+	errCredentialsRequired = errors.New("initial enrollment requires configured credentials")
+	errSubjectMismatch     = errors.New("CSR subject does not match the client certificate")
+)
+
+// attribute is an Attribute of the CsrAttrs of RFC 7030 section 4.5.2.
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.ObjectIdentifier `asn1:"set"`
+}
+
+type service struct {
+	certs  certs.Service
+	config Config
+}
+
+var _ Service = (*service)(nil)
+
+// NewService returns a new EST service issuing certificates with the certs service.
+func NewService(certsSvc certs.Service, config Config) Service {
+	return &service{
+		certs:  certsSvc,
+		config: config,
+	}
+}
+
+func (s *service) CACerts(ctx context.Context, label string) ([]*x509.Certificate, error) {
+	chain, err := s.certs.ViewCAChain(ctx, s.issuer(label))
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	var cas []*x509.Certificate
+	for rest := chain.Certificate; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		cas = append(cas, ca)
+	}
+
+	return cas, nil
+}
+
+func (s *service) CSRAttrs(ctx context.Context, label string) ([]byte, error) {
+	if _, err := s.CACerts(ctx, label); err != nil {
+		return nil, err
+	}
+
+	// The attributes ask for a key of the configured algorithm and a matching signature.
+	var attrs []interface{}
+	switch strings.ToLower(s.config.KeyAlgorithm) {
+	case certs.KeyAlgorithmECDSA:
+		curve, sig := oidNamedCurveP256, oidECDSAWithSHA256
+		switch s.config.KeySize {
+		case 384:
+			curve, sig = oidNamedCurveP384, oidECDSAWithSHA384
+		case 521:
+			curve, sig = oidNamedCurveP521, oidECDSAWithSHA512
+		}
+		attrs = []interface{}{attribute{Type: oidECPublicKey, Values: []asn1.ObjectIdentifier{curve}}, sig}
+	case certs.KeyAlgorithmEd25519:
+		attrs = []interface{}{oidEd25519}
+	default:
+		attrs = []interface{}{oidRSAEncryption, oidSHA256WithRSA}
+	}
+	var raw []asn1.RawValue
+	for _, attr := range attrs {
+		der, err := asn1.Marshal(attr)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, asn1.RawValue{FullBytes: der})
+	}
+
+	return asn1.Marshal(raw)
+}
+
+func (s *service) Enroll(ctx context.Context, client Client, label string, der []byte) (*x509.Certificate, error) {
+	current, err := s.authenticate(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := parseCSR(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, entityID(current, csr), label, csr)
+}
+
+func (s *service) Reenroll(ctx context.Context, client Client, label string, der []byte) (*x509.Certificate, error) {
+	if client.Certificate == nil {
+		return nil, errors.Wrap(ErrUnauthorized, errCertificateRequired)
+	}
+	current, err := s.authenticate(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := parseCSR(der)
+	if err != nil {
+		return nil, err
+	}
+	cert := client.Certificate
+	if csr.Subject.String() != cert.Subject.String() ||
+		!sameNames(csr.DNSNames, cert.DNSNames) ||
+		!sameNames(csr.EmailAddresses, cert.EmailAddresses) ||
+		!slices.EqualFunc(csr.IPAddresses, cert.IPAddresses, net.IP.Equal) {
+		return nil, errors.Wrap(ErrMalformed, errSubjectMismatch)
+	}
+
+	return s.issue(ctx, current.EntityID, label, csr)
+}
+
+func (s *service) ServerKeyGen(ctx context.Context, client Client, label string, der []byte) (*x509.Certificate, []byte, error) {
+	if !s.config.ServerKeyGen {
+		return nil, nil, ErrNotSupported
+	}
+	current, err := s.authenticate(ctx, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := parseCSR(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := certs.GenerateKey(s.config.KeyAlgorithm, s.config.KeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The request is signed again with the generated key, keeping its subject.
+	keyDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:        csr.Subject,
+		DNSNames:       csr.DNSNames,
+		EmailAddresses: csr.EmailAddresses,
+		IPAddresses:    csr.IPAddresses,
+		URIs:           csr.URIs,
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyCSR, err := parseCSR(keyDER)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := s.issue(ctx, entityID(current, csr), label, keyCSR)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, pkcs8, nil
+}
+
+// authenticate authenticates the client and returns its current certificate,
+// if it presented one. Clients without a certificate need the configured
+// basic credentials, so they are refused if there are none.
+func (s *service) authenticate(ctx context.Context, client Client) (certs.Certificate, error) {
+	if client.Certificate == nil {
+		if s.config.Username == "" || s.config.Password == "" {
+			return certs.Certificate{}, errors.Wrap(ErrUnauthorized, errCredentialsRequired)
+		}
+		if subtle.ConstantTimeCompare([]byte(client.Username), []byte(s.config.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(client.Password), []byte(s.config.Password)) != 1 {
+			return certs.Certificate{}, ErrUnauthorized
+		}
+		return certs.Certificate{}, nil
+	}
+
+	// The certificate must be one issued by the service and still in use.
+	cert, err := s.certs.ViewCert(ctx, client.Certificate.SerialNumber.String())
+	if err != nil {
+		if errors.Contains(err, certs.ErrNotFound) {
+			return certs.Certificate{}, ErrUnauthorized
+		}
+		return certs.Certificate{}, err
+	}
+	block, _ := pem.Decode(cert.Certificate)
+	switch {
+	case block == nil, string(block.Bytes) != string(client.Certificate.Raw), cert.Type != certs.ClientCert:
+		return certs.Certificate{}, ErrUnauthorized
+	case cert.Revoked:
+		return certs.Certificate{}, errors.Wrap(ErrUnauthorized, certs.ErrCertRevoked)
+	case time.Now().After(client.Certificate.NotAfter):
+		return certs.Certificate{}, errors.Wrap(ErrUnauthorized, certs.ErrCertExpired)
+	}
+
+	return cert, nil
+}
+
+// issue issues the certificate for the CSR with the issuer of the label.
+func (s *service) issue(ctx context.Context, entityID, label string, csr *x509.CertificateRequest) (*x509.Certificate, error) {
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr.Raw})
+	cert, err := s.certs.IssueFromCSR(ctx, entityID, s.issuer(label), s.config.Profile, s.config.TTL, certs.CSR{CSR: csrPEM})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	block, _ := pem.Decode(cert.Certificate)
+	if block == nil {
+		return nil, errors.New("failed to decode issued certificate")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func (s *service) issuer(label string) string {
+	if label != "" {
+		return label
+	}
+
+	return s.config.Issuer
+}
+
+// entityID returns the entity of the client certificate or, if the client
+// has none, the common name or first DNS name of the CSR.
+func entityID(current certs.Certificate, csr *x509.CertificateRequest) string {
+	switch {
+	case current.EntityID != "":
+		return current.EntityID
+	case csr.Subject.CommonName != "":
+		return csr.Subject.CommonName
+	default:
+		return csr.DNSNames[0]
+	}
+}
+
+func parseCSR(der []byte) (*x509.CertificateRequest, error) {
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, errors.Wrap(ErrMalformed, err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, errors.Wrap(ErrMalformed, err)
+	}
+	if csr.Subject.CommonName == "" && len(csr.DNSNames) == 0 {
+		return nil, errors.Wrap(ErrMalformed, errors.New("CSR has no subject"))
+	}
+
+	return csr, nil
+}
+
+// mapError maps the certs service errors to the EST ones.
+func mapError(err error) error {
+	switch {
+	case errors.Contains(err, certs.ErrIssuerNotFound),
+		errors.Contains(err, certs.ErrIntermediateCANotFound):
+		return errors.Wrap(ErrNotFound, err)
+	case errors.Contains(err, certs.ErrPolicyViolation),
+		errors.Contains(err, certs.ErrMalformedEntity):
+		return errors.Wrap(ErrMalformed, err)
+	default:
+		return err
+	}
+}
+
+func sameNames(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(a, b)
+}
@@ -9,36 +9,53 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"time"
 
 	"golang.org/x/crypto/ocsp"
 )
 
 var (
-	ErrCertExpired = errors.New("certificate expired before renewal")
-	ErrCertRevoked = errors.New("certificate has been revoked and cannot be renewed")
-	ErrUnkonwn     = errors.New("certificate status unknown")
+	ErrCertExpired           = errors.New("certificate expired before renewal")
+	ErrCertRevoked           = errors.New("certificate has been revoked and cannot be renewed")
+	ErrUnkonwn               = errors.New("certificate status unknown")
+	ErrNoResponder           = errors.New("no OCSP responder for certificate")
+	ErrUnauthorizedResponder = errors.New("OCSP responder is not authorized by the issuer")
 )
 
+// OCSP verifies peer certificates against the OCSP responder named in their
+// Authority Information Access extension, falling back to the responder of the
+// certs service at the given URI for certificates without one.
 type OCSP struct {
 	certsURI string
 }
 
 func New(certsURI string) *OCSP {
-	return &OCSP{
-		certsURI: fmt.Sprintf("%s/certs/ocsp", certsURI),
+	o := &OCSP{}
+	if certsURI != "" {
+		o.certsURI = fmt.Sprintf("%s/certs/ocsp", certsURI)
 	}
+
+	return o
 }
 
 func (o *OCSP) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	responderURI := o.certsURI
+	if servers := verifiedChains[0][0].OCSPServer; len(servers) > 0 {
+		responderURI = servers[0]
+	}
+	if responderURI == "" {
+		return ErrNoResponder
+	}
 	req, err := ocsp.CreateRequest(verifiedChains[0][0], verifiedChains[0][1], &ocsp.RequestOptions{Hash: crypto.SHA256})
 	if err != nil {
 		return err
 	}
-	httpRequest, err := http.NewRequest(http.MethodPost, o.certsURI, bytes.NewBuffer(req))
+	httpRequest, err := http.NewRequest(http.MethodPost, responderURI, bytes.NewBuffer(req))
 	if err != nil {
 		return err
 	}
-	ocspURL, err := url.Parse(o.certsURI)
+	ocspURL, err := url.Parse(responderURI)
 	if err != nil {
 		return err
 	}
@@ -61,14 +78,21 @@ func (o *OCSP) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509
 	if err != nil {
 		return err
 	}
+	// The issuer either signs the response itself or delegates it to a
+	// responder certificate it issued for OCSP signing.
+	if responder := ocspResponse.Certificate; responder != nil && !bytes.Equal(responder.Raw, verifiedChains[0][1].Raw) &&
+		!slices.Contains(responder.ExtKeyUsage, x509.ExtKeyUsageOCSPSigning) {
+		return ErrUnauthorizedResponder
+	}
 	switch ocspResponse.Status {
 	case ocsp.Good:
 		return nil
 	case ocsp.Revoked:
-		if ocspResponse.RevocationReason == ocsp.Unspecified {
-			return ErrCertRevoked
+		// Expired certificates are reported as revoked with the cessation of operation reason.
+		if ocspResponse.RevocationReason == ocsp.CessationOfOperation && !verifiedChains[0][0].NotAfter.After(time.Now()) {
+			return ErrCertExpired
 		}
-		return ErrCertExpired
+		return ErrCertRevoked
 	case ocsp.Unknown:
 		return ErrUnkonwn
 	}
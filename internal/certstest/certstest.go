@@ -0,0 +1,71 @@
+// Package certstest provides the certs service the protocol tests issue
+// certificates from.
+package certstest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// NewService returns the certs service on a repository backed by a map.
+// Unknown serial numbers are reported as certs.ErrNotFound, as the
+// database repository does.
+func NewService(t *testing.T) certs.Service {
+	var mu sync.Mutex
+	stored := map[string]certs.Certificate{}
+	repo := new(mocks.MockRepository)
+	repo.On("GetCAs", mock.Anything).Return([]certs.Certificate{}, nil)
+	repo.On("CreateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	repo.On("RetrieveCert", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) (certs.Certificate, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		c, ok := stored[sn]
+		if !ok {
+			return certs.Certificate{}, certs.ErrNotFound
+		}
+		return c, nil
+	})
+	repo.On("UpdateCert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		c := args.Get(1).(certs.Certificate)
+		stored[c.SerialNumber] = c
+	}).Return(nil)
+	repo.On("GetEntityID", mock.Anything, mock.Anything).Return(func(_ context.Context, sn string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		c, ok := stored[sn]
+		if !ok {
+			return "", certs.ErrNotFound
+		}
+		return c.EntityID, nil
+	})
+	repo.On("ListCerts", mock.Anything, mock.Anything).Return(func(_ context.Context, pm certs.PageMetadata) certs.CertificatePage {
+		mu.Lock()
+		defer mu.Unlock()
+		page := certs.CertificatePage{PageMetadata: pm}
+		for _, c := range stored {
+			if c.EntityID == pm.EntityID {
+				page.Certificates = append(page.Certificates, c)
+			}
+		}
+		return page
+	}, nil)
+
+	cfg := certs.Config{CommonName: "test", KeyAlgorithm: certs.KeyAlgorithmECDSA, KeySize: 256}
+	svc, err := certs.NewService(context.Background(), repo, nil, &cfg)
+	require.NoError(t, err)
+
+	return svc
+}
@@ -0,0 +1,137 @@
+package cms
+
+import "errors"
+
+const (
+	tagOctetString            = 0x04
+	tagConstructedOctetString = 0x24
+	maxDepth                  = 32
+)
+
+var errBER = errors.Join(ErrMalformed, errors.New("cms: invalid BER encoding"))
+
+// normalize converts the BER encoding many SCEP clients produce to DER, as
+// far as encoding/asn1 needs it: indefinite lengths become definite and
+// constructed OCTET STRINGs are joined into primitive ones.
+func normalize(ber []byte) ([]byte, error) {
+	elem, rest, err := normalizeElement(ber, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errBER
+	}
+
+	return elem.encode(), nil
+}
+
+type element struct {
+	tag         []byte
+	constructed bool
+	content     []byte
+}
+
+func (e element) encode() []byte {
+	out := append([]byte{}, e.tag...)
+	out = append(out, encodeLength(len(e.content))...)
+	return append(out, e.content...)
+}
+
+// normalizeElement normalizes the first element of the data and returns the
+// data following it.
+func normalizeElement(data []byte, depth int) (element, []byte, error) {
+	if depth > maxDepth || len(data) < 2 {
+		return element{}, nil, errBER
+	}
+
+	n := 1
+	if data[0]&0x1f == 0x1f {
+		for n < len(data) && data[n]&0x80 != 0 {
+			n++
+		}
+		n++
+	}
+	if n >= len(data) {
+		return element{}, nil, errBER
+	}
+	elem := element{tag: data[:n], constructed: data[0]&0x20 != 0}
+
+	indefinite := data[n] == 0x80
+	length := int(data[n])
+	n++
+	if length&0x80 != 0 && !indefinite {
+		size := length & 0x7f
+		if size > 4 || n+size > len(data) {
+			return element{}, nil, errBER
+		}
+		length = 0
+		for _, b := range data[n : n+size] {
+			length = length<<8 | int(b)
+		}
+		n += size
+	}
+
+	var content, rest []byte
+	switch {
+	case indefinite:
+		if !elem.constructed {
+			return element{}, nil, errBER
+		}
+		content = data[n:]
+	case length < 0 || n+length > len(data):
+		return element{}, nil, errBER
+	default:
+		content, rest = data[n:n+length], data[n+length:]
+	}
+
+	if !elem.constructed {
+		elem.content = content
+		return elem, rest, nil
+	}
+
+	var children []element
+	for {
+		if indefinite {
+			if len(content) < 2 {
+				return element{}, nil, errBER
+			}
+			if content[0] == 0 && content[1] == 0 {
+				rest = content[2:]
+				break
+			}
+		} else if len(content) == 0 {
+			break
+		}
+		child, next, err := normalizeElement(content, depth+1)
+		if err != nil {
+			return element{}, nil, err
+		}
+		children = append(children, child)
+		content = next
+	}
+
+	if len(elem.tag) == 1 && elem.tag[0] == tagConstructedOctetString {
+		elem = element{tag: []byte{tagOctetString}}
+		for _, child := range children {
+			elem.content = append(elem.content, child.content...)
+		}
+		return elem, rest, nil
+	}
+	for _, child := range children {
+		elem.content = append(elem.content, child.encode()...)
+	}
+
+	return elem, rest, nil
+}
+
+func encodeLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+	var out []byte
+	for l := length; l > 0; l >>= 8 {
+		out = append([]byte{byte(l)}, out...)
+	}
+
+	return append([]byte{0x80 | byte(len(out))}, out...)
+}
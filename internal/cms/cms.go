@@ -0,0 +1,386 @@
+// Package cms implements the subset of the Cryptographic Message Syntax
+// (RFC 5652) SCEP messages are built of: RSA signed data with signed
+// attributes and enveloped data with RSA key transport.
+package cms
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"slices"
+	"time"
+
+	// Registers the hashes signed data can be signed and verified with.
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+var (
+	OIDData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	OIDSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	OIDEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA1WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidSHA256WithRSA = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+
+	digestAlgorithms = map[crypto.Hash]asn1.ObjectIdentifier{
+		crypto.SHA1:   {1, 3, 14, 3, 2, 26},
+		crypto.SHA256: {2, 16, 840, 1, 101, 3, 4, 2, 1},
+		crypto.SHA384: {2, 16, 840, 1, 101, 3, 4, 2, 2},
+		crypto.SHA512: {2, 16, 840, 1, 101, 3, 4, 2, 3},
+	}
+
+	ErrUnsupportedAlgorithm = errors.New("cms: unsupported algorithm")
+	ErrInvalidSignature     = errors.New("cms: invalid signature")
+	ErrNoSigner             = errors.New("cms: signer certificate not found")
+	ErrMalformed            = errors.New("cms: malformed message")
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	// Content is the [0] EXPLICIT content, if there is any.
+	Content asn1.RawValue `asn1:"optional"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// Attribute is a signed attribute with a single value.
+type Attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value interface{}
+}
+
+// SignedData is a verified signed data message.
+type SignedData struct {
+	// Content is the signed content, empty if there is none.
+	Content []byte
+	// Certificates are the certificates the message carries.
+	Certificates []*x509.Certificate
+	// Signer is the certificate of the signer.
+	Signer *x509.Certificate
+	// Hash is the digest algorithm of the signature.
+	Hash       crypto.Hash
+	attributes map[string]asn1.RawValue
+}
+
+// Attribute returns the value of the signed attribute with the given type.
+func (sd *SignedData) Attribute(oid asn1.ObjectIdentifier) (asn1.RawValue, bool) {
+	v, ok := sd.attributes[oid.String()]
+	return v, ok
+}
+
+// ParseSignedData parses a signed data content info and verifies the
+// signature of its first signer, which must be one of its certificates.
+func ParseSignedData(data []byte) (*SignedData, error) {
+	der, err := normalize(data)
+	if err != nil {
+		return nil, err
+	}
+	var ci contentInfo
+	if err := unmarshal(der, &ci); err != nil {
+		return nil, err
+	}
+	if !ci.ContentType.Equal(OIDSignedData) {
+		return nil, ErrMalformed
+	}
+	var sd signedData
+	if err := unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, err
+	}
+	if len(sd.SignerInfos) == 0 {
+		return nil, ErrNoSigner
+	}
+
+	res := &SignedData{attributes: map[string]asn1.RawValue{}}
+	if len(sd.EncapContentInfo.Content.Bytes) > 0 {
+		if err := unmarshal(sd.EncapContentInfo.Content.Bytes, &res.Content); err != nil {
+			return nil, err
+		}
+	}
+	if res.Certificates, err = x509.ParseCertificates(sd.Certificates.Bytes); err != nil {
+		return nil, err
+	}
+
+	si := sd.SignerInfos[0]
+	if res.Signer, err = findCertificate(res.Certificates, si.SID); err != nil {
+		return nil, err
+	}
+	if res.Hash, err = hashOf(si.DigestAlgorithm.Algorithm); err != nil {
+		return nil, err
+	}
+	pub, ok := res.Signer.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	// Without signed attributes the signature is over the content itself.
+	signed := res.Content
+	if len(si.SignedAttrs.Bytes) > 0 {
+		if signed, err = asn1.Marshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: si.SignedAttrs.Bytes}); err != nil {
+			return nil, err
+		}
+		for rest := si.SignedAttrs.Bytes; len(rest) > 0; {
+			var attr attribute
+			if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+				return nil, errors.Join(ErrMalformed, err)
+			}
+			if len(attr.Values) > 0 {
+				res.attributes[attr.Type.String()] = attr.Values[0]
+			}
+		}
+		var digest []byte
+		if v, ok := res.Attribute(oidMessageDigest); !ok {
+			return nil, ErrMalformed
+		} else if err := unmarshal(v.FullBytes, &digest); err != nil {
+			return nil, err
+		}
+		h := res.Hash.New()
+		h.Write(res.Content)
+		if !bytes.Equal(h.Sum(nil), digest) {
+			return nil, ErrInvalidSignature
+		}
+	}
+	h := res.Hash.New()
+	h.Write(signed)
+	if err := rsa.VerifyPKCS1v15(pub, res.Hash, h.Sum(nil), si.Signature); err != nil {
+		return nil, errors.Join(ErrInvalidSignature, err)
+	}
+
+	return res, nil
+}
+
+// Sign returns the signed data content info of the content, signed with the
+// RSA key of the certificate. The signed attributes include the content type,
+// message digest and signing time. The certificate is included with the
+// additional certificates.
+func Sign(content []byte, cert *x509.Certificate, key crypto.Signer, hash crypto.Hash, attrs []Attribute, certs ...*x509.Certificate) ([]byte, error) {
+	digestAlg, ok := digestAlgorithms[hash]
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if _, ok := key.Public().(*rsa.PublicKey); !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	h := hash.New()
+	h.Write(content)
+
+	attrs = append([]Attribute{
+		{Type: oidContentType, Value: OIDData},
+		{Type: oidMessageDigest, Value: h.Sum(nil)},
+		{Type: oidSigningTime, Value: time.Now().UTC()},
+	}, attrs...)
+	var encoded [][]byte
+	for _, attr := range attrs {
+		value, err := asn1.Marshal(attr.Value)
+		if err != nil {
+			return nil, err
+		}
+		der, err := asn1.Marshal(attribute{Type: attr.Type, Values: []asn1.RawValue{{FullBytes: value}}})
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, der)
+	}
+	// The elements of a DER SET OF are sorted by their encoding.
+	slices.SortFunc(encoded, bytes.Compare)
+	signedAttrs := bytes.Join(encoded, nil)
+	signed, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: signedAttrs})
+	if err != nil {
+		return nil, err
+	}
+	h = hash.New()
+	h.Write(signed)
+	signature, err := key.Sign(rand.Reader, h.Sum(nil), hash)
+	if err != nil {
+		return nil, err
+	}
+
+	sid, err := asn1.Marshal(issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber})
+	if err != nil {
+		return nil, err
+	}
+	var raw []byte
+	for _, c := range append([]*x509.Certificate{cert}, certs...) {
+		raw = append(raw, c.Raw...)
+	}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: digestAlg, Parameters: asn1.NullRawValue}},
+		EncapContentInfo: contentInfo{ContentType: OIDData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: digestAlg, Parameters: asn1.NullRawValue},
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+			Signature:          signature,
+		}},
+	}
+	if len(content) > 0 {
+		octets, err := asn1.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		sd.EncapContentInfo.Content = explicit(octets)
+	}
+
+	return marshalContentInfo(OIDSignedData, sd)
+}
+
+// MarshalCertsOnly encodes the certificates as a degenerate signed data
+// content info without signers.
+func MarshalCertsOnly(certs []*x509.Certificate) ([]byte, error) {
+	var raw []byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw...)
+	}
+
+	return marshalContentInfo(OIDSignedData, struct {
+		Version          int
+		DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+		EncapContentInfo contentInfo
+		Certificates     asn1.RawValue
+		SignerInfos      []signerInfo `asn1:"set"`
+	}{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{},
+		EncapContentInfo: contentInfo{ContentType: OIDData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      []signerInfo{},
+	})
+}
+
+// ParseCertsOnly parses the certificates of a degenerate signed data content info.
+func ParseCertsOnly(data []byte) ([]*x509.Certificate, error) {
+	der, err := normalize(data)
+	if err != nil {
+		return nil, err
+	}
+	var ci contentInfo
+	if err := unmarshal(der, &ci); err != nil {
+		return nil, err
+	}
+	if !ci.ContentType.Equal(OIDSignedData) {
+		return nil, ErrMalformed
+	}
+	var sd signedData
+	if err := unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificates(sd.Certificates.Bytes)
+}
+
+func marshalContentInfo(contentType asn1.ObjectIdentifier, content interface{}) ([]byte, error) {
+	der, err := asn1.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(contentInfo{ContentType: contentType, Content: explicit(der)})
+}
+
+// explicit wraps the DER element in a [0] EXPLICIT tag.
+func explicit(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+// unmarshal parses the DER element into v, which must take all of the data.
+func unmarshal(der []byte, v interface{}) error {
+	rest, err := asn1.Unmarshal(der, v)
+	if err != nil {
+		return errors.Join(ErrMalformed, err)
+	}
+	if len(rest) > 0 {
+		return ErrMalformed
+	}
+
+	return nil
+}
+
+// findCertificate returns the certificate of the signer or recipient identifier.
+func findCertificate(certs []*x509.Certificate, id asn1.RawValue) (*x509.Certificate, error) {
+	var ias issuerAndSerialNumber
+	if id.Class == asn1.ClassContextSpecific {
+		// [0] SubjectKeyIdentifier
+		for _, cert := range certs {
+			if bytes.Equal(cert.SubjectKeyId, id.Bytes) {
+				return cert, nil
+			}
+		}
+		return nil, ErrNoSigner
+	}
+	if err := unmarshal(id.FullBytes, &ias); err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		if cert.SerialNumber.Cmp(ias.SerialNumber) == 0 && bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) {
+			return cert, nil
+		}
+	}
+
+	return nil, ErrNoSigner
+}
+
+func hashOf(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	for hash, alg := range digestAlgorithms {
+		if alg.Equal(oid) {
+			return hash, nil
+		}
+	}
+	// Some signers name the signature algorithm instead of the digest.
+	switch {
+	case oid.Equal(oidSHA1WithRSA):
+		return crypto.SHA1, nil
+	case oid.Equal(oidSHA256WithRSA):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384WithRSA):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512WithRSA):
+		return crypto.SHA512, nil
+	}
+
+	return 0, ErrUnsupportedAlgorithm
+}
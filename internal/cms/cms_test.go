@@ -0,0 +1,404 @@
+package cms_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hantdev/certs/internal/cms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var oidTransactionID = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 7}
+
+// The structures below mirror the encoding of the messages, so that the
+// tests can tamper with them.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type keyTransRecipientInfo struct {
+	Version                int
+	RID                    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+func TestSignedData(t *testing.T) {
+	cert, key := newCert(t, "signer")
+	ca, _ := newCert(t, "ca")
+	content := []byte("certificate request")
+
+	testCases := []struct {
+		desc    string
+		content []byte
+		hash    crypto.Hash
+	}{
+		{desc: "SHA-256", content: content, hash: crypto.SHA256},
+		{desc: "SHA-1", content: content, hash: crypto.SHA1},
+		{desc: "SHA-512", content: content, hash: crypto.SHA512},
+		{desc: "no content", hash: crypto.SHA256},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			der, err := cms.Sign(tc.content, cert, key, tc.hash, []cms.Attribute{{Type: oidTransactionID, Value: "tx-1"}}, ca)
+			require.NoError(t, err)
+
+			sd, err := cms.ParseSignedData(der)
+			require.NoError(t, err)
+			assert.Equal(t, tc.content, sd.Content)
+			assert.Equal(t, tc.hash, sd.Hash)
+			assert.True(t, cert.Equal(sd.Signer))
+			require.Len(t, sd.Certificates, 2)
+			assert.True(t, ca.Equal(sd.Certificates[1]))
+			value, ok := sd.Attribute(oidTransactionID)
+			require.True(t, ok)
+			var transactionID string
+			_, err = asn1.Unmarshal(value.FullBytes, &transactionID)
+			require.NoError(t, err)
+			assert.Equal(t, "tx-1", transactionID)
+		})
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = cms.Sign(content, cert, ecKey, crypto.SHA256, nil)
+	assert.ErrorIs(t, err, cms.ErrUnsupportedAlgorithm)
+	_, err = cms.Sign(content, cert, key, crypto.MD5, nil)
+	assert.ErrorIs(t, err, cms.ErrUnsupportedAlgorithm)
+}
+
+func TestParseSignedData(t *testing.T) {
+	cert, key := newCert(t, "signer")
+	other, _ := newCert(t, "other")
+	der, err := cms.Sign([]byte("certificate request"), cert, key, crypto.SHA256, nil)
+	require.NoError(t, err)
+
+	tamper := func(update func(sd *signedData)) []byte {
+		var ci contentInfo
+		_, err := asn1.Unmarshal(der, &ci)
+		require.NoError(t, err)
+		var sd signedData
+		_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+		require.NoError(t, err)
+		update(&sd)
+		return marshalContentInfo(t, ci.ContentType, sd)
+	}
+	digest := func(oid asn1.ObjectIdentifier) func(sd *signedData) {
+		return func(sd *signedData) {
+			sd.SignerInfos[0].DigestAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.NullRawValue}
+		}
+	}
+
+	testCases := []struct {
+		desc string
+		data []byte
+		err  error
+	}{
+		{
+			desc: "tampered signature",
+			data: tamper(func(sd *signedData) { sd.SignerInfos[0].Signature[0] ^= 1 }),
+			err:  cms.ErrInvalidSignature,
+		},
+		{
+			desc: "tampered content",
+			data: tamper(func(sd *signedData) {
+				octets, err := asn1.Marshal([]byte("other request"))
+				require.NoError(t, err)
+				sd.EncapContentInfo.Content = explicit(octets)
+			}),
+			err: cms.ErrInvalidSignature,
+		},
+		{
+			desc: "tampered signed attributes",
+			data: tamper(func(sd *signedData) {
+				attrs := sd.SignerInfos[0].SignedAttrs
+				sd.SignerInfos[0].SignedAttrs = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: bytes.Replace(attrs.Bytes, []byte{0x17}, []byte{0x18}, 1)}
+			}),
+			err: cms.ErrInvalidSignature,
+		},
+		{
+			desc: "wrong digest algorithm",
+			data: tamper(digest(asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3})),
+			err:  cms.ErrInvalidSignature,
+		},
+		{
+			desc: "unsupported digest algorithm",
+			data: tamper(digest(asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 5})),
+			err:  cms.ErrUnsupportedAlgorithm,
+		},
+		{
+			desc: "signer certificate missing",
+			data: tamper(func(sd *signedData) {
+				sd.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: other.Raw}
+			}),
+			err: cms.ErrNoSigner,
+		},
+		{
+			desc: "no signers",
+			data: tamper(func(sd *signedData) { sd.SignerInfos = []signerInfo{} }),
+			err:  cms.ErrNoSigner,
+		},
+		{
+			desc: "enveloped data",
+			data: func() []byte {
+				data, err := cms.Encrypt([]byte("content"), cert, cms.OIDAES128CBC)
+				require.NoError(t, err)
+				return data
+			}(),
+			err: cms.ErrMalformed,
+		},
+		{
+			desc: "trailing data",
+			data: append(bytes.Clone(der), 0),
+			err:  cms.ErrMalformed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := cms.ParseSignedData(tc.data)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestEnvelopedData(t *testing.T) {
+	cert, key := newCert(t, "recipient")
+	content := []byte("certificate request")
+
+	for _, alg := range []asn1.ObjectIdentifier{cms.OIDAES128CBC, cms.OIDAES192CBC, cms.OIDAES256CBC, cms.OIDDESEDE3CBC, cms.OIDDESCBC} {
+		t.Run(alg.String(), func(t *testing.T) {
+			for _, c := range [][]byte{content, content[:16], {}} {
+				der, err := cms.Encrypt(c, cert, alg)
+				require.NoError(t, err)
+				decrypted, decryptedAlg, err := cms.Decrypt(der, cert, key)
+				require.NoError(t, err)
+				assert.Equal(t, c, decrypted)
+				assert.True(t, alg.Equal(decryptedAlg))
+			}
+		})
+	}
+
+	_, err := cms.Encrypt(content, cert, asn1.ObjectIdentifier{1, 2, 3})
+	assert.ErrorIs(t, err, cms.ErrUnsupportedAlgorithm)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecCert := &x509.Certificate{PublicKey: &ecKey.PublicKey}
+	_, err = cms.Encrypt(content, ecCert, cms.OIDAES128CBC)
+	assert.ErrorIs(t, err, cms.ErrUnsupportedAlgorithm)
+}
+
+func TestDecrypt(t *testing.T) {
+	cert, key := newCert(t, "recipient")
+	other, otherKey := newCert(t, "other")
+	der, err := cms.Encrypt([]byte("certificate request"), cert, cms.OIDAES128CBC)
+	require.NoError(t, err)
+
+	var ci contentInfo
+	_, err = asn1.Unmarshal(der, &ci)
+	require.NoError(t, err)
+	var ed envelopedData
+	_, err = asn1.Unmarshal(ci.Content.Bytes, &ed)
+	require.NoError(t, err)
+	var ri keyTransRecipientInfo
+	_, err = asn1.Unmarshal(ed.RecipientInfos[0].FullBytes, &ri)
+	require.NoError(t, err)
+	contentKey, err := rsa.DecryptPKCS1v15(nil, key, ri.EncryptedKey)
+	require.NoError(t, err)
+	var iv []byte
+	_, err = asn1.Unmarshal(ed.EncryptedContentInfo.ContentEncryptionAlgorithm.Parameters.FullBytes, &iv)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(contentKey)
+	require.NoError(t, err)
+
+	// envelope re-encrypts the padded plaintext with the content key.
+	envelope := func(padded []byte, encode func(ciphertext []byte) asn1.RawValue) []byte {
+		ciphertext := bytes.Clone(padded)
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+		ed := ed
+		ed.EncryptedContentInfo.EncryptedContent = encode(ciphertext)
+		return marshalContentInfo(t, ci.ContentType, ed)
+	}
+	primitive := func(ciphertext []byte) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext}
+	}
+	padded := append([]byte("certificate request"), bytes.Repeat([]byte{13}, 13)...)
+	badPadding := func(update func(padded []byte)) []byte {
+		padded := bytes.Clone(padded)
+		update(padded)
+		return envelope(padded, primitive)
+	}
+
+	// Some clients send the ciphertext as a constructed string of segments.
+	segmented := envelope(padded, func(ciphertext []byte) asn1.RawValue {
+		var segments []byte
+		for _, segment := range [][]byte{ciphertext[:16], ciphertext[16:]} {
+			der, err := asn1.Marshal(segment)
+			require.NoError(t, err)
+			segments = append(segments, der...)
+		}
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: segments}
+	})
+	decrypted, _, err := cms.Decrypt(segmented, cert, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("certificate request"), decrypted)
+
+	testCases := []struct {
+		desc string
+		data []byte
+		cert *x509.Certificate
+		key  *rsa.PrivateKey
+		err  error
+	}{
+		{
+			desc: "other recipient",
+			data: der,
+			cert: other,
+			key:  otherKey,
+			err:  cms.ErrNoRecipient,
+		},
+		{
+			desc: "wrong key",
+			data: der,
+			cert: cert,
+			key:  otherKey,
+			err:  cms.ErrDecryption,
+		},
+		{
+			desc: "zero padding",
+			data: badPadding(func(padded []byte) { padded[len(padded)-1] = 0 }),
+			err:  cms.ErrDecryption,
+		},
+		{
+			desc: "padding longer than a block",
+			data: badPadding(func(padded []byte) { padded[len(padded)-1] = 17 }),
+			err:  cms.ErrDecryption,
+		},
+		{
+			desc: "inconsistent padding",
+			data: badPadding(func(padded []byte) { padded[len(padded)-2] = 12 }),
+			err:  cms.ErrDecryption,
+		},
+		{
+			desc: "truncated ciphertext",
+			data: envelope(padded, func(ciphertext []byte) asn1.RawValue { return primitive(ciphertext[:24]) }),
+			err:  cms.ErrDecryption,
+		},
+		{
+			desc: "signed data",
+			data: func() []byte {
+				data, err := cms.Sign([]byte("content"), cert, key, crypto.SHA256, nil)
+				require.NoError(t, err)
+				return data
+			}(),
+			err: cms.ErrMalformed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c, k := cert, key
+			if tc.cert != nil {
+				c, k = tc.cert, tc.key
+			}
+			_, _, err := cms.Decrypt(tc.data, c, k)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestCertsOnly(t *testing.T) {
+	ca, _ := newCert(t, "ca")
+	ra, _ := newCert(t, "ra")
+
+	der, err := cms.MarshalCertsOnly([]*x509.Certificate{ra, ca})
+	require.NoError(t, err)
+	certs, err := cms.ParseCertsOnly(der)
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	assert.True(t, ra.Equal(certs[0]))
+	assert.True(t, ca.Equal(certs[1]))
+
+	_, err = cms.ParseSignedData(der)
+	assert.ErrorIs(t, err, cms.ErrNoSigner)
+	_, err = cms.ParseCertsOnly(ca.Raw)
+	assert.Error(t, err)
+}
+
+func newCert(t *testing.T, cn string) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	sn, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: sn,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func marshalContentInfo(t *testing.T, contentType asn1.ObjectIdentifier, content any) []byte {
+	der, err := asn1.Marshal(content)
+	require.NoError(t, err)
+	der, err = asn1.Marshal(contentInfo{ContentType: contentType, Content: explicit(der)})
+	require.NoError(t, err)
+
+	return der
+}
+
+func explicit(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
@@ -0,0 +1,206 @@
+package cms
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// Content encryption algorithms.
+var (
+	OIDAES128CBC   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	OIDAES192CBC   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	OIDAES256CBC   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	OIDDESEDE3CBC  = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	OIDDESCBC      = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 7}
+	ErrDecryption  = errors.New("cms: decryption failed")
+	ErrNoRecipient = errors.New("cms: no recipient info for the certificate")
+)
+
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type keyTransRecipientInfo struct {
+	Version                int
+	RID                    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// Encrypt returns the enveloped data content info of the content, encrypted
+// with the content encryption algorithm for the RSA key of the recipient.
+func Encrypt(content []byte, recipient *x509.Certificate, alg asn1.ObjectIdentifier) ([]byte, error) {
+	pub, ok := recipient.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	block, key, err := newCipher(alg, nil)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, block.BlockSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	padding := block.BlockSize() - len(content)%block.BlockSize()
+	ciphertext := append(bytes.Clone(content), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, pub, key)
+	if err != nil {
+		return nil, err
+	}
+	rid, err := asn1.Marshal(issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: recipient.RawIssuer}, SerialNumber: recipient.SerialNumber})
+	if err != nil {
+		return nil, err
+	}
+	ri, err := asn1.Marshal(keyTransRecipientInfo{
+		RID:                    asn1.RawValue{FullBytes: rid},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+		EncryptedKey:           encryptedKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalContentInfo(OIDEnvelopedData, envelopedData{
+		RecipientInfos: []asn1.RawValue{{FullBytes: ri}},
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                OIDData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: alg, Parameters: asn1.RawValue{FullBytes: params}},
+			EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext},
+		},
+	})
+}
+
+// Decrypt decrypts the enveloped data content info with the RSA key of the
+// recipient certificate. It returns the content and its encryption algorithm.
+func Decrypt(data []byte, cert *x509.Certificate, key *rsa.PrivateKey) ([]byte, asn1.ObjectIdentifier, error) {
+	der, err := normalize(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ci contentInfo
+	if err := unmarshal(der, &ci); err != nil {
+		return nil, nil, err
+	}
+	if !ci.ContentType.Equal(OIDEnvelopedData) {
+		return nil, nil, ErrMalformed
+	}
+	var ed envelopedData
+	if err := unmarshal(ci.Content.Bytes, &ed); err != nil {
+		return nil, nil, err
+	}
+
+	var encryptedKey []byte
+	for _, raw := range ed.RecipientInfos {
+		var ri keyTransRecipientInfo
+		if _, err := asn1.Unmarshal(raw.FullBytes, &ri); err != nil {
+			continue
+		}
+		if _, err := findCertificate([]*x509.Certificate{cert}, ri.RID); err == nil {
+			encryptedKey = ri.EncryptedKey
+			break
+		}
+	}
+	if encryptedKey == nil {
+		return nil, nil, ErrNoRecipient
+	}
+	contentKey, err := rsa.DecryptPKCS1v15(nil, key, encryptedKey)
+	if err != nil {
+		return nil, nil, errors.Join(ErrDecryption, err)
+	}
+
+	eci := ed.EncryptedContentInfo
+	alg := eci.ContentEncryptionAlgorithm.Algorithm
+	block, _, err := newCipher(alg, contentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	var iv []byte
+	if err := unmarshal(eci.ContentEncryptionAlgorithm.Parameters.FullBytes, &iv); err != nil {
+		return nil, nil, err
+	}
+	ciphertext := eci.EncryptedContent.Bytes
+	if eci.EncryptedContent.IsCompound {
+		// A constructed string holds the ciphertext in OCTET STRING segments.
+		ciphertext = nil
+		for rest := eci.EncryptedContent.Bytes; len(rest) > 0; {
+			var segment []byte
+			if rest, err = asn1.Unmarshal(rest, &segment); err != nil {
+				return nil, nil, errors.Join(ErrMalformed, err)
+			}
+			ciphertext = append(ciphertext, segment...)
+		}
+	}
+	if len(iv) != block.BlockSize() || len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, nil, ErrDecryption
+	}
+	content := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(content, ciphertext)
+
+	padding := int(content[len(content)-1])
+	if padding == 0 || padding > block.BlockSize() || !bytes.Equal(content[len(content)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, nil, ErrDecryption
+	}
+
+	return content[:len(content)-padding], alg, nil
+}
+
+// newCipher returns the block cipher of the algorithm with the key. If the
+// key is nil, a random one is generated.
+func newCipher(alg asn1.ObjectIdentifier, key []byte) (cipher.Block, []byte, error) {
+	var (
+		size     int
+		newBlock func([]byte) (cipher.Block, error)
+	)
+	switch {
+	case alg.Equal(OIDAES128CBC):
+		size, newBlock = 16, aes.NewCipher
+	case alg.Equal(OIDAES192CBC):
+		size, newBlock = 24, aes.NewCipher
+	case alg.Equal(OIDAES256CBC):
+		size, newBlock = 32, aes.NewCipher
+	case alg.Equal(OIDDESEDE3CBC):
+		size, newBlock = 24, des.NewTripleDESCipher
+	case alg.Equal(OIDDESCBC):
+		size, newBlock = 8, des.NewCipher
+	default:
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+	if key == nil {
+		key = make([]byte, size)
+		if _, err := rand.Read(key); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(key) != size {
+		return nil, nil, ErrDecryption
+	}
+	block, err := newBlock(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return block, key, nil
+}
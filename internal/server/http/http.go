@@ -2,9 +2,12 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/hantdev/certs/internal/server"
 )
@@ -37,6 +40,11 @@ func (s *httpServer) Start() error {
 	switch {
 	case s.Config.CertFile != "" || s.Config.KeyFile != "":
 		s.Protocol = httpsProtocol
+		tlsConfig, err := s.tlsConfig()
+		if err != nil {
+			return err
+		}
+		s.server.TLSConfig = tlsConfig
 		s.Logger.Info(fmt.Sprintf("%s service %s server listening at %s with TLS cert %s and key %s", s.Name, s.Protocol, s.Address, s.Config.CertFile, s.Config.KeyFile))
 		go func() {
 			errCh <- s.server.ListenAndServeTLS(s.Config.CertFile, s.Config.KeyFile)
@@ -55,6 +63,27 @@ func (s *httpServer) Start() error {
 	}
 }
 
+// tlsConfig requests optional client certificates, which authenticate EST
+// re-enrollments. If client CAs are configured, the certificates must be
+// issued by them.
+func (s *httpServer) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{ClientAuth: tls.RequestClientCert}
+	if s.Config.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+	clientCA, err := os.ReadFile(s.Config.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client ca file: %w", err)
+	}
+	tlsConfig.ClientCAs = x509.NewCertPool()
+	if !tlsConfig.ClientCAs.AppendCertsFromPEM(clientCA) {
+		return nil, fmt.Errorf("failed to append client ca to tls.Config")
+	}
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	return tlsConfig, nil
+}
+
 func (s *httpServer) Stop() error {
 	defer s.Cancel()
 	ctx, cancel := context.WithTimeout(context.Background(), server.StopWaitTime)
@@ -0,0 +1,189 @@
+package certs
+
+import (
+	"context"
+	"encoding/pem"
+	"sort"
+
+	"github.com/hantdev/certs/errors"
+)
+
+var (
+	ErrIssuerNotFound = errors.New("issuer not found")
+	ErrIssuerRetired  = errors.New("issuer has been retired")
+)
+
+// CreateIssuer creates a new named intermediate CA signed by the root CA.
+func (s *service) CreateIssuer(ctx context.Context, name string) (Issuer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findIssuer(name) != nil {
+		return Issuer{}, errors.Wrap(ErrConflict, errors.New("issuer already exists"))
+	}
+	if s.rootCA == nil {
+		return Issuer{}, ErrRootCANotFound
+	}
+	if s.rootCA.Signer == nil {
+		return Issuer{}, ErrCAKeyUnavailable
+	}
+
+	ca, err := s.createIntermediateCA(ctx, s.rootCA, name, s.config)
+	if err != nil {
+		return Issuer{}, err
+	}
+	s.intermediates[ca.SerialNumber] = ca
+
+	return issuerFromCA(ca), nil
+}
+
+// ListIssuers lists the active and retired issuers sorted by name.
+func (s *service) ListIssuers(ctx context.Context) ([]Issuer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	issuers := make([]Issuer, 0, len(s.intermediates))
+	for _, ca := range s.intermediates {
+		issuers = append(issuers, issuerFromCA(ca))
+	}
+	sort.Slice(issuers, func(i, j int) bool {
+		if issuers[i].Name != issuers[j].Name {
+			return issuers[i].Name < issuers[j].Name
+		}
+		return !issuers[i].Retired && issuers[j].Retired
+	})
+
+	return issuers, nil
+}
+
+// RetireIssuer stops the issuer from issuing new certificates. A retired
+// issuer keeps signing CRLs and OCSP responses for the certificates it issued.
+func (s *service) RetireIssuer(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ca := s.findIssuer(name)
+	if ca == nil {
+		return ErrIssuerNotFound
+	}
+
+	return s.retireCA(ctx, ca)
+}
+
+// issuer returns the active issuer with the given name. An empty name selects
+// the default issuer.
+func (s *service) issuer(name string) (*CA, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ca := s.findIssuer(name)
+	if ca == nil && issuerName(name) == DefaultIssuer {
+		return nil, ErrIntermediateCANotFound
+	}
+	if ca == nil {
+		return nil, ErrIssuerNotFound
+	}
+	if ca.Certificate == nil || ca.Signer == nil {
+		return nil, ErrIntermediateCANotFound
+	}
+
+	return ca, nil
+}
+
+// signingIssuer returns the issuer with the given name for signing CRLs,
+// falling back to the most recently retired issuer with that name.
+func (s *service) signingIssuer(name string) (*CA, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if ca := s.findIssuer(name); ca != nil {
+		return ca, nil
+	}
+	name = issuerName(name)
+	var retired *CA
+	for _, ca := range s.intermediates {
+		if ca.Name == name && (retired == nil || ca.Certificate.NotAfter.After(retired.Certificate.NotAfter)) {
+			retired = ca
+		}
+	}
+	if retired == nil {
+		return nil, ErrIssuerNotFound
+	}
+
+	return retired, nil
+}
+
+// issuerOf returns the CA that issued the certificate. Certificates issued
+// before named issuers were introduced belong to the default issuer.
+func (s *service) issuerOf(cert Certificate) *CA {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if ca, ok := s.intermediates[cert.IssuerSerial]; ok {
+		return ca
+	}
+
+	return s.findIssuer(DefaultIssuer)
+}
+
+// root returns the active root CA.
+func (s *service) root() *CA {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rootCA
+}
+
+// findIssuer returns the active issuer with the given name. The caller must hold the lock.
+func (s *service) findIssuer(name string) *CA {
+	name = issuerName(name)
+	for _, ca := range s.intermediates {
+		if ca.Name == name && !ca.Retired {
+			return ca
+		}
+	}
+
+	return nil
+}
+
+// activeIssuers returns the names of the active issuers. The caller must hold the lock.
+func (s *service) activeIssuers() []string {
+	var names []string
+	for _, ca := range s.intermediates {
+		if !ca.Retired {
+			names = append(names, ca.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// retireIssuer retires the active issuer with the given name, so that the
+// certificates it issued stay valid and are renewed by its replacement. The
+// caller must hold the lock.
+func (s *service) retireIssuer(ctx context.Context, name string) error {
+	ca := s.findIssuer(name)
+	if ca == nil {
+		return nil
+	}
+
+	return s.retireCA(ctx, ca)
+}
+
+func issuerFromCA(ca *CA) Issuer {
+	return Issuer{
+		Name:         ca.Name,
+		SerialNumber: ca.SerialNumber,
+		Certificate:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate.Raw}),
+		ExpiryTime:   ca.Certificate.NotAfter,
+		Retired:      ca.Retired,
+	}
+}
+
+func issuerName(name string) string {
+	if name == "" {
+		return DefaultIssuer
+	}
+	return name
+}
\ No newline at end of file
@@ -0,0 +1,171 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+
+	"github.com/hantdev/certs/errors"
+)
+
+const (
+	KeyAlgorithmRSA     = "rsa"
+	KeyAlgorithmECDSA   = "ecdsa"
+	KeyAlgorithmEd25519 = "ed25519"
+
+	minRSAKeyBits = 2048
+	defECKeyBits  = 256
+)
+
+var (
+	ErrKeyAlgorithm = errors.New("unsupported key algorithm")
+	ErrKeySize      = errors.New("unsupported key size")
+)
+
+// validateKeyAlgorithm checks that the key algorithm and size combination is supported.
+func validateKeyAlgorithm(algorithm string, size int) error {
+	switch strings.ToLower(algorithm) {
+	case "", KeyAlgorithmRSA:
+		if size != 0 && size < minRSAKeyBits {
+			return ErrKeySize
+		}
+	case KeyAlgorithmECDSA:
+		if _, err := ECDSACurve(size); err != nil {
+			return err
+		}
+	case KeyAlgorithmEd25519:
+	default:
+		return ErrKeyAlgorithm
+	}
+
+	return nil
+}
+
+// GenerateKey generates a new private key for the given algorithm.
+// RSA and empty algorithm default to 2048 bit RSA, ECDSA defaults to the P-256 curve.
+func GenerateKey(algorithm string, size int) (crypto.Signer, error) {
+	switch strings.ToLower(algorithm) {
+	case "", KeyAlgorithmRSA:
+		if size == 0 {
+			size = PrivateKeyBytes
+		}
+		if size < minRSAKeyBits {
+			return nil, ErrKeySize
+		}
+		return rsa.GenerateKey(rand.Reader, size)
+	case KeyAlgorithmECDSA:
+		c, err := ECDSACurve(size)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.GenerateKey(c, rand.Reader)
+	case KeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	default:
+		return nil, ErrKeyAlgorithm
+	}
+}
+
+// ECDSACurve returns the NIST curve for the given key size in bits.
+func ECDSACurve(size int) (elliptic.Curve, error) {
+	switch size {
+	case 0, defECKeyBits:
+		return elliptic.P256(), nil
+	case 384:
+		return elliptic.P384(), nil
+	case 521:
+		return elliptic.P521(), nil
+	default:
+		return nil, ErrKeySize
+	}
+}
+
+// keyUsage returns the key usage bits suitable for the given public key.
+// Key encipherment only applies to RSA keys.
+func keyUsage(pubKey crypto.PublicKey, usage x509.KeyUsage) x509.KeyUsage {
+	if _, ok := pubKey.(*rsa.PublicKey); ok {
+		return usage | x509.KeyUsageKeyEncipherment
+	}
+	return usage
+}
+
+// MarshalPrivateKey encodes a private key to PEM.
+// RSA keys are stored as PKCS#1, ECDSA keys as SEC 1 and Ed25519 keys as PKCS#8.
+func MarshalPrivateKey(key crypto.PrivateKey) ([]byte, error) {
+	var (
+		der     []byte
+		keyType string
+		err     error
+	)
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		der = x509.MarshalPKCS1PrivateKey(k)
+		keyType = RSAPrivateKey
+	case *ecdsa.PrivateKey:
+		der, err = x509.MarshalECPrivateKey(k)
+		keyType = ECPrivateKey
+	case ed25519.PrivateKey:
+		der, err = x509.MarshalPKCS8PrivateKey(k)
+		keyType = PrivateKey
+	default:
+		return nil, ErrPrivKeyType
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: keyType, Bytes: der}), nil
+}
+
+// ParsePrivateKey decodes a PEM encoded RSA, ECDSA or Ed25519 private key.
+func ParsePrivateKey(pemKey []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, ErrFailedParse
+	}
+
+	var (
+		key any
+		err error
+	)
+
+	switch block.Type {
+	case RSAPrivateKey:
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case ECPrivateKey:
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			// Older releases stored ECDSA keys as PKCS#8 under the EC header.
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+	case PrivateKey, PKCS8PrivateKey, EDPrivateKey:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, ErrPrivKeyType
+	}
+	if err != nil {
+		return nil, errors.Wrap(ErrFailedParse, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrPrivKeyType
+	}
+	switch signer.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return signer, nil
+	default:
+		return nil, ErrPrivKeyType
+	}
+}
@@ -8,6 +8,8 @@ import (
 	certs "github.com/hantdev/certs"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // MockRepository is an autogenerated mock type for the Repository type
@@ -23,6 +25,110 @@ func (_m *MockRepository) EXPECT() *MockRepository_Expecter {
 	return &MockRepository_Expecter{mock: &_m.Mock}
 }
 
+// CountValidCerts provides a mock function with given fields: ctx, issuerSerials
+func (_m *MockRepository) CountValidCerts(ctx context.Context, issuerSerials []string) (uint64, error) {
+	ret := _m.Called(ctx, issuerSerials)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountValidCerts")
+	}
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) (uint64, error)); ok {
+		return rf(ctx, issuerSerials)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) uint64); ok {
+		r0 = rf(ctx, issuerSerials)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, issuerSerials)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_CountValidCerts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountValidCerts'
+type MockRepository_CountValidCerts_Call struct {
+	*mock.Call
+}
+
+// CountValidCerts is a helper method to define mock.On call
+//   - ctx context.Context
+//   - issuerSerials []string
+func (_e *MockRepository_Expecter) CountValidCerts(ctx interface{}, issuerSerials interface{}) *MockRepository_CountValidCerts_Call {
+	return &MockRepository_CountValidCerts_Call{Call: _e.mock.On("CountValidCerts", ctx, issuerSerials)}
+}
+
+func (_c *MockRepository_CountValidCerts_Call) Run(run func(ctx context.Context, issuerSerials []string)) *MockRepository_CountValidCerts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_CountValidCerts_Call) Return(_a0 uint64, _a1 error) *MockRepository_CountValidCerts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_CountValidCerts_Call) RunAndReturn(run func(context.Context, []string) (uint64, error)) *MockRepository_CountValidCerts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateCSR provides a mock function with given fields: ctx, csr
+func (_m *MockRepository) CreateCSR(ctx context.Context, csr certs.CSR) error {
+	ret := _m.Called(ctx, csr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCSR")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.CSR) error); ok {
+		r0 = rf(ctx, csr)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_CreateCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateCSR'
+type MockRepository_CreateCSR_Call struct {
+	*mock.Call
+}
+
+// CreateCSR is a helper method to define mock.On call
+//   - ctx context.Context
+//   - csr certs.CSR
+func (_e *MockRepository_Expecter) CreateCSR(ctx interface{}, csr interface{}) *MockRepository_CreateCSR_Call {
+	return &MockRepository_CreateCSR_Call{Call: _e.mock.On("CreateCSR", ctx, csr)}
+}
+
+func (_c *MockRepository_CreateCSR_Call) Run(run func(ctx context.Context, csr certs.CSR)) *MockRepository_CreateCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.CSR))
+	})
+	return _c
+}
+
+func (_c *MockRepository_CreateCSR_Call) Return(_a0 error) *MockRepository_CreateCSR_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_CreateCSR_Call) RunAndReturn(run func(context.Context, certs.CSR) error) *MockRepository_CreateCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateCert provides a mock function with given fields: ctx, cert
 func (_m *MockRepository) CreateCert(ctx context.Context, cert certs.Certificate) error {
 	ret := _m.Called(ctx, cert)
@@ -70,6 +176,53 @@ func (_c *MockRepository_CreateCert_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
+// CreateProfile provides a mock function with given fields: ctx, profile
+func (_m *MockRepository) CreateProfile(ctx context.Context, profile certs.Profile) error {
+	ret := _m.Called(ctx, profile)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateProfile")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.Profile) error); ok {
+		r0 = rf(ctx, profile)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_CreateProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateProfile'
+type MockRepository_CreateProfile_Call struct {
+	*mock.Call
+}
+
+// CreateProfile is a helper method to define mock.On call
+//   - ctx context.Context
+//   - profile certs.Profile
+func (_e *MockRepository_Expecter) CreateProfile(ctx interface{}, profile interface{}) *MockRepository_CreateProfile_Call {
+	return &MockRepository_CreateProfile_Call{Call: _e.mock.On("CreateProfile", ctx, profile)}
+}
+
+func (_c *MockRepository_CreateProfile_Call) Run(run func(ctx context.Context, profile certs.Profile)) *MockRepository_CreateProfile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.Profile))
+	})
+	return _c
+}
+
+func (_c *MockRepository_CreateProfile_Call) Return(_a0 error) *MockRepository_CreateProfile_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_CreateProfile_Call) RunAndReturn(run func(context.Context, certs.Profile) error) *MockRepository_CreateProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetCAs provides a mock function with given fields: ctx, caType
 func (_m *MockRepository) GetCAs(ctx context.Context, caType ...certs.CertType) ([]certs.Certificate, error) {
 	_va := make([]interface{}, len(caType))
@@ -143,6 +296,63 @@ func (_c *MockRepository_GetCAs_Call) RunAndReturn(run func(context.Context, ...
 	return _c
 }
 
+// ListCSRs provides a mock function with given fields: ctx, pm
+func (_m *MockRepository) ListCSRs(ctx context.Context, pm certs.PageMetadata) (certs.CSRPage, error) {
+	ret := _m.Called(ctx, pm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCSRs")
+	}
+
+	var r0 certs.CSRPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.PageMetadata) (certs.CSRPage, error)); ok {
+		return rf(ctx, pm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, certs.PageMetadata) certs.CSRPage); ok {
+		r0 = rf(ctx, pm)
+	} else {
+		r0 = ret.Get(0).(certs.CSRPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, certs.PageMetadata) error); ok {
+		r1 = rf(ctx, pm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_ListCSRs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListCSRs'
+type MockRepository_ListCSRs_Call struct {
+	*mock.Call
+}
+
+// ListCSRs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pm certs.PageMetadata
+func (_e *MockRepository_Expecter) ListCSRs(ctx interface{}, pm interface{}) *MockRepository_ListCSRs_Call {
+	return &MockRepository_ListCSRs_Call{Call: _e.mock.On("ListCSRs", ctx, pm)}
+}
+
+func (_c *MockRepository_ListCSRs_Call) Run(run func(ctx context.Context, pm certs.PageMetadata)) *MockRepository_ListCSRs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.PageMetadata))
+	})
+	return _c
+}
+
+func (_c *MockRepository_ListCSRs_Call) Return(_a0 certs.CSRPage, _a1 error) *MockRepository_ListCSRs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_ListCSRs_Call) RunAndReturn(run func(context.Context, certs.PageMetadata) (certs.CSRPage, error)) *MockRepository_ListCSRs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListCerts provides a mock function with given fields: ctx, pm
 func (_m *MockRepository) ListCerts(ctx context.Context, pm certs.PageMetadata) (certs.CertificatePage, error) {
 	ret := _m.Called(ctx, pm)
@@ -200,24 +410,24 @@ func (_c *MockRepository_ListCerts_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
-// ListRevokedCerts provides a mock function with given fields: ctx
-func (_m *MockRepository) ListRevokedCerts(ctx context.Context) ([]certs.Certificate, error) {
+// ListProfiles provides a mock function with given fields: ctx
+func (_m *MockRepository) ListProfiles(ctx context.Context) ([]certs.Profile, error) {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListRevokedCerts")
+		panic("no return value specified for ListProfiles")
 	}
 
-	var r0 []certs.Certificate
+	var r0 []certs.Profile
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]certs.Certificate, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) ([]certs.Profile, error)); ok {
 		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []certs.Certificate); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) []certs.Profile); ok {
 		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]certs.Certificate)
+			r0 = ret.Get(0).([]certs.Profile)
 		}
 	}
 
@@ -230,102 +440,138 @@ func (_m *MockRepository) ListRevokedCerts(ctx context.Context) ([]certs.Certifi
 	return r0, r1
 }
 
-// MockRepository_ListRevokedCerts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRevokedCerts'
-type MockRepository_ListRevokedCerts_Call struct {
+// MockRepository_ListProfiles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProfiles'
+type MockRepository_ListProfiles_Call struct {
 	*mock.Call
 }
 
-// ListRevokedCerts is a helper method to define mock.On call
+// ListProfiles is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockRepository_Expecter) ListRevokedCerts(ctx interface{}) *MockRepository_ListRevokedCerts_Call {
-	return &MockRepository_ListRevokedCerts_Call{Call: _e.mock.On("ListRevokedCerts", ctx)}
+func (_e *MockRepository_Expecter) ListProfiles(ctx interface{}) *MockRepository_ListProfiles_Call {
+	return &MockRepository_ListProfiles_Call{Call: _e.mock.On("ListProfiles", ctx)}
 }
 
-func (_c *MockRepository_ListRevokedCerts_Call) Run(run func(ctx context.Context)) *MockRepository_ListRevokedCerts_Call {
+func (_c *MockRepository_ListProfiles_Call) Run(run func(ctx context.Context)) *MockRepository_ListProfiles_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockRepository_ListRevokedCerts_Call) Return(_a0 []certs.Certificate, _a1 error) *MockRepository_ListRevokedCerts_Call {
+func (_c *MockRepository_ListProfiles_Call) Return(_a0 []certs.Profile, _a1 error) *MockRepository_ListProfiles_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockRepository_ListRevokedCerts_Call) RunAndReturn(run func(context.Context) ([]certs.Certificate, error)) *MockRepository_ListRevokedCerts_Call {
+func (_c *MockRepository_ListProfiles_Call) RunAndReturn(run func(context.Context) ([]certs.Profile, error)) *MockRepository_ListProfiles_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RemoveCert provides a mock function with given fields: ctx, entityId
-func (_m *MockRepository) RemoveCert(ctx context.Context, entityId string) error {
-	ret := _m.Called(ctx, entityId)
+// ListRevocationChanges provides a mock function with given fields: ctx, since, issuerSerials
+func (_m *MockRepository) ListRevocationChanges(ctx context.Context, since time.Time, issuerSerials ...string) ([]certs.Certificate, error) {
+	_va := make([]interface{}, len(issuerSerials))
+	for _i := range issuerSerials {
+		_va[_i] = issuerSerials[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, since)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RemoveCert")
+		panic("no return value specified for ListRevocationChanges")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, entityId)
+	var r0 []certs.Certificate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, ...string) ([]certs.Certificate, error)); ok {
+		return rf(ctx, since, issuerSerials...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, ...string) []certs.Certificate); ok {
+		r0 = rf(ctx, since, issuerSerials...)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]certs.Certificate)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, ...string) error); ok {
+		r1 = rf(ctx, since, issuerSerials...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockRepository_RemoveCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveCert'
-type MockRepository_RemoveCert_Call struct {
+// MockRepository_ListRevocationChanges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRevocationChanges'
+type MockRepository_ListRevocationChanges_Call struct {
 	*mock.Call
 }
 
-// RemoveCert is a helper method to define mock.On call
+// ListRevocationChanges is a helper method to define mock.On call
 //   - ctx context.Context
-//   - entityId string
-func (_e *MockRepository_Expecter) RemoveCert(ctx interface{}, entityId interface{}) *MockRepository_RemoveCert_Call {
-	return &MockRepository_RemoveCert_Call{Call: _e.mock.On("RemoveCert", ctx, entityId)}
+//   - since time.Time
+//   - issuerSerials ...string
+func (_e *MockRepository_Expecter) ListRevocationChanges(ctx interface{}, since interface{}, issuerSerials ...interface{}) *MockRepository_ListRevocationChanges_Call {
+	return &MockRepository_ListRevocationChanges_Call{Call: _e.mock.On("ListRevocationChanges",
+		append([]interface{}{ctx, since}, issuerSerials...)...)}
 }
 
-func (_c *MockRepository_RemoveCert_Call) Run(run func(ctx context.Context, entityId string)) *MockRepository_RemoveCert_Call {
+func (_c *MockRepository_ListRevocationChanges_Call) Run(run func(ctx context.Context, since time.Time, issuerSerials ...string)) *MockRepository_ListRevocationChanges_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		variadicArgs := make([]string, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), args[1].(time.Time), variadicArgs...)
 	})
 	return _c
 }
 
-func (_c *MockRepository_RemoveCert_Call) Return(_a0 error) *MockRepository_RemoveCert_Call {
-	_c.Call.Return(_a0)
+func (_c *MockRepository_ListRevocationChanges_Call) Return(_a0 []certs.Certificate, _a1 error) *MockRepository_ListRevocationChanges_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockRepository_RemoveCert_Call) RunAndReturn(run func(context.Context, string) error) *MockRepository_RemoveCert_Call {
+func (_c *MockRepository_ListRevocationChanges_Call) RunAndReturn(run func(context.Context, time.Time, ...string) ([]certs.Certificate, error)) *MockRepository_ListRevocationChanges_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RetrieveCert provides a mock function with given fields: ctx, serialNumber
-func (_m *MockRepository) RetrieveCert(ctx context.Context, serialNumber string) (certs.Certificate, error) {
-	ret := _m.Called(ctx, serialNumber)
+// ListRevokedCerts provides a mock function with given fields: ctx, issuerSerials
+func (_m *MockRepository) ListRevokedCerts(ctx context.Context, issuerSerials ...string) ([]certs.Certificate, error) {
+	_va := make([]interface{}, len(issuerSerials))
+	for _i := range issuerSerials {
+		_va[_i] = issuerSerials[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RetrieveCert")
+		panic("no return value specified for ListRevokedCerts")
 	}
 
-	var r0 certs.Certificate
+	var r0 []certs.Certificate
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.Certificate, error)); ok {
-		return rf(ctx, serialNumber)
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) ([]certs.Certificate, error)); ok {
+		return rf(ctx, issuerSerials...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) certs.Certificate); ok {
-		r0 = rf(ctx, serialNumber)
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) []certs.Certificate); ok {
+		r0 = rf(ctx, issuerSerials...)
 	} else {
-		r0 = ret.Get(0).(certs.Certificate)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]certs.Certificate)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, serialNumber)
+	if rf, ok := ret.Get(1).(func(context.Context, ...string) error); ok {
+		r1 = rf(ctx, issuerSerials...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -333,73 +579,605 @@ func (_m *MockRepository) RetrieveCert(ctx context.Context, serialNumber string)
 	return r0, r1
 }
 
-// MockRepository_RetrieveCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCert'
-type MockRepository_RetrieveCert_Call struct {
+// MockRepository_ListRevokedCerts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRevokedCerts'
+type MockRepository_ListRevokedCerts_Call struct {
 	*mock.Call
 }
 
-// RetrieveCert is a helper method to define mock.On call
+// ListRevokedCerts is a helper method to define mock.On call
 //   - ctx context.Context
-//   - serialNumber string
-func (_e *MockRepository_Expecter) RetrieveCert(ctx interface{}, serialNumber interface{}) *MockRepository_RetrieveCert_Call {
-	return &MockRepository_RetrieveCert_Call{Call: _e.mock.On("RetrieveCert", ctx, serialNumber)}
+//   - issuerSerials ...string
+func (_e *MockRepository_Expecter) ListRevokedCerts(ctx interface{}, issuerSerials ...interface{}) *MockRepository_ListRevokedCerts_Call {
+	return &MockRepository_ListRevokedCerts_Call{Call: _e.mock.On("ListRevokedCerts",
+		append([]interface{}{ctx}, issuerSerials...)...)}
 }
 
-func (_c *MockRepository_RetrieveCert_Call) Run(run func(ctx context.Context, serialNumber string)) *MockRepository_RetrieveCert_Call {
+func (_c *MockRepository_ListRevokedCerts_Call) Run(run func(ctx context.Context, issuerSerials ...string)) *MockRepository_ListRevokedCerts_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		variadicArgs := make([]string, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), variadicArgs...)
 	})
 	return _c
 }
 
-func (_c *MockRepository_RetrieveCert_Call) Return(_a0 certs.Certificate, _a1 error) *MockRepository_RetrieveCert_Call {
+func (_c *MockRepository_ListRevokedCerts_Call) Return(_a0 []certs.Certificate, _a1 error) *MockRepository_ListRevokedCerts_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockRepository_RetrieveCert_Call) RunAndReturn(run func(context.Context, string) (certs.Certificate, error)) *MockRepository_RetrieveCert_Call {
+func (_c *MockRepository_ListRevokedCerts_Call) RunAndReturn(run func(context.Context, ...string) ([]certs.Certificate, error)) *MockRepository_ListRevokedCerts_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// UpdateCert provides a mock function with given fields: ctx, cert
-func (_m *MockRepository) UpdateCert(ctx context.Context, cert certs.Certificate) error {
-	ret := _m.Called(ctx, cert)
+// ListStaleKeys provides a mock function with given fields: ctx, keyVersion, limit
+func (_m *MockRepository) ListStaleKeys(ctx context.Context, keyVersion int, limit uint64) ([]certs.Certificate, error) {
+	ret := _m.Called(ctx, keyVersion, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateCert")
+		panic("no return value specified for ListStaleKeys")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, certs.Certificate) error); ok {
-		r0 = rf(ctx, cert)
+	var r0 []certs.Certificate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, uint64) ([]certs.Certificate, error)); ok {
+		return rf(ctx, keyVersion, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, uint64) []certs.Certificate); ok {
+		r0 = rf(ctx, keyVersion, limit)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]certs.Certificate)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, int, uint64) error); ok {
+		r1 = rf(ctx, keyVersion, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockRepository_UpdateCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateCert'
-type MockRepository_UpdateCert_Call struct {
+// MockRepository_ListStaleKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListStaleKeys'
+type MockRepository_ListStaleKeys_Call struct {
 	*mock.Call
 }
 
-// UpdateCert is a helper method to define mock.On call
+// ListStaleKeys is a helper method to define mock.On call
 //   - ctx context.Context
-//   - cert certs.Certificate
-func (_e *MockRepository_Expecter) UpdateCert(ctx interface{}, cert interface{}) *MockRepository_UpdateCert_Call {
-	return &MockRepository_UpdateCert_Call{Call: _e.mock.On("UpdateCert", ctx, cert)}
+//   - keyVersion int
+//   - limit uint64
+func (_e *MockRepository_Expecter) ListStaleKeys(ctx interface{}, keyVersion interface{}, limit interface{}) *MockRepository_ListStaleKeys_Call {
+	return &MockRepository_ListStaleKeys_Call{Call: _e.mock.On("ListStaleKeys", ctx, keyVersion, limit)}
 }
 
-func (_c *MockRepository_UpdateCert_Call) Run(run func(ctx context.Context, cert certs.Certificate)) *MockRepository_UpdateCert_Call {
+func (_c *MockRepository_ListStaleKeys_Call) Run(run func(ctx context.Context, keyVersion int, limit uint64)) *MockRepository_ListStaleKeys_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(certs.Certificate))
+		run(args[0].(context.Context), args[1].(int), args[2].(uint64))
 	})
 	return _c
 }
 
-func (_c *MockRepository_UpdateCert_Call) Return(_a0 error) *MockRepository_UpdateCert_Call {
+func (_c *MockRepository_ListStaleKeys_Call) Return(_a0 []certs.Certificate, _a1 error) *MockRepository_ListStaleKeys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_ListStaleKeys_Call) RunAndReturn(run func(context.Context, int, uint64) ([]certs.Certificate, error)) *MockRepository_ListStaleKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NextCRLNumber provides a mock function with given fields: ctx, issuerSerial
+func (_m *MockRepository) NextCRLNumber(ctx context.Context, issuerSerial string) (uint64, error) {
+	ret := _m.Called(ctx, issuerSerial)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextCRLNumber")
+	}
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (uint64, error)); ok {
+		return rf(ctx, issuerSerial)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) uint64); ok {
+		r0 = rf(ctx, issuerSerial)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, issuerSerial)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_NextCRLNumber_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NextCRLNumber'
+type MockRepository_NextCRLNumber_Call struct {
+	*mock.Call
+}
+
+// NextCRLNumber is a helper method to define mock.On call
+//   - ctx context.Context
+//   - issuerSerial string
+func (_e *MockRepository_Expecter) NextCRLNumber(ctx interface{}, issuerSerial interface{}) *MockRepository_NextCRLNumber_Call {
+	return &MockRepository_NextCRLNumber_Call{Call: _e.mock.On("NextCRLNumber", ctx, issuerSerial)}
+}
+
+func (_c *MockRepository_NextCRLNumber_Call) Run(run func(ctx context.Context, issuerSerial string)) *MockRepository_NextCRLNumber_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_NextCRLNumber_Call) Return(_a0 uint64, _a1 error) *MockRepository_NextCRLNumber_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_NextCRLNumber_Call) RunAndReturn(run func(context.Context, string) (uint64, error)) *MockRepository_NextCRLNumber_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RedeemToken provides a mock function with given fields: ctx, id, expiresAt
+func (_m *MockRepository) RedeemToken(ctx context.Context, id string, expiresAt time.Time) error {
+	ret := _m.Called(ctx, id, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RedeemToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, id, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_RedeemToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RedeemToken'
+type MockRepository_RedeemToken_Call struct {
+	*mock.Call
+}
+
+// RedeemToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+//   - expiresAt time.Time
+func (_e *MockRepository_Expecter) RedeemToken(ctx interface{}, id interface{}, expiresAt interface{}) *MockRepository_RedeemToken_Call {
+	return &MockRepository_RedeemToken_Call{Call: _e.mock.On("RedeemToken", ctx, id, expiresAt)}
+}
+
+func (_c *MockRepository_RedeemToken_Call) Run(run func(ctx context.Context, id string, expiresAt time.Time)) *MockRepository_RedeemToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RedeemToken_Call) Return(_a0 error) *MockRepository_RedeemToken_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_RedeemToken_Call) RunAndReturn(run func(context.Context, string, time.Time) error) *MockRepository_RedeemToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveCert provides a mock function with given fields: ctx, entityId
+func (_m *MockRepository) RemoveCert(ctx context.Context, entityId string) error {
+	ret := _m.Called(ctx, entityId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveCert")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, entityId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_RemoveCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveCert'
+type MockRepository_RemoveCert_Call struct {
+	*mock.Call
+}
+
+// RemoveCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - entityId string
+func (_e *MockRepository_Expecter) RemoveCert(ctx interface{}, entityId interface{}) *MockRepository_RemoveCert_Call {
+	return &MockRepository_RemoveCert_Call{Call: _e.mock.On("RemoveCert", ctx, entityId)}
+}
+
+func (_c *MockRepository_RemoveCert_Call) Run(run func(ctx context.Context, entityId string)) *MockRepository_RemoveCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RemoveCert_Call) Return(_a0 error) *MockRepository_RemoveCert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_RemoveCert_Call) RunAndReturn(run func(context.Context, string) error) *MockRepository_RemoveCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveCertBySerial provides a mock function with given fields: ctx, serialNumber
+func (_m *MockRepository) RemoveCertBySerial(ctx context.Context, serialNumber string) error {
+	ret := _m.Called(ctx, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveCertBySerial")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, serialNumber)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_RemoveCertBySerial_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveCertBySerial'
+type MockRepository_RemoveCertBySerial_Call struct {
+	*mock.Call
+}
+
+// RemoveCertBySerial is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serialNumber string
+func (_e *MockRepository_Expecter) RemoveCertBySerial(ctx interface{}, serialNumber interface{}) *MockRepository_RemoveCertBySerial_Call {
+	return &MockRepository_RemoveCertBySerial_Call{Call: _e.mock.On("RemoveCertBySerial", ctx, serialNumber)}
+}
+
+func (_c *MockRepository_RemoveCertBySerial_Call) Run(run func(ctx context.Context, serialNumber string)) *MockRepository_RemoveCertBySerial_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RemoveCertBySerial_Call) Return(_a0 error) *MockRepository_RemoveCertBySerial_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_RemoveCertBySerial_Call) RunAndReturn(run func(context.Context, string) error) *MockRepository_RemoveCertBySerial_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveProfile provides a mock function with given fields: ctx, name
+func (_m *MockRepository) RemoveProfile(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveProfile")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_RemoveProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveProfile'
+type MockRepository_RemoveProfile_Call struct {
+	*mock.Call
+}
+
+// RemoveProfile is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockRepository_Expecter) RemoveProfile(ctx interface{}, name interface{}) *MockRepository_RemoveProfile_Call {
+	return &MockRepository_RemoveProfile_Call{Call: _e.mock.On("RemoveProfile", ctx, name)}
+}
+
+func (_c *MockRepository_RemoveProfile_Call) Run(run func(ctx context.Context, name string)) *MockRepository_RemoveProfile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RemoveProfile_Call) Return(_a0 error) *MockRepository_RemoveProfile_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_RemoveProfile_Call) RunAndReturn(run func(context.Context, string) error) *MockRepository_RemoveProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveCSR provides a mock function with given fields: ctx, id
+func (_m *MockRepository) RetrieveCSR(ctx context.Context, id string) (certs.CSR, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveCSR")
+	}
+
+	var r0 certs.CSR
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.CSR, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) certs.CSR); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(certs.CSR)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCSR'
+type MockRepository_RetrieveCSR_Call struct {
+	*mock.Call
+}
+
+// RetrieveCSR is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockRepository_Expecter) RetrieveCSR(ctx interface{}, id interface{}) *MockRepository_RetrieveCSR_Call {
+	return &MockRepository_RetrieveCSR_Call{Call: _e.mock.On("RetrieveCSR", ctx, id)}
+}
+
+func (_c *MockRepository_RetrieveCSR_Call) Run(run func(ctx context.Context, id string)) *MockRepository_RetrieveCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveCSR_Call) Return(_a0 certs.CSR, _a1 error) *MockRepository_RetrieveCSR_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveCSR_Call) RunAndReturn(run func(context.Context, string) (certs.CSR, error)) *MockRepository_RetrieveCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveCert provides a mock function with given fields: ctx, serialNumber
+func (_m *MockRepository) RetrieveCert(ctx context.Context, serialNumber string) (certs.Certificate, error) {
+	ret := _m.Called(ctx, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveCert")
+	}
+
+	var r0 certs.Certificate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.Certificate, error)); ok {
+		return rf(ctx, serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) certs.Certificate); ok {
+		r0 = rf(ctx, serialNumber)
+	} else {
+		r0 = ret.Get(0).(certs.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, serialNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCert'
+type MockRepository_RetrieveCert_Call struct {
+	*mock.Call
+}
+
+// RetrieveCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serialNumber string
+func (_e *MockRepository_Expecter) RetrieveCert(ctx interface{}, serialNumber interface{}) *MockRepository_RetrieveCert_Call {
+	return &MockRepository_RetrieveCert_Call{Call: _e.mock.On("RetrieveCert", ctx, serialNumber)}
+}
+
+func (_c *MockRepository_RetrieveCert_Call) Run(run func(ctx context.Context, serialNumber string)) *MockRepository_RetrieveCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveCert_Call) Return(_a0 certs.Certificate, _a1 error) *MockRepository_RetrieveCert_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveCert_Call) RunAndReturn(run func(context.Context, string) (certs.Certificate, error)) *MockRepository_RetrieveCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveProfile provides a mock function with given fields: ctx, name
+func (_m *MockRepository) RetrieveProfile(ctx context.Context, name string) (certs.Profile, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveProfile")
+	}
+
+	var r0 certs.Profile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.Profile, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) certs.Profile); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(certs.Profile)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveProfile'
+type MockRepository_RetrieveProfile_Call struct {
+	*mock.Call
+}
+
+// RetrieveProfile is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockRepository_Expecter) RetrieveProfile(ctx interface{}, name interface{}) *MockRepository_RetrieveProfile_Call {
+	return &MockRepository_RetrieveProfile_Call{Call: _e.mock.On("RetrieveProfile", ctx, name)}
+}
+
+func (_c *MockRepository_RetrieveProfile_Call) Run(run func(ctx context.Context, name string)) *MockRepository_RetrieveProfile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveProfile_Call) Return(_a0 certs.Profile, _a1 error) *MockRepository_RetrieveProfile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveProfile_Call) RunAndReturn(run func(context.Context, string) (certs.Profile, error)) *MockRepository_RetrieveProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateCSR provides a mock function with given fields: ctx, csr, status
+func (_m *MockRepository) UpdateCSR(ctx context.Context, csr certs.CSR, status certs.CSRStatus) error {
+	ret := _m.Called(ctx, csr, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCSR")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.CSR, certs.CSRStatus) error); ok {
+		r0 = rf(ctx, csr, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_UpdateCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateCSR'
+type MockRepository_UpdateCSR_Call struct {
+	*mock.Call
+}
+
+// UpdateCSR is a helper method to define mock.On call
+//   - ctx context.Context
+//   - csr certs.CSR
+//   - status certs.CSRStatus
+func (_e *MockRepository_Expecter) UpdateCSR(ctx interface{}, csr interface{}, status interface{}) *MockRepository_UpdateCSR_Call {
+	return &MockRepository_UpdateCSR_Call{Call: _e.mock.On("UpdateCSR", ctx, csr, status)}
+}
+
+func (_c *MockRepository_UpdateCSR_Call) Run(run func(ctx context.Context, csr certs.CSR, status certs.CSRStatus)) *MockRepository_UpdateCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.CSR), args[2].(certs.CSRStatus))
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateCSR_Call) Return(_a0 error) *MockRepository_UpdateCSR_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_UpdateCSR_Call) RunAndReturn(run func(context.Context, certs.CSR, certs.CSRStatus) error) *MockRepository_UpdateCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateCert provides a mock function with given fields: ctx, cert
+func (_m *MockRepository) UpdateCert(ctx context.Context, cert certs.Certificate) error {
+	ret := _m.Called(ctx, cert)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCert")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.Certificate) error); ok {
+		r0 = rf(ctx, cert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_UpdateCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateCert'
+type MockRepository_UpdateCert_Call struct {
+	*mock.Call
+}
+
+// UpdateCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cert certs.Certificate
+func (_e *MockRepository_Expecter) UpdateCert(ctx interface{}, cert interface{}) *MockRepository_UpdateCert_Call {
+	return &MockRepository_UpdateCert_Call{Call: _e.mock.On("UpdateCert", ctx, cert)}
+}
+
+func (_c *MockRepository_UpdateCert_Call) Run(run func(ctx context.Context, cert certs.Certificate)) *MockRepository_UpdateCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.Certificate))
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateCert_Call) Return(_a0 error) *MockRepository_UpdateCert_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
@@ -409,6 +1187,100 @@ func (_c *MockRepository_UpdateCert_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
+// UpdateKey provides a mock function with given fields: ctx, cert
+func (_m *MockRepository) UpdateKey(ctx context.Context, cert certs.Certificate) error {
+	ret := _m.Called(ctx, cert)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.Certificate) error); ok {
+		r0 = rf(ctx, cert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_UpdateKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateKey'
+type MockRepository_UpdateKey_Call struct {
+	*mock.Call
+}
+
+// UpdateKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cert certs.Certificate
+func (_e *MockRepository_Expecter) UpdateKey(ctx interface{}, cert interface{}) *MockRepository_UpdateKey_Call {
+	return &MockRepository_UpdateKey_Call{Call: _e.mock.On("UpdateKey", ctx, cert)}
+}
+
+func (_c *MockRepository_UpdateKey_Call) Run(run func(ctx context.Context, cert certs.Certificate)) *MockRepository_UpdateKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.Certificate))
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateKey_Call) Return(_a0 error) *MockRepository_UpdateKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_UpdateKey_Call) RunAndReturn(run func(context.Context, certs.Certificate) error) *MockRepository_UpdateKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateProfile provides a mock function with given fields: ctx, profile
+func (_m *MockRepository) UpdateProfile(ctx context.Context, profile certs.Profile) error {
+	ret := _m.Called(ctx, profile)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateProfile")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.Profile) error); ok {
+		r0 = rf(ctx, profile)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_UpdateProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateProfile'
+type MockRepository_UpdateProfile_Call struct {
+	*mock.Call
+}
+
+// UpdateProfile is a helper method to define mock.On call
+//   - ctx context.Context
+//   - profile certs.Profile
+func (_e *MockRepository_Expecter) UpdateProfile(ctx interface{}, profile interface{}) *MockRepository_UpdateProfile_Call {
+	return &MockRepository_UpdateProfile_Call{Call: _e.mock.On("UpdateProfile", ctx, profile)}
+}
+
+func (_c *MockRepository_UpdateProfile_Call) Run(run func(ctx context.Context, profile certs.Profile)) *MockRepository_UpdateProfile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.Profile))
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateProfile_Call) Return(_a0 error) *MockRepository_UpdateProfile_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_UpdateProfile_Call) RunAndReturn(run func(context.Context, certs.Profile) error) *MockRepository_UpdateProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockRepository creates a new instance of MockRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockRepository(t interface {
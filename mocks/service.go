@@ -9,7 +9,9 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
-	x509 "crypto/x509"
+	ocsp "golang.org/x/crypto/ocsp"
+
+	time "time"
 )
 
 // MockService is an autogenerated mock type for the Service type
@@ -25,29 +27,27 @@ func (_m *MockService) EXPECT() *MockService_Expecter {
 	return &MockService_Expecter{mock: &_m.Mock}
 }
 
-// GenerateCRL provides a mock function with given fields: ctx, caType
-func (_m *MockService) GenerateCRL(ctx context.Context, caType certs.CertType) ([]byte, error) {
-	ret := _m.Called(ctx, caType)
+// ApproveCSR provides a mock function with given fields: ctx, id, ttl, profile
+func (_m *MockService) ApproveCSR(ctx context.Context, id string, ttl string, profile string) (certs.CSR, error) {
+	ret := _m.Called(ctx, id, ttl, profile)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GenerateCRL")
+		panic("no return value specified for ApproveCSR")
 	}
 
-	var r0 []byte
+	var r0 certs.CSR
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, certs.CertType) ([]byte, error)); ok {
-		return rf(ctx, caType)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (certs.CSR, error)); ok {
+		return rf(ctx, id, ttl, profile)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, certs.CertType) []byte); ok {
-		r0 = rf(ctx, caType)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) certs.CSR); ok {
+		r0 = rf(ctx, id, ttl, profile)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]byte)
-		}
+		r0 = ret.Get(0).(certs.CSR)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, certs.CertType) error); ok {
-		r1 = rf(ctx, caType)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, id, ttl, profile)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -55,56 +55,58 @@ func (_m *MockService) GenerateCRL(ctx context.Context, caType certs.CertType) (
 	return r0, r1
 }
 
-// MockService_GenerateCRL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GenerateCRL'
-type MockService_GenerateCRL_Call struct {
+// MockService_ApproveCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApproveCSR'
+type MockService_ApproveCSR_Call struct {
 	*mock.Call
 }
 
-// GenerateCRL is a helper method to define mock.On call
+// ApproveCSR is a helper method to define mock.On call
 //   - ctx context.Context
-//   - caType certs.CertType
-func (_e *MockService_Expecter) GenerateCRL(ctx interface{}, caType interface{}) *MockService_GenerateCRL_Call {
-	return &MockService_GenerateCRL_Call{Call: _e.mock.On("GenerateCRL", ctx, caType)}
+//   - id string
+//   - ttl string
+//   - profile string
+func (_e *MockService_Expecter) ApproveCSR(ctx interface{}, id interface{}, ttl interface{}, profile interface{}) *MockService_ApproveCSR_Call {
+	return &MockService_ApproveCSR_Call{Call: _e.mock.On("ApproveCSR", ctx, id, ttl, profile)}
 }
 
-func (_c *MockService_GenerateCRL_Call) Run(run func(ctx context.Context, caType certs.CertType)) *MockService_GenerateCRL_Call {
+func (_c *MockService_ApproveCSR_Call) Run(run func(ctx context.Context, id string, ttl string, profile string)) *MockService_ApproveCSR_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(certs.CertType))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
 	})
 	return _c
 }
 
-func (_c *MockService_GenerateCRL_Call) Return(_a0 []byte, _a1 error) *MockService_GenerateCRL_Call {
+func (_c *MockService_ApproveCSR_Call) Return(_a0 certs.CSR, _a1 error) *MockService_ApproveCSR_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_GenerateCRL_Call) RunAndReturn(run func(context.Context, certs.CertType) ([]byte, error)) *MockService_GenerateCRL_Call {
+func (_c *MockService_ApproveCSR_Call) RunAndReturn(run func(context.Context, string, string, string) (certs.CSR, error)) *MockService_ApproveCSR_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetChainCA provides a mock function with given fields: ctx, token
-func (_m *MockService) GetChainCA(ctx context.Context, token string) (certs.Certificate, error) {
-	ret := _m.Called(ctx, token)
+// CreateIssuer provides a mock function with given fields: ctx, name
+func (_m *MockService) CreateIssuer(ctx context.Context, name string) (certs.Issuer, error) {
+	ret := _m.Called(ctx, name)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetChainCA")
+		panic("no return value specified for CreateIssuer")
 	}
 
-	var r0 certs.Certificate
+	var r0 certs.Issuer
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.Certificate, error)); ok {
-		return rf(ctx, token)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.Issuer, error)); ok {
+		return rf(ctx, name)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) certs.Certificate); ok {
-		r0 = rf(ctx, token)
+	if rf, ok := ret.Get(0).(func(context.Context, string) certs.Issuer); ok {
+		r0 = rf(ctx, name)
 	} else {
-		r0 = ret.Get(0).(certs.Certificate)
+		r0 = ret.Get(0).(certs.Issuer)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, token)
+		r1 = rf(ctx, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -112,56 +114,56 @@ func (_m *MockService) GetChainCA(ctx context.Context, token string) (certs.Cert
 	return r0, r1
 }
 
-// MockService_GetChainCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChainCA'
-type MockService_GetChainCA_Call struct {
+// MockService_CreateIssuer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateIssuer'
+type MockService_CreateIssuer_Call struct {
 	*mock.Call
 }
 
-// GetChainCA is a helper method to define mock.On call
+// CreateIssuer is a helper method to define mock.On call
 //   - ctx context.Context
-//   - token string
-func (_e *MockService_Expecter) GetChainCA(ctx interface{}, token interface{}) *MockService_GetChainCA_Call {
-	return &MockService_GetChainCA_Call{Call: _e.mock.On("GetChainCA", ctx, token)}
+//   - name string
+func (_e *MockService_Expecter) CreateIssuer(ctx interface{}, name interface{}) *MockService_CreateIssuer_Call {
+	return &MockService_CreateIssuer_Call{Call: _e.mock.On("CreateIssuer", ctx, name)}
 }
 
-func (_c *MockService_GetChainCA_Call) Run(run func(ctx context.Context, token string)) *MockService_GetChainCA_Call {
+func (_c *MockService_CreateIssuer_Call) Run(run func(ctx context.Context, name string)) *MockService_CreateIssuer_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockService_GetChainCA_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_GetChainCA_Call {
+func (_c *MockService_CreateIssuer_Call) Return(_a0 certs.Issuer, _a1 error) *MockService_CreateIssuer_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_GetChainCA_Call) RunAndReturn(run func(context.Context, string) (certs.Certificate, error)) *MockService_GetChainCA_Call {
+func (_c *MockService_CreateIssuer_Call) RunAndReturn(run func(context.Context, string) (certs.Issuer, error)) *MockService_CreateIssuer_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetEntityID provides a mock function with given fields: ctx, serialNumber
-func (_m *MockService) GetEntityID(ctx context.Context, serialNumber string) (string, error) {
-	ret := _m.Called(ctx, serialNumber)
+// CreateProfile provides a mock function with given fields: ctx, profile
+func (_m *MockService) CreateProfile(ctx context.Context, profile certs.Profile) (certs.Profile, error) {
+	ret := _m.Called(ctx, profile)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetEntityID")
+		panic("no return value specified for CreateProfile")
 	}
 
-	var r0 string
+	var r0 certs.Profile
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return rf(ctx, serialNumber)
+	if rf, ok := ret.Get(0).(func(context.Context, certs.Profile) (certs.Profile, error)); ok {
+		return rf(ctx, profile)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = rf(ctx, serialNumber)
+	if rf, ok := ret.Get(0).(func(context.Context, certs.Profile) certs.Profile); ok {
+		r0 = rf(ctx, profile)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(certs.Profile)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, serialNumber)
+	if rf, ok := ret.Get(1).(func(context.Context, certs.Profile) error); ok {
+		r1 = rf(ctx, profile)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -169,56 +171,58 @@ func (_m *MockService) GetEntityID(ctx context.Context, serialNumber string) (st
 	return r0, r1
 }
 
-// MockService_GetEntityID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetEntityID'
-type MockService_GetEntityID_Call struct {
+// MockService_CreateProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateProfile'
+type MockService_CreateProfile_Call struct {
 	*mock.Call
 }
 
-// GetEntityID is a helper method to define mock.On call
+// CreateProfile is a helper method to define mock.On call
 //   - ctx context.Context
-//   - serialNumber string
-func (_e *MockService_Expecter) GetEntityID(ctx interface{}, serialNumber interface{}) *MockService_GetEntityID_Call {
-	return &MockService_GetEntityID_Call{Call: _e.mock.On("GetEntityID", ctx, serialNumber)}
+//   - profile certs.Profile
+func (_e *MockService_Expecter) CreateProfile(ctx interface{}, profile interface{}) *MockService_CreateProfile_Call {
+	return &MockService_CreateProfile_Call{Call: _e.mock.On("CreateProfile", ctx, profile)}
 }
 
-func (_c *MockService_GetEntityID_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_GetEntityID_Call {
+func (_c *MockService_CreateProfile_Call) Run(run func(ctx context.Context, profile certs.Profile)) *MockService_CreateProfile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].(certs.Profile))
 	})
 	return _c
 }
 
-func (_c *MockService_GetEntityID_Call) Return(_a0 string, _a1 error) *MockService_GetEntityID_Call {
+func (_c *MockService_CreateProfile_Call) Return(_a0 certs.Profile, _a1 error) *MockService_CreateProfile_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_GetEntityID_Call) RunAndReturn(run func(context.Context, string) (string, error)) *MockService_GetEntityID_Call {
+func (_c *MockService_CreateProfile_Call) RunAndReturn(run func(context.Context, certs.Profile) (certs.Profile, error)) *MockService_CreateProfile_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// IssueCert provides a mock function with given fields: ctx, entityID, ttl, ipAddrs, option
-func (_m *MockService) IssueCert(ctx context.Context, entityID string, ttl string, ipAddrs []string, option certs.SubjectOptions) (certs.Certificate, error) {
-	ret := _m.Called(ctx, entityID, ttl, ipAddrs, option)
+// ExportCA provides a mock function with given fields: ctx, serialNumber, passphrase
+func (_m *MockService) ExportCA(ctx context.Context, serialNumber string, passphrase string) ([]byte, error) {
+	ret := _m.Called(ctx, serialNumber, passphrase)
 
 	if len(ret) == 0 {
-		panic("no return value specified for IssueCert")
+		panic("no return value specified for ExportCA")
 	}
 
-	var r0 certs.Certificate
+	var r0 []byte
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, certs.SubjectOptions) (certs.Certificate, error)); ok {
-		return rf(ctx, entityID, ttl, ipAddrs, option)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]byte, error)); ok {
+		return rf(ctx, serialNumber, passphrase)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, certs.SubjectOptions) certs.Certificate); ok {
-		r0 = rf(ctx, entityID, ttl, ipAddrs, option)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []byte); ok {
+		r0 = rf(ctx, serialNumber, passphrase)
 	} else {
-		r0 = ret.Get(0).(certs.Certificate)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string, certs.SubjectOptions) error); ok {
-		r1 = rf(ctx, entityID, ttl, ipAddrs, option)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, serialNumber, passphrase)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -226,59 +230,57 @@ func (_m *MockService) IssueCert(ctx context.Context, entityID string, ttl strin
 	return r0, r1
 }
 
-// MockService_IssueCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IssueCert'
-type MockService_IssueCert_Call struct {
+// MockService_ExportCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExportCA'
+type MockService_ExportCA_Call struct {
 	*mock.Call
 }
 
-// IssueCert is a helper method to define mock.On call
+// ExportCA is a helper method to define mock.On call
 //   - ctx context.Context
-//   - entityID string
-//   - ttl string
-//   - ipAddrs []string
-//   - option certs.SubjectOptions
-func (_e *MockService_Expecter) IssueCert(ctx interface{}, entityID interface{}, ttl interface{}, ipAddrs interface{}, option interface{}) *MockService_IssueCert_Call {
-	return &MockService_IssueCert_Call{Call: _e.mock.On("IssueCert", ctx, entityID, ttl, ipAddrs, option)}
+//   - serialNumber string
+//   - passphrase string
+func (_e *MockService_Expecter) ExportCA(ctx interface{}, serialNumber interface{}, passphrase interface{}) *MockService_ExportCA_Call {
+	return &MockService_ExportCA_Call{Call: _e.mock.On("ExportCA", ctx, serialNumber, passphrase)}
 }
 
-func (_c *MockService_IssueCert_Call) Run(run func(ctx context.Context, entityID string, ttl string, ipAddrs []string, option certs.SubjectOptions)) *MockService_IssueCert_Call {
+func (_c *MockService_ExportCA_Call) Run(run func(ctx context.Context, serialNumber string, passphrase string)) *MockService_ExportCA_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].([]string), args[4].(certs.SubjectOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *MockService_IssueCert_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_IssueCert_Call {
+func (_c *MockService_ExportCA_Call) Return(_a0 []byte, _a1 error) *MockService_ExportCA_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_IssueCert_Call) RunAndReturn(run func(context.Context, string, string, []string, certs.SubjectOptions) (certs.Certificate, error)) *MockService_IssueCert_Call {
+func (_c *MockService_ExportCA_Call) RunAndReturn(run func(context.Context, string, string) ([]byte, error)) *MockService_ExportCA_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// IssueFromCSR provides a mock function with given fields: ctx, entityID, ttl, csr
-func (_m *MockService) IssueFromCSR(ctx context.Context, entityID string, ttl string, csr certs.CSR) (certs.Certificate, error) {
-	ret := _m.Called(ctx, entityID, ttl, csr)
+// GenerateCACRL provides a mock function with given fields: ctx, serialNumber, delta
+func (_m *MockService) GenerateCACRL(ctx context.Context, serialNumber string, delta bool) (certs.CRL, error) {
+	ret := _m.Called(ctx, serialNumber, delta)
 
 	if len(ret) == 0 {
-		panic("no return value specified for IssueFromCSR")
+		panic("no return value specified for GenerateCACRL")
 	}
 
-	var r0 certs.Certificate
+	var r0 certs.CRL
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, certs.CSR) (certs.Certificate, error)); ok {
-		return rf(ctx, entityID, ttl, csr)
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (certs.CRL, error)); ok {
+		return rf(ctx, serialNumber, delta)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, certs.CSR) certs.Certificate); ok {
-		r0 = rf(ctx, entityID, ttl, csr)
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) certs.CRL); ok {
+		r0 = rf(ctx, serialNumber, delta)
 	} else {
-		r0 = ret.Get(0).(certs.Certificate)
+		r0 = ret.Get(0).(certs.CRL)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, certs.CSR) error); ok {
-		r1 = rf(ctx, entityID, ttl, csr)
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, serialNumber, delta)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -286,58 +288,59 @@ func (_m *MockService) IssueFromCSR(ctx context.Context, entityID string, ttl st
 	return r0, r1
 }
 
-// MockService_IssueFromCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IssueFromCSR'
-type MockService_IssueFromCSR_Call struct {
+// MockService_GenerateCACRL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GenerateCACRL'
+type MockService_GenerateCACRL_Call struct {
 	*mock.Call
 }
 
-// IssueFromCSR is a helper method to define mock.On call
+// GenerateCACRL is a helper method to define mock.On call
 //   - ctx context.Context
-//   - entityID string
-//   - ttl string
-//   - csr certs.CSR
-func (_e *MockService_Expecter) IssueFromCSR(ctx interface{}, entityID interface{}, ttl interface{}, csr interface{}) *MockService_IssueFromCSR_Call {
-	return &MockService_IssueFromCSR_Call{Call: _e.mock.On("IssueFromCSR", ctx, entityID, ttl, csr)}
+//   - serialNumber string
+//   - delta bool
+func (_e *MockService_Expecter) GenerateCACRL(ctx interface{}, serialNumber interface{}, delta interface{}) *MockService_GenerateCACRL_Call {
+	return &MockService_GenerateCACRL_Call{Call: _e.mock.On("GenerateCACRL", ctx, serialNumber, delta)}
 }
 
-func (_c *MockService_IssueFromCSR_Call) Run(run func(ctx context.Context, entityID string, ttl string, csr certs.CSR)) *MockService_IssueFromCSR_Call {
+func (_c *MockService_GenerateCACRL_Call) Run(run func(ctx context.Context, serialNumber string, delta bool)) *MockService_GenerateCACRL_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(certs.CSR))
+		run(args[0].(context.Context), args[1].(string), args[2].(bool))
 	})
 	return _c
 }
 
-func (_c *MockService_IssueFromCSR_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_IssueFromCSR_Call {
+func (_c *MockService_GenerateCACRL_Call) Return(_a0 certs.CRL, _a1 error) *MockService_GenerateCACRL_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_IssueFromCSR_Call) RunAndReturn(run func(context.Context, string, string, certs.CSR) (certs.Certificate, error)) *MockService_IssueFromCSR_Call {
+func (_c *MockService_GenerateCACRL_Call) RunAndReturn(run func(context.Context, string, bool) (certs.CRL, error)) *MockService_GenerateCACRL_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListCerts provides a mock function with given fields: ctx, pm
-func (_m *MockService) ListCerts(ctx context.Context, pm certs.PageMetadata) (certs.CertificatePage, error) {
-	ret := _m.Called(ctx, pm)
+// GenerateCRL provides a mock function with given fields: ctx, caType, issuer
+func (_m *MockService) GenerateCRL(ctx context.Context, caType certs.CertType, issuer string) ([]byte, error) {
+	ret := _m.Called(ctx, caType, issuer)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListCerts")
+		panic("no return value specified for GenerateCRL")
 	}
 
-	var r0 certs.CertificatePage
+	var r0 []byte
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, certs.PageMetadata) (certs.CertificatePage, error)); ok {
-		return rf(ctx, pm)
+	if rf, ok := ret.Get(0).(func(context.Context, certs.CertType, string) ([]byte, error)); ok {
+		return rf(ctx, caType, issuer)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, certs.PageMetadata) certs.CertificatePage); ok {
-		r0 = rf(ctx, pm)
+	if rf, ok := ret.Get(0).(func(context.Context, certs.CertType, string) []byte); ok {
+		r0 = rf(ctx, caType, issuer)
 	} else {
-		r0 = ret.Get(0).(certs.CertificatePage)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, certs.PageMetadata) error); ok {
-		r1 = rf(ctx, pm)
+	if rf, ok := ret.Get(1).(func(context.Context, certs.CertType, string) error); ok {
+		r1 = rf(ctx, caType, issuer)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -345,221 +348,1453 @@ func (_m *MockService) ListCerts(ctx context.Context, pm certs.PageMetadata) (ce
 	return r0, r1
 }
 
-// MockService_ListCerts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListCerts'
-type MockService_ListCerts_Call struct {
+// MockService_GenerateCRL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GenerateCRL'
+type MockService_GenerateCRL_Call struct {
 	*mock.Call
 }
 
-// ListCerts is a helper method to define mock.On call
+// GenerateCRL is a helper method to define mock.On call
 //   - ctx context.Context
-//   - pm certs.PageMetadata
-func (_e *MockService_Expecter) ListCerts(ctx interface{}, pm interface{}) *MockService_ListCerts_Call {
-	return &MockService_ListCerts_Call{Call: _e.mock.On("ListCerts", ctx, pm)}
+//   - caType certs.CertType
+//   - issuer string
+func (_e *MockService_Expecter) GenerateCRL(ctx interface{}, caType interface{}, issuer interface{}) *MockService_GenerateCRL_Call {
+	return &MockService_GenerateCRL_Call{Call: _e.mock.On("GenerateCRL", ctx, caType, issuer)}
 }
 
-func (_c *MockService_ListCerts_Call) Run(run func(ctx context.Context, pm certs.PageMetadata)) *MockService_ListCerts_Call {
+func (_c *MockService_GenerateCRL_Call) Run(run func(ctx context.Context, caType certs.CertType, issuer string)) *MockService_GenerateCRL_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(certs.PageMetadata))
+		run(args[0].(context.Context), args[1].(certs.CertType), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *MockService_ListCerts_Call) Return(_a0 certs.CertificatePage, _a1 error) *MockService_ListCerts_Call {
+func (_c *MockService_GenerateCRL_Call) Return(_a0 []byte, _a1 error) *MockService_GenerateCRL_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_ListCerts_Call) RunAndReturn(run func(context.Context, certs.PageMetadata) (certs.CertificatePage, error)) *MockService_ListCerts_Call {
+func (_c *MockService_GenerateCRL_Call) RunAndReturn(run func(context.Context, certs.CertType, string) ([]byte, error)) *MockService_GenerateCRL_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// OCSP provides a mock function with given fields: ctx, serialNumber
-func (_m *MockService) OCSP(ctx context.Context, serialNumber string) (*certs.Certificate, int, *x509.Certificate, error) {
-	ret := _m.Called(ctx, serialNumber)
+// GenerateIntermediateCSR provides a mock function with given fields: ctx
+func (_m *MockService) GenerateIntermediateCSR(ctx context.Context) (certs.CSR, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for OCSP")
-	}
-
-	var r0 *certs.Certificate
-	var r1 int
-	var r2 *x509.Certificate
-	var r3 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*certs.Certificate, int, *x509.Certificate, error)); ok {
-		return rf(ctx, serialNumber)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *certs.Certificate); ok {
-		r0 = rf(ctx, serialNumber)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*certs.Certificate)
-		}
+		panic("no return value specified for GenerateIntermediateCSR")
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) int); ok {
-		r1 = rf(ctx, serialNumber)
-	} else {
-		r1 = ret.Get(1).(int)
+	var r0 certs.CSR
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (certs.CSR, error)); ok {
+		return rf(ctx)
 	}
-
-	if rf, ok := ret.Get(2).(func(context.Context, string) *x509.Certificate); ok {
-		r2 = rf(ctx, serialNumber)
+	if rf, ok := ret.Get(0).(func(context.Context) certs.CSR); ok {
+		r0 = rf(ctx)
 	} else {
-		if ret.Get(2) != nil {
-			r2 = ret.Get(2).(*x509.Certificate)
-		}
+		r0 = ret.Get(0).(certs.CSR)
 	}
 
-	if rf, ok := ret.Get(3).(func(context.Context, string) error); ok {
-		r3 = rf(ctx, serialNumber)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
-		r3 = ret.Error(3)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2, r3
+	return r0, r1
 }
 
-// MockService_OCSP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'OCSP'
-type MockService_OCSP_Call struct {
+// MockService_GenerateIntermediateCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GenerateIntermediateCSR'
+type MockService_GenerateIntermediateCSR_Call struct {
 	*mock.Call
 }
 
-// OCSP is a helper method to define mock.On call
+// GenerateIntermediateCSR is a helper method to define mock.On call
 //   - ctx context.Context
-//   - serialNumber string
-func (_e *MockService_Expecter) OCSP(ctx interface{}, serialNumber interface{}) *MockService_OCSP_Call {
-	return &MockService_OCSP_Call{Call: _e.mock.On("OCSP", ctx, serialNumber)}
+func (_e *MockService_Expecter) GenerateIntermediateCSR(ctx interface{}) *MockService_GenerateIntermediateCSR_Call {
+	return &MockService_GenerateIntermediateCSR_Call{Call: _e.mock.On("GenerateIntermediateCSR", ctx)}
 }
 
-func (_c *MockService_OCSP_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_OCSP_Call {
+func (_c *MockService_GenerateIntermediateCSR_Call) Run(run func(ctx context.Context)) *MockService_GenerateIntermediateCSR_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockService_OCSP_Call) Return(_a0 *certs.Certificate, _a1 int, _a2 *x509.Certificate, _a3 error) *MockService_OCSP_Call {
-	_c.Call.Return(_a0, _a1, _a2, _a3)
+func (_c *MockService_GenerateIntermediateCSR_Call) Return(_a0 certs.CSR, _a1 error) *MockService_GenerateIntermediateCSR_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_OCSP_Call) RunAndReturn(run func(context.Context, string) (*certs.Certificate, int, *x509.Certificate, error)) *MockService_OCSP_Call {
+func (_c *MockService_GenerateIntermediateCSR_Call) RunAndReturn(run func(context.Context) (certs.CSR, error)) *MockService_GenerateIntermediateCSR_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RemoveCert provides a mock function with given fields: ctx, entityId
-func (_m *MockService) RemoveCert(ctx context.Context, entityId string) error {
-	ret := _m.Called(ctx, entityId)
+// GetChainCA provides a mock function with given fields: ctx, token
+func (_m *MockService) GetChainCA(ctx context.Context, token string) (certs.Certificate, error) {
+	ret := _m.Called(ctx, token)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RemoveCert")
+		panic("no return value specified for GetChainCA")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, entityId)
+	var r0 certs.Certificate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.Certificate, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) certs.Certificate); ok {
+		r0 = rf(ctx, token)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(certs.Certificate)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockService_RemoveCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveCert'
-type MockService_RemoveCert_Call struct {
+// MockService_GetChainCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChainCA'
+type MockService_GetChainCA_Call struct {
 	*mock.Call
 }
 
-// RemoveCert is a helper method to define mock.On call
+// GetChainCA is a helper method to define mock.On call
 //   - ctx context.Context
-//   - entityId string
-func (_e *MockService_Expecter) RemoveCert(ctx interface{}, entityId interface{}) *MockService_RemoveCert_Call {
-	return &MockService_RemoveCert_Call{Call: _e.mock.On("RemoveCert", ctx, entityId)}
+//   - token string
+func (_e *MockService_Expecter) GetChainCA(ctx interface{}, token interface{}) *MockService_GetChainCA_Call {
+	return &MockService_GetChainCA_Call{Call: _e.mock.On("GetChainCA", ctx, token)}
 }
 
-func (_c *MockService_RemoveCert_Call) Run(run func(ctx context.Context, entityId string)) *MockService_RemoveCert_Call {
+func (_c *MockService_GetChainCA_Call) Run(run func(ctx context.Context, token string)) *MockService_GetChainCA_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockService_RemoveCert_Call) Return(_a0 error) *MockService_RemoveCert_Call {
-	_c.Call.Return(_a0)
+func (_c *MockService_GetChainCA_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_GetChainCA_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_RemoveCert_Call) RunAndReturn(run func(context.Context, string) error) *MockService_RemoveCert_Call {
+func (_c *MockService_GetChainCA_Call) RunAndReturn(run func(context.Context, string) (certs.Certificate, error)) *MockService_GetChainCA_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RenewCert provides a mock function with given fields: ctx, serialNumber
-func (_m *MockService) RenewCert(ctx context.Context, serialNumber string) error {
+// GetEntityID provides a mock function with given fields: ctx, serialNumber
+func (_m *MockService) GetEntityID(ctx context.Context, serialNumber string) (string, error) {
 	ret := _m.Called(ctx, serialNumber)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RenewCert")
+		panic("no return value specified for GetEntityID")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
 		r0 = rf(ctx, serialNumber)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(string)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, serialNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockService_RenewCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenewCert'
-type MockService_RenewCert_Call struct {
+// MockService_GetEntityID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetEntityID'
+type MockService_GetEntityID_Call struct {
 	*mock.Call
 }
 
-// RenewCert is a helper method to define mock.On call
+// GetEntityID is a helper method to define mock.On call
 //   - ctx context.Context
 //   - serialNumber string
-func (_e *MockService_Expecter) RenewCert(ctx interface{}, serialNumber interface{}) *MockService_RenewCert_Call {
-	return &MockService_RenewCert_Call{Call: _e.mock.On("RenewCert", ctx, serialNumber)}
+func (_e *MockService_Expecter) GetEntityID(ctx interface{}, serialNumber interface{}) *MockService_GetEntityID_Call {
+	return &MockService_GetEntityID_Call{Call: _e.mock.On("GetEntityID", ctx, serialNumber)}
 }
 
-func (_c *MockService_RenewCert_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_RenewCert_Call {
+func (_c *MockService_GetEntityID_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_GetEntityID_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockService_RenewCert_Call) Return(_a0 error) *MockService_RenewCert_Call {
-	_c.Call.Return(_a0)
+func (_c *MockService_GetEntityID_Call) Return(_a0 string, _a1 error) *MockService_GetEntityID_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_RenewCert_Call) RunAndReturn(run func(context.Context, string) error) *MockService_RenewCert_Call {
+func (_c *MockService_GetEntityID_Call) RunAndReturn(run func(context.Context, string) (string, error)) *MockService_GetEntityID_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RetrieveCAToken provides a mock function with given fields: ctx
-func (_m *MockService) RetrieveCAToken(ctx context.Context) (string, error) {
-	ret := _m.Called(ctx)
+// HoldCert provides a mock function with given fields: ctx, serialNumber
+func (_m *MockService) HoldCert(ctx context.Context, serialNumber string) error {
+	ret := _m.Called(ctx, serialNumber)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RetrieveCAToken")
+		panic("no return value specified for HoldCert")
 	}
 
-	var r0 string
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (string, error)); ok {
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, serialNumber)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_HoldCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HoldCert'
+type MockService_HoldCert_Call struct {
+	*mock.Call
+}
+
+// HoldCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serialNumber string
+func (_e *MockService_Expecter) HoldCert(ctx interface{}, serialNumber interface{}) *MockService_HoldCert_Call {
+	return &MockService_HoldCert_Call{Call: _e.mock.On("HoldCert", ctx, serialNumber)}
+}
+
+func (_c *MockService_HoldCert_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_HoldCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_HoldCert_Call) Return(_a0 error) *MockService_HoldCert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_HoldCert_Call) RunAndReturn(run func(context.Context, string) error) *MockService_HoldCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportCA provides a mock function with given fields: ctx, ca
+func (_m *MockService) ImportCA(ctx context.Context, ca certs.CAImport) (certs.Certificate, error) {
+	ret := _m.Called(ctx, ca)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportCA")
+	}
+
+	var r0 certs.Certificate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.CAImport) (certs.Certificate, error)); ok {
+		return rf(ctx, ca)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, certs.CAImport) certs.Certificate); ok {
+		r0 = rf(ctx, ca)
+	} else {
+		r0 = ret.Get(0).(certs.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, certs.CAImport) error); ok {
+		r1 = rf(ctx, ca)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ImportCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportCA'
+type MockService_ImportCA_Call struct {
+	*mock.Call
+}
+
+// ImportCA is a helper method to define mock.On call
+//   - ctx context.Context
+//   - ca certs.CAImport
+func (_e *MockService_Expecter) ImportCA(ctx interface{}, ca interface{}) *MockService_ImportCA_Call {
+	return &MockService_ImportCA_Call{Call: _e.mock.On("ImportCA", ctx, ca)}
+}
+
+func (_c *MockService_ImportCA_Call) Run(run func(ctx context.Context, ca certs.CAImport)) *MockService_ImportCA_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.CAImport))
+	})
+	return _c
+}
+
+func (_c *MockService_ImportCA_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_ImportCA_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ImportCA_Call) RunAndReturn(run func(context.Context, certs.CAImport) (certs.Certificate, error)) *MockService_ImportCA_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InstallIntermediateCA provides a mock function with given fields: ctx, cert
+func (_m *MockService) InstallIntermediateCA(ctx context.Context, cert []byte) (certs.Certificate, error) {
+	ret := _m.Called(ctx, cert)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InstallIntermediateCA")
+	}
+
+	var r0 certs.Certificate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (certs.Certificate, error)); ok {
+		return rf(ctx, cert)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) certs.Certificate); ok {
+		r0 = rf(ctx, cert)
+	} else {
+		r0 = ret.Get(0).(certs.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, cert)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_InstallIntermediateCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InstallIntermediateCA'
+type MockService_InstallIntermediateCA_Call struct {
+	*mock.Call
+}
+
+// InstallIntermediateCA is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cert []byte
+func (_e *MockService_Expecter) InstallIntermediateCA(ctx interface{}, cert interface{}) *MockService_InstallIntermediateCA_Call {
+	return &MockService_InstallIntermediateCA_Call{Call: _e.mock.On("InstallIntermediateCA", ctx, cert)}
+}
+
+func (_c *MockService_InstallIntermediateCA_Call) Run(run func(ctx context.Context, cert []byte)) *MockService_InstallIntermediateCA_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockService_InstallIntermediateCA_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_InstallIntermediateCA_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_InstallIntermediateCA_Call) RunAndReturn(run func(context.Context, []byte) (certs.Certificate, error)) *MockService_InstallIntermediateCA_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IssueCert provides a mock function with given fields: ctx, entityID, issuer, profile, ttl, ipAddrs, option
+func (_m *MockService) IssueCert(ctx context.Context, entityID string, issuer string, profile string, ttl string, ipAddrs []string, option certs.SubjectOptions) (certs.Certificate, error) {
+	ret := _m.Called(ctx, entityID, issuer, profile, ttl, ipAddrs, option)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueCert")
+	}
+
+	var r0 certs.Certificate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, []string, certs.SubjectOptions) (certs.Certificate, error)); ok {
+		return rf(ctx, entityID, issuer, profile, ttl, ipAddrs, option)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, []string, certs.SubjectOptions) certs.Certificate); ok {
+		r0 = rf(ctx, entityID, issuer, profile, ttl, ipAddrs, option)
+	} else {
+		r0 = ret.Get(0).(certs.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, []string, certs.SubjectOptions) error); ok {
+		r1 = rf(ctx, entityID, issuer, profile, ttl, ipAddrs, option)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_IssueCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IssueCert'
+type MockService_IssueCert_Call struct {
+	*mock.Call
+}
+
+// IssueCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - entityID string
+//   - issuer string
+//   - profile string
+//   - ttl string
+//   - ipAddrs []string
+//   - option certs.SubjectOptions
+func (_e *MockService_Expecter) IssueCert(ctx interface{}, entityID interface{}, issuer interface{}, profile interface{}, ttl interface{}, ipAddrs interface{}, option interface{}) *MockService_IssueCert_Call {
+	return &MockService_IssueCert_Call{Call: _e.mock.On("IssueCert", ctx, entityID, issuer, profile, ttl, ipAddrs, option)}
+}
+
+func (_c *MockService_IssueCert_Call) Run(run func(ctx context.Context, entityID string, issuer string, profile string, ttl string, ipAddrs []string, option certs.SubjectOptions)) *MockService_IssueCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string), args[4].(string), args[5].([]string), args[6].(certs.SubjectOptions))
+	})
+	return _c
+}
+
+func (_c *MockService_IssueCert_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_IssueCert_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_IssueCert_Call) RunAndReturn(run func(context.Context, string, string, string, string, []string, certs.SubjectOptions) (certs.Certificate, error)) *MockService_IssueCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IssueFromCSR provides a mock function with given fields: ctx, entityID, issuer, profile, ttl, csr
+func (_m *MockService) IssueFromCSR(ctx context.Context, entityID string, issuer string, profile string, ttl string, csr certs.CSR) (certs.Certificate, error) {
+	ret := _m.Called(ctx, entityID, issuer, profile, ttl, csr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueFromCSR")
+	}
+
+	var r0 certs.Certificate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, certs.CSR) (certs.Certificate, error)); ok {
+		return rf(ctx, entityID, issuer, profile, ttl, csr)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, certs.CSR) certs.Certificate); ok {
+		r0 = rf(ctx, entityID, issuer, profile, ttl, csr)
+	} else {
+		r0 = ret.Get(0).(certs.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, certs.CSR) error); ok {
+		r1 = rf(ctx, entityID, issuer, profile, ttl, csr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_IssueFromCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IssueFromCSR'
+type MockService_IssueFromCSR_Call struct {
+	*mock.Call
+}
+
+// IssueFromCSR is a helper method to define mock.On call
+//   - ctx context.Context
+//   - entityID string
+//   - issuer string
+//   - profile string
+//   - ttl string
+//   - csr certs.CSR
+func (_e *MockService_Expecter) IssueFromCSR(ctx interface{}, entityID interface{}, issuer interface{}, profile interface{}, ttl interface{}, csr interface{}) *MockService_IssueFromCSR_Call {
+	return &MockService_IssueFromCSR_Call{Call: _e.mock.On("IssueFromCSR", ctx, entityID, issuer, profile, ttl, csr)}
+}
+
+func (_c *MockService_IssueFromCSR_Call) Run(run func(ctx context.Context, entityID string, issuer string, profile string, ttl string, csr certs.CSR)) *MockService_IssueFromCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string), args[4].(string), args[5].(certs.CSR))
+	})
+	return _c
+}
+
+func (_c *MockService_IssueFromCSR_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_IssueFromCSR_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_IssueFromCSR_Call) RunAndReturn(run func(context.Context, string, string, string, string, certs.CSR) (certs.Certificate, error)) *MockService_IssueFromCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListCSRs provides a mock function with given fields: ctx, pm
+func (_m *MockService) ListCSRs(ctx context.Context, pm certs.PageMetadata) (certs.CSRPage, error) {
+	ret := _m.Called(ctx, pm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCSRs")
+	}
+
+	var r0 certs.CSRPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.PageMetadata) (certs.CSRPage, error)); ok {
+		return rf(ctx, pm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, certs.PageMetadata) certs.CSRPage); ok {
+		r0 = rf(ctx, pm)
+	} else {
+		r0 = ret.Get(0).(certs.CSRPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, certs.PageMetadata) error); ok {
+		r1 = rf(ctx, pm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ListCSRs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListCSRs'
+type MockService_ListCSRs_Call struct {
+	*mock.Call
+}
+
+// ListCSRs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pm certs.PageMetadata
+func (_e *MockService_Expecter) ListCSRs(ctx interface{}, pm interface{}) *MockService_ListCSRs_Call {
+	return &MockService_ListCSRs_Call{Call: _e.mock.On("ListCSRs", ctx, pm)}
+}
+
+func (_c *MockService_ListCSRs_Call) Run(run func(ctx context.Context, pm certs.PageMetadata)) *MockService_ListCSRs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.PageMetadata))
+	})
+	return _c
+}
+
+func (_c *MockService_ListCSRs_Call) Return(_a0 certs.CSRPage, _a1 error) *MockService_ListCSRs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ListCSRs_Call) RunAndReturn(run func(context.Context, certs.PageMetadata) (certs.CSRPage, error)) *MockService_ListCSRs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListCerts provides a mock function with given fields: ctx, pm
+func (_m *MockService) ListCerts(ctx context.Context, pm certs.PageMetadata) (certs.CertificatePage, error) {
+	ret := _m.Called(ctx, pm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCerts")
+	}
+
+	var r0 certs.CertificatePage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.PageMetadata) (certs.CertificatePage, error)); ok {
+		return rf(ctx, pm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, certs.PageMetadata) certs.CertificatePage); ok {
+		r0 = rf(ctx, pm)
+	} else {
+		r0 = ret.Get(0).(certs.CertificatePage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, certs.PageMetadata) error); ok {
+		r1 = rf(ctx, pm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ListCerts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListCerts'
+type MockService_ListCerts_Call struct {
+	*mock.Call
+}
+
+// ListCerts is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pm certs.PageMetadata
+func (_e *MockService_Expecter) ListCerts(ctx interface{}, pm interface{}) *MockService_ListCerts_Call {
+	return &MockService_ListCerts_Call{Call: _e.mock.On("ListCerts", ctx, pm)}
+}
+
+func (_c *MockService_ListCerts_Call) Run(run func(ctx context.Context, pm certs.PageMetadata)) *MockService_ListCerts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.PageMetadata))
+	})
+	return _c
+}
+
+func (_c *MockService_ListCerts_Call) Return(_a0 certs.CertificatePage, _a1 error) *MockService_ListCerts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ListCerts_Call) RunAndReturn(run func(context.Context, certs.PageMetadata) (certs.CertificatePage, error)) *MockService_ListCerts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListIssuers provides a mock function with given fields: ctx
+func (_m *MockService) ListIssuers(ctx context.Context) ([]certs.Issuer, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListIssuers")
+	}
+
+	var r0 []certs.Issuer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]certs.Issuer, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []certs.Issuer); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]certs.Issuer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ListIssuers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListIssuers'
+type MockService_ListIssuers_Call struct {
+	*mock.Call
+}
+
+// ListIssuers is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) ListIssuers(ctx interface{}) *MockService_ListIssuers_Call {
+	return &MockService_ListIssuers_Call{Call: _e.mock.On("ListIssuers", ctx)}
+}
+
+func (_c *MockService_ListIssuers_Call) Run(run func(ctx context.Context)) *MockService_ListIssuers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_ListIssuers_Call) Return(_a0 []certs.Issuer, _a1 error) *MockService_ListIssuers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ListIssuers_Call) RunAndReturn(run func(context.Context) ([]certs.Issuer, error)) *MockService_ListIssuers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListProfiles provides a mock function with given fields: ctx
+func (_m *MockService) ListProfiles(ctx context.Context) ([]certs.Profile, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListProfiles")
+	}
+
+	var r0 []certs.Profile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]certs.Profile, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []certs.Profile); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]certs.Profile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ListProfiles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProfiles'
+type MockService_ListProfiles_Call struct {
+	*mock.Call
+}
+
+// ListProfiles is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) ListProfiles(ctx interface{}) *MockService_ListProfiles_Call {
+	return &MockService_ListProfiles_Call{Call: _e.mock.On("ListProfiles", ctx)}
+}
+
+func (_c *MockService_ListProfiles_Call) Run(run func(ctx context.Context)) *MockService_ListProfiles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_ListProfiles_Call) Return(_a0 []certs.Profile, _a1 error) *MockService_ListProfiles_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ListProfiles_Call) RunAndReturn(run func(context.Context) ([]certs.Profile, error)) *MockService_ListProfiles_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// OCSP provides a mock function with given fields: ctx, serialNumber
+func (_m *MockService) OCSP(ctx context.Context, serialNumber string) (*certs.Certificate, int, *certs.CA, error) {
+	ret := _m.Called(ctx, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OCSP")
+	}
+
+	var r0 *certs.Certificate
+	var r1 int
+	var r2 *certs.CA
+	var r3 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*certs.Certificate, int, *certs.CA, error)); ok {
+		return rf(ctx, serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *certs.Certificate); ok {
+		r0 = rf(ctx, serialNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*certs.Certificate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) int); ok {
+		r1 = rf(ctx, serialNumber)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) *certs.CA); ok {
+		r2 = rf(ctx, serialNumber)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(*certs.CA)
+		}
+	}
+
+	if rf, ok := ret.Get(3).(func(context.Context, string) error); ok {
+		r3 = rf(ctx, serialNumber)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// MockService_OCSP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'OCSP'
+type MockService_OCSP_Call struct {
+	*mock.Call
+}
+
+// OCSP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serialNumber string
+func (_e *MockService_Expecter) OCSP(ctx interface{}, serialNumber interface{}) *MockService_OCSP_Call {
+	return &MockService_OCSP_Call{Call: _e.mock.On("OCSP", ctx, serialNumber)}
+}
+
+func (_c *MockService_OCSP_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_OCSP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_OCSP_Call) Return(_a0 *certs.Certificate, _a1 int, _a2 *certs.CA, _a3 error) *MockService_OCSP_Call {
+	_c.Call.Return(_a0, _a1, _a2, _a3)
+	return _c
+}
+
+func (_c *MockService_OCSP_Call) RunAndReturn(run func(context.Context, string) (*certs.Certificate, int, *certs.CA, error)) *MockService_OCSP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RejectCSR provides a mock function with given fields: ctx, id, reason
+func (_m *MockService) RejectCSR(ctx context.Context, id string, reason string) (certs.CSR, error) {
+	ret := _m.Called(ctx, id, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RejectCSR")
+	}
+
+	var r0 certs.CSR
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (certs.CSR, error)); ok {
+		return rf(ctx, id, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) certs.CSR); ok {
+		r0 = rf(ctx, id, reason)
+	} else {
+		r0 = ret.Get(0).(certs.CSR)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, id, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_RejectCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RejectCSR'
+type MockService_RejectCSR_Call struct {
+	*mock.Call
+}
+
+// RejectCSR is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+//   - reason string
+func (_e *MockService_Expecter) RejectCSR(ctx interface{}, id interface{}, reason interface{}) *MockService_RejectCSR_Call {
+	return &MockService_RejectCSR_Call{Call: _e.mock.On("RejectCSR", ctx, id, reason)}
+}
+
+func (_c *MockService_RejectCSR_Call) Run(run func(ctx context.Context, id string, reason string)) *MockService_RejectCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RejectCSR_Call) Return(_a0 certs.CSR, _a1 error) *MockService_RejectCSR_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_RejectCSR_Call) RunAndReturn(run func(context.Context, string, string) (certs.CSR, error)) *MockService_RejectCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseCert provides a mock function with given fields: ctx, serialNumber
+func (_m *MockService) ReleaseCert(ctx context.Context, serialNumber string) error {
+	ret := _m.Called(ctx, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseCert")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, serialNumber)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_ReleaseCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseCert'
+type MockService_ReleaseCert_Call struct {
+	*mock.Call
+}
+
+// ReleaseCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serialNumber string
+func (_e *MockService_Expecter) ReleaseCert(ctx interface{}, serialNumber interface{}) *MockService_ReleaseCert_Call {
+	return &MockService_ReleaseCert_Call{Call: _e.mock.On("ReleaseCert", ctx, serialNumber)}
+}
+
+func (_c *MockService_ReleaseCert_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_ReleaseCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_ReleaseCert_Call) Return(_a0 error) *MockService_ReleaseCert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_ReleaseCert_Call) RunAndReturn(run func(context.Context, string) error) *MockService_ReleaseCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveCert provides a mock function with given fields: ctx, entityId
+func (_m *MockService) RemoveCert(ctx context.Context, entityId string) error {
+	ret := _m.Called(ctx, entityId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveCert")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, entityId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_RemoveCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveCert'
+type MockService_RemoveCert_Call struct {
+	*mock.Call
+}
+
+// RemoveCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - entityId string
+func (_e *MockService_Expecter) RemoveCert(ctx interface{}, entityId interface{}) *MockService_RemoveCert_Call {
+	return &MockService_RemoveCert_Call{Call: _e.mock.On("RemoveCert", ctx, entityId)}
+}
+
+func (_c *MockService_RemoveCert_Call) Run(run func(ctx context.Context, entityId string)) *MockService_RemoveCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RemoveCert_Call) Return(_a0 error) *MockService_RemoveCert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_RemoveCert_Call) RunAndReturn(run func(context.Context, string) error) *MockService_RemoveCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveProfile provides a mock function with given fields: ctx, name
+func (_m *MockService) RemoveProfile(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveProfile")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_RemoveProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveProfile'
+type MockService_RemoveProfile_Call struct {
+	*mock.Call
+}
+
+// RemoveProfile is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockService_Expecter) RemoveProfile(ctx interface{}, name interface{}) *MockService_RemoveProfile_Call {
+	return &MockService_RemoveProfile_Call{Call: _e.mock.On("RemoveProfile", ctx, name)}
+}
+
+func (_c *MockService_RemoveProfile_Call) Run(run func(ctx context.Context, name string)) *MockService_RemoveProfile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RemoveProfile_Call) Return(_a0 error) *MockService_RemoveProfile_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_RemoveProfile_Call) RunAndReturn(run func(context.Context, string) error) *MockService_RemoveProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RenewCert provides a mock function with given fields: ctx, serialNumber
+func (_m *MockService) RenewCert(ctx context.Context, serialNumber string) error {
+	ret := _m.Called(ctx, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenewCert")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, serialNumber)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_RenewCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenewCert'
+type MockService_RenewCert_Call struct {
+	*mock.Call
+}
+
+// RenewCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serialNumber string
+func (_e *MockService_Expecter) RenewCert(ctx interface{}, serialNumber interface{}) *MockService_RenewCert_Call {
+	return &MockService_RenewCert_Call{Call: _e.mock.On("RenewCert", ctx, serialNumber)}
+}
+
+func (_c *MockService_RenewCert_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_RenewCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RenewCert_Call) Return(_a0 error) *MockService_RenewCert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_RenewCert_Call) RunAndReturn(run func(context.Context, string) error) *MockService_RenewCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetireIssuer provides a mock function with given fields: ctx, name
+func (_m *MockService) RetireIssuer(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetireIssuer")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_RetireIssuer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetireIssuer'
+type MockService_RetireIssuer_Call struct {
+	*mock.Call
+}
+
+// RetireIssuer is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockService_Expecter) RetireIssuer(ctx interface{}, name interface{}) *MockService_RetireIssuer_Call {
+	return &MockService_RetireIssuer_Call{Call: _e.mock.On("RetireIssuer", ctx, name)}
+}
+
+func (_c *MockService_RetireIssuer_Call) Run(run func(ctx context.Context, name string)) *MockService_RetireIssuer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RetireIssuer_Call) Return(_a0 error) *MockService_RetireIssuer_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_RetireIssuer_Call) RunAndReturn(run func(context.Context, string) error) *MockService_RetireIssuer_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveCAToken provides a mock function with given fields: ctx, issuer
+func (_m *MockService) RetrieveCAToken(ctx context.Context, issuer string) (string, error) {
+	ret := _m.Called(ctx, issuer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveCAToken")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, issuer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, issuer)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, issuer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_RetrieveCAToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCAToken'
+type MockService_RetrieveCAToken_Call struct {
+	*mock.Call
+}
+
+// RetrieveCAToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - issuer string
+func (_e *MockService_Expecter) RetrieveCAToken(ctx interface{}, issuer interface{}) *MockService_RetrieveCAToken_Call {
+	return &MockService_RetrieveCAToken_Call{Call: _e.mock.On("RetrieveCAToken", ctx, issuer)}
+}
+
+func (_c *MockService_RetrieveCAToken_Call) Run(run func(ctx context.Context, issuer string)) *MockService_RetrieveCAToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RetrieveCAToken_Call) Return(_a0 string, _a1 error) *MockService_RetrieveCAToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_RetrieveCAToken_Call) RunAndReturn(run func(context.Context, string) (string, error)) *MockService_RetrieveCAToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveCert provides a mock function with given fields: ctx, token, serialNumber
+func (_m *MockService) RetrieveCert(ctx context.Context, token string, serialNumber string) (certs.Certificate, []byte, error) {
+	ret := _m.Called(ctx, token, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveCert")
+	}
+
+	var r0 certs.Certificate
+	var r1 []byte
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (certs.Certificate, []byte, error)); ok {
+		return rf(ctx, token, serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) certs.Certificate); ok {
+		r0 = rf(ctx, token, serialNumber)
+	} else {
+		r0 = ret.Get(0).(certs.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) []byte); ok {
+		r1 = rf(ctx, token, serialNumber)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, token, serialNumber)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockService_RetrieveCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCert'
+type MockService_RetrieveCert_Call struct {
+	*mock.Call
+}
+
+// RetrieveCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - serialNumber string
+func (_e *MockService_Expecter) RetrieveCert(ctx interface{}, token interface{}, serialNumber interface{}) *MockService_RetrieveCert_Call {
+	return &MockService_RetrieveCert_Call{Call: _e.mock.On("RetrieveCert", ctx, token, serialNumber)}
+}
+
+func (_c *MockService_RetrieveCert_Call) Run(run func(ctx context.Context, token string, serialNumber string)) *MockService_RetrieveCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RetrieveCert_Call) Return(_a0 certs.Certificate, _a1 []byte, _a2 error) *MockService_RetrieveCert_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockService_RetrieveCert_Call) RunAndReturn(run func(context.Context, string, string) (certs.Certificate, []byte, error)) *MockService_RetrieveCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveCertDownloadToken provides a mock function with given fields: ctx, serialNumber
+func (_m *MockService) RetrieveCertDownloadToken(ctx context.Context, serialNumber string) (string, error) {
+	ret := _m.Called(ctx, serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveCertDownloadToken")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, serialNumber)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, serialNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_RetrieveCertDownloadToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCertDownloadToken'
+type MockService_RetrieveCertDownloadToken_Call struct {
+	*mock.Call
+}
+
+// RetrieveCertDownloadToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serialNumber string
+func (_e *MockService_Expecter) RetrieveCertDownloadToken(ctx interface{}, serialNumber interface{}) *MockService_RetrieveCertDownloadToken_Call {
+	return &MockService_RetrieveCertDownloadToken_Call{Call: _e.mock.On("RetrieveCertDownloadToken", ctx, serialNumber)}
+}
+
+func (_c *MockService_RetrieveCertDownloadToken_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_RetrieveCertDownloadToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RetrieveCertDownloadToken_Call) Return(_a0 string, _a1 error) *MockService_RetrieveCertDownloadToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_RetrieveCertDownloadToken_Call) RunAndReturn(run func(context.Context, string) (string, error)) *MockService_RetrieveCertDownloadToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeCert provides a mock function with given fields: ctx, serialNumber, reason, invalidityDate
+func (_m *MockService) RevokeCert(ctx context.Context, serialNumber string, reason certs.RevocationReason, invalidityDate time.Time) error {
+	ret := _m.Called(ctx, serialNumber, reason, invalidityDate)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeCert")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, certs.RevocationReason, time.Time) error); ok {
+		r0 = rf(ctx, serialNumber, reason, invalidityDate)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_RevokeCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeCert'
+type MockService_RevokeCert_Call struct {
+	*mock.Call
+}
+
+// RevokeCert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serialNumber string
+//   - reason certs.RevocationReason
+//   - invalidityDate time.Time
+func (_e *MockService_Expecter) RevokeCert(ctx interface{}, serialNumber interface{}, reason interface{}, invalidityDate interface{}) *MockService_RevokeCert_Call {
+	return &MockService_RevokeCert_Call{Call: _e.mock.On("RevokeCert", ctx, serialNumber, reason, invalidityDate)}
+}
+
+func (_c *MockService_RevokeCert_Call) Run(run func(ctx context.Context, serialNumber string, reason certs.RevocationReason, invalidityDate time.Time)) *MockService_RevokeCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(certs.RevocationReason), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockService_RevokeCert_Call) Return(_a0 error) *MockService_RevokeCert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_RevokeCert_Call) RunAndReturn(run func(context.Context, string, certs.RevocationReason, time.Time) error) *MockService_RevokeCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SignOCSP provides a mock function with given fields: ctx, issuerSerial, template, nonce
+func (_m *MockService) SignOCSP(ctx context.Context, issuerSerial string, template ocsp.Response, nonce []byte) (certs.OCSPResponse, error) {
+	ret := _m.Called(ctx, issuerSerial, template, nonce)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SignOCSP")
+	}
+
+	var r0 certs.OCSPResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ocsp.Response, []byte) (certs.OCSPResponse, error)); ok {
+		return rf(ctx, issuerSerial, template, nonce)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ocsp.Response, []byte) certs.OCSPResponse); ok {
+		r0 = rf(ctx, issuerSerial, template, nonce)
+	} else {
+		r0 = ret.Get(0).(certs.OCSPResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ocsp.Response, []byte) error); ok {
+		r1 = rf(ctx, issuerSerial, template, nonce)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_SignOCSP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SignOCSP'
+type MockService_SignOCSP_Call struct {
+	*mock.Call
+}
+
+// SignOCSP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - issuerSerial string
+//   - template ocsp.Response
+//   - nonce []byte
+func (_e *MockService_Expecter) SignOCSP(ctx interface{}, issuerSerial interface{}, template interface{}, nonce interface{}) *MockService_SignOCSP_Call {
+	return &MockService_SignOCSP_Call{Call: _e.mock.On("SignOCSP", ctx, issuerSerial, template, nonce)}
+}
+
+func (_c *MockService_SignOCSP_Call) Run(run func(ctx context.Context, issuerSerial string, template ocsp.Response, nonce []byte)) *MockService_SignOCSP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(ocsp.Response), args[3].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockService_SignOCSP_Call) Return(_a0 certs.OCSPResponse, _a1 error) *MockService_SignOCSP_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_SignOCSP_Call) RunAndReturn(run func(context.Context, string, ocsp.Response, []byte) (certs.OCSPResponse, error)) *MockService_SignOCSP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SubmitCSR provides a mock function with given fields: ctx, csr
+func (_m *MockService) SubmitCSR(ctx context.Context, csr certs.CSR) (certs.CSR, error) {
+	ret := _m.Called(ctx, csr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SubmitCSR")
+	}
+
+	var r0 certs.CSR
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.CSR) (certs.CSR, error)); ok {
+		return rf(ctx, csr)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, certs.CSR) certs.CSR); ok {
+		r0 = rf(ctx, csr)
+	} else {
+		r0 = ret.Get(0).(certs.CSR)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, certs.CSR) error); ok {
+		r1 = rf(ctx, csr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_SubmitCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SubmitCSR'
+type MockService_SubmitCSR_Call struct {
+	*mock.Call
+}
+
+// SubmitCSR is a helper method to define mock.On call
+//   - ctx context.Context
+//   - csr certs.CSR
+func (_e *MockService_Expecter) SubmitCSR(ctx interface{}, csr interface{}) *MockService_SubmitCSR_Call {
+	return &MockService_SubmitCSR_Call{Call: _e.mock.On("SubmitCSR", ctx, csr)}
+}
+
+func (_c *MockService_SubmitCSR_Call) Run(run func(ctx context.Context, csr certs.CSR)) *MockService_SubmitCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(certs.CSR))
+	})
+	return _c
+}
+
+func (_c *MockService_SubmitCSR_Call) Return(_a0 certs.CSR, _a1 error) *MockService_SubmitCSR_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_SubmitCSR_Call) RunAndReturn(run func(context.Context, certs.CSR) (certs.CSR, error)) *MockService_SubmitCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TrustBundle provides a mock function with given fields: ctx
+func (_m *MockService) TrustBundle(ctx context.Context) ([]byte, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TrustBundle")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]byte, error)); ok {
 		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) []byte); ok {
 		r0 = rf(ctx)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
@@ -571,118 +1806,108 @@ func (_m *MockService) RetrieveCAToken(ctx context.Context) (string, error) {
 	return r0, r1
 }
 
-// MockService_RetrieveCAToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCAToken'
-type MockService_RetrieveCAToken_Call struct {
+// MockService_TrustBundle_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrustBundle'
+type MockService_TrustBundle_Call struct {
 	*mock.Call
 }
 
-// RetrieveCAToken is a helper method to define mock.On call
+// TrustBundle is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockService_Expecter) RetrieveCAToken(ctx interface{}) *MockService_RetrieveCAToken_Call {
-	return &MockService_RetrieveCAToken_Call{Call: _e.mock.On("RetrieveCAToken", ctx)}
+func (_e *MockService_Expecter) TrustBundle(ctx interface{}) *MockService_TrustBundle_Call {
+	return &MockService_TrustBundle_Call{Call: _e.mock.On("TrustBundle", ctx)}
 }
 
-func (_c *MockService_RetrieveCAToken_Call) Run(run func(ctx context.Context)) *MockService_RetrieveCAToken_Call {
+func (_c *MockService_TrustBundle_Call) Run(run func(ctx context.Context)) *MockService_TrustBundle_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *MockService_RetrieveCAToken_Call) Return(_a0 string, _a1 error) *MockService_RetrieveCAToken_Call {
+func (_c *MockService_TrustBundle_Call) Return(_a0 []byte, _a1 error) *MockService_TrustBundle_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_RetrieveCAToken_Call) RunAndReturn(run func(context.Context) (string, error)) *MockService_RetrieveCAToken_Call {
+func (_c *MockService_TrustBundle_Call) RunAndReturn(run func(context.Context) ([]byte, error)) *MockService_TrustBundle_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RetrieveCert provides a mock function with given fields: ctx, token, serialNumber
-func (_m *MockService) RetrieveCert(ctx context.Context, token string, serialNumber string) (certs.Certificate, []byte, error) {
-	ret := _m.Called(ctx, token, serialNumber)
+// UpdateProfile provides a mock function with given fields: ctx, profile
+func (_m *MockService) UpdateProfile(ctx context.Context, profile certs.Profile) (certs.Profile, error) {
+	ret := _m.Called(ctx, profile)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RetrieveCert")
+		panic("no return value specified for UpdateProfile")
 	}
 
-	var r0 certs.Certificate
-	var r1 []byte
-	var r2 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (certs.Certificate, []byte, error)); ok {
-		return rf(ctx, token, serialNumber)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) certs.Certificate); ok {
-		r0 = rf(ctx, token, serialNumber)
-	} else {
-		r0 = ret.Get(0).(certs.Certificate)
+	var r0 certs.Profile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, certs.Profile) (certs.Profile, error)); ok {
+		return rf(ctx, profile)
 	}
-
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) []byte); ok {
-		r1 = rf(ctx, token, serialNumber)
+	if rf, ok := ret.Get(0).(func(context.Context, certs.Profile) certs.Profile); ok {
+		r0 = rf(ctx, profile)
 	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).([]byte)
-		}
+		r0 = ret.Get(0).(certs.Profile)
 	}
 
-	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
-		r2 = rf(ctx, token, serialNumber)
+	if rf, ok := ret.Get(1).(func(context.Context, certs.Profile) error); ok {
+		r1 = rf(ctx, profile)
 	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
-// MockService_RetrieveCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCert'
-type MockService_RetrieveCert_Call struct {
+// MockService_UpdateProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateProfile'
+type MockService_UpdateProfile_Call struct {
 	*mock.Call
 }
 
-// RetrieveCert is a helper method to define mock.On call
+// UpdateProfile is a helper method to define mock.On call
 //   - ctx context.Context
-//   - token string
-//   - serialNumber string
-func (_e *MockService_Expecter) RetrieveCert(ctx interface{}, token interface{}, serialNumber interface{}) *MockService_RetrieveCert_Call {
-	return &MockService_RetrieveCert_Call{Call: _e.mock.On("RetrieveCert", ctx, token, serialNumber)}
+//   - profile certs.Profile
+func (_e *MockService_Expecter) UpdateProfile(ctx interface{}, profile interface{}) *MockService_UpdateProfile_Call {
+	return &MockService_UpdateProfile_Call{Call: _e.mock.On("UpdateProfile", ctx, profile)}
 }
 
-func (_c *MockService_RetrieveCert_Call) Run(run func(ctx context.Context, token string, serialNumber string)) *MockService_RetrieveCert_Call {
+func (_c *MockService_UpdateProfile_Call) Run(run func(ctx context.Context, profile certs.Profile)) *MockService_UpdateProfile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string))
+		run(args[0].(context.Context), args[1].(certs.Profile))
 	})
 	return _c
 }
 
-func (_c *MockService_RetrieveCert_Call) Return(_a0 certs.Certificate, _a1 []byte, _a2 error) *MockService_RetrieveCert_Call {
-	_c.Call.Return(_a0, _a1, _a2)
+func (_c *MockService_UpdateProfile_Call) Return(_a0 certs.Profile, _a1 error) *MockService_UpdateProfile_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_RetrieveCert_Call) RunAndReturn(run func(context.Context, string, string) (certs.Certificate, []byte, error)) *MockService_RetrieveCert_Call {
+func (_c *MockService_UpdateProfile_Call) RunAndReturn(run func(context.Context, certs.Profile) (certs.Profile, error)) *MockService_UpdateProfile_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RetrieveCertDownloadToken provides a mock function with given fields: ctx, serialNumber
-func (_m *MockService) RetrieveCertDownloadToken(ctx context.Context, serialNumber string) (string, error) {
+// ViewCACert provides a mock function with given fields: ctx, serialNumber
+func (_m *MockService) ViewCACert(ctx context.Context, serialNumber string) (certs.Certificate, error) {
 	ret := _m.Called(ctx, serialNumber)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RetrieveCertDownloadToken")
+		panic("no return value specified for ViewCACert")
 	}
 
-	var r0 string
+	var r0 certs.Certificate
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.Certificate, error)); ok {
 		return rf(ctx, serialNumber)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) certs.Certificate); ok {
 		r0 = rf(ctx, serialNumber)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(certs.Certificate)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
@@ -694,78 +1919,145 @@ func (_m *MockService) RetrieveCertDownloadToken(ctx context.Context, serialNumb
 	return r0, r1
 }
 
-// MockService_RetrieveCertDownloadToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCertDownloadToken'
-type MockService_RetrieveCertDownloadToken_Call struct {
+// MockService_ViewCACert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewCACert'
+type MockService_ViewCACert_Call struct {
 	*mock.Call
 }
 
-// RetrieveCertDownloadToken is a helper method to define mock.On call
+// ViewCACert is a helper method to define mock.On call
 //   - ctx context.Context
 //   - serialNumber string
-func (_e *MockService_Expecter) RetrieveCertDownloadToken(ctx interface{}, serialNumber interface{}) *MockService_RetrieveCertDownloadToken_Call {
-	return &MockService_RetrieveCertDownloadToken_Call{Call: _e.mock.On("RetrieveCertDownloadToken", ctx, serialNumber)}
+func (_e *MockService_Expecter) ViewCACert(ctx interface{}, serialNumber interface{}) *MockService_ViewCACert_Call {
+	return &MockService_ViewCACert_Call{Call: _e.mock.On("ViewCACert", ctx, serialNumber)}
 }
 
-func (_c *MockService_RetrieveCertDownloadToken_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_RetrieveCertDownloadToken_Call {
+func (_c *MockService_ViewCACert_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_ViewCACert_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockService_RetrieveCertDownloadToken_Call) Return(_a0 string, _a1 error) *MockService_RetrieveCertDownloadToken_Call {
+func (_c *MockService_ViewCACert_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_ViewCACert_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_RetrieveCertDownloadToken_Call) RunAndReturn(run func(context.Context, string) (string, error)) *MockService_RetrieveCertDownloadToken_Call {
+func (_c *MockService_ViewCACert_Call) RunAndReturn(run func(context.Context, string) (certs.Certificate, error)) *MockService_ViewCACert_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RevokeCert provides a mock function with given fields: ctx, serialNumber
-func (_m *MockService) RevokeCert(ctx context.Context, serialNumber string) error {
-	ret := _m.Called(ctx, serialNumber)
+// ViewCAChain provides a mock function with given fields: ctx, issuer
+func (_m *MockService) ViewCAChain(ctx context.Context, issuer string) (certs.Certificate, error) {
+	ret := _m.Called(ctx, issuer)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RevokeCert")
+		panic("no return value specified for ViewCAChain")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, serialNumber)
+	var r0 certs.Certificate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.Certificate, error)); ok {
+		return rf(ctx, issuer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) certs.Certificate); ok {
+		r0 = rf(ctx, issuer)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(certs.Certificate)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, issuer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockService_RevokeCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeCert'
-type MockService_RevokeCert_Call struct {
+// MockService_ViewCAChain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewCAChain'
+type MockService_ViewCAChain_Call struct {
 	*mock.Call
 }
 
-// RevokeCert is a helper method to define mock.On call
+// ViewCAChain is a helper method to define mock.On call
 //   - ctx context.Context
-//   - serialNumber string
-func (_e *MockService_Expecter) RevokeCert(ctx interface{}, serialNumber interface{}) *MockService_RevokeCert_Call {
-	return &MockService_RevokeCert_Call{Call: _e.mock.On("RevokeCert", ctx, serialNumber)}
+//   - issuer string
+func (_e *MockService_Expecter) ViewCAChain(ctx interface{}, issuer interface{}) *MockService_ViewCAChain_Call {
+	return &MockService_ViewCAChain_Call{Call: _e.mock.On("ViewCAChain", ctx, issuer)}
 }
 
-func (_c *MockService_RevokeCert_Call) Run(run func(ctx context.Context, serialNumber string)) *MockService_RevokeCert_Call {
+func (_c *MockService_ViewCAChain_Call) Run(run func(ctx context.Context, issuer string)) *MockService_ViewCAChain_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockService_RevokeCert_Call) Return(_a0 error) *MockService_RevokeCert_Call {
-	_c.Call.Return(_a0)
+func (_c *MockService_ViewCAChain_Call) Return(_a0 certs.Certificate, _a1 error) *MockService_ViewCAChain_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ViewCAChain_Call) RunAndReturn(run func(context.Context, string) (certs.Certificate, error)) *MockService_ViewCAChain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ViewCSR provides a mock function with given fields: ctx, id
+func (_m *MockService) ViewCSR(ctx context.Context, id string) (certs.CSR, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewCSR")
+	}
+
+	var r0 certs.CSR
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.CSR, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) certs.CSR); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(certs.CSR)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ViewCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewCSR'
+type MockService_ViewCSR_Call struct {
+	*mock.Call
+}
+
+// ViewCSR is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockService_Expecter) ViewCSR(ctx interface{}, id interface{}) *MockService_ViewCSR_Call {
+	return &MockService_ViewCSR_Call{Call: _e.mock.On("ViewCSR", ctx, id)}
+}
+
+func (_c *MockService_ViewCSR_Call) Run(run func(ctx context.Context, id string)) *MockService_ViewCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_ViewCSR_Call) Return(_a0 certs.CSR, _a1 error) *MockService_ViewCSR_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_RevokeCert_Call) RunAndReturn(run func(context.Context, string) error) *MockService_RevokeCert_Call {
+func (_c *MockService_ViewCSR_Call) RunAndReturn(run func(context.Context, string) (certs.CSR, error)) *MockService_ViewCSR_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -827,6 +2119,63 @@ func (_c *MockService_ViewCert_Call) RunAndReturn(run func(context.Context, stri
 	return _c
 }
 
+// ViewProfile provides a mock function with given fields: ctx, name
+func (_m *MockService) ViewProfile(ctx context.Context, name string) (certs.Profile, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewProfile")
+	}
+
+	var r0 certs.Profile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (certs.Profile, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) certs.Profile); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(certs.Profile)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ViewProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewProfile'
+type MockService_ViewProfile_Call struct {
+	*mock.Call
+}
+
+// ViewProfile is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockService_Expecter) ViewProfile(ctx interface{}, name interface{}) *MockService_ViewProfile_Call {
+	return &MockService_ViewProfile_Call{Call: _e.mock.On("ViewProfile", ctx, name)}
+}
+
+func (_c *MockService_ViewProfile_Call) Run(run func(ctx context.Context, name string)) *MockService_ViewProfile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_ViewProfile_Call) Return(_a0 certs.Profile, _a1 error) *MockService_ViewProfile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ViewProfile_Call) RunAndReturn(run func(context.Context, string) (certs.Profile, error)) *MockService_ViewProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockService(t interface {
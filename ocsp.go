@@ -0,0 +1,404 @@
+package certs
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+	"golang.org/x/crypto/ocsp"
+)
+
+// Default OCSP settings.
+const (
+	ocspNextUpdate        = time.Hour
+	ocspResponderValidity = 7 * 24 * time.Hour
+)
+
+var (
+	// oidOCSPNonce is the extension binding a response to the request it answers.
+	oidOCSPNonce = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+	// oidOCSPNoCheck marks a delegated responder certificate whose revocation
+	// status relying parties do not need to check.
+	oidOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+	// oidSignatureEd25519 identifies Ed25519 signatures (RFC 8410).
+	oidSignatureEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+	// ed25519StandIn is reported to golang.org/x/crypto/ocsp in place of
+	// Ed25519 keys, which it cannot sign with. Responses of Ed25519 keys are
+	// signed by signResponseData instead.
+	ed25519StandIn = &ecdsa.PublicKey{Curve: elliptic.P256()}
+)
+
+// ocspResponder holds the certificate and key signing the OCSP responses of a CA.
+type ocspResponder struct {
+	certificate *x509.Certificate
+	signer      crypto.Signer
+}
+
+// ocspCacheKey identifies the cached response for a certificate. Requests may
+// identify the issuer with different hash algorithms, which yield different
+// responses.
+type ocspCacheKey struct {
+	issuerSerial string
+	serialNumber string
+	hash         crypto.Hash
+}
+
+// cachedOCSPResponse is a signed response together with the template it was
+// signed from. Responses that were not requested since they were last signed
+// are dropped instead of re-signed.
+type cachedOCSPResponse struct {
+	template  ocsp.Response
+	response  OCSPResponse
+	requested bool
+}
+
+// The types below mirror the OCSP response structures of RFC 6960 down to
+// the response extensions, which golang.org/x/crypto/ocsp cannot produce.
+type ocspResponseASN1 struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type basicOCSPResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	Version            int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID     asn1.RawValue
+	ProducedAt         time.Time `asn1:"generalized"`
+	Responses          []asn1.RawValue
+	ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+// deferredSigner records the signing options instead of signing, so that the
+// response data can be amended before it is signed once.
+type deferredSigner struct {
+	crypto.Signer
+	opts crypto.SignerOpts
+}
+
+func (d *deferredSigner) Public() crypto.PublicKey {
+	if _, ok := d.Signer.Public().(ed25519.PublicKey); ok {
+		return ed25519StandIn
+	}
+
+	return d.Signer.Public()
+}
+
+func (d *deferredSigner) Sign(_ io.Reader, _ []byte, opts crypto.SignerOpts) ([]byte, error) {
+	d.opts = opts
+
+	return nil, nil
+}
+
+// SignOCSP signs the OCSP response for a certificate issued by the CA with the
+// given serial number. The response is signed by the CA itself or, if enabled,
+// by its delegated responder and is valid for the configured next update.
+// With the cache enabled, responses without a nonce are served from the cache
+// as long as the certificate status matches the template.
+func (s *service) SignOCSP(ctx context.Context, issuerSerial string, template ocsp.Response, nonce []byte) (OCSPResponse, error) {
+	ca := s.caBySerial(issuerSerial)
+	if ca == nil {
+		return OCSPResponse{}, ErrCANotFound
+	}
+	if ca.Signer == nil {
+		return OCSPResponse{}, ErrCAKeyUnavailable
+	}
+	if !s.config.OCSP.Cache || nonce != nil {
+		return s.signOCSP(ca, template, nonce)
+	}
+
+	key := ocspCacheKey{issuerSerial: ca.SerialNumber, serialNumber: template.SerialNumber.String(), hash: template.IssuerHash}
+	s.ocspCacheMu.Lock()
+	if cached, ok := s.ocspResponses[key]; ok && cached.matches(template) && !cached.due() {
+		cached.requested = true
+		s.ocspCacheMu.Unlock()
+		return cached.response, nil
+	}
+	s.ocspCacheMu.Unlock()
+
+	response, err := s.signOCSP(ca, template, nil)
+	if err != nil {
+		return OCSPResponse{}, err
+	}
+	s.ocspCacheMu.Lock()
+	s.ocspResponses[key] = &cachedOCSPResponse{template: template, response: response, requested: true}
+	s.ocspCacheMu.Unlock()
+
+	return response, nil
+}
+
+// signOCSP signs the response of the CA from the template, echoing the nonce if given.
+func (s *service) signOCSP(ca *CA, template ocsp.Response, nonce []byte) (OCSPResponse, error) {
+	responder, err := s.ocspResponder(ca)
+	if err != nil {
+		return OCSPResponse{}, err
+	}
+
+	// OCSP times are encoded with a precision of seconds.
+	template.ThisUpdate = time.Now().Truncate(time.Second)
+	template.NextUpdate = template.ThisUpdate.Add(s.config.OCSP.NextUpdate)
+	if template.NextUpdate.After(responder.certificate.NotAfter) {
+		template.NextUpdate = responder.certificate.NotAfter
+	}
+	// Relying parties need the delegated responder certificate to verify the response.
+	template.Certificate = nil
+	if responder.certificate != ca.Certificate {
+		template.Certificate = responder.certificate
+	}
+
+	response := OCSPResponse{ThisUpdate: template.ThisUpdate, NextUpdate: template.NextUpdate}
+
+	_, ed := responder.signer.Public().(ed25519.PublicKey)
+	if nonce == nil && !ed {
+		if response.DER, err = ocsp.CreateResponse(ca.Certificate, responder.certificate, template, responder.signer); err != nil {
+			return OCSPResponse{}, err
+		}
+		return response, nil
+	}
+	var extensions []pkix.Extension
+	if nonce != nil {
+		value, err := asn1.Marshal(nonce)
+		if err != nil {
+			return OCSPResponse{}, err
+		}
+		extensions = append(extensions, pkix.Extension{Id: oidOCSPNonce, Value: value})
+	}
+	signer := &deferredSigner{Signer: responder.signer}
+	der, err := ocsp.CreateResponse(ca.Certificate, responder.certificate, template, signer)
+	if err != nil {
+		return OCSPResponse{}, err
+	}
+	if response.DER, err = signResponseData(der, responder.signer, signer.opts, extensions); err != nil {
+		return OCSPResponse{}, err
+	}
+
+	return response, nil
+}
+
+// signResponseData adds the response extensions to the unsigned response and
+// signs it. Ed25519 keys sign the response data itself instead of its hash.
+func signResponseData(der []byte, signer crypto.Signer, opts crypto.SignerOpts, extensions []pkix.Extension) ([]byte, error) {
+	var response ocspResponseASN1
+	if _, err := asn1.Unmarshal(der, &response); err != nil {
+		return nil, err
+	}
+	var basic basicOCSPResponse
+	if _, err := asn1.Unmarshal(response.Response.Response, &basic); err != nil {
+		return nil, err
+	}
+	var data ocspResponseData
+	if _, err := asn1.Unmarshal(basic.TBSResponseData.FullBytes, &data); err != nil {
+		return nil, err
+	}
+	data.ResponseExtensions = extensions
+	tbs, err := asn1.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var signature []byte
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		basic.SignatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidSignatureEd25519}
+		signature, err = signer.Sign(rand.Reader, tbs, crypto.Hash(0))
+	} else {
+		h := opts.HashFunc().New()
+		h.Write(tbs)
+		signature, err = signer.Sign(rand.Reader, h.Sum(nil), opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	basic.TBSResponseData = asn1.RawValue{FullBytes: tbs}
+	basic.Signature = asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)}
+	if response.Response.Response, err = asn1.Marshal(basic); err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(response)
+}
+
+// refreshOCSPResponses re-signs the cached responses halfway to their next
+// update until the context is done, so that requests are answered without
+// signing. Responses not requested since they were last signed are dropped.
+func (s *service) refreshOCSPResponses(ctx context.Context) {
+	ticker := time.NewTicker(s.config.OCSP.NextUpdate / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ocspCacheMu.Lock()
+			due := make(map[ocspCacheKey]*cachedOCSPResponse)
+			for key, cached := range s.ocspResponses {
+				switch {
+				case !cached.due():
+				case cached.requested:
+					due[key] = cached
+				default:
+					delete(s.ocspResponses, key)
+				}
+			}
+			s.ocspCacheMu.Unlock()
+
+			for key, cached := range due {
+				ca := s.caBySerial(key.issuerSerial)
+				if ca == nil || ca.Signer == nil {
+					continue
+				}
+				response, err := s.signOCSP(ca, cached.template, nil)
+				if err != nil {
+					continue
+				}
+				s.ocspCacheMu.Lock()
+				// Responses dropped or replaced meanwhile are not restored.
+				if s.ocspResponses[key] == cached {
+					s.ocspResponses[key] = &cachedOCSPResponse{template: cached.template, response: response}
+				}
+				s.ocspCacheMu.Unlock()
+			}
+		}
+	}
+}
+
+// invalidateOCSPResponses drops the cached responses of the certificate
+// after its revocation status changed.
+func (s *service) invalidateOCSPResponses(cert Certificate) {
+	s.ocspCacheMu.Lock()
+	defer s.ocspCacheMu.Unlock()
+
+	for key := range s.ocspResponses {
+		if key.serialNumber == cert.SerialNumber {
+			delete(s.ocspResponses, key)
+		}
+	}
+}
+
+// matches reports whether the cached response reports the status of the template.
+func (c *cachedOCSPResponse) matches(template ocsp.Response) bool {
+	return c.template.Status == template.Status &&
+		c.template.RevokedAt.Equal(template.RevokedAt) &&
+		c.template.RevocationReason == template.RevocationReason
+}
+
+// due reports whether the cached response is halfway to its next update.
+func (c *cachedOCSPResponse) due() bool {
+	validity := c.response.NextUpdate.Sub(c.response.ThisUpdate)
+
+	return !time.Now().Before(c.response.ThisUpdate.Add(validity / 2))
+}
+
+// ocspResponder returns the responder of the CA. Unless delegated responders
+// are enabled, that is the CA itself. Delegated responders are provisioned on
+// first use and replaced halfway through their validity.
+func (s *service) ocspResponder(ca *CA) (ocspResponder, error) {
+	if !s.config.OCSP.DelegatedResponder {
+		return ocspResponder{certificate: ca.Certificate, signer: ca.Signer}, nil
+	}
+
+	s.ocspMu.Lock()
+	defer s.ocspMu.Unlock()
+
+	if responder, ok := s.responders[ca.SerialNumber]; ok {
+		validity := responder.certificate.NotAfter.Sub(responder.certificate.NotBefore)
+		if time.Now().Before(responder.certificate.NotBefore.Add(validity / 2)) {
+			return responder, nil
+		}
+	}
+	responder, err := s.delegateResponder(ca)
+	if err != nil {
+		return ocspResponder{}, err
+	}
+	s.responders[ca.SerialNumber] = responder
+
+	return responder, nil
+}
+
+// delegateResponder issues a delegated OCSP responder certificate with the
+// OCSP signing extended key usage under the CA. Its key is kept in memory only.
+func (s *service) delegateResponder(ca *CA) (ocspResponder, error) {
+	settings := s.config.Root
+	if ca.Type == IntermediateCA {
+		settings = s.config.issuerSettings(ca.Name)
+	}
+	key, err := GenerateKey(settings.KeyAlgorithm, settings.KeySize)
+	if err != nil {
+		return ocspResponder{}, err
+	}
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return ocspResponder{}, err
+	}
+
+	now := time.Now()
+	notAfter := now.Add(s.config.OCSP.ResponderValidity)
+	if notAfter.After(ca.Certificate.NotAfter) {
+		notAfter = ca.Certificate.NotAfter
+	}
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   ca.Certificate.Subject.CommonName + " OCSP Responder",
+			Organization: ca.Certificate.Subject.Organization,
+		},
+		NotBefore:             now,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		BasicConstraintsValid: true,
+		ExtraExtensions:       []pkix.Extension{{Id: oidOCSPNoCheck, Value: asn1.NullBytes}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, ca.Certificate, key.Public(), ca.Signer)
+	if err != nil {
+		return ocspResponder{}, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return ocspResponder{}, err
+	}
+
+	return ocspResponder{certificate: cert, signer: key}, nil
+}
+
+// withDefaults fills the unset OCSP settings with the built-in defaults.
+func (c OCSPSettings) withDefaults() OCSPSettings {
+	if c.NextUpdate == 0 {
+		c.NextUpdate = ocspNextUpdate
+	}
+	if c.ResponderValidity == 0 {
+		c.ResponderValidity = max(ocspResponderValidity, 2*c.NextUpdate)
+	}
+
+	return c
+}
+
+func (c OCSPSettings) validate() error {
+	switch {
+	case c.NextUpdate <= 0:
+		return errors.New("next_update must be positive")
+	case c.ResponderValidity <= c.NextUpdate:
+		return errors.New("responder_validity must be longer than next_update")
+	}
+
+	return nil
+}
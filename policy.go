@@ -0,0 +1,285 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+const (
+	minRSAKeySize   = 2048
+	minECDSAKeySize = 256
+)
+
+// Policy rules reported by PolicyError.
+const (
+	RuleKeyStrength        = "key_strength"
+	RuleMaxTTL             = "max_ttl"
+	RuleEntityMaxTTL       = "entity_max_ttl"
+	RuleProfileMaxTTL      = "profile_max_ttl"
+	RuleProfileAllowedSANs = "profile_allowed_sans"
+	RuleDeniedDomains      = "denied_domains"
+	RuleAllowedDomains     = "allowed_domains"
+	RuleDeniedIPRanges     = "denied_ip_ranges"
+	RuleAllowedIPRanges    = "allowed_ip_ranges"
+	RuleRequiredSubject    = "required_subject"
+)
+
+var ErrPolicyViolation = errors.New("certificate request violates the issuance policy")
+
+var subjectFields = map[string]func(pkix.Name) bool{
+	"common_name":         func(n pkix.Name) bool { return n.CommonName != "" },
+	"organization":        func(n pkix.Name) bool { return len(n.Organization) > 0 },
+	"organizational_unit": func(n pkix.Name) bool { return len(n.OrganizationalUnit) > 0 },
+	"country":             func(n pkix.Name) bool { return len(n.Country) > 0 },
+	"province":            func(n pkix.Name) bool { return len(n.Province) > 0 },
+	"locality":            func(n pkix.Name) bool { return len(n.Locality) > 0 },
+	"street_address":      func(n pkix.Name) bool { return len(n.StreetAddress) > 0 },
+	"postal_code":         func(n pkix.Name) bool { return len(n.PostalCode) > 0 },
+}
+
+var _ errors.Error = (*PolicyError)(nil)
+
+// PolicyError is returned when a certificate request is rejected by the
+// issuance policy or the certificate profile. It names the failed rule and the
+// offending value.
+type PolicyError struct {
+	Rule   string
+	Field  string
+	Value  string
+	Reason string
+	// cause is the sentinel error the violation is reported as, besides ErrPolicyViolation.
+	cause error
+}
+
+func policyError(cause error, rule, field, value, reason string) *PolicyError {
+	return &PolicyError{
+		Rule:   rule,
+		Field:  field,
+		Value:  value,
+		Reason: reason,
+		cause:  cause,
+	}
+}
+
+func (e *PolicyError) Error() string {
+	return e.Msg() + " : " + e.Err().Error()
+}
+
+func (e *PolicyError) Msg() string {
+	return ErrPolicyViolation.Msg()
+}
+
+func (e *PolicyError) Err() errors.Error {
+	reason := errors.New(e.Reason)
+	if e.cause == nil {
+		return reason
+	}
+
+	return errors.Wrap(e.cause, reason).(errors.Error)
+}
+
+func (e *PolicyError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Err   string `json:"error"`
+		Msg   string `json:"message"`
+		Rule  string `json:"rule"`
+		Field string `json:"field,omitempty"`
+		Value string `json:"value,omitempty"`
+	}{
+		Err:   e.Reason,
+		Msg:   e.Msg(),
+		Rule:  e.Rule,
+		Field: e.Field,
+		Value: e.Value,
+	})
+}
+
+// evaluate checks the certificate template of the entity against the policy.
+func (p Policy) evaluate(entityID string, template *x509.Certificate, pubKey crypto.PublicKey) error {
+	if err := p.checkKey(pubKey); err != nil {
+		return err
+	}
+	if err := p.checkValidity(entityID, template.NotAfter.Sub(template.NotBefore)); err != nil {
+		return err
+	}
+
+	names := template.DNSNames
+	ips := template.IPAddresses
+	// Common names holding a host name or an IP address are subject to the same rules as the SANs.
+	cn := template.Subject.CommonName
+	switch ip := net.ParseIP(cn); {
+	case ip != nil:
+		ips = append([]net.IP{ip}, ips...)
+	case isDomain(cn):
+		names = append([]string{cn}, names...)
+	}
+	for _, name := range names {
+		if err := p.checkDomain(name); err != nil {
+			return err
+		}
+	}
+	for _, ip := range ips {
+		if err := p.checkIP(ip); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range p.RequiredSubject {
+		if !subjectFields[field](template.Subject) {
+			return policyError(nil, RuleRequiredSubject, field, "", fmt.Sprintf("subject field %s is required", field))
+		}
+	}
+
+	return nil
+}
+
+func (p Policy) checkKey(pubKey crypto.PublicKey) error {
+	switch key := pubKey.(type) {
+	case *rsa.PublicKey:
+		if size := key.N.BitLen(); size < p.MinRSAKeySize {
+			return policyError(nil, RuleKeyStrength, "public_key", fmt.Sprintf("RSA %d", size), fmt.Sprintf("RSA keys must have at least %d bits", p.MinRSAKeySize))
+		}
+	case *ecdsa.PublicKey:
+		if size := key.Curve.Params().BitSize; size < p.MinECDSAKeySize {
+			return policyError(nil, RuleKeyStrength, "public_key", fmt.Sprintf("ECDSA %d", size), fmt.Sprintf("ECDSA keys must have at least %d bits", p.MinECDSAKeySize))
+		}
+	}
+
+	return nil
+}
+
+func (p Policy) checkValidity(entityID string, validity time.Duration) error {
+	if limit, ok := p.EntityMaxTTL[entityID]; ok && validity > limit {
+		return policyError(nil, RuleEntityMaxTTL, "ttl", validity.String(), fmt.Sprintf("ttl exceeds the max_ttl %s of entity %s", limit, entityID))
+	}
+	if p.MaxTTL > 0 && validity > p.MaxTTL {
+		return policyError(nil, RuleMaxTTL, "ttl", validity.String(), fmt.Sprintf("ttl exceeds the max_ttl %s", p.MaxTTL))
+	}
+
+	return nil
+}
+
+func (p Policy) checkDomain(name string) error {
+	for _, pattern := range p.DeniedDomains {
+		if matchDomain(pattern, name) || coversDomain(name, pattern) {
+			return policyError(nil, RuleDeniedDomains, "dns_names", name, "domain matches the denied pattern "+pattern)
+		}
+	}
+	if len(p.AllowedDomains) == 0 {
+		return nil
+	}
+	for _, pattern := range p.AllowedDomains {
+		if matchDomain(pattern, name) {
+			return nil
+		}
+	}
+
+	return policyError(nil, RuleAllowedDomains, "dns_names", name, "domain does not match any allowed pattern")
+}
+
+func (p Policy) checkIP(ip net.IP) error {
+	for _, r := range p.DeniedIPRanges {
+		if r.Contains(ip) {
+			return policyError(nil, RuleDeniedIPRanges, "ip_addresses", ip.String(), "IP address is in the denied range "+r.String())
+		}
+	}
+	if len(p.AllowedIPRanges) == 0 {
+		return nil
+	}
+	for _, r := range p.AllowedIPRanges {
+		if r.Contains(ip) {
+			return nil
+		}
+	}
+
+	return policyError(nil, RuleAllowedIPRanges, "ip_addresses", ip.String(), "IP address is not in any allowed range")
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MinRSAKeySize == 0 {
+		p.MinRSAKeySize = minRSAKeySize
+	}
+	if p.MinECDSAKeySize == 0 {
+		p.MinECDSAKeySize = minECDSAKeySize
+	}
+
+	return p
+}
+
+func (p Policy) validate() error {
+	for _, pattern := range append(p.AllowedDomains, p.DeniedDomains...) {
+		if strings.Trim(pattern, "*.") == "" || strings.Contains(strings.TrimPrefix(pattern, "*."), "*") {
+			return errors.New("invalid domain pattern " + pattern)
+		}
+	}
+	for _, field := range p.RequiredSubject {
+		if _, ok := subjectFields[field]; !ok {
+			return errors.New("unsupported required subject field " + field)
+		}
+	}
+	if p.MaxTTL < 0 {
+		return errors.New("max_ttl must not be negative")
+	}
+	for entityID, ttl := range p.EntityMaxTTL {
+		if ttl <= 0 {
+			return errors.New("max_ttl of entity " + entityID + " must be positive")
+		}
+	}
+	if p.MinRSAKeySize < 0 || p.MinECDSAKeySize < 0 {
+		return errors.New("minimum key sizes must not be negative")
+	}
+
+	return nil
+}
+
+// matchDomain reports whether the name matches the pattern. A pattern starting
+// with "*." matches a single label and one starting with "." matches any
+// subdomain. Wildcard names match patterns covering all of their names.
+func matchDomain(pattern, name string) bool {
+	pattern = strings.ToLower(pattern)
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	switch {
+	case pattern == name:
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		label, ok := strings.CutSuffix(name, pattern[1:])
+		return ok && label != "" && !strings.Contains(label, ".")
+	case strings.HasPrefix(pattern, "."):
+		return len(name) > len(pattern) && strings.HasSuffix(name, pattern)
+	}
+
+	return false
+}
+
+// coversDomain reports whether the wildcard name is valid for a name matching
+// the pattern, so that a wildcard cannot get past a denied name it covers.
+func coversDomain(name, pattern string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	scope, ok := strings.CutPrefix(name, "*")
+	if !ok || !strings.HasPrefix(scope, ".") {
+		return false
+	}
+	pattern = strings.ToLower(pattern)
+	if strings.HasPrefix(pattern, "*.") || strings.HasPrefix(pattern, ".") {
+		// Wildcard and subdomain patterns match a name covered by the
+		// wildcard if they match any single label in its place.
+		return matchDomain(pattern, "x"+scope)
+	}
+	label, ok := strings.CutSuffix(pattern, scope)
+
+	return ok && label != "" && !strings.Contains(label, ".")
+}
+
+func isDomain(name string) bool {
+	return strings.Contains(name, ".") && !strings.ContainsAny(name, " @/:")
+}
\ No newline at end of file
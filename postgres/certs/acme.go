@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/acme"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/postgres"
+)
+
+var _ acme.Repository = (*acmeRepo)(nil)
+
+// acmeRepo stores ACME accounts, orders and authorizations as JSON documents.
+type acmeRepo struct {
+	db postgres.Database
+}
+
+// NewACMERepository returns the ACME repository on the certs database.
+func NewACMERepository(db postgres.Database) acme.Repository {
+	return acmeRepo{
+		db: db,
+	}
+}
+
+func (repo acmeRepo) CreateAccount(ctx context.Context, account acme.Account) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return errors.Wrap(certs.ErrCreateEntity, err)
+	}
+	q := `INSERT INTO acme_accounts (id, thumbprint, data) VALUES ($1, $2, $3)`
+	if _, err := repo.db.ExecContext(ctx, q, account.ID, account.Thumbprint, data); err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (repo acmeRepo) RetrieveAccount(ctx context.Context, id string) (acme.Account, error) {
+	var account acme.Account
+	if err := repo.retrieve(ctx, `SELECT data FROM acme_accounts WHERE id = $1`, id, &account); err != nil {
+		return acme.Account{}, err
+	}
+
+	return account, nil
+}
+
+func (repo acmeRepo) RetrieveAccountByThumbprint(ctx context.Context, thumbprint string) (acme.Account, error) {
+	var account acme.Account
+	if err := repo.retrieve(ctx, `SELECT data FROM acme_accounts WHERE thumbprint = $1`, thumbprint, &account); err != nil {
+		return acme.Account{}, err
+	}
+
+	return account, nil
+}
+
+func (repo acmeRepo) UpdateAccount(ctx context.Context, account acme.Account) error {
+	return repo.update(ctx, `UPDATE acme_accounts SET data = $2 WHERE id = $1`, account.ID, account)
+}
+
+// CreateOrder stores the order and its authorizations in a single transaction.
+func (repo acmeRepo) CreateOrder(ctx context.Context, order acme.Order, authzs []acme.Authorization) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(certs.ErrCreateEntity, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	data, err := json.Marshal(order)
+	if err != nil {
+		return errors.Wrap(certs.ErrCreateEntity, err)
+	}
+	q := `INSERT INTO acme_orders (id, account_id, data) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, q, order.ID, order.AccountID, data); err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+	for _, authz := range authzs {
+		data, err := json.Marshal(authz)
+		if err != nil {
+			return errors.Wrap(certs.ErrCreateEntity, err)
+		}
+		q := `INSERT INTO acme_authorizations (id, order_id, data) VALUES ($1, $2, $3)`
+		if _, err := tx.ExecContext(ctx, q, authz.ID, order.ID, data); err != nil {
+			return handleError(certs.ErrCreateEntity, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(certs.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (repo acmeRepo) RetrieveOrder(ctx context.Context, id string) (acme.Order, error) {
+	var order acme.Order
+	if err := repo.retrieve(ctx, `SELECT data FROM acme_orders WHERE id = $1`, id, &order); err != nil {
+		return acme.Order{}, err
+	}
+
+	return order, nil
+}
+
+// UpdateOrder updates the order only if it still has the status, so that
+// concurrent requests cannot both move the order out of it.
+func (repo acmeRepo) UpdateOrder(ctx context.Context, order acme.Order, status acme.Status) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	q := `UPDATE acme_orders SET data = $2 WHERE id = $1 AND data->>'status' = $3`
+	res, err := repo.db.ExecContext(ctx, q, order.ID, data, status)
+	if err != nil {
+		return handleError(certs.ErrUpdateEntity, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	if count == 0 {
+		return acme.ErrOrderNotReady
+	}
+
+	return nil
+}
+
+func (repo acmeRepo) RetrieveAuthorization(ctx context.Context, id string) (acme.Authorization, error) {
+	var authz acme.Authorization
+	if err := repo.retrieve(ctx, `SELECT data FROM acme_authorizations WHERE id = $1`, id, &authz); err != nil {
+		return acme.Authorization{}, err
+	}
+
+	return authz, nil
+}
+
+func (repo acmeRepo) UpdateAuthorization(ctx context.Context, authz acme.Authorization) error {
+	return repo.update(ctx, `UPDATE acme_authorizations SET data = $2 WHERE id = $1`, authz.ID, authz)
+}
+
+// retrieve decodes the JSON document selected by the query with the key into v.
+func (repo acmeRepo) retrieve(ctx context.Context, q, key string, v any) error {
+	var data []byte
+	if err := repo.db.QueryRowxContext(ctx, q, key).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.Wrap(acme.ErrNotFound, err)
+		}
+		return errors.Wrap(certs.ErrViewEntity, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return nil
+}
+
+// update replaces the JSON document with the given ID by v.
+func (repo acmeRepo) update(ctx context.Context, q, id string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	res, err := repo.db.ExecContext(ctx, q, id, data)
+	if err != nil {
+		return handleError(certs.ErrUpdateEntity, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	if count == 0 {
+		return acme.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/audit"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/postgres"
+)
+
+var _ audit.Repository = (*auditRepo)(nil)
+
+// auditRepo stores audit events in the append-only audit_events table,
+// which rejects updates and deletions.
+type auditRepo struct {
+	db postgres.Database
+}
+
+// NewAuditRepository returns the audit log repository on the certs database.
+func NewAuditRepository(db postgres.Database) audit.Repository {
+	return auditRepo{
+		db: db,
+	}
+}
+
+const auditColumns = `seq, time, actor, role, action, serial_number, entity_id, params, result, error, prev_hash, hash`
+
+type dbEvent struct {
+	Seq          uint64    `db:"seq"`
+	Time         time.Time `db:"time"`
+	Actor        string    `db:"actor"`
+	Role         string    `db:"role"`
+	Action       string    `db:"action"`
+	SerialNumber string    `db:"serial_number"`
+	EntityID     string    `db:"entity_id"`
+	Params       []byte    `db:"params"`
+	Result       string    `db:"result"`
+	Error        string    `db:"error"`
+	PrevHash     string    `db:"prev_hash"`
+	Hash         string    `db:"hash"`
+}
+
+func (repo auditRepo) Last(ctx context.Context) (audit.Event, error) {
+	q := fmt.Sprintf(`SELECT %s FROM audit_events ORDER BY seq DESC LIMIT 1`, auditColumns)
+	var e dbEvent
+	if err := repo.db.QueryRowxContext(ctx, q).StructScan(&e); err != nil {
+		if err == sql.ErrNoRows {
+			return audit.Event{}, nil
+		}
+		return audit.Event{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return toEvent(e)
+}
+
+func (repo auditRepo) Append(ctx context.Context, event audit.Event) error {
+	params, err := json.Marshal(event.Params)
+	if err != nil {
+		return errors.Wrap(certs.ErrCreateEntity, err)
+	}
+	q := fmt.Sprintf(`INSERT INTO audit_events (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT (seq) DO NOTHING`, auditColumns)
+	res, err := repo.db.ExecContext(ctx, q, event.Seq, event.Time, event.Actor, event.Role, string(event.Action),
+		event.SerialNumber, event.EntityID, params, string(event.Result), event.Error, event.PrevHash, event.Hash)
+	if err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return audit.ErrConflict
+	}
+
+	return nil
+}
+
+func (repo auditRepo) List(ctx context.Context, filter audit.Filter) (audit.Page, error) {
+	var conditions []string
+	if filter.Actor != "" {
+		conditions = append(conditions, `actor = :actor`)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, `action = :action`)
+	}
+	if filter.SerialNumber != "" {
+		conditions = append(conditions, `serial_number = :serial_number`)
+	}
+	if filter.EntityID != "" {
+		conditions = append(conditions, `entity_id = :entity_id`)
+	}
+	if filter.Result != "" {
+		conditions = append(conditions, `result = :result`)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, `time >= :from`)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, `time <= :to`)
+	}
+	var condition string
+	if len(conditions) > 0 {
+		condition = `WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	params := map[string]interface{}{
+		"limit":         filter.Limit,
+		"offset":        filter.Offset,
+		"actor":         filter.Actor,
+		"action":        string(filter.Action),
+		"serial_number": filter.SerialNumber,
+		"entity_id":     filter.EntityID,
+		"result":        string(filter.Result),
+		"from":          filter.From,
+		"to":            filter.To,
+	}
+
+	q := fmt.Sprintf(`SELECT %s FROM audit_events %s ORDER BY seq DESC LIMIT :limit OFFSET :offset`, auditColumns, condition)
+	rows, err := repo.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return audit.Page{}, handleError(certs.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	events := []audit.Event{}
+	for rows.Next() {
+		var e dbEvent
+		if err := rows.StructScan(&e); err != nil {
+			return audit.Page{}, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		event, err := toEvent(e)
+		if err != nil {
+			return audit.Page{}, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return audit.Page{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	q = fmt.Sprintf(`SELECT COUNT(*) FROM audit_events %s`, condition)
+	filter.Total, err = certsRepo{db: repo.db}.total(ctx, q, params)
+	if err != nil {
+		return audit.Page{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return audit.Page{
+		Filter: filter,
+		Events: events,
+	}, nil
+}
+
+func (repo auditRepo) Range(ctx context.Context, from, limit uint64) ([]audit.Event, error) {
+	q := fmt.Sprintf(`SELECT %s FROM audit_events WHERE seq >= $1 ORDER BY seq LIMIT $2`, auditColumns)
+	rows, err := repo.db.QueryxContext(ctx, q, from, limit)
+	if err != nil {
+		return nil, handleError(certs.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var events []audit.Event
+	for rows.Next() {
+		var e dbEvent
+		if err := rows.StructScan(&e); err != nil {
+			return nil, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		event, err := toEvent(e)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return events, nil
+}
+
+func toEvent(e dbEvent) (audit.Event, error) {
+	var params map[string]string
+	if len(e.Params) > 0 {
+		if err := json.Unmarshal(e.Params, &params); err != nil {
+			return audit.Event{}, errors.Wrap(certs.ErrViewEntity, err)
+		}
+	}
+
+	return audit.Event{
+		Seq:          e.Seq,
+		Time:         e.Time.UTC(),
+		Actor:        e.Actor,
+		Role:         e.Role,
+		Action:       audit.Action(e.Action),
+		SerialNumber: e.SerialNumber,
+		EntityID:     e.EntityID,
+		Params:       params,
+		Result:       audit.Result(e.Result),
+		Error:        e.Error,
+		PrevHash:     e.PrevHash,
+		Hash:         e.Hash,
+	}, nil
+}
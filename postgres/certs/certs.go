@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/hantdev/certs"
 	"github.com/hantdev/certs/errors"
@@ -41,8 +42,8 @@ func NewRepository(db postgres.Database) certs.Repository {
 // CreateLog creates computation log in the database.
 func (repo certsRepo) CreateCert(ctx context.Context, cert certs.Certificate) error {
 	q := `
-	INSERT INTO certs (serial_number, certificate, key, entity_id, revoked, expiry_time, type)
-	VALUES (:serial_number, :certificate, :key, :entity_id, :revoked, :expiry_time, :type)`
+	INSERT INTO certs (serial_number, certificate, key, key_ref, key_version, entity_id, revoked, expiry_time, type, issuer_name, issuer_serial, retired, staged, cross_certificate, profile)
+	VALUES (:serial_number, :certificate, :key, :key_ref, :key_version, :entity_id, :revoked, :expiry_time, :type, :issuer_name, :issuer_serial, :retired, :staged, :cross_certificate, :profile)`
 	_, err := repo.db.NamedExecContext(ctx, q, cert)
 	if err != nil {
 		return handleError(certs.ErrCreateEntity, err)
@@ -52,7 +53,7 @@ func (repo certsRepo) CreateCert(ctx context.Context, cert certs.Certificate) er
 
 // RetrieveLog retrieves computation log from the database.
 func (repo certsRepo) RetrieveCert(ctx context.Context, serialNumber string) (certs.Certificate, error) {
-	q := `SELECT serial_number, certificate, key, entity_id, revoked, expiry_time FROM certs WHERE serial_number = $1`
+	q := `SELECT serial_number, certificate, key, key_ref, key_version, COALESCE(entity_id, '') AS entity_id, revoked, expiry_time, revocation_reason, revocation_time, invalidity_date, issuer_name, issuer_serial, retired, staged, profile FROM certs WHERE serial_number = $1`
 	var cert certs.Certificate
 	if err := repo.db.QueryRowxContext(ctx, q, serialNumber).StructScan(&cert); err != nil {
 		if err == sql.ErrNoRows {
@@ -65,12 +66,12 @@ func (repo certsRepo) RetrieveCert(ctx context.Context, serialNumber string) (ce
 
 // GetCAs reterives rootCA and intermediateCA from database.
 func (repo certsRepo) GetCAs(ctx context.Context, caType ...certs.CertType) ([]certs.Certificate, error) {
-	q := `SELECT serial_number, key, certificate, expiry_time, revoked, type FROM certs WHERE type = ANY($1)`
+	q := `SELECT serial_number, key, key_ref, key_version, certificate, expiry_time, revoked, type, issuer_name, issuer_serial, retired, staged, cross_certificate FROM certs WHERE type = ANY($1)`
 	var certificates []certs.Certificate
 
 	types := make([]string, 0, len(caType))
-	for i, t := range caType {
-		types[i] = t.String()
+	for _, t := range caType {
+		types = append(types, t.String())
 	}
 
 	if len(types) == 0 {
@@ -89,10 +90,17 @@ func (repo certsRepo) GetCAs(ctx context.Context, caType ...certs.CertType) ([]c
 		if err := rows.Scan(
 			&cert.SerialNumber,
 			&cert.Key,
+			&cert.KeyRef,
+			&cert.KeyVersion,
 			&cert.Certificate,
 			&cert.ExpiryTime,
 			&cert.Revoked,
 			&certType,
+			&cert.IssuerName,
+			&cert.IssuerSerial,
+			&cert.Retired,
+			&cert.Staged,
+			&cert.CrossCert,
 		); err != nil {
 			return []certs.Certificate{}, errors.Wrap(certs.ErrViewEntity, err)
 		}
@@ -115,7 +123,7 @@ func (repo certsRepo) GetCAs(ctx context.Context, caType ...certs.CertType) ([]c
 
 // UpdateLog updates computation log in the database.
 func (repo certsRepo) UpdateCert(ctx context.Context, cert certs.Certificate) error {
-	q := `UPDATE certs SET certificate = :certificate, key = :key, revoked = :revoked, expiry_time = :expiry_time WHERE serial_number = :serial_number`
+	q := `UPDATE certs SET certificate = :certificate, key = :key, key_version = :key_version, revoked = :revoked, expiry_time = :expiry_time, revocation_reason = :revocation_reason, revocation_time = :revocation_time, invalidity_date = :invalidity_date, issuer_serial = :issuer_serial, retired = :retired, staged = :staged WHERE serial_number = :serial_number`
 	res, err := repo.db.NamedExecContext(ctx, q, cert)
 	if err != nil {
 		return handleError(certs.ErrUpdateEntity, err)
@@ -172,13 +180,29 @@ func (repo certsRepo) ListCerts(ctx context.Context, pm certs.PageMetadata) (cer
 	}, nil
 }
 
-func (repo certsRepo) ListRevokedCerts(ctx context.Context) ([]certs.Certificate, error) {
+func (repo certsRepo) CountValidCerts(ctx context.Context, issuerSerials []string) (uint64, error) {
+	q := `SELECT COUNT(*) FROM certs WHERE type = $1 AND revoked = false AND expiry_time > $2 AND issuer_serial = ANY($3)`
+
+	var total uint64
+	if err := repo.db.QueryRowxContext(ctx, q, certs.ClientCert.String(), time.Now(), issuerSerials).Scan(&total); err != nil {
+		return 0, handleError(certs.ErrViewEntity, err)
+	}
+
+	return total, nil
+}
+
+func (repo certsRepo) ListRevokedCerts(ctx context.Context, issuerSerials ...string) ([]certs.Certificate, error) {
 	query := `
-        SELECT serial_number, entity_id, expiry_time
+        SELECT serial_number, entity_id, expiry_time, revocation_reason, revocation_time, invalidity_date
         FROM certs
         WHERE revoked = true
     `
-	rows, err := repo.db.QueryContext(ctx, query)
+	var args []interface{}
+	if len(issuerSerials) > 0 {
+		query += ` AND issuer_serial = ANY($1)`
+		args = append(args, issuerSerials)
+	}
+	rows, err := repo.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, handleError(certs.ErrViewEntity, err)
 	}
@@ -187,7 +211,7 @@ func (repo certsRepo) ListRevokedCerts(ctx context.Context) ([]certs.Certificate
 	var revokedCerts []certs.Certificate
 	for rows.Next() {
 		var cert certs.Certificate
-		if err := rows.Scan(&cert.SerialNumber, &cert.EntityID, &cert.ExpiryTime); err != nil {
+		if err := rows.Scan(&cert.SerialNumber, &cert.EntityID, &cert.ExpiryTime, &cert.RevocationReason, &cert.RevocationTime, &cert.InvalidityDate); err != nil {
 			return nil, handleError(certs.ErrViewEntity, err)
 		}
 		revokedCerts = append(revokedCerts, cert)
@@ -196,6 +220,50 @@ func (repo certsRepo) ListRevokedCerts(ctx context.Context) ([]certs.Certificate
 	return revokedCerts, nil
 }
 
+func (repo certsRepo) ListRevocationChanges(ctx context.Context, since time.Time, issuerSerials ...string) ([]certs.Certificate, error) {
+	query := `
+        SELECT serial_number, entity_id, expiry_time, revoked, revocation_reason, revocation_time, invalidity_date
+        FROM certs
+        WHERE revocation_time >= $1 AND (revoked = true OR revocation_reason = $2)
+    `
+	args := []interface{}{since, certs.RevocationRemoveFromCRL}
+	if len(issuerSerials) > 0 {
+		query += ` AND issuer_serial = ANY($3)`
+		args = append(args, issuerSerials)
+	}
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, handleError(certs.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var changed []certs.Certificate
+	for rows.Next() {
+		var cert certs.Certificate
+		if err := rows.Scan(&cert.SerialNumber, &cert.EntityID, &cert.ExpiryTime, &cert.Revoked, &cert.RevocationReason, &cert.RevocationTime, &cert.InvalidityDate); err != nil {
+			return nil, handleError(certs.ErrViewEntity, err)
+		}
+		changed = append(changed, cert)
+	}
+
+	return changed, nil
+}
+
+func (repo certsRepo) NextCRLNumber(ctx context.Context, issuerSerial string) (uint64, error) {
+	q := `
+        INSERT INTO crl_numbers (issuer_serial, crl_number) VALUES ($1, 1)
+        ON CONFLICT (issuer_serial) DO UPDATE SET crl_number = crl_numbers.crl_number + 1
+        RETURNING crl_number
+    `
+
+	var number uint64
+	if err := repo.db.QueryRowxContext(ctx, q, issuerSerial).Scan(&number); err != nil {
+		return 0, handleError(certs.ErrUpdateEntity, err)
+	}
+
+	return number, nil
+}
+
 func (repo certsRepo) RemoveCert(ctx context.Context, backendId string) error {
 	q := `DELETE FROM certs WHERE entity_id = $1`
 
@@ -211,6 +279,61 @@ func (repo certsRepo) RemoveCert(ctx context.Context, backendId string) error {
 	return nil
 }
 
+func (repo certsRepo) RemoveCertBySerial(ctx context.Context, serialNumber string) error {
+	q := `DELETE FROM certs WHERE serial_number = $1`
+
+	result, err := repo.db.ExecContext(ctx, q, serialNumber)
+	if err != nil {
+		return errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return certs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo certsRepo) ListStaleKeys(ctx context.Context, keyVersion int, limit uint64) ([]certs.Certificate, error) {
+	q := `SELECT serial_number, key, key_version FROM certs WHERE key IS NOT NULL AND key <> '' AND key_version <> $1 LIMIT $2`
+
+	rows, err := repo.db.QueryContext(ctx, q, keyVersion, limit)
+	if err != nil {
+		return nil, handleError(certs.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var certificates []certs.Certificate
+	for rows.Next() {
+		var cert certs.Certificate
+		if err := rows.Scan(&cert.SerialNumber, &cert.Key, &cert.KeyVersion); err != nil {
+			return nil, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		certificates = append(certificates, cert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return certificates, nil
+}
+
+func (repo certsRepo) UpdateKey(ctx context.Context, cert certs.Certificate) error {
+	q := `UPDATE certs SET key = :key, key_version = :key_version WHERE serial_number = :serial_number`
+	res, err := repo.db.NamedExecContext(ctx, q, cert)
+	if err != nil {
+		return handleError(certs.ErrUpdateEntity, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	if count == 0 {
+		return certs.ErrNotFound
+	}
+	return nil
+}
+
 func (repo certsRepo) total(ctx context.Context, query string, params interface{}) (uint64, error) {
 	rows, err := repo.db.NamedQueryContext(ctx, query, params)
 	if err != nil {
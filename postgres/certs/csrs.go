@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+// CreateCSR stores a submitted CSR as a JSON document. The entity ID and the
+// status are kept in columns to filter the approval queue.
+func (repo certsRepo) CreateCSR(ctx context.Context, csr certs.CSR) error {
+	data, err := json.Marshal(csr)
+	if err != nil {
+		return errors.Wrap(certs.ErrCreateEntity, err)
+	}
+	q := `INSERT INTO csrs (id, entity_id, status, submitted_at, data) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := repo.db.ExecContext(ctx, q, csr.ID, csr.EntityID, csr.Status, csr.SubmittedAt, data); err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (repo certsRepo) RetrieveCSR(ctx context.Context, id string) (certs.CSR, error) {
+	q := `SELECT data FROM csrs WHERE id = $1`
+	var data []byte
+	if err := repo.db.QueryRowxContext(ctx, q, id).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return certs.CSR{}, errors.Wrap(certs.ErrNotFound, err)
+		}
+		return certs.CSR{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+	var csr certs.CSR
+	if err := json.Unmarshal(data, &csr); err != nil {
+		return certs.CSR{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return csr, nil
+}
+
+// UpdateCSR updates the CSR only if it still has the status, so that
+// concurrent status changes cannot both succeed.
+func (repo certsRepo) UpdateCSR(ctx context.Context, csr certs.CSR, status certs.CSRStatus) error {
+	data, err := json.Marshal(csr)
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	q := `UPDATE csrs SET status = $2, data = $3 WHERE id = $1 AND status = $4`
+	res, err := repo.db.ExecContext(ctx, q, csr.ID, csr.Status, data, status)
+	if err != nil {
+		return handleError(certs.ErrUpdateEntity, err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return certs.ErrCSRNotPending
+	}
+
+	return nil
+}
+
+func (repo certsRepo) ListCSRs(ctx context.Context, pm certs.PageMetadata) (certs.CSRPage, error) {
+	var conditions []string
+	if pm.EntityID != "" {
+		conditions = append(conditions, `entity_id = :entity_id`)
+	}
+	if pm.Status != "" {
+		conditions = append(conditions, `status = :status`)
+	}
+	var condition string
+	if len(conditions) > 0 {
+		condition = `WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	params := map[string]interface{}{
+		"limit":     pm.Limit,
+		"offset":    pm.Offset,
+		"entity_id": pm.EntityID,
+		"status":    pm.Status,
+	}
+
+	q := fmt.Sprintf(`SELECT data FROM csrs %s ORDER BY submitted_at DESC LIMIT :limit OFFSET :offset`, condition)
+	rows, err := repo.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return certs.CSRPage{}, handleError(certs.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var csrs []certs.CSR
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return certs.CSRPage{}, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		var csr certs.CSR
+		if err := json.Unmarshal(data, &csr); err != nil {
+			return certs.CSRPage{}, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		csrs = append(csrs, csr)
+	}
+	if err := rows.Err(); err != nil {
+		return certs.CSRPage{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	q = fmt.Sprintf(`SELECT COUNT(*) FROM csrs %s`, condition)
+	pm.Total, err = repo.total(ctx, q, params)
+	if err != nil {
+		return certs.CSRPage{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return certs.CSRPage{
+		PageMetadata: pm,
+		CSRs:         csrs,
+	}, nil
+}
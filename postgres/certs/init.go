@@ -26,6 +26,358 @@ func Migration() *migrate.MemoryMigrationSource {
 					"DROP TABLE certs",
 				},
 			},
+			{
+				Id: "certs_2",
+				Up: []string{
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS key_ref TEXT NOT NULL DEFAULT ''`,
+				},
+				Down: []string{
+					`ALTER TABLE certs DROP COLUMN IF EXISTS key_ref`,
+				},
+			},
+			{
+				Id: "certs_3",
+				Up: []string{
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS key_version INTEGER NOT NULL DEFAULT 0`,
+				},
+				Down: []string{
+					`ALTER TABLE certs DROP COLUMN IF EXISTS key_version`,
+				},
+			},
+			{
+				Id: "certs_4",
+				Up: []string{
+					`ALTER TABLE certs DROP CONSTRAINT IF EXISTS certs_type_check`,
+					`ALTER TABLE certs ADD CONSTRAINT certs_type_check CHECK (type IN ('RootCA', 'IntermediateCA', 'ClientCert', 'PendingIntermediateCA'))`,
+				},
+				Down: []string{
+					`DELETE FROM certs WHERE type = 'PendingIntermediateCA'`,
+					`ALTER TABLE certs DROP CONSTRAINT IF EXISTS certs_type_check`,
+					`ALTER TABLE certs ADD CONSTRAINT certs_type_check CHECK (type IN ('RootCA', 'IntermediateCA', 'ClientCert'))`,
+				},
+			},
+			{
+				Id: "certs_5",
+				Up: []string{
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS issuer_name TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS issuer_serial TEXT NOT NULL DEFAULT ''`,
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS retired BOOLEAN NOT NULL DEFAULT false`,
+					`UPDATE certs SET issuer_name = 'default' WHERE type = 'IntermediateCA'`,
+					`CREATE INDEX IF NOT EXISTS certs_issuer_serial_idx ON certs (issuer_serial)`,
+				},
+				Down: []string{
+					`DROP INDEX IF EXISTS certs_issuer_serial_idx`,
+					`ALTER TABLE certs DROP COLUMN IF EXISTS retired`,
+					`ALTER TABLE certs DROP COLUMN IF EXISTS issuer_serial`,
+					`ALTER TABLE certs DROP COLUMN IF EXISTS issuer_name`,
+				},
+			},
+			{
+				Id: "certs_6",
+				Up: []string{
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS staged BOOLEAN NOT NULL DEFAULT false`,
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS cross_certificate TEXT NOT NULL DEFAULT ''`,
+				},
+				Down: []string{
+					`ALTER TABLE certs DROP COLUMN IF EXISTS cross_certificate`,
+					`ALTER TABLE certs DROP COLUMN IF EXISTS staged`,
+				},
+			},
+			{
+				Id: "certs_7",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS profiles (
+						name    TEXT PRIMARY KEY,
+						profile JSONB NOT NULL
+					)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS profiles`,
+				},
+			},
+			{
+				Id: "certs_8",
+				Up: []string{
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS revocation_reason INTEGER NOT NULL DEFAULT 0`,
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS revocation_time TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'`,
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS invalidity_date TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'`,
+					// Revocation used to overwrite the expiry time with the revocation time.
+					`UPDATE certs SET revocation_time = expiry_time WHERE revoked = true`,
+				},
+				Down: []string{
+					`ALTER TABLE certs DROP COLUMN IF EXISTS invalidity_date`,
+					`ALTER TABLE certs DROP COLUMN IF EXISTS revocation_time`,
+					`ALTER TABLE certs DROP COLUMN IF EXISTS revocation_reason`,
+				},
+			},
+			{
+				Id: "certs_9",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS crl_numbers (
+						issuer_serial TEXT PRIMARY KEY,
+						crl_number    BIGINT NOT NULL
+					)`,
+					`CREATE INDEX IF NOT EXISTS certs_revocation_time_idx ON certs (revocation_time)`,
+				},
+				Down: []string{
+					`DROP INDEX IF EXISTS certs_revocation_time_idx`,
+					`DROP TABLE IF EXISTS crl_numbers`,
+				},
+			},
+			{
+				Id: "certs_10",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS acme_accounts (
+						id         VARCHAR(36) PRIMARY KEY,
+						thumbprint TEXT UNIQUE NOT NULL,
+						data       JSONB NOT NULL
+					)`,
+					`CREATE TABLE IF NOT EXISTS acme_orders (
+						id         VARCHAR(36) PRIMARY KEY,
+						account_id VARCHAR(36) NOT NULL REFERENCES acme_accounts (id) ON DELETE CASCADE,
+						data       JSONB NOT NULL
+					)`,
+					`CREATE TABLE IF NOT EXISTS acme_authorizations (
+						id       VARCHAR(36) PRIMARY KEY,
+						order_id VARCHAR(36) NOT NULL REFERENCES acme_orders (id) ON DELETE CASCADE,
+						data     JSONB NOT NULL
+					)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS acme_authorizations`,
+					`DROP TABLE IF EXISTS acme_orders`,
+					`DROP TABLE IF EXISTS acme_accounts`,
+				},
+			},
+			{
+				Id: "certs_11",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS scep_challenges (
+						hash       CHAR(64) PRIMARY KEY,
+						entity_id  VARCHAR(36) NOT NULL,
+						expires_at TIMESTAMPTZ NOT NULL
+					)`,
+					`CREATE TABLE IF NOT EXISTS scep_requests (
+						transaction_id TEXT PRIMARY KEY,
+						status         VARCHAR(16) NOT NULL,
+						data           JSONB NOT NULL
+					)`,
+					`CREATE INDEX IF NOT EXISTS scep_requests_status_idx ON scep_requests (status)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS scep_requests`,
+					`DROP TABLE IF EXISTS scep_challenges`,
+				},
+			},
+			{
+				Id: "certs_12",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS csrs (
+						id           VARCHAR(36) PRIMARY KEY,
+						entity_id    VARCHAR(36) NOT NULL,
+						status       VARCHAR(16) NOT NULL,
+						submitted_at TIMESTAMPTZ NOT NULL,
+						data         JSONB NOT NULL
+					)`,
+					`CREATE INDEX IF NOT EXISTS csrs_status_idx ON csrs (status)`,
+					`CREATE INDEX IF NOT EXISTS csrs_entity_id_idx ON csrs (entity_id)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS csrs`,
+				},
+			},
+			{
+				Id: "certs_13",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS redeemed_tokens (
+						id         VARCHAR(36) PRIMARY KEY,
+						expires_at TIMESTAMPTZ NOT NULL
+					)`,
+					`CREATE INDEX IF NOT EXISTS redeemed_tokens_expires_at_idx ON redeemed_tokens (expires_at)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS redeemed_tokens`,
+				},
+			},
+			{
+				Id: "certs_14",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS audit_events (
+						seq           BIGINT PRIMARY KEY,
+						time          TIMESTAMPTZ NOT NULL,
+						actor         TEXT NOT NULL,
+						role          VARCHAR(16) NOT NULL DEFAULT '',
+						action        VARCHAR(64) NOT NULL,
+						serial_number TEXT NOT NULL DEFAULT '',
+						entity_id     TEXT NOT NULL DEFAULT '',
+						params        JSONB,
+						result        VARCHAR(16) NOT NULL,
+						error         TEXT NOT NULL DEFAULT '',
+						prev_hash     CHAR(64) NOT NULL,
+						hash          CHAR(64) NOT NULL
+					)`,
+					`CREATE INDEX IF NOT EXISTS audit_events_time_idx ON audit_events (time)`,
+					`CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor)`,
+					`CREATE INDEX IF NOT EXISTS audit_events_serial_number_idx ON audit_events (serial_number)`,
+					`CREATE INDEX IF NOT EXISTS audit_events_entity_id_idx ON audit_events (entity_id)`,
+					// Events are only ever appended.
+					`CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+					BEGIN
+						RAISE EXCEPTION 'audit_events is append-only';
+					END;
+					$$ LANGUAGE plpgsql`,
+					`CREATE TRIGGER audit_events_no_update BEFORE UPDATE OR DELETE ON audit_events
+						FOR EACH ROW EXECUTE FUNCTION audit_events_append_only()`,
+					`CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+						FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only()`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS audit_events`,
+					`DROP FUNCTION IF EXISTS audit_events_append_only`,
+				},
+			},
+			{
+				Id: "certs_15",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+						id         VARCHAR(36) PRIMARY KEY,
+						url        TEXT NOT NULL,
+						events     TEXT NOT NULL DEFAULT '',
+						secret     TEXT NOT NULL,
+						created_at TIMESTAMPTZ NOT NULL
+					)`,
+					`CREATE TABLE IF NOT EXISTS webhook_events (
+						id                BIGSERIAL PRIMARY KEY,
+						type              VARCHAR(32) NOT NULL,
+						serial_number     VARCHAR(40) NOT NULL,
+						entity_id         TEXT NOT NULL DEFAULT '',
+						expiry_time       TIMESTAMP,
+						revocation_reason INTEGER NOT NULL DEFAULT 0,
+						created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+						dispatched        BOOLEAN NOT NULL DEFAULT false
+					)`,
+					`CREATE INDEX IF NOT EXISTS webhook_events_pending_idx ON webhook_events (id) WHERE NOT dispatched`,
+					`CREATE UNIQUE INDEX IF NOT EXISTS webhook_events_expiring_idx ON webhook_events (serial_number, expiry_time) WHERE type = 'cert.expiring'`,
+					`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+						id               BIGSERIAL PRIMARY KEY,
+						subscription_id  VARCHAR(36) NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+						event_id         BIGINT NOT NULL REFERENCES webhook_events (id),
+						status           VARCHAR(16) NOT NULL,
+						attempts         INTEGER NOT NULL DEFAULT 0,
+						next_attempt_at  TIMESTAMPTZ NOT NULL,
+						last_status_code INTEGER NOT NULL DEFAULT 0,
+						last_error       TEXT NOT NULL DEFAULT '',
+						created_at       TIMESTAMPTZ NOT NULL,
+						updated_at       TIMESTAMPTZ NOT NULL
+					)`,
+					`CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending'`,
+					`CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_id_idx ON webhook_deliveries (subscription_id)`,
+					// Client certificate changes are added to the outbox in the
+					// transaction making them, unless nobody subscribed.
+					`CREATE OR REPLACE FUNCTION webhook_events_enqueue() RETURNS TRIGGER AS $$
+					DECLARE
+						event_type TEXT;
+					BEGIN
+						IF NEW.type IS DISTINCT FROM 'ClientCert' OR NOT EXISTS (SELECT 1 FROM webhook_subscriptions) THEN
+							RETURN NULL;
+						END IF;
+						IF TG_OP = 'INSERT' THEN
+							event_type := 'cert.issued';
+						ELSIF COALESCE(NEW.revoked, false) AND NOT COALESCE(OLD.revoked, false) THEN
+							event_type := 'cert.revoked';
+						ELSIF NEW.certificate IS DISTINCT FROM OLD.certificate AND NOT COALESCE(NEW.revoked, false) THEN
+							event_type := 'cert.renewed';
+						ELSE
+							RETURN NULL;
+						END IF;
+						INSERT INTO webhook_events (type, serial_number, entity_id, expiry_time, revocation_reason)
+							VALUES (event_type, NEW.serial_number, COALESCE(NEW.entity_id, ''), NEW.expiry_time,
+								CASE WHEN event_type = 'cert.revoked' THEN NEW.revocation_reason ELSE 0 END);
+						RETURN NULL;
+					END;
+					$$ LANGUAGE plpgsql`,
+					`CREATE TRIGGER certs_webhook_events AFTER INSERT OR UPDATE ON certs
+						FOR EACH ROW EXECUTE FUNCTION webhook_events_enqueue()`,
+				},
+				Down: []string{
+					`DROP TRIGGER IF EXISTS certs_webhook_events ON certs`,
+					`DROP FUNCTION IF EXISTS webhook_events_enqueue`,
+					`DROP TABLE IF EXISTS webhook_deliveries`,
+					`DROP TABLE IF EXISTS webhook_events`,
+					`DROP TABLE IF EXISTS webhook_subscriptions`,
+				},
+			},
+			{
+				Id: "certs_16",
+				Up: []string{
+					// A hold is announced as cert.held and its release as
+					// cert.released. Revoking a held certificate only changes
+					// its revocation reason and is announced as cert.revoked.
+					`CREATE OR REPLACE FUNCTION webhook_events_enqueue() RETURNS TRIGGER AS $$
+					DECLARE
+						event_type TEXT;
+					BEGIN
+						IF NEW.type IS DISTINCT FROM 'ClientCert' OR NOT EXISTS (SELECT 1 FROM webhook_subscriptions) THEN
+							RETURN NULL;
+						END IF;
+						IF TG_OP = 'INSERT' THEN
+							event_type := 'cert.issued';
+						ELSIF COALESCE(NEW.revoked, false) THEN
+							IF COALESCE(OLD.revoked, false) AND NEW.revocation_reason IS NOT DISTINCT FROM OLD.revocation_reason THEN
+								RETURN NULL;
+							ELSIF NEW.revocation_reason = 6 THEN
+								event_type := 'cert.held';
+							ELSE
+								event_type := 'cert.revoked';
+							END IF;
+						ELSIF COALESCE(OLD.revoked, false) THEN
+							event_type := 'cert.released';
+						ELSIF NEW.certificate IS DISTINCT FROM OLD.certificate THEN
+							event_type := 'cert.renewed';
+						ELSE
+							RETURN NULL;
+						END IF;
+						INSERT INTO webhook_events (type, serial_number, entity_id, expiry_time, revocation_reason)
+							VALUES (event_type, NEW.serial_number, COALESCE(NEW.entity_id, ''), NEW.expiry_time,
+								CASE WHEN event_type IN ('cert.revoked', 'cert.held') THEN COALESCE(NEW.revocation_reason, 0) ELSE 0 END);
+						RETURN NULL;
+					END;
+					$$ LANGUAGE plpgsql`,
+				},
+				Down: []string{
+					`CREATE OR REPLACE FUNCTION webhook_events_enqueue() RETURNS TRIGGER AS $$
+					DECLARE
+						event_type TEXT;
+					BEGIN
+						IF NEW.type IS DISTINCT FROM 'ClientCert' OR NOT EXISTS (SELECT 1 FROM webhook_subscriptions) THEN
+							RETURN NULL;
+						END IF;
+						IF TG_OP = 'INSERT' THEN
+							event_type := 'cert.issued';
+						ELSIF COALESCE(NEW.revoked, false) AND NOT COALESCE(OLD.revoked, false) THEN
+							event_type := 'cert.revoked';
+						ELSIF NEW.certificate IS DISTINCT FROM OLD.certificate AND NOT COALESCE(NEW.revoked, false) THEN
+							event_type := 'cert.renewed';
+						ELSE
+							RETURN NULL;
+						END IF;
+						INSERT INTO webhook_events (type, serial_number, entity_id, expiry_time, revocation_reason)
+							VALUES (event_type, NEW.serial_number, COALESCE(NEW.entity_id, ''), NEW.expiry_time,
+								CASE WHEN event_type = 'cert.revoked' THEN NEW.revocation_reason ELSE 0 END);
+						RETURN NULL;
+					END;
+					$$ LANGUAGE plpgsql`,
+				},
+			},
+			{
+				Id: "certs_17",
+				Up: []string{
+					`ALTER TABLE certs ADD COLUMN IF NOT EXISTS profile TEXT NOT NULL DEFAULT ''`,
+				},
+				Down: []string{
+					`ALTER TABLE certs DROP COLUMN IF EXISTS profile`,
+				},
+			},
 		},
 	}
 }
\ No newline at end of file
@@ -0,0 +1,116 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v10"
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/internal/postgres"
+	cpostgres "github.com/hantdev/certs/postgres/certs"
+	"github.com/hantdev/certs/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// testDBPrefix prefixes the settings of the database the migration tests run
+// against. The tests are skipped unless its name is set.
+const testDBPrefix = "CERTS_TEST_DB_"
+
+func setupDB(t *testing.T) postgres.Database {
+	var cfg postgres.Config
+	require.NoError(t, env.ParseWithOptions(&cfg, env.Options{Prefix: testDBPrefix}))
+	if cfg.Name == "" {
+		t.Skipf("%sNAME is not set", testDBPrefix)
+	}
+	db, err := postgres.Setup(cfg, *cpostgres.Migration())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return postgres.NewDatabase(db, cfg, noop.NewTracerProvider().Tracer("certs-test"))
+}
+
+func TestWebhookEventsHoldReleaseRevoke(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.Background()
+	repo := cpostgres.NewRepository(db)
+	hooks := cpostgres.NewWebhooksRepository(db)
+
+	sub := webhooks.Subscription{ID: fmt.Sprintf("sub-%d", time.Now().UnixNano()), URL: "https://example.com/hook", Secret: "secret", CreatedAt: time.Now().UTC()}
+	require.NoError(t, hooks.CreateSubscription(ctx, sub))
+	t.Cleanup(func() { _ = hooks.RemoveSubscription(ctx, sub.ID) })
+
+	serial := fmt.Sprintf("%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		_, _ = db.ExecContext(ctx, `DELETE FROM webhook_events WHERE serial_number = $1`, serial)
+		_, _ = db.ExecContext(ctx, `DELETE FROM certs WHERE serial_number = $1`, serial)
+	})
+	cert := certs.Certificate{SerialNumber: serial, EntityID: "entity", Type: certs.ClientCert, ExpiryTime: time.Now().Add(time.Hour).UTC()}
+	require.NoError(t, repo.CreateCert(ctx, cert))
+
+	steps := []struct {
+		desc   string
+		update func(*certs.Certificate)
+	}{
+		{
+			desc: "hold",
+			update: func(c *certs.Certificate) {
+				c.Revoked = true
+				c.RevocationReason = certs.RevocationCertificateHold
+				c.RevocationTime = time.Now().UTC()
+			},
+		},
+		{
+			desc: "release",
+			update: func(c *certs.Certificate) {
+				c.Revoked = false
+				c.RevocationReason = certs.RevocationRemoveFromCRL
+			},
+		},
+		{
+			desc: "hold again",
+			update: func(c *certs.Certificate) {
+				c.Revoked = true
+				c.RevocationReason = certs.RevocationCertificateHold
+			},
+		},
+		{
+			desc:   "revoke held",
+			update: func(c *certs.Certificate) { c.RevocationReason = certs.RevocationKeyCompromise },
+		},
+		{
+			desc:   "unchanged",
+			update: func(c *certs.Certificate) {},
+		},
+	}
+	for _, step := range steps {
+		step.update(&cert)
+		require.NoError(t, repo.UpdateCert(ctx, cert), step.desc)
+	}
+
+	rows, err := db.QueryxContext(ctx, `SELECT type, revocation_reason FROM webhook_events WHERE serial_number = $1 ORDER BY id`, serial)
+	require.NoError(t, err)
+	defer rows.Close()
+	type event struct {
+		typ    webhooks.EventType
+		reason certs.RevocationReason
+	}
+	var events []event
+	for rows.Next() {
+		var e event
+		require.NoError(t, rows.Scan(&e.typ, &e.reason))
+		events = append(events, e)
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, []event{
+		{typ: webhooks.EventCertIssued},
+		{typ: webhooks.EventCertHeld, reason: certs.RevocationCertificateHold},
+		{typ: webhooks.EventCertReleased},
+		{typ: webhooks.EventCertHeld, reason: certs.RevocationCertificateHold},
+		{typ: webhooks.EventCertRevoked, reason: certs.RevocationKeyCompromise},
+	}, events)
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+// CreateProfile stores a certificate profile as a JSON document keyed by its name.
+func (repo certsRepo) CreateProfile(ctx context.Context, profile certs.Profile) error {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return errors.Wrap(certs.ErrCreateEntity, err)
+	}
+	q := `INSERT INTO profiles (name, profile) VALUES ($1, $2)`
+	if _, err := repo.db.ExecContext(ctx, q, profile.Name, data); err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (repo certsRepo) RetrieveProfile(ctx context.Context, name string) (certs.Profile, error) {
+	q := `SELECT profile FROM profiles WHERE name = $1`
+	var data []byte
+	if err := repo.db.QueryRowxContext(ctx, q, name).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return certs.Profile{}, errors.Wrap(certs.ErrNotFound, err)
+		}
+		return certs.Profile{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+	var profile certs.Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return certs.Profile{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return profile, nil
+}
+
+func (repo certsRepo) ListProfiles(ctx context.Context) ([]certs.Profile, error) {
+	q := `SELECT profile FROM profiles ORDER BY name`
+	rows, err := repo.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, handleError(certs.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var profiles []certs.Profile
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		var profile certs.Profile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			return nil, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		profiles = append(profiles, profile)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return profiles, nil
+}
+
+func (repo certsRepo) UpdateProfile(ctx context.Context, profile certs.Profile) error {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	q := `UPDATE profiles SET profile = $2 WHERE name = $1`
+	res, err := repo.db.ExecContext(ctx, q, profile.Name, data)
+	if err != nil {
+		return handleError(certs.ErrUpdateEntity, err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return certs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo certsRepo) RemoveProfile(ctx context.Context, name string) error {
+	q := `DELETE FROM profiles WHERE name = $1`
+	res, err := repo.db.ExecContext(ctx, q, name)
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return certs.ErrNotFound
+	}
+
+	return nil
+}
\ No newline at end of file
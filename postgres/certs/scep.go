@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/postgres"
+	"github.com/hantdev/certs/scep"
+)
+
+var _ scep.Repository = (*scepRepo)(nil)
+
+// scepRepo stores hashed SCEP challenges and enrollment requests as JSON documents.
+type scepRepo struct {
+	db postgres.Database
+}
+
+// NewSCEPRepository returns the SCEP repository on the certs database.
+func NewSCEPRepository(db postgres.Database) scep.Repository {
+	return scepRepo{
+		db: db,
+	}
+}
+
+func (repo scepRepo) CreateChallenge(ctx context.Context, challenge scep.Challenge) error {
+	q := `INSERT INTO scep_challenges (hash, entity_id, expires_at) VALUES ($1, $2, $3)`
+	if _, err := repo.db.ExecContext(ctx, q, challenge.Hash, challenge.EntityID, challenge.ExpiresAt); err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+// ConsumeChallenge deletes the challenge as it is retrieved, so that it
+// cannot be used twice.
+func (repo scepRepo) ConsumeChallenge(ctx context.Context, hash string) (scep.Challenge, error) {
+	q := `DELETE FROM scep_challenges WHERE hash = $1 RETURNING hash, entity_id, expires_at`
+	var challenge scep.Challenge
+	if err := repo.db.QueryRowxContext(ctx, q, hash).Scan(&challenge.Hash, &challenge.EntityID, &challenge.ExpiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return scep.Challenge{}, errors.Wrap(scep.ErrInvalidChallenge, err)
+		}
+		return scep.Challenge{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+	if challenge.ExpiresAt.Before(time.Now()) {
+		return scep.Challenge{}, scep.ErrInvalidChallenge
+	}
+
+	return challenge, nil
+}
+
+func (repo scepRepo) CreateRequest(ctx context.Context, req scep.Request) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrap(certs.ErrCreateEntity, err)
+	}
+	q := `INSERT INTO scep_requests (transaction_id, status, data) VALUES ($1, $2, $3)`
+	if _, err := repo.db.ExecContext(ctx, q, req.TransactionID, req.Status, data); err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (repo scepRepo) RetrieveRequest(ctx context.Context, transactionID string) (scep.Request, error) {
+	var data []byte
+	q := `SELECT data FROM scep_requests WHERE transaction_id = $1`
+	if err := repo.db.QueryRowxContext(ctx, q, transactionID).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return scep.Request{}, errors.Wrap(scep.ErrNotFound, err)
+		}
+		return scep.Request{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+	var req scep.Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return scep.Request{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return req, nil
+}
+
+// UpdateRequest updates the request only if it still has the status, so that
+// concurrent status changes cannot both succeed.
+func (repo scepRepo) UpdateRequest(ctx context.Context, req scep.Request, status scep.Status) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	q := `UPDATE scep_requests SET status = $2, data = $3 WHERE transaction_id = $1 AND status = $4`
+	res, err := repo.db.ExecContext(ctx, q, req.TransactionID, req.Status, data, status)
+	if err != nil {
+		return handleError(certs.ErrUpdateEntity, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	if count == 0 {
+		return scep.ErrNotPending
+	}
+
+	return nil
+}
+
+func (repo scepRepo) ListRequests(ctx context.Context, status scep.Status) ([]scep.Request, error) {
+	q := `SELECT data FROM scep_requests WHERE $1 = '' OR status = $1 ORDER BY data->>'created_at'`
+	rows, err := repo.db.QueryxContext(ctx, q, status)
+	if err != nil {
+		return nil, handleError(certs.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var reqs []scep.Request
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		var req scep.Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		reqs = append(reqs, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return reqs, nil
+}
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+// RedeemToken records the redemption of a download token. Redemptions of
+// expired tokens are pruned, as the tokens are rejected before they are
+// looked up.
+func (repo certsRepo) RedeemToken(ctx context.Context, id string, expiresAt time.Time) error {
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM redeemed_tokens WHERE expires_at < $1`, time.Now()); err != nil {
+		return errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+	q := `INSERT INTO redeemed_tokens (id, expires_at) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`
+	res, err := repo.db.ExecContext(ctx, q, id, expiresAt)
+	if err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return certs.ErrConflict
+	}
+
+	return nil
+}
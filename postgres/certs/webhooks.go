@@ -0,0 +1,293 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/postgres"
+	"github.com/hantdev/certs/webhooks"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ webhooks.Repository = (*webhooksRepo)(nil)
+
+// webhooksRepo stores webhook subscriptions, the webhook_events outbox and
+// the deliveries of its events. Issuance, renewal, revocation, hold and
+// release events are added to the outbox by a trigger on the certs table,
+// within the transaction changing the certificate.
+type webhooksRepo struct {
+	db postgres.Database
+}
+
+// NewWebhooksRepository returns the webhooks repository on the certs database.
+func NewWebhooksRepository(db postgres.Database) webhooks.Repository {
+	return webhooksRepo{
+		db: db,
+	}
+}
+
+const (
+	deliveryColumns = `d.id, d.subscription_id, d.status, d.attempts, d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.updated_at,
+		e.id, e.type, e.serial_number, e.entity_id, e.expiry_time, e.revocation_reason, e.created_at, s.url, s.secret`
+	deliveryTables = `webhook_deliveries d
+		JOIN webhook_events e ON e.id = d.event_id
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id`
+)
+
+func (repo webhooksRepo) CreateSubscription(ctx context.Context, sub webhooks.Subscription) error {
+	q := `INSERT INTO webhook_subscriptions (id, url, events, secret, created_at) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := repo.db.ExecContext(ctx, q, sub.ID, sub.URL, joinEvents(sub.Events), sub.Secret, sub.CreatedAt); err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (repo webhooksRepo) RetrieveSubscription(ctx context.Context, id string) (webhooks.Subscription, error) {
+	q := `SELECT id, url, events, secret, created_at FROM webhook_subscriptions WHERE id = $1`
+	sub, err := scanSubscription(repo.db.QueryRowxContext(ctx, q, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return webhooks.Subscription{}, errors.Wrap(certs.ErrNotFound, webhooks.ErrNotFound)
+		}
+		return webhooks.Subscription{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return sub, nil
+}
+
+func (repo webhooksRepo) ListSubscriptions(ctx context.Context, offset, limit uint64) (webhooks.SubscriptionsPage, error) {
+	q := `SELECT id, url, events, secret, created_at FROM webhook_subscriptions ORDER BY created_at, id LIMIT $1 OFFSET $2`
+	rows, err := repo.db.QueryxContext(ctx, q, limit, offset)
+	if err != nil {
+		return webhooks.SubscriptionsPage{}, handleError(certs.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	subs := []webhooks.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return webhooks.SubscriptionsPage{}, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return webhooks.SubscriptionsPage{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	var total uint64
+	if err := repo.db.QueryRowxContext(ctx, `SELECT COUNT(*) FROM webhook_subscriptions`).Scan(&total); err != nil {
+		return webhooks.SubscriptionsPage{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return webhooks.SubscriptionsPage{
+		Total:         total,
+		Offset:        offset,
+		Limit:         limit,
+		Subscriptions: subs,
+	}, nil
+}
+
+// RemoveSubscription removes the deliveries of the subscription with it.
+func (repo webhooksRepo) RemoveSubscription(ctx context.Context, id string) error {
+	res, err := repo.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return handleError(certs.ErrUpdateEntity, err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.Wrap(certs.ErrNotFound, webhooks.ErrNotFound)
+	}
+
+	return nil
+}
+
+// AddExpiring relies on the unique index of expiring events on the serial
+// number and expiry time, so that a certificate is announced once, and once
+// again after it is renewed.
+func (repo webhooksRepo) AddExpiring(ctx context.Context, now, before time.Time) error {
+	q := `INSERT INTO webhook_events (type, serial_number, entity_id, expiry_time, created_at)
+		SELECT $1::text, serial_number, COALESCE(entity_id, ''), expiry_time, $2::timestamptz FROM certs
+		WHERE type = $3 AND NOT COALESCE(revoked, false) AND expiry_time > $4 AND expiry_time <= $5
+			AND EXISTS (SELECT 1 FROM webhook_subscriptions)
+		ON CONFLICT (serial_number, expiry_time) WHERE type = 'cert.expiring' DO NOTHING`
+	if _, err := repo.db.ExecContext(ctx, q, string(webhooks.EventCertExpiring), now, certs.Client, now, before); err != nil {
+		return handleError(certs.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+// Dispatch marks the events as dispatched and creates their deliveries in
+// a single statement, so that no event is dispatched twice or lost.
+func (repo webhooksRepo) Dispatch(ctx context.Context, now time.Time, limit uint64) (uint64, error) {
+	q := `WITH taken AS (
+			UPDATE webhook_events SET dispatched = true
+			WHERE id IN (SELECT id FROM webhook_events WHERE NOT dispatched ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED)
+			RETURNING id, type
+		), created AS (
+			INSERT INTO webhook_deliveries (subscription_id, event_id, status, attempts, next_attempt_at, created_at, updated_at)
+			SELECT s.id, t.id, $2::text, 0, $3::timestamptz, $3::timestamptz, $3::timestamptz FROM taken t
+			JOIN webhook_subscriptions s ON s.events = '' OR t.type = ANY(string_to_array(s.events, ','))
+		)
+		SELECT COUNT(*) FROM taken`
+	var n uint64
+	if err := repo.db.QueryRowxContext(ctx, q, limit, string(webhooks.StatusPending), now).Scan(&n); err != nil {
+		return 0, handleError(certs.ErrCreateEntity, err)
+	}
+
+	return n, nil
+}
+
+func (repo webhooksRepo) ClaimDeliveries(ctx context.Context, now, until time.Time, limit uint64) ([]webhooks.Delivery, error) {
+	q := fmt.Sprintf(`UPDATE webhook_deliveries d SET next_attempt_at = $1
+		FROM webhook_events e, webhook_subscriptions s
+		WHERE d.id IN (
+			SELECT id FROM webhook_deliveries WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at, id LIMIT $4 FOR UPDATE SKIP LOCKED
+		) AND e.id = d.event_id AND s.id = d.subscription_id
+		RETURNING %s`, deliveryColumns)
+	rows, err := repo.db.QueryxContext(ctx, q, until, string(webhooks.StatusPending), now, limit)
+	if err != nil {
+		return nil, handleError(certs.ErrUpdateEntity, err)
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
+
+func (repo webhooksRepo) RetrieveDelivery(ctx context.Context, id uint64) (webhooks.Delivery, error) {
+	q := fmt.Sprintf(`SELECT %s FROM %s WHERE d.id = $1`, deliveryColumns, deliveryTables)
+	d, err := scanDelivery(repo.db.QueryRowxContext(ctx, q, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return webhooks.Delivery{}, errors.Wrap(certs.ErrNotFound, webhooks.ErrNotFound)
+		}
+		return webhooks.Delivery{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return d, nil
+}
+
+func (repo webhooksRepo) UpdateDelivery(ctx context.Context, d webhooks.Delivery) error {
+	q := `UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, updated_at = $7
+		WHERE id = $1`
+	res, err := repo.db.ExecContext(ctx, q, d.ID, string(d.Status), d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.UpdatedAt)
+	if err != nil {
+		return handleError(certs.ErrUpdateEntity, err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.Wrap(certs.ErrNotFound, webhooks.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (repo webhooksRepo) ListDeliveries(ctx context.Context, filter webhooks.DeliveryFilter) (webhooks.DeliveriesPage, error) {
+	condition := `WHERE (:subscription_id = '' OR d.subscription_id = :subscription_id) AND (:status = '' OR d.status = :status)`
+	params := map[string]interface{}{
+		"subscription_id": filter.SubscriptionID,
+		"status":          string(filter.Status),
+		"limit":           filter.Limit,
+		"offset":          filter.Offset,
+	}
+
+	q := fmt.Sprintf(`SELECT %s FROM %s %s ORDER BY d.id DESC LIMIT :limit OFFSET :offset`, deliveryColumns, deliveryTables, condition)
+	rows, err := repo.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return webhooks.DeliveriesPage{}, handleError(certs.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return webhooks.DeliveriesPage{}, err
+	}
+	if deliveries == nil {
+		deliveries = []webhooks.Delivery{}
+	}
+
+	q = fmt.Sprintf(`SELECT COUNT(*) FROM webhook_deliveries d %s`, condition)
+	filter.Total, err = certsRepo{db: repo.db}.total(ctx, q, params)
+	if err != nil {
+		return webhooks.DeliveriesPage{}, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return webhooks.DeliveriesPage{
+		DeliveryFilter: filter,
+		Deliveries:     deliveries,
+	}, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row scanner) (webhooks.Subscription, error) {
+	var sub webhooks.Subscription
+	var events string
+	if err := row.Scan(&sub.ID, &sub.URL, &events, &sub.Secret, &sub.CreatedAt); err != nil {
+		return webhooks.Subscription{}, err
+	}
+	if events != "" {
+		for _, typ := range strings.Split(events, ",") {
+			sub.Events = append(sub.Events, webhooks.EventType(typ))
+		}
+	}
+	sub.CreatedAt = sub.CreatedAt.UTC()
+
+	return sub, nil
+}
+
+func scanDelivery(row scanner) (webhooks.Delivery, error) {
+	var d webhooks.Delivery
+	var status, typ string
+	var expiry sql.NullTime
+	var reason int
+	if err := row.Scan(&d.ID, &d.SubscriptionID, &status, &d.Attempts, &d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt,
+		&d.Event.ID, &typ, &d.Event.SerialNumber, &d.Event.EntityID, &expiry, &reason, &d.Event.Time, &d.URL, &d.Secret); err != nil {
+		return webhooks.Delivery{}, err
+	}
+	d.Status = webhooks.Status(status)
+	d.Event.Type = webhooks.EventType(typ)
+	if d.Event.Type == webhooks.EventCertRevoked || d.Event.Type == webhooks.EventCertHeld {
+		d.Event.RevocationReason = certs.RevocationReason(reason).String()
+	}
+	d.Event.ExpiryTime = expiry.Time
+	d.Event.Time = d.Event.Time.UTC()
+	d.NextAttemptAt = d.NextAttemptAt.UTC()
+	d.CreatedAt = d.CreatedAt.UTC()
+	d.UpdatedAt = d.UpdatedAt.UTC()
+
+	return d, nil
+}
+
+func scanDeliveries(rows *sqlx.Rows) ([]webhooks.Delivery, error) {
+	var deliveries []webhooks.Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, errors.Wrap(certs.ErrViewEntity, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(certs.ErrViewEntity, err)
+	}
+
+	return deliveries, nil
+}
+
+func joinEvents(events []webhooks.EventType) string {
+	types := make([]string, len(events))
+	for i, typ := range events {
+		types[i] = string(typ)
+	}
+
+	return strings.Join(types, ",")
+}
@@ -0,0 +1,329 @@
+package certs
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"sort"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// DefaultProfile is the profile used when a request does not name one. It
+// keeps the key usages and extended key usages certificates had before
+// profiles were introduced.
+const DefaultProfile = "default"
+
+// Subject alternative name types a profile may allow.
+const (
+	SANDNS   = "dns"
+	SANIP    = "ip"
+	SANEmail = "email"
+)
+
+var (
+	ErrProfileNotFound  = errors.New("profile not found")
+	ErrProfileReadOnly  = errors.New("profile is read-only")
+	ErrProfileViolation = errors.New("request violates the certificate profile")
+)
+
+var keyUsages = map[string]x509.KeyUsage{
+	"digital_signature":  x509.KeyUsageDigitalSignature,
+	"content_commitment": x509.KeyUsageContentCommitment,
+	"key_encipherment":   x509.KeyUsageKeyEncipherment,
+	"data_encipherment":  x509.KeyUsageDataEncipherment,
+	"key_agreement":      x509.KeyUsageKeyAgreement,
+}
+
+var extKeyUsages = map[string]x509.ExtKeyUsage{
+	"any":              x509.ExtKeyUsageAny,
+	"server_auth":      x509.ExtKeyUsageServerAuth,
+	"client_auth":      x509.ExtKeyUsageClientAuth,
+	"code_signing":     x509.ExtKeyUsageCodeSigning,
+	"email_protection": x509.ExtKeyUsageEmailProtection,
+	"time_stamping":    x509.ExtKeyUsageTimeStamping,
+	"ocsp_signing":     x509.ExtKeyUsageOCSPSigning,
+}
+
+// builtinProfiles returns the profiles that are always available. A profile
+// from the configuration with the same name replaces the built-in one.
+func builtinProfiles() []Profile {
+	return []Profile{
+		{
+			Name:         DefaultProfile,
+			KeyUsages:    []string{"digital_signature", "key_encipherment"},
+			ExtKeyUsages: []string{"client_auth", "server_auth"},
+			TTL:          certValidityPeriod.String(),
+			MaxTTL:       "8760h",
+			AllowedSANs:  []string{SANDNS, SANIP, SANEmail},
+		},
+		{
+			Name:         "server",
+			KeyUsages:    []string{"digital_signature", "key_encipherment"},
+			ExtKeyUsages: []string{"server_auth"},
+			TTL:          certValidityPeriod.String(),
+			MaxTTL:       "8760h",
+			AllowedSANs:  []string{SANDNS, SANIP},
+		},
+		{
+			Name:         "client",
+			KeyUsages:    []string{"digital_signature", "key_encipherment"},
+			ExtKeyUsages: []string{"client_auth"},
+			TTL:          certValidityPeriod.String(),
+			MaxTTL:       "8760h",
+			AllowedSANs:  []string{SANDNS, SANIP, SANEmail},
+		},
+		{
+			Name:         "code-signing",
+			KeyUsages:    []string{"digital_signature"},
+			ExtKeyUsages: []string{"code_signing"},
+			TTL:          "8760h",
+			MaxTTL:       "26280h",
+		},
+		{
+			Name:         "smime",
+			KeyUsages:    []string{"digital_signature", "key_encipherment"},
+			ExtKeyUsages: []string{"email_protection"},
+			TTL:          "8760h",
+			MaxTTL:       "17520h",
+			AllowedSANs:  []string{SANEmail},
+		},
+	}
+}
+
+// CreateProfile creates a new certificate profile. Names of the built-in and
+// configured profiles cannot be reused.
+func (s *service) CreateProfile(ctx context.Context, profile Profile) (Profile, error) {
+	if err := profile.validate(); err != nil {
+		return Profile{}, errors.Wrap(ErrMalformedEntity, err)
+	}
+	if _, ok := s.profiles[profile.Name]; ok {
+		return Profile{}, errors.Wrap(ErrConflict, errors.New("profile already exists"))
+	}
+	profile.ReadOnly = false
+	if err := s.repo.CreateProfile(ctx, profile); err != nil {
+		if errors.Contains(err, ErrConflict) {
+			return Profile{}, errors.Wrap(ErrConflict, err)
+		}
+		return Profile{}, errors.Wrap(ErrCreateEntity, err)
+	}
+
+	return profile, nil
+}
+
+// ViewProfile retrieves the certificate profile with the given name.
+func (s *service) ViewProfile(ctx context.Context, name string) (Profile, error) {
+	return s.profile(ctx, name)
+}
+
+// ListProfiles lists the built-in, configured and created profiles sorted by name.
+func (s *service) ListProfiles(ctx context.Context) ([]Profile, error) {
+	profiles, err := s.repo.ListProfiles(ctx)
+	if err != nil {
+		return nil, errors.Wrap(ErrViewEntity, err)
+	}
+	for _, p := range s.profiles {
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+
+	return profiles, nil
+}
+
+// UpdateProfile replaces the definition of a created profile.
+func (s *service) UpdateProfile(ctx context.Context, profile Profile) (Profile, error) {
+	if _, ok := s.profiles[profile.Name]; ok {
+		return Profile{}, ErrProfileReadOnly
+	}
+	if err := profile.validate(); err != nil {
+		return Profile{}, errors.Wrap(ErrMalformedEntity, err)
+	}
+	profile.ReadOnly = false
+	if err := s.repo.UpdateProfile(ctx, profile); err != nil {
+		if errors.Contains(err, ErrNotFound) {
+			return Profile{}, ErrProfileNotFound
+		}
+		return Profile{}, errors.Wrap(ErrUpdateEntity, err)
+	}
+
+	return profile, nil
+}
+
+// RemoveProfile deletes a created profile.
+func (s *service) RemoveProfile(ctx context.Context, name string) error {
+	if _, ok := s.profiles[name]; ok {
+		return ErrProfileReadOnly
+	}
+	if err := s.repo.RemoveProfile(ctx, name); err != nil {
+		if errors.Contains(err, ErrNotFound) {
+			return ErrProfileNotFound
+		}
+		return errors.Wrap(ErrUpdateEntity, err)
+	}
+
+	return nil
+}
+
+// profile returns the profile with the given name. An empty name selects the
+// default profile.
+func (s *service) profile(ctx context.Context, name string) (Profile, error) {
+	if name == "" {
+		name = DefaultProfile
+	}
+	if p, ok := s.profiles[name]; ok {
+		return p, nil
+	}
+	p, err := s.repo.RetrieveProfile(ctx, name)
+	if err != nil {
+		if errors.Contains(err, ErrNotFound) {
+			return Profile{}, ErrProfileNotFound
+		}
+		return Profile{}, errors.Wrap(ErrViewEntity, err)
+	}
+
+	return p, nil
+}
+
+// staticProfiles returns the built-in profiles overridden by the configured ones.
+func staticProfiles(config Config) map[string]Profile {
+	profiles := make(map[string]Profile)
+	for _, p := range append(builtinProfiles(), config.Profiles...) {
+		p.ReadOnly = true
+		profiles[p.Name] = p
+	}
+
+	return profiles
+}
+
+func (p Profile) validate() error {
+	if p.Name == "" {
+		return errors.New("profile name must not be empty")
+	}
+	if len(p.KeyUsages) == 0 {
+		return errors.New("key_usages must not be empty")
+	}
+	for _, ku := range p.KeyUsages {
+		if _, ok := keyUsages[ku]; !ok {
+			return errors.New("unsupported key usage " + ku)
+		}
+	}
+	for _, eku := range p.ExtKeyUsages {
+		if _, ok := extKeyUsages[eku]; !ok {
+			return errors.New("unsupported extended key usage " + eku)
+		}
+	}
+	for _, san := range p.AllowedSANs {
+		switch san {
+		case SANDNS, SANIP, SANEmail:
+		default:
+			return errors.New("unsupported SAN type " + san)
+		}
+	}
+	ttl, err := parseDuration("ttl", p.TTL)
+	if err != nil {
+		return err
+	}
+	maxTTL, err := parseDuration("max_ttl", p.MaxTTL)
+	if err != nil {
+		return err
+	}
+	if ttl < 0 || maxTTL < 0 {
+		return errors.New("ttl and max_ttl must not be negative")
+	}
+	if maxTTL > 0 && ttl > maxTTL {
+		return errors.New("ttl must not exceed max_ttl")
+	}
+
+	return nil
+}
+
+// validity returns the validity period of a certificate issued with the
+// profile for the requested TTL.
+func (p Profile) validity(ttl string) (time.Duration, error) {
+	if ttl == "" {
+		ttl = p.TTL
+	}
+	if ttl == "" {
+		return certValidityPeriod, nil
+	}
+	validity, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, errors.Wrap(ErrMalformedEntity, err)
+	}
+	if validity <= 0 {
+		return 0, errors.Wrap(ErrMalformedEntity, errors.New("ttl must be positive"))
+	}
+	if p.MaxTTL != "" {
+		maxTTL, err := time.ParseDuration(p.MaxTTL)
+		if err != nil {
+			return 0, errors.Wrap(ErrMalformedEntity, err)
+		}
+		if validity > maxTTL {
+			return 0, policyError(ErrProfileViolation, RuleProfileMaxTTL, "ttl", validity.String(), "ttl exceeds the max_ttl "+p.MaxTTL+" of profile "+p.Name)
+		}
+	}
+
+	return validity, nil
+}
+
+// keyUsage returns the key usage of the profile. Key encipherment only
+// applies to RSA keys and is dropped for the others.
+func (p Profile) keyUsage(pubKey crypto.PublicKey) x509.KeyUsage {
+	var usage x509.KeyUsage
+	for _, ku := range p.KeyUsages {
+		usage |= keyUsages[ku]
+	}
+	if usage&x509.KeyUsageKeyEncipherment != 0 {
+		usage = keyUsage(pubKey, usage&^x509.KeyUsageKeyEncipherment)
+	}
+
+	return usage
+}
+
+func (p Profile) extKeyUsage() []x509.ExtKeyUsage {
+	var usages []x509.ExtKeyUsage
+	for _, eku := range p.ExtKeyUsages {
+		usages = append(usages, extKeyUsages[eku])
+	}
+
+	return usages
+}
+
+// checkSANs rejects the subject alternative names of types the profile does not allow.
+func (p Profile) checkSANs(template *x509.Certificate) error {
+	allowed := make(map[string]bool, len(p.AllowedSANs))
+	for _, san := range p.AllowedSANs {
+		allowed[san] = true
+	}
+	switch {
+	case len(template.DNSNames) > 0 && !allowed[SANDNS]:
+		return policyError(ErrProfileViolation, RuleProfileAllowedSANs, "dns_names", template.DNSNames[0], "DNS names are not allowed by profile "+p.Name)
+	case len(template.IPAddresses) > 0 && !allowed[SANIP]:
+		return policyError(ErrProfileViolation, RuleProfileAllowedSANs, "ip_addresses", template.IPAddresses[0].String(), "IP addresses are not allowed by profile "+p.Name)
+	case len(template.EmailAddresses) > 0 && !allowed[SANEmail]:
+		return policyError(ErrProfileViolation, RuleProfileAllowedSANs, "email_addresses", template.EmailAddresses[0], "email addresses are not allowed by profile "+p.Name)
+	}
+
+	return nil
+}
+
+// applySubject fills the subject fields left empty by the request with the
+// profile defaults.
+func (p Profile) applySubject(subject *pkix.Name) {
+	fill := func(field *[]string, def []string) {
+		if len(*field) == 0 {
+			*field = def
+		}
+	}
+	fill(&subject.Organization, p.Subject.Organization)
+	fill(&subject.OrganizationalUnit, p.Subject.OrganizationalUnit)
+	fill(&subject.Country, p.Subject.Country)
+	fill(&subject.Province, p.Subject.Province)
+	fill(&subject.Locality, p.Subject.Locality)
+	fill(&subject.StreetAddress, p.Subject.StreetAddress)
+	fill(&subject.PostalCode, p.Subject.PostalCode)
+}
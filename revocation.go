@@ -0,0 +1,148 @@
+package certs
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// RevocationReason is the CRL reason code of RFC 5280 a certificate is revoked with.
+type RevocationReason int
+
+// Revocation reasons as defined in RFC 5280 section 5.3.1. The value 7 is not used.
+const (
+	RevocationUnspecified          RevocationReason = 0
+	RevocationKeyCompromise        RevocationReason = 1
+	RevocationCACompromise         RevocationReason = 2
+	RevocationAffiliationChanged   RevocationReason = 3
+	RevocationSuperseded           RevocationReason = 4
+	RevocationCessationOfOperation RevocationReason = 5
+	RevocationCertificateHold      RevocationReason = 6
+	RevocationRemoveFromCRL        RevocationReason = 8
+	RevocationPrivilegeWithdrawn   RevocationReason = 9
+	RevocationAACompromise         RevocationReason = 10
+)
+
+var (
+	ErrInvalidRevocationReason = errors.New("invalid revocation reason")
+	ErrCertAlreadyRevoked      = errors.New("certificate is already revoked")
+	ErrCertNotOnHold           = errors.New("certificate is not on hold")
+)
+
+// oidInvalidityDate is the CRL entry extension holding the invalidity date.
+var oidInvalidityDate = asn1.ObjectIdentifier{2, 5, 29, 24}
+
+var revocationReasons = map[RevocationReason]string{
+	RevocationUnspecified:          "unspecified",
+	RevocationKeyCompromise:        "key_compromise",
+	RevocationCACompromise:         "ca_compromise",
+	RevocationAffiliationChanged:   "affiliation_changed",
+	RevocationSuperseded:           "superseded",
+	RevocationCessationOfOperation: "cessation_of_operation",
+	RevocationCertificateHold:      "certificate_hold",
+	RevocationRemoveFromCRL:        "remove_from_crl",
+	RevocationPrivilegeWithdrawn:   "privilege_withdrawn",
+	RevocationAACompromise:         "aa_compromise",
+}
+
+// String returns the name of the revocation reason.
+func (r RevocationReason) String() string {
+	if name, ok := revocationReasons[r]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// ParseRevocationReason returns the revocation reason with the given name.
+// An empty name is the unspecified reason.
+func ParseRevocationReason(name string) (RevocationReason, error) {
+	if name == "" {
+		return RevocationUnspecified, nil
+	}
+	for reason, n := range revocationReasons {
+		if n == name {
+			return reason, nil
+		}
+	}
+
+	return 0, errors.Wrap(ErrInvalidRevocationReason, errors.New(name))
+}
+
+// HoldCert suspends a certificate. It is revoked with the certificateHold
+// reason until it is released or revoked permanently.
+func (s *service) HoldCert(ctx context.Context, serialNumber string) error {
+	cert, err := s.repo.RetrieveCert(ctx, serialNumber)
+	if err != nil {
+		return errors.Wrap(ErrViewEntity, err)
+	}
+	if cert.Revoked {
+		if cert.RevocationReason == RevocationCertificateHold {
+			return nil
+		}
+		return ErrCertAlreadyRevoked
+	}
+	cert.Revoked = true
+	cert.RevocationReason = RevocationCertificateHold
+	cert.RevocationTime = time.Now()
+	cert.InvalidityDate = time.Time{}
+	if err := s.repo.UpdateCert(ctx, cert); err != nil {
+		return errors.Wrap(ErrUpdateEntity, err)
+	}
+	s.invalidateCRLs(cert)
+	s.invalidateOCSPResponses(cert)
+
+	return nil
+}
+
+// ReleaseCert releases a certificate from hold. The certificate is no longer
+// revoked and keeps the removeFromCRL reason with the release time, so that
+// CRLs issued after the release can report its removal.
+func (s *service) ReleaseCert(ctx context.Context, serialNumber string) error {
+	cert, err := s.repo.RetrieveCert(ctx, serialNumber)
+	if err != nil {
+		return errors.Wrap(ErrViewEntity, err)
+	}
+	if !cert.Revoked || cert.RevocationReason != RevocationCertificateHold {
+		return ErrCertNotOnHold
+	}
+	cert.Revoked = false
+	cert.RevocationReason = RevocationRemoveFromCRL
+	cert.RevocationTime = time.Now()
+	if err := s.repo.UpdateCert(ctx, cert); err != nil {
+		return errors.Wrap(ErrUpdateEntity, err)
+	}
+	s.invalidateCRLs(cert)
+	s.invalidateOCSPResponses(cert)
+
+	return nil
+}
+
+// validateRevocation checks the reason and the invalidity date a certificate is revoked with.
+func validateRevocation(reason RevocationReason, invalidityDate time.Time) error {
+	switch reason {
+	case RevocationCertificateHold, RevocationRemoveFromCRL:
+		return errors.Wrap(ErrInvalidRevocationReason, errors.New(reason.String()+" cannot be used to revoke a certificate"))
+	}
+	if _, ok := revocationReasons[reason]; !ok {
+		return ErrInvalidRevocationReason
+	}
+	if invalidityDate.After(time.Now()) {
+		return errors.New("invalidity date must not be in the future")
+	}
+
+	return nil
+}
+
+// invalidityDateExtension returns the CRL entry extension holding the invalidity date.
+func invalidityDateExtension(invalidityDate time.Time) (pkix.Extension, error) {
+	value, err := asn1.MarshalWithParams(invalidityDate.UTC(), "generalized")
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: oidInvalidityDate, Value: value}, nil
+}
@@ -0,0 +1,259 @@
+package certs
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"sort"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// shouldStage reports whether the next root CA should be generated ahead of
+// the rotation i.e., within the stage threshold of the active root expiring.
+func (s *service) shouldStage() bool {
+	if s.rootCA == nil || s.rootCA.Signer == nil || s.stagedRoot() != nil {
+		return false
+	}
+
+	return time.Now().Add(s.config.Root.StageThreshold).After(s.rootCA.Certificate.NotAfter)
+}
+
+// stageRootCA generates the root CA that replaces the active one on rotation.
+// The staged root is published in the trust bundle right away so devices
+// trust it before it starts signing.
+func (s *service) stageRootCA(ctx context.Context, config *Config) error {
+	next, err := s.generateRootCA(ctx, *config, s.rootCA)
+	if err != nil {
+		return err
+	}
+	s.roots[next.SerialNumber] = next
+
+	return nil
+}
+
+// activateRootCA makes the given root CA the active one. The previous root
+// and its issuers are retired instead of revoked, so certificates issued
+// under them stay valid until they expire or are renewed.
+func (s *service) activateRootCA(ctx context.Context, next *CA, config *Config) error {
+	names := s.activeIssuers()
+	if s.rootCA != nil {
+		if err := s.retireCA(ctx, s.rootCA); err != nil {
+			return err
+		}
+	}
+	if next.Staged {
+		cert, err := s.repo.RetrieveCert(ctx, next.SerialNumber)
+		if err != nil {
+			return errors.Wrap(ErrViewEntity, err)
+		}
+		cert.Staged = false
+		if err := s.repo.UpdateCert(ctx, cert); err != nil {
+			return errors.Wrap(ErrUpdateEntity, err)
+		}
+		next.Staged = false
+	}
+	s.rootCA = next
+	s.roots[next.SerialNumber] = next
+
+	if len(names) == 0 {
+		names = []string{DefaultIssuer}
+	}
+	// Issuers are recreated under the new root CA.
+	for _, name := range names {
+		if err := s.rotateIssuer(ctx, next, name, config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rotateIssuer retires the active issuer with the given name and creates its
+// successor under the given root CA.
+func (s *service) rotateIssuer(ctx context.Context, rootCA *CA, name string, config *Config) error {
+	if ca := s.findIssuer(name); ca != nil {
+		if err := s.retireCA(ctx, ca); err != nil {
+			return err
+		}
+	}
+	ca, err := s.createIntermediateCA(ctx, rootCA, name, *config)
+	if err != nil {
+		return err
+	}
+	s.intermediates[ca.SerialNumber] = ca
+
+	return nil
+}
+
+// pruneRoots drops retired root CAs from the trust bundle once they expired
+// or none of the certificates issued under them is valid anymore.
+func (s *service) pruneRoots(ctx context.Context) error {
+	now := time.Now()
+	for serialNumber, root := range s.roots {
+		if !root.Retired {
+			continue
+		}
+		if now.Before(root.Certificate.NotAfter) {
+			count, err := s.repo.CountValidCerts(ctx, s.issuedBy(root))
+			if err != nil {
+				return errors.Wrap(ErrViewEntity, err)
+			}
+			if count > 0 {
+				continue
+			}
+		}
+		delete(s.roots, serialNumber)
+	}
+
+	return nil
+}
+
+// successor returns the active issuer that replaced the given retired issuer.
+func (s *service) successor(ca *CA) (*CA, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	next := s.findIssuer(ca.Name)
+	if next == nil || next.Signer == nil {
+		return nil, ErrIssuerRetired
+	}
+
+	return next, nil
+}
+
+// chainRoots returns the certificates published after the issuer in its chain:
+// the root that signed the issuer, the other trusted roots and the
+// certificates cross-signing newer roots by the previous ones.
+func (s *service) chainRoots(issuer *CA) []*x509.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roots := make([]*CA, 0, len(s.roots))
+	for _, root := range s.roots {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].Certificate.NotAfter.After(roots[j].Certificate.NotAfter)
+	})
+
+	var parents, others, cross []*x509.Certificate
+	for _, root := range roots {
+		if issuer.Certificate.CheckSignatureFrom(root.Certificate) == nil {
+			parents = append(parents, root.Certificate)
+		} else {
+			others = append(others, root.Certificate)
+		}
+		if root.CrossCertificate != nil {
+			cross = append(cross, root.CrossCertificate)
+		}
+	}
+
+	return append(append(parents, others...), cross...)
+}
+
+// trustBundle returns the PEM encoded certificates relying parties trust: the
+// active, staged and retired roots, the newest first, and the certificates
+// cross-signing newer roots by the previous ones.
+func (s *service) trustBundle() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roots := make([]*CA, 0, len(s.roots))
+	for _, root := range s.roots {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].Certificate.NotAfter.After(roots[j].Certificate.NotAfter)
+	})
+
+	var bundle, cross []byte
+	for _, root := range roots {
+		bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Certificate.Raw})...)
+		if root.CrossCertificate != nil {
+			cross = append(cross, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.CrossCertificate.Raw})...)
+		}
+	}
+
+	return append(bundle, cross...)
+}
+
+// stagedRoot returns the root CA staged for the next rotation. The caller must hold the lock.
+func (s *service) stagedRoot() *CA {
+	for _, root := range s.roots {
+		if root.Staged {
+			return root
+		}
+	}
+
+	return nil
+}
+
+// issuedBy returns the serial numbers of the issuers signed by the root CA. The caller must hold the lock.
+func (s *service) issuedBy(root *CA) []string {
+	var serialNumbers []string
+	for _, ca := range s.intermediates {
+		if ca.Certificate.CheckSignatureFrom(root.Certificate) == nil {
+			serialNumbers = append(serialNumbers, ca.SerialNumber)
+		}
+	}
+
+	return serialNumbers
+}
+
+// retireCA persists the CA as retired. A staged root is no longer staged, so
+// it is never activated. The caller must hold the lock.
+func (s *service) retireCA(ctx context.Context, ca *CA) error {
+	cert, err := s.repo.RetrieveCert(ctx, ca.SerialNumber)
+	if err != nil {
+		return errors.Wrap(ErrViewEntity, err)
+	}
+	cert.Retired = true
+	cert.Staged = false
+	if err := s.repo.UpdateCert(ctx, cert); err != nil {
+		return errors.Wrap(ErrUpdateEntity, err)
+	}
+	ca.Retired = true
+	ca.Staged = false
+
+	return nil
+}
+
+// crossSign issues a certificate for the subject and key of the root CA signed
+// by the previous root, so that devices trusting only the previous root can
+// validate chains under the new one.
+func crossSign(cert *x509.Certificate, previous *CA) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:            serialNumber,
+		RawSubject:              cert.RawSubject,
+		SubjectKeyId:            cert.SubjectKeyId,
+		NotBefore:               time.Now(),
+		NotAfter:                previous.Certificate.NotAfter,
+		KeyUsage:                cert.KeyUsage,
+		ExtKeyUsage:             cert.ExtKeyUsage,
+		BasicConstraintsValid:   true,
+		IsCA:                    true,
+		MaxPathLen:              cert.MaxPathLen,
+		MaxPathLenZero:          cert.MaxPathLenZero,
+		PermittedDNSDomains:     cert.PermittedDNSDomains,
+		ExcludedDNSDomains:      cert.ExcludedDNSDomains,
+		PermittedIPRanges:       cert.PermittedIPRanges,
+		ExcludedIPRanges:        cert.ExcludedIPRanges,
+		PermittedEmailAddresses: cert.PermittedEmailAddresses,
+		ExcludedEmailAddresses:  cert.ExcludedEmailAddresses,
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, previous.Certificate, cert.PublicKey, previous.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(certBytes)
+}
\ No newline at end of file
@@ -0,0 +1,154 @@
+package scep
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/cms"
+)
+
+const nonceSize = 16
+
+var (
+	oidMessageType       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 2}
+	oidPKIStatus         = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 3}
+	oidFailInfo          = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 4}
+	oidSenderNonce       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 5}
+	oidRecipientNonce    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 6}
+	oidTransactionID     = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 7}
+	oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+	errMissingAttribute  = errors.New("missing SCEP attribute")
+)
+
+// message is a verified PKI message.
+type message struct {
+	*cms.SignedData
+	messageType   MessageType
+	transactionID string
+	senderNonce   []byte
+}
+
+// reply is the outcome of a PKI message, sent back as a CertRep.
+type reply struct {
+	status   PKIStatus
+	failInfo FailInfo
+	cert     *x509.Certificate
+}
+
+func success(cert *x509.Certificate) reply {
+	return reply{status: PKIStatusSuccess, cert: cert}
+}
+
+func pending() reply {
+	return reply{status: PKIStatusPending}
+}
+
+func failure(info FailInfo) reply {
+	return reply{status: PKIStatusFailure, failInfo: info}
+}
+
+// parseMessage parses and verifies the signed PKI message.
+func parseMessage(data []byte) (message, error) {
+	sd, err := cms.ParseSignedData(data)
+	if err != nil {
+		return message{}, errors.Wrap(ErrMalformed, err)
+	}
+	msg := message{SignedData: sd}
+	var messageType string
+	if err := attribute(sd, oidMessageType, &messageType); err != nil {
+		return message{}, err
+	}
+	msg.messageType = MessageType(messageType)
+	if err := attribute(sd, oidTransactionID, &msg.transactionID); err != nil {
+		return message{}, err
+	}
+	if err := attribute(sd, oidSenderNonce, &msg.senderNonce); err != nil {
+		return message{}, err
+	}
+	if msg.transactionID == "" {
+		return message{}, errors.Wrap(ErrMalformed, errMissingAttribute)
+	}
+
+	return msg, nil
+}
+
+// marshalCertRep signs the reply to the message with the registration
+// authority. A certificate is encrypted for the signer of the message with
+// the content encryption algorithm of the message.
+func marshalCertRep(ra registrationAuthority, msg message, rep reply, alg asn1.ObjectIdentifier) ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	attrs := []cms.Attribute{
+		{Type: oidMessageType, Value: string(MessageCertRep)},
+		{Type: oidPKIStatus, Value: string(rep.status)},
+		{Type: oidTransactionID, Value: msg.transactionID},
+		{Type: oidRecipientNonce, Value: msg.senderNonce},
+		{Type: oidSenderNonce, Value: nonce},
+	}
+	if rep.status == PKIStatusFailure {
+		attrs = append(attrs, cms.Attribute{Type: oidFailInfo, Value: string(rep.failInfo)})
+	}
+
+	var content []byte
+	if rep.cert != nil {
+		certs, err := cms.MarshalCertsOnly([]*x509.Certificate{rep.cert})
+		if err != nil {
+			return nil, err
+		}
+		if content, err = cms.Encrypt(certs, msg.Signer, alg); err != nil {
+			return nil, err
+		}
+	}
+
+	return cms.Sign(content, ra.cert, ra.key, msg.Hash, attrs)
+}
+
+// attribute decodes the value of the signed attribute into v.
+func attribute(sd *cms.SignedData, oid asn1.ObjectIdentifier, v interface{}) error {
+	raw, ok := sd.Attribute(oid)
+	if !ok {
+		return errors.Wrap(ErrMalformed, errMissingAttribute)
+	}
+	if _, err := asn1.Unmarshal(raw.FullBytes, v); err != nil {
+		return errors.Wrap(ErrMalformed, err)
+	}
+
+	return nil
+}
+
+// challengePassword returns the challenge password attribute of the CSR,
+// which the standard library does not expose.
+func challengePassword(csr *x509.CertificateRequest) (string, error) {
+	var tbs struct {
+		Version    int
+		Subject    asn1.RawValue
+		PublicKey  asn1.RawValue
+		Attributes []asn1.RawValue `asn1:"tag:0"`
+	}
+	if _, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+		return "", errors.Wrap(ErrMalformed, err)
+	}
+	for _, raw := range tbs.Attributes {
+		var attr struct {
+			Type   asn1.ObjectIdentifier
+			Values []asn1.RawValue `asn1:"set"`
+		}
+		if _, err := asn1.Unmarshal(raw.FullBytes, &attr); err != nil {
+			return "", errors.Wrap(ErrMalformed, err)
+		}
+		if !attr.Type.Equal(oidChallengePassword) || len(attr.Values) == 0 {
+			continue
+		}
+		var password string
+		if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &password); err != nil {
+			return "", errors.Wrap(ErrMalformed, err)
+		}
+		return password, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,352 @@
+// Code generated by mockery v2.53.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	scep "github.com/hantdev/certs/scep"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockRepository is an autogenerated mock type for the Repository type
+type MockRepository struct {
+	mock.Mock
+}
+
+type MockRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRepository) EXPECT() *MockRepository_Expecter {
+	return &MockRepository_Expecter{mock: &_m.Mock}
+}
+
+// ConsumeChallenge provides a mock function with given fields: ctx, hash
+func (_m *MockRepository) ConsumeChallenge(ctx context.Context, hash string) (scep.Challenge, error) {
+	ret := _m.Called(ctx, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeChallenge")
+	}
+
+	var r0 scep.Challenge
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (scep.Challenge, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) scep.Challenge); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		r0 = ret.Get(0).(scep.Challenge)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_ConsumeChallenge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConsumeChallenge'
+type MockRepository_ConsumeChallenge_Call struct {
+	*mock.Call
+}
+
+// ConsumeChallenge is a helper method to define mock.On call
+//   - ctx context.Context
+//   - hash string
+func (_e *MockRepository_Expecter) ConsumeChallenge(ctx interface{}, hash interface{}) *MockRepository_ConsumeChallenge_Call {
+	return &MockRepository_ConsumeChallenge_Call{Call: _e.mock.On("ConsumeChallenge", ctx, hash)}
+}
+
+func (_c *MockRepository_ConsumeChallenge_Call) Run(run func(ctx context.Context, hash string)) *MockRepository_ConsumeChallenge_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_ConsumeChallenge_Call) Return(_a0 scep.Challenge, _a1 error) *MockRepository_ConsumeChallenge_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_ConsumeChallenge_Call) RunAndReturn(run func(context.Context, string) (scep.Challenge, error)) *MockRepository_ConsumeChallenge_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateChallenge provides a mock function with given fields: ctx, challenge
+func (_m *MockRepository) CreateChallenge(ctx context.Context, challenge scep.Challenge) error {
+	ret := _m.Called(ctx, challenge)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateChallenge")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, scep.Challenge) error); ok {
+		r0 = rf(ctx, challenge)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_CreateChallenge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateChallenge'
+type MockRepository_CreateChallenge_Call struct {
+	*mock.Call
+}
+
+// CreateChallenge is a helper method to define mock.On call
+//   - ctx context.Context
+//   - challenge scep.Challenge
+func (_e *MockRepository_Expecter) CreateChallenge(ctx interface{}, challenge interface{}) *MockRepository_CreateChallenge_Call {
+	return &MockRepository_CreateChallenge_Call{Call: _e.mock.On("CreateChallenge", ctx, challenge)}
+}
+
+func (_c *MockRepository_CreateChallenge_Call) Run(run func(ctx context.Context, challenge scep.Challenge)) *MockRepository_CreateChallenge_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(scep.Challenge))
+	})
+	return _c
+}
+
+func (_c *MockRepository_CreateChallenge_Call) Return(_a0 error) *MockRepository_CreateChallenge_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_CreateChallenge_Call) RunAndReturn(run func(context.Context, scep.Challenge) error) *MockRepository_CreateChallenge_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateRequest provides a mock function with given fields: ctx, req
+func (_m *MockRepository) CreateRequest(ctx context.Context, req scep.Request) error {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRequest")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, scep.Request) error); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_CreateRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRequest'
+type MockRepository_CreateRequest_Call struct {
+	*mock.Call
+}
+
+// CreateRequest is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req scep.Request
+func (_e *MockRepository_Expecter) CreateRequest(ctx interface{}, req interface{}) *MockRepository_CreateRequest_Call {
+	return &MockRepository_CreateRequest_Call{Call: _e.mock.On("CreateRequest", ctx, req)}
+}
+
+func (_c *MockRepository_CreateRequest_Call) Run(run func(ctx context.Context, req scep.Request)) *MockRepository_CreateRequest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(scep.Request))
+	})
+	return _c
+}
+
+func (_c *MockRepository_CreateRequest_Call) Return(_a0 error) *MockRepository_CreateRequest_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_CreateRequest_Call) RunAndReturn(run func(context.Context, scep.Request) error) *MockRepository_CreateRequest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListRequests provides a mock function with given fields: ctx, status
+func (_m *MockRepository) ListRequests(ctx context.Context, status scep.Status) ([]scep.Request, error) {
+	ret := _m.Called(ctx, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRequests")
+	}
+
+	var r0 []scep.Request
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, scep.Status) ([]scep.Request, error)); ok {
+		return rf(ctx, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, scep.Status) []scep.Request); ok {
+		r0 = rf(ctx, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]scep.Request)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, scep.Status) error); ok {
+		r1 = rf(ctx, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_ListRequests_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRequests'
+type MockRepository_ListRequests_Call struct {
+	*mock.Call
+}
+
+// ListRequests is a helper method to define mock.On call
+//   - ctx context.Context
+//   - status scep.Status
+func (_e *MockRepository_Expecter) ListRequests(ctx interface{}, status interface{}) *MockRepository_ListRequests_Call {
+	return &MockRepository_ListRequests_Call{Call: _e.mock.On("ListRequests", ctx, status)}
+}
+
+func (_c *MockRepository_ListRequests_Call) Run(run func(ctx context.Context, status scep.Status)) *MockRepository_ListRequests_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(scep.Status))
+	})
+	return _c
+}
+
+func (_c *MockRepository_ListRequests_Call) Return(_a0 []scep.Request, _a1 error) *MockRepository_ListRequests_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_ListRequests_Call) RunAndReturn(run func(context.Context, scep.Status) ([]scep.Request, error)) *MockRepository_ListRequests_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveRequest provides a mock function with given fields: ctx, transactionID
+func (_m *MockRepository) RetrieveRequest(ctx context.Context, transactionID string) (scep.Request, error) {
+	ret := _m.Called(ctx, transactionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveRequest")
+	}
+
+	var r0 scep.Request
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (scep.Request, error)); ok {
+		return rf(ctx, transactionID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) scep.Request); ok {
+		r0 = rf(ctx, transactionID)
+	} else {
+		r0 = ret.Get(0).(scep.Request)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, transactionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveRequest'
+type MockRepository_RetrieveRequest_Call struct {
+	*mock.Call
+}
+
+// RetrieveRequest is a helper method to define mock.On call
+//   - ctx context.Context
+//   - transactionID string
+func (_e *MockRepository_Expecter) RetrieveRequest(ctx interface{}, transactionID interface{}) *MockRepository_RetrieveRequest_Call {
+	return &MockRepository_RetrieveRequest_Call{Call: _e.mock.On("RetrieveRequest", ctx, transactionID)}
+}
+
+func (_c *MockRepository_RetrieveRequest_Call) Run(run func(ctx context.Context, transactionID string)) *MockRepository_RetrieveRequest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveRequest_Call) Return(_a0 scep.Request, _a1 error) *MockRepository_RetrieveRequest_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveRequest_Call) RunAndReturn(run func(context.Context, string) (scep.Request, error)) *MockRepository_RetrieveRequest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateRequest provides a mock function with given fields: ctx, req, status
+func (_m *MockRepository) UpdateRequest(ctx context.Context, req scep.Request, status scep.Status) error {
+	ret := _m.Called(ctx, req, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateRequest")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, scep.Request, scep.Status) error); ok {
+		r0 = rf(ctx, req, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_UpdateRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateRequest'
+type MockRepository_UpdateRequest_Call struct {
+	*mock.Call
+}
+
+// UpdateRequest is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req scep.Request
+//   - status scep.Status
+func (_e *MockRepository_Expecter) UpdateRequest(ctx interface{}, req interface{}, status interface{}) *MockRepository_UpdateRequest_Call {
+	return &MockRepository_UpdateRequest_Call{Call: _e.mock.On("UpdateRequest", ctx, req, status)}
+}
+
+func (_c *MockRepository_UpdateRequest_Call) Run(run func(ctx context.Context, req scep.Request, status scep.Status)) *MockRepository_UpdateRequest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(scep.Request), args[2].(scep.Status))
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateRequest_Call) Return(_a0 error) *MockRepository_UpdateRequest_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_UpdateRequest_Call) RunAndReturn(run func(context.Context, scep.Request, scep.Status) error) *MockRepository_UpdateRequest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRepository creates a new instance of MockRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRepository {
+	mock := &MockRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
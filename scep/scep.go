@@ -0,0 +1,173 @@
+// Package scep implements a Simple Certificate Enrollment Protocol (RFC 8894)
+// responder on top of the certs service.
+//
+// Devices enroll with a CSR carrying a one-time challenge password that is
+// tied to an entity, renew with their current certificate and poll for
+// requests that wait for manual approval. Messages are signed and encrypted
+// for a registration authority certificate issued by the configured CA.
+package scep
+
+import (
+	"context"
+	"crypto/x509"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// Operations of the SCEP HTTP API.
+const (
+	OpGetCACaps    = "GetCACaps"
+	OpGetCACert    = "GetCACert"
+	OpPKIOperation = "PKIOperation"
+)
+
+// MessageType is the SCEP message type, a decimal number.
+type MessageType string
+
+const (
+	MessageCertRep        MessageType = "3"
+	MessageRenewalReq     MessageType = "17"
+	MessagePKCSReq        MessageType = "19"
+	MessageGetCertInitial MessageType = "20"
+)
+
+// PKIStatus is the status of a CertRep message.
+type PKIStatus string
+
+const (
+	PKIStatusSuccess PKIStatus = "0"
+	PKIStatusFailure PKIStatus = "2"
+	PKIStatusPending PKIStatus = "3"
+)
+
+// FailInfo is the reason of a failed CertRep message.
+type FailInfo string
+
+const (
+	FailBadAlg          FailInfo = "0"
+	FailBadMessageCheck FailInfo = "1"
+	FailBadRequest      FailInfo = "2"
+	FailBadTime         FailInfo = "3"
+	FailBadCertID       FailInfo = "4"
+)
+
+// Status is the status of an enrollment request. An approved request is
+// issuing while its certificate is signed.
+type Status string
+
+// issuingTimeout is how long after its issuance started a request may stay
+// issuing. A request issuing for longer was left behind by a failed request,
+// so it is issued again when polled and can be approved again or rejected.
+const issuingTimeout = 10 * time.Minute
+
+const (
+	StatusPending  Status = "pending"
+	StatusIssuing  Status = "issuing"
+	StatusIssued   Status = "issued"
+	StatusRejected Status = "rejected"
+)
+
+// Capabilities are the capabilities the responder advertises with GetCACaps.
+var Capabilities = []string{"POSTPKIOperation", "SHA-256", "SHA-1", "AES", "DES3", "Renewal", "SCEPStandard"}
+
+var (
+	ErrMalformed        = errors.New("malformed message")
+	ErrNotFound         = errors.New("request not found")
+	ErrInvalidChallenge = errors.New("invalid or expired challenge password")
+	ErrNotPending       = errors.New("request is not pending")
+)
+
+// Config holds the SCEP responder settings.
+type Config struct {
+	// Enabled serves the SCEP API next to the certs API.
+	Enabled bool `env:"ENABLED" envDefault:"false"`
+	// Issuer, Profile and TTL are the issuer, profile and validity
+	// certificates are issued with.
+	Issuer  string `env:"ISSUER"  envDefault:""`
+	Profile string `env:"PROFILE" envDefault:""`
+	TTL     string `env:"TTL"     envDefault:""`
+	// ManualApproval keeps new enrollments pending until they are approved.
+	// Renewals of valid certificates are always issued right away.
+	ManualApproval bool `env:"MANUAL_APPROVAL" envDefault:"false"`
+	// ChallengeTTL is the validity of challenge passwords created without one.
+	ChallengeTTL time.Duration `env:"CHALLENGE_TTL" envDefault:"24h"`
+	// RATTL is the validity of the registration authority certificate, which
+	// is renewed when it is about to expire.
+	RATTL string `env:"RA_TTL" envDefault:"8760h"`
+}
+
+// Challenge is a one-time challenge password enrolling a device for an entity.
+type Challenge struct {
+	// Password is only known when the challenge is created; the
+	// repository keeps its hash.
+	Password  string    `json:"password,omitempty"`
+	Hash      string    `json:"-"`
+	EntityID  string    `json:"entity_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Request is an enrollment request, identified by the transaction ID the
+// device chose for it.
+type Request struct {
+	TransactionID string `json:"transaction_id"`
+	EntityID      string `json:"entity_id"`
+	Status        Status `json:"status"`
+	// CSR is the DER encoded CSR of the request.
+	CSR     []byte `json:"csr"`
+	Renewal bool   `json:"renewal,omitempty"`
+	// KeyHash is the hash of the key the request is signed with, which
+	// must sign the polls of the request as well.
+	KeyHash    string    `json:"key_hash"`
+	CertSerial string    `json:"cert_serial,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Service specifies the SCEP responder API.
+type Service interface {
+	// CACerts returns the registration authority certificate followed by
+	// the certificates of the issuer's chain.
+	CACerts(ctx context.Context) ([]*x509.Certificate, error)
+
+	// PKIOperation handles the DER encoded PKI message and returns the signed CertRep.
+	PKIOperation(ctx context.Context, msg []byte) ([]byte, error)
+
+	// CreateChallenge creates a challenge password for the entity, valid
+	// for the TTL or the configured challenge TTL if it is zero.
+	CreateChallenge(ctx context.Context, entityID string, ttl time.Duration) (Challenge, error)
+
+	// ListRequests lists the enrollment requests, all of them if the status is empty.
+	ListRequests(ctx context.Context, status Status) ([]Request, error)
+
+	// ApproveRequest issues the certificate of a pending request, or of one
+	// left issuing by a failed request once its issuance timed out.
+	ApproveRequest(ctx context.Context, transactionID string) (Request, error)
+
+	// RejectRequest rejects a pending request, or one left issuing by a
+	// failed request once its issuance timed out.
+	RejectRequest(ctx context.Context, transactionID, reason string) (Request, error)
+}
+
+// Repository specifies the SCEP persistence API.
+type Repository interface {
+	// CreateChallenge stores a challenge.
+	CreateChallenge(ctx context.Context, challenge Challenge) error
+
+	// ConsumeChallenge removes and returns the unexpired challenge with the given hash.
+	ConsumeChallenge(ctx context.Context, hash string) (Challenge, error)
+
+	// CreateRequest stores a request.
+	CreateRequest(ctx context.Context, req Request) error
+
+	// RetrieveRequest retrieves the request with the given transaction ID.
+	RetrieveRequest(ctx context.Context, transactionID string) (Request, error)
+
+	// UpdateRequest updates a request if it still has the status, and
+	// returns ErrNotPending otherwise.
+	UpdateRequest(ctx context.Context, req Request, status Status) error
+
+	// ListRequests lists the requests with the status, all of them if it is empty.
+	ListRequests(ctx context.Context, status Status) ([]Request, error)
+}
@@ -0,0 +1,553 @@
+package scep_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	scepapi "github.com/hantdev/certs/api/scep"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/certstest"
+	"github.com/hantdev/certs/internal/cms"
+	"github.com/hantdev/certs/scep"
+	smocks "github.com/hantdev/certs/scep/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const entityID = "router-1"
+
+var (
+	oidMessageType       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 2}
+	oidPKIStatus         = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 3}
+	oidFailInfo          = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 4}
+	oidSenderNonce       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 5}
+	oidRecipientNonce    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 6}
+	oidTransactionID     = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 7}
+	oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+	oidSHA256WithRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+)
+
+// newRepository returns the SCEP repository backed by maps.
+func newRepository() *smocks.MockRepository {
+	var mu sync.Mutex
+	challenges := map[string]scep.Challenge{}
+	reqs := map[string]scep.Request{}
+
+	repo := new(smocks.MockRepository)
+	repo.On("CreateChallenge", mock.Anything, mock.Anything).Return(func(_ context.Context, challenge scep.Challenge) error {
+		mu.Lock()
+		defer mu.Unlock()
+		challenges[challenge.Hash] = challenge
+		return nil
+	})
+	repo.On("ConsumeChallenge", mock.Anything, mock.Anything).Return(func(_ context.Context, hash string) (scep.Challenge, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		challenge, ok := challenges[hash]
+		delete(challenges, hash)
+		if !ok || challenge.ExpiresAt.Before(time.Now()) {
+			return scep.Challenge{}, scep.ErrInvalidChallenge
+		}
+		return challenge, nil
+	})
+	repo.On("CreateRequest", mock.Anything, mock.Anything).Return(func(_ context.Context, req scep.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs[req.TransactionID] = req
+		return nil
+	})
+	repo.On("RetrieveRequest", mock.Anything, mock.Anything).Return(func(_ context.Context, id string) (scep.Request, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		req, ok := reqs[id]
+		if !ok {
+			return scep.Request{}, scep.ErrNotFound
+		}
+		return req, nil
+	})
+	repo.On("UpdateRequest", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, req scep.Request, status scep.Status) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if reqs[req.TransactionID].Status != status {
+			return scep.ErrNotPending
+		}
+		reqs[req.TransactionID] = req
+		return nil
+	})
+	repo.On("ListRequests", mock.Anything, mock.Anything).Return(func(_ context.Context, status scep.Status) ([]scep.Request, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var res []scep.Request
+		for _, req := range reqs {
+			if status == "" || req.Status == status {
+				res = append(res, req)
+			}
+		}
+		return res, nil
+	})
+
+	return repo
+}
+
+func newServer(t *testing.T, svc scep.Service) *httptest.Server {
+	srv := httptest.NewServer(scepapi.MakeHandler(svc, nil, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// device is a SCEP client with an RSA key and the certificate it signs its
+// messages with: a self-signed one until it is enrolled.
+type device struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newDevice(t *testing.T, cn string) device {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return device{key: key, cert: cert}
+}
+
+// newCSR returns a CSR of the key with the challenge password attribute,
+// which the standard library cannot encode.
+func newCSR(t *testing.T, key *rsa.PrivateKey, cn, password string) []byte {
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}}, key)
+	require.NoError(t, err)
+	if password == "" {
+		return der
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	require.NoError(t, err)
+
+	value, err := asn1.Marshal(password)
+	require.NoError(t, err)
+	attr, err := asn1.Marshal(struct {
+		Type   asn1.ObjectIdentifier
+		Values []asn1.RawValue `asn1:"set"`
+	}{Type: oidChallengePassword, Values: []asn1.RawValue{{FullBytes: value}}})
+	require.NoError(t, err)
+	tbs, err := asn1.Marshal(struct {
+		Version    int
+		Subject    asn1.RawValue
+		PublicKey  asn1.RawValue
+		Attributes []asn1.RawValue `asn1:"tag:0"`
+	}{
+		Subject:    asn1.RawValue{FullBytes: csr.RawSubject},
+		PublicKey:  asn1.RawValue{FullBytes: csr.RawSubjectPublicKeyInfo},
+		Attributes: []asn1.RawValue{{FullBytes: attr}},
+	})
+	require.NoError(t, err)
+	digest := sha256.Sum256(tbs)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	der, err = asn1.Marshal(struct {
+		TBS       asn1.RawValue
+		Algorithm pkix.AlgorithmIdentifier
+		Signature asn1.BitString
+	}{
+		TBS:       asn1.RawValue{FullBytes: tbs},
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256WithRSA, Parameters: asn1.NullRawValue},
+		Signature: asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+	require.NoError(t, err)
+
+	return der
+}
+
+// pkiMessage returns the PKI message with the content enveloped for the
+// registration authority, signed by the device, and its sender nonce.
+func pkiMessage(t *testing.T, ra *x509.Certificate, d device, messageType scep.MessageType, transactionID string, content []byte) ([]byte, []byte) {
+	envelope, err := cms.Encrypt(content, ra, cms.OIDAES256CBC)
+	require.NoError(t, err)
+	nonce := make([]byte, 16)
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+	msg, err := cms.Sign(envelope, d.cert, d.key, crypto.SHA256, []cms.Attribute{
+		{Type: oidMessageType, Value: string(messageType)},
+		{Type: oidTransactionID, Value: transactionID},
+		{Type: oidSenderNonce, Value: nonce},
+	})
+	require.NoError(t, err)
+
+	return msg, nonce
+}
+
+// certRep is a verified CertRep message.
+type certRep struct {
+	*cms.SignedData
+	t *testing.T
+}
+
+func (rep certRep) attribute(oid asn1.ObjectIdentifier, v interface{}) {
+	raw, ok := rep.Attribute(oid)
+	require.True(rep.t, ok, "missing attribute %s", oid)
+	_, err := asn1.Unmarshal(raw.FullBytes, v)
+	require.NoError(rep.t, err)
+}
+
+func (rep certRep) status() scep.PKIStatus {
+	var status string
+	rep.attribute(oidPKIStatus, &status)
+	return scep.PKIStatus(status)
+}
+
+func (rep certRep) failInfo() scep.FailInfo {
+	var info string
+	rep.attribute(oidFailInfo, &info)
+	return scep.FailInfo(info)
+}
+
+// certificate decrypts the certificate of a successful reply for the device.
+func (rep certRep) certificate(d device) *x509.Certificate {
+	certsOnly, alg, err := cms.Decrypt(rep.Content, d.cert, d.key)
+	require.NoError(rep.t, err)
+	assert.Equal(rep.t, cms.OIDAES256CBC, alg)
+	certs, err := cms.ParseCertsOnly(certsOnly)
+	require.NoError(rep.t, err)
+	require.Len(rep.t, certs, 1)
+
+	return certs[0]
+}
+
+// operation posts the PKI message and verifies the CertRep signed by the
+// registration authority in reply to it.
+func operation(t *testing.T, srv *httptest.Server, ra *x509.Certificate, msg, nonce []byte, transactionID string) certRep {
+	res, err := srv.Client().Post(srv.URL+"/scep?operation=PKIOperation", scepapi.PKIMessageType, bytes.NewReader(msg))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	assert.Equal(t, scepapi.PKIMessageType, res.Header.Get("Content-Type"))
+
+	sd, err := cms.ParseSignedData(body)
+	require.NoError(t, err)
+	require.Equal(t, ra.Raw, sd.Signer.Raw)
+	rep := certRep{SignedData: sd, t: t}
+	var messageType, id string
+	var recipientNonce []byte
+	rep.attribute(oidMessageType, &messageType)
+	rep.attribute(oidTransactionID, &id)
+	rep.attribute(oidRecipientNonce, &recipientNonce)
+	assert.Equal(t, string(scep.MessageCertRep), messageType)
+	assert.Equal(t, transactionID, id)
+	assert.Equal(t, nonce, recipientNonce)
+
+	return rep
+}
+
+// caCerts returns the registration authority and CA certificates.
+func caCerts(t *testing.T, srv *httptest.Server, path string) []*x509.Certificate {
+	res, err := srv.Client().Get(srv.URL + path + "?operation=GetCACert")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+	assert.Equal(t, scepapi.CACertType, res.Header.Get("Content-Type"))
+	certs, err := cms.ParseCertsOnly(body)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(certs), 2)
+
+	return certs
+}
+
+func createChallenge(t *testing.T, srv *httptest.Server, entityID string) string {
+	res, err := srv.Client().Post(srv.URL+"/scep/challenges", scepapi.ContentType, strings.NewReader(`{"entity_id":"`+entityID+`","ttl":"1h"}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var challenge scep.Challenge
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&challenge))
+	assert.Equal(t, entityID, challenge.EntityID)
+	require.NotEmpty(t, challenge.Password)
+
+	return challenge.Password
+}
+
+func patch(t *testing.T, srv *httptest.Server, path, body string) (int, scep.Request) {
+	req, err := http.NewRequest(http.MethodPatch, srv.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", scepapi.ContentType)
+	res, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	var r scep.Request
+	if res.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&r))
+	}
+
+	return res.StatusCode, r
+}
+
+func TestSCEP(t *testing.T) {
+	certsSvc := certstest.NewService(t)
+	svc := scep.NewService(newRepository(), certsSvc, scep.Config{})
+	srv := newServer(t, svc)
+
+	res, err := srv.Client().Get(srv.URL + "/scep?operation=GetCACaps")
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	caps := strings.Fields(string(body))
+	assert.Contains(t, caps, "POSTPKIOperation")
+	assert.Contains(t, caps, "Renewal")
+
+	cas := caCerts(t, srv, "/cgi-bin/pkiclient.exe")
+	ra := cas[0]
+	assert.Equal(t, "SCEP RA", ra.Subject.CommonName)
+	require.NoError(t, ra.CheckSignatureFrom(cas[1]))
+	assert.Equal(t, ra.Raw, caCerts(t, srv, "/scep")[0].Raw)
+	// Another instance reuses the registration authority.
+	other, err := scep.NewService(newRepository(), certsSvc, scep.Config{}).CACerts(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ra.Raw, other[0].Raw)
+
+	password := createChallenge(t, srv, entityID)
+	d := newDevice(t, entityID)
+	msg, nonce := pkiMessage(t, ra, d, scep.MessagePKCSReq, "tx-1", newCSR(t, d.key, entityID, password))
+	rep := operation(t, srv, ra, msg, nonce, "tx-1")
+	require.Equal(t, scep.PKIStatusSuccess, rep.status())
+	cert := rep.certificate(d)
+	assert.Equal(t, entityID, cert.Subject.CommonName)
+	assert.True(t, cert.PublicKey.(*rsa.PublicKey).Equal(d.key.Public()))
+	require.NoError(t, cert.CheckSignatureFrom(cas[1]))
+	issued, err := certsSvc.ViewCert(context.Background(), cert.SerialNumber.String())
+	require.NoError(t, err)
+	assert.Equal(t, entityID, issued.EntityID)
+
+	// A resent request gets the same certificate.
+	msg, nonce = pkiMessage(t, ra, d, scep.MessagePKCSReq, "tx-1", newCSR(t, d.key, entityID, password))
+	rep = operation(t, srv, ra, msg, nonce, "tx-1")
+	require.Equal(t, scep.PKIStatusSuccess, rep.status())
+	assert.Equal(t, cert.Raw, rep.certificate(d).Raw)
+
+	// Polls are sent as GET requests with the base64 encoded message.
+	msg, nonce = pkiMessage(t, ra, d, scep.MessageGetCertInitial, "tx-1", []byte("issuer and subject"))
+	res, err = srv.Client().Get(srv.URL + "/scep?operation=PKIOperation&message=" + url.QueryEscape(base64.StdEncoding.EncodeToString(msg)))
+	require.NoError(t, err)
+	body, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	sd, err := cms.ParseSignedData(body)
+	require.NoError(t, err)
+	poll := certRep{SignedData: sd, t: t}
+	require.Equal(t, scep.PKIStatusSuccess, poll.status())
+	assert.Equal(t, cert.Raw, poll.certificate(d).Raw)
+
+	// The challenge password can only be used once.
+	other2 := newDevice(t, entityID)
+	msg, nonce = pkiMessage(t, ra, other2, scep.MessagePKCSReq, "tx-2", newCSR(t, other2.key, entityID, password))
+	rep = operation(t, srv, ra, msg, nonce, "tx-2")
+	require.Equal(t, scep.PKIStatusFailure, rep.status())
+	assert.Equal(t, scep.FailBadRequest, rep.failInfo())
+
+	// The enrolled device renews with its certificate and a new key.
+	renewed := newDevice(t, entityID)
+	enrolled := device{key: d.key, cert: cert}
+	msg, nonce = pkiMessage(t, ra, enrolled, scep.MessageRenewalReq, "tx-3", newCSR(t, renewed.key, entityID, ""))
+	rep = operation(t, srv, ra, msg, nonce, "tx-3")
+	require.Equal(t, scep.PKIStatusSuccess, rep.status())
+	renewal := rep.certificate(enrolled)
+	assert.True(t, renewal.PublicKey.(*rsa.PublicKey).Equal(renewed.key.Public()))
+	issued, err = certsSvc.ViewCert(context.Background(), renewal.SerialNumber.String())
+	require.NoError(t, err)
+	assert.Equal(t, entityID, issued.EntityID)
+
+	// Renewals must be signed with a certificate issued by the service.
+	msg, nonce = pkiMessage(t, ra, renewed, scep.MessageRenewalReq, "tx-4", newCSR(t, renewed.key, entityID, ""))
+	rep = operation(t, srv, ra, msg, nonce, "tx-4")
+	require.Equal(t, scep.PKIStatusFailure, rep.status())
+	assert.Equal(t, scep.FailBadMessageCheck, rep.failInfo())
+
+	// Renewals cannot change the subject alternative names.
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: entityID},
+		DNSNames: []string{"other.example.com"},
+	}, renewed.key)
+	require.NoError(t, err)
+	msg, nonce = pkiMessage(t, ra, enrolled, scep.MessageRenewalReq, "tx-5", der)
+	rep = operation(t, srv, ra, msg, nonce, "tx-5")
+	require.Equal(t, scep.PKIStatusFailure, rep.status())
+	assert.Equal(t, scep.FailBadRequest, rep.failInfo())
+
+	res, err = srv.Client().Post(srv.URL+"/scep?operation=PKIOperation", scepapi.PKIMessageType, strings.NewReader("not a message"))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestSCEPManualApproval(t *testing.T) {
+	repo := newRepository()
+	svc := scep.NewService(repo, certstest.NewService(t), scep.Config{ManualApproval: true})
+	srv := newServer(t, svc)
+	ra := caCerts(t, srv, "/scep")[0]
+
+	d := newDevice(t, entityID)
+	password := createChallenge(t, srv, entityID)
+	msg, nonce := pkiMessage(t, ra, d, scep.MessagePKCSReq, "tx-1", newCSR(t, d.key, entityID, password))
+	rep := operation(t, srv, ra, msg, nonce, "tx-1")
+	require.Equal(t, scep.PKIStatusPending, rep.status())
+
+	res, err := srv.Client().Get(srv.URL + "/scep/requests?status=pending")
+	require.NoError(t, err)
+	var page struct {
+		Requests []scep.Request `json:"requests"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&page))
+	res.Body.Close()
+	require.Len(t, page.Requests, 1)
+	assert.Equal(t, "tx-1", page.Requests[0].TransactionID)
+	assert.Equal(t, entityID, page.Requests[0].EntityID)
+
+	msg, nonce = pkiMessage(t, ra, d, scep.MessageGetCertInitial, "tx-1", []byte("issuer and subject"))
+	rep = operation(t, srv, ra, msg, nonce, "tx-1")
+	require.Equal(t, scep.PKIStatusPending, rep.status())
+
+	// Only the key of the request can poll for it.
+	intruder := newDevice(t, entityID)
+	msg, nonce = pkiMessage(t, ra, intruder, scep.MessageGetCertInitial, "tx-1", []byte("issuer and subject"))
+	rep = operation(t, srv, ra, msg, nonce, "tx-1")
+	require.Equal(t, scep.PKIStatusFailure, rep.status())
+	assert.Equal(t, scep.FailBadMessageCheck, rep.failInfo())
+
+	code, approved := patch(t, srv, "/scep/requests/tx-1/approve", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, scep.StatusIssued, approved.Status)
+	assert.NotEmpty(t, approved.CertSerial)
+
+	msg, nonce = pkiMessage(t, ra, d, scep.MessageGetCertInitial, "tx-1", []byte("issuer and subject"))
+	rep = operation(t, srv, ra, msg, nonce, "tx-1")
+	require.Equal(t, scep.PKIStatusSuccess, rep.status())
+	assert.Equal(t, approved.CertSerial, rep.certificate(d).SerialNumber.String())
+
+	code, _ = patch(t, srv, "/scep/requests/tx-1/approve", "")
+	assert.Equal(t, http.StatusConflict, code)
+
+	password = createChallenge(t, srv, entityID)
+	msg, nonce = pkiMessage(t, ra, d, scep.MessagePKCSReq, "tx-2", newCSR(t, d.key, entityID, password))
+	rep = operation(t, srv, ra, msg, nonce, "tx-2")
+	require.Equal(t, scep.PKIStatusPending, rep.status())
+
+	code, rejected := patch(t, srv, "/scep/requests/tx-2/reject", `{"reason":"unknown device"}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, scep.StatusRejected, rejected.Status)
+	assert.Equal(t, "unknown device", rejected.Reason)
+
+	msg, nonce = pkiMessage(t, ra, d, scep.MessageGetCertInitial, "tx-2", []byte("issuer and subject"))
+	rep = operation(t, srv, ra, msg, nonce, "tx-2")
+	require.Equal(t, scep.PKIStatusFailure, rep.status())
+	assert.Equal(t, scep.FailBadRequest, rep.failInfo())
+
+	msg, nonce = pkiMessage(t, ra, d, scep.MessageGetCertInitial, "tx-3", []byte("issuer and subject"))
+	rep = operation(t, srv, ra, msg, nonce, "tx-3")
+	require.Equal(t, scep.PKIStatusFailure, rep.status())
+	assert.Equal(t, scep.FailBadCertID, rep.failInfo())
+
+	code, _ = patch(t, srv, "/scep/requests/tx-3/approve", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Concurrent approvals of the same request issue a single certificate.
+	password = createChallenge(t, srv, entityID)
+	msg, nonce = pkiMessage(t, ra, d, scep.MessagePKCSReq, "tx-4", newCSR(t, d.key, entityID, password))
+	rep = operation(t, srv, ra, msg, nonce, "tx-4")
+	require.Equal(t, scep.PKIStatusPending, rep.status())
+	const approvals = 5
+	results := make(chan error, approvals)
+	var wg sync.WaitGroup
+	for range approvals {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.ApproveRequest(context.Background(), "tx-4")
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+	var issued int
+	for err := range results {
+		if err == nil {
+			issued++
+			continue
+		}
+		assert.True(t, errors.Contains(err, scep.ErrNotPending), "expected error %v, got %v", scep.ErrNotPending, err)
+	}
+	assert.Equal(t, 1, issued)
+
+	// A request left issuing by a failed request is issued again when polled
+	// once its issuance timed out, and can be approved or rejected again.
+	stuck := func(transactionID string, since time.Duration) {
+		password := createChallenge(t, srv, entityID)
+		msg, nonce := pkiMessage(t, ra, d, scep.MessagePKCSReq, transactionID, newCSR(t, d.key, entityID, password))
+		rep := operation(t, srv, ra, msg, nonce, transactionID)
+		require.Equal(t, scep.PKIStatusPending, rep.status())
+		req, err := repo.RetrieveRequest(context.Background(), transactionID)
+		require.NoError(t, err)
+		req.Status = scep.StatusIssuing
+		req.UpdatedAt = time.Now().Add(-since).UTC()
+		require.NoError(t, repo.UpdateRequest(context.Background(), req, scep.StatusPending))
+	}
+	stuck("tx-5", 0)
+	_, err = svc.ApproveRequest(context.Background(), "tx-5")
+	assert.True(t, errors.Contains(err, scep.ErrNotPending), "expected error %v, got %v", scep.ErrNotPending, err)
+	_, err = svc.RejectRequest(context.Background(), "tx-5", "stuck")
+	assert.True(t, errors.Contains(err, scep.ErrNotPending), "expected error %v, got %v", scep.ErrNotPending, err)
+	msg, nonce = pkiMessage(t, ra, d, scep.MessageGetCertInitial, "tx-5", []byte("issuer and subject"))
+	rep = operation(t, srv, ra, msg, nonce, "tx-5")
+	require.Equal(t, scep.PKIStatusPending, rep.status())
+
+	stuck("tx-6", time.Hour)
+	msg, nonce = pkiMessage(t, ra, d, scep.MessageGetCertInitial, "tx-6", []byte("issuer and subject"))
+	rep = operation(t, srv, ra, msg, nonce, "tx-6")
+	require.Equal(t, scep.PKIStatusSuccess, rep.status())
+	req, err := repo.RetrieveRequest(context.Background(), "tx-6")
+	require.NoError(t, err)
+	assert.Equal(t, scep.StatusIssued, req.Status)
+	assert.Equal(t, req.CertSerial, rep.certificate(d).SerialNumber.String())
+
+	stuck("tx-7", time.Hour)
+	req, err = svc.ApproveRequest(context.Background(), "tx-7")
+	require.NoError(t, err)
+	assert.Equal(t, scep.StatusIssued, req.Status)
+
+	stuck("tx-8", time.Hour)
+	req, err = svc.RejectRequest(context.Background(), "tx-8", "stuck")
+	require.NoError(t, err)
+	assert.Equal(t, scep.StatusRejected, req.Status)
+}
@@ -0,0 +1,496 @@
+package scep
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/cms"
+)
+
+const (
+	// raEntityID is the entity the registration authority certificates are issued to.
+	raEntityID    = "scep-ra"
+	raCommonName  = "SCEP RA"
+	raRenewBefore = 7 * 24 * time.Hour
+	raListLimit   = 100
+	passwordSize  = 16
+	challengeTTL  = 24 * time.Hour
+)
+
+var (
+	errCertificateRequired = errors.New("renewal requires a certificate issued by the service")
+	errMissingEntity       = errors.New("missing entity ID")
+	errInvalidStatus       = errors.New("invalid request status")
+	errRAKeyAlgorithm      = errors.New("registration authority key is not an RSA key")
+)
+
+// registrationAuthority is the RSA certificate and key SCEP messages are
+// encrypted for and signed with, since the CA key may be of another
+// algorithm and is not available to the responder.
+type registrationAuthority struct {
+	cert  *x509.Certificate
+	key   *rsa.PrivateKey
+	chain []*x509.Certificate
+}
+
+type service struct {
+	repo   Repository
+	certs  certs.Service
+	config Config
+	// ra is the current registration authority, guarded by raMu.
+	raMu sync.Mutex
+	ra   *registrationAuthority
+}
+
+var _ Service = (*service)(nil)
+
+// NewService returns a new SCEP service issuing certificates with the certs service.
+func NewService(repo Repository, certsSvc certs.Service, config Config) Service {
+	if config.ChallengeTTL == 0 {
+		config.ChallengeTTL = challengeTTL
+	}
+
+	return &service{
+		repo:   repo,
+		certs:  certsSvc,
+		config: config,
+	}
+}
+
+func (s *service) CACerts(ctx context.Context) ([]*x509.Certificate, error) {
+	ra, err := s.registrationAuthority(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]*x509.Certificate{ra.cert}, ra.chain...), nil
+}
+
+func (s *service) PKIOperation(ctx context.Context, data []byte) ([]byte, error) {
+	msg, err := parseMessage(data)
+	if err != nil {
+		return nil, err
+	}
+	ra, err := s.registrationAuthority(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every request is encrypted for the registration authority, even the
+	// polls, and the certificate is encrypted the same way in return.
+	content, alg, err := cms.Decrypt(msg.Content, ra.cert, ra.key)
+	if err != nil {
+		return marshalCertRep(ra, msg, failure(FailBadMessageCheck), nil)
+	}
+	var rep reply
+	switch msg.messageType {
+	case MessagePKCSReq:
+		rep, err = s.enroll(ctx, msg, content, false)
+	case MessageRenewalReq:
+		rep, err = s.enroll(ctx, msg, content, true)
+	case MessageGetCertInitial:
+		rep, err = s.poll(ctx, msg)
+	default:
+		rep = failure(FailBadRequest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalCertRep(ra, msg, rep, alg)
+}
+
+func (s *service) CreateChallenge(ctx context.Context, entityID string, ttl time.Duration) (Challenge, error) {
+	if entityID == "" {
+		return Challenge{}, errors.Wrap(ErrMalformed, errMissingEntity)
+	}
+	if ttl <= 0 {
+		ttl = s.config.ChallengeTTL
+	}
+	b := make([]byte, passwordSize)
+	if _, err := rand.Read(b); err != nil {
+		return Challenge{}, err
+	}
+	challenge := Challenge{
+		Password:  hex.EncodeToString(b),
+		EntityID:  entityID,
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	}
+	challenge.Hash = hash([]byte(challenge.Password))
+	if err := s.repo.CreateChallenge(ctx, challenge); err != nil {
+		return Challenge{}, err
+	}
+
+	return challenge, nil
+}
+
+func (s *service) ListRequests(ctx context.Context, status Status) ([]Request, error) {
+	switch status {
+	case "", StatusPending, StatusIssuing, StatusIssued, StatusRejected:
+	default:
+		return nil, errors.Wrap(ErrMalformed, errInvalidStatus)
+	}
+
+	return s.repo.ListRequests(ctx, status)
+}
+
+func (s *service) ApproveRequest(ctx context.Context, transactionID string) (Request, error) {
+	req, err := s.repo.RetrieveRequest(ctx, transactionID)
+	if err != nil {
+		return Request{}, err
+	}
+	if !req.open() {
+		return Request{}, ErrNotPending
+	}
+
+	return s.issue(ctx, req)
+}
+
+func (s *service) RejectRequest(ctx context.Context, transactionID, reason string) (Request, error) {
+	req, err := s.repo.RetrieveRequest(ctx, transactionID)
+	if err != nil {
+		return Request{}, err
+	}
+	if !req.open() {
+		return Request{}, ErrNotPending
+	}
+	status := req.Status
+	req.Status = StatusRejected
+	req.Reason = reason
+	req.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpdateRequest(ctx, req, status); err != nil {
+		return Request{}, err
+	}
+
+	return req, nil
+}
+
+// enroll handles an enrollment or renewal request with the DER encoded CSR.
+// A request that is sent again with the same transaction ID gets the state
+// of the existing request.
+func (s *service) enroll(ctx context.Context, msg message, der []byte, renewal bool) (reply, error) {
+	keyHash := hash(msg.Signer.RawSubjectPublicKeyInfo)
+	req, err := s.repo.RetrieveRequest(ctx, msg.transactionID)
+	switch {
+	case err == nil:
+		if req.KeyHash != keyHash {
+			return failure(FailBadMessageCheck), nil
+		}
+		return s.state(ctx, req)
+	case !errors.Contains(err, ErrNotFound):
+		return reply{}, err
+	}
+
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil || csr.CheckSignature() != nil {
+		return failure(FailBadRequest), nil
+	}
+	now := time.Now().UTC()
+	req = Request{
+		TransactionID: msg.transactionID,
+		Status:        StatusPending,
+		CSR:           csr.Raw,
+		Renewal:       renewal,
+		KeyHash:       keyHash,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if renewal {
+		current, err := s.renewed(ctx, msg.Signer)
+		if err != nil {
+			return failure(FailBadMessageCheck), nil
+		}
+		if csr.Subject.String() != msg.Signer.Subject.String() ||
+			!sameNames(csr.DNSNames, msg.Signer.DNSNames) ||
+			!sameNames(csr.EmailAddresses, msg.Signer.EmailAddresses) ||
+			!slices.EqualFunc(csr.IPAddresses, msg.Signer.IPAddresses, net.IP.Equal) {
+			return failure(FailBadRequest), nil
+		}
+		req.EntityID = current.EntityID
+	} else {
+		password, err := challengePassword(csr)
+		if err != nil || password == "" {
+			return failure(FailBadRequest), nil
+		}
+		challenge, err := s.repo.ConsumeChallenge(ctx, hash([]byte(password)))
+		if err != nil {
+			if errors.Contains(err, ErrInvalidChallenge) {
+				return failure(FailBadRequest), nil
+			}
+			return reply{}, err
+		}
+		req.EntityID = challenge.EntityID
+	}
+	if err := s.repo.CreateRequest(ctx, req); err != nil {
+		return reply{}, err
+	}
+	if s.config.ManualApproval && !renewal {
+		return pending(), nil
+	}
+	if req, err = s.issue(ctx, req); err != nil {
+		return reply{}, err
+	}
+
+	return s.state(ctx, req)
+}
+
+// poll returns the state of the request of a GetCertInitial message.
+func (s *service) poll(ctx context.Context, msg message) (reply, error) {
+	req, err := s.repo.RetrieveRequest(ctx, msg.transactionID)
+	if err != nil {
+		if errors.Contains(err, ErrNotFound) {
+			return failure(FailBadCertID), nil
+		}
+		return reply{}, err
+	}
+	if req.KeyHash != hash(msg.Signer.RawSubjectPublicKeyInfo) {
+		return failure(FailBadMessageCheck), nil
+	}
+
+	return s.state(ctx, req)
+}
+
+// state returns the reply of the request's current state. A request left
+// issuing by a failed request, which was approved already, is issued again.
+func (s *service) state(ctx context.Context, req Request) (reply, error) {
+	if req.Status == StatusIssuing && req.open() {
+		issued, err := s.issue(ctx, req)
+		switch {
+		case errors.Contains(err, ErrNotPending):
+			return pending(), nil
+		case err != nil:
+			return reply{}, err
+		}
+		req = issued
+	}
+	switch req.Status {
+	case StatusPending, StatusIssuing:
+		return pending(), nil
+	case StatusRejected:
+		return failure(FailBadRequest), nil
+	}
+	cert, err := s.certs.ViewCert(ctx, req.CertSerial)
+	if err != nil {
+		return reply{}, err
+	}
+	x509Cert, err := parseCert(cert.Certificate)
+	if err != nil {
+		return reply{}, err
+	}
+
+	return success(x509Cert), nil
+}
+
+// issue moves the pending or stale issuing request to issuing and issues its
+// certificate. Only one approval can move the request out of its status, so
+// concurrent or retried approvals issue a single certificate. Requests the
+// issuer refuses, e.g. for violating the profile, are rejected with the
+// reason, and requests that fail otherwise are pending again.
+func (s *service) issue(ctx context.Context, req Request) (Request, error) {
+	status := req.Status
+	req.Status = StatusIssuing
+	req.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpdateRequest(ctx, req, status); err != nil {
+		return Request{}, err
+	}
+
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: req.CSR})
+	cert, err := s.certs.IssueFromCSR(ctx, req.EntityID, s.config.Issuer, s.config.Profile, s.config.TTL, certs.CSR{CSR: csrPEM})
+	switch {
+	case err == nil:
+		req.Status = StatusIssued
+		req.CertSerial = cert.SerialNumber
+	case errors.Contains(err, certs.ErrPolicyViolation),
+		errors.Contains(err, certs.ErrMalformedEntity):
+		req.Status = StatusRejected
+		req.Reason = err.Error()
+	default:
+		req.Status = StatusPending
+		req.UpdatedAt = time.Now().UTC()
+		if uerr := s.repo.UpdateRequest(ctx, req, StatusIssuing); uerr != nil {
+			return Request{}, errors.Wrap(err, uerr)
+		}
+		return Request{}, err
+	}
+	req.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpdateRequest(ctx, req, StatusIssuing); err != nil {
+		return Request{}, err
+	}
+
+	return req, nil
+}
+
+// renewed returns the certificate a renewal is signed with, which must be an
+// unexpired and unrevoked one issued by the service.
+func (s *service) renewed(ctx context.Context, signer *x509.Certificate) (certs.Certificate, error) {
+	cert, err := s.certs.ViewCert(ctx, signer.SerialNumber.String())
+	if err != nil {
+		return certs.Certificate{}, errors.Wrap(errCertificateRequired, err)
+	}
+	block, _ := pem.Decode(cert.Certificate)
+	switch {
+	case block == nil, string(block.Bytes) != string(signer.Raw):
+		return certs.Certificate{}, errCertificateRequired
+	case cert.Revoked:
+		return certs.Certificate{}, certs.ErrCertRevoked
+	case time.Now().After(signer.NotAfter):
+		return certs.Certificate{}, certs.ErrCertExpired
+	}
+
+	return cert, nil
+}
+
+// registrationAuthority returns the current registration authority. It
+// reuses a valid one issued before and issues a new one when there is none
+// or it is about to expire.
+func (s *service) registrationAuthority(ctx context.Context) (registrationAuthority, error) {
+	s.raMu.Lock()
+	defer s.raMu.Unlock()
+
+	if s.ra != nil && time.Until(s.ra.cert.NotAfter) > raRenewBefore {
+		return *s.ra, nil
+	}
+	chain, err := s.chain(ctx)
+	if err != nil {
+		return registrationAuthority{}, err
+	}
+
+	page, err := s.certs.ListCerts(ctx, certs.PageMetadata{EntityID: raEntityID, Limit: raListLimit})
+	if err != nil {
+		return registrationAuthority{}, err
+	}
+	var ra *registrationAuthority
+	for _, c := range page.Certificates {
+		if c.Revoked || time.Until(c.ExpiryTime) <= raRenewBefore {
+			continue
+		}
+		cert, err := s.certs.ViewCert(ctx, c.SerialNumber)
+		if err != nil {
+			return registrationAuthority{}, err
+		}
+		// Certificates of a previous issuer or CA are not reused.
+		candidate, err := parseRA(cert, chain)
+		if err != nil {
+			continue
+		}
+		if ra == nil || candidate.cert.NotAfter.After(ra.cert.NotAfter) {
+			ra = &candidate
+		}
+	}
+	if ra == nil {
+		issued, err := s.certs.IssueCert(ctx, raEntityID, s.config.Issuer, "", s.config.RATTL, nil, certs.SubjectOptions{CommonName: raCommonName})
+		if err != nil {
+			return registrationAuthority{}, err
+		}
+		// The issued certificate is returned without its key.
+		cert, err := s.certs.ViewCert(ctx, issued.SerialNumber)
+		if err != nil {
+			return registrationAuthority{}, err
+		}
+		candidate, err := parseRA(cert, chain)
+		if err != nil {
+			return registrationAuthority{}, err
+		}
+		ra = &candidate
+	}
+	s.ra = ra
+
+	return *ra, nil
+}
+
+// chain returns the certificates of the issuer's chain, the issuing CA first.
+func (s *service) chain(ctx context.Context) ([]*x509.Certificate, error) {
+	chain, err := s.certs.ViewCAChain(ctx, s.config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	var cas []*x509.Certificate
+	for rest := chain.Certificate; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		cas = append(cas, ca)
+	}
+	if len(cas) == 0 {
+		return nil, certs.ErrIntermediateCANotFound
+	}
+
+	return cas, nil
+}
+
+// parseRA parses the registration authority certificate, which must be
+// issued by the first certificate of the chain.
+func parseRA(cert certs.Certificate, chain []*x509.Certificate) (registrationAuthority, error) {
+	x509Cert, err := parseCert(cert.Certificate)
+	if err != nil {
+		return registrationAuthority{}, err
+	}
+	if err := x509Cert.CheckSignatureFrom(chain[0]); err != nil {
+		return registrationAuthority{}, err
+	}
+	signer, err := certs.ParsePrivateKey(cert.Key)
+	if err != nil {
+		return registrationAuthority{}, err
+	}
+	key, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		return registrationAuthority{}, errRAKeyAlgorithm
+	}
+
+	return registrationAuthority{cert: x509Cert, key: key, chain: chain}, nil
+}
+
+func parseCert(pemCert []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(pemCert)
+	if block == nil {
+		return nil, errors.New("failed to decode certificate")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// open reports whether the request can still be approved or rejected. An
+// issuing request can once its issuance, which its update time records, timed
+// out.
+func (req Request) open() bool {
+	switch req.Status {
+	case StatusPending:
+		return true
+	case StatusIssuing:
+		return time.Since(req.UpdatedAt) > issuingTimeout
+	default:
+		return false
+	}
+}
+
+func hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// sameNames reports whether the names are the same regardless of their order.
+func sameNames(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(a, b)
+}
@@ -7,6 +7,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	sdk "github.com/hantdev/certs/sdk"
+
+	time "time"
 )
 
 // MockSDK is an autogenerated mock type for the SDK type
@@ -22,6 +24,300 @@ func (_m *MockSDK) EXPECT() *MockSDK_Expecter {
 	return &MockSDK_Expecter{mock: &_m.Mock}
 }
 
+// ApproveCSR provides a mock function with given fields: id, ttl, profile
+func (_m *MockSDK) ApproveCSR(id string, ttl string, profile string) (sdk.SubmittedCSR, errors.SDKError) {
+	ret := _m.Called(id, ttl, profile)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApproveCSR")
+	}
+
+	var r0 sdk.SubmittedCSR
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string, string, string) (sdk.SubmittedCSR, errors.SDKError)); ok {
+		return rf(id, ttl, profile)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string) sdk.SubmittedCSR); ok {
+		r0 = rf(id, ttl, profile)
+	} else {
+		r0 = ret.Get(0).(sdk.SubmittedCSR)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string) errors.SDKError); ok {
+		r1 = rf(id, ttl, profile)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ApproveCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApproveCSR'
+type MockSDK_ApproveCSR_Call struct {
+	*mock.Call
+}
+
+// ApproveCSR is a helper method to define mock.On call
+//   - id string
+//   - ttl string
+//   - profile string
+func (_e *MockSDK_Expecter) ApproveCSR(id interface{}, ttl interface{}, profile interface{}) *MockSDK_ApproveCSR_Call {
+	return &MockSDK_ApproveCSR_Call{Call: _e.mock.On("ApproveCSR", id, ttl, profile)}
+}
+
+func (_c *MockSDK_ApproveCSR_Call) Run(run func(id string, ttl string, profile string)) *MockSDK_ApproveCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ApproveCSR_Call) Return(_a0 sdk.SubmittedCSR, _a1 errors.SDKError) *MockSDK_ApproveCSR_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ApproveCSR_Call) RunAndReturn(run func(string, string, string) (sdk.SubmittedCSR, errors.SDKError)) *MockSDK_ApproveCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CAChain provides a mock function with given fields: issuer
+func (_m *MockSDK) CAChain(issuer string) ([]byte, errors.SDKError) {
+	ret := _m.Called(issuer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CAChain")
+	}
+
+	var r0 []byte
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) ([]byte, errors.SDKError)); ok {
+		return rf(issuer)
+	}
+	if rf, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = rf(issuer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
+		r1 = rf(issuer)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_CAChain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CAChain'
+type MockSDK_CAChain_Call struct {
+	*mock.Call
+}
+
+// CAChain is a helper method to define mock.On call
+//   - issuer string
+func (_e *MockSDK_Expecter) CAChain(issuer interface{}) *MockSDK_CAChain_Call {
+	return &MockSDK_CAChain_Call{Call: _e.mock.On("CAChain", issuer)}
+}
+
+func (_c *MockSDK_CAChain_Call) Run(run func(issuer string)) *MockSDK_CAChain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_CAChain_Call) Return(_a0 []byte, _a1 errors.SDKError) *MockSDK_CAChain_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_CAChain_Call) RunAndReturn(run func(string) ([]byte, errors.SDKError)) *MockSDK_CAChain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateIssuer provides a mock function with given fields: name
+func (_m *MockSDK) CreateIssuer(name string) (sdk.Issuer, errors.SDKError) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateIssuer")
+	}
+
+	var r0 sdk.Issuer
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) (sdk.Issuer, errors.SDKError)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) sdk.Issuer); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(sdk.Issuer)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
+		r1 = rf(name)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_CreateIssuer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateIssuer'
+type MockSDK_CreateIssuer_Call struct {
+	*mock.Call
+}
+
+// CreateIssuer is a helper method to define mock.On call
+//   - name string
+func (_e *MockSDK_Expecter) CreateIssuer(name interface{}) *MockSDK_CreateIssuer_Call {
+	return &MockSDK_CreateIssuer_Call{Call: _e.mock.On("CreateIssuer", name)}
+}
+
+func (_c *MockSDK_CreateIssuer_Call) Run(run func(name string)) *MockSDK_CreateIssuer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_CreateIssuer_Call) Return(_a0 sdk.Issuer, _a1 errors.SDKError) *MockSDK_CreateIssuer_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_CreateIssuer_Call) RunAndReturn(run func(string) (sdk.Issuer, errors.SDKError)) *MockSDK_CreateIssuer_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateProfile provides a mock function with given fields: profile
+func (_m *MockSDK) CreateProfile(profile sdk.Profile) (sdk.Profile, errors.SDKError) {
+	ret := _m.Called(profile)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateProfile")
+	}
+
+	var r0 sdk.Profile
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(sdk.Profile) (sdk.Profile, errors.SDKError)); ok {
+		return rf(profile)
+	}
+	if rf, ok := ret.Get(0).(func(sdk.Profile) sdk.Profile); ok {
+		r0 = rf(profile)
+	} else {
+		r0 = ret.Get(0).(sdk.Profile)
+	}
+
+	if rf, ok := ret.Get(1).(func(sdk.Profile) errors.SDKError); ok {
+		r1 = rf(profile)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_CreateProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateProfile'
+type MockSDK_CreateProfile_Call struct {
+	*mock.Call
+}
+
+// CreateProfile is a helper method to define mock.On call
+//   - profile sdk.Profile
+func (_e *MockSDK_Expecter) CreateProfile(profile interface{}) *MockSDK_CreateProfile_Call {
+	return &MockSDK_CreateProfile_Call{Call: _e.mock.On("CreateProfile", profile)}
+}
+
+func (_c *MockSDK_CreateProfile_Call) Run(run func(profile sdk.Profile)) *MockSDK_CreateProfile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(sdk.Profile))
+	})
+	return _c
+}
+
+func (_c *MockSDK_CreateProfile_Call) Return(_a0 sdk.Profile, _a1 errors.SDKError) *MockSDK_CreateProfile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_CreateProfile_Call) RunAndReturn(run func(sdk.Profile) (sdk.Profile, errors.SDKError)) *MockSDK_CreateProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateWebhook provides a mock function with given fields: sub
+func (_m *MockSDK) CreateWebhook(sub sdk.WebhookSubscription) (sdk.WebhookSubscription, errors.SDKError) {
+	ret := _m.Called(sub)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhook")
+	}
+
+	var r0 sdk.WebhookSubscription
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(sdk.WebhookSubscription) (sdk.WebhookSubscription, errors.SDKError)); ok {
+		return rf(sub)
+	}
+	if rf, ok := ret.Get(0).(func(sdk.WebhookSubscription) sdk.WebhookSubscription); ok {
+		r0 = rf(sub)
+	} else {
+		r0 = ret.Get(0).(sdk.WebhookSubscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(sdk.WebhookSubscription) errors.SDKError); ok {
+		r1 = rf(sub)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_CreateWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWebhook'
+type MockSDK_CreateWebhook_Call struct {
+	*mock.Call
+}
+
+// CreateWebhook is a helper method to define mock.On call
+//   - sub sdk.WebhookSubscription
+func (_e *MockSDK_Expecter) CreateWebhook(sub interface{}) *MockSDK_CreateWebhook_Call {
+	return &MockSDK_CreateWebhook_Call{Call: _e.mock.On("CreateWebhook", sub)}
+}
+
+func (_c *MockSDK_CreateWebhook_Call) Run(run func(sub sdk.WebhookSubscription)) *MockSDK_CreateWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(sdk.WebhookSubscription))
+	})
+	return _c
+}
+
+func (_c *MockSDK_CreateWebhook_Call) Return(_a0 sdk.WebhookSubscription, _a1 errors.SDKError) *MockSDK_CreateWebhook_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_CreateWebhook_Call) RunAndReturn(run func(sdk.WebhookSubscription) (sdk.WebhookSubscription, errors.SDKError)) *MockSDK_CreateWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DeleteCert provides a mock function with given fields: entityID
 func (_m *MockSDK) DeleteCert(entityID string) errors.SDKError {
 	ret := _m.Called(entityID)
@@ -187,23 +483,84 @@ func (_c *MockSDK_DownloadCert_Call) RunAndReturn(run func(string, string) (sdk.
 	return _c
 }
 
-// GetCAToken provides a mock function with no fields
-func (_m *MockSDK) GetCAToken() (sdk.Token, errors.SDKError) {
+// ExportCA provides a mock function with given fields: serialNumber, passphrase
+func (_m *MockSDK) ExportCA(serialNumber string, passphrase string) ([]byte, errors.SDKError) {
+	ret := _m.Called(serialNumber, passphrase)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportCA")
+	}
+
+	var r0 []byte
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string, string) ([]byte, errors.SDKError)); ok {
+		return rf(serialNumber, passphrase)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []byte); ok {
+		r0 = rf(serialNumber, passphrase)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) errors.SDKError); ok {
+		r1 = rf(serialNumber, passphrase)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ExportCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExportCA'
+type MockSDK_ExportCA_Call struct {
+	*mock.Call
+}
+
+// ExportCA is a helper method to define mock.On call
+//   - serialNumber string
+//   - passphrase string
+func (_e *MockSDK_Expecter) ExportCA(serialNumber interface{}, passphrase interface{}) *MockSDK_ExportCA_Call {
+	return &MockSDK_ExportCA_Call{Call: _e.mock.On("ExportCA", serialNumber, passphrase)}
+}
+
+func (_c *MockSDK_ExportCA_Call) Run(run func(serialNumber string, passphrase string)) *MockSDK_ExportCA_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ExportCA_Call) Return(_a0 []byte, _a1 errors.SDKError) *MockSDK_ExportCA_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ExportCA_Call) RunAndReturn(run func(string, string) ([]byte, errors.SDKError)) *MockSDK_ExportCA_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GenerateIntermediateCSR provides a mock function with no fields
+func (_m *MockSDK) GenerateIntermediateCSR() (sdk.CSR, errors.SDKError) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetCAToken")
+		panic("no return value specified for GenerateIntermediateCSR")
 	}
 
-	var r0 sdk.Token
+	var r0 sdk.CSR
 	var r1 errors.SDKError
-	if rf, ok := ret.Get(0).(func() (sdk.Token, errors.SDKError)); ok {
+	if rf, ok := ret.Get(0).(func() (sdk.CSR, errors.SDKError)); ok {
 		return rf()
 	}
-	if rf, ok := ret.Get(0).(func() sdk.Token); ok {
+	if rf, ok := ret.Get(0).(func() sdk.CSR); ok {
 		r0 = rf()
 	} else {
-		r0 = ret.Get(0).(sdk.Token)
+		r0 = ret.Get(0).(sdk.CSR)
 	}
 
 	if rf, ok := ret.Get(1).(func() errors.SDKError); ok {
@@ -217,54 +574,1334 @@ func (_m *MockSDK) GetCAToken() (sdk.Token, errors.SDKError) {
 	return r0, r1
 }
 
-// MockSDK_GetCAToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCAToken'
-type MockSDK_GetCAToken_Call struct {
+// MockSDK_GenerateIntermediateCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GenerateIntermediateCSR'
+type MockSDK_GenerateIntermediateCSR_Call struct {
+	*mock.Call
+}
+
+// GenerateIntermediateCSR is a helper method to define mock.On call
+func (_e *MockSDK_Expecter) GenerateIntermediateCSR() *MockSDK_GenerateIntermediateCSR_Call {
+	return &MockSDK_GenerateIntermediateCSR_Call{Call: _e.mock.On("GenerateIntermediateCSR")}
+}
+
+func (_c *MockSDK_GenerateIntermediateCSR_Call) Run(run func()) *MockSDK_GenerateIntermediateCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSDK_GenerateIntermediateCSR_Call) Return(_a0 sdk.CSR, _a1 errors.SDKError) *MockSDK_GenerateIntermediateCSR_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_GenerateIntermediateCSR_Call) RunAndReturn(run func() (sdk.CSR, errors.SDKError)) *MockSDK_GenerateIntermediateCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCAToken provides a mock function with given fields: issuer
+func (_m *MockSDK) GetCAToken(issuer string) (sdk.Token, errors.SDKError) {
+	ret := _m.Called(issuer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCAToken")
+	}
+
+	var r0 sdk.Token
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) (sdk.Token, errors.SDKError)); ok {
+		return rf(issuer)
+	}
+	if rf, ok := ret.Get(0).(func(string) sdk.Token); ok {
+		r0 = rf(issuer)
+	} else {
+		r0 = ret.Get(0).(sdk.Token)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
+		r1 = rf(issuer)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_GetCAToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCAToken'
+type MockSDK_GetCAToken_Call struct {
+	*mock.Call
+}
+
+// GetCAToken is a helper method to define mock.On call
+//   - issuer string
+func (_e *MockSDK_Expecter) GetCAToken(issuer interface{}) *MockSDK_GetCAToken_Call {
+	return &MockSDK_GetCAToken_Call{Call: _e.mock.On("GetCAToken", issuer)}
+}
+
+func (_c *MockSDK_GetCAToken_Call) Run(run func(issuer string)) *MockSDK_GetCAToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_GetCAToken_Call) Return(_a0 sdk.Token, _a1 errors.SDKError) *MockSDK_GetCAToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_GetCAToken_Call) RunAndReturn(run func(string) (sdk.Token, errors.SDKError)) *MockSDK_GetCAToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HoldCert provides a mock function with given fields: serialNumber
+func (_m *MockSDK) HoldCert(serialNumber string) errors.SDKError {
+	ret := _m.Called(serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HoldCert")
+	}
+
+	var r0 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) errors.SDKError); ok {
+		r0 = rf(serialNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(errors.SDKError)
+		}
+	}
+
+	return r0
+}
+
+// MockSDK_HoldCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HoldCert'
+type MockSDK_HoldCert_Call struct {
+	*mock.Call
+}
+
+// HoldCert is a helper method to define mock.On call
+//   - serialNumber string
+func (_e *MockSDK_Expecter) HoldCert(serialNumber interface{}) *MockSDK_HoldCert_Call {
+	return &MockSDK_HoldCert_Call{Call: _e.mock.On("HoldCert", serialNumber)}
+}
+
+func (_c *MockSDK_HoldCert_Call) Run(run func(serialNumber string)) *MockSDK_HoldCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_HoldCert_Call) Return(_a0 errors.SDKError) *MockSDK_HoldCert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSDK_HoldCert_Call) RunAndReturn(run func(string) errors.SDKError) *MockSDK_HoldCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportCA provides a mock function with given fields: caType, cert, key, keyRef
+func (_m *MockSDK) ImportCA(caType string, cert string, key string, keyRef string) (sdk.Certificate, errors.SDKError) {
+	ret := _m.Called(caType, cert, key, keyRef)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportCA")
+	}
+
+	var r0 sdk.Certificate
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string, string, string, string) (sdk.Certificate, errors.SDKError)); ok {
+		return rf(caType, cert, key, keyRef)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string, string) sdk.Certificate); ok {
+		r0 = rf(caType, cert, key, keyRef)
+	} else {
+		r0 = ret.Get(0).(sdk.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string, string) errors.SDKError); ok {
+		r1 = rf(caType, cert, key, keyRef)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ImportCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportCA'
+type MockSDK_ImportCA_Call struct {
+	*mock.Call
+}
+
+// ImportCA is a helper method to define mock.On call
+//   - caType string
+//   - cert string
+//   - key string
+//   - keyRef string
+func (_e *MockSDK_Expecter) ImportCA(caType interface{}, cert interface{}, key interface{}, keyRef interface{}) *MockSDK_ImportCA_Call {
+	return &MockSDK_ImportCA_Call{Call: _e.mock.On("ImportCA", caType, cert, key, keyRef)}
+}
+
+func (_c *MockSDK_ImportCA_Call) Run(run func(caType string, cert string, key string, keyRef string)) *MockSDK_ImportCA_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ImportCA_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_ImportCA_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ImportCA_Call) RunAndReturn(run func(string, string, string, string) (sdk.Certificate, errors.SDKError)) *MockSDK_ImportCA_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InstallIntermediateCA provides a mock function with given fields: cert
+func (_m *MockSDK) InstallIntermediateCA(cert string) (sdk.Certificate, errors.SDKError) {
+	ret := _m.Called(cert)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InstallIntermediateCA")
+	}
+
+	var r0 sdk.Certificate
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) (sdk.Certificate, errors.SDKError)); ok {
+		return rf(cert)
+	}
+	if rf, ok := ret.Get(0).(func(string) sdk.Certificate); ok {
+		r0 = rf(cert)
+	} else {
+		r0 = ret.Get(0).(sdk.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
+		r1 = rf(cert)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_InstallIntermediateCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InstallIntermediateCA'
+type MockSDK_InstallIntermediateCA_Call struct {
+	*mock.Call
+}
+
+// InstallIntermediateCA is a helper method to define mock.On call
+//   - cert string
+func (_e *MockSDK_Expecter) InstallIntermediateCA(cert interface{}) *MockSDK_InstallIntermediateCA_Call {
+	return &MockSDK_InstallIntermediateCA_Call{Call: _e.mock.On("InstallIntermediateCA", cert)}
+}
+
+func (_c *MockSDK_InstallIntermediateCA_Call) Run(run func(cert string)) *MockSDK_InstallIntermediateCA_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_InstallIntermediateCA_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_InstallIntermediateCA_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_InstallIntermediateCA_Call) RunAndReturn(run func(string) (sdk.Certificate, errors.SDKError)) *MockSDK_InstallIntermediateCA_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IssueCert provides a mock function with given fields: entityID, ttl, ipAddrs, opts
+func (_m *MockSDK) IssueCert(entityID string, ttl string, ipAddrs []string, opts sdk.Options) (sdk.Certificate, errors.SDKError) {
+	ret := _m.Called(entityID, ttl, ipAddrs, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueCert")
+	}
+
+	var r0 sdk.Certificate
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string, string, []string, sdk.Options) (sdk.Certificate, errors.SDKError)); ok {
+		return rf(entityID, ttl, ipAddrs, opts)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, []string, sdk.Options) sdk.Certificate); ok {
+		r0 = rf(entityID, ttl, ipAddrs, opts)
+	} else {
+		r0 = ret.Get(0).(sdk.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, []string, sdk.Options) errors.SDKError); ok {
+		r1 = rf(entityID, ttl, ipAddrs, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_IssueCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IssueCert'
+type MockSDK_IssueCert_Call struct {
+	*mock.Call
+}
+
+// IssueCert is a helper method to define mock.On call
+//   - entityID string
+//   - ttl string
+//   - ipAddrs []string
+//   - opts sdk.Options
+func (_e *MockSDK_Expecter) IssueCert(entityID interface{}, ttl interface{}, ipAddrs interface{}, opts interface{}) *MockSDK_IssueCert_Call {
+	return &MockSDK_IssueCert_Call{Call: _e.mock.On("IssueCert", entityID, ttl, ipAddrs, opts)}
+}
+
+func (_c *MockSDK_IssueCert_Call) Run(run func(entityID string, ttl string, ipAddrs []string, opts sdk.Options)) *MockSDK_IssueCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].([]string), args[3].(sdk.Options))
+	})
+	return _c
+}
+
+func (_c *MockSDK_IssueCert_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_IssueCert_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_IssueCert_Call) RunAndReturn(run func(string, string, []string, sdk.Options) (sdk.Certificate, errors.SDKError)) *MockSDK_IssueCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IssueFromCSR provides a mock function with given fields: entityID, ttl, issuer, profile, csr
+func (_m *MockSDK) IssueFromCSR(entityID string, ttl string, issuer string, profile string, csr string) (sdk.Certificate, errors.SDKError) {
+	ret := _m.Called(entityID, ttl, issuer, profile, csr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueFromCSR")
+	}
+
+	var r0 sdk.Certificate
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string, string, string, string, string) (sdk.Certificate, errors.SDKError)); ok {
+		return rf(entityID, ttl, issuer, profile, csr)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string, string, string) sdk.Certificate); ok {
+		r0 = rf(entityID, ttl, issuer, profile, csr)
+	} else {
+		r0 = ret.Get(0).(sdk.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string, string, string) errors.SDKError); ok {
+		r1 = rf(entityID, ttl, issuer, profile, csr)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_IssueFromCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IssueFromCSR'
+type MockSDK_IssueFromCSR_Call struct {
+	*mock.Call
+}
+
+// IssueFromCSR is a helper method to define mock.On call
+//   - entityID string
+//   - ttl string
+//   - issuer string
+//   - profile string
+//   - csr string
+func (_e *MockSDK_Expecter) IssueFromCSR(entityID interface{}, ttl interface{}, issuer interface{}, profile interface{}, csr interface{}) *MockSDK_IssueFromCSR_Call {
+	return &MockSDK_IssueFromCSR_Call{Call: _e.mock.On("IssueFromCSR", entityID, ttl, issuer, profile, csr)}
+}
+
+func (_c *MockSDK_IssueFromCSR_Call) Run(run func(entityID string, ttl string, issuer string, profile string, csr string)) *MockSDK_IssueFromCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(string), args[4].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_IssueFromCSR_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_IssueFromCSR_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_IssueFromCSR_Call) RunAndReturn(run func(string, string, string, string, string) (sdk.Certificate, errors.SDKError)) *MockSDK_IssueFromCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAuditEvents provides a mock function with given fields: filter
+func (_m *MockSDK) ListAuditEvents(filter sdk.AuditFilter) (sdk.AuditEventsPage, errors.SDKError) {
+	ret := _m.Called(filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuditEvents")
+	}
+
+	var r0 sdk.AuditEventsPage
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(sdk.AuditFilter) (sdk.AuditEventsPage, errors.SDKError)); ok {
+		return rf(filter)
+	}
+	if rf, ok := ret.Get(0).(func(sdk.AuditFilter) sdk.AuditEventsPage); ok {
+		r0 = rf(filter)
+	} else {
+		r0 = ret.Get(0).(sdk.AuditEventsPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(sdk.AuditFilter) errors.SDKError); ok {
+		r1 = rf(filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ListAuditEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAuditEvents'
+type MockSDK_ListAuditEvents_Call struct {
+	*mock.Call
+}
+
+// ListAuditEvents is a helper method to define mock.On call
+//   - filter sdk.AuditFilter
+func (_e *MockSDK_Expecter) ListAuditEvents(filter interface{}) *MockSDK_ListAuditEvents_Call {
+	return &MockSDK_ListAuditEvents_Call{Call: _e.mock.On("ListAuditEvents", filter)}
+}
+
+func (_c *MockSDK_ListAuditEvents_Call) Run(run func(filter sdk.AuditFilter)) *MockSDK_ListAuditEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(sdk.AuditFilter))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ListAuditEvents_Call) Return(_a0 sdk.AuditEventsPage, _a1 errors.SDKError) *MockSDK_ListAuditEvents_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ListAuditEvents_Call) RunAndReturn(run func(sdk.AuditFilter) (sdk.AuditEventsPage, errors.SDKError)) *MockSDK_ListAuditEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListCSRs provides a mock function with given fields: pm
+func (_m *MockSDK) ListCSRs(pm sdk.PageMetadata) (sdk.CSRPage, errors.SDKError) {
+	ret := _m.Called(pm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCSRs")
+	}
+
+	var r0 sdk.CSRPage
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(sdk.PageMetadata) (sdk.CSRPage, errors.SDKError)); ok {
+		return rf(pm)
+	}
+	if rf, ok := ret.Get(0).(func(sdk.PageMetadata) sdk.CSRPage); ok {
+		r0 = rf(pm)
+	} else {
+		r0 = ret.Get(0).(sdk.CSRPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(sdk.PageMetadata) errors.SDKError); ok {
+		r1 = rf(pm)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ListCSRs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListCSRs'
+type MockSDK_ListCSRs_Call struct {
+	*mock.Call
+}
+
+// ListCSRs is a helper method to define mock.On call
+//   - pm sdk.PageMetadata
+func (_e *MockSDK_Expecter) ListCSRs(pm interface{}) *MockSDK_ListCSRs_Call {
+	return &MockSDK_ListCSRs_Call{Call: _e.mock.On("ListCSRs", pm)}
+}
+
+func (_c *MockSDK_ListCSRs_Call) Run(run func(pm sdk.PageMetadata)) *MockSDK_ListCSRs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(sdk.PageMetadata))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ListCSRs_Call) Return(_a0 sdk.CSRPage, _a1 errors.SDKError) *MockSDK_ListCSRs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ListCSRs_Call) RunAndReturn(run func(sdk.PageMetadata) (sdk.CSRPage, errors.SDKError)) *MockSDK_ListCSRs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListCerts provides a mock function with given fields: pm
+func (_m *MockSDK) ListCerts(pm sdk.PageMetadata) (sdk.CertificatePage, errors.SDKError) {
+	ret := _m.Called(pm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCerts")
+	}
+
+	var r0 sdk.CertificatePage
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(sdk.PageMetadata) (sdk.CertificatePage, errors.SDKError)); ok {
+		return rf(pm)
+	}
+	if rf, ok := ret.Get(0).(func(sdk.PageMetadata) sdk.CertificatePage); ok {
+		r0 = rf(pm)
+	} else {
+		r0 = ret.Get(0).(sdk.CertificatePage)
+	}
+
+	if rf, ok := ret.Get(1).(func(sdk.PageMetadata) errors.SDKError); ok {
+		r1 = rf(pm)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ListCerts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListCerts'
+type MockSDK_ListCerts_Call struct {
+	*mock.Call
+}
+
+// ListCerts is a helper method to define mock.On call
+//   - pm sdk.PageMetadata
+func (_e *MockSDK_Expecter) ListCerts(pm interface{}) *MockSDK_ListCerts_Call {
+	return &MockSDK_ListCerts_Call{Call: _e.mock.On("ListCerts", pm)}
+}
+
+func (_c *MockSDK_ListCerts_Call) Run(run func(pm sdk.PageMetadata)) *MockSDK_ListCerts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(sdk.PageMetadata))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ListCerts_Call) Return(_a0 sdk.CertificatePage, _a1 errors.SDKError) *MockSDK_ListCerts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ListCerts_Call) RunAndReturn(run func(sdk.PageMetadata) (sdk.CertificatePage, errors.SDKError)) *MockSDK_ListCerts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListIssuers provides a mock function with no fields
+func (_m *MockSDK) ListIssuers() ([]sdk.Issuer, errors.SDKError) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListIssuers")
+	}
+
+	var r0 []sdk.Issuer
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func() ([]sdk.Issuer, errors.SDKError)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []sdk.Issuer); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sdk.Issuer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() errors.SDKError); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ListIssuers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListIssuers'
+type MockSDK_ListIssuers_Call struct {
+	*mock.Call
+}
+
+// ListIssuers is a helper method to define mock.On call
+func (_e *MockSDK_Expecter) ListIssuers() *MockSDK_ListIssuers_Call {
+	return &MockSDK_ListIssuers_Call{Call: _e.mock.On("ListIssuers")}
+}
+
+func (_c *MockSDK_ListIssuers_Call) Run(run func()) *MockSDK_ListIssuers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSDK_ListIssuers_Call) Return(_a0 []sdk.Issuer, _a1 errors.SDKError) *MockSDK_ListIssuers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ListIssuers_Call) RunAndReturn(run func() ([]sdk.Issuer, errors.SDKError)) *MockSDK_ListIssuers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListProfiles provides a mock function with no fields
+func (_m *MockSDK) ListProfiles() ([]sdk.Profile, errors.SDKError) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListProfiles")
+	}
+
+	var r0 []sdk.Profile
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func() ([]sdk.Profile, errors.SDKError)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []sdk.Profile); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sdk.Profile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() errors.SDKError); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ListProfiles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProfiles'
+type MockSDK_ListProfiles_Call struct {
+	*mock.Call
+}
+
+// ListProfiles is a helper method to define mock.On call
+func (_e *MockSDK_Expecter) ListProfiles() *MockSDK_ListProfiles_Call {
+	return &MockSDK_ListProfiles_Call{Call: _e.mock.On("ListProfiles")}
+}
+
+func (_c *MockSDK_ListProfiles_Call) Run(run func()) *MockSDK_ListProfiles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSDK_ListProfiles_Call) Return(_a0 []sdk.Profile, _a1 errors.SDKError) *MockSDK_ListProfiles_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ListProfiles_Call) RunAndReturn(run func() ([]sdk.Profile, errors.SDKError)) *MockSDK_ListProfiles_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListWebhookDeliveries provides a mock function with given fields: filter
+func (_m *MockSDK) ListWebhookDeliveries(filter sdk.WebhookDeliveryFilter) (sdk.WebhookDeliveriesPage, errors.SDKError) {
+	ret := _m.Called(filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhookDeliveries")
+	}
+
+	var r0 sdk.WebhookDeliveriesPage
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(sdk.WebhookDeliveryFilter) (sdk.WebhookDeliveriesPage, errors.SDKError)); ok {
+		return rf(filter)
+	}
+	if rf, ok := ret.Get(0).(func(sdk.WebhookDeliveryFilter) sdk.WebhookDeliveriesPage); ok {
+		r0 = rf(filter)
+	} else {
+		r0 = ret.Get(0).(sdk.WebhookDeliveriesPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(sdk.WebhookDeliveryFilter) errors.SDKError); ok {
+		r1 = rf(filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ListWebhookDeliveries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListWebhookDeliveries'
+type MockSDK_ListWebhookDeliveries_Call struct {
+	*mock.Call
+}
+
+// ListWebhookDeliveries is a helper method to define mock.On call
+//   - filter sdk.WebhookDeliveryFilter
+func (_e *MockSDK_Expecter) ListWebhookDeliveries(filter interface{}) *MockSDK_ListWebhookDeliveries_Call {
+	return &MockSDK_ListWebhookDeliveries_Call{Call: _e.mock.On("ListWebhookDeliveries", filter)}
+}
+
+func (_c *MockSDK_ListWebhookDeliveries_Call) Run(run func(filter sdk.WebhookDeliveryFilter)) *MockSDK_ListWebhookDeliveries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(sdk.WebhookDeliveryFilter))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ListWebhookDeliveries_Call) Return(_a0 sdk.WebhookDeliveriesPage, _a1 errors.SDKError) *MockSDK_ListWebhookDeliveries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ListWebhookDeliveries_Call) RunAndReturn(run func(sdk.WebhookDeliveryFilter) (sdk.WebhookDeliveriesPage, errors.SDKError)) *MockSDK_ListWebhookDeliveries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListWebhooks provides a mock function with given fields: pm
+func (_m *MockSDK) ListWebhooks(pm sdk.PageMetadata) (sdk.WebhookSubscriptionsPage, errors.SDKError) {
+	ret := _m.Called(pm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhooks")
+	}
+
+	var r0 sdk.WebhookSubscriptionsPage
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(sdk.PageMetadata) (sdk.WebhookSubscriptionsPage, errors.SDKError)); ok {
+		return rf(pm)
+	}
+	if rf, ok := ret.Get(0).(func(sdk.PageMetadata) sdk.WebhookSubscriptionsPage); ok {
+		r0 = rf(pm)
+	} else {
+		r0 = ret.Get(0).(sdk.WebhookSubscriptionsPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(sdk.PageMetadata) errors.SDKError); ok {
+		r1 = rf(pm)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ListWebhooks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListWebhooks'
+type MockSDK_ListWebhooks_Call struct {
+	*mock.Call
+}
+
+// ListWebhooks is a helper method to define mock.On call
+//   - pm sdk.PageMetadata
+func (_e *MockSDK_Expecter) ListWebhooks(pm interface{}) *MockSDK_ListWebhooks_Call {
+	return &MockSDK_ListWebhooks_Call{Call: _e.mock.On("ListWebhooks", pm)}
+}
+
+func (_c *MockSDK_ListWebhooks_Call) Run(run func(pm sdk.PageMetadata)) *MockSDK_ListWebhooks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(sdk.PageMetadata))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ListWebhooks_Call) Return(_a0 sdk.WebhookSubscriptionsPage, _a1 errors.SDKError) *MockSDK_ListWebhooks_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ListWebhooks_Call) RunAndReturn(run func(sdk.PageMetadata) (sdk.WebhookSubscriptionsPage, errors.SDKError)) *MockSDK_ListWebhooks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// OCSP provides a mock function with given fields: serialNumber, cert
+func (_m *MockSDK) OCSP(serialNumber string, cert string) (sdk.OCSPResponse, errors.SDKError) {
+	ret := _m.Called(serialNumber, cert)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OCSP")
+	}
+
+	var r0 sdk.OCSPResponse
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string, string) (sdk.OCSPResponse, errors.SDKError)); ok {
+		return rf(serialNumber, cert)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) sdk.OCSPResponse); ok {
+		r0 = rf(serialNumber, cert)
+	} else {
+		r0 = ret.Get(0).(sdk.OCSPResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) errors.SDKError); ok {
+		r1 = rf(serialNumber, cert)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_OCSP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'OCSP'
+type MockSDK_OCSP_Call struct {
+	*mock.Call
+}
+
+// OCSP is a helper method to define mock.On call
+//   - serialNumber string
+//   - cert string
+func (_e *MockSDK_Expecter) OCSP(serialNumber interface{}, cert interface{}) *MockSDK_OCSP_Call {
+	return &MockSDK_OCSP_Call{Call: _e.mock.On("OCSP", serialNumber, cert)}
+}
+
+func (_c *MockSDK_OCSP_Call) Run(run func(serialNumber string, cert string)) *MockSDK_OCSP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_OCSP_Call) Return(_a0 sdk.OCSPResponse, _a1 errors.SDKError) *MockSDK_OCSP_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_OCSP_Call) RunAndReturn(run func(string, string) (sdk.OCSPResponse, errors.SDKError)) *MockSDK_OCSP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RejectCSR provides a mock function with given fields: id, reason
+func (_m *MockSDK) RejectCSR(id string, reason string) (sdk.SubmittedCSR, errors.SDKError) {
+	ret := _m.Called(id, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RejectCSR")
+	}
+
+	var r0 sdk.SubmittedCSR
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string, string) (sdk.SubmittedCSR, errors.SDKError)); ok {
+		return rf(id, reason)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) sdk.SubmittedCSR); ok {
+		r0 = rf(id, reason)
+	} else {
+		r0 = ret.Get(0).(sdk.SubmittedCSR)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) errors.SDKError); ok {
+		r1 = rf(id, reason)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_RejectCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RejectCSR'
+type MockSDK_RejectCSR_Call struct {
+	*mock.Call
+}
+
+// RejectCSR is a helper method to define mock.On call
+//   - id string
+//   - reason string
+func (_e *MockSDK_Expecter) RejectCSR(id interface{}, reason interface{}) *MockSDK_RejectCSR_Call {
+	return &MockSDK_RejectCSR_Call{Call: _e.mock.On("RejectCSR", id, reason)}
+}
+
+func (_c *MockSDK_RejectCSR_Call) Run(run func(id string, reason string)) *MockSDK_RejectCSR_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_RejectCSR_Call) Return(_a0 sdk.SubmittedCSR, _a1 errors.SDKError) *MockSDK_RejectCSR_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_RejectCSR_Call) RunAndReturn(run func(string, string) (sdk.SubmittedCSR, errors.SDKError)) *MockSDK_RejectCSR_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseCert provides a mock function with given fields: serialNumber
+func (_m *MockSDK) ReleaseCert(serialNumber string) errors.SDKError {
+	ret := _m.Called(serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseCert")
+	}
+
+	var r0 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) errors.SDKError); ok {
+		r0 = rf(serialNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(errors.SDKError)
+		}
+	}
+
+	return r0
+}
+
+// MockSDK_ReleaseCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseCert'
+type MockSDK_ReleaseCert_Call struct {
+	*mock.Call
+}
+
+// ReleaseCert is a helper method to define mock.On call
+//   - serialNumber string
+func (_e *MockSDK_Expecter) ReleaseCert(serialNumber interface{}) *MockSDK_ReleaseCert_Call {
+	return &MockSDK_ReleaseCert_Call{Call: _e.mock.On("ReleaseCert", serialNumber)}
+}
+
+func (_c *MockSDK_ReleaseCert_Call) Run(run func(serialNumber string)) *MockSDK_ReleaseCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ReleaseCert_Call) Return(_a0 errors.SDKError) *MockSDK_ReleaseCert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSDK_ReleaseCert_Call) RunAndReturn(run func(string) errors.SDKError) *MockSDK_ReleaseCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveProfile provides a mock function with given fields: name
+func (_m *MockSDK) RemoveProfile(name string) errors.SDKError {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveProfile")
+	}
+
+	var r0 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) errors.SDKError); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(errors.SDKError)
+		}
+	}
+
+	return r0
+}
+
+// MockSDK_RemoveProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveProfile'
+type MockSDK_RemoveProfile_Call struct {
+	*mock.Call
+}
+
+// RemoveProfile is a helper method to define mock.On call
+//   - name string
+func (_e *MockSDK_Expecter) RemoveProfile(name interface{}) *MockSDK_RemoveProfile_Call {
+	return &MockSDK_RemoveProfile_Call{Call: _e.mock.On("RemoveProfile", name)}
+}
+
+func (_c *MockSDK_RemoveProfile_Call) Run(run func(name string)) *MockSDK_RemoveProfile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_RemoveProfile_Call) Return(_a0 errors.SDKError) *MockSDK_RemoveProfile_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSDK_RemoveProfile_Call) RunAndReturn(run func(string) errors.SDKError) *MockSDK_RemoveProfile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveWebhook provides a mock function with given fields: id
+func (_m *MockSDK) RemoveWebhook(id string) errors.SDKError {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveWebhook")
+	}
+
+	var r0 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) errors.SDKError); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(errors.SDKError)
+		}
+	}
+
+	return r0
+}
+
+// MockSDK_RemoveWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveWebhook'
+type MockSDK_RemoveWebhook_Call struct {
+	*mock.Call
+}
+
+// RemoveWebhook is a helper method to define mock.On call
+//   - id string
+func (_e *MockSDK_Expecter) RemoveWebhook(id interface{}) *MockSDK_RemoveWebhook_Call {
+	return &MockSDK_RemoveWebhook_Call{Call: _e.mock.On("RemoveWebhook", id)}
+}
+
+func (_c *MockSDK_RemoveWebhook_Call) Run(run func(id string)) *MockSDK_RemoveWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_RemoveWebhook_Call) Return(_a0 errors.SDKError) *MockSDK_RemoveWebhook_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSDK_RemoveWebhook_Call) RunAndReturn(run func(string) errors.SDKError) *MockSDK_RemoveWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RenewCert provides a mock function with given fields: serialNumber
+func (_m *MockSDK) RenewCert(serialNumber string) errors.SDKError {
+	ret := _m.Called(serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenewCert")
+	}
+
+	var r0 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) errors.SDKError); ok {
+		r0 = rf(serialNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(errors.SDKError)
+		}
+	}
+
+	return r0
+}
+
+// MockSDK_RenewCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenewCert'
+type MockSDK_RenewCert_Call struct {
+	*mock.Call
+}
+
+// RenewCert is a helper method to define mock.On call
+//   - serialNumber string
+func (_e *MockSDK_Expecter) RenewCert(serialNumber interface{}) *MockSDK_RenewCert_Call {
+	return &MockSDK_RenewCert_Call{Call: _e.mock.On("RenewCert", serialNumber)}
+}
+
+func (_c *MockSDK_RenewCert_Call) Run(run func(serialNumber string)) *MockSDK_RenewCert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_RenewCert_Call) Return(_a0 errors.SDKError) *MockSDK_RenewCert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSDK_RenewCert_Call) RunAndReturn(run func(string) errors.SDKError) *MockSDK_RenewCert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReplayWebhookDelivery provides a mock function with given fields: id
+func (_m *MockSDK) ReplayWebhookDelivery(id uint64) (sdk.WebhookDelivery, errors.SDKError) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplayWebhookDelivery")
+	}
+
+	var r0 sdk.WebhookDelivery
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(uint64) (sdk.WebhookDelivery, errors.SDKError)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(uint64) sdk.WebhookDelivery); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(sdk.WebhookDelivery)
+	}
+
+	if rf, ok := ret.Get(1).(func(uint64) errors.SDKError); ok {
+		r1 = rf(id)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_ReplayWebhookDelivery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReplayWebhookDelivery'
+type MockSDK_ReplayWebhookDelivery_Call struct {
+	*mock.Call
+}
+
+// ReplayWebhookDelivery is a helper method to define mock.On call
+//   - id uint64
+func (_e *MockSDK_Expecter) ReplayWebhookDelivery(id interface{}) *MockSDK_ReplayWebhookDelivery_Call {
+	return &MockSDK_ReplayWebhookDelivery_Call{Call: _e.mock.On("ReplayWebhookDelivery", id)}
+}
+
+func (_c *MockSDK_ReplayWebhookDelivery_Call) Run(run func(id uint64)) *MockSDK_ReplayWebhookDelivery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockSDK_ReplayWebhookDelivery_Call) Return(_a0 sdk.WebhookDelivery, _a1 errors.SDKError) *MockSDK_ReplayWebhookDelivery_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_ReplayWebhookDelivery_Call) RunAndReturn(run func(uint64) (sdk.WebhookDelivery, errors.SDKError)) *MockSDK_ReplayWebhookDelivery_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetireIssuer provides a mock function with given fields: name
+func (_m *MockSDK) RetireIssuer(name string) errors.SDKError {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetireIssuer")
+	}
+
+	var r0 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) errors.SDKError); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(errors.SDKError)
+		}
+	}
+
+	return r0
+}
+
+// MockSDK_RetireIssuer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetireIssuer'
+type MockSDK_RetireIssuer_Call struct {
+	*mock.Call
+}
+
+// RetireIssuer is a helper method to define mock.On call
+//   - name string
+func (_e *MockSDK_Expecter) RetireIssuer(name interface{}) *MockSDK_RetireIssuer_Call {
+	return &MockSDK_RetireIssuer_Call{Call: _e.mock.On("RetireIssuer", name)}
+}
+
+func (_c *MockSDK_RetireIssuer_Call) Run(run func(name string)) *MockSDK_RetireIssuer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_RetireIssuer_Call) Return(_a0 errors.SDKError) *MockSDK_RetireIssuer_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSDK_RetireIssuer_Call) RunAndReturn(run func(string) errors.SDKError) *MockSDK_RetireIssuer_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveCertDownloadToken provides a mock function with given fields: serialNumber
+func (_m *MockSDK) RetrieveCertDownloadToken(serialNumber string) (sdk.Token, errors.SDKError) {
+	ret := _m.Called(serialNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveCertDownloadToken")
+	}
+
+	var r0 sdk.Token
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) (sdk.Token, errors.SDKError)); ok {
+		return rf(serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(string) sdk.Token); ok {
+		r0 = rf(serialNumber)
+	} else {
+		r0 = ret.Get(0).(sdk.Token)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
+		r1 = rf(serialNumber)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
+		}
+	}
+
+	return r0, r1
+}
+
+// MockSDK_RetrieveCertDownloadToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCertDownloadToken'
+type MockSDK_RetrieveCertDownloadToken_Call struct {
+	*mock.Call
+}
+
+// RetrieveCertDownloadToken is a helper method to define mock.On call
+//   - serialNumber string
+func (_e *MockSDK_Expecter) RetrieveCertDownloadToken(serialNumber interface{}) *MockSDK_RetrieveCertDownloadToken_Call {
+	return &MockSDK_RetrieveCertDownloadToken_Call{Call: _e.mock.On("RetrieveCertDownloadToken", serialNumber)}
+}
+
+func (_c *MockSDK_RetrieveCertDownloadToken_Call) Run(run func(serialNumber string)) *MockSDK_RetrieveCertDownloadToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSDK_RetrieveCertDownloadToken_Call) Return(_a0 sdk.Token, _a1 errors.SDKError) *MockSDK_RetrieveCertDownloadToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSDK_RetrieveCertDownloadToken_Call) RunAndReturn(run func(string) (sdk.Token, errors.SDKError)) *MockSDK_RetrieveCertDownloadToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeCert provides a mock function with given fields: serialNumber, reason, invalidityDate
+func (_m *MockSDK) RevokeCert(serialNumber string, reason string, invalidityDate time.Time) errors.SDKError {
+	ret := _m.Called(serialNumber, reason, invalidityDate)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeCert")
+	}
+
+	var r0 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) errors.SDKError); ok {
+		r0 = rf(serialNumber, reason, invalidityDate)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(errors.SDKError)
+		}
+	}
+
+	return r0
+}
+
+// MockSDK_RevokeCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeCert'
+type MockSDK_RevokeCert_Call struct {
 	*mock.Call
 }
 
-// GetCAToken is a helper method to define mock.On call
-func (_e *MockSDK_Expecter) GetCAToken() *MockSDK_GetCAToken_Call {
-	return &MockSDK_GetCAToken_Call{Call: _e.mock.On("GetCAToken")}
+// RevokeCert is a helper method to define mock.On call
+//   - serialNumber string
+//   - reason string
+//   - invalidityDate time.Time
+func (_e *MockSDK_Expecter) RevokeCert(serialNumber interface{}, reason interface{}, invalidityDate interface{}) *MockSDK_RevokeCert_Call {
+	return &MockSDK_RevokeCert_Call{Call: _e.mock.On("RevokeCert", serialNumber, reason, invalidityDate)}
 }
 
-func (_c *MockSDK_GetCAToken_Call) Run(run func()) *MockSDK_GetCAToken_Call {
+func (_c *MockSDK_RevokeCert_Call) Run(run func(serialNumber string, reason string, invalidityDate time.Time)) *MockSDK_RevokeCert_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(string), args[1].(string), args[2].(time.Time))
 	})
 	return _c
 }
 
-func (_c *MockSDK_GetCAToken_Call) Return(_a0 sdk.Token, _a1 errors.SDKError) *MockSDK_GetCAToken_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *MockSDK_RevokeCert_Call) Return(_a0 errors.SDKError) *MockSDK_RevokeCert_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockSDK_GetCAToken_Call) RunAndReturn(run func() (sdk.Token, errors.SDKError)) *MockSDK_GetCAToken_Call {
+func (_c *MockSDK_RevokeCert_Call) RunAndReturn(run func(string, string, time.Time) errors.SDKError) *MockSDK_RevokeCert_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// IssueCert provides a mock function with given fields: entityID, ttl, ipAddrs, opts
-func (_m *MockSDK) IssueCert(entityID string, ttl string, ipAddrs []string, opts sdk.Options) (sdk.Certificate, errors.SDKError) {
-	ret := _m.Called(entityID, ttl, ipAddrs, opts)
+// SubmitCSR provides a mock function with given fields: entityID, ttl, issuer, profile, csr
+func (_m *MockSDK) SubmitCSR(entityID string, ttl string, issuer string, profile string, csr string) (sdk.SubmittedCSR, errors.SDKError) {
+	ret := _m.Called(entityID, ttl, issuer, profile, csr)
 
 	if len(ret) == 0 {
-		panic("no return value specified for IssueCert")
+		panic("no return value specified for SubmitCSR")
 	}
 
-	var r0 sdk.Certificate
+	var r0 sdk.SubmittedCSR
 	var r1 errors.SDKError
-	if rf, ok := ret.Get(0).(func(string, string, []string, sdk.Options) (sdk.Certificate, errors.SDKError)); ok {
-		return rf(entityID, ttl, ipAddrs, opts)
+	if rf, ok := ret.Get(0).(func(string, string, string, string, string) (sdk.SubmittedCSR, errors.SDKError)); ok {
+		return rf(entityID, ttl, issuer, profile, csr)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, []string, sdk.Options) sdk.Certificate); ok {
-		r0 = rf(entityID, ttl, ipAddrs, opts)
+	if rf, ok := ret.Get(0).(func(string, string, string, string, string) sdk.SubmittedCSR); ok {
+		r0 = rf(entityID, ttl, issuer, profile, csr)
 	} else {
-		r0 = ret.Get(0).(sdk.Certificate)
+		r0 = ret.Get(0).(sdk.SubmittedCSR)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, []string, sdk.Options) errors.SDKError); ok {
-		r1 = rf(entityID, ttl, ipAddrs, opts)
+	if rf, ok := ret.Get(1).(func(string, string, string, string, string) errors.SDKError); ok {
+		r1 = rf(entityID, ttl, issuer, profile, csr)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(errors.SDKError)
@@ -274,58 +1911,61 @@ func (_m *MockSDK) IssueCert(entityID string, ttl string, ipAddrs []string, opts
 	return r0, r1
 }
 
-// MockSDK_IssueCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IssueCert'
-type MockSDK_IssueCert_Call struct {
+// MockSDK_SubmitCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SubmitCSR'
+type MockSDK_SubmitCSR_Call struct {
 	*mock.Call
 }
 
-// IssueCert is a helper method to define mock.On call
+// SubmitCSR is a helper method to define mock.On call
 //   - entityID string
 //   - ttl string
-//   - ipAddrs []string
-//   - opts sdk.Options
-func (_e *MockSDK_Expecter) IssueCert(entityID interface{}, ttl interface{}, ipAddrs interface{}, opts interface{}) *MockSDK_IssueCert_Call {
-	return &MockSDK_IssueCert_Call{Call: _e.mock.On("IssueCert", entityID, ttl, ipAddrs, opts)}
+//   - issuer string
+//   - profile string
+//   - csr string
+func (_e *MockSDK_Expecter) SubmitCSR(entityID interface{}, ttl interface{}, issuer interface{}, profile interface{}, csr interface{}) *MockSDK_SubmitCSR_Call {
+	return &MockSDK_SubmitCSR_Call{Call: _e.mock.On("SubmitCSR", entityID, ttl, issuer, profile, csr)}
 }
 
-func (_c *MockSDK_IssueCert_Call) Run(run func(entityID string, ttl string, ipAddrs []string, opts sdk.Options)) *MockSDK_IssueCert_Call {
+func (_c *MockSDK_SubmitCSR_Call) Run(run func(entityID string, ttl string, issuer string, profile string, csr string)) *MockSDK_SubmitCSR_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].([]string), args[3].(sdk.Options))
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(string), args[4].(string))
 	})
 	return _c
 }
 
-func (_c *MockSDK_IssueCert_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_IssueCert_Call {
+func (_c *MockSDK_SubmitCSR_Call) Return(_a0 sdk.SubmittedCSR, _a1 errors.SDKError) *MockSDK_SubmitCSR_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSDK_IssueCert_Call) RunAndReturn(run func(string, string, []string, sdk.Options) (sdk.Certificate, errors.SDKError)) *MockSDK_IssueCert_Call {
+func (_c *MockSDK_SubmitCSR_Call) RunAndReturn(run func(string, string, string, string, string) (sdk.SubmittedCSR, errors.SDKError)) *MockSDK_SubmitCSR_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// IssueFromCSR provides a mock function with given fields: entityID, ttl, csr
-func (_m *MockSDK) IssueFromCSR(entityID string, ttl string, csr string) (sdk.Certificate, errors.SDKError) {
-	ret := _m.Called(entityID, ttl, csr)
+// TrustBundle provides a mock function with no fields
+func (_m *MockSDK) TrustBundle() ([]byte, errors.SDKError) {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for IssueFromCSR")
+		panic("no return value specified for TrustBundle")
 	}
 
-	var r0 sdk.Certificate
+	var r0 []byte
 	var r1 errors.SDKError
-	if rf, ok := ret.Get(0).(func(string, string, string) (sdk.Certificate, errors.SDKError)); ok {
-		return rf(entityID, ttl, csr)
+	if rf, ok := ret.Get(0).(func() ([]byte, errors.SDKError)); ok {
+		return rf()
 	}
-	if rf, ok := ret.Get(0).(func(string, string, string) sdk.Certificate); ok {
-		r0 = rf(entityID, ttl, csr)
+	if rf, ok := ret.Get(0).(func() []byte); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Get(0).(sdk.Certificate)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, string) errors.SDKError); ok {
-		r1 = rf(entityID, ttl, csr)
+	if rf, ok := ret.Get(1).(func() errors.SDKError); ok {
+		r1 = rf()
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(errors.SDKError)
@@ -335,57 +1975,54 @@ func (_m *MockSDK) IssueFromCSR(entityID string, ttl string, csr string) (sdk.Ce
 	return r0, r1
 }
 
-// MockSDK_IssueFromCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IssueFromCSR'
-type MockSDK_IssueFromCSR_Call struct {
+// MockSDK_TrustBundle_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrustBundle'
+type MockSDK_TrustBundle_Call struct {
 	*mock.Call
 }
 
-// IssueFromCSR is a helper method to define mock.On call
-//   - entityID string
-//   - ttl string
-//   - csr string
-func (_e *MockSDK_Expecter) IssueFromCSR(entityID interface{}, ttl interface{}, csr interface{}) *MockSDK_IssueFromCSR_Call {
-	return &MockSDK_IssueFromCSR_Call{Call: _e.mock.On("IssueFromCSR", entityID, ttl, csr)}
+// TrustBundle is a helper method to define mock.On call
+func (_e *MockSDK_Expecter) TrustBundle() *MockSDK_TrustBundle_Call {
+	return &MockSDK_TrustBundle_Call{Call: _e.mock.On("TrustBundle")}
 }
 
-func (_c *MockSDK_IssueFromCSR_Call) Run(run func(entityID string, ttl string, csr string)) *MockSDK_IssueFromCSR_Call {
+func (_c *MockSDK_TrustBundle_Call) Run(run func()) *MockSDK_TrustBundle_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(string))
+		run()
 	})
 	return _c
 }
 
-func (_c *MockSDK_IssueFromCSR_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_IssueFromCSR_Call {
+func (_c *MockSDK_TrustBundle_Call) Return(_a0 []byte, _a1 errors.SDKError) *MockSDK_TrustBundle_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSDK_IssueFromCSR_Call) RunAndReturn(run func(string, string, string) (sdk.Certificate, errors.SDKError)) *MockSDK_IssueFromCSR_Call {
+func (_c *MockSDK_TrustBundle_Call) RunAndReturn(run func() ([]byte, errors.SDKError)) *MockSDK_TrustBundle_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListCerts provides a mock function with given fields: pm
-func (_m *MockSDK) ListCerts(pm sdk.PageMetadata) (sdk.CertificatePage, errors.SDKError) {
-	ret := _m.Called(pm)
+// UpdateProfile provides a mock function with given fields: profile
+func (_m *MockSDK) UpdateProfile(profile sdk.Profile) (sdk.Profile, errors.SDKError) {
+	ret := _m.Called(profile)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListCerts")
+		panic("no return value specified for UpdateProfile")
 	}
 
-	var r0 sdk.CertificatePage
+	var r0 sdk.Profile
 	var r1 errors.SDKError
-	if rf, ok := ret.Get(0).(func(sdk.PageMetadata) (sdk.CertificatePage, errors.SDKError)); ok {
-		return rf(pm)
+	if rf, ok := ret.Get(0).(func(sdk.Profile) (sdk.Profile, errors.SDKError)); ok {
+		return rf(profile)
 	}
-	if rf, ok := ret.Get(0).(func(sdk.PageMetadata) sdk.CertificatePage); ok {
-		r0 = rf(pm)
+	if rf, ok := ret.Get(0).(func(sdk.Profile) sdk.Profile); ok {
+		r0 = rf(profile)
 	} else {
-		r0 = ret.Get(0).(sdk.CertificatePage)
+		r0 = ret.Get(0).(sdk.Profile)
 	}
 
-	if rf, ok := ret.Get(1).(func(sdk.PageMetadata) errors.SDKError); ok {
-		r1 = rf(pm)
+	if rf, ok := ret.Get(1).(func(sdk.Profile) errors.SDKError); ok {
+		r1 = rf(profile)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(errors.SDKError)
@@ -395,55 +2032,55 @@ func (_m *MockSDK) ListCerts(pm sdk.PageMetadata) (sdk.CertificatePage, errors.S
 	return r0, r1
 }
 
-// MockSDK_ListCerts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListCerts'
-type MockSDK_ListCerts_Call struct {
+// MockSDK_UpdateProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateProfile'
+type MockSDK_UpdateProfile_Call struct {
 	*mock.Call
 }
 
-// ListCerts is a helper method to define mock.On call
-//   - pm sdk.PageMetadata
-func (_e *MockSDK_Expecter) ListCerts(pm interface{}) *MockSDK_ListCerts_Call {
-	return &MockSDK_ListCerts_Call{Call: _e.mock.On("ListCerts", pm)}
+// UpdateProfile is a helper method to define mock.On call
+//   - profile sdk.Profile
+func (_e *MockSDK_Expecter) UpdateProfile(profile interface{}) *MockSDK_UpdateProfile_Call {
+	return &MockSDK_UpdateProfile_Call{Call: _e.mock.On("UpdateProfile", profile)}
 }
 
-func (_c *MockSDK_ListCerts_Call) Run(run func(pm sdk.PageMetadata)) *MockSDK_ListCerts_Call {
+func (_c *MockSDK_UpdateProfile_Call) Run(run func(profile sdk.Profile)) *MockSDK_UpdateProfile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(sdk.PageMetadata))
+		run(args[0].(sdk.Profile))
 	})
 	return _c
 }
 
-func (_c *MockSDK_ListCerts_Call) Return(_a0 sdk.CertificatePage, _a1 errors.SDKError) *MockSDK_ListCerts_Call {
+func (_c *MockSDK_UpdateProfile_Call) Return(_a0 sdk.Profile, _a1 errors.SDKError) *MockSDK_UpdateProfile_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSDK_ListCerts_Call) RunAndReturn(run func(sdk.PageMetadata) (sdk.CertificatePage, errors.SDKError)) *MockSDK_ListCerts_Call {
+func (_c *MockSDK_UpdateProfile_Call) RunAndReturn(run func(sdk.Profile) (sdk.Profile, errors.SDKError)) *MockSDK_UpdateProfile_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// OCSP provides a mock function with given fields: serialNumber, cert
-func (_m *MockSDK) OCSP(serialNumber string, cert string) (sdk.OCSPResponse, errors.SDKError) {
-	ret := _m.Called(serialNumber, cert)
+// VerifyAuditLog provides a mock function with no fields
+func (_m *MockSDK) VerifyAuditLog() (sdk.AuditVerification, errors.SDKError) {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for OCSP")
+		panic("no return value specified for VerifyAuditLog")
 	}
 
-	var r0 sdk.OCSPResponse
+	var r0 sdk.AuditVerification
 	var r1 errors.SDKError
-	if rf, ok := ret.Get(0).(func(string, string) (sdk.OCSPResponse, errors.SDKError)); ok {
-		return rf(serialNumber, cert)
+	if rf, ok := ret.Get(0).(func() (sdk.AuditVerification, errors.SDKError)); ok {
+		return rf()
 	}
-	if rf, ok := ret.Get(0).(func(string, string) sdk.OCSPResponse); ok {
-		r0 = rf(serialNumber, cert)
+	if rf, ok := ret.Get(0).(func() sdk.AuditVerification); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Get(0).(sdk.OCSPResponse)
+		r0 = ret.Get(0).(sdk.AuditVerification)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) errors.SDKError); ok {
-		r1 = rf(serialNumber, cert)
+	if rf, ok := ret.Get(1).(func() errors.SDKError); ok {
+		r1 = rf()
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(errors.SDKError)
@@ -453,104 +2090,112 @@ func (_m *MockSDK) OCSP(serialNumber string, cert string) (sdk.OCSPResponse, err
 	return r0, r1
 }
 
-// MockSDK_OCSP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'OCSP'
-type MockSDK_OCSP_Call struct {
+// MockSDK_VerifyAuditLog_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'VerifyAuditLog'
+type MockSDK_VerifyAuditLog_Call struct {
 	*mock.Call
 }
 
-// OCSP is a helper method to define mock.On call
-//   - serialNumber string
-//   - cert string
-func (_e *MockSDK_Expecter) OCSP(serialNumber interface{}, cert interface{}) *MockSDK_OCSP_Call {
-	return &MockSDK_OCSP_Call{Call: _e.mock.On("OCSP", serialNumber, cert)}
+// VerifyAuditLog is a helper method to define mock.On call
+func (_e *MockSDK_Expecter) VerifyAuditLog() *MockSDK_VerifyAuditLog_Call {
+	return &MockSDK_VerifyAuditLog_Call{Call: _e.mock.On("VerifyAuditLog")}
 }
 
-func (_c *MockSDK_OCSP_Call) Run(run func(serialNumber string, cert string)) *MockSDK_OCSP_Call {
+func (_c *MockSDK_VerifyAuditLog_Call) Run(run func()) *MockSDK_VerifyAuditLog_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run()
 	})
 	return _c
 }
 
-func (_c *MockSDK_OCSP_Call) Return(_a0 sdk.OCSPResponse, _a1 errors.SDKError) *MockSDK_OCSP_Call {
+func (_c *MockSDK_VerifyAuditLog_Call) Return(_a0 sdk.AuditVerification, _a1 errors.SDKError) *MockSDK_VerifyAuditLog_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSDK_OCSP_Call) RunAndReturn(run func(string, string) (sdk.OCSPResponse, errors.SDKError)) *MockSDK_OCSP_Call {
+func (_c *MockSDK_VerifyAuditLog_Call) RunAndReturn(run func() (sdk.AuditVerification, errors.SDKError)) *MockSDK_VerifyAuditLog_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RenewCert provides a mock function with given fields: serialNumber
-func (_m *MockSDK) RenewCert(serialNumber string) errors.SDKError {
-	ret := _m.Called(serialNumber)
+// ViewCA provides a mock function with given fields: token
+func (_m *MockSDK) ViewCA(token string) (sdk.Certificate, errors.SDKError) {
+	ret := _m.Called(token)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RenewCert")
+		panic("no return value specified for ViewCA")
 	}
 
-	var r0 errors.SDKError
-	if rf, ok := ret.Get(0).(func(string) errors.SDKError); ok {
-		r0 = rf(serialNumber)
+	var r0 sdk.Certificate
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) (sdk.Certificate, errors.SDKError)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) sdk.Certificate); ok {
+		r0 = rf(token)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(errors.SDKError)
+		r0 = ret.Get(0).(sdk.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
+		r1 = rf(token)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
 		}
 	}
 
-	return r0
+	return r0, r1
 }
 
-// MockSDK_RenewCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenewCert'
-type MockSDK_RenewCert_Call struct {
+// MockSDK_ViewCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewCA'
+type MockSDK_ViewCA_Call struct {
 	*mock.Call
 }
 
-// RenewCert is a helper method to define mock.On call
-//   - serialNumber string
-func (_e *MockSDK_Expecter) RenewCert(serialNumber interface{}) *MockSDK_RenewCert_Call {
-	return &MockSDK_RenewCert_Call{Call: _e.mock.On("RenewCert", serialNumber)}
+// ViewCA is a helper method to define mock.On call
+//   - token string
+func (_e *MockSDK_Expecter) ViewCA(token interface{}) *MockSDK_ViewCA_Call {
+	return &MockSDK_ViewCA_Call{Call: _e.mock.On("ViewCA", token)}
 }
 
-func (_c *MockSDK_RenewCert_Call) Run(run func(serialNumber string)) *MockSDK_RenewCert_Call {
+func (_c *MockSDK_ViewCA_Call) Run(run func(token string)) *MockSDK_ViewCA_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *MockSDK_RenewCert_Call) Return(_a0 errors.SDKError) *MockSDK_RenewCert_Call {
-	_c.Call.Return(_a0)
+func (_c *MockSDK_ViewCA_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_ViewCA_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSDK_RenewCert_Call) RunAndReturn(run func(string) errors.SDKError) *MockSDK_RenewCert_Call {
+func (_c *MockSDK_ViewCA_Call) RunAndReturn(run func(string) (sdk.Certificate, errors.SDKError)) *MockSDK_ViewCA_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RetrieveCertDownloadToken provides a mock function with given fields: serialNumber
-func (_m *MockSDK) RetrieveCertDownloadToken(serialNumber string) (sdk.Token, errors.SDKError) {
-	ret := _m.Called(serialNumber)
+// ViewCSR provides a mock function with given fields: id
+func (_m *MockSDK) ViewCSR(id string) (sdk.SubmittedCSR, errors.SDKError) {
+	ret := _m.Called(id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RetrieveCertDownloadToken")
+		panic("no return value specified for ViewCSR")
 	}
 
-	var r0 sdk.Token
+	var r0 sdk.SubmittedCSR
 	var r1 errors.SDKError
-	if rf, ok := ret.Get(0).(func(string) (sdk.Token, errors.SDKError)); ok {
-		return rf(serialNumber)
+	if rf, ok := ret.Get(0).(func(string) (sdk.SubmittedCSR, errors.SDKError)); ok {
+		return rf(id)
 	}
-	if rf, ok := ret.Get(0).(func(string) sdk.Token); ok {
-		r0 = rf(serialNumber)
+	if rf, ok := ret.Get(0).(func(string) sdk.SubmittedCSR); ok {
+		r0 = rf(id)
 	} else {
-		r0 = ret.Get(0).(sdk.Token)
+		r0 = ret.Get(0).(sdk.SubmittedCSR)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
-		r1 = rf(serialNumber)
+		r1 = rf(id)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(errors.SDKError)
@@ -560,103 +2205,113 @@ func (_m *MockSDK) RetrieveCertDownloadToken(serialNumber string) (sdk.Token, er
 	return r0, r1
 }
 
-// MockSDK_RetrieveCertDownloadToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveCertDownloadToken'
-type MockSDK_RetrieveCertDownloadToken_Call struct {
+// MockSDK_ViewCSR_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewCSR'
+type MockSDK_ViewCSR_Call struct {
 	*mock.Call
 }
 
-// RetrieveCertDownloadToken is a helper method to define mock.On call
-//   - serialNumber string
-func (_e *MockSDK_Expecter) RetrieveCertDownloadToken(serialNumber interface{}) *MockSDK_RetrieveCertDownloadToken_Call {
-	return &MockSDK_RetrieveCertDownloadToken_Call{Call: _e.mock.On("RetrieveCertDownloadToken", serialNumber)}
+// ViewCSR is a helper method to define mock.On call
+//   - id string
+func (_e *MockSDK_Expecter) ViewCSR(id interface{}) *MockSDK_ViewCSR_Call {
+	return &MockSDK_ViewCSR_Call{Call: _e.mock.On("ViewCSR", id)}
 }
 
-func (_c *MockSDK_RetrieveCertDownloadToken_Call) Run(run func(serialNumber string)) *MockSDK_RetrieveCertDownloadToken_Call {
+func (_c *MockSDK_ViewCSR_Call) Run(run func(id string)) *MockSDK_ViewCSR_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *MockSDK_RetrieveCertDownloadToken_Call) Return(_a0 sdk.Token, _a1 errors.SDKError) *MockSDK_RetrieveCertDownloadToken_Call {
+func (_c *MockSDK_ViewCSR_Call) Return(_a0 sdk.SubmittedCSR, _a1 errors.SDKError) *MockSDK_ViewCSR_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSDK_RetrieveCertDownloadToken_Call) RunAndReturn(run func(string) (sdk.Token, errors.SDKError)) *MockSDK_RetrieveCertDownloadToken_Call {
+func (_c *MockSDK_ViewCSR_Call) RunAndReturn(run func(string) (sdk.SubmittedCSR, errors.SDKError)) *MockSDK_ViewCSR_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RevokeCert provides a mock function with given fields: serialNumber
-func (_m *MockSDK) RevokeCert(serialNumber string) errors.SDKError {
+// ViewCert provides a mock function with given fields: serialNumber
+func (_m *MockSDK) ViewCert(serialNumber string) (sdk.Certificate, errors.SDKError) {
 	ret := _m.Called(serialNumber)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RevokeCert")
+		panic("no return value specified for ViewCert")
 	}
 
-	var r0 errors.SDKError
-	if rf, ok := ret.Get(0).(func(string) errors.SDKError); ok {
+	var r0 sdk.Certificate
+	var r1 errors.SDKError
+	if rf, ok := ret.Get(0).(func(string) (sdk.Certificate, errors.SDKError)); ok {
+		return rf(serialNumber)
+	}
+	if rf, ok := ret.Get(0).(func(string) sdk.Certificate); ok {
 		r0 = rf(serialNumber)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(errors.SDKError)
+		r0 = ret.Get(0).(sdk.Certificate)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
+		r1 = rf(serialNumber)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(errors.SDKError)
 		}
 	}
 
-	return r0
+	return r0, r1
 }
 
-// MockSDK_RevokeCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeCert'
-type MockSDK_RevokeCert_Call struct {
+// MockSDK_ViewCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewCert'
+type MockSDK_ViewCert_Call struct {
 	*mock.Call
 }
 
-// RevokeCert is a helper method to define mock.On call
+// ViewCert is a helper method to define mock.On call
 //   - serialNumber string
-func (_e *MockSDK_Expecter) RevokeCert(serialNumber interface{}) *MockSDK_RevokeCert_Call {
-	return &MockSDK_RevokeCert_Call{Call: _e.mock.On("RevokeCert", serialNumber)}
+func (_e *MockSDK_Expecter) ViewCert(serialNumber interface{}) *MockSDK_ViewCert_Call {
+	return &MockSDK_ViewCert_Call{Call: _e.mock.On("ViewCert", serialNumber)}
 }
 
-func (_c *MockSDK_RevokeCert_Call) Run(run func(serialNumber string)) *MockSDK_RevokeCert_Call {
+func (_c *MockSDK_ViewCert_Call) Run(run func(serialNumber string)) *MockSDK_ViewCert_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *MockSDK_RevokeCert_Call) Return(_a0 errors.SDKError) *MockSDK_RevokeCert_Call {
-	_c.Call.Return(_a0)
+func (_c *MockSDK_ViewCert_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_ViewCert_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSDK_RevokeCert_Call) RunAndReturn(run func(string) errors.SDKError) *MockSDK_RevokeCert_Call {
+func (_c *MockSDK_ViewCert_Call) RunAndReturn(run func(string) (sdk.Certificate, errors.SDKError)) *MockSDK_ViewCert_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ViewCA provides a mock function with given fields: token
-func (_m *MockSDK) ViewCA(token string) (sdk.Certificate, errors.SDKError) {
-	ret := _m.Called(token)
+// ViewProfile provides a mock function with given fields: name
+func (_m *MockSDK) ViewProfile(name string) (sdk.Profile, errors.SDKError) {
+	ret := _m.Called(name)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ViewCA")
+		panic("no return value specified for ViewProfile")
 	}
 
-	var r0 sdk.Certificate
+	var r0 sdk.Profile
 	var r1 errors.SDKError
-	if rf, ok := ret.Get(0).(func(string) (sdk.Certificate, errors.SDKError)); ok {
-		return rf(token)
+	if rf, ok := ret.Get(0).(func(string) (sdk.Profile, errors.SDKError)); ok {
+		return rf(name)
 	}
-	if rf, ok := ret.Get(0).(func(string) sdk.Certificate); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(string) sdk.Profile); ok {
+		r0 = rf(name)
 	} else {
-		r0 = ret.Get(0).(sdk.Certificate)
+		r0 = ret.Get(0).(sdk.Profile)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
-		r1 = rf(token)
+		r1 = rf(name)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(errors.SDKError)
@@ -666,55 +2321,55 @@ func (_m *MockSDK) ViewCA(token string) (sdk.Certificate, errors.SDKError) {
 	return r0, r1
 }
 
-// MockSDK_ViewCA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewCA'
-type MockSDK_ViewCA_Call struct {
+// MockSDK_ViewProfile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewProfile'
+type MockSDK_ViewProfile_Call struct {
 	*mock.Call
 }
 
-// ViewCA is a helper method to define mock.On call
-//   - token string
-func (_e *MockSDK_Expecter) ViewCA(token interface{}) *MockSDK_ViewCA_Call {
-	return &MockSDK_ViewCA_Call{Call: _e.mock.On("ViewCA", token)}
+// ViewProfile is a helper method to define mock.On call
+//   - name string
+func (_e *MockSDK_Expecter) ViewProfile(name interface{}) *MockSDK_ViewProfile_Call {
+	return &MockSDK_ViewProfile_Call{Call: _e.mock.On("ViewProfile", name)}
 }
 
-func (_c *MockSDK_ViewCA_Call) Run(run func(token string)) *MockSDK_ViewCA_Call {
+func (_c *MockSDK_ViewProfile_Call) Run(run func(name string)) *MockSDK_ViewProfile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *MockSDK_ViewCA_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_ViewCA_Call {
+func (_c *MockSDK_ViewProfile_Call) Return(_a0 sdk.Profile, _a1 errors.SDKError) *MockSDK_ViewProfile_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSDK_ViewCA_Call) RunAndReturn(run func(string) (sdk.Certificate, errors.SDKError)) *MockSDK_ViewCA_Call {
+func (_c *MockSDK_ViewProfile_Call) RunAndReturn(run func(string) (sdk.Profile, errors.SDKError)) *MockSDK_ViewProfile_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ViewCert provides a mock function with given fields: serialNumber
-func (_m *MockSDK) ViewCert(serialNumber string) (sdk.Certificate, errors.SDKError) {
-	ret := _m.Called(serialNumber)
+// ViewWebhook provides a mock function with given fields: id
+func (_m *MockSDK) ViewWebhook(id string) (sdk.WebhookSubscription, errors.SDKError) {
+	ret := _m.Called(id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ViewCert")
+		panic("no return value specified for ViewWebhook")
 	}
 
-	var r0 sdk.Certificate
+	var r0 sdk.WebhookSubscription
 	var r1 errors.SDKError
-	if rf, ok := ret.Get(0).(func(string) (sdk.Certificate, errors.SDKError)); ok {
-		return rf(serialNumber)
+	if rf, ok := ret.Get(0).(func(string) (sdk.WebhookSubscription, errors.SDKError)); ok {
+		return rf(id)
 	}
-	if rf, ok := ret.Get(0).(func(string) sdk.Certificate); ok {
-		r0 = rf(serialNumber)
+	if rf, ok := ret.Get(0).(func(string) sdk.WebhookSubscription); ok {
+		r0 = rf(id)
 	} else {
-		r0 = ret.Get(0).(sdk.Certificate)
+		r0 = ret.Get(0).(sdk.WebhookSubscription)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) errors.SDKError); ok {
-		r1 = rf(serialNumber)
+		r1 = rf(id)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(errors.SDKError)
@@ -724,30 +2379,30 @@ func (_m *MockSDK) ViewCert(serialNumber string) (sdk.Certificate, errors.SDKErr
 	return r0, r1
 }
 
-// MockSDK_ViewCert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewCert'
-type MockSDK_ViewCert_Call struct {
+// MockSDK_ViewWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewWebhook'
+type MockSDK_ViewWebhook_Call struct {
 	*mock.Call
 }
 
-// ViewCert is a helper method to define mock.On call
-//   - serialNumber string
-func (_e *MockSDK_Expecter) ViewCert(serialNumber interface{}) *MockSDK_ViewCert_Call {
-	return &MockSDK_ViewCert_Call{Call: _e.mock.On("ViewCert", serialNumber)}
+// ViewWebhook is a helper method to define mock.On call
+//   - id string
+func (_e *MockSDK_Expecter) ViewWebhook(id interface{}) *MockSDK_ViewWebhook_Call {
+	return &MockSDK_ViewWebhook_Call{Call: _e.mock.On("ViewWebhook", id)}
 }
 
-func (_c *MockSDK_ViewCert_Call) Run(run func(serialNumber string)) *MockSDK_ViewCert_Call {
+func (_c *MockSDK_ViewWebhook_Call) Run(run func(id string)) *MockSDK_ViewWebhook_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *MockSDK_ViewCert_Call) Return(_a0 sdk.Certificate, _a1 errors.SDKError) *MockSDK_ViewCert_Call {
+func (_c *MockSDK_ViewWebhook_Call) Return(_a0 sdk.WebhookSubscription, _a1 errors.SDKError) *MockSDK_ViewWebhook_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockSDK_ViewCert_Call) RunAndReturn(run func(string) (sdk.Certificate, errors.SDKError)) *MockSDK_ViewCert_Call {
+func (_c *MockSDK_ViewWebhook_Call) RunAndReturn(run func(string) (sdk.WebhookSubscription, errors.SDKError)) *MockSDK_ViewWebhook_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -26,6 +26,10 @@ const (
 	certsEndpoint     = "certs"
 	csrEndpoint       = "csrs"
 	issueCertEndpoint = "certs/issue"
+	issuersEndpoint   = "issuers"
+	profilesEndpoint  = "profiles"
+	auditEndpoint     = "audit"
+	webhooksEndpoint  = "webhooks"
 	emptyOCSPbody     = 22
 )
 
@@ -91,6 +95,8 @@ type PageMetadata struct {
 	EmailAddresses     []string `json:"email_addresses,omitempty"`
 	Status             string   `json:"status,omitempty"`
 	TTL                string   `json:"ttl,omitempty"`
+	Issuer             string   `json:"issuer,omitempty"`
+	Profile            string   `json:"profile,omitempty"`
 }
 
 type Options struct {
@@ -103,6 +109,11 @@ type Options struct {
 	StreetAddress      []string `json:"street_address"`
 	PostalCode         []string `json:"postal_code"`
 	DnsNames           []string `json:"dns_names"`
+	EmailAddresses     []string `json:"email_addresses"`
+	// Issuer selects the issuing CA, the default issuer is used if empty.
+	Issuer string `json:"-"`
+	// Profile selects the certificate profile, the default profile is used if empty.
+	Profile string `json:"-"`
 }
 
 type Token struct {
@@ -117,6 +128,10 @@ type Certificate struct {
 	ExpiryTime   time.Time `json:"expiry_time,omitempty"`
 	EntityID     string    `json:"entity_id,omitempty"`
 	DownloadUrl  string    `json:"-"`
+	// RevocationReason, RevocationTime and InvalidityDate are only set for revoked certificates.
+	RevocationReason string     `json:"revocation_reason,omitempty"`
+	RevocationTime   *time.Time `json:"revocation_time,omitempty"`
+	InvalidityDate   *time.Time `json:"invalidity_date,omitempty"`
 }
 
 type CertificatePage struct {
@@ -133,6 +148,11 @@ type Config struct {
 	MsgContentType  ContentType
 	TLSVerification bool
 	CurlFlag        bool
+
+	// APIKey and Token authenticate the requests with an API key or a
+	// bearer JWT when set.
+	APIKey string
+	Token  string
 }
 
 type mgSDK struct {
@@ -142,6 +162,8 @@ type mgSDK struct {
 	msgContentType ContentType
 	client         *http.Client
 	curlFlag       bool
+	apiKey         string
+	token          string
 }
 
 type CertificateBundle struct {
@@ -177,11 +199,167 @@ type CSR struct {
 	CSR []byte `json:"csr,omitempty"`
 }
 
+// SubmittedCSR is a CSR in the approval queue. The certificate is only set
+// when a CSR is issued on submission.
+type SubmittedCSR struct {
+	ID           string    `json:"id"`
+	EntityID     string    `json:"entity_id"`
+	Issuer       string    `json:"issuer,omitempty"`
+	Profile      string    `json:"profile,omitempty"`
+	TTL          string    `json:"ttl,omitempty"`
+	CSR          string    `json:"csr,omitempty"`
+	Status       string    `json:"status"`
+	Requester    Requester `json:"requester"`
+	Reason       string    `json:"reason,omitempty"`
+	SerialNumber string    `json:"serial_number,omitempty"`
+	Certificate  string    `json:"certificate,omitempty"`
+	SubmittedAt  time.Time `json:"submitted_at,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
+}
+
+// Requester describes the client that submitted a CSR.
+type Requester struct {
+	Address   string `json:"address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+type CSRPage struct {
+	Total  uint64         `json:"total"`
+	Offset uint64         `json:"offset"`
+	Limit  uint64         `json:"limit"`
+	CSRs   []SubmittedCSR `json:"csrs,omitempty"`
+}
+
+type Issuer struct {
+	Name         string    `json:"name"`
+	SerialNumber string    `json:"serial_number"`
+	Certificate  string    `json:"certificate,omitempty"`
+	ExpiryTime   time.Time `json:"expiry_time"`
+	Retired      bool      `json:"retired"`
+}
+
+type Profile struct {
+	Name         string         `json:"name"`
+	KeyUsages    []string       `json:"key_usages"`
+	ExtKeyUsages []string       `json:"ext_key_usages"`
+	TTL          string         `json:"ttl,omitempty"`
+	MaxTTL       string         `json:"max_ttl,omitempty"`
+	AllowedSANs  []string       `json:"allowed_sans"`
+	Subject      ProfileSubject `json:"subject"`
+	ReadOnly     bool           `json:"read_only,omitempty"`
+}
+
+type ProfileSubject struct {
+	Organization       []string `json:"organization,omitempty"`
+	OrganizationalUnit []string `json:"organizational_unit,omitempty"`
+	Country            []string `json:"country,omitempty"`
+	Province           []string `json:"province,omitempty"`
+	Locality           []string `json:"locality,omitempty"`
+	StreetAddress      []string `json:"street_address,omitempty"`
+	PostalCode         []string `json:"postal_code,omitempty"`
+}
+
+type AuditEvent struct {
+	Seq          uint64            `json:"seq"`
+	Time         time.Time         `json:"time"`
+	Actor        string            `json:"actor"`
+	Role         string            `json:"role,omitempty"`
+	Action       string            `json:"action"`
+	SerialNumber string            `json:"serial_number,omitempty"`
+	EntityID     string            `json:"entity_id,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`
+	Result       string            `json:"result"`
+	Error        string            `json:"error,omitempty"`
+	PrevHash     string            `json:"prev_hash"`
+	Hash         string            `json:"hash"`
+}
+
+// AuditFilter selects audit events. Empty fields and zero times match all events.
+type AuditFilter struct {
+	Actor        string
+	Action       string
+	SerialNumber string
+	EntityID     string
+	Result       string
+	From         time.Time
+	To           time.Time
+	Offset       uint64
+	Limit        uint64
+}
+
+type AuditEventsPage struct {
+	Total  uint64       `json:"total"`
+	Offset uint64       `json:"offset"`
+	Limit  uint64       `json:"limit"`
+	Events []AuditEvent `json:"events"`
+}
+
+type AuditVerification struct {
+	Valid    bool   `json:"valid"`
+	Events   uint64 `json:"events"`
+	LastHash string `json:"last_hash,omitempty"`
+	BrokenAt uint64 `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type WebhookSubscription struct {
+	ID        string    `json:"id,omitempty"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events,omitempty"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+type WebhookSubscriptionsPage struct {
+	Total         uint64                `json:"total"`
+	Offset        uint64                `json:"offset"`
+	Limit         uint64                `json:"limit"`
+	Subscriptions []WebhookSubscription `json:"subscriptions"`
+}
+
+type WebhookEvent struct {
+	ID               uint64    `json:"id"`
+	Type             string    `json:"type"`
+	Time             time.Time `json:"time"`
+	SerialNumber     string    `json:"serial_number"`
+	EntityID         string    `json:"entity_id"`
+	ExpiryTime       time.Time `json:"expiry_time"`
+	RevocationReason string    `json:"revocation_reason,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID             uint64       `json:"id"`
+	SubscriptionID string       `json:"subscription_id"`
+	Event          WebhookEvent `json:"event"`
+	Status         string       `json:"status"`
+	Attempts       uint64       `json:"attempts"`
+	NextAttemptAt  time.Time    `json:"next_attempt_at"`
+	LastStatusCode int          `json:"last_status_code,omitempty"`
+	LastError      string       `json:"last_error,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// WebhookDeliveryFilter selects webhook deliveries. Empty fields match all deliveries.
+type WebhookDeliveryFilter struct {
+	SubscriptionID string
+	Status         string
+	Offset         uint64
+	Limit          uint64
+}
+
+type WebhookDeliveriesPage struct {
+	Total      uint64            `json:"total"`
+	Offset     uint64            `json:"offset"`
+	Limit      uint64            `json:"limit"`
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
 type SDK interface {
 	// IssueCert issues a certificate for a thing required for mTLS.
 	//
 	// example:
-	// cert , _ := sdk.IssueCert("entityID", "10h", []string{"ipAddr1", "ipAddr2"}, sdk.Options{CommonName: "commonName"})
+	// cert , _ := sdk.IssueCert("entityID", "10h", []string{"ipAddr1", "ipAddr2"}, sdk.Options{CommonName: "commonName", Issuer: "issuerName", Profile: "server"})
 	//  fmt.Println(cert)
 	IssueCert(entityID, ttl string, ipAddrs []string, opts Options) (Certificate, errors.SDKError)
 
@@ -192,12 +370,29 @@ type SDK interface {
 	//  fmt.Println(certBundle)
 	DownloadCert(token, serialNumber string) (CertificateBundle, errors.SDKError)
 
-	// RevokeCert revokes certificate for thing with thingID
+	// RevokeCert revokes certificate for thing with thingID with the given
+	// RFC 5280 reason, e.g. "key_compromise". An empty reason is unspecified
+	// and a zero invalidity date is not set.
 	//
 	// example:
-	//  err := sdk.RevokeCert("serialNumber")
+	//  err := sdk.RevokeCert("serialNumber", "key_compromise", time.Time{})
 	//  fmt.Println(err) // nil if successful
-	RevokeCert(serialNumber string) errors.SDKError
+	RevokeCert(serialNumber, reason string, invalidityDate time.Time) errors.SDKError
+
+	// HoldCert places certificate on hold. It is reported as revoked with
+	// the certificate_hold reason until it is released.
+	//
+	// example:
+	//  err := sdk.HoldCert("serialNumber")
+	//  fmt.Println(err) // nil if successful
+	HoldCert(serialNumber string) errors.SDKError
+
+	// ReleaseCert releases certificate from hold.
+	//
+	// example:
+	//  err := sdk.ReleaseCert("serialNumber")
+	//  fmt.Println(err) // nil if successful
+	ReleaseCert(serialNumber string) errors.SDKError
 
 	// RenewCert renews certificate for thing with thingID
 	//
@@ -248,26 +443,233 @@ type SDK interface {
 	//  fmt.Println(response)
 	ViewCA(token string) (Certificate, errors.SDKError)
 
-	// DownloadCA downloads the signing certificate
+	// DownloadCA downloads the PEM encoded CA chain of the issuer.
 	//
 	// example:
 	//  response, _ := sdk.DownloadCA(token)
 	//  fmt.Println(response)
 	DownloadCA(token string) (CertificateBundle, errors.SDKError)
 
-	// GetCAToken get token for viewing and downloading CA
+	// GetCAToken get token for viewing and downloading CA chain of the issuer.
+	// The default issuer is used if the issuer is empty.
 	//
 	// example:
-	//  response, _ := sdk.GetCAToken()
+	//  response, _ := sdk.GetCAToken("issuerName")
 	//  fmt.Println(response)
-	GetCAToken() (Token, errors.SDKError)
+	GetCAToken(issuer string) (Token, errors.SDKError)
+
+	// CAChain returns the PEM encoded certificates of the CA chain of the
+	// issuer, the issuing CA first. The default issuer is used if the issuer
+	// is empty.
+	//
+	// example:
+	//  chain, _ := sdk.CAChain("issuerName")
+	//  fmt.Println(string(chain))
+	CAChain(issuer string) ([]byte, errors.SDKError)
 
-	// IssueFromCSR issues certificate from provided CSR
+	// TrustBundle returns the PEM encoded root CA certificates to trust.
 	//
 	// example:
-	//	certs, err := sdk.IssueFromCSR( "entityID", "ttl", "csrFile")
+	//  bundle, _ := sdk.TrustBundle()
+	//  fmt.Println(string(bundle))
+	TrustBundle() ([]byte, errors.SDKError)
+
+	// ExportCA returns the backup of the CA with the serial number, including
+	// its private key, encrypted with the passphrase.
+	//
+	// example:
+	//  backup, _ := sdk.ExportCA("serialNumber", "passphrase")
+	//  fmt.Println(string(backup))
+	ExportCA(serialNumber, passphrase string) ([]byte, errors.SDKError)
+
+	// IssueFromCSR issues certificate from provided CSR signed by the issuer
+	// using the profile. The default issuer and profile are used if empty.
+	//
+	// example:
+	//	certs, err := sdk.IssueFromCSR( "entityID", "ttl", "issuerName", "profileName", "csrFile")
 	//	fmt.Println(err)
-	IssueFromCSR(entityID, ttl string, csr string) (Certificate, errors.SDKError)
+	IssueFromCSR(entityID, ttl, issuer, profile, csr string) (Certificate, errors.SDKError)
+
+	// SubmitCSR submits a CSR to the approval queue. The returned CSR holds
+	// the certificate if the CSR was approved on submission.
+	//
+	// example:
+	//	csr, _ := sdk.SubmitCSR("entityID", "ttl", "issuerName", "profileName", "csrFile")
+	//	fmt.Println(csr.Status)
+	SubmitCSR(entityID, ttl, issuer, profile, csr string) (SubmittedCSR, errors.SDKError)
+
+	// ListCSRs lists the submitted CSRs, filtered by the entity ID and status
+	// of the page metadata.
+	//
+	// example:
+	//  page, _ := sdk.ListCSRs(PageMetadata{Status: "pending", Limit: 10})
+	//  fmt.Println(page)
+	ListCSRs(pm PageMetadata) (CSRPage, errors.SDKError)
+
+	// ViewCSR retrieves a submitted CSR.
+	//
+	// example:
+	//  csr, _ := sdk.ViewCSR("id")
+	//  fmt.Println(csr)
+	ViewCSR(id string) (SubmittedCSR, errors.SDKError)
+
+	// ApproveCSR approves a pending CSR and issues its certificate. A
+	// non-empty TTL or profile overrides the requested one.
+	//
+	// example:
+	//  csr, _ := sdk.ApproveCSR("id", "720h", "")
+	//  fmt.Println(csr.SerialNumber)
+	ApproveCSR(id, ttl, profile string) (SubmittedCSR, errors.SDKError)
+
+	// RejectCSR rejects a pending CSR with the given reason.
+	//
+	// example:
+	//  csr, _ := sdk.RejectCSR("id", "unknown device")
+	//  fmt.Println(csr.Status)
+	RejectCSR(id, reason string) (SubmittedCSR, errors.SDKError)
+
+	// ImportCA imports an existing root or intermediate CA instead of the generated one.
+	// The CA type is either "RootCA" or "IntermediateCA". The private key or the key
+	// store reference may be omitted for an offline root CA.
+	//
+	// example:
+	//  ca, _ := sdk.ImportCA("RootCA", "caCertPEM", "caKeyPEM", "")
+	//  fmt.Println(ca)
+	ImportCA(caType, cert, key, keyRef string) (Certificate, errors.SDKError)
+
+	// GenerateIntermediateCSR generates an intermediate CA key and returns its CSR,
+	// to be signed by the root CA.
+	//
+	// example:
+	//  csr, _ := sdk.GenerateIntermediateCSR()
+	//  fmt.Println(string(csr.CSR))
+	GenerateIntermediateCSR() (CSR, errors.SDKError)
+
+	// InstallIntermediateCA installs the intermediate CA certificate signed from
+	// the CSR returned by GenerateIntermediateCSR.
+	//
+	// example:
+	//  ca, _ := sdk.InstallIntermediateCA("intermediateCertPEM")
+	//  fmt.Println(ca)
+	InstallIntermediateCA(cert string) (Certificate, errors.SDKError)
+
+	// CreateIssuer creates a new named intermediate CA under the root CA.
+	//
+	// example:
+	//  issuer, _ := sdk.CreateIssuer("issuerName")
+	//  fmt.Println(issuer)
+	CreateIssuer(name string) (Issuer, errors.SDKError)
+
+	// ListIssuers lists the active and retired issuers.
+	//
+	// example:
+	//  issuers, _ := sdk.ListIssuers()
+	//  fmt.Println(issuers)
+	ListIssuers() ([]Issuer, errors.SDKError)
+
+	// RetireIssuer stops the issuer from issuing new certificates.
+	//
+	// example:
+	//  err := sdk.RetireIssuer("issuerName")
+	//  fmt.Println(err) // nil if successful
+	RetireIssuer(name string) errors.SDKError
+
+	// CreateProfile creates a new certificate profile.
+	//
+	// example:
+	//  profile, _ := sdk.CreateProfile(sdk.Profile{Name: "iot", KeyUsages: []string{"digital_signature"}, ExtKeyUsages: []string{"client_auth"}})
+	//  fmt.Println(profile)
+	CreateProfile(profile Profile) (Profile, errors.SDKError)
+
+	// ViewProfile retrieves the certificate profile with the given name.
+	//
+	// example:
+	//  profile, _ := sdk.ViewProfile("server")
+	//  fmt.Println(profile)
+	ViewProfile(name string) (Profile, errors.SDKError)
+
+	// ListProfiles lists the configured and created certificate profiles.
+	//
+	// example:
+	//  profiles, _ := sdk.ListProfiles()
+	//  fmt.Println(profiles)
+	ListProfiles() ([]Profile, errors.SDKError)
+
+	// UpdateProfile updates a created certificate profile.
+	//
+	// example:
+	//  profile, _ := sdk.UpdateProfile(sdk.Profile{Name: "iot", KeyUsages: []string{"digital_signature"}, MaxTTL: "2160h"})
+	//  fmt.Println(profile)
+	UpdateProfile(profile Profile) (Profile, errors.SDKError)
+
+	// RemoveProfile deletes a created certificate profile.
+	//
+	// example:
+	//  err := sdk.RemoveProfile("iot")
+	//  fmt.Println(err) // nil if successful
+	RemoveProfile(name string) errors.SDKError
+
+	// ListAuditEvents lists the audit events matching the filter, the most
+	// recent first.
+	//
+	// example:
+	//  page, _ := sdk.ListAuditEvents(AuditFilter{Action: "revoke_cert", Limit: 10})
+	//  fmt.Println(page)
+	ListAuditEvents(filter AuditFilter) (AuditEventsPage, errors.SDKError)
+
+	// VerifyAuditLog verifies the hash chain of the audit log.
+	//
+	// example:
+	//  v, _ := sdk.VerifyAuditLog()
+	//  fmt.Println(v.Valid)
+	VerifyAuditLog() (AuditVerification, errors.SDKError)
+
+	// CreateWebhook subscribes the URL to certificate lifecycle events of
+	// the types, or of all types if there are none. The returned
+	// subscription holds the secret deliveries are signed with, which is
+	// generated unless one is given.
+	//
+	// example:
+	//  sub, _ := sdk.CreateWebhook(WebhookSubscription{URL: "https://example.com/hook", Events: []string{"cert.revoked"}})
+	//  fmt.Println(sub.Secret)
+	CreateWebhook(sub WebhookSubscription) (WebhookSubscription, errors.SDKError)
+
+	// ViewWebhook retrieves a webhook subscription without its secret.
+	//
+	// example:
+	//  sub, _ := sdk.ViewWebhook("id")
+	//  fmt.Println(sub)
+	ViewWebhook(id string) (WebhookSubscription, errors.SDKError)
+
+	// ListWebhooks lists the webhook subscriptions without their secrets.
+	//
+	// example:
+	//  page, _ := sdk.ListWebhooks(PageMetadata{Limit: 10})
+	//  fmt.Println(page)
+	ListWebhooks(pm PageMetadata) (WebhookSubscriptionsPage, errors.SDKError)
+
+	// RemoveWebhook removes a webhook subscription and its deliveries.
+	//
+	// example:
+	//  err := sdk.RemoveWebhook("id")
+	//  fmt.Println(err) // nil if successful
+	RemoveWebhook(id string) errors.SDKError
+
+	// ListWebhookDeliveries lists the webhook deliveries matching the
+	// filter, the most recent first.
+	//
+	// example:
+	//  page, _ := sdk.ListWebhookDeliveries(WebhookDeliveryFilter{Status: "dead", Limit: 10})
+	//  fmt.Println(page)
+	ListWebhookDeliveries(filter WebhookDeliveryFilter) (WebhookDeliveriesPage, errors.SDKError)
+
+	// ReplayWebhookDelivery makes a delivered or dead webhook delivery
+	// pending again.
+	//
+	// example:
+	//  d, _ := sdk.ReplayWebhookDelivery(42)
+	//  fmt.Println(d.Status)
+	ReplayWebhookDelivery(id uint64) (WebhookDelivery, errors.SDKError)
 }
 
 func (sdk mgSDK) IssueCert(entityID, ttl string, ipAddrs []string, opts Options) (Certificate, errors.SDKError) {
@@ -282,7 +684,7 @@ func (sdk mgSDK) IssueCert(entityID, ttl string, ipAddrs []string, opts Options)
 	}
 	url := fmt.Sprintf("%s/%s", issueCertEndpoint, entityID)
 
-	url, err = sdk.withQueryParams(sdk.certsURL, url, PageMetadata{CommonName: opts.CommonName})
+	url, err = sdk.withQueryParams(sdk.certsURL, url, PageMetadata{CommonName: opts.CommonName, Issuer: opts.Issuer, Profile: opts.Profile})
 	if err != nil {
 		return Certificate{}, errors.NewSDKError(err)
 	}
@@ -349,8 +751,30 @@ func (sdk mgSDK) ViewCert(serialNumber string) (Certificate, errors.SDKError) {
 	return cert, nil
 }
 
-func (sdk mgSDK) RevokeCert(serialNumber string) errors.SDKError {
+func (sdk mgSDK) RevokeCert(serialNumber, reason string, invalidityDate time.Time) errors.SDKError {
+	r := revokeReq{
+		Reason: reason,
+	}
+	if !invalidityDate.IsZero() {
+		r.InvalidityDate = &invalidityDate
+	}
+	d, err := json.Marshal(r)
+	if err != nil {
+		return errors.NewSDKError(err)
+	}
 	url := fmt.Sprintf("%s/%s/%s/revoke", sdk.certsURL, certsEndpoint, serialNumber)
+	_, _, sdkerr := sdk.processRequest(http.MethodPatch, url, d, nil, http.StatusNoContent)
+	return sdkerr
+}
+
+func (sdk mgSDK) HoldCert(serialNumber string) errors.SDKError {
+	url := fmt.Sprintf("%s/%s/%s/hold", sdk.certsURL, certsEndpoint, serialNumber)
+	_, _, sdkerr := sdk.processRequest(http.MethodPatch, url, nil, nil, http.StatusNoContent)
+	return sdkerr
+}
+
+func (sdk mgSDK) ReleaseCert(serialNumber string) errors.SDKError {
+	url := fmt.Sprintf("%s/%s/%s/release", sdk.certsURL, certsEndpoint, serialNumber)
 	_, _, sdkerr := sdk.processRequest(http.MethodPatch, url, nil, nil, http.StatusNoContent)
 	return sdkerr
 }
@@ -500,30 +924,14 @@ func (sdk mgSDK) DownloadCA(token string) (CertificateBundle, errors.SDKError) {
 		return CertificateBundle{}, sdkerr
 	}
 
-	zipReader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
-	if err != nil {
-		return CertificateBundle{}, errors.NewSDKError(err)
-	}
-
-	var bundle CertificateBundle
-	for _, file := range zipReader.File {
-		fileContent, err := readZipFile(file)
-		if err != nil {
-			return CertificateBundle{}, errors.NewSDKError(err)
-		}
-		switch file.Name {
-		case "ca.crt":
-			bundle.Certificate = fileContent
-		case "ca.key":
-			bundle.PrivateKey = fileContent
-		}
-	}
-
-	return bundle, nil
+	return CertificateBundle{Certificate: body}, nil
 }
 
-func (sdk mgSDK) GetCAToken() (Token, errors.SDKError) {
-	url := fmt.Sprintf("%s/%s/get-ca/token", sdk.certsURL, certsEndpoint)
+func (sdk mgSDK) GetCAToken(issuer string) (Token, errors.SDKError) {
+	url, err := sdk.withQueryParams(sdk.certsURL, fmt.Sprintf("%s/get-ca/token", certsEndpoint), PageMetadata{Issuer: issuer})
+	if err != nil {
+		return Token{}, errors.NewSDKError(err)
+	}
 	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
 	if sdkerr != nil {
 		return Token{}, sdkerr
@@ -536,9 +944,49 @@ func (sdk mgSDK) GetCAToken() (Token, errors.SDKError) {
 	return tk, nil
 }
 
-func (sdk mgSDK) IssueFromCSR(entityID, ttl string, csr string) (Certificate, errors.SDKError) {
+func (sdk mgSDK) CAChain(issuer string) ([]byte, errors.SDKError) {
+	url, err := sdk.withQueryParams(sdk.certsURL, fmt.Sprintf("%s/ca/chain", certsEndpoint), PageMetadata{Issuer: issuer})
+	if err != nil {
+		return nil, errors.NewSDKError(err)
+	}
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return nil, sdkerr
+	}
+
+	return body, nil
+}
+
+func (sdk mgSDK) TrustBundle() ([]byte, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/ca/bundle", sdk.certsURL, certsEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return nil, sdkerr
+	}
+
+	return body, nil
+}
+
+func (sdk mgSDK) ExportCA(serialNumber, passphrase string) ([]byte, errors.SDKError) {
+	d, err := json.Marshal(exportCAReq{Passphrase: passphrase})
+	if err != nil {
+		return nil, errors.NewSDKError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s/ca/%s/export", sdk.certsURL, certsEndpoint, serialNumber)
+	_, body, sdkerr := sdk.processRequest(http.MethodPost, url, d, nil, http.StatusOK)
+	if sdkerr != nil {
+		return nil, sdkerr
+	}
+
+	return body, nil
+}
+
+func (sdk mgSDK) IssueFromCSR(entityID, ttl, issuer, profile, csr string) (Certificate, errors.SDKError) {
 	pm := PageMetadata{
-		TTL: ttl,
+		TTL:     ttl,
+		Issuer:  issuer,
+		Profile: profile,
 	}
 
 	r := csrReq{
@@ -567,6 +1015,385 @@ func (sdk mgSDK) IssueFromCSR(entityID, ttl string, csr string) (Certificate, er
 	return cert, nil
 }
 
+func (sdk mgSDK) ImportCA(caType, cert, key, keyRef string) (Certificate, errors.SDKError) {
+	r := importCAReq{
+		Type:        caType,
+		Certificate: cert,
+		PrivateKey:  key,
+		KeyRef:      keyRef,
+	}
+	d, err := json.Marshal(r)
+	if err != nil {
+		return Certificate{}, errors.NewSDKError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s/ca/import", sdk.certsURL, certsEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodPost, url, d, nil, http.StatusCreated)
+	if sdkerr != nil {
+		return Certificate{}, sdkerr
+	}
+
+	var ca Certificate
+	if err := json.Unmarshal(body, &ca); err != nil {
+		return Certificate{}, errors.NewSDKError(err)
+	}
+	return ca, nil
+}
+
+func (sdk mgSDK) SubmitCSR(entityID, ttl, issuer, profile, csr string) (SubmittedCSR, errors.SDKError) {
+	pm := PageMetadata{
+		TTL:     ttl,
+		Issuer:  issuer,
+		Profile: profile,
+	}
+
+	d, err := json.Marshal(csrReq{CSR: csr})
+	if err != nil {
+		return SubmittedCSR{}, errors.NewSDKError(err)
+	}
+
+	url, err := sdk.withQueryParams(sdk.certsURL, fmt.Sprintf("%s/%s/%s", certsEndpoint, csrEndpoint, entityID), pm)
+	if err != nil {
+		return SubmittedCSR{}, errors.NewSDKError(err)
+	}
+
+	_, body, sdkerr := sdk.processRequest(http.MethodPost, url, d, nil, http.StatusOK, http.StatusAccepted)
+	if sdkerr != nil {
+		return SubmittedCSR{}, sdkerr
+	}
+
+	// An issued CSR is answered with the certificate, which names the CSR by csr_id.
+	var res struct {
+		SubmittedCSR
+		CSRID string `json:"csr_id"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return SubmittedCSR{}, errors.NewSDKError(err)
+	}
+	if res.CSRID != "" {
+		res.ID = res.CSRID
+	}
+	return res.SubmittedCSR, nil
+}
+
+func (sdk mgSDK) ListCSRs(pm PageMetadata) (CSRPage, errors.SDKError) {
+	url, err := sdk.withQueryParams(sdk.certsURL, fmt.Sprintf("%s/%s", certsEndpoint, csrEndpoint), pm)
+	if err != nil {
+		return CSRPage{}, errors.NewSDKError(err)
+	}
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return CSRPage{}, sdkerr
+	}
+	var page CSRPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return CSRPage{}, errors.NewSDKError(err)
+	}
+	return page, nil
+}
+
+func (sdk mgSDK) ViewCSR(id string) (SubmittedCSR, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/%s/%s", sdk.certsURL, certsEndpoint, csrEndpoint, id)
+	return sdk.csrRequest(http.MethodGet, url, nil)
+}
+
+func (sdk mgSDK) ApproveCSR(id, ttl, profile string) (SubmittedCSR, errors.SDKError) {
+	pm := PageMetadata{
+		TTL:     ttl,
+		Profile: profile,
+	}
+	url, err := sdk.withQueryParams(sdk.certsURL, fmt.Sprintf("%s/%s/%s/approve", certsEndpoint, csrEndpoint, id), pm)
+	if err != nil {
+		return SubmittedCSR{}, errors.NewSDKError(err)
+	}
+	return sdk.csrRequest(http.MethodPatch, url, nil)
+}
+
+func (sdk mgSDK) RejectCSR(id, reason string) (SubmittedCSR, errors.SDKError) {
+	d, err := json.Marshal(rejectCSRReq{Reason: reason})
+	if err != nil {
+		return SubmittedCSR{}, errors.NewSDKError(err)
+	}
+	url := fmt.Sprintf("%s/%s/%s/%s/reject", sdk.certsURL, certsEndpoint, csrEndpoint, id)
+	return sdk.csrRequest(http.MethodPatch, url, d)
+}
+
+func (sdk mgSDK) csrRequest(method, url string, data []byte) (SubmittedCSR, errors.SDKError) {
+	_, body, sdkerr := sdk.processRequest(method, url, data, nil, http.StatusOK)
+	if sdkerr != nil {
+		return SubmittedCSR{}, sdkerr
+	}
+	var csr SubmittedCSR
+	if err := json.Unmarshal(body, &csr); err != nil {
+		return SubmittedCSR{}, errors.NewSDKError(err)
+	}
+	return csr, nil
+}
+
+func (sdk mgSDK) GenerateIntermediateCSR() (CSR, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/ca/intermediate/csr", sdk.certsURL, certsEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodPost, url, nil, nil, http.StatusCreated)
+	if sdkerr != nil {
+		return CSR{}, sdkerr
+	}
+
+	var res csrReq
+	if err := json.Unmarshal(body, &res); err != nil {
+		return CSR{}, errors.NewSDKError(err)
+	}
+	return CSR{CSR: []byte(res.CSR)}, nil
+}
+
+func (sdk mgSDK) InstallIntermediateCA(cert string) (Certificate, errors.SDKError) {
+	d, err := json.Marshal(installCAReq{Certificate: cert})
+	if err != nil {
+		return Certificate{}, errors.NewSDKError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s/ca/intermediate/install", sdk.certsURL, certsEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodPost, url, d, nil, http.StatusCreated)
+	if sdkerr != nil {
+		return Certificate{}, sdkerr
+	}
+
+	var ca Certificate
+	if err := json.Unmarshal(body, &ca); err != nil {
+		return Certificate{}, errors.NewSDKError(err)
+	}
+	return ca, nil
+}
+
+func (sdk mgSDK) CreateIssuer(name string) (Issuer, errors.SDKError) {
+	d, err := json.Marshal(issuerReq{Name: name})
+	if err != nil {
+		return Issuer{}, errors.NewSDKError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s/%s", sdk.certsURL, certsEndpoint, issuersEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodPost, url, d, nil, http.StatusCreated)
+	if sdkerr != nil {
+		return Issuer{}, sdkerr
+	}
+
+	var issuer Issuer
+	if err := json.Unmarshal(body, &issuer); err != nil {
+		return Issuer{}, errors.NewSDKError(err)
+	}
+	return issuer, nil
+}
+
+func (sdk mgSDK) ListIssuers() ([]Issuer, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/%s", sdk.certsURL, certsEndpoint, issuersEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return nil, sdkerr
+	}
+
+	var res struct {
+		Issuers []Issuer `json:"issuers"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, errors.NewSDKError(err)
+	}
+	return res.Issuers, nil
+}
+
+func (sdk mgSDK) RetireIssuer(name string) errors.SDKError {
+	url := fmt.Sprintf("%s/%s/%s/%s/retire", sdk.certsURL, certsEndpoint, issuersEndpoint, name)
+	_, _, sdkerr := sdk.processRequest(http.MethodPatch, url, nil, nil, http.StatusNoContent)
+	return sdkerr
+}
+
+func (sdk mgSDK) CreateProfile(profile Profile) (Profile, errors.SDKError) {
+	d, err := json.Marshal(profile)
+	if err != nil {
+		return Profile{}, errors.NewSDKError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s/%s", sdk.certsURL, certsEndpoint, profilesEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodPost, url, d, nil, http.StatusCreated)
+	if sdkerr != nil {
+		return Profile{}, sdkerr
+	}
+
+	var p Profile
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Profile{}, errors.NewSDKError(err)
+	}
+	return p, nil
+}
+
+func (sdk mgSDK) ViewProfile(name string) (Profile, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/%s/%s", sdk.certsURL, certsEndpoint, profilesEndpoint, name)
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return Profile{}, sdkerr
+	}
+
+	var p Profile
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Profile{}, errors.NewSDKError(err)
+	}
+	return p, nil
+}
+
+func (sdk mgSDK) ListProfiles() ([]Profile, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/%s", sdk.certsURL, certsEndpoint, profilesEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return nil, sdkerr
+	}
+
+	var res struct {
+		Profiles []Profile `json:"profiles"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, errors.NewSDKError(err)
+	}
+	return res.Profiles, nil
+}
+
+func (sdk mgSDK) UpdateProfile(profile Profile) (Profile, errors.SDKError) {
+	d, err := json.Marshal(profile)
+	if err != nil {
+		return Profile{}, errors.NewSDKError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s/%s/%s", sdk.certsURL, certsEndpoint, profilesEndpoint, profile.Name)
+	_, body, sdkerr := sdk.processRequest(http.MethodPut, url, d, nil, http.StatusOK)
+	if sdkerr != nil {
+		return Profile{}, sdkerr
+	}
+
+	var p Profile
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Profile{}, errors.NewSDKError(err)
+	}
+	return p, nil
+}
+
+func (sdk mgSDK) RemoveProfile(name string) errors.SDKError {
+	url := fmt.Sprintf("%s/%s/%s/%s", sdk.certsURL, certsEndpoint, profilesEndpoint, name)
+	_, _, sdkerr := sdk.processRequest(http.MethodDelete, url, nil, nil, http.StatusNoContent)
+	return sdkerr
+}
+
+func (sdk mgSDK) ListAuditEvents(filter AuditFilter) (AuditEventsPage, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/events?%s", sdk.certsURL, auditEndpoint, filter.query())
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return AuditEventsPage{}, sdkerr
+	}
+
+	var page AuditEventsPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return AuditEventsPage{}, errors.NewSDKError(err)
+	}
+	return page, nil
+}
+
+func (sdk mgSDK) VerifyAuditLog() (AuditVerification, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/verify", sdk.certsURL, auditEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return AuditVerification{}, sdkerr
+	}
+
+	var v AuditVerification
+	if err := json.Unmarshal(body, &v); err != nil {
+		return AuditVerification{}, errors.NewSDKError(err)
+	}
+	return v, nil
+}
+
+func (sdk mgSDK) CreateWebhook(sub WebhookSubscription) (WebhookSubscription, errors.SDKError) {
+	d, err := json.Marshal(sub)
+	if err != nil {
+		return WebhookSubscription{}, errors.NewSDKError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s/subscriptions", sdk.certsURL, webhooksEndpoint)
+	_, body, sdkerr := sdk.processRequest(http.MethodPost, url, d, nil, http.StatusCreated)
+	if sdkerr != nil {
+		return WebhookSubscription{}, sdkerr
+	}
+
+	var res WebhookSubscription
+	if err := json.Unmarshal(body, &res); err != nil {
+		return WebhookSubscription{}, errors.NewSDKError(err)
+	}
+	return res, nil
+}
+
+func (sdk mgSDK) ViewWebhook(id string) (WebhookSubscription, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/subscriptions/%s", sdk.certsURL, webhooksEndpoint, id)
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return WebhookSubscription{}, sdkerr
+	}
+
+	var sub WebhookSubscription
+	if err := json.Unmarshal(body, &sub); err != nil {
+		return WebhookSubscription{}, errors.NewSDKError(err)
+	}
+	return sub, nil
+}
+
+func (sdk mgSDK) ListWebhooks(pm PageMetadata) (WebhookSubscriptionsPage, errors.SDKError) {
+	q := url.Values{}
+	if pm.Offset != 0 {
+		q.Add("offset", strconv.FormatUint(pm.Offset, 10))
+	}
+	if pm.Limit != 0 {
+		q.Add("limit", strconv.FormatUint(pm.Limit, 10))
+	}
+	url := fmt.Sprintf("%s/%s/subscriptions?%s", sdk.certsURL, webhooksEndpoint, q.Encode())
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return WebhookSubscriptionsPage{}, sdkerr
+	}
+
+	var page WebhookSubscriptionsPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return WebhookSubscriptionsPage{}, errors.NewSDKError(err)
+	}
+	return page, nil
+}
+
+func (sdk mgSDK) RemoveWebhook(id string) errors.SDKError {
+	url := fmt.Sprintf("%s/%s/subscriptions/%s", sdk.certsURL, webhooksEndpoint, id)
+	_, _, sdkerr := sdk.processRequest(http.MethodDelete, url, nil, nil, http.StatusNoContent)
+	return sdkerr
+}
+
+func (sdk mgSDK) ListWebhookDeliveries(filter WebhookDeliveryFilter) (WebhookDeliveriesPage, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/deliveries?%s", sdk.certsURL, webhooksEndpoint, filter.query())
+	_, body, sdkerr := sdk.processRequest(http.MethodGet, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return WebhookDeliveriesPage{}, sdkerr
+	}
+
+	var page WebhookDeliveriesPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return WebhookDeliveriesPage{}, errors.NewSDKError(err)
+	}
+	return page, nil
+}
+
+func (sdk mgSDK) ReplayWebhookDelivery(id uint64) (WebhookDelivery, errors.SDKError) {
+	url := fmt.Sprintf("%s/%s/deliveries/%d/replay", sdk.certsURL, webhooksEndpoint, id)
+	_, body, sdkerr := sdk.processRequest(http.MethodPost, url, nil, nil, http.StatusOK)
+	if sdkerr != nil {
+		return WebhookDelivery{}, sdkerr
+	}
+
+	var d WebhookDelivery
+	if err := json.Unmarshal(body, &d); err != nil {
+		return WebhookDelivery{}, errors.NewSDKError(err)
+	}
+	return d, nil
+}
+
 func NewSDK(conf Config) SDK {
 	return &mgSDK{
 		certsURL: conf.CertsURL,
@@ -581,6 +1408,8 @@ func NewSDK(conf Config) SDK {
 			},
 		},
 		curlFlag: conf.CurlFlag,
+		apiKey:   conf.APIKey,
+		token:    conf.Token,
 	}
 }
 
@@ -596,6 +1425,13 @@ func (sdk mgSDK) processRequest(method, reqUrl string, data []byte, headers map[
 	// Overridden if Content-Type is passed in the headers arguments.
 	req.Header.Add("Content-Type", string(CTJSON))
 
+	if sdk.apiKey != "" {
+		req.Header.Set("X-API-Key", sdk.apiKey)
+	}
+	if sdk.token != "" {
+		req.Header.Set("Authorization", "Bearer "+sdk.token)
+	}
+
 	for key, value := range headers {
 		req.Header.Add(key, value)
 	}
@@ -656,10 +1492,67 @@ func (pm PageMetadata) query() (string, error) {
 	if pm.TTL != "" {
 		q.Add("ttl", pm.TTL)
 	}
+	if pm.Issuer != "" {
+		q.Add("issuer", pm.Issuer)
+	}
+	if pm.Profile != "" {
+		q.Add("profile", pm.Profile)
+	}
+	if pm.Status != "" {
+		q.Add("status", pm.Status)
+	}
 
 	return q.Encode(), nil
 }
 
+func (f AuditFilter) query() string {
+	q := url.Values{}
+	params := map[string]string{
+		"actor":         f.Actor,
+		"action":        f.Action,
+		"serial_number": f.SerialNumber,
+		"entity_id":     f.EntityID,
+		"result":        f.Result,
+	}
+	for k, v := range params {
+		if v != "" {
+			q.Add(k, v)
+		}
+	}
+	if !f.From.IsZero() {
+		q.Add("from", f.From.Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		q.Add("to", f.To.Format(time.RFC3339))
+	}
+	if f.Offset != 0 {
+		q.Add("offset", strconv.FormatUint(f.Offset, 10))
+	}
+	if f.Limit != 0 {
+		q.Add("limit", strconv.FormatUint(f.Limit, 10))
+	}
+
+	return q.Encode()
+}
+
+func (f WebhookDeliveryFilter) query() string {
+	q := url.Values{}
+	if f.SubscriptionID != "" {
+		q.Add("subscription_id", f.SubscriptionID)
+	}
+	if f.Status != "" {
+		q.Add("status", f.Status)
+	}
+	if f.Offset != 0 {
+		q.Add("offset", strconv.FormatUint(f.Offset, 10))
+	}
+	if f.Limit != 0 {
+		q.Add("limit", strconv.FormatUint(f.Limit, 10))
+	}
+
+	return q.Encode()
+}
+
 func readZipFile(file *zip.File) ([]byte, error) {
 	fc, err := file.Open()
 	if err != nil {
@@ -675,6 +1568,34 @@ type certReq struct {
 	Options Options  `json:"options"`
 }
 
+type revokeReq struct {
+	Reason         string     `json:"reason,omitempty"`
+	InvalidityDate *time.Time `json:"invalidity_date,omitempty"`
+}
+
 type csrReq struct {
 	CSR string `json:"csr,omitempty"`
+}
+
+type rejectCSRReq struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type importCAReq struct {
+	Type        string `json:"type"`
+	Certificate string `json:"certificate"`
+	PrivateKey  string `json:"private_key,omitempty"`
+	KeyRef      string `json:"key_ref,omitempty"`
+}
+
+type exportCAReq struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type installCAReq struct {
+	Certificate string `json:"certificate"`
+}
+
+type issuerReq struct {
+	Name string `json:"name"`
 }
\ No newline at end of file
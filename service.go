@@ -11,12 +11,13 @@ import (
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/pem"
+	"fmt"
 	"math/big"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/hantdev/certs/errors"
-	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/ocsp"
 )
 
@@ -27,6 +28,7 @@ const (
 	RootCAValidityPeriod         = time.Hour * 24 * 365 // 365 days
 	IntermediateCAVAlidityPeriod = time.Hour * 24 * 90  // 90 days
 	certValidityPeriod           = time.Hour * 24 * 30  // 30 days
+	rCertStageThreshold          = time.Hour * 24 * 60  // 60 days
 	rCertExpiryThreshold         = time.Hour * 24 * 30  // 30 days
 	iCertExpiryThreshold         = time.Hour * 24 * 10  // 10 days
 	downloadTokenExpiry          = time.Minute * 5
@@ -56,24 +58,77 @@ var (
 	ErrPubKeyType             = errors.New("unsupported public key type")
 	ErrFailedParse            = errors.New("failed to parse key PEM")
 	ErrInvalidIP              = errors.New("invalid IP address")
+	ErrKeyStoreNotConfigured  = errors.New("CA key is held by an external key store that is not configured")
 )
 
 type service struct {
-	repo           Repository
-	rootCA         *CA
-	intermediateCA *CA
+	mu       sync.RWMutex
+	repo     Repository
+	keyStore KeyStore
+	config   Config
+	rootCA   *CA
+	// roots holds the active, staged and retired root CAs published in the
+	// trust bundle by serial number.
+	roots map[string]*CA
+	// intermediates holds the active and retired issuers by serial number.
+	intermediates map[string]*CA
+	// profiles holds the read-only built-in and configured profiles by name.
+	profiles map[string]Profile
+	// crls holds the CRLs published by the CAs by serial number, guarded by crlMu.
+	crlMu sync.Mutex
+	crls  map[string]publishedCRLs
+	// responders holds the delegated OCSP responders of the CAs by serial number, guarded by ocspMu.
+	ocspMu     sync.Mutex
+	responders map[string]ocspResponder
+	// ocspResponses holds the cached signed OCSP responses, guarded by ocspCacheMu.
+	ocspCacheMu   sync.Mutex
+	ocspResponses map[ocspCacheKey]*cachedOCSPResponse
+	// tokens signs and verifies the single-use download tokens.
+	tokens tokenSigner
 }
 
 var _ Service = (*service)(nil)
 
-func NewService(ctx context.Context, repo Repository, config *Config) (Service, error) {
+// NewService returns a new certs service. If keyStore is nil, CA private keys
+// are generated in memory and persisted in the repository.
+func NewService(ctx context.Context, repo Repository, keyStore KeyStore, config *Config) (Service, error) {
 	var svc service
 
+	cfg := config.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return &svc, err
+	}
+	config = &cfg
+
 	svc.repo = repo
+	svc.keyStore = keyStore
+	svc.config = cfg
+	svc.roots = make(map[string]*CA)
+	svc.intermediates = make(map[string]*CA)
+	svc.profiles = staticProfiles(cfg)
+	svc.crls = make(map[string]publishedCRLs)
+	svc.responders = make(map[string]ocspResponder)
+	svc.ocspResponses = make(map[ocspCacheKey]*cachedOCSPResponse)
+	tokens, err := newTokenSigner(cfg.DownloadTokens)
+	if err != nil {
+		return &svc, err
+	}
+	svc.tokens = tokens
 	if err := svc.loadCACerts(ctx); err != nil {
 		return &svc, err
 	}
 
+	if err := svc.importFromConfig(ctx, config); err != nil {
+		return &svc, err
+	}
+
+	// generate the next root ca ahead of the rotation
+	if svc.shouldStage() {
+		if err := svc.stageRootCA(ctx, config); err != nil {
+			return &svc, err
+		}
+	}
+
 	// check if root ca should be rotated
 	if svc.shouldRotate(RootCA) {
 		if err := svc.rotateCA(ctx, RootCA, config); err != nil {
@@ -87,6 +142,15 @@ func NewService(ctx context.Context, repo Repository, config *Config) (Service,
 		}
 	}
 
+	if err := svc.pruneRoots(ctx); err != nil {
+		return &svc, err
+	}
+
+	go svc.publishCRLs(ctx)
+	if cfg.OCSP.Cache {
+		go svc.refreshOCSPResponses(ctx)
+	}
+
 	return &svc, nil
 }
 
@@ -95,17 +159,22 @@ func NewService(ctx context.Context, repo Repository, config *Config) (Service,
 // using the provided template and the generated private key.
 // The certificate is then stored in the repository using the CreateCert method.
 // If the root CA is not found, it returns an error.
-func (s *service) IssueCert(ctx context.Context, entityID, ttl string, ipAddrs []string, options SubjectOptions) (Certificate, error) {
-	pKey, err := rsa.GenerateKey(rand.Reader, PrivateKeyBytes)
+func (s *service) IssueCert(ctx context.Context, entityID, issuer, profile, ttl string, ipAddrs []string, options SubjectOptions) (Certificate, error) {
+	ca, err := s.issuer(issuer)
+	if err != nil {
+		return Certificate{}, err
+	}
+	p, err := s.profile(ctx, profile)
 	if err != nil {
 		return Certificate{}, err
 	}
 
-	if s.intermediateCA.Certificate == nil || s.intermediateCA.PrivateKey == nil {
-		return Certificate{}, ErrIntermediateCANotFound
+	pKey, err := rsa.GenerateKey(rand.Reader, PrivateKeyBytes)
+	if err != nil {
+		return Certificate{}, err
 	}
 
-	cert, err := s.issue(ctx, entityID, ttl, ipAddrs, options, pKey.Public(), pKey)
+	cert, err := s.issue(ctx, ca, p, entityID, ttl, ipAddrs, options, pKey.Public(), pKey)
 	if err != nil {
 		return Certificate{}, err
 	}
@@ -113,16 +182,18 @@ func (s *service) IssueCert(ctx context.Context, entityID, ttl string, ipAddrs [
 	return cert, nil
 }
 
-func (s *service) issue(ctx context.Context, entityID, ttl string, ipAddrs []string, options SubjectOptions, pubKey crypto.PublicKey, privKey crypto.PrivateKey) (Certificate, error) {
+func (s *service) issue(ctx context.Context, ca *CA, profile Profile, entityID, ttl string, ipAddrs []string, options SubjectOptions, pubKey crypto.PublicKey, privKey crypto.PrivateKey) (Certificate, error) {
+
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
 		return Certificate{}, err
 	}
 
 	subject := subjectFromOpts(options)
+	profile.applySubject(&subject)
 	if privKey != nil {
 		switch privKey.(type) {
-		case *rsa.PrivateKey, *ecdsa.PrivateKey, *ed25519.PrivateKey:
+		case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
 			break
 		default:
 			return Certificate{}, errors.Wrap(ErrCreateEntity, ErrPrivKeyType)
@@ -130,23 +201,19 @@ func (s *service) issue(ctx context.Context, entityID, ttl string, ipAddrs []str
 	}
 
 	switch pubKey.(type) {
-	case *rsa.PublicKey, *ecdsa.PublicKey, *ed25519.PublicKey:
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
 		break
 	default:
 		return Certificate{}, errors.Wrap(ErrCreateEntity, ErrPubKeyType)
 	}
 
-	// Parse the TTL if provided, otherwise use the default certValidityPeriod.
-	validity := certValidityPeriod
-	if ttl != "" {
-		validity, err = time.ParseDuration(ttl)
-		if err != nil {
-			return Certificate{}, errors.Wrap(ErrMalformedEntity, err)
-		}
+	// Use the TTL if provided, otherwise the profile default, capped by the profile max TTL.
+	validity, err := profile.validity(ttl)
+	if err != nil {
+		return Certificate{}, err
 	}
 
 	var ipArray []net.IP
-	ipArray = append(ipArray, s.intermediateCA.Certificate.IPAddresses...)
 	ipArray = append(ipArray, options.IpAddresses...)
 	for _, ip := range ipAddrs {
 		parsedIP := net.ParseIP(ip)
@@ -161,36 +228,28 @@ func (s *service) issue(ctx context.Context, entityID, ttl string, ipAddrs []str
 		Subject:               subject,
 		NotBefore:             time.Now(),
 		NotAfter:              time.Now().Add(validity),
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		KeyUsage:              profile.keyUsage(pubKey),
+		ExtKeyUsage:           profile.extKeyUsage(),
 		BasicConstraintsValid: true,
 		IsCA:                  false,
-		DNSNames:              append(s.intermediateCA.Certificate.DNSNames, options.DnsNames...),
+		DNSNames:              options.DnsNames,
 		IPAddresses:           ipArray,
+		EmailAddresses:        options.EmailAddresses,
+	}
+	s.config.authorityURLs(&template, ca)
+	if err := s.validate(ca, profile, entityID, &template, pubKey); err != nil {
+		return Certificate{}, err
 	}
 
-	var privKeyBytes []byte
-	var privKeyType string
-
+	var privKeyPEM []byte
 	if privKey != nil {
-		switch key := privKey.(type) {
-		case *rsa.PrivateKey:
-			privKeyBytes = x509.MarshalPKCS1PrivateKey(key)
-			privKeyType = RSAPrivateKey
-		case *ecdsa.PrivateKey:
-			privKeyBytes, err = x509.MarshalPKCS8PrivateKey(key)
-			privKeyType = ECPrivateKey
-		case ed25519.PrivateKey:
-			privKeyBytes, err = x509.MarshalPKCS8PrivateKey(key)
-			privKeyType = PrivateKey
-		}
-
+		privKeyPEM, err = MarshalPrivateKey(privKey)
 		if err != nil {
 			return Certificate{}, err
 		}
 	}
 
-	certBytes, err := x509.CreateCertificate(rand.Reader, &template, s.intermediateCA.Certificate, pubKey, s.intermediateCA.PrivateKey)
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, ca.Certificate, pubKey, ca.Signer)
 	if err != nil {
 		return Certificate{}, err
 	}
@@ -201,10 +260,12 @@ func (s *service) issue(ctx context.Context, entityID, ttl string, ipAddrs []str
 		ExpiryTime:   template.NotAfter,
 		Type:         ClientCert,
 		Certificate:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}),
+		IssuerSerial: ca.SerialNumber,
+		Profile:      profile.Name,
 	}
 
-	if privKeyBytes != nil {
-		dbCert.Key = pem.EncodeToMemory(&pem.Block{Type: privKeyType, Bytes: privKeyBytes})
+	if privKeyPEM != nil {
+		dbCert.Key = privKeyPEM
 	}
 
 	if err = s.repo.CreateCert(ctx, dbCert); err != nil {
@@ -218,44 +279,77 @@ func (s *service) issue(ctx context.Context, entityID, ttl string, ipAddrs []str
 		ExpiryTime:   dbCert.ExpiryTime,
 		Revoked:      dbCert.Revoked,
 		Type:         dbCert.Type,
+		IssuerSerial: dbCert.IssuerSerial,
+		Profile:      dbCert.Profile,
 	}, nil
 }
 
+// validate checks the certificate template against the profile, the issuance
+// policy and the name constraints of the issuer chain.
+func (s *service) validate(ca *CA, profile Profile, entityID string, template *x509.Certificate, pubKey crypto.PublicKey) error {
+	if err := profile.checkSANs(template); err != nil {
+		return err
+	}
+	if err := s.config.Policy.evaluate(entityID, template, pubKey); err != nil {
+		return err
+	}
+
+	return checkConstraints(s.issuerChain(ca), template)
+}
+
 // RevokeCert revokes a certificate identified by its serial number.
 // It requires a valid authentication token to authorize the revocation.
 // If the authentication fails or the certificate cannot be found, an error is returned.
 // Otherwise, the certificate is marked as revoked and updated in the repository.
-func (s *service) RevokeCert(ctx context.Context, serialNumber string) error {
+func (s *service) RevokeCert(ctx context.Context, serialNumber string, reason RevocationReason, invalidityDate time.Time) error {
+	if err := validateRevocation(reason, invalidityDate); err != nil {
+		return errors.Wrap(ErrMalformedEntity, err)
+	}
 	cert, err := s.repo.RetrieveCert(ctx, serialNumber)
 	if err != nil {
 		return errors.Wrap(ErrViewEntity, err)
 	}
+	// Revoking a revoked certificate again updates the reason but keeps the
+	// original revocation time, unless the certificate was only on hold.
+	if !cert.Revoked || cert.RevocationTime.IsZero() || cert.RevocationReason == RevocationCertificateHold {
+		cert.RevocationTime = time.Now()
+	}
 	cert.Revoked = true
-	cert.ExpiryTime = time.Now()
-	if err != s.repo.UpdateCert(ctx, cert) {
+	cert.RevocationReason = reason
+	cert.InvalidityDate = invalidityDate
+	if err := s.repo.UpdateCert(ctx, cert); err != nil {
 		return errors.Wrap(ErrUpdateEntity, err)
 	}
+	s.invalidateCRLs(cert)
+	s.invalidateOCSPResponses(cert)
 	return nil
 }
 
 // RetrieveCert retrieves a certificate with the specified serial number.
-// It requires a valid authentication token to be provided.
-// If the token is invalid or expired, an error is returned.
+// It requires a download token issued for the certificate, which is redeemed
+// once the certificate and its chain are retrieved, so that a failed
+// retrieval can be retried with the same token. If the token is invalid,
+// expired or already redeemed, an error is returned.
 // The function returns the retrieved certificate and any error encountered.
 func (s *service) RetrieveCert(ctx context.Context, token, serialNumber string) (Certificate, []byte, error) {
-	if _, err := jwt.ParseWithClaims(token, &jwt.StandardClaims{Issuer: Organization, Subject: "certs"}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(serialNumber), nil
-	}); err != nil {
-		return Certificate{}, []byte{}, errors.Wrap(err, ErrMalformedEntity)
+	claims, err := s.tokens.verify(token, TokenDownloadCert)
+	if err != nil {
+		return Certificate{}, []byte{}, errors.Wrap(ErrMalformedEntity, err)
+	}
+	if claims.Subject != serialNumber {
+		return Certificate{}, []byte{}, errors.Wrap(ErrMalformedEntity, errors.Wrap(ErrInvalidToken, errors.New("token is not valid for certificate "+serialNumber)))
 	}
 	cert, err := s.repo.RetrieveCert(ctx, serialNumber)
 	if err != nil {
 		return Certificate{}, []byte{}, errors.Wrap(ErrViewEntity, err)
 	}
-	concat, err := s.getConcatCAs(ctx)
+	concat, err := s.getConcatCAs(ctx, s.issuerOf(cert))
 	if err != nil {
 		return Certificate{}, []byte{}, errors.Wrap(ErrViewEntity, err)
 	}
+	if err := s.redeemToken(ctx, claims); err != nil {
+		return Certificate{}, []byte{}, err
+	}
 
 	return cert, concat.Certificate, nil
 }
@@ -281,17 +375,29 @@ func (s *service) ViewCert(ctx context.Context, serialNumber string) (Certificat
 	return cert, nil
 }
 
-func (s *service) ViewCA(ctx context.Context) (Certificate, error) {
-	cert, err := s.repo.RetrieveCert(ctx, s.intermediateCA.SerialNumber)
+// ViewCAChain returns the public certificates of the chain of an issuer.
+func (s *service) ViewCAChain(ctx context.Context, issuer string) (Certificate, error) {
+	ca, err := s.issuer(issuer)
 	if err != nil {
-		return Certificate{}, errors.Wrap(ErrViewEntity, err)
+		return Certificate{}, err
 	}
-	return cert, nil
+
+	return s.getConcatCAs(ctx, ca)
+}
+
+// TrustBundle returns the PEM encoded root CA certificates to trust.
+func (s *service) TrustBundle(ctx context.Context) ([]byte, error) {
+	bundle := s.trustBundle()
+	if len(bundle) == 0 {
+		return nil, ErrRootCANotFound
+	}
+
+	return bundle, nil
 }
 
 // RetrieveCertDownloadToken generates a download token for a certificate.
-// It verifies the token and serial number, and returns a signed JWT token string.
-// The token is valid for 5 minutes.
+// It returns a JWT signed with the configured secret or key, bound to the
+// serial number, which can be redeemed once before it expires.
 // Parameters:
 //   - ctx: the context.Context object for the request
 //   - serialNumber: the serial number of the certificate
@@ -300,36 +406,31 @@ func (s *service) ViewCA(ctx context.Context) (Certificate, error) {
 //   - string: the signed JWT token string
 //   - error: an error if the authentication fails or any other error occurs
 func (s *service) RetrieveCertDownloadToken(ctx context.Context, serialNumber string) (string, error) {
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{ExpiresAt: time.Now().Add(downloadTokenExpiry).Unix(), Issuer: Organization, Subject: "certs"})
-	token, err := jwtToken.SignedString([]byte(serialNumber))
-	if err != nil {
-		return "", errors.Wrap(ErrGetToken, err)
-	}
-
-	return token, nil
+	return s.tokens.issue(serialNumber, TokenDownloadCert)
 }
 
-// RetrieveCAToken generates a download token for a certificate.
-// It verifies the token and serial number, and returns a signed JWT token string.
-// The token is valid for 5 minutes.
+// RetrieveCAToken generates a download token for the CA chain of an issuer.
+// It returns a JWT signed with the configured secret or key, bound to the
+// issuer serial number, which can be redeemed once before it expires.
 // Parameters:
 //   - ctx: the context.Context object for the request
+//   - issuer: the name of the issuer, empty for the default issuer
 //
 // Returns:
 //   - string: the signed JWT token string
 //   - error: an error if the authentication fails or any other error occurs
-func (s *service) RetrieveCAToken(ctx context.Context) (string, error) {
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{ExpiresAt: time.Now().Add(downloadTokenExpiry).Unix(), Issuer: Organization, Subject: "certs"})
-	token, err := jwtToken.SignedString([]byte(s.intermediateCA.SerialNumber))
+func (s *service) RetrieveCAToken(ctx context.Context, issuer string) (string, error) {
+	ca, err := s.issuer(issuer)
 	if err != nil {
-		return "", errors.Wrap(ErrGetToken, err)
+		return "", err
 	}
 
-	return token, nil
+	return s.tokens.issue(ca.SerialNumber, TokenDownloadCA)
 }
 
 // RenewCert renews a certificate by updating its validity period and generating a new certificate.
-// It takes a context, token, and serialNumber as input parameters.
+// The renewal keeps the lifetime of the certificate and is validated like an issuance, against the
+// profile the certificate was issued with, the issuance policy and the name constraints of its issuer.
 // It returns an error if there is any issue with retrieving the certificate, parsing the certificate,
 // parsing the private key, creating a new certificate, or updating the certificate in the repository.
 func (s *service) RenewCert(ctx context.Context, serialNumber string) error {
@@ -348,23 +449,40 @@ func (s *service) RenewCert(ctx context.Context, serialNumber string) error {
 	if !oldCert.NotAfter.After(time.Now()) {
 		return ErrCertExpired
 	}
-	oldCert.NotBefore = time.Now()
-	oldCert.NotAfter = time.Now().Add(certValidityPeriod)
-	keyBlock, _ := pem.Decode(cert.Key)
-	privKey, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	profile, err := s.profile(ctx, cert.Profile)
 	if err != nil {
 		return err
 	}
-	if s.intermediateCA.Certificate == nil || s.intermediateCA.PrivateKey == nil {
+	validity, err := profile.validity(oldCert.NotAfter.Sub(oldCert.NotBefore).String())
+	if err != nil {
+		return err
+	}
+	oldCert.NotBefore = time.Now()
+	oldCert.NotAfter = time.Now().Add(validity)
+	ca := s.issuerOf(cert)
+	if ca == nil || ca.Certificate == nil || ca.Signer == nil {
 		return ErrIntermediateCANotFound
 	}
-	newCertBytes, err := x509.CreateCertificate(rand.Reader, oldCert, s.intermediateCA.Certificate, &privKey.PublicKey, s.intermediateCA.PrivateKey)
+	if ca.Retired {
+		// Certificates of a rotated issuer are reissued by its successor.
+		if ca, err = s.successor(ca); err != nil {
+			return err
+		}
+		cert.IssuerSerial = ca.SerialNumber
+	}
+	// The signature algorithm follows the key of the issuer signing the renewal.
+	oldCert.SignatureAlgorithm = x509.UnknownSignatureAlgorithm
+	s.config.authorityURLs(oldCert, ca)
+	if err := s.validate(ca, profile, cert.EntityID, oldCert, oldCert.PublicKey); err != nil {
+		return err
+	}
+	newCertBytes, err := x509.CreateCertificate(rand.Reader, oldCert, ca.Certificate, oldCert.PublicKey, ca.Signer)
 	if err != nil {
 		return err
 	}
 	cert.Certificate = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: newCertBytes})
 	cert.ExpiryTime = oldCert.NotAfter
-	if err != s.repo.UpdateCert(ctx, cert) {
+	if err := s.repo.UpdateCert(ctx, cert); err != nil {
 		return errors.Wrap(ErrUpdateEntity, err)
 	}
 	return nil
@@ -372,23 +490,25 @@ func (s *service) RenewCert(ctx context.Context, serialNumber string) error {
 
 // OCSP retrieves the OCSP response for a certificate.
 // It takes a context and serialNumber as input parameters.
-// It returns the OCSP status, the root CA certificate, the root CA private key, and an error if any issue occurs.
-// If the certificate is not found, it returns an OCSP status of Unknown.
+// It returns the OCSP status, the issuing CA whose signer signs the response, and an error if any issue occurs.
+// If the certificate is not found, it returns an OCSP status of Unknown signed by the default issuer.
 // If the certificate is revoked, it returns an OCSP status of Revoked.
 // If the server fails to retrieve the certificate, it returns an OCSP status of ServerFailed.
 // Otherwise, it returns an OCSP status of Good.
-func (s *service) OCSP(ctx context.Context, serialNumber string) (*Certificate, int, *x509.Certificate, error) {
+func (s *service) OCSP(ctx context.Context, serialNumber string) (*Certificate, int, *CA, error) {
 	cert, err := s.repo.RetrieveCert(ctx, serialNumber)
 	if err != nil {
+		defaultCA := s.issuerOf(Certificate{})
 		if errors.Contains(err, ErrNotFound) {
-			return nil, ocsp.Unknown, s.intermediateCA.Certificate, nil
+			return nil, ocsp.Unknown, defaultCA, nil
 		}
-		return nil, ocsp.ServerFailed, s.intermediateCA.Certificate, err
+		return nil, ocsp.ServerFailed, defaultCA, err
 	}
+	ca := s.issuerOf(cert)
 	if cert.Revoked {
-		return &cert, ocsp.Revoked, s.intermediateCA.Certificate, nil
+		return &cert, ocsp.Revoked, ca, nil
 	}
-	return &cert, ocsp.Good, s.intermediateCA.Certificate, nil
+	return &cert, ocsp.Good, ca, nil
 }
 
 func (s *service) GetEntityID(ctx context.Context, serialNumber string) (string, error) {
@@ -399,90 +519,74 @@ func (s *service) GetEntityID(ctx context.Context, serialNumber string) (string,
 	return cert.EntityID, nil
 }
 
-func (s *service) GenerateCRL(ctx context.Context, caType CertType) ([]byte, error) {
+func (s *service) GenerateCRL(ctx context.Context, caType CertType, issuer string) ([]byte, error) {
 	var ca *CA
+	var err error
 
 	switch caType {
 	case RootCA:
-		if s.rootCA == nil {
+		ca = s.root()
+		if ca == nil {
 			return nil, errors.New("root CA not initialized")
 		}
-		ca = s.rootCA
 	case IntermediateCA:
-		if s.intermediateCA == nil {
-			return nil, errors.New("intermediate CA not initialized")
+		ca, err = s.signingIssuer(issuer)
+		if err != nil {
+			return nil, err
 		}
-		ca = s.intermediateCA
 	default:
 		return nil, errors.New("invalid CA type")
 	}
-
-	revokedCerts, err := s.repo.ListRevokedCerts(ctx)
+	crl, err := s.publishedCRL(ctx, ca, false)
 	if err != nil {
 		return nil, err
 	}
 
-	revokedCertificates := make([]pkix.RevokedCertificate, len(revokedCerts))
-	for i, cert := range revokedCerts {
-		serialNumber := new(big.Int)
-		serialNumber.SetString(cert.SerialNumber, 10)
-		revokedCertificates[i] = pkix.RevokedCertificate{
-			SerialNumber:   serialNumber,
-			RevocationTime: cert.ExpiryTime,
-		}
-	}
-
-	// CRL valid for 24 hours
-	now := time.Now()
-	expiry := now.Add(24 * time.Hour)
-
-	crlTemplate := &x509.RevocationList{
-		Number:              big.NewInt(time.Now().UnixNano()),
-		ThisUpdate:          now,
-		NextUpdate:          expiry,
-		RevokedCertificates: revokedCertificates,
-	}
+	return crl.PEM(), nil
+}
 
-	crlBytes, err := x509.CreateRevocationList(rand.Reader, crlTemplate, ca.Certificate, ca.PrivateKey)
+// GetChainCA redeems a CA download token and returns the CA chain of the
+// issuer the token is bound to.
+func (s *service) GetChainCA(ctx context.Context, token string) (Certificate, error) {
+	claims, err := s.tokens.verify(token, TokenDownloadCA)
 	if err != nil {
-		return nil, err
+		return Certificate{}, errors.Wrap(ErrMalformedEntity, err)
 	}
-
-	pemBlock := &pem.Block{
-		Type:  "X509 CRL",
-		Bytes: crlBytes,
+	s.mu.RLock()
+	ca, ok := s.intermediates[claims.Subject]
+	s.mu.RUnlock()
+	if !ok || ca.Certificate == nil {
+		return Certificate{}, ErrIntermediateCANotFound
 	}
-	pemBytes := pem.EncodeToMemory(pemBlock)
-
-	return pemBytes, nil
-}
-
-func (s *service) GetChainCA(ctx context.Context, token string) (Certificate, error) {
-	if _, err := jwt.ParseWithClaims(token, &jwt.StandardClaims{Issuer: Organization, Subject: "certs"}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.intermediateCA.SerialNumber), nil
-	}); err != nil {
-		return Certificate{}, errors.Wrap(err, ErrMalformedEntity)
+	chain, err := s.getConcatCAs(ctx, ca)
+	if err != nil {
+		return Certificate{}, err
+	}
+	// The token is redeemed once the chain is retrieved, so that a failed
+	// retrieval can be retried with the same token.
+	if err := s.redeemToken(ctx, claims); err != nil {
+		return Certificate{}, err
 	}
 
-	return s.getConcatCAs(ctx)
+	return chain, nil
 }
 
-func (s *service) IssueFromCSR(ctx context.Context, entityID, ttl string, csr CSR) (Certificate, error) {
-	block, _ := pem.Decode(csr.CSR)
-	if block == nil {
-		return Certificate{}, errors.New("failed to parse CSR PEM")
+func (s *service) IssueFromCSR(ctx context.Context, entityID, issuer, profile, ttl string, csr CSR) (Certificate, error) {
+	ca, err := s.issuer(issuer)
+	if err != nil {
+		return Certificate{}, err
 	}
-
-	parsedCSR, err := x509.ParseCertificateRequest(block.Bytes)
+	p, err := s.profile(ctx, profile)
 	if err != nil {
-		return Certificate{}, errors.Wrap(ErrMalformedEntity, err)
+		return Certificate{}, err
 	}
 
-	if err := parsedCSR.CheckSignature(); err != nil {
-		return Certificate{}, errors.Wrap(ErrMalformedEntity, err)
+	parsedCSR, err := parseCSR(csr.CSR)
+	if err != nil {
+		return Certificate{}, err
 	}
 
-	cert, err := s.issue(ctx, entityID, ttl, nil, SubjectOptions{
+	cert, err := s.issue(ctx, ca, p, entityID, ttl, nil, SubjectOptions{
 		CommonName:         parsedCSR.Subject.CommonName,
 		Organization:       parsedCSR.Subject.Organization,
 		OrganizationalUnit: parsedCSR.Subject.OrganizationalUnit,
@@ -491,7 +595,9 @@ func (s *service) IssueFromCSR(ctx context.Context, entityID, ttl string, csr CS
 		Locality:           parsedCSR.Subject.Locality,
 		StreetAddress:      parsedCSR.Subject.StreetAddress,
 		PostalCode:         parsedCSR.Subject.PostalCode,
+		DnsNames:           parsedCSR.DNSNames,
 		IpAddresses:        parsedCSR.IPAddresses,
+		EmailAddresses:     parsedCSR.EmailAddresses,
 	}, parsedCSR.PublicKey, nil)
 	if err != nil {
 		return Certificate{}, errors.Wrap(ErrCreateEntity, err)
@@ -500,32 +606,37 @@ func (s *service) IssueFromCSR(ctx context.Context, entityID, ttl string, csr CS
 	return cert, nil
 }
 
-func (s *service) getConcatCAs(ctx context.Context) (Certificate, error) {
-	intermediateCert, err := s.repo.RetrieveCert(ctx, s.intermediateCA.SerialNumber)
-	if err != nil {
-		return Certificate{}, errors.Wrap(ErrViewEntity, err)
+func (s *service) getConcatCAs(ctx context.Context, ca *CA) (Certificate, error) {
+	rootCA := s.root()
+	if rootCA == nil || ca == nil {
+		return Certificate{}, ErrIntermediateCANotFound
 	}
-
-	rootCert, err := s.repo.RetrieveCert(ctx, s.rootCA.SerialNumber)
+	intermediateCert, err := s.repo.RetrieveCert(ctx, ca.SerialNumber)
 	if err != nil {
 		return Certificate{}, errors.Wrap(ErrViewEntity, err)
 	}
 
-	concat := string(intermediateCert.Certificate) + string(rootCert.Certificate)
+	// Old and new roots are published together while a rotation is in progress.
+	concat := string(intermediateCert.Certificate)
+	for _, cert := range s.chainRoots(ca) {
+		concat += string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
 	return Certificate{
 		Certificate: []byte(concat),
-		Key:         intermediateCert.Key,
 		ExpiryTime:  intermediateCert.ExpiryTime,
 	}, nil
 }
 
-func (s *service) generateRootCA(ctx context.Context, config Config) (*CA, error) {
-	rootKey, err := rsa.GenerateKey(rand.Reader, PrivateKeyBytes)
+// generateRootCA generates a new root CA. If a previous root is given, the new
+// root is staged for the rotation of the previous one and, if configured,
+// cross-signed by it.
+func (s *service) generateRootCA(ctx context.Context, config Config, previous *CA) (*CA, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
 	if err != nil {
 		return nil, err
 	}
 
-	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	rootKey, err := s.generateCAKey(ctx, RootCA, serialNumber, config.Root)
 	if err != nil {
 		return nil, err
 	}
@@ -545,21 +656,21 @@ func (s *service) generateRootCA(ctx context.Context, config Config) (*CA, error
 			ExtraNames: []pkix.AttributeTypeAndValue{
 				{
 					Type:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1},
-					Value: emailAddress,
+					Value: config.Root.EmailAddress,
 				},
 			},
 		},
 		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(RootCAValidityPeriod),
-		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCRLSign,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		NotAfter:              time.Now().Add(config.Root.ValidityPeriod),
+		KeyUsage:              keyUsage(rootKey.Public(), x509.KeyUsageCertSign|x509.KeyUsageDigitalSignature|x509.KeyUsageCRLSign),
 		BasicConstraintsValid: true,
 		IsCA:                  true,
 		DNSNames:              config.DNSNames,
 		IPAddresses:           config.IPAddresses,
 	}
+	config.Root.constrain(certTemplate)
 
-	certBytes, err := x509.CreateCertificate(rand.Reader, certTemplate, certTemplate, &rootKey.PublicKey, rootKey)
+	certBytes, err := x509.CreateCertificate(rand.Reader, certTemplate, certTemplate, rootKey.Public(), rootKey)
 	if err != nil {
 		return nil, err
 	}
@@ -569,25 +680,44 @@ func (s *service) generateRootCA(ctx context.Context, config Config) (*CA, error
 		return nil, err
 	}
 
-	if err := s.saveCA(ctx, cert, rootKey, RootCA); err != nil {
-		return nil, err
-	}
-
-	return &CA{
+	rootCA := &CA{
 		Type:         RootCA,
 		Certificate:  cert,
-		PrivateKey:   rootKey,
+		Signer:       rootKey,
 		SerialNumber: cert.SerialNumber.String(),
-	}, nil
+		Staged:       previous != nil,
+	}
+	record := caCertificate(cert, RootCA)
+	record.Staged = rootCA.Staged
+	if previous != nil && previous.Signer != nil && config.CrossSign {
+		if rootCA.CrossCertificate, err = crossSign(cert, previous); err != nil {
+			return nil, err
+		}
+		record.CrossCert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootCA.CrossCertificate.Raw})
+	}
+
+	if err := s.storeCA(ctx, record, rootKey); err != nil {
+		return nil, err
+	}
+
+	return rootCA, nil
 }
 
-func (s *service) saveCA(ctx context.Context, cert *x509.Certificate, privateKey *rsa.PrivateKey, CertType CertType) error {
-	dbCert := Certificate{
-		Key:          pem.EncodeToMemory(&pem.Block{Type: RSAPrivateKey, Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
-		Certificate:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
-		SerialNumber: cert.SerialNumber.String(),
-		ExpiryTime:   cert.NotAfter,
-		Type:         CertType,
+func (s *service) saveCA(ctx context.Context, cert *x509.Certificate, privateKey crypto.Signer, CertType CertType) error {
+	return s.storeCA(ctx, caCertificate(cert, CertType), privateKey)
+}
+
+// storeCA persists a CA record together with its key. Keys held by a key
+// store are persisted as references, a nil key is not persisted at all.
+func (s *service) storeCA(ctx context.Context, dbCert Certificate, privateKey crypto.Signer) error {
+	if signer, ok := privateKey.(Signer); ok && s.keyStore != nil {
+		dbCert.KeyRef = signer.KeyRef()
+	} else if privateKey != nil {
+		key, err := MarshalPrivateKey(privateKey)
+		if err != nil {
+			return errors.Wrap(ErrCreateEntity, err)
+		}
+		dbCert.Key = key
 	}
 	if err := s.repo.CreateCert(ctx, dbCert); err != nil {
 		return errors.Wrap(ErrCreateEntity, err)
@@ -595,21 +725,30 @@ func (s *service) saveCA(ctx context.Context, cert *x509.Certificate, privateKey
 	return nil
 }
 
-func (s *service) createIntermediateCA(ctx context.Context, rootCA *CA, config Config) (*CA, error) {
-	intermediateKey, err := rsa.GenerateKey(rand.Reader, PrivateKeyBytes)
+func (s *service) createIntermediateCA(ctx context.Context, rootCA *CA, name string, config Config) (*CA, error) {
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
 		return nil, err
 	}
 
-	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err := allowsSubordinateCA(rootCA.Certificate); err != nil {
+		return nil, err
+	}
+	settings := config.issuerSettings(name)
+	intermediateKey, err := s.generateCAKey(ctx, IntermediateCA, serialNumber, settings)
 	if err != nil {
 		return nil, err
 	}
 
+	commonName := config.CommonName
+	if name != DefaultIssuer {
+		commonName = fmt.Sprintf("%s %s", config.CommonName, name)
+	}
+
 	template := x509.Certificate{
 		SerialNumber: serialNumber,
 		Subject: pkix.Name{
-			CommonName:         config.CommonName,
+			CommonName:         commonName,
 			Organization:       config.Organization,
 			OrganizationalUnit: config.OrganizationalUnit,
 			Country:            config.Country,
@@ -621,21 +760,22 @@ func (s *service) createIntermediateCA(ctx context.Context, rootCA *CA, config C
 			ExtraNames: []pkix.AttributeTypeAndValue{
 				{
 					Type:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1},
-					Value: emailAddress,
+					Value: settings.EmailAddress,
 				},
 			},
 		},
 		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(IntermediateCAVAlidityPeriod),
-		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCRLSign,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		NotAfter:              time.Now().Add(settings.ValidityPeriod),
+		KeyUsage:              keyUsage(intermediateKey.Public(), x509.KeyUsageCertSign|x509.KeyUsageDigitalSignature|x509.KeyUsageCRLSign),
 		BasicConstraintsValid: true,
 		IsCA:                  true,
 		DNSNames:              config.DNSNames,
 		IPAddresses:           config.IPAddresses,
 	}
+	settings.constrain(&template)
+	config.authorityURLs(&template, rootCA)
 
-	certBytes, err := x509.CreateCertificate(rand.Reader, &template, rootCA.Certificate, &intermediateKey.PublicKey, rootCA.PrivateKey)
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, rootCA.Certificate, intermediateKey.Public(), rootCA.Signer)
 	if err != nil {
 		return nil, err
 	}
@@ -645,20 +785,37 @@ func (s *service) createIntermediateCA(ctx context.Context, rootCA *CA, config C
 		return nil, err
 	}
 
-	if err != s.saveCA(ctx, intermediateCert, intermediateKey, IntermediateCA) {
+	if err := s.storeCA(ctx, intermediateRecord(intermediateCert, rootCA, name), intermediateKey); err != nil {
 		return nil, err
 	}
 
 	intermediateCA := &CA{
 		Type:         IntermediateCA,
 		Certificate:  intermediateCert,
-		PrivateKey:   intermediateKey,
+		Signer:       intermediateKey,
 		SerialNumber: serialNumber.String(),
+		Name:         name,
 	}
 
 	return intermediateCA, nil
 }
 
+// constrain applies the path length, name constraints and extended key usages to the CA certificate template.
+func (s CASettings) constrain(template *x509.Certificate) {
+	template.MaxPathLen = s.MaxPathLen
+	template.MaxPathLenZero = s.MaxPathLenZero
+	template.PermittedDNSDomains = s.PermittedDNSDomains
+	template.ExcludedDNSDomains = s.ExcludedDNSDomains
+	template.PermittedIPRanges = s.PermittedIPRanges
+	template.ExcludedIPRanges = s.ExcludedIPRanges
+	template.PermittedEmailAddresses = s.PermittedEmailAddresses
+	template.ExcludedEmailAddresses = s.ExcludedEmailAddresses
+	template.ExtKeyUsage = nil
+	for _, eku := range s.ExtKeyUsages {
+		template.ExtKeyUsage = append(template.ExtKeyUsage, extKeyUsages[eku])
+	}
+}
+
 func subjectFromOpts(opts SubjectOptions) pkix.Name {
 	subject := pkix.Name{
 		CommonName: opts.CommonName,
@@ -692,41 +849,28 @@ func subjectFromOpts(opts SubjectOptions) pkix.Name {
 func (s *service) rotateCA(ctx context.Context, ctype CertType, config *Config) error {
 	switch ctype {
 	case RootCA:
-		certificates, err := s.repo.GetCAs(ctx)
-		if err != nil {
-			return err
-		}
-		for _, cert := range certificates {
-			if err := s.RevokeCert(ctx, cert.SerialNumber); err != nil {
+		// The staged root is activated, or a new one is generated if none was staged.
+		next := s.stagedRoot()
+		if next == nil {
+			var err error
+			if next, err = s.generateRootCA(ctx, *config, nil); err != nil {
 				return err
 			}
 		}
-		newRootCA, err := s.generateRootCA(ctx, *config)
-		if err != nil {
+		if err := s.activateRootCA(ctx, next, config); err != nil {
 			return err
 		}
-		s.rootCA = newRootCA
-		newIntermediateCA, err := s.createIntermediateCA(ctx, newRootCA, *config)
-		if err != nil {
-			return err
-		}
-		s.intermediateCA = newIntermediateCA
 
 	case IntermediateCA:
-		certificates, err := s.repo.GetCAs(ctx, IntermediateCA)
-		if err != nil {
-			return err
+		names := s.expiringIssuers()
+		if len(s.activeIssuers()) == 0 {
+			names = []string{DefaultIssuer}
 		}
-		for _, cert := range certificates {
-			if err := s.RevokeCert(ctx, cert.SerialNumber); err != nil {
+		for _, name := range names {
+			if err := s.rotateIssuer(ctx, s.rootCA, name, config); err != nil {
 				return err
 			}
 		}
-		newIntermediateCA, err := s.createIntermediateCA(ctx, s.rootCA, *config)
-		if err != nil {
-			return err
-		}
-		s.intermediateCA = newIntermediateCA
 
 	default:
 		return ErrCertInvalidType
@@ -741,20 +885,22 @@ func (s *service) shouldRotate(ctype CertType) bool {
 		if s.rootCA == nil {
 			return true
 		}
+		// An imported root without its key is managed externally.
+		if s.rootCA.Signer == nil {
+			return false
+		}
 		now := time.Now()
 
-		// Check if the certificate is expiring soon i.e., within 30 days.
-		if now.Add(rCertExpiryThreshold).After(s.rootCA.Certificate.NotAfter) {
+		// Check if the certificate is expiring soon i.e., within the rotation threshold.
+		if now.Add(s.config.Root.RotationThreshold).After(s.rootCA.Certificate.NotAfter) {
 			return true
 		}
 	case IntermediateCA:
-		if s.intermediateCA == nil {
-			return true
+		// Intermediates under an offline root are installed from a signed CSR.
+		if s.rootCA != nil && s.rootCA.Signer == nil {
+			return false
 		}
-		now := time.Now()
-
-		// Check if the certificate is expiring soon i.e., within 10 days.
-		if now.Add(iCertExpiryThreshold).After(s.intermediateCA.Certificate.NotAfter) {
+		if len(s.activeIssuers()) == 0 || len(s.expiringIssuers()) > 0 {
 			return true
 		}
 	}
@@ -762,6 +908,19 @@ func (s *service) shouldRotate(ctype CertType) bool {
 	return false
 }
 
+// expiringIssuers returns the names of the active issuers expiring soon i.e., within the rotation threshold.
+func (s *service) expiringIssuers() []string {
+	now := time.Now()
+	var names []string
+	for _, ca := range s.intermediates {
+		if !ca.Retired && now.Add(s.config.issuerSettings(ca.Name).RotationThreshold).After(ca.Certificate.NotAfter) {
+			names = append(names, ca.Name)
+		}
+	}
+
+	return names
+}
+
 func (s *service) loadCACerts(ctx context.Context) error {
 	certificates, err := s.repo.GetCAs(ctx)
 	if err != nil {
@@ -769,6 +928,9 @@ func (s *service) loadCACerts(ctx context.Context) error {
 	}
 
 	for _, c := range certificates {
+		if c.Revoked {
+			continue
+		}
 		if c.Type == RootCA {
 			rblock, _ := pem.Decode(c.Certificate)
 			if rblock == nil {
@@ -779,19 +941,26 @@ func (s *service) loadCACerts(ctx context.Context) error {
 			if err != nil {
 				return err
 			}
-			rkey, _ := pem.Decode(c.Key)
-			if rkey == nil {
-				return ErrFailedParse
-			}
-			rootKey, err := x509.ParsePKCS1PrivateKey(rkey.Bytes)
+			rootKey, err := s.loadCAKey(ctx, c)
 			if err != nil {
 				return err
 			}
-			s.rootCA = &CA{
+			rootCA := &CA{
 				Type:         c.Type,
 				Certificate:  rootCert,
-				PrivateKey:   rootKey,
+				Signer:       rootKey,
 				SerialNumber: c.SerialNumber,
+				Retired:      c.Retired,
+				Staged:       c.Staged,
+			}
+			if len(c.CrossCert) > 0 {
+				if rootCA.CrossCertificate, err = parseCACertificate(c.CrossCert); err != nil {
+					return err
+				}
+			}
+			s.roots[c.SerialNumber] = rootCA
+			if !c.Retired && !c.Staged {
+				s.rootCA = rootCA
 			}
 		}
 
@@ -804,21 +973,45 @@ func (s *service) loadCACerts(ctx context.Context) error {
 			if err != nil {
 				return err
 			}
-			ikey, _ := pem.Decode(c.Key)
-			if ikey == nil {
-				return ErrFailedParse
-			}
-			interKey, err := x509.ParsePKCS1PrivateKey(ikey.Bytes)
+			interKey, err := s.loadCAKey(ctx, c)
 			if err != nil {
 				return err
 			}
-			s.intermediateCA = &CA{
+			s.intermediates[c.SerialNumber] = &CA{
 				Type:         c.Type,
 				Certificate:  interCert,
-				PrivateKey:   interKey,
+				Signer:       interKey,
 				SerialNumber: c.SerialNumber,
+				Name:         issuerName(c.IssuerName),
+				Retired:      c.Retired,
 			}
 		}
 	}
 	return nil
+}
+
+// generateCAKey creates a new CA signing key, either in the configured key store or in memory.
+func (s *service) generateCAKey(ctx context.Context, certType CertType, serialNumber *big.Int, settings CASettings) (crypto.Signer, error) {
+	if s.keyStore == nil {
+		return GenerateKey(settings.KeyAlgorithm, settings.KeySize)
+	}
+
+	keyID := fmt.Sprintf("%s-%s", certType, serialNumber.Text(16))
+	return s.keyStore.Generate(ctx, keyID, settings.KeyAlgorithm, settings.KeySize)
+}
+
+// loadCAKey returns the signer for a persisted CA, resolving external key references through the key store.
+// A CA imported without its key, such as an offline root, has no signer.
+func (s *service) loadCAKey(ctx context.Context, c Certificate) (crypto.Signer, error) {
+	if c.KeyRef == "" && len(c.Key) == 0 {
+		return nil, nil
+	}
+	if c.KeyRef == "" {
+		return ParsePrivateKey(c.Key)
+	}
+	if s.keyStore == nil {
+		return nil, ErrKeyStoreNotConfigured
+	}
+
+	return s.keyStore.Load(ctx, c.KeyRef)
 }
\ No newline at end of file
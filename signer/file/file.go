@@ -0,0 +1,113 @@
+// Package file provides a key store that keeps CA private keys as PEM files
+// in a dedicated directory, outside of the certs database. The directory is
+// expected to be a mounted secret volume or a KMS backed file system.
+package file
+
+import (
+	"context"
+	"crypto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+const (
+	refPrefix = "file:"
+	dirMode   = 0o700
+	keyMode   = 0o600
+	keyExt    = ".pem"
+)
+
+var (
+	// ErrInvalidKeyRef indicates a key reference that does not belong to this key store.
+	ErrInvalidKeyRef = errors.New("invalid file key reference")
+
+	keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// Config defines the file key store configuration.
+type Config struct {
+	Dir string `env:"DIR" envDefault:"/keys"`
+}
+
+var _ certs.KeyStore = (*keyStore)(nil)
+
+type keyStore struct {
+	dir string
+}
+
+type signer struct {
+	crypto.Signer
+	ref string
+}
+
+func (s signer) KeyRef() string {
+	return s.ref
+}
+
+// New returns a key store that persists keys in the configured directory.
+func New(cfg Config) (certs.KeyStore, error) {
+	if err := os.MkdirAll(cfg.Dir, dirMode); err != nil {
+		return nil, err
+	}
+
+	return &keyStore{dir: cfg.Dir}, nil
+}
+
+func (ks *keyStore) Generate(_ context.Context, keyID, algorithm string, size int) (certs.Signer, error) {
+	if !keyIDPattern.MatchString(keyID) {
+		return nil, ErrInvalidKeyRef
+	}
+
+	key, err := certs.GenerateKey(algorithm, size)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := certs.MarshalPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(ks.path(keyID), os.O_WRONLY|os.O_CREATE|os.O_EXCL, keyMode)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return nil, err
+	}
+
+	return signer{Signer: key, ref: refPrefix + keyID}, nil
+}
+
+func (ks *keyStore) Load(_ context.Context, keyRef string) (certs.Signer, error) {
+	keyID, ok := strings.CutPrefix(keyRef, refPrefix)
+	if !ok || !keyIDPattern.MatchString(keyID) {
+		return nil, ErrInvalidKeyRef
+	}
+
+	data, err := os.ReadFile(ks.path(keyID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrap(certs.ErrNotFound, err)
+		}
+		return nil, err
+	}
+
+	key, err := certs.ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer{Signer: key, ref: keyRef}, nil
+}
+
+func (ks *keyStore) path(keyID string) string {
+	return filepath.Join(ks.dir, keyID+keyExt)
+}
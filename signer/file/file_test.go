@@ -0,0 +1,117 @@
+package file_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/signer/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAndLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	ks, err := file.New(file.Config{Dir: dir})
+	require.NoError(t, err)
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+	testCases := []struct {
+		desc      string
+		keyID     string
+		algorithm string
+		size      int
+		pubKey    any
+		err       error
+	}{
+		{desc: "RSA key", keyID: "rsa", algorithm: certs.KeyAlgorithmRSA, size: 2048, pubKey: &rsa.PublicKey{}},
+		{desc: "ECDSA P-256 key", keyID: "ecdsa-256", algorithm: certs.KeyAlgorithmECDSA, size: 256, pubKey: &ecdsa.PublicKey{}},
+		{desc: "ECDSA P-384 key", keyID: "ecdsa-384", algorithm: certs.KeyAlgorithmECDSA, size: 384, pubKey: &ecdsa.PublicKey{}},
+		{desc: "Ed25519 key", keyID: "ed25519", algorithm: certs.KeyAlgorithmEd25519, pubKey: ed25519.PublicKey{}},
+		{desc: "unknown key algorithm", keyID: "dsa", algorithm: "dsa", err: certs.ErrKeyAlgorithm},
+		{desc: "key ID outside the directory", keyID: "../root", algorithm: certs.KeyAlgorithmECDSA, size: 256, err: file.ErrInvalidKeyRef},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, err := ks.Generate(context.Background(), tc.keyID, tc.algorithm, tc.size)
+			if tc.err != nil {
+				assert.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "file:"+tc.keyID, s.KeyRef())
+			assert.IsType(t, tc.pubKey, s.Public())
+
+			info, err := os.Stat(filepath.Join(dir, tc.keyID+".pem"))
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+			loaded, err := ks.Load(context.Background(), s.KeyRef())
+			require.NoError(t, err)
+			assert.Equal(t, s.KeyRef(), loaded.KeyRef())
+			assert.True(t, s.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(loaded.Public()))
+
+			// The loaded key signs for the generated one.
+			msg := []byte("certs")
+			var opts crypto.SignerOpts = crypto.Hash(0)
+			if _, ok := s.Public().(ed25519.PublicKey); !ok {
+				digest := sha256.Sum256(msg)
+				msg, opts = digest[:], crypto.SHA256
+			}
+			signature, err := loaded.Sign(rand.Reader, msg, opts)
+			require.NoError(t, err)
+			switch pub := s.Public().(type) {
+			case *rsa.PublicKey:
+				assert.NoError(t, rsa.VerifyPKCS1v15(pub, crypto.SHA256, msg, signature))
+			case *ecdsa.PublicKey:
+				assert.True(t, ecdsa.VerifyASN1(pub, msg, signature))
+			case ed25519.PublicKey:
+				assert.True(t, ed25519.Verify(pub, msg, signature))
+			}
+
+			// Existing keys are never overwritten.
+			_, err = ks.Generate(context.Background(), tc.keyID, tc.algorithm, tc.size)
+			assert.True(t, os.IsExist(err), "expected an existing file error, got %v", err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	ks, err := file.New(file.Config{Dir: dir})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "corrupt.pem"), []byte("not a key"), 0o600))
+
+	testCases := []struct {
+		desc   string
+		keyRef string
+		err    error
+	}{
+		{desc: "missing key file", keyRef: "file:missing", err: certs.ErrNotFound},
+		{desc: "reference of another key store", keyRef: "pkcs11:root", err: file.ErrInvalidKeyRef},
+		{desc: "reference outside the directory", keyRef: "file:../root", err: file.ErrInvalidKeyRef},
+		{desc: "empty reference", keyRef: "file:", err: file.ErrInvalidKeyRef},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ks.Load(context.Background(), tc.keyRef)
+			assert.True(t, errors.Contains(err, tc.err), "expected error %v, got %v", tc.err, err)
+		})
+	}
+
+	_, err = ks.Load(context.Background(), "file:corrupt")
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+//go:build pkcs11
+
+// Package pkcs11 provides a key store backed by a PKCS#11 token such as an
+// HSM or SoftHSM. CA private keys are generated on the token and never leave
+// it; only a reference to the key pair is persisted in the certs database.
+//
+// The package requires cgo and is only built with the pkcs11 build tag.
+package pkcs11
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ThalesGroup/crypto11"
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+)
+
+const refPrefix = "pkcs11:"
+
+var (
+	// ErrInvalidKeyRef indicates a key reference that does not belong to this key store.
+	ErrInvalidKeyRef = errors.New("invalid PKCS#11 key reference")
+
+	// ErrKeyNotFound indicates that the referenced key pair does not exist on the token.
+	ErrKeyNotFound = errors.New("PKCS#11 key pair not found")
+)
+
+// Config defines the PKCS#11 key store configuration.
+type Config struct {
+	Path       string `env:"PATH"        envDefault:""`
+	TokenLabel string `env:"TOKEN_LABEL" envDefault:""`
+	Pin        string `env:"PIN"         envDefault:""`
+}
+
+// KeyStore is a certs.KeyStore that must be closed to release the token sessions.
+type KeyStore interface {
+	certs.KeyStore
+
+	// Close releases the PKCS#11 context.
+	Close() error
+}
+
+var _ KeyStore = (*keyStore)(nil)
+
+type keyStore struct {
+	ctx *crypto11.Context
+}
+
+type signer struct {
+	crypto11.Signer
+	ref string
+}
+
+func (s signer) KeyRef() string {
+	return s.ref
+}
+
+// New opens the configured PKCS#11 module and logs in to the token.
+func New(cfg Config) (KeyStore, error) {
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       cfg.Path,
+		TokenLabel: cfg.TokenLabel,
+		Pin:        cfg.Pin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyStore{ctx: ctx}, nil
+}
+
+func (ks *keyStore) Generate(_ context.Context, keyID, algorithm string, size int) (certs.Signer, error) {
+	id := []byte(keyID)
+
+	var (
+		key crypto11.Signer
+		err error
+	)
+	switch strings.ToLower(algorithm) {
+	case "", certs.KeyAlgorithmRSA:
+		if size == 0 {
+			size = certs.PrivateKeyBytes
+		}
+		key, err = ks.ctx.GenerateRSAKeyPairWithLabel(id, id, size)
+	case certs.KeyAlgorithmECDSA:
+		curve, cerr := certs.ECDSACurve(size)
+		if cerr != nil {
+			return nil, cerr
+		}
+		key, err = ks.ctx.GenerateECDSAKeyPairWithLabel(id, id, curve)
+	default:
+		return nil, certs.ErrKeyAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return signer{Signer: key, ref: refPrefix + keyID}, nil
+}
+
+func (ks *keyStore) Load(_ context.Context, keyRef string) (certs.Signer, error) {
+	keyID, ok := strings.CutPrefix(keyRef, refPrefix)
+	if !ok || keyID == "" {
+		return nil, ErrInvalidKeyRef
+	}
+
+	key, err := ks.ctx.FindKeyPair([]byte(keyID), nil)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	return signer{Signer: key, ref: keyRef}, nil
+}
+
+func (ks *keyStore) Close() error {
+	return ks.ctx.Close()
+}
@@ -0,0 +1,85 @@
+//go:build pkcs11
+
+package pkcs11_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/signer/pkcs11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests run against SoftHSM. Initialise a token and export its settings, e.g.
+//
+//	softhsm2-util --init-token --free --label certs --pin 1234 --so-pin 1234
+//	export AM_CERTS_TEST_PKCS11_PATH=/usr/lib/softhsm/libsofthsm2.so
+//	export AM_CERTS_TEST_PKCS11_TOKEN_LABEL=certs AM_CERTS_TEST_PKCS11_PIN=1234
+//	go test -tags pkcs11 ./signer/pkcs11/...
+func newKeyStore(t *testing.T) pkcs11.KeyStore {
+	path := os.Getenv("AM_CERTS_TEST_PKCS11_PATH")
+	if path == "" {
+		t.Skip("AM_CERTS_TEST_PKCS11_PATH is not set, skipping PKCS#11 tests")
+	}
+
+	ks, err := pkcs11.New(pkcs11.Config{
+		Path:       path,
+		TokenLabel: os.Getenv("AM_CERTS_TEST_PKCS11_TOKEN_LABEL"),
+		Pin:        os.Getenv("AM_CERTS_TEST_PKCS11_PIN"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { ks.Close() })
+
+	return ks
+}
+
+func TestGenerateAndLoad(t *testing.T) {
+	ks := newKeyStore(t)
+
+	testCases := []struct {
+		desc      string
+		algorithm string
+		size      int
+		err       error
+	}{
+		{desc: "RSA key", algorithm: certs.KeyAlgorithmRSA, size: 2048},
+		{desc: "ECDSA P-256 key", algorithm: certs.KeyAlgorithmECDSA, size: 256},
+		{desc: "ECDSA P-384 key", algorithm: certs.KeyAlgorithmECDSA, size: 384},
+		{desc: "Ed25519 key", algorithm: certs.KeyAlgorithmEd25519, err: certs.ErrKeyAlgorithm},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			keyID := fmt.Sprintf("test-%s-%d-%d", tc.algorithm, tc.size, time.Now().UnixNano())
+			s, err := ks.Generate(context.Background(), keyID, tc.algorithm, tc.size)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			loaded, err := ks.Load(context.Background(), s.KeyRef())
+			require.NoError(t, err)
+			assert.Equal(t, s.Public(), loaded.Public())
+
+			digest := sha256.Sum256([]byte("certs"))
+			_, err = loaded.Sign(rand.Reader, digest[:], crypto.SHA256)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadInvalidRef(t *testing.T) {
+	ks := newKeyStore(t)
+
+	_, err := ks.Load(context.Background(), "file:root")
+	assert.ErrorIs(t, err, pkcs11.ErrInvalidKeyRef)
+}
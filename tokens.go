@@ -0,0 +1,183 @@
+package certs
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/uuid"
+)
+
+// minTokenSecretSize is the minimum size in bytes of the secret download
+// tokens are signed with.
+const minTokenSecretSize = 32
+
+var (
+	// ErrInvalidToken indicates a download token which is malformed, expired,
+	// not signed by the service or not valid for the requested download.
+	ErrInvalidToken = errors.New("invalid download token")
+	// ErrTokenRedeemed indicates a download token which has already been used.
+	ErrTokenRedeemed = errors.New("download token has already been redeemed")
+)
+
+// TokenAction is the download a token is valid for.
+type TokenAction string
+
+const (
+	// TokenDownloadCert allows downloading a certificate and its private key.
+	TokenDownloadCert TokenAction = "download_cert"
+	// TokenDownloadCA allows downloading the CA chain of an issuer.
+	TokenDownloadCA TokenAction = "download_ca"
+)
+
+// TokenSettings holds how download tokens are signed and how long they are
+// valid. Tokens are signed with the secret using HMAC-SHA256, or with the
+// private key if one is set. If neither is set, a random secret is generated
+// at start-up, so tokens are neither valid after a restart nor across
+// instances.
+type TokenSettings struct {
+	Secret []byte
+	Key    crypto.Signer
+	TTL    time.Duration
+}
+
+// downloadClaims are the claims of a download token. The subject is the
+// serial number of the certificate the token is bound to and the ID is the
+// unique ID the redemption is recorded under.
+type downloadClaims struct {
+	jwt.StandardClaims
+	Action TokenAction `json:"act"`
+}
+
+// tokenSigner signs and verifies download tokens.
+type tokenSigner struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	ttl       time.Duration
+}
+
+// withDefaults fills the unset download token settings with the built-in defaults.
+func (c TokenSettings) withDefaults() TokenSettings {
+	if c.TTL == 0 {
+		c.TTL = downloadTokenExpiry
+	}
+
+	return c
+}
+
+func (c TokenSettings) validate() error {
+	switch {
+	case c.TTL <= 0:
+		return errors.New("ttl must be positive")
+	case c.Secret != nil && c.Key != nil:
+		return errors.New("either a secret or a key must be set, not both")
+	case c.Secret != nil && len(c.Secret) < minTokenSecretSize:
+		return errors.New("secret must be at least 32 bytes long")
+	}
+
+	return nil
+}
+
+func newTokenSigner(c TokenSettings) (tokenSigner, error) {
+	signer := tokenSigner{ttl: c.TTL}
+	switch key := c.Key.(type) {
+	case nil:
+		secret := c.Secret
+		if secret == nil {
+			secret = make([]byte, minTokenSecretSize)
+			if _, err := rand.Read(secret); err != nil {
+				return tokenSigner{}, err
+			}
+		}
+		signer.method, signer.signKey, signer.verifyKey = jwt.SigningMethodHS256, secret, secret
+	case *rsa.PrivateKey:
+		signer.method, signer.signKey, signer.verifyKey = jwt.SigningMethodRS256, key, &key.PublicKey
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			signer.method = jwt.SigningMethodES256
+		case elliptic.P384():
+			signer.method = jwt.SigningMethodES384
+		case elliptic.P521():
+			signer.method = jwt.SigningMethodES512
+		default:
+			return tokenSigner{}, ErrPrivKeyType
+		}
+		signer.signKey, signer.verifyKey = key, &key.PublicKey
+	case ed25519.PrivateKey:
+		signer.method, signer.signKey, signer.verifyKey = jwt.SigningMethodEdDSA, key, key.Public()
+	default:
+		return tokenSigner{}, ErrPrivKeyType
+	}
+
+	return signer, nil
+}
+
+// issue returns a token for a single download of the certificate with the
+// serial number.
+func (t tokenSigner) issue(serialNumber string, action TokenAction) (string, error) {
+	id, err := uuid.New().ID()
+	if err != nil {
+		return "", errors.Wrap(ErrGetToken, err)
+	}
+	now := time.Now()
+	token, err := jwt.NewWithClaims(t.method, downloadClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        id,
+			Issuer:    Organization,
+			Subject:   serialNumber,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(t.ttl).Unix(),
+		},
+		Action: action,
+	}).SignedString(t.signKey)
+	if err != nil {
+		return "", errors.Wrap(ErrGetToken, err)
+	}
+
+	return token, nil
+}
+
+// verify returns the claims of a valid token for the action.
+func (t tokenSigner) verify(token string, action TokenAction) (downloadClaims, error) {
+	var claims downloadClaims
+	if _, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != t.method.Alg() {
+			return nil, errors.New("unexpected signing algorithm " + token.Method.Alg())
+		}
+		return t.verifyKey, nil
+	}); err != nil {
+		return downloadClaims{}, errors.Wrap(ErrInvalidToken, err)
+	}
+	switch {
+	case claims.Id == "", claims.ExpiresAt == 0, claims.Subject == "":
+		return downloadClaims{}, errors.Wrap(ErrInvalidToken, errors.New("missing claims"))
+	case claims.Issuer != Organization:
+		return downloadClaims{}, errors.Wrap(ErrInvalidToken, errors.New("unexpected issuer"))
+	case claims.Action != action:
+		return downloadClaims{}, errors.Wrap(ErrInvalidToken, errors.New("token is not valid for "+string(action)))
+	}
+
+	return claims, nil
+}
+
+// redeemToken records the redemption of a verified token, so that it cannot
+// be replayed.
+func (s *service) redeemToken(ctx context.Context, claims downloadClaims) error {
+	if err := s.repo.RedeemToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		if errors.Contains(err, ErrConflict) {
+			return errors.Wrap(ErrMalformedEntity, ErrTokenRedeemed)
+		}
+		return errors.Wrap(ErrUpdateEntity, err)
+	}
+
+	return nil
+}
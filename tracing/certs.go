@@ -2,10 +2,11 @@ package tracing
 
 import (
 	"context"
-	"crypto/x509"
+	"time"
 
 	"github.com/hantdev/certs"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/ocsp"
 )
 
 var _ certs.Service = (*tracingMiddleware)(nil)
@@ -26,10 +27,10 @@ func (tm *tracingMiddleware) RenewCert(ctx context.Context, serialNumber string)
 	return tm.svc.RenewCert(ctx, serialNumber)
 }
 
-func (tm *tracingMiddleware) RevokeCert(ctx context.Context, serialNumber string) error {
+func (tm *tracingMiddleware) RevokeCert(ctx context.Context, serialNumber string, reason certs.RevocationReason, invalidityDate time.Time) error {
 	ctx, span := tm.tracer.Start(ctx, "revoke_cert")
 	defer span.End()
-	return tm.svc.RevokeCert(ctx, serialNumber)
+	return tm.svc.RevokeCert(ctx, serialNumber, reason, invalidityDate)
 }
 
 func (tm *tracingMiddleware) RetrieveCert(ctx context.Context, token, serialNumber string) (certs.Certificate, []byte, error) {
@@ -44,16 +45,16 @@ func (tm *tracingMiddleware) RetrieveCertDownloadToken(ctx context.Context, seri
 	return tm.svc.RetrieveCertDownloadToken(ctx, serialNumber)
 }
 
-func (tm *tracingMiddleware) RetrieveCAToken(ctx context.Context) (string, error) {
+func (tm *tracingMiddleware) RetrieveCAToken(ctx context.Context, issuer string) (string, error) {
 	ctx, span := tm.tracer.Start(ctx, "get_CA_download_token")
 	defer span.End()
-	return tm.svc.RetrieveCAToken(ctx)
+	return tm.svc.RetrieveCAToken(ctx, issuer)
 }
 
-func (tm *tracingMiddleware) IssueCert(ctx context.Context, entityID, ttl string, ipAddrs []string, options certs.SubjectOptions) (certs.Certificate, error) {
+func (tm *tracingMiddleware) IssueCert(ctx context.Context, entityID, issuer, profile, ttl string, ipAddrs []string, options certs.SubjectOptions) (certs.Certificate, error) {
 	ctx, span := tm.tracer.Start(ctx, "issue_cert")
 	defer span.End()
-	return tm.svc.IssueCert(ctx, entityID, ttl, ipAddrs, options)
+	return tm.svc.IssueCert(ctx, entityID, issuer, profile, ttl, ipAddrs, options)
 }
 
 func (tm *tracingMiddleware) ListCerts(ctx context.Context, pm certs.PageMetadata) (certs.CertificatePage, error) {
@@ -74,7 +75,7 @@ func (s *tracingMiddleware) ViewCert(ctx context.Context, serialNumber string) (
 	return s.svc.ViewCert(ctx, serialNumber)
 }
 
-func (tm *tracingMiddleware) OCSP(ctx context.Context, serialNumber string) (*certs.Certificate, int, *x509.Certificate, error) {
+func (tm *tracingMiddleware) OCSP(ctx context.Context, serialNumber string) (*certs.Certificate, int, *certs.CA, error) {
 	ctx, span := tm.tracer.Start(ctx, "ocsp")
 	defer span.End()
 	return tm.svc.OCSP(ctx, serialNumber)
@@ -86,10 +87,28 @@ func (tm *tracingMiddleware) GetEntityID(ctx context.Context, serialNumber strin
 	return tm.svc.GetEntityID(ctx, serialNumber)
 }
 
-func (tm *tracingMiddleware) GenerateCRL(ctx context.Context, caType certs.CertType) ([]byte, error) {
+func (tm *tracingMiddleware) GenerateCRL(ctx context.Context, caType certs.CertType, issuer string) ([]byte, error) {
 	ctx, span := tm.tracer.Start(ctx, "generate_crl")
 	defer span.End()
-	return tm.svc.GenerateCRL(ctx, caType)
+	return tm.svc.GenerateCRL(ctx, caType, issuer)
+}
+
+func (tm *tracingMiddleware) ViewCAChain(ctx context.Context, issuer string) (certs.Certificate, error) {
+	ctx, span := tm.tracer.Start(ctx, "view_ca_chain")
+	defer span.End()
+	return tm.svc.ViewCAChain(ctx, issuer)
+}
+
+func (tm *tracingMiddleware) TrustBundle(ctx context.Context) ([]byte, error) {
+	ctx, span := tm.tracer.Start(ctx, "trust_bundle")
+	defer span.End()
+	return tm.svc.TrustBundle(ctx)
+}
+
+func (tm *tracingMiddleware) ExportCA(ctx context.Context, serialNumber, passphrase string) ([]byte, error) {
+	ctx, span := tm.tracer.Start(ctx, "export_ca")
+	defer span.End()
+	return tm.svc.ExportCA(ctx, serialNumber, passphrase)
 }
 
 func (tm *tracingMiddleware) GetChainCA(ctx context.Context, token string) (certs.Certificate, error) {
@@ -98,8 +117,134 @@ func (tm *tracingMiddleware) GetChainCA(ctx context.Context, token string) (cert
 	return tm.svc.GetChainCA(ctx, token)
 }
 
-func (tm *tracingMiddleware) IssueFromCSR(ctx context.Context, entityID, ttl string, csr certs.CSR) (certs.Certificate, error) {
+func (tm *tracingMiddleware) IssueFromCSR(ctx context.Context, entityID, issuer, profile, ttl string, csr certs.CSR) (certs.Certificate, error) {
 	ctx, span := tm.tracer.Start(ctx, "issue_from_csr")
 	defer span.End()
-	return tm.svc.IssueFromCSR(ctx, entityID, ttl, csr)
+	return tm.svc.IssueFromCSR(ctx, entityID, issuer, profile, ttl, csr)
+}
+
+func (tm *tracingMiddleware) SubmitCSR(ctx context.Context, csr certs.CSR) (certs.CSR, error) {
+	ctx, span := tm.tracer.Start(ctx, "submit_csr")
+	defer span.End()
+	return tm.svc.SubmitCSR(ctx, csr)
+}
+
+func (tm *tracingMiddleware) ViewCSR(ctx context.Context, id string) (certs.CSR, error) {
+	ctx, span := tm.tracer.Start(ctx, "view_csr")
+	defer span.End()
+	return tm.svc.ViewCSR(ctx, id)
+}
+
+func (tm *tracingMiddleware) ListCSRs(ctx context.Context, pm certs.PageMetadata) (certs.CSRPage, error) {
+	ctx, span := tm.tracer.Start(ctx, "list_csrs")
+	defer span.End()
+	return tm.svc.ListCSRs(ctx, pm)
+}
+
+func (tm *tracingMiddleware) ApproveCSR(ctx context.Context, id, ttl, profile string) (certs.CSR, error) {
+	ctx, span := tm.tracer.Start(ctx, "approve_csr")
+	defer span.End()
+	return tm.svc.ApproveCSR(ctx, id, ttl, profile)
+}
+
+func (tm *tracingMiddleware) RejectCSR(ctx context.Context, id, reason string) (certs.CSR, error) {
+	ctx, span := tm.tracer.Start(ctx, "reject_csr")
+	defer span.End()
+	return tm.svc.RejectCSR(ctx, id, reason)
+}
+
+func (tm *tracingMiddleware) ImportCA(ctx context.Context, ca certs.CAImport) (certs.Certificate, error) {
+	ctx, span := tm.tracer.Start(ctx, "import_ca")
+	defer span.End()
+	return tm.svc.ImportCA(ctx, ca)
+}
+
+func (tm *tracingMiddleware) GenerateIntermediateCSR(ctx context.Context) (certs.CSR, error) {
+	ctx, span := tm.tracer.Start(ctx, "generate_intermediate_csr")
+	defer span.End()
+	return tm.svc.GenerateIntermediateCSR(ctx)
+}
+
+func (tm *tracingMiddleware) InstallIntermediateCA(ctx context.Context, cert []byte) (certs.Certificate, error) {
+	ctx, span := tm.tracer.Start(ctx, "install_intermediate_ca")
+	defer span.End()
+	return tm.svc.InstallIntermediateCA(ctx, cert)
+}
+
+func (tm *tracingMiddleware) CreateIssuer(ctx context.Context, name string) (certs.Issuer, error) {
+	ctx, span := tm.tracer.Start(ctx, "create_issuer")
+	defer span.End()
+	return tm.svc.CreateIssuer(ctx, name)
 }
+
+func (tm *tracingMiddleware) ListIssuers(ctx context.Context) ([]certs.Issuer, error) {
+	ctx, span := tm.tracer.Start(ctx, "list_issuers")
+	defer span.End()
+	return tm.svc.ListIssuers(ctx)
+}
+
+func (tm *tracingMiddleware) RetireIssuer(ctx context.Context, name string) error {
+	ctx, span := tm.tracer.Start(ctx, "retire_issuer")
+	defer span.End()
+	return tm.svc.RetireIssuer(ctx, name)
+}
+
+func (tm *tracingMiddleware) CreateProfile(ctx context.Context, profile certs.Profile) (certs.Profile, error) {
+	ctx, span := tm.tracer.Start(ctx, "create_profile")
+	defer span.End()
+	return tm.svc.CreateProfile(ctx, profile)
+}
+
+func (tm *tracingMiddleware) ViewProfile(ctx context.Context, name string) (certs.Profile, error) {
+	ctx, span := tm.tracer.Start(ctx, "view_profile")
+	defer span.End()
+	return tm.svc.ViewProfile(ctx, name)
+}
+
+func (tm *tracingMiddleware) ListProfiles(ctx context.Context) ([]certs.Profile, error) {
+	ctx, span := tm.tracer.Start(ctx, "list_profiles")
+	defer span.End()
+	return tm.svc.ListProfiles(ctx)
+}
+
+func (tm *tracingMiddleware) UpdateProfile(ctx context.Context, profile certs.Profile) (certs.Profile, error) {
+	ctx, span := tm.tracer.Start(ctx, "update_profile")
+	defer span.End()
+	return tm.svc.UpdateProfile(ctx, profile)
+}
+
+func (tm *tracingMiddleware) RemoveProfile(ctx context.Context, name string) error {
+	ctx, span := tm.tracer.Start(ctx, "remove_profile")
+	defer span.End()
+	return tm.svc.RemoveProfile(ctx, name)
+}
+
+func (tm *tracingMiddleware) ViewCACert(ctx context.Context, serialNumber string) (certs.Certificate, error) {
+	ctx, span := tm.tracer.Start(ctx, "view_ca_cert")
+	defer span.End()
+	return tm.svc.ViewCACert(ctx, serialNumber)
+}
+
+func (tm *tracingMiddleware) GenerateCACRL(ctx context.Context, serialNumber string, delta bool) (certs.CRL, error) {
+	ctx, span := tm.tracer.Start(ctx, "generate_ca_crl")
+	defer span.End()
+	return tm.svc.GenerateCACRL(ctx, serialNumber, delta)
+}
+
+func (tm *tracingMiddleware) HoldCert(ctx context.Context, serialNumber string) error {
+	ctx, span := tm.tracer.Start(ctx, "hold_cert")
+	defer span.End()
+	return tm.svc.HoldCert(ctx, serialNumber)
+}
+
+func (tm *tracingMiddleware) ReleaseCert(ctx context.Context, serialNumber string) error {
+	ctx, span := tm.tracer.Start(ctx, "release_cert")
+	defer span.End()
+	return tm.svc.ReleaseCert(ctx, serialNumber)
+}
+
+func (tm *tracingMiddleware) SignOCSP(ctx context.Context, issuerSerial string, template ocsp.Response, nonce []byte) (certs.OCSPResponse, error) {
+	ctx, span := tm.tracer.Start(ctx, "sign_ocsp")
+	defer span.End()
+	return tm.svc.SignOCSP(ctx, issuerSerial, template, nonce)
+}
\ No newline at end of file
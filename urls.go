@@ -0,0 +1,71 @@
+package certs
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// Paths of the public endpoints below the public base URLs. The CA
+// certificate and CRL paths take the serial number of the CA.
+const (
+	OCSPPath   = "/certs/ocsp"
+	CACertPath = "/certs/ca/%s"
+	CACRLPath  = "/certs/ca/%s/crl"
+)
+
+var ErrCANotFound = errors.New("CA not found")
+
+// ViewCACert retrieves the public certificate of the root or intermediate CA
+// with the given serial number, including retired CAs.
+func (s *service) ViewCACert(ctx context.Context, serialNumber string) (Certificate, error) {
+	ca := s.caBySerial(serialNumber)
+	if ca == nil {
+		return Certificate{}, ErrCANotFound
+	}
+
+	return caCertificate(ca.Certificate, ca.Type), nil
+}
+
+// caBySerial returns the root or intermediate CA with the given serial number.
+func (s *service) caBySerial(serialNumber string) *CA {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if ca, ok := s.roots[serialNumber]; ok {
+		return ca
+	}
+	if ca, ok := s.intermediates[serialNumber]; ok {
+		return ca
+	}
+
+	return nil
+}
+
+// authorityURLs sets the Authority Information Access and CRL Distribution
+// Points of a certificate issued by the given CA to the public URLs.
+func (c Config) authorityURLs(template *x509.Certificate, ca *CA) {
+	template.OCSPServer = nil
+	template.IssuingCertificateURL = nil
+	template.CRLDistributionPoints = nil
+	for _, base := range c.PublicURLs {
+		template.OCSPServer = append(template.OCSPServer, base+OCSPPath)
+		template.IssuingCertificateURL = append(template.IssuingCertificateURL, base+fmt.Sprintf(CACertPath, ca.SerialNumber))
+		template.CRLDistributionPoints = append(template.CRLDistributionPoints, base+fmt.Sprintf(CACRLPath, ca.SerialNumber))
+	}
+}
+
+func validatePublicURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.Wrap(errors.New("invalid public URL "+rawURL), err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("public URL " + rawURL + " must be an absolute http or https URL without query")
+	}
+
+	return nil
+}
@@ -0,0 +1,576 @@
+// Code generated by mockery v2.53.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+
+	webhooks "github.com/hantdev/certs/webhooks"
+)
+
+// MockRepository is an autogenerated mock type for the Repository type
+type MockRepository struct {
+	mock.Mock
+}
+
+type MockRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRepository) EXPECT() *MockRepository_Expecter {
+	return &MockRepository_Expecter{mock: &_m.Mock}
+}
+
+// AddExpiring provides a mock function with given fields: ctx, now, before
+func (_m *MockRepository) AddExpiring(ctx context.Context, now time.Time, before time.Time) error {
+	ret := _m.Called(ctx, now, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddExpiring")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) error); ok {
+		r0 = rf(ctx, now, before)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_AddExpiring_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddExpiring'
+type MockRepository_AddExpiring_Call struct {
+	*mock.Call
+}
+
+// AddExpiring is a helper method to define mock.On call
+//   - ctx context.Context
+//   - now time.Time
+//   - before time.Time
+func (_e *MockRepository_Expecter) AddExpiring(ctx interface{}, now interface{}, before interface{}) *MockRepository_AddExpiring_Call {
+	return &MockRepository_AddExpiring_Call{Call: _e.mock.On("AddExpiring", ctx, now, before)}
+}
+
+func (_c *MockRepository_AddExpiring_Call) Run(run func(ctx context.Context, now time.Time, before time.Time)) *MockRepository_AddExpiring_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockRepository_AddExpiring_Call) Return(_a0 error) *MockRepository_AddExpiring_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_AddExpiring_Call) RunAndReturn(run func(context.Context, time.Time, time.Time) error) *MockRepository_AddExpiring_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimDeliveries provides a mock function with given fields: ctx, now, until, limit
+func (_m *MockRepository) ClaimDeliveries(ctx context.Context, now time.Time, until time.Time, limit uint64) ([]webhooks.Delivery, error) {
+	ret := _m.Called(ctx, now, until, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDeliveries")
+	}
+
+	var r0 []webhooks.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, uint64) ([]webhooks.Delivery, error)); ok {
+		return rf(ctx, now, until, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, uint64) []webhooks.Delivery); ok {
+		r0 = rf(ctx, now, until, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhooks.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time, uint64) error); ok {
+		r1 = rf(ctx, now, until, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_ClaimDeliveries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimDeliveries'
+type MockRepository_ClaimDeliveries_Call struct {
+	*mock.Call
+}
+
+// ClaimDeliveries is a helper method to define mock.On call
+//   - ctx context.Context
+//   - now time.Time
+//   - until time.Time
+//   - limit uint64
+func (_e *MockRepository_Expecter) ClaimDeliveries(ctx interface{}, now interface{}, until interface{}, limit interface{}) *MockRepository_ClaimDeliveries_Call {
+	return &MockRepository_ClaimDeliveries_Call{Call: _e.mock.On("ClaimDeliveries", ctx, now, until, limit)}
+}
+
+func (_c *MockRepository_ClaimDeliveries_Call) Run(run func(ctx context.Context, now time.Time, until time.Time, limit uint64)) *MockRepository_ClaimDeliveries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time), args[2].(time.Time), args[3].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockRepository_ClaimDeliveries_Call) Return(_a0 []webhooks.Delivery, _a1 error) *MockRepository_ClaimDeliveries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_ClaimDeliveries_Call) RunAndReturn(run func(context.Context, time.Time, time.Time, uint64) ([]webhooks.Delivery, error)) *MockRepository_ClaimDeliveries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateSubscription provides a mock function with given fields: ctx, sub
+func (_m *MockRepository) CreateSubscription(ctx context.Context, sub webhooks.Subscription) error {
+	ret := _m.Called(ctx, sub)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, webhooks.Subscription) error); ok {
+		r0 = rf(ctx, sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_CreateSubscription_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateSubscription'
+type MockRepository_CreateSubscription_Call struct {
+	*mock.Call
+}
+
+// CreateSubscription is a helper method to define mock.On call
+//   - ctx context.Context
+//   - sub webhooks.Subscription
+func (_e *MockRepository_Expecter) CreateSubscription(ctx interface{}, sub interface{}) *MockRepository_CreateSubscription_Call {
+	return &MockRepository_CreateSubscription_Call{Call: _e.mock.On("CreateSubscription", ctx, sub)}
+}
+
+func (_c *MockRepository_CreateSubscription_Call) Run(run func(ctx context.Context, sub webhooks.Subscription)) *MockRepository_CreateSubscription_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(webhooks.Subscription))
+	})
+	return _c
+}
+
+func (_c *MockRepository_CreateSubscription_Call) Return(_a0 error) *MockRepository_CreateSubscription_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_CreateSubscription_Call) RunAndReturn(run func(context.Context, webhooks.Subscription) error) *MockRepository_CreateSubscription_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Dispatch provides a mock function with given fields: ctx, now, limit
+func (_m *MockRepository) Dispatch(ctx context.Context, now time.Time, limit uint64) (uint64, error) {
+	ret := _m.Called(ctx, now, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Dispatch")
+	}
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, uint64) (uint64, error)); ok {
+		return rf(ctx, now, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, uint64) uint64); ok {
+		r0 = rf(ctx, now, limit)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, uint64) error); ok {
+		r1 = rf(ctx, now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_Dispatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Dispatch'
+type MockRepository_Dispatch_Call struct {
+	*mock.Call
+}
+
+// Dispatch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - now time.Time
+//   - limit uint64
+func (_e *MockRepository_Expecter) Dispatch(ctx interface{}, now interface{}, limit interface{}) *MockRepository_Dispatch_Call {
+	return &MockRepository_Dispatch_Call{Call: _e.mock.On("Dispatch", ctx, now, limit)}
+}
+
+func (_c *MockRepository_Dispatch_Call) Run(run func(ctx context.Context, now time.Time, limit uint64)) *MockRepository_Dispatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time), args[2].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockRepository_Dispatch_Call) Return(_a0 uint64, _a1 error) *MockRepository_Dispatch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_Dispatch_Call) RunAndReturn(run func(context.Context, time.Time, uint64) (uint64, error)) *MockRepository_Dispatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListDeliveries provides a mock function with given fields: ctx, filter
+func (_m *MockRepository) ListDeliveries(ctx context.Context, filter webhooks.DeliveryFilter) (webhooks.DeliveriesPage, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDeliveries")
+	}
+
+	var r0 webhooks.DeliveriesPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, webhooks.DeliveryFilter) (webhooks.DeliveriesPage, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, webhooks.DeliveryFilter) webhooks.DeliveriesPage); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(webhooks.DeliveriesPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, webhooks.DeliveryFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_ListDeliveries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDeliveries'
+type MockRepository_ListDeliveries_Call struct {
+	*mock.Call
+}
+
+// ListDeliveries is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter webhooks.DeliveryFilter
+func (_e *MockRepository_Expecter) ListDeliveries(ctx interface{}, filter interface{}) *MockRepository_ListDeliveries_Call {
+	return &MockRepository_ListDeliveries_Call{Call: _e.mock.On("ListDeliveries", ctx, filter)}
+}
+
+func (_c *MockRepository_ListDeliveries_Call) Run(run func(ctx context.Context, filter webhooks.DeliveryFilter)) *MockRepository_ListDeliveries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(webhooks.DeliveryFilter))
+	})
+	return _c
+}
+
+func (_c *MockRepository_ListDeliveries_Call) Return(_a0 webhooks.DeliveriesPage, _a1 error) *MockRepository_ListDeliveries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_ListDeliveries_Call) RunAndReturn(run func(context.Context, webhooks.DeliveryFilter) (webhooks.DeliveriesPage, error)) *MockRepository_ListDeliveries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListSubscriptions provides a mock function with given fields: ctx, offset, limit
+func (_m *MockRepository) ListSubscriptions(ctx context.Context, offset uint64, limit uint64) (webhooks.SubscriptionsPage, error) {
+	ret := _m.Called(ctx, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSubscriptions")
+	}
+
+	var r0 webhooks.SubscriptionsPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) (webhooks.SubscriptionsPage, error)); ok {
+		return rf(ctx, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) webhooks.SubscriptionsPage); ok {
+		r0 = rf(ctx, offset, limit)
+	} else {
+		r0 = ret.Get(0).(webhooks.SubscriptionsPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, uint64) error); ok {
+		r1 = rf(ctx, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_ListSubscriptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSubscriptions'
+type MockRepository_ListSubscriptions_Call struct {
+	*mock.Call
+}
+
+// ListSubscriptions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - offset uint64
+//   - limit uint64
+func (_e *MockRepository_Expecter) ListSubscriptions(ctx interface{}, offset interface{}, limit interface{}) *MockRepository_ListSubscriptions_Call {
+	return &MockRepository_ListSubscriptions_Call{Call: _e.mock.On("ListSubscriptions", ctx, offset, limit)}
+}
+
+func (_c *MockRepository_ListSubscriptions_Call) Run(run func(ctx context.Context, offset uint64, limit uint64)) *MockRepository_ListSubscriptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uint64), args[2].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockRepository_ListSubscriptions_Call) Return(_a0 webhooks.SubscriptionsPage, _a1 error) *MockRepository_ListSubscriptions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_ListSubscriptions_Call) RunAndReturn(run func(context.Context, uint64, uint64) (webhooks.SubscriptionsPage, error)) *MockRepository_ListSubscriptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveSubscription provides a mock function with given fields: ctx, id
+func (_m *MockRepository) RemoveSubscription(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_RemoveSubscription_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveSubscription'
+type MockRepository_RemoveSubscription_Call struct {
+	*mock.Call
+}
+
+// RemoveSubscription is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockRepository_Expecter) RemoveSubscription(ctx interface{}, id interface{}) *MockRepository_RemoveSubscription_Call {
+	return &MockRepository_RemoveSubscription_Call{Call: _e.mock.On("RemoveSubscription", ctx, id)}
+}
+
+func (_c *MockRepository_RemoveSubscription_Call) Run(run func(ctx context.Context, id string)) *MockRepository_RemoveSubscription_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RemoveSubscription_Call) Return(_a0 error) *MockRepository_RemoveSubscription_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_RemoveSubscription_Call) RunAndReturn(run func(context.Context, string) error) *MockRepository_RemoveSubscription_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveDelivery provides a mock function with given fields: ctx, id
+func (_m *MockRepository) RetrieveDelivery(ctx context.Context, id uint64) (webhooks.Delivery, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveDelivery")
+	}
+
+	var r0 webhooks.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (webhooks.Delivery, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) webhooks.Delivery); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(webhooks.Delivery)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveDelivery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveDelivery'
+type MockRepository_RetrieveDelivery_Call struct {
+	*mock.Call
+}
+
+// RetrieveDelivery is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uint64
+func (_e *MockRepository_Expecter) RetrieveDelivery(ctx interface{}, id interface{}) *MockRepository_RetrieveDelivery_Call {
+	return &MockRepository_RetrieveDelivery_Call{Call: _e.mock.On("RetrieveDelivery", ctx, id)}
+}
+
+func (_c *MockRepository_RetrieveDelivery_Call) Run(run func(ctx context.Context, id uint64)) *MockRepository_RetrieveDelivery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveDelivery_Call) Return(_a0 webhooks.Delivery, _a1 error) *MockRepository_RetrieveDelivery_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveDelivery_Call) RunAndReturn(run func(context.Context, uint64) (webhooks.Delivery, error)) *MockRepository_RetrieveDelivery_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveSubscription provides a mock function with given fields: ctx, id
+func (_m *MockRepository) RetrieveSubscription(ctx context.Context, id string) (webhooks.Subscription, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveSubscription")
+	}
+
+	var r0 webhooks.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (webhooks.Subscription, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) webhooks.Subscription); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(webhooks.Subscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepository_RetrieveSubscription_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveSubscription'
+type MockRepository_RetrieveSubscription_Call struct {
+	*mock.Call
+}
+
+// RetrieveSubscription is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockRepository_Expecter) RetrieveSubscription(ctx interface{}, id interface{}) *MockRepository_RetrieveSubscription_Call {
+	return &MockRepository_RetrieveSubscription_Call{Call: _e.mock.On("RetrieveSubscription", ctx, id)}
+}
+
+func (_c *MockRepository_RetrieveSubscription_Call) Run(run func(ctx context.Context, id string)) *MockRepository_RetrieveSubscription_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepository_RetrieveSubscription_Call) Return(_a0 webhooks.Subscription, _a1 error) *MockRepository_RetrieveSubscription_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepository_RetrieveSubscription_Call) RunAndReturn(run func(context.Context, string) (webhooks.Subscription, error)) *MockRepository_RetrieveSubscription_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateDelivery provides a mock function with given fields: ctx, d
+func (_m *MockRepository) UpdateDelivery(ctx context.Context, d webhooks.Delivery) error {
+	ret := _m.Called(ctx, d)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, webhooks.Delivery) error); ok {
+		r0 = rf(ctx, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepository_UpdateDelivery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateDelivery'
+type MockRepository_UpdateDelivery_Call struct {
+	*mock.Call
+}
+
+// UpdateDelivery is a helper method to define mock.On call
+//   - ctx context.Context
+//   - d webhooks.Delivery
+func (_e *MockRepository_Expecter) UpdateDelivery(ctx interface{}, d interface{}) *MockRepository_UpdateDelivery_Call {
+	return &MockRepository_UpdateDelivery_Call{Call: _e.mock.On("UpdateDelivery", ctx, d)}
+}
+
+func (_c *MockRepository_UpdateDelivery_Call) Run(run func(ctx context.Context, d webhooks.Delivery)) *MockRepository_UpdateDelivery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(webhooks.Delivery))
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateDelivery_Call) Return(_a0 error) *MockRepository_UpdateDelivery_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepository_UpdateDelivery_Call) RunAndReturn(run func(context.Context, webhooks.Delivery) error) *MockRepository_UpdateDelivery_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRepository creates a new instance of MockRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRepository {
+	mock := &MockRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,259 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hantdev/certs"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/internal/uuid"
+)
+
+const (
+	// claimLease is how long a claimed delivery is left to the worker that
+	// claimed it before it is attempted again.
+	claimLease = 5 * time.Minute
+	// secretSize is the size of generated secrets in bytes.
+	secretSize = 32
+	// maxResponseSize bounds the response body read from receivers.
+	maxResponseSize = 64 * 1024
+)
+
+type service struct {
+	repo   Repository
+	client *http.Client
+	config Config
+}
+
+var _ Service = (*service)(nil)
+
+// NewService returns a new webhooks service. Deliveries are sent with the
+// client, or with a client with the configured timeout if it is nil.
+func NewService(repo Repository, client *http.Client, config Config) Service {
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+
+	return &service{
+		repo:   repo,
+		client: client,
+		config: config,
+	}
+}
+
+func (s *service) CreateSubscription(ctx context.Context, sub Subscription) (Subscription, error) {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscription{}, errors.Wrap(certs.ErrMalformedEntity, ErrInvalidURL)
+	}
+	for _, typ := range sub.Events {
+		if !validEvent(typ) {
+			return Subscription{}, errors.Wrap(certs.ErrMalformedEntity, ErrInvalidEvent)
+		}
+	}
+
+	if sub.ID, err = uuid.New().ID(); err != nil {
+		return Subscription{}, errors.Wrap(certs.ErrCreateEntity, err)
+	}
+	if sub.Secret == "" {
+		secret := make([]byte, secretSize)
+		if _, err := rand.Read(secret); err != nil {
+			return Subscription{}, errors.Wrap(certs.ErrCreateEntity, err)
+		}
+		sub.Secret = hex.EncodeToString(secret)
+	}
+	sub.CreatedAt = time.Now().UTC()
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return Subscription{}, errors.Wrap(certs.ErrCreateEntity, err)
+	}
+
+	return sub, nil
+}
+
+func (s *service) ViewSubscription(ctx context.Context, id string) (Subscription, error) {
+	sub, err := s.repo.RetrieveSubscription(ctx, id)
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub.Secret = ""
+
+	return sub, nil
+}
+
+func (s *service) ListSubscriptions(ctx context.Context, offset, limit uint64) (SubscriptionsPage, error) {
+	page, err := s.repo.ListSubscriptions(ctx, offset, limit)
+	if err != nil {
+		return SubscriptionsPage{}, err
+	}
+	for i := range page.Subscriptions {
+		page.Subscriptions[i].Secret = ""
+	}
+
+	return page, nil
+}
+
+func (s *service) RemoveSubscription(ctx context.Context, id string) error {
+	return s.repo.RemoveSubscription(ctx, id)
+}
+
+func (s *service) ListDeliveries(ctx context.Context, filter DeliveryFilter) (DeliveriesPage, error) {
+	return s.repo.ListDeliveries(ctx, filter)
+}
+
+func (s *service) ReplayDelivery(ctx context.Context, id uint64) (Delivery, error) {
+	d, err := s.repo.RetrieveDelivery(ctx, id)
+	if err != nil {
+		return Delivery{}, err
+	}
+	if d.Status == StatusPending {
+		return Delivery{}, errors.Wrap(certs.ErrConflict, ErrNotReplayable)
+	}
+	now := time.Now().UTC()
+	d.Status = StatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.UpdatedAt = now
+	if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+		return Delivery{}, errors.Wrap(certs.ErrUpdateEntity, err)
+	}
+
+	return d, nil
+}
+
+func (s *service) Process(ctx context.Context) error {
+	now := time.Now().UTC()
+	if s.config.ExpiryWindow > 0 {
+		if err := s.repo.AddExpiring(ctx, now, now.Add(s.config.ExpiryWindow)); err != nil {
+			return err
+		}
+	}
+	for {
+		n, err := s.repo.Dispatch(ctx, now, s.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		if n == 0 || n < s.config.BatchSize {
+			break
+		}
+	}
+
+	// A delivery that cannot be updated is attempted again once its claim
+	// expires, so the remaining deliveries are still attempted.
+	var uerr error
+	for ctx.Err() == nil {
+		deliveries, err := s.repo.ClaimDeliveries(ctx, now, now.Add(claimLease), s.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, d := range deliveries {
+			if err := s.repo.UpdateDelivery(ctx, s.deliver(ctx, d)); err != nil && uerr == nil {
+				uerr = errors.Wrap(certs.ErrUpdateEntity, err)
+			}
+		}
+		if len(deliveries) == 0 || uint64(len(deliveries)) < s.config.BatchSize {
+			break
+		}
+	}
+
+	return uerr
+}
+
+// deliver posts the event of the delivery to the subscription and returns
+// the delivery updated with the result of the attempt.
+func (s *service) deliver(ctx context.Context, d Delivery) Delivery {
+	now := time.Now().UTC()
+	d.Attempts++
+	d.UpdatedAt = now
+
+	code, err := s.post(ctx, d, now)
+	d.LastStatusCode = code
+	if err == nil {
+		d.Status = StatusDelivered
+		d.LastError = ""
+		return d
+	}
+	d.LastError = err.Error()
+	if d.Attempts >= s.config.MaxAttempts {
+		d.Status = StatusDead
+		return d
+	}
+	d.NextAttemptAt = now.Add(s.backoff(d.Attempts))
+
+	return d
+}
+
+func (s *service) post(ctx context.Context, d Delivery, now time.Time) (int, error) {
+	// Marshaling strings, numbers and times does not fail.
+	body, _ := json.Marshal(d.Event)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(d.Event.Type))
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(d.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(d.Secret, now, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay after the failed attempt, which doubles with
+// every attempt from the minimum up to the maximum backoff.
+func (s *service) backoff(attempts uint64) time.Duration {
+	d := s.config.MinBackoff
+	for i := uint64(1); i < attempts && d < s.config.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > s.config.MaxBackoff {
+		d = s.config.MaxBackoff
+	}
+
+	return d
+}
+
+func validEvent(typ EventType) bool {
+	for _, t := range EventTypes {
+		if t == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run runs a worker pass every interval until the context is done. Failed
+// passes are logged and retried on the next interval.
+func Run(ctx context.Context, svc Service, interval time.Duration, logger *slog.Logger) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := svc.Process(ctx); err != nil && ctx.Err() == nil {
+			logger.Error(fmt.Sprintf("Failed to process webhook deliveries: %s", err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
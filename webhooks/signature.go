@@ -0,0 +1,72 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// signatureVersion names the HMAC-SHA256 signature in the signature header.
+const signatureVersion = "v1"
+
+// Sign returns the signature header of a body sent at the time. It has the
+// form t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">, so that
+// receivers can reject replayed requests by their time.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+
+	return fmt.Sprintf("t=%s,%s=%s", ts, signatureVersion, hex.EncodeToString(mac(secret, ts, body)))
+}
+
+// Verify checks the signature header of a received body. Signatures made
+// more than tolerance before now are rejected, unless tolerance is zero.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts string
+	var sigs [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidSignature
+		}
+		switch key {
+		case "t":
+			ts = val
+		case signatureVersion:
+			sig, err := hex.DecodeString(val)
+			if err != nil {
+				return errors.Wrap(ErrInvalidSignature, err)
+			}
+			sigs = append(sigs, sig)
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.Wrap(ErrInvalidSignature, err)
+	}
+	if tolerance > 0 && now.Sub(time.Unix(unix, 0)) > tolerance {
+		return ErrInvalidSignature
+	}
+	expected := mac(secret, ts, body)
+	for _, sig := range sigs {
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func mac(secret, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+
+	return h.Sum(nil)
+}
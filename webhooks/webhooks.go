@@ -0,0 +1,225 @@
+// Package webhooks notifies subscribers of certificate lifecycle events.
+//
+// Issuance, renewal, revocation, hold and release of client certificates are
+// written to an outbox in the same transaction as the certificate change, and
+// certificates about to expire are added to it by the worker. The worker fans
+// the outbox out into one delivery per matching subscription and posts each
+// delivery as a JSON body signed with the subscription secret. Failed
+// deliveries are retried with exponential backoff and dead-lettered after the
+// last attempt, from where they can be replayed.
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/hantdev/certs/errors"
+)
+
+// EventType is the type of a certificate lifecycle event.
+type EventType string
+
+const (
+	EventCertIssued   EventType = "cert.issued"
+	EventCertRenewed  EventType = "cert.renewed"
+	EventCertRevoked  EventType = "cert.revoked"
+	EventCertHeld     EventType = "cert.held"
+	EventCertReleased EventType = "cert.released"
+	EventCertExpiring EventType = "cert.expiring"
+)
+
+// EventTypes are the event types subscriptions can filter on.
+var EventTypes = []EventType{EventCertIssued, EventCertRenewed, EventCertRevoked, EventCertHeld, EventCertReleased, EventCertExpiring}
+
+// Status is the status of a delivery.
+type Status string
+
+const (
+	// StatusPending deliveries are attempted when their next attempt is due.
+	StatusPending Status = "pending"
+	// StatusDelivered deliveries were acknowledged with a 2xx response.
+	StatusDelivered Status = "delivered"
+	// StatusDead deliveries failed their last attempt and are only
+	// attempted again when they are replayed.
+	StatusDead Status = "dead"
+)
+
+// Headers of the delivery requests.
+const (
+	EventHeader     = "X-Certs-Event"
+	DeliveryHeader  = "X-Certs-Delivery"
+	SignatureHeader = "X-Certs-Signature"
+)
+
+var (
+	ErrNotFound         = errors.New("webhook subscription or delivery not found")
+	ErrInvalidURL       = errors.New("invalid webhook URL, an absolute http or https URL is required")
+	ErrInvalidEvent     = errors.New("invalid webhook event type")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrNotReplayable    = errors.New("pending deliveries cannot be replayed")
+)
+
+// Config holds the webhook worker settings.
+type Config struct {
+	// Enabled serves the webhooks API and runs the delivery worker.
+	Enabled bool `env:"ENABLED" envDefault:"false"`
+	// Interval is the time between worker passes.
+	Interval time.Duration `env:"INTERVAL" envDefault:"5s"`
+	// BatchSize bounds the events and deliveries handled in one pass.
+	BatchSize uint64 `env:"BATCH_SIZE" envDefault:"100"`
+	// Timeout bounds a delivery request.
+	Timeout time.Duration `env:"TIMEOUT" envDefault:"10s"`
+	// MaxAttempts is the number of attempts after which a delivery is dead.
+	MaxAttempts uint64 `env:"MAX_ATTEMPTS" envDefault:"8"`
+	// MinBackoff is the delay before the first retry, which doubles with
+	// every further retry up to MaxBackoff.
+	MinBackoff time.Duration `env:"MIN_BACKOFF" envDefault:"30s"`
+	MaxBackoff time.Duration `env:"MAX_BACKOFF" envDefault:"1h"`
+	// ExpiryWindow is how long before their expiry certificates are
+	// announced as expiring, zero disables the announcements.
+	ExpiryWindow time.Duration `env:"EXPIRY_WINDOW" envDefault:"720h"`
+}
+
+// Subscription receives the events of its types, or of all types if it has
+// none. The secret signs the deliveries and is only returned on creation.
+type Subscription struct {
+	ID        string      `json:"id"`
+	URL       string      `json:"url"`
+	Events    []EventType `json:"events,omitempty"`
+	Secret    string      `json:"secret,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Matches reports whether the subscription receives events of the type.
+func (sub Subscription) Matches(typ EventType) bool {
+	if len(sub.Events) == 0 {
+		return true
+	}
+	for _, t := range sub.Events {
+		if t == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Event is a certificate lifecycle event. RevocationReason is only set for
+// revocations and holds, where a hold is reported as certificate_hold.
+type Event struct {
+	ID               uint64    `json:"id"`
+	Type             EventType `json:"type"`
+	Time             time.Time `json:"time"`
+	SerialNumber     string    `json:"serial_number"`
+	EntityID         string    `json:"entity_id"`
+	ExpiryTime       time.Time `json:"expiry_time"`
+	RevocationReason string    `json:"revocation_reason,omitempty"`
+}
+
+// Delivery is the delivery of an event to a subscription. The URL and
+// secret are those of the subscription.
+type Delivery struct {
+	ID             uint64    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	Event          Event     `json:"event"`
+	Status         Status    `json:"status"`
+	Attempts       uint64    `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	URL            string    `json:"-"`
+	Secret         string    `json:"-"`
+}
+
+// SubscriptionsPage is a page of subscriptions, the oldest first.
+type SubscriptionsPage struct {
+	Total         uint64         `json:"total"`
+	Offset        uint64         `json:"offset"`
+	Limit         uint64         `json:"limit"`
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+// DeliveryFilter selects deliveries. Empty fields match all deliveries.
+type DeliveryFilter struct {
+	SubscriptionID string `json:"subscription_id,omitempty"`
+	Status         Status `json:"status,omitempty"`
+	Offset         uint64 `json:"offset"`
+	Limit          uint64 `json:"limit"`
+	Total          uint64 `json:"total"`
+}
+
+// DeliveriesPage is a page of deliveries, the most recent first.
+type DeliveriesPage struct {
+	DeliveryFilter
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+// Service specifies the webhooks API.
+type Service interface {
+	// CreateSubscription validates and stores the subscription. A secret
+	// is generated unless one is given.
+	CreateSubscription(ctx context.Context, sub Subscription) (Subscription, error)
+
+	// ViewSubscription retrieves the subscription without its secret.
+	ViewSubscription(ctx context.Context, id string) (Subscription, error)
+
+	// ListSubscriptions lists the subscriptions without their secrets.
+	ListSubscriptions(ctx context.Context, offset, limit uint64) (SubscriptionsPage, error)
+
+	// RemoveSubscription removes the subscription and its deliveries.
+	RemoveSubscription(ctx context.Context, id string) error
+
+	// ListDeliveries lists the deliveries matching the filter.
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) (DeliveriesPage, error)
+
+	// ReplayDelivery makes a delivered or dead delivery pending again,
+	// with a new set of attempts starting right away.
+	ReplayDelivery(ctx context.Context, id uint64) (Delivery, error)
+
+	// Process runs one worker pass: it adds expiring certificates to the
+	// outbox, creates the deliveries of the outbox events and attempts the
+	// due deliveries.
+	Process(ctx context.Context) error
+}
+
+// Repository specifies the webhooks persistence API.
+type Repository interface {
+	// CreateSubscription stores the subscription.
+	CreateSubscription(ctx context.Context, sub Subscription) error
+
+	// RetrieveSubscription retrieves the subscription with its secret.
+	RetrieveSubscription(ctx context.Context, id string) (Subscription, error)
+
+	// ListSubscriptions lists the subscriptions, the oldest first.
+	ListSubscriptions(ctx context.Context, offset, limit uint64) (SubscriptionsPage, error)
+
+	// RemoveSubscription removes the subscription and its deliveries.
+	RemoveSubscription(ctx context.Context, id string) error
+
+	// AddExpiring adds an expiring event to the outbox for each valid
+	// client certificate expiring before the time, once per expiry time.
+	AddExpiring(ctx context.Context, now, before time.Time) error
+
+	// Dispatch takes at most limit events from the outbox and creates a
+	// pending delivery, due at now, for each subscription matching them.
+	// It returns the number of events taken.
+	Dispatch(ctx context.Context, now time.Time, limit uint64) (uint64, error)
+
+	// ClaimDeliveries retrieves at most limit pending deliveries due at now
+	// and postpones them until the time, so that no other worker attempts
+	// them meanwhile.
+	ClaimDeliveries(ctx context.Context, now, until time.Time, limit uint64) ([]Delivery, error)
+
+	// RetrieveDelivery retrieves the delivery.
+	RetrieveDelivery(ctx context.Context, id uint64) (Delivery, error)
+
+	// UpdateDelivery stores the status, attempts and last result of the
+	// delivery.
+	UpdateDelivery(ctx context.Context, d Delivery) error
+
+	// ListDeliveries lists the deliveries matching the filter, the most
+	// recent first.
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) (DeliveriesPage, error)
+}
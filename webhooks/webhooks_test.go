@@ -0,0 +1,604 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hantdev/certs"
+	webhooksapi "github.com/hantdev/certs/api/webhooks"
+	"github.com/hantdev/certs/auth"
+	"github.com/hantdev/certs/errors"
+	"github.com/hantdev/certs/webhooks"
+	wmocks "github.com/hantdev/certs/webhooks/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	serialNumber = "serial number"
+	entityID     = "entity"
+)
+
+// expiringCert is a certificate the outbox announces as expiring.
+type expiringCert struct {
+	serialNumber string
+	expiryTime   time.Time
+}
+
+// memoryOutbox is a webhooks repository backed by slices. Events are added
+// with add, as the trigger on the certs table does.
+type memoryOutbox struct {
+	mu         sync.Mutex
+	subs       []webhooks.Subscription
+	events     []webhooks.Event
+	dispatched int
+	deliveries []webhooks.Delivery
+	expiring   []expiringCert
+	announced  map[expiringCert]bool
+}
+
+func (o *memoryOutbox) add(typ webhooks.EventType) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, webhooks.Event{
+		ID:           uint64(len(o.events) + 1),
+		Type:         typ,
+		Time:         time.Now().UTC(),
+		SerialNumber: serialNumber,
+		EntityID:     entityID,
+	})
+}
+
+func (o *memoryOutbox) delivery(id uint64) webhooks.Delivery {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.deliveries[id-1]
+}
+
+// due makes the pending deliveries due right away.
+func (o *memoryOutbox) due() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := range o.deliveries {
+		o.deliveries[i].NextAttemptAt = time.Time{}
+	}
+}
+
+func (o *memoryOutbox) subscription(id string) (webhooks.Subscription, error) {
+	for _, sub := range o.subs {
+		if sub.ID == id {
+			return sub, nil
+		}
+	}
+
+	return webhooks.Subscription{}, errors.Wrap(certs.ErrNotFound, webhooks.ErrNotFound)
+}
+
+func (o *memoryOutbox) repository() *wmocks.MockRepository {
+	repo := new(wmocks.MockRepository)
+	repo.On("CreateSubscription", mock.Anything, mock.Anything).Return(func(_ context.Context, sub webhooks.Subscription) error {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.subs = append(o.subs, sub)
+		return nil
+	})
+	repo.On("RetrieveSubscription", mock.Anything, mock.Anything).Return(func(_ context.Context, id string) (webhooks.Subscription, error) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		return o.subscription(id)
+	})
+	repo.On("ListSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, offset, limit uint64) (webhooks.SubscriptionsPage, error) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		subs := append([]webhooks.Subscription{}, o.subs...)
+		return webhooks.SubscriptionsPage{Total: uint64(len(subs)), Offset: offset, Limit: limit, Subscriptions: subs}, nil
+	})
+	repo.On("RemoveSubscription", mock.Anything, mock.Anything).Return(func(_ context.Context, id string) error {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		for i, sub := range o.subs {
+			if sub.ID == id {
+				o.subs = append(o.subs[:i], o.subs[i+1:]...)
+				return nil
+			}
+		}
+		return errors.Wrap(certs.ErrNotFound, webhooks.ErrNotFound)
+	})
+	repo.On("AddExpiring", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, now, before time.Time) error {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		for _, c := range o.expiring {
+			if c.expiryTime.After(now) && !c.expiryTime.After(before) && !o.announced[c] {
+				o.announced[c] = true
+				o.events = append(o.events, webhooks.Event{
+					ID:           uint64(len(o.events) + 1),
+					Type:         webhooks.EventCertExpiring,
+					Time:         now,
+					SerialNumber: c.serialNumber,
+					ExpiryTime:   c.expiryTime,
+				})
+			}
+		}
+		return nil
+	})
+	repo.On("Dispatch", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, now time.Time, limit uint64) (uint64, error) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		var n uint64
+		for ; o.dispatched < len(o.events) && n < limit; o.dispatched++ {
+			e := o.events[o.dispatched]
+			for _, sub := range o.subs {
+				if sub.Matches(e.Type) {
+					o.deliveries = append(o.deliveries, webhooks.Delivery{
+						ID:             uint64(len(o.deliveries) + 1),
+						SubscriptionID: sub.ID,
+						Event:          e,
+						Status:         webhooks.StatusPending,
+						NextAttemptAt:  now,
+						CreatedAt:      now,
+						UpdatedAt:      now,
+					})
+				}
+			}
+			n++
+		}
+		return n, nil
+	})
+	repo.On("ClaimDeliveries", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, now, until time.Time, limit uint64) ([]webhooks.Delivery, error) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		var claimed []webhooks.Delivery
+		for i, d := range o.deliveries {
+			if d.Status != webhooks.StatusPending || d.NextAttemptAt.After(now) || uint64(len(claimed)) == limit {
+				continue
+			}
+			sub, err := o.subscription(d.SubscriptionID)
+			if err != nil {
+				return nil, err
+			}
+			o.deliveries[i].NextAttemptAt = until
+			d.NextAttemptAt, d.URL, d.Secret = until, sub.URL, sub.Secret
+			claimed = append(claimed, d)
+		}
+		return claimed, nil
+	})
+	repo.On("RetrieveDelivery", mock.Anything, mock.Anything).Return(func(_ context.Context, id uint64) (webhooks.Delivery, error) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if id == 0 || id > uint64(len(o.deliveries)) {
+			return webhooks.Delivery{}, errors.Wrap(certs.ErrNotFound, webhooks.ErrNotFound)
+		}
+		return o.deliveries[id-1], nil
+	})
+	repo.On("UpdateDelivery", mock.Anything, mock.Anything).Return(func(_ context.Context, d webhooks.Delivery) error {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		d.URL, d.Secret = "", ""
+		o.deliveries[d.ID-1] = d
+		return nil
+	})
+	repo.On("ListDeliveries", mock.Anything, mock.Anything).Return(func(_ context.Context, f webhooks.DeliveryFilter) (webhooks.DeliveriesPage, error) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		page := webhooks.DeliveriesPage{DeliveryFilter: f, Deliveries: []webhooks.Delivery{}}
+		for i := len(o.deliveries) - 1; i >= 0; i-- {
+			if d := o.deliveries[i]; f.Status == "" || d.Status == f.Status {
+				page.Deliveries = append(page.Deliveries, d)
+			}
+		}
+		page.Total = uint64(len(page.Deliveries))
+		return page, nil
+	})
+
+	return repo
+}
+
+// receiver is a webhook receiver which verifies the signatures and answers
+// with the next status code, or 200 once there are none left.
+type receiver struct {
+	t      *testing.T
+	secret string
+	mu     sync.Mutex
+	codes  []int
+	events []webhooks.Event
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(rc.t, err)
+	assert.NoError(rc.t, webhooks.Verify(rc.secret, r.Header.Get(webhooks.SignatureHeader), body, time.Now(), time.Minute))
+	var e webhooks.Event
+	require.NoError(rc.t, json.Unmarshal(body, &e))
+	assert.Equal(rc.t, string(e.Type), r.Header.Get(webhooks.EventHeader))
+	assert.NotEmpty(rc.t, r.Header.Get(webhooks.DeliveryHeader))
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.events = append(rc.events, e)
+	if len(rc.codes) > 0 {
+		code := rc.codes[0]
+		rc.codes = rc.codes[1:]
+		w.WriteHeader(code)
+	}
+}
+
+func config() webhooks.Config {
+	return webhooks.Config{
+		BatchSize:   2,
+		Timeout:     time.Second,
+		MaxAttempts: 4,
+		MinBackoff:  time.Minute,
+		MaxBackoff:  3 * time.Minute,
+	}
+}
+
+func TestCreateSubscription(t *testing.T) {
+	outbox := &memoryOutbox{}
+	svc := webhooks.NewService(outbox.repository(), nil, config())
+
+	testCases := []struct {
+		desc string
+		sub  webhooks.Subscription
+		err  error
+	}{
+		{
+			desc: "subscribe to all events",
+			sub:  webhooks.Subscription{URL: "https://example.com/hook"},
+		},
+		{
+			desc: "subscribe to some events with a secret",
+			sub:  webhooks.Subscription{URL: "http://localhost:8080/hook", Events: []webhooks.EventType{webhooks.EventCertRevoked, webhooks.EventCertExpiring}, Secret: "secret"},
+		},
+		{
+			desc: "subscribe a relative URL",
+			sub:  webhooks.Subscription{URL: "/hook"},
+			err:  webhooks.ErrInvalidURL,
+		},
+		{
+			desc: "subscribe a URL with an unsupported scheme",
+			sub:  webhooks.Subscription{URL: "ftp://example.com/hook"},
+			err:  webhooks.ErrInvalidURL,
+		},
+		{
+			desc: "subscribe to an unknown event",
+			sub:  webhooks.Subscription{URL: "https://example.com/hook", Events: []webhooks.EventType{"cert.deleted"}},
+			err:  webhooks.ErrInvalidEvent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			sub, err := svc.CreateSubscription(context.Background(), tc.sub)
+			if tc.err != nil {
+				assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected %s, got %s", tc.err, err))
+				assert.True(t, errors.Contains(err, certs.ErrMalformedEntity))
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, sub.ID)
+			assert.False(t, sub.CreatedAt.IsZero())
+			if tc.sub.Secret != "" {
+				assert.Equal(t, tc.sub.Secret, sub.Secret)
+			} else {
+				assert.Len(t, sub.Secret, 64)
+			}
+
+			view, err := svc.ViewSubscription(context.Background(), sub.ID)
+			require.NoError(t, err)
+			assert.Empty(t, view.Secret)
+			assert.Equal(t, tc.sub.Events, view.Events)
+		})
+	}
+}
+
+func TestProcess(t *testing.T) {
+	rc := &receiver{t: t, secret: "secret"}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	outbox := &memoryOutbox{announced: map[expiringCert]bool{}}
+	cfg := config()
+	cfg.ExpiryWindow = 24 * time.Hour
+	svc := webhooks.NewService(outbox.repository(), srv.Client(), cfg)
+
+	all, err := svc.CreateSubscription(context.Background(), webhooks.Subscription{URL: srv.URL, Secret: rc.secret})
+	require.NoError(t, err)
+	revoked, err := svc.CreateSubscription(context.Background(), webhooks.Subscription{URL: srv.URL, Secret: rc.secret, Events: []webhooks.EventType{webhooks.EventCertRevoked}})
+	require.NoError(t, err)
+
+	outbox.add(webhooks.EventCertIssued)
+	outbox.add(webhooks.EventCertRenewed)
+	outbox.add(webhooks.EventCertRevoked)
+	outbox.expiring = []expiringCert{
+		{serialNumber: "expiring", expiryTime: time.Now().Add(time.Hour).UTC()},
+		{serialNumber: "valid", expiryTime: time.Now().Add(48 * time.Hour).UTC()},
+	}
+
+	require.NoError(t, svc.Process(context.Background()))
+	// Expiring certificates are announced once.
+	require.NoError(t, svc.Process(context.Background()))
+
+	page, err := svc.ListDeliveries(context.Background(), webhooks.DeliveryFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Deliveries, 5)
+	subs := map[string][]webhooks.EventType{}
+	for _, d := range page.Deliveries {
+		assert.Equal(t, webhooks.StatusDelivered, d.Status)
+		assert.Equal(t, uint64(1), d.Attempts)
+		assert.Equal(t, http.StatusOK, d.LastStatusCode)
+		subs[d.SubscriptionID] = append(subs[d.SubscriptionID], d.Event.Type)
+	}
+	assert.ElementsMatch(t, []webhooks.EventType{webhooks.EventCertIssued, webhooks.EventCertRenewed, webhooks.EventCertRevoked, webhooks.EventCertExpiring}, subs[all.ID])
+	assert.Equal(t, []webhooks.EventType{webhooks.EventCertRevoked}, subs[revoked.ID])
+
+	require.Len(t, rc.events, 5)
+	for _, e := range rc.events {
+		if e.Type == webhooks.EventCertExpiring {
+			assert.Equal(t, "expiring", e.SerialNumber)
+			continue
+		}
+		assert.Equal(t, serialNumber, e.SerialNumber)
+		assert.Equal(t, entityID, e.EntityID)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	rc := &receiver{t: t, secret: "secret", codes: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusNotFound}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	outbox := &memoryOutbox{}
+	svc := webhooks.NewService(outbox.repository(), srv.Client(), config())
+	_, err := svc.CreateSubscription(context.Background(), webhooks.Subscription{URL: srv.URL, Secret: rc.secret})
+	require.NoError(t, err)
+	outbox.add(webhooks.EventCertRevoked)
+
+	// The backoff doubles from a minute up to three minutes, and the
+	// fourth failed attempt is the last.
+	for i, backoff := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		require.NoError(t, svc.Process(context.Background()))
+		d := outbox.delivery(1)
+		assert.Equal(t, webhooks.StatusPending, d.Status)
+		assert.Equal(t, uint64(i+1), d.Attempts)
+		assert.Equal(t, backoff, d.NextAttemptAt.Sub(d.UpdatedAt))
+		assert.NotEmpty(t, d.LastError)
+
+		// The delivery is not attempted before it is due.
+		require.NoError(t, svc.Process(context.Background()))
+		assert.Equal(t, uint64(i+1), outbox.delivery(1).Attempts)
+		outbox.due()
+	}
+	require.NoError(t, svc.Process(context.Background()))
+	d := outbox.delivery(1)
+	assert.Equal(t, webhooks.StatusDead, d.Status)
+	assert.Equal(t, uint64(4), d.Attempts)
+	assert.Equal(t, http.StatusNotFound, d.LastStatusCode)
+
+	outbox.due()
+	require.NoError(t, svc.Process(context.Background()))
+	assert.Equal(t, uint64(4), outbox.delivery(1).Attempts)
+	assert.Len(t, rc.events, 4)
+
+	d, err = svc.ReplayDelivery(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.StatusPending, d.Status)
+	assert.Zero(t, d.Attempts)
+	_, err = svc.ReplayDelivery(context.Background(), 1)
+	assert.True(t, errors.Contains(err, webhooks.ErrNotReplayable))
+
+	require.NoError(t, svc.Process(context.Background()))
+	d = outbox.delivery(1)
+	assert.Equal(t, webhooks.StatusDelivered, d.Status)
+	assert.Equal(t, uint64(1), d.Attempts)
+	assert.Empty(t, d.LastError)
+	assert.Len(t, rc.events, 5)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"type":"cert.revoked"}`)
+	header := webhooks.Sign("secret", now, body)
+
+	testCases := []struct {
+		desc   string
+		secret string
+		header string
+		body   []byte
+		err    error
+	}{
+		{
+			desc:   "verify a signature",
+			secret: "secret",
+			header: header,
+			body:   body,
+		},
+		{
+			desc:   "verify one of several signatures",
+			secret: "secret",
+			header: header + ",v1=" + strings.Repeat("00", 32),
+			body:   body,
+		},
+		{
+			desc:   "verify with another secret",
+			secret: "other",
+			header: header,
+			body:   body,
+			err:    webhooks.ErrInvalidSignature,
+		},
+		{
+			desc:   "verify a changed body",
+			secret: "secret",
+			header: header,
+			body:   []byte(`{"type":"cert.issued"}`),
+			err:    webhooks.ErrInvalidSignature,
+		},
+		{
+			desc:   "verify an old signature",
+			secret: "secret",
+			header: webhooks.Sign("secret", now.Add(-time.Hour), body),
+			body:   body,
+			err:    webhooks.ErrInvalidSignature,
+		},
+		{
+			desc:   "verify a malformed header",
+			secret: "secret",
+			header: "v1",
+			body:   body,
+			err:    webhooks.ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := webhooks.Verify(tc.secret, tc.header, tc.body, now, 5*time.Minute)
+			if tc.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected %s, got %v", tc.err, err))
+		})
+	}
+}
+
+// roleAuthn authenticates callers with the role of the X-Role header.
+type roleAuthn struct{}
+
+func (roleAuthn) Authenticate(r *http.Request) (auth.Identity, error) {
+	return auth.Identity{Subject: "tester", Role: auth.Role(r.Header.Get("X-Role"))}, nil
+}
+
+func TestHandler(t *testing.T) {
+	outbox := &memoryOutbox{}
+	svc := webhooks.NewService(outbox.repository(), nil, config())
+	srv := httptest.NewServer(webhooksapi.MakeHandler(svc, roleAuthn{}, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer srv.Close()
+
+	do := func(method, path, role, body string) (int, []byte) {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Role", role)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, data
+	}
+
+	code, data := do(http.MethodPost, "/webhooks/subscriptions", string(auth.RoleAdmin), `{"url":"https://example.com/hook","events":["cert.revoked"]}`)
+	require.Equal(t, http.StatusCreated, code, string(data))
+	var sub webhooks.Subscription
+	require.NoError(t, json.Unmarshal(data, &sub))
+	assert.NotEmpty(t, sub.Secret)
+
+	testCases := []struct {
+		desc   string
+		method string
+		path   string
+		role   auth.Role
+		body   string
+		code   int
+	}{
+		{
+			desc:   "create a subscription as auditor",
+			method: http.MethodPost,
+			path:   "/webhooks/subscriptions",
+			role:   auth.RoleAuditor,
+			body:   `{"url":"https://example.com/hook"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			desc:   "create a subscription with an invalid URL",
+			method: http.MethodPost,
+			path:   "/webhooks/subscriptions",
+			role:   auth.RoleAdmin,
+			body:   `{"url":"example.com"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			desc:   "list subscriptions",
+			method: http.MethodGet,
+			path:   "/webhooks/subscriptions?limit=5",
+			role:   auth.RoleAdmin,
+			code:   http.StatusOK,
+		},
+		{
+			desc:   "list subscriptions with an invalid limit",
+			method: http.MethodGet,
+			path:   "/webhooks/subscriptions?limit=1000",
+			role:   auth.RoleAdmin,
+			code:   http.StatusBadRequest,
+		},
+		{
+			desc:   "view a subscription",
+			method: http.MethodGet,
+			path:   "/webhooks/subscriptions/" + sub.ID,
+			role:   auth.RoleAdmin,
+			code:   http.StatusOK,
+		},
+		{
+			desc:   "view an unknown subscription",
+			method: http.MethodGet,
+			path:   "/webhooks/subscriptions/unknown",
+			role:   auth.RoleAdmin,
+			code:   http.StatusNotFound,
+		},
+		{
+			desc:   "list deliveries",
+			method: http.MethodGet,
+			path:   "/webhooks/deliveries?status=dead",
+			role:   auth.RoleAdmin,
+			code:   http.StatusOK,
+		},
+		{
+			desc:   "replay an unknown delivery",
+			method: http.MethodPost,
+			path:   "/webhooks/deliveries/1/replay",
+			role:   auth.RoleAdmin,
+			code:   http.StatusNotFound,
+		},
+		{
+			desc:   "replay a delivery with an invalid ID",
+			method: http.MethodPost,
+			path:   "/webhooks/deliveries/first/replay",
+			role:   auth.RoleAdmin,
+			code:   http.StatusBadRequest,
+		},
+		{
+			desc:   "remove a subscription",
+			method: http.MethodDelete,
+			path:   "/webhooks/subscriptions/" + sub.ID,
+			role:   auth.RoleAdmin,
+			code:   http.StatusNoContent,
+		},
+		{
+			desc:   "remove a removed subscription",
+			method: http.MethodDelete,
+			path:   "/webhooks/subscriptions/" + sub.ID,
+			role:   auth.RoleAdmin,
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			code, data := do(tc.method, tc.path, string(tc.role), tc.body)
+			assert.Equal(t, tc.code, code, string(data))
+			if tc.code == http.StatusOK && tc.method == http.MethodGet && strings.HasPrefix(tc.path, "/webhooks/subscriptions") {
+				assert.NotContains(t, string(data), sub.Secret)
+			}
+		})
+	}
+}